@@ -0,0 +1,116 @@
+package apiv1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/archives"
+	"github.com/dabbertorres/notes/internal/blobs"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/jobs"
+	jobsapiv1 "github.com/dabbertorres/notes/internal/jobs/apiv1"
+)
+
+type Service interface {
+	CreateExport(ctx context.Context) (*jobs.Job, error)
+	GetExport(ctx context.Context, exportID uuid.UUID) (*jobs.Job, error)
+	OpenExport(ctx context.Context, exportID uuid.UUID) (*jobs.Job, blobs.Blob, error)
+	Import(ctx context.Context, r io.ReaderAt, size int64) (*archives.ImportResult, error)
+}
+
+// PostExport starts exporting every note the user can see as a zip of markdown documents, in the background.
+// The export's status is polled with [GetExport], and its archive downloaded with [GetExportArchive] once it succeeds.
+func PostExport(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, err := svc.CreateExport(r.Context())
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := jobsapiv1.JobFromDomain(job)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
+// GetExport reports the status and progress of an export, so that clients can poll it.
+func GetExport(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		exportID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid export id"))
+			return
+		}
+
+		job, err := svc.GetExport(r.Context(), exportID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := jobsapiv1.JobFromDomain(job)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
+// GetExportArchive downloads the archive that a succeeded export produced.
+func GetExportArchive(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		exportID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid export id"))
+			return
+		}
+
+		job, blob, err := svc.OpenExport(r.Context(), exportID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+		defer blob.Close()
+
+		filename := "notes-" + job.CreatedAt.UTC().Format(time.DateOnly) + ".zip"
+
+		header := w.Header()
+		header.Set("Content-Type", "application/zip")
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+
+		http.ServeContent(w, r, "", blob.ModTime(), blob)
+	}
+}
+
+// PostImport imports the notes in a zip of markdown documents, sent as the request body.
+func PostImport(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, archives.MaxImportSize)
+
+		// a zip archive is read from its end, so it has to be read completely first
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusRequestEntityTooLarge, "archive is too large"))
+				return
+			}
+
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "could not read archive"))
+			return
+		}
+
+		result, err := svc.Import(r.Context(), bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := ImportResultFromDomain(result)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
@@ -0,0 +1,32 @@
+package apiv1
+
+import (
+	"github.com/dabbertorres/notes/internal/archives"
+)
+
+type ImportResult struct {
+	Created  int             `json:"created"`
+	Updated  int             `json:"updated"`
+	Failures []ImportFailure `json:"failures"`
+}
+
+func ImportResultFromDomain(domain *archives.ImportResult) (r ImportResult) {
+	r.Created = domain.Created
+	r.Updated = domain.Updated
+	r.Failures = make([]ImportFailure, len(domain.Failures))
+	for i, failure := range domain.Failures {
+		r.Failures[i] = ImportFailureFromDomain(failure)
+	}
+	return r
+}
+
+type ImportFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+func ImportFailureFromDomain(domain archives.ImportFailure) (f ImportFailure) {
+	f.Path = domain.Path
+	f.Error = archives.FailureMessage(domain.Err)
+	return f
+}
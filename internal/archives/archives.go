@@ -0,0 +1,9 @@
+// Package archives exports notes as, and imports notes from, zip archives of markdown documents with YAML front matter.
+package archives
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewService),
+	do.Lazy(NewExporter),
+)
@@ -0,0 +1,239 @@
+package archives
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/tags"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// frontMatterDelimiter separates the front matter of a document from its body.
+const frontMatterDelimiter = "---"
+
+// Document is a note as a markdown file, with its metadata as YAML front matter.
+type Document struct {
+	// Path is where the document is in an archive, using forward slashes.
+	Path        string
+	FrontMatter FrontMatter
+	Body        string
+}
+
+// FrontMatter is the metadata of a note. Every field is optional, so that notes written by other tools can be imported.
+type FrontMatter struct {
+	ID        string        `yaml:"id,omitempty"`
+	Title     string        `yaml:"title,omitempty"`
+	Tags      stringList    `yaml:"tags,omitempty"`
+	CreatedAt string        `yaml:"created_at,omitempty"`
+	UpdatedAt string        `yaml:"updated_at,omitempty"`
+	Access    []AccessEntry `yaml:"access,omitempty"`
+}
+
+type AccessEntry struct {
+	User   string `yaml:"user"`
+	Access string `yaml:"access"`
+}
+
+// stringList is a list of strings that may also be written as a single string, as other tools often do for tags.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(unmarshal func(any) error) error {
+	var one string
+	if err := unmarshal(&one); err == nil {
+		*l = stringList{one}
+		return nil
+	}
+
+	var many []string
+	if err := unmarshal(&many); err != nil {
+		return err
+	}
+
+	*l = many
+	return nil
+}
+
+// DocumentFromNote describes note as a document. Its Path is not set.
+func DocumentFromNote(note *notes.Note) *Document {
+	doc := &Document{
+		FrontMatter: FrontMatter{
+			ID:        note.ID.String(),
+			Title:     note.Title,
+			CreatedAt: note.CreatedAt.UTC().Format(time.RFC3339),
+			UpdatedAt: note.UpdatedAt.UTC().Format(time.RFC3339),
+		},
+		Body: note.Body,
+	}
+
+	for _, t := range note.Tags {
+		doc.FrontMatter.Tags = append(doc.FrontMatter.Tags, t.Name)
+	}
+
+	for _, a := range note.Access {
+		doc.FrontMatter.Access = append(doc.FrontMatter.Access, AccessEntry{
+			User:   a.User.ID.String(),
+			Access: a.Access.String(),
+		})
+	}
+
+	return doc
+}
+
+// ParseDocument parses a markdown file, with or without front matter.
+func ParseDocument(path string, data []byte) (*Document, error) {
+	doc := &Document{Path: path}
+
+	// tolerate files written on windows
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	rest, ok := strings.CutPrefix(text, frontMatterDelimiter+"\n")
+	if !ok {
+		doc.Body = text
+		return doc, nil
+	}
+
+	var frontMatter string
+	if after, ok := strings.CutPrefix(rest, frontMatterDelimiter+"\n"); ok {
+		// the front matter is empty
+		rest = after
+	} else {
+		frontMatter, rest, ok = strings.Cut(rest, "\n"+frontMatterDelimiter+"\n")
+		if !ok {
+			// the closing delimiter may be the end of the file
+			frontMatter, ok = strings.CutSuffix(rest, "\n"+frontMatterDelimiter)
+			if !ok {
+				return nil, errors.New("front matter is not terminated")
+			}
+			rest = ""
+		}
+	}
+
+	if err := yaml.Unmarshal([]byte(frontMatter), &doc.FrontMatter); err != nil {
+		return nil, fmt.Errorf("invalid front matter: %w", err)
+	}
+
+	doc.Body = rest
+	return doc, nil
+}
+
+// Marshal writes doc as a markdown file with front matter.
+func (d *Document) Marshal() ([]byte, error) {
+	frontMatter, err := yaml.Marshal(&d.FrontMatter)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(frontMatter) + len(d.Body) + 2*len(frontMatterDelimiter) + 2)
+	buf.WriteString(frontMatterDelimiter + "\n")
+	buf.Write(frontMatter)
+	buf.WriteString(frontMatterDelimiter + "\n")
+	buf.WriteString(d.Body)
+	return buf.Bytes(), nil
+}
+
+// documentNamespace is the namespace of the IDs given to documents that don't have one.
+var documentNamespace = uuid.MustParse("5b0f3a8e-6c1e-4f8e-9d59-3c3a0c6f2e41")
+
+// ToNote creates the note that d describes, for userID to import.
+// The note's tags only have their names set, since names are all a document has of them.
+//
+// A document without an ID is given one derived from userID and its path, so that importing it again finds the
+// same note.
+func (d *Document) ToNote(userID uuid.UUID) (*notes.Note, error) {
+	var errs []error
+
+	note := &notes.Note{
+		Title: d.FrontMatter.Title,
+		Body:  d.Body,
+	}
+
+	if d.FrontMatter.ID != "" {
+		id, err := uuid.Parse(d.FrontMatter.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid id: %w", err))
+		}
+		note.ID = id
+	} else {
+		note.ID = uuid.NewSHA1(documentNamespace, []byte(userID.String()+"/"+d.Path))
+	}
+
+	if note.Title == "" {
+		note.Title = strings.TrimSuffix(path.Base(d.Path), path.Ext(d.Path))
+	}
+
+	var err error
+	if note.CreatedAt, err = parseTime(d.FrontMatter.CreatedAt); err != nil {
+		errs = append(errs, fmt.Errorf("invalid created_at: %w", err))
+	}
+
+	if note.UpdatedAt, err = parseTime(d.FrontMatter.UpdatedAt); err != nil {
+		errs = append(errs, fmt.Errorf("invalid updated_at: %w", err))
+	}
+
+	for _, name := range d.FrontMatter.Tags {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "#"))
+		if name != "" {
+			note.Tags = append(note.Tags, tags.Tag{Name: name})
+		}
+	}
+
+	for i, a := range d.FrontMatter.Access {
+		granteeID, err := uuid.Parse(a.User)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid access[%d].user: %w", i, err))
+			continue
+		}
+
+		level, err := users.ParseAccessLevel(a.Access)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid access[%d].access: %w", i, err))
+			continue
+		}
+
+		if level == users.AccessLevelNone {
+			continue
+		}
+
+		note.Access = append(note.Access, users.Access{
+			User:   users.User{ID: granteeID},
+			Access: level,
+		})
+	}
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return note, nil
+}
+
+// timeLayouts are the formats accepted for times in front matter, most precise first.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
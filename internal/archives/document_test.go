@@ -0,0 +1,95 @@
+package archives
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/tags"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+func TestDocument_RoundTrip(t *testing.T) {
+	userID := uuid.New()
+	note := &notes.Note{
+		ID:        uuid.New(),
+		CreatedAt: time.Date(2024, 7, 4, 13, 37, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 7, 5, 9, 0, 0, 0, time.UTC),
+		Title:     "on-call: handoff",
+		Body:      "# Handoff\n\n---\n\n- [ ] page the next person\n",
+		Tags:      []tags.Tag{{Name: "oncall"}, {Name: "2026"}},
+		Access:    []users.Access{{User: users.User{ID: userID}, Access: users.AccessLevelOwner}},
+	}
+
+	doc := DocumentFromNote(note)
+	data, err := doc.Marshal()
+	require.NoError(t, err)
+
+	parsed, err := ParseDocument("oncall/on-call- handoff.md", data)
+	require.NoError(t, err)
+
+	out, err := parsed.ToNote(userID)
+	require.NoError(t, err)
+	assert.Equal(t, note, out)
+}
+
+func TestParseDocument_WithoutFrontMatter(t *testing.T) {
+	userID := uuid.New()
+
+	doc, err := ParseDocument("Runbooks/Restart the queue.md", []byte("Drain it first.\r\n"))
+	require.NoError(t, err)
+
+	note, err := doc.ToNote(userID)
+	require.NoError(t, err)
+	assert.Equal(t, "Restart the queue", note.Title)
+	assert.Equal(t, "Drain it first.\n", note.Body)
+
+	// importing the same file again must find the same note
+	again, err := doc.ToNote(userID)
+	require.NoError(t, err)
+	assert.Equal(t, note.ID, again.ID)
+}
+
+func TestParseDocument_ObsidianFrontMatter(t *testing.T) {
+	doc, err := ParseDocument("Incident.md", []byte("---\ntags: \"#incident\"\ncreated_at: 2024-07-04\n---\nbody"))
+	require.NoError(t, err)
+
+	note, err := doc.ToNote(uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, []tags.Tag{{Name: "incident"}}, note.Tags)
+	assert.Equal(t, time.Date(2024, 7, 4, 0, 0, 0, 0, time.UTC), note.CreatedAt)
+	assert.Equal(t, "body", note.Body)
+}
+
+func TestParseDocument_Invalid(t *testing.T) {
+	_, err := ParseDocument("a.md", []byte("---\ntitle: never closed\n"))
+	assert.Error(t, err)
+
+	doc, err := ParseDocument("a.md", []byte("---\naccess:\n  - user: "+uuid.NewString()+"\n    access: wner\n---\n"))
+	require.NoError(t, err)
+
+	_, err = doc.ToNote(uuid.New())
+	assert.Error(t, err)
+}
+
+func TestExportPath(t *testing.T) {
+	taken := make(map[string]struct{})
+	note := &notes.Note{
+		ID:    uuid.New(),
+		Title: "../etc/passwd",
+		Tags:  []tags.Tag{{Name: "Zebra"}, {Name: "apple"}},
+	}
+
+	assert.Equal(t, "apple/-etc-passwd.md", exportPath(note, taken))
+
+	other := *note
+	other.ID = uuid.New()
+	assert.Equal(t, "apple/-etc-passwd ("+other.ID.String()+").md", exportPath(&other, taken))
+
+	untitled := &notes.Note{ID: uuid.New()}
+	assert.Equal(t, untitled.ID.String()+".md", exportPath(untitled, taken))
+}
@@ -0,0 +1,133 @@
+package archives
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/blobs"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/jobs"
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+// exportRetention is how long the archive an export produces is kept for downloading.
+const exportRetention = 24 * time.Hour
+
+var errExportNotFound = apiv1.NewError(http.StatusNotFound, "export does not exist")
+
+// ExportJob is the job that exports a user's notes. An export is identified by its job's ID.
+var ExportJob = jobs.Type[ExportPayload]{
+	Kind: "archives.export",
+}
+
+// ExportPayload is empty, as an export is of everything the job's user can see.
+type ExportPayload struct{}
+
+// expireExportJob is the job that deletes the archive an export produced, once it has been kept long enough.
+var expireExportJob = jobs.Type[expireExportPayload]{
+	Kind: "archives.expire_export",
+}
+
+type expireExportPayload struct {
+	ExportID uuid.UUID `json:"export_id"`
+}
+
+// exportBlobKey is where the archive produced by the export with exportID is stored.
+func exportBlobKey(exportID uuid.UUID) string {
+	return "exports/" + exportID.String()
+}
+
+// Exporter runs exports, as the handler of [ExportJob].
+//
+// An export that is interrupted is started over when its job is run again, replacing whatever it stored before.
+type Exporter struct {
+	archives *Service
+	jobs     JobStore
+	blobs    blobs.BlobStore
+}
+
+func NewExporter(injector do.Injector) (*Exporter, error) {
+	archives, err := do.Invoke[*Service](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	jobService, err := do.Invoke[*jobs.Service](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := do.Invoke[*blobs.Store](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Exporter{
+		archives: archives,
+		jobs:     jobService,
+		blobs:    store,
+	}, nil
+}
+
+// Handler runs [ExportJob]s, for registering with a [jobs.Worker].
+func (e *Exporter) Handler() jobs.Handler {
+	return ExportJob.Handler(e.RunExport)
+}
+
+// ExpireHandler deletes the archives of exports that have been kept long enough, for registering with a [jobs.Worker].
+func (e *Exporter) ExpireHandler() jobs.Handler {
+	return expireExportJob.Handler(e.expireExport)
+}
+
+// RunExport stores an archive of every note the job's user can see, to be downloaded once the job has succeeded.
+func (e *Exporter) RunExport(ctx context.Context, job *jobs.Job, _ ExportPayload, progress *jobs.Reporter) error {
+	key := exportBlobKey(job.ID)
+
+	pr, pw := io.Pipe()
+	exported := make(chan error, 1)
+	go func() {
+		err := e.archives.Export(ctx, pw, progress)
+		pw.CloseWithError(err)
+		exported <- err
+	}()
+
+	err := e.blobs.Put(ctx, key, pr, -1, "application/zip")
+	// stops the export, if storing it failed before it was done
+	pr.CloseWithError(err)
+
+	if exportErr := <-exported; exportErr != nil {
+		err = exportErr
+	}
+
+	if err != nil {
+		// the store may have kept part of the archive
+		if deleteErr := e.blobs.Delete(context.WithoutCancel(ctx), key); deleteErr != nil {
+			log.Error(ctx, "error deleting export blob", zap.String("blob_key", key), zap.Error(deleteErr))
+		}
+
+		return err
+	}
+
+	expire, err := expireExportJob.New(uuid.Nil, expireExportPayload{ExportID: job.ID})
+	if err != nil {
+		return err
+	}
+
+	expire.RunAt = expire.CreatedAt.Add(exportRetention)
+	return e.jobs.CreateJob(ctx, expire)
+}
+
+func (e *Exporter) expireExport(ctx context.Context, _ *jobs.Job, payload expireExportPayload, _ *jobs.Reporter) error {
+	if err := e.blobs.Delete(ctx, exportBlobKey(payload.ExportID)); err != nil && !errors.Is(err, blobs.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package archives
+
+import (
+	"archive/zip"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dabbertorres/notes/internal/blobs"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/jobs"
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/scope"
+	"github.com/dabbertorres/notes/internal/tags"
+)
+
+type memoryNoteStore struct {
+	notes []notes.Note
+}
+
+func (s *memoryNoteStore) SearchNotes(_ context.Context, params notes.NoteSearchParams, pageSize int) ([]notes.NoteSearchResult, *notes.NoteSearchParams, error) {
+	var results []notes.NoteSearchResult
+	for _, note := range s.notes {
+		if params.LastNoteID.Valid && note.ID.String() <= params.LastNoteID.UUID.String() {
+			continue
+		}
+
+		if len(results) == pageSize {
+			last := results[len(results)-1].ID
+			return results, &notes.NoteSearchParams{LastNoteID: uuid.NullUUID{UUID: last, Valid: true}}, nil
+		}
+
+		results = append(results, notes.NoteSearchResult{ID: note.ID, Title: note.Title})
+	}
+
+	return results, nil, nil
+}
+
+func (s *memoryNoteStore) GetNote(_ context.Context, noteID uuid.UUID) (*notes.Note, error) {
+	for i := range s.notes {
+		if s.notes[i].ID == noteID {
+			return &s.notes[i], nil
+		}
+	}
+
+	return nil, apiv1.NewError(http.StatusNotFound, "note does not exist")
+}
+
+func (s *memoryNoteStore) ImportNote(context.Context, *notes.Note) (bool, error) { return false, nil }
+
+type memoryJobStore struct {
+	jobs []*jobs.Job
+}
+
+func (s *memoryJobStore) CreateJob(_ context.Context, job *jobs.Job) error {
+	s.jobs = append(s.jobs, job)
+	return nil
+}
+
+func (s *memoryJobStore) GetJob(_ context.Context, jobID uuid.UUID) (*jobs.Job, error) {
+	for _, job := range s.jobs {
+		if job.ID == jobID {
+			return job, nil
+		}
+	}
+
+	return nil, apiv1.NewError(http.StatusNotFound, "job does not exist")
+}
+
+func TestExporter(t *testing.T) {
+	userID := uuid.New()
+	ctx := scope.WithUserID(context.Background(), userID)
+
+	store, err := blobs.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	noteStore := &memoryNoteStore{}
+	for _, title := range []string{"first", "second", "third"} {
+		noteStore.notes = append(noteStore.notes, notes.Note{
+			ID:        uuid.Must(uuid.NewV7()),
+			Title:     title,
+			UpdatedAt: time.Date(2024, 7, 5, 9, 0, 0, 0, time.UTC),
+		})
+	}
+	noteStore.notes[2].Tags = []tags.Tag{{Name: "work"}}
+
+	jobStore := &memoryJobStore{}
+	svc := &Service{notes: noteStore, jobs: jobStore, blobs: store}
+	exporter := &Exporter{archives: svc, jobs: jobStore, blobs: store}
+
+	job, err := svc.CreateExport(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ExportJob.Kind, job.Kind)
+
+	_, _, err = svc.OpenExport(ctx, job.ID)
+	assertStatus(t, http.StatusConflict, err)
+
+	var progress jobs.Reporter
+	require.NoError(t, exporter.RunExport(ctx, job, ExportPayload{}, &progress))
+	assert.Equal(t, 3, progress.Progress().Done)
+
+	job.Status = jobs.StatusSucceeded
+
+	_, blob, err := svc.OpenExport(ctx, job.ID)
+	require.NoError(t, err)
+	defer blob.Close()
+
+	zr, err := zip.NewReader(blob, blob.Size())
+	require.NoError(t, err)
+
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	assert.ElementsMatch(t, []string{"first.md", "second.md", "work/third.md"}, names)
+
+	require.Len(t, jobStore.jobs, 2)
+	expire := jobStore.jobs[1]
+	assert.Equal(t, expireExportJob.Kind, expire.Kind)
+	assert.False(t, expire.UserID.Valid)
+	assert.Equal(t, expire.CreatedAt.Add(exportRetention), expire.RunAt)
+
+	_, err = svc.GetExport(ctx, expire.ID)
+	assertStatus(t, http.StatusNotFound, err)
+
+	require.NoError(t, exporter.expireExport(ctx, expire, expireExportPayload{ExportID: job.ID}, &progress))
+
+	_, _, err = svc.OpenExport(ctx, job.ID)
+	assertStatus(t, http.StatusGone, err)
+}
+
+func assertStatus(t *testing.T, want int, err error) {
+	t.Helper()
+
+	var apiErr apiv1.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, want, apiErr.Status())
+}
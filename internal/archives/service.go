@@ -0,0 +1,461 @@
+package archives
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/blobs"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/jobs"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/scope"
+	"github.com/dabbertorres/notes/internal/tags"
+)
+
+const (
+	// MaxImportSize is the largest archive that can be imported.
+	MaxImportSize = 64 << 20
+	// maxDocumentSize is the largest that a document in an archive may be, once decompressed.
+	maxDocumentSize = 4 << 20
+	// maxNameLength is the most characters of a title or tag name used to name files and directories.
+	maxNameLength = 100
+)
+
+type NoteStore interface {
+	SearchNotes(ctx context.Context, params notes.NoteSearchParams, pageSize int) ([]notes.NoteSearchResult, *notes.NoteSearchParams, error)
+	GetNote(ctx context.Context, noteID uuid.UUID) (*notes.Note, error)
+	ImportNote(ctx context.Context, note *notes.Note) (created bool, err error)
+}
+
+type TagStore interface {
+	ListTags(ctx context.Context, params tags.TagSearchParams, pageSize int) ([]tags.Tag, *tags.TagSearchParams, error)
+	CreateTag(ctx context.Context, tag *tags.Tag) (*tags.Tag, error)
+}
+
+// JobStore queues the jobs that run exports, and reports on them. Jobs of other users don't exist, as far as it is
+// concerned.
+type JobStore interface {
+	CreateJob(ctx context.Context, job *jobs.Job) error
+	GetJob(ctx context.Context, jobID uuid.UUID) (*jobs.Job, error)
+}
+
+type Service struct {
+	notes NoteStore
+	tags  TagStore
+	jobs  JobStore
+	blobs blobs.BlobStore
+}
+
+func NewService(injector do.Injector) (*Service, error) {
+	noteStore, err := do.InvokeAs[NoteStore](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	tagStore, err := do.InvokeAs[TagStore](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	// the repository is a JobStore too, but it doesn't hide other users' jobs
+	jobService, err := do.Invoke[*jobs.Service](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := do.Invoke[*blobs.Store](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		notes: noteStore,
+		tags:  tagStore,
+		jobs:  jobService,
+		blobs: store,
+	}, nil
+}
+
+// CreateExport starts exporting every note the user in ctx can see, in the background by an [ExportJob].
+// The job is the export: its progress is the export's, and its result is downloaded with [Service.OpenExport].
+func (s *Service) CreateExport(ctx context.Context) (*jobs.Job, error) {
+	job, err := ExportJob.New(scope.MustUserID(ctx), ExportPayload{})
+	if err != nil {
+		log.Error(ctx, "error creating export job", zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusServiceUnavailable)
+	}
+
+	if err := s.jobs.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// GetExport returns an export of the user in ctx. Other users' exports don't exist, as far as they are concerned.
+func (s *Service) GetExport(ctx context.Context, exportID uuid.UUID) (*jobs.Job, error) {
+	job, err := s.jobs.GetJob(ctx, exportID)
+	if err != nil {
+		var apiErr apiv1.Error
+		if errors.As(err, &apiErr) && apiErr.Status() == http.StatusNotFound {
+			return nil, errExportNotFound
+		}
+
+		return nil, err
+	}
+
+	if job.Kind != ExportJob.Kind {
+		return nil, errExportNotFound
+	}
+
+	return job, nil
+}
+
+// OpenExport opens the archive that an export produced, once it has succeeded.
+// Archives are only kept for [exportRetention] after they are produced.
+func (s *Service) OpenExport(ctx context.Context, exportID uuid.UUID) (*jobs.Job, blobs.Blob, error) {
+	job, err := s.GetExport(ctx, exportID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch job.Status {
+	case jobs.StatusSucceeded:
+	case jobs.StatusPending, jobs.StatusRunning:
+		return nil, nil, apiv1.NewError(http.StatusConflict, "export has not finished")
+	default:
+		return nil, nil, apiv1.NewError(http.StatusConflict, "export did not succeed")
+	}
+
+	blob, err := s.blobs.Get(ctx, exportBlobKey(job.ID))
+	if err != nil {
+		if errors.Is(err, blobs.ErrNotFound) {
+			return nil, nil, apiv1.NewError(http.StatusGone, "export has expired")
+		}
+
+		log.Error(ctx, "error opening export", zap.Stringer("export_id", job.ID), zap.Error(err))
+		return nil, nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	return job, blob, nil
+}
+
+// Export writes every note the user can see to w, as a zip of markdown documents.
+// Each note is in the directory named for its first tag, alphabetically, or at the root if it has no tags.
+func (s *Service) Export(ctx context.Context, w io.Writer, progress *jobs.Reporter) error {
+	zw := zip.NewWriter(w)
+	taken := make(map[string]struct{})
+
+	var params notes.NoteSearchParams
+	for {
+		results, next, err := s.notes.SearchNotes(ctx, params, 100)
+		if err != nil {
+			return err
+		}
+
+		for _, result := range results {
+			note, err := s.notes.GetNote(ctx, result.ID)
+			if err != nil {
+				return err
+			}
+
+			doc := DocumentFromNote(note)
+			doc.Path = exportPath(note, taken)
+
+			data, err := doc.Marshal()
+			if err != nil {
+				log.Error(ctx, "error marshaling note", zap.Stringer("note_id", note.ID), zap.Error(err))
+				return apiv1.StatusError(http.StatusInternalServerError)
+			}
+
+			fw, err := zw.CreateHeader(&zip.FileHeader{
+				Name:     doc.Path,
+				Method:   zip.Deflate,
+				Modified: note.UpdatedAt,
+			})
+			if err != nil {
+				return err
+			}
+
+			if _, err := fw.Write(data); err != nil {
+				return err
+			}
+
+			progress.Advance(1)
+		}
+
+		if next == nil {
+			break
+		}
+
+		params = *next
+	}
+
+	return zw.Close()
+}
+
+// exportPath chooses a path for note that isn't already taken, and takes it.
+func exportPath(note *notes.Note, taken map[string]struct{}) string {
+	dir := ""
+	if len(note.Tags) != 0 {
+		first := slices.MinFunc(note.Tags, func(lhs, rhs tags.Tag) int {
+			return strings.Compare(strings.ToLower(lhs.Name), strings.ToLower(rhs.Name))
+		})
+		dir = sanitizeName(first.Name, first.ID.String())
+	}
+
+	name := sanitizeName(note.Title, note.ID.String())
+
+	p := path.Join(dir, name+".md")
+	if _, ok := taken[p]; ok {
+		// IDs are unique, so this can't be taken as well
+		p = path.Join(dir, name+" ("+note.ID.String()+").md")
+	}
+
+	taken[p] = struct{}{}
+	return p
+}
+
+// sanitizeName makes name safe to use as a file or directory name on any common OS, or uses fallback if nothing is left.
+func sanitizeName(name, fallback string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '-'
+		}
+
+		return r
+	}, name)
+
+	if runes := []rune(name); len(runes) > maxNameLength {
+		name = string(runes[:maxNameLength])
+	}
+
+	// leading dots hide files, and windows drops trailing dots and spaces
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		return fallback
+	}
+
+	return name
+}
+
+// ImportResult summarizes an import. A note that fails to import doesn't stop the rest from being imported.
+type ImportResult struct {
+	Created  int
+	Updated  int
+	Failures []ImportFailure
+}
+
+type ImportFailure struct {
+	// Path is where the note was in what was imported.
+	Path string
+	Err  error
+}
+
+// FailureMessage describes why a note failed to import, the same way a response would describe err.
+// Anything other than an [apiv1.Error] is a problem with what was imported, and so is described as it is.
+func FailureMessage(err error) string {
+	var (
+		apiErr         apiv1.Error
+		apiErrWithBody apiv1.ErrorWithBody
+	)
+	switch {
+	case errors.As(err, &apiErrWithBody):
+		return apiErrWithBody.Error()
+	case errors.As(err, &apiErr):
+		return http.StatusText(apiErr.Status())
+	default:
+		return err.Error()
+	}
+}
+
+// Import creates or updates a note from each markdown document in the zip archive r.
+// Hidden files and directories, such as an Obsidian vault's settings, are skipped.
+func (s *Service) Import(ctx context.Context, r io.ReaderAt, size int64) (*ImportResult, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, apiv1.NewError(http.StatusBadRequest, "invalid zip archive")
+	}
+
+	result := &ImportResult{}
+	importer := s.NewImporter()
+
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if f.FileInfo().IsDir() || !isDocument(f.Name) {
+			continue
+		}
+
+		data, err := readDocument(f)
+		if err != nil {
+			result.Failures = append(result.Failures, ImportFailure{Path: f.Name, Err: err})
+			continue
+		}
+
+		doc, err := ParseDocument(f.Name, data)
+		if err != nil {
+			result.Failures = append(result.Failures, ImportFailure{Path: f.Name, Err: err})
+			continue
+		}
+
+		created, err := importer.Import(ctx, doc)
+		switch {
+		case err != nil:
+			result.Failures = append(result.Failures, ImportFailure{Path: f.Name, Err: err})
+		case created:
+			result.Created++
+		default:
+			result.Updated++
+		}
+	}
+
+	return result, nil
+}
+
+// Importer imports documents one at a time, as the user in the context it is given.
+// It remembers the user's tags between documents, so one should be used for each batch of documents.
+type Importer struct {
+	notes NoteStore
+	tags  *tagResolver
+}
+
+func (s *Service) NewImporter() *Importer {
+	return &Importer{
+		notes: s.notes,
+		tags:  newTagResolver(s.tags),
+	}
+}
+
+// Import creates or updates the note that doc describes, creating any of its tags that the user doesn't have yet.
+func (i *Importer) Import(ctx context.Context, doc *Document) (created bool, err error) {
+	note, err := doc.ToNote(scope.MustUserID(ctx))
+	if err != nil {
+		return false, err
+	}
+
+	for j := range note.Tags {
+		tag, err := i.tags.resolve(ctx, note.Tags[j].Name)
+		if err != nil {
+			return false, err
+		}
+
+		note.Tags[j] = *tag
+	}
+
+	return i.notes.ImportNote(ctx, note)
+}
+
+// isDocument reports whether name is a markdown file that isn't hidden, or in a hidden directory.
+func isDocument(name string) bool {
+	if !strings.EqualFold(path.Ext(name), ".md") {
+		return false
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func readDocument(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxDocumentSize {
+		return nil, fmt.Errorf("document is larger than %d bytes", maxDocumentSize)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	// the size in the header may be a lie
+	data, err := io.ReadAll(io.LimitReader(rc, maxDocumentSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxDocumentSize {
+		return nil, fmt.Errorf("document is larger than %d bytes", maxDocumentSize)
+	}
+
+	return data, nil
+}
+
+// tagResolver finds the tags that the user can see by name, creating any that don't exist yet.
+// Names are matched regardless of case.
+type tagResolver struct {
+	tags   TagStore
+	byName map[string]*tags.Tag
+}
+
+func newTagResolver(store TagStore) *tagResolver {
+	return &tagResolver{tags: store}
+}
+
+func (r *tagResolver) resolve(ctx context.Context, name string) (*tags.Tag, error) {
+	if r.byName == nil {
+		if err := r.load(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	key := strings.ToLower(name)
+	if tag, ok := r.byName[key]; ok {
+		return tag, nil
+	}
+
+	tag, err := r.tags.CreateTag(ctx, &tags.Tag{Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	r.byName[key] = tag
+	return tag, nil
+}
+
+func (r *tagResolver) load(ctx context.Context) error {
+	byName := make(map[string]*tags.Tag)
+
+	var params tags.TagSearchParams
+	for {
+		results, next, err := r.tags.ListTags(ctx, params, 100)
+		if err != nil {
+			return err
+		}
+
+		for i := range results {
+			key := strings.ToLower(results[i].Name)
+			if _, ok := byName[key]; !ok {
+				byName[key] = &results[i]
+			}
+		}
+
+		if next == nil {
+			break
+		}
+
+		params = *next
+	}
+
+	r.byName = byName
+	return nil
+}
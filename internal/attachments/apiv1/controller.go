@@ -0,0 +1,243 @@
+package apiv1
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/attachments"
+	"github.com/dabbertorres/notes/internal/blobs"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/jobs"
+	jobsapiv1 "github.com/dabbertorres/notes/internal/jobs/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+// multipartOverhead is allowed on top of the maximum attachment size, for the multipart framing and other form fields.
+const multipartOverhead = 64 << 10
+
+type Service interface {
+	MaxSize() int64
+	CreateAttachment(ctx context.Context, noteID uuid.UUID, name string, r io.Reader) (*attachments.Attachment, error)
+	OpenAttachment(ctx context.Context, noteID, attachmentID uuid.UUID) (*attachments.Attachment, blobs.Blob, error)
+	ListAttachments(ctx context.Context, noteID uuid.UUID, params attachments.AttachmentListParams, pageSize int) (results []attachments.Attachment, next *attachments.AttachmentListParams, err error)
+	DeleteAttachment(ctx context.Context, noteID, attachmentID uuid.UUID) error
+	Reindex(ctx context.Context) (*jobs.Job, error)
+}
+
+// PostAttachment uploads the "file" field of a multipart/form-data body as an attachment of a note.
+func PostAttachment(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, svc.MaxSize()+multipartOverhead)
+
+		mr, err := r.MultipartReader()
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "expected a multipart/form-data body"))
+			return
+		}
+
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				switch {
+				case errors.Is(err, io.EOF):
+					apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "missing file field"))
+				case errors.As(err, &maxBytesErr):
+					apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusRequestEntityTooLarge, "attachment is too large"))
+				default:
+					apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid multipart body"))
+				}
+				return
+			}
+
+			if part.FormName() != "file" {
+				continue
+			}
+
+			name := part.FileName()
+			if name == "" {
+				apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "file field is missing a filename"))
+				return
+			}
+
+			created, err := svc.CreateAttachment(r.Context(), noteID, name, part)
+			if err != nil {
+				apiv1.WriteError(r.Context(), w, err)
+				return
+			}
+
+			dto := AttachmentFromDomain(created)
+			apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+			return
+		}
+	}
+}
+
+// GetAttachment downloads the contents of an attachment, supporting Range requests.
+func GetAttachment(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		attachmentID, err := apiv1.ParsePathValue(r, "attachment_id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid attachment id"))
+			return
+		}
+
+		attachment, blob, err := svc.OpenAttachment(r.Context(), noteID, attachmentID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+		defer blob.Close()
+
+		header := w.Header()
+		header.Set("Content-Type", attachment.ContentType)
+		header.Set("Content-Disposition", contentDisposition(attachment))
+		// attachments are user content, so never let a browser treat them as anything more than what they claim
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Content-Security-Policy", "sandbox")
+		// attachments are immutable
+		header.Set("ETag", strconv.Quote(attachment.ID.String()))
+
+		http.ServeContent(w, r, "", blob.ModTime(), blob)
+	}
+}
+
+func ListAttachments(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		paging, err := parseListAttachmentsParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		params := attachments.AttachmentListParams{
+			LastAttachmentID: paging.Data.LastAttachmentID,
+		}
+		results, next, err := svc.ListAttachments(r.Context(), noteID, params, paging.PageSize)
+		if err != nil {
+			log.Error(r.Context(), "error listing attachments", zap.Stringer("note_id", noteID), zap.Error(err))
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		page := apiv1.Page[Attachment, *ListAttachmentsPageTokenData]{
+			NextPageToken: nil,
+			Items: util.MapSlice(results, func(attachment attachments.Attachment) Attachment {
+				return AttachmentFromDomain(&attachment)
+			}),
+		}
+
+		if next != nil {
+			page.NextPageToken = &apiv1.PageToken[*ListAttachmentsPageTokenData]{
+				Data: &ListAttachmentsPageTokenData{
+					LastAttachmentID: next.LastAttachmentID,
+				},
+				PageSize: paging.PageSize,
+			}
+		}
+
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, page)
+	}
+}
+
+func DeleteAttachment(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		attachmentID, err := apiv1.ParsePathValue(r, "attachment_id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid attachment id"))
+			return
+		}
+
+		if err := svc.DeleteAttachment(r.Context(), noteID, attachmentID); err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// contentDisposition displays types that browsers can show safely, and downloads everything else.
+func contentDisposition(attachment *attachments.Attachment) string {
+	disposition := "attachment"
+
+	mediaType, _, _ := mime.ParseMediaType(attachment.ContentType)
+	switch mediaType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain":
+		disposition = "inline"
+	}
+
+	return mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Name})
+}
+
+func parseListAttachmentsParams(r *http.Request) (token apiv1.PageToken[*ListAttachmentsPageTokenData], err error) {
+	rawPageToken := r.FormValue("next_page_token")
+	if rawPageToken != "" {
+		pageToken, err := apiv1.ParsePageToken[*ListAttachmentsPageTokenData](rawPageToken, 100, 100)
+		if err != nil {
+			return token, apiv1.NewValidationFailureError(err)
+		}
+
+		token = pageToken
+	} else {
+		token.Data = &ListAttachmentsPageTokenData{}
+
+		if size := r.FormValue("page_size"); size != "" {
+			pageSize, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return token, apiv1.NewValidationFailureError(err)
+			}
+
+			token.PageSize = int(pageSize)
+		}
+	}
+
+	return token, nil
+}
+
+// PostReindex starts extracting the text of the attachments of the user's notes again, in the background.
+// The returned job is polled for its progress.
+func PostReindex(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, err := svc.Reindex(r.Context())
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := jobsapiv1.JobFromDomain(job)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
@@ -0,0 +1,64 @@
+package apiv1
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/attachments"
+	notesapiv1 "github.com/dabbertorres/notes/internal/notes/apiv1"
+)
+
+type Attachment struct {
+	ID          string          `json:"id"`
+	NoteID      string          `json:"note_id"`
+	Name        string          `json:"name"`
+	ContentType string          `json:"content_type"`
+	Size        int64           `json:"size"`
+	CreatedAt   string          `json:"created_at"`
+	CreatedBy   notesapiv1.User `json:"created_by"`
+}
+
+func AttachmentFromDomain(domain *attachments.Attachment) (a Attachment) {
+	a.ID = domain.ID.String()
+	a.NoteID = domain.NoteID.String()
+	a.Name = domain.Name
+	a.ContentType = domain.ContentType
+	a.Size = domain.Size
+	a.CreatedAt = domain.CreatedAt.Format(time.RFC3339)
+	a.CreatedBy = notesapiv1.UserFromDomain(domain.CreatedBy)
+	return a
+}
+
+type ListAttachmentsPageTokenData struct {
+	LastAttachmentID uuid.NullUUID
+}
+
+func (d *ListAttachmentsPageTokenData) EncodePager() ([][]byte, error) {
+	var out [1][]byte
+
+	if d.LastAttachmentID.Valid {
+		out[0] = []byte(d.LastAttachmentID.UUID.String())
+	}
+
+	return out[:], nil
+}
+
+func (d *ListAttachmentsPageTokenData) DecodePager(data [][]byte) error {
+	if len(data) != 1 {
+		return errors.New("invalid page token format (incorrect number of parts)")
+	}
+
+	if len(data[0]) != 0 {
+		lastID, err := uuid.ParseBytes(data[0])
+		if err != nil {
+			return err
+		}
+
+		d.LastAttachmentID.UUID = lastID
+		d.LastAttachmentID.Valid = true
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+// Package attachments manages files uploaded to notes.
+package attachments
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewPGXRepository),
+	do.Lazy(NewService),
+	do.Lazy(NewSweeper),
+	do.Lazy(NewExtractor),
+)
@@ -0,0 +1,72 @@
+package attachments
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// sniffLen is the number of bytes [http.DetectContentType] considers.
+const sniffLen = 512
+
+// extensionTypes supplements [mime.TypeByExtension], which depends on the system's MIME database.
+var extensionTypes = map[string]string{
+	".md":       "text/markdown; charset=utf-8",
+	".markdown": "text/markdown; charset=utf-8",
+	".csv":      "text/csv; charset=utf-8",
+	".docx":     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx":     "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pptx":     "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".odt":      "application/vnd.oasis.opendocument.text",
+	".ods":      "application/vnd.oasis.opendocument.spreadsheet",
+	".odp":      "application/vnd.oasis.opendocument.presentation",
+}
+
+// DetectContentType determines the content type of an attachment from its first [sniffLen] bytes.
+// The name's extension is only used to refine a generic sniffed type (e.g. plain text to markdown, or zip to an office
+// document), so that a file cannot claim to be something that would be treated differently, such as HTML.
+func DetectContentType(name string, head []byte) string {
+	sniffed := http.DetectContentType(head)
+
+	ext := strings.ToLower(path.Ext(name))
+	byExt, ok := extensionTypes[ext]
+	if !ok {
+		byExt = mime.TypeByExtension(ext)
+	}
+
+	if byExt == "" {
+		return sniffed
+	}
+
+	extType := mediaType(byExt)
+
+	switch mediaType(sniffed) {
+	case "application/octet-stream":
+		if !strings.HasPrefix(extType, "text/") && extType != "application/xhtml+xml" && extType != "image/svg+xml" {
+			return byExt
+		}
+
+	case "text/plain":
+		if extType == "text/markdown" || extType == "text/csv" {
+			return byExt
+		}
+
+	case "application/zip":
+		if strings.HasPrefix(extType, "application/vnd.openxmlformats-officedocument.") ||
+			strings.HasPrefix(extType, "application/vnd.oasis.opendocument.") {
+			return byExt
+		}
+	}
+
+	return sniffed
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+
+	return mt
+}
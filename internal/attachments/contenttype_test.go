@@ -0,0 +1,32 @@
+package attachments
+
+import "testing"
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		head string
+		want string
+	}{
+		{name: "notes.txt", head: "just some text", want: "text/plain; charset=utf-8"},
+		{name: "notes.md", head: "# Heading\n\n- item", want: "text/markdown; charset=utf-8"},
+		{name: "data.csv", head: "a,b\n1,2\n", want: "text/csv; charset=utf-8"},
+		{name: "report.pdf", head: "%PDF-1.7\n", want: "application/pdf"},
+		{name: "misnamed.png", head: "%PDF-1.7\n", want: "application/pdf"},
+		{name: "page.html", head: "<!DOCTYPE html><html>", want: "text/html; charset=utf-8"},
+		{name: "sneaky.html", head: "hello", want: "text/plain; charset=utf-8"},
+		{name: "sneaky.svg", head: "hello", want: "text/plain; charset=utf-8"},
+		{name: "doc.docx", head: "PK\x03\x04", want: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{name: "archive.zip", head: "PK\x03\x04", want: "application/zip"},
+		{name: "noext", head: "\x00\x01\x02", want: "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectContentType(tt.name, []byte(tt.head))
+			if got != tt.want {
+				t.Errorf("DetectContentType(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package attachments
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	// maxExtractedText is the most text, in bytes, that is indexed from an attachment. Postgres limits a tsvector to
+	// 1MiB.
+	maxExtractedText = 512 << 10
+
+	// maxArchiveEntries is the most files that a zipped document can have for its text to be extracted.
+	maxArchiveEntries = 10_000
+
+	// maxDecompressedSize is the most, in bytes, that is decompressed from a zipped document, so that a small upload
+	// can't make the extractor decompress (or parse) an unbounded amount of data.
+	maxDecompressedSize = 64 << 20
+)
+
+var (
+	ErrUnsupportedType = errors.New("text cannot be extracted from this type of attachment")
+
+	errTooManyEntries = errors.New("archive has too many entries")
+)
+
+// ExtractText extracts the text of an attachment for indexing, truncating it to [maxExtractedText].
+// If contentType is not supported, [ErrUnsupportedType] is returned.
+func ExtractText(r io.ReaderAt, size int64, contentType string) (string, error) {
+	var b textBuilder
+
+	var err error
+	switch mt := mediaType(contentType); mt {
+	case "text/plain", "text/markdown", "text/csv":
+		err = extractPlainText(io.NewSectionReader(r, 0, size), &b)
+
+	case "application/pdf":
+		err = extractPDF(r, size, &b)
+
+	case "application/vnd.openxmlformats-officedocument.wordprocessingml.document":
+		err = extractZippedXML(r, size, &b, []string{"word/document.xml"}, "t", "p")
+
+	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+		err = extractZippedXML(r, size, &b, []string{"xl/sharedStrings.xml"}, "t", "si")
+
+	case "application/vnd.openxmlformats-officedocument.presentationml.presentation":
+		err = extractZippedXML(r, size, &b, []string{"ppt/slides/slide*.xml"}, "t", "p")
+
+	case "application/vnd.oasis.opendocument.text",
+		"application/vnd.oasis.opendocument.spreadsheet",
+		"application/vnd.oasis.opendocument.presentation":
+		err = extractZippedXML(r, size, &b, []string{"content.xml"}, "", "p", "h")
+
+	default:
+		return "", ErrUnsupportedType
+	}
+
+	if err != nil && !errors.Is(err, errTextFull) && !errors.Is(err, errDecompressedFull) {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+var (
+	errTextFull         = errors.New("extracted text limit reached")
+	errDecompressedFull = errors.New("decompressed size limit reached")
+)
+
+// textBuilder accumulates valid UTF-8 text, up to [maxExtractedText] bytes.
+type textBuilder struct {
+	strings.Builder
+}
+
+func (b *textBuilder) WriteText(s string) error {
+	// Postgres' text type cannot hold NULs
+	s = strings.ReplaceAll(strings.ToValidUTF8(s, ""), "\x00", "")
+
+	if remaining := maxExtractedText - b.Len(); len(s) > remaining {
+		// don't split a character
+		for remaining > 0 && !utf8.RuneStart(s[remaining]) {
+			remaining--
+		}
+
+		b.WriteString(s[:remaining])
+		return errTextFull
+	}
+
+	b.WriteString(s)
+	return nil
+}
+
+func extractPlainText(r io.Reader, b *textBuilder) error {
+	data, err := io.ReadAll(io.LimitReader(r, maxExtractedText))
+	if err != nil {
+		return err
+	}
+
+	return b.WriteText(string(data))
+}
+
+func extractPDF(r io.ReaderAt, size int64, b *textBuilder) (err error) {
+	// the PDF reader panics on some malformed documents
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("malformed pdf: %v", v)
+		}
+	}()
+
+	doc, err := pdf.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	text, err := doc.GetPlainText()
+	if err != nil {
+		return err
+	}
+
+	return extractPlainText(text, b)
+}
+
+// extractZippedXML extracts text from the XML files in a zip archive matching patterns, in the order of patterns.
+// Only the character data within elements named textElement is extracted, or all of it if textElement is empty.
+// A line break is written after each element named in breakElements.
+// At most [maxDecompressedSize] bytes are read from the files, after which the text extracted so far is kept.
+func extractZippedXML(r io.ReaderAt, size int64, b *textBuilder, patterns []string, textElement string, breakElements ...string) error {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	if len(archive.File) > maxArchiveEntries {
+		return errTooManyEntries
+	}
+
+	remaining := int64(maxDecompressedSize)
+
+	for _, pattern := range patterns {
+		var files []*zip.File
+		for _, f := range archive.File {
+			if ok, _ := path.Match(pattern, f.Name); ok {
+				files = append(files, f)
+			}
+		}
+
+		// e.g. slide2.xml comes before slide10.xml
+		slices.SortFunc(files, func(lhs, rhs *zip.File) int {
+			return compareNumbered(lhs.Name, rhs.Name)
+		})
+
+		for _, f := range files {
+			if err := extractXMLFile(f, &remaining, b, textElement, breakElements); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// extractXMLFile extracts text from f, reading at most remaining bytes of it, and subtracting what it read.
+func extractXMLFile(f *zip.File, remaining *int64, b *textBuilder, textElement string, breakElements []string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	limited := &io.LimitedReader{R: rc, N: *remaining}
+	defer func() { *remaining = limited.N }()
+
+	dec := xml.NewDecoder(limited)
+	inText := 0
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			// the limit may cut the file off anywhere, including within a token
+			if limited.N == 0 {
+				return errDecompressedFull
+			}
+
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if tok.Name.Local == textElement {
+				inText++
+			}
+
+		case xml.EndElement:
+			if tok.Name.Local == textElement {
+				inText--
+			}
+
+			if slices.Contains(breakElements, tok.Name.Local) {
+				if err := b.WriteText("\n"); err != nil {
+					return err
+				}
+			}
+
+		case xml.CharData:
+			if textElement == "" || inText > 0 {
+				if err := b.WriteText(string(tok)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// compareNumbered compares names by the number before their extension, if they have one, and then lexically.
+func compareNumbered(lhs, rhs string) int {
+	if ln, rn := trailingNumber(lhs), trailingNumber(rhs); ln != rn {
+		return ln - rn
+	}
+
+	return strings.Compare(lhs, rhs)
+}
+
+func trailingNumber(name string) int {
+	name = strings.TrimSuffix(name, path.Ext(name))
+
+	i := len(name)
+	for i > 0 && name[i-1] >= '0' && name[i-1] <= '9' {
+		i--
+	}
+
+	n, _ := strconv.Atoi(name[i:])
+	return n
+}
@@ -0,0 +1,154 @@
+package attachments
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractText(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		want        string
+		wantErr     error
+	}{
+		{
+			name:        "plain text",
+			contentType: "text/plain; charset=utf-8",
+			data:        []byte("hello\x00 world\xff"),
+			want:        "hello world",
+		},
+		{
+			name:        "markdown",
+			contentType: "text/markdown; charset=utf-8",
+			data:        []byte("# Title\n\nbody"),
+			want:        "# Title\n\nbody",
+		},
+		{
+			name:        "docx",
+			contentType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			data: zipFiles(t, map[string]string{
+				"word/document.xml": `<w:document xmlns:w="w"><w:body>` +
+					`<w:p><w:r><w:t>First</w:t></w:r><w:r><w:t xml:space="preserve"> paragraph</w:t></w:r></w:p>` +
+					`<w:p><w:r><w:instrText>ignored</w:instrText><w:t>Second</w:t></w:r></w:p>` +
+					`</w:body></w:document>`,
+			}),
+			want: "First paragraph\nSecond\n",
+		},
+		{
+			name:        "pptx slides in order",
+			contentType: "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+			data: zipFiles(t, map[string]string{
+				"ppt/slides/slide10.xml": `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>ten</a:t></a:r></a:p></p:sld>`,
+				"ppt/slides/slide2.xml":  `<p:sld xmlns:p="p" xmlns:a="a"><a:p><a:r><a:t>two</a:t></a:r></a:p></p:sld>`,
+			}),
+			want: "two\nten\n",
+		},
+		{
+			name:        "odt",
+			contentType: "application/vnd.oasis.opendocument.text",
+			data: zipFiles(t, map[string]string{
+				"content.xml": `<office:document-content xmlns:office="o" xmlns:text="t">` +
+					`<text:h>Heading</text:h><text:p>Some <text:span>styled</text:span> text</text:p>` +
+					`</office:document-content>`,
+			}),
+			want: "Heading\nSome styled text\n",
+		},
+		{
+			name:        "unsupported",
+			contentType: "image/png",
+			data:        []byte("\x89PNG"),
+			wantErr:     ErrUnsupportedType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractText(bytes.NewReader(tt.data), int64(len(tt.data)), tt.contentType)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExtractTextTruncates(t *testing.T) {
+	data := strings.Repeat("é", maxExtractedText)
+
+	got, err := ExtractText(strings.NewReader(data), int64(len(data)), "text/plain")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(got), maxExtractedText)
+	assert.Equal(t, strings.Repeat("é", maxExtractedText/2), got)
+}
+
+func TestExtractTextLimitsArchives(t *testing.T) {
+	const odt = "application/vnd.oasis.opendocument.text"
+
+	t.Run("too many entries", func(t *testing.T) {
+		files := make(map[string]string, maxArchiveEntries+1)
+		for i := range maxArchiveEntries + 1 {
+			files[fmt.Sprintf("file%d.xml", i)] = ""
+		}
+		files["content.xml"] = `<office:document-content xmlns:office="o" xmlns:text="t"><text:p>text</text:p></office:document-content>`
+		data := zipFiles(t, files)
+
+		_, err := ExtractText(bytes.NewReader(data), int64(len(data)), odt)
+		assert.ErrorIs(t, err, errTooManyEntries)
+	})
+
+	t.Run("decompressed size", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+
+		f, err := w.Create("content.xml")
+		require.NoError(t, err)
+
+		// a comment compresses to almost nothing, and has no text to fill the extracted text's limit with
+		_, err = io.WriteString(f, `<office:document-content xmlns:office="o" xmlns:text="t"><text:p>before</text:p><!--`)
+		require.NoError(t, err)
+		padding := bytes.Repeat([]byte(" "), 1<<20)
+		for range maxDecompressedSize>>20 + 1 {
+			_, err = f.Write(padding)
+			require.NoError(t, err)
+		}
+		_, err = io.WriteString(f, `--><text:p>after</text:p></office:document-content>`)
+		require.NoError(t, err)
+
+		require.NoError(t, w.Close())
+		data := buf.Bytes()
+
+		got, err := ExtractText(bytes.NewReader(data), int64(len(data)), odt)
+		require.NoError(t, err)
+		assert.Equal(t, "before\n", got)
+	})
+}
+
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	for name, contents := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+
+		_, err = f.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
@@ -0,0 +1,164 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/blobs"
+	"github.com/dabbertorres/notes/internal/jobs"
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+const (
+	extractInterval  = 15 * time.Second
+	extractBatchSize = 20
+)
+
+// ReindexJob is the job that extracts the text of a user's attachments again, such as once extraction has improved.
+var ReindexJob = jobs.Type[ReindexPayload]{
+	Kind: "attachments.reindex",
+}
+
+type ReindexPayload struct {
+	// ExtractedBefore is when reindexing was requested. Text extracted since then is already up to date, so a job that
+	// is run again doesn't redo what it already did.
+	ExtractedBefore time.Time `json:"extracted_before"`
+}
+
+// Extractor extracts the text of new attachments, so that notes can be searched by the contents of their attachments.
+type Extractor struct {
+	repo  Repository
+	blobs blobs.BlobStore
+}
+
+func NewExtractor(injector do.Injector) (*Extractor, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := do.Invoke[*blobs.Store](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Extractor{
+		repo:  repo,
+		blobs: store,
+	}, nil
+}
+
+// Run extracts text periodically until ctx is cancelled.
+func (e *Extractor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(extractInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.ExtractPending(ctx); err != nil && ctx.Err() == nil {
+			log.Error(ctx, "error extracting attachment text", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExtractPending extracts the text of attachments until there are none left that have not been processed.
+func (e *Extractor) ExtractPending(ctx context.Context) error {
+	for {
+		pending, err := e.repo.ListPendingExtractions(ctx, extractBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range pending {
+			if err := e.extract(ctx, &pending[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(pending) < extractBatchSize {
+			return nil
+		}
+	}
+}
+
+// ReindexHandler runs [ReindexJob]s, for registering with a [jobs.Worker].
+func (e *Extractor) ReindexHandler() jobs.Handler {
+	return ReindexJob.Handler(e.Reindex)
+}
+
+// Reindex extracts the text of the attachments of the notes that the job's user owns again.
+func (e *Extractor) Reindex(ctx context.Context, job *jobs.Job, payload ReindexPayload, progress *jobs.Reporter) error {
+	userID := job.UserID.UUID
+
+	total, err := e.repo.CountStaleExtractions(ctx, userID, payload.ExtractedBefore)
+	if err != nil {
+		return err
+	}
+
+	progress.SetTotal(total)
+
+	var params AttachmentListParams
+	for {
+		stale, err := e.repo.ListStaleExtractions(ctx, userID, payload.ExtractedBefore, params, extractBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range stale {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if err := e.extract(ctx, &stale[i]); err != nil {
+				return err
+			}
+
+			progress.Advance(1)
+		}
+
+		if len(stale) < extractBatchSize {
+			return nil
+		}
+
+		params.LastAttachmentID = uuid.NullUUID{UUID: stale[len(stale)-1].ID, Valid: true}
+	}
+}
+
+// extract records the text of attachment. Problems with the attachment itself are recorded rather than returned,
+// so that it is not retried forever.
+func (e *Extractor) extract(ctx context.Context, attachment *Attachment) error {
+	blob, err := e.blobs.Get(ctx, attachment.BlobKey)
+	if err != nil && !errors.Is(err, blobs.ErrNotFound) {
+		return err
+	}
+
+	var text string
+	if err == nil {
+		text, err = ExtractText(blob, blob.Size(), attachment.ContentType)
+		blob.Close()
+	}
+
+	switch {
+	case errors.Is(err, ErrUnsupportedType):
+		err = nil
+
+	case err != nil:
+		log.Warn(ctx, "unable to extract attachment text",
+			zap.Stringer("attachment_id", attachment.ID),
+			zap.String("content_type", attachment.ContentType),
+			zap.Error(err),
+		)
+	}
+
+	return e.repo.SaveAttachmentText(ctx, attachment.ID, text, time.Now(), err)
+}
@@ -0,0 +1,25 @@
+package attachments
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// Attachment is a file uploaded to a note. Its contents are stored in a [blobs.BlobStore] as BlobKey.
+type Attachment struct {
+	ID          uuid.UUID
+	NoteID      uuid.UUID
+	Name        string
+	ContentType string
+	Size        int64
+	BlobKey     string
+	CreatedAt   time.Time
+	CreatedBy   users.User
+}
+
+type AttachmentListParams struct {
+	LastAttachmentID uuid.NullUUID
+}
@@ -0,0 +1,248 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+type PGXRepository struct {
+	db      database.Database
+	queries *database.Queries
+}
+
+func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGXRepository{
+		db:      db,
+		queries: database.New(),
+	}, nil
+}
+
+func (r *PGXRepository) CreateAttachment(ctx context.Context, attachment *Attachment) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return r.queries.CreateAttachment(ctx, tx, database.CreateAttachmentParams{
+			AttachmentID: attachment.ID,
+			NoteID:       uuid.NullUUID{UUID: attachment.NoteID, Valid: true},
+			Name:         attachment.Name,
+			ContentType:  attachment.ContentType,
+			Size:         attachment.Size,
+			BlobKey:      attachment.BlobKey,
+			CreatedAt:    pgtype.Timestamptz{Time: attachment.CreatedAt, Valid: true},
+			CreatedBy:    uuid.NullUUID{UUID: attachment.CreatedBy.ID, Valid: attachment.CreatedBy.ID != uuid.Nil},
+		})
+	})
+	if err != nil {
+		log.Error(ctx, "error creating attachment", zap.Stringer("attachment_id", attachment.ID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) GetAttachment(ctx context.Context, noteID, id uuid.UUID) (attachment *Attachment, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.GetAttachment(ctx, tx, database.GetAttachmentParams{
+			AttachmentID: id,
+			NoteID:       uuid.NullUUID{UUID: noteID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		attachment = attachmentFromRow(&row)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apiv1.NewError(http.StatusNotFound, "attachment does not exist")
+		}
+
+		log.Error(ctx, "error fetching attachment", zap.Stringer("attachment_id", id), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return attachment, nil
+}
+
+func (r *PGXRepository) ListAttachments(ctx context.Context, noteID uuid.UUID, params AttachmentListParams, pageSize int) (attachments []Attachment, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ListAttachments(ctx, tx, database.ListAttachmentsParams{
+			NoteID:           uuid.NullUUID{UUID: noteID, Valid: true},
+			LastAttachmentID: params.LastAttachmentID,
+			PageSize:         int64(pageSize),
+		})
+		if err != nil {
+			return err
+		}
+
+		attachments = make([]Attachment, 0, len(rows))
+		for i := range rows {
+			attachments = append(attachments, *attachmentFromRow(&rows[i]))
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error listing attachments", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return attachments, nil
+}
+
+func (r *PGXRepository) DetachAttachment(ctx context.Context, noteID, id uuid.UUID) error {
+	var numDetached int64
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		numDetached, err = r.queries.DetachAttachment(ctx, tx, database.DetachAttachmentParams{
+			AttachmentID: id,
+			NoteID:       uuid.NullUUID{UUID: noteID, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error detaching attachment", zap.Stringer("attachment_id", id), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	if numDetached != 1 {
+		return apiv1.NewError(http.StatusNotFound, "attachment does not exist")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) ListOrphanedAttachments(ctx context.Context, limit int) (attachments []Attachment, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ListOrphanedAttachments(ctx, tx, int64(limit))
+		if err != nil {
+			return err
+		}
+
+		attachments = make([]Attachment, 0, len(rows))
+		for i := range rows {
+			attachments = append(attachments, *attachmentFromRow(&rows[i]))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (r *PGXRepository) DeleteOrphanedAttachment(ctx context.Context, id uuid.UUID) error {
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return r.queries.DeleteOrphanedAttachment(ctx, tx, id)
+	})
+}
+
+func (r *PGXRepository) ListPendingExtractions(ctx context.Context, limit int) (attachments []Attachment, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ListPendingExtractions(ctx, tx, int64(limit))
+		if err != nil {
+			return err
+		}
+
+		attachments = make([]Attachment, 0, len(rows))
+		for i := range rows {
+			attachments = append(attachments, *attachmentFromRow(&rows[i]))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (r *PGXRepository) ListStaleExtractions(ctx context.Context, userID uuid.UUID, extractedBefore time.Time, params AttachmentListParams, limit int) (attachments []Attachment, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ListStaleExtractions(ctx, tx, database.ListStaleExtractionsParams{
+			UserID:           userID,
+			ExtractedBefore:  pgtype.Timestamptz{Time: extractedBefore, Valid: true},
+			LastAttachmentID: params.LastAttachmentID,
+			PageSize:         int64(limit),
+		})
+		if err != nil {
+			return err
+		}
+
+		attachments = make([]Attachment, 0, len(rows))
+		for i := range rows {
+			attachments = append(attachments, *attachmentFromRow(&rows[i]))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (r *PGXRepository) CountStaleExtractions(ctx context.Context, userID uuid.UUID, extractedBefore time.Time) (count int, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		n, err := r.queries.CountStaleExtractions(ctx, tx, database.CountStaleExtractionsParams{
+			UserID:          userID,
+			ExtractedBefore: pgtype.Timestamptz{Time: extractedBefore, Valid: true},
+		})
+		count = int(n)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *PGXRepository) SaveAttachmentText(ctx context.Context, id uuid.UUID, text string, extractedAt time.Time, extractErr error) error {
+	var errText pgtype.Text
+	if extractErr != nil {
+		errText = pgtype.Text{String: extractErr.Error(), Valid: true}
+	}
+
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return r.queries.SaveAttachmentText(ctx, tx, database.SaveAttachmentTextParams{
+			AttachmentID: id,
+			Content:      text,
+			ExtractedAt:  pgtype.Timestamptz{Time: extractedAt, Valid: true},
+			Error:        errText,
+		})
+	})
+}
+
+func attachmentFromRow(row *database.NotesAttachment) *Attachment {
+	return &Attachment{
+		ID:          row.AttachmentID,
+		NoteID:      row.NoteID.UUID,
+		Name:        row.Name,
+		ContentType: row.ContentType,
+		Size:        row.Size,
+		BlobKey:     row.BlobKey,
+		CreatedAt:   row.CreatedAt.Time,
+		CreatedBy:   users.User{ID: row.CreatedBy.UUID},
+	}
+}
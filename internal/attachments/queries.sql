@@ -0,0 +1,153 @@
+-- name: CreateAttachment :exec
+INSERT INTO notes.attachments (
+  attachment_id,
+  note_id,
+  name,
+  content_type,
+  size,
+  blob_key,
+  created_at,
+  created_by
+) VALUES (
+  sqlc.arg(attachment_id),
+  sqlc.arg(note_id),
+  sqlc.arg(name),
+  sqlc.arg(content_type),
+  sqlc.arg(size),
+  sqlc.arg(blob_key),
+  sqlc.arg(created_at),
+  sqlc.arg(created_by)
+)
+;
+
+-- name: GetAttachment :one
+SELECT
+  attachment_id,
+  note_id,
+  name,
+  content_type,
+  size,
+  blob_key,
+  created_at,
+  created_by
+FROM notes.attachments
+WHERE attachment_id = sqlc.arg(attachment_id)
+  AND note_id = sqlc.arg(note_id)
+;
+
+-- name: ListAttachments :many
+SELECT
+  attachment_id,
+  note_id,
+  name,
+  content_type,
+  size,
+  blob_key,
+  created_at,
+  created_by
+FROM notes.attachments
+WHERE note_id = sqlc.arg(note_id)
+  AND (sqlc.narg(last_attachment_id)::uuid IS NULL OR attachment_id > sqlc.narg(last_attachment_id)::uuid)
+ORDER BY attachment_id ASC
+LIMIT sqlc.arg(page_size)
+;
+
+-- name: DetachAttachment :execrows
+UPDATE notes.attachments
+  SET note_id = NULL
+WHERE attachment_id = sqlc.arg(attachment_id)
+  AND note_id = sqlc.arg(note_id)
+;
+
+-- name: ListOrphanedAttachments :many
+SELECT
+  attachment_id,
+  note_id,
+  name,
+  content_type,
+  size,
+  blob_key,
+  created_at,
+  created_by
+FROM notes.attachments
+WHERE note_id IS NULL
+ORDER BY created_at ASC
+LIMIT sqlc.arg(page_size)
+;
+
+-- name: DeleteOrphanedAttachment :exec
+DELETE FROM notes.attachments
+WHERE attachment_id = sqlc.arg(attachment_id)
+  AND note_id IS NULL
+;
+
+-- name: ListPendingExtractions :many
+SELECT
+  attachments.attachment_id,
+  attachments.note_id,
+  attachments.name,
+  attachments.content_type,
+  attachments.size,
+  attachments.blob_key,
+  attachments.created_at,
+  attachments.created_by
+FROM notes.attachments
+LEFT JOIN notes.attachment_texts ON
+  attachment_texts.attachment_id = attachments.attachment_id
+WHERE attachment_texts.attachment_id IS NULL
+  AND attachments.note_id IS NOT NULL
+ORDER BY attachments.created_at ASC
+LIMIT sqlc.arg(page_size)
+;
+
+-- name: SaveAttachmentText :exec
+INSERT INTO notes.attachment_texts (
+  attachment_id,
+  content,
+  extracted_at,
+  error
+) VALUES (
+  sqlc.arg(attachment_id),
+  sqlc.arg(content),
+  sqlc.arg(extracted_at),
+  sqlc.narg(error)
+) ON CONFLICT (attachment_id) DO UPDATE
+  SET content      = excluded.content,
+      extracted_at = excluded.extracted_at,
+      error        = excluded.error
+;
+
+-- name: ListStaleExtractions :many
+SELECT
+  attachments.attachment_id,
+  attachments.note_id,
+  attachments.name,
+  attachments.content_type,
+  attachments.size,
+  attachments.blob_key,
+  attachments.created_at,
+  attachments.created_by
+FROM notes.attachments
+JOIN notes.attachment_texts ON
+  attachment_texts.attachment_id = attachments.attachment_id
+JOIN notes.user_note_access ON
+  user_note_access.note_id = attachments.note_id
+WHERE user_note_access.user_id = sqlc.arg(user_id)
+  AND user_note_access.access = 'owner'
+  AND attachment_texts.extracted_at < sqlc.arg(extracted_before)
+  AND (sqlc.narg(last_attachment_id)::uuid IS NULL OR attachments.attachment_id > sqlc.narg(last_attachment_id)::uuid)
+ORDER BY attachments.attachment_id ASC
+LIMIT sqlc.arg(page_size)
+;
+
+-- name: CountStaleExtractions :one
+SELECT COUNT(*)
+FROM notes.attachments
+JOIN notes.attachment_texts ON
+  attachment_texts.attachment_id = attachments.attachment_id
+JOIN notes.user_note_access ON
+  user_note_access.note_id = attachments.note_id
+WHERE user_note_access.user_id = sqlc.arg(user_id)
+  AND user_note_access.access = 'owner'
+  AND attachment_texts.extracted_at < sqlc.arg(extracted_before)
+;
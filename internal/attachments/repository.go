@@ -0,0 +1,26 @@
+package attachments
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	CreateAttachment(ctx context.Context, attachment *Attachment) error
+	GetAttachment(ctx context.Context, noteID, id uuid.UUID) (*Attachment, error)
+	ListAttachments(ctx context.Context, noteID uuid.UUID, params AttachmentListParams, pageSize int) ([]Attachment, error)
+	// DetachAttachment removes an attachment from its note. Its blob is deleted later by the [Sweeper].
+	DetachAttachment(ctx context.Context, noteID, id uuid.UUID) error
+	// ListOrphanedAttachments returns attachments that no longer belong to a note, oldest first.
+	ListOrphanedAttachments(ctx context.Context, limit int) ([]Attachment, error)
+	DeleteOrphanedAttachment(ctx context.Context, id uuid.UUID) error
+	// ListPendingExtractions returns attachments that have not had their text extracted yet, oldest first.
+	ListPendingExtractions(ctx context.Context, limit int) ([]Attachment, error)
+	// ListStaleExtractions returns the attachments of userID's notes whose text was extracted before extractedBefore.
+	ListStaleExtractions(ctx context.Context, userID uuid.UUID, extractedBefore time.Time, params AttachmentListParams, limit int) ([]Attachment, error)
+	CountStaleExtractions(ctx context.Context, userID uuid.UUID, extractedBefore time.Time) (int, error)
+	// SaveAttachmentText records the text extracted from an attachment, or why it could not be.
+	SaveAttachmentText(ctx context.Context, id uuid.UUID, text string, extractedAt time.Time, extractErr error) error
+}
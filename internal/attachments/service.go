@@ -0,0 +1,257 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/blobs"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/config"
+	"github.com/dabbertorres/notes/internal/jobs"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/scope"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// NoteAccess reports a user's access to a note. Attachments have the same access as the note they belong to.
+type NoteAccess interface {
+	GetUsersNoteAccess(ctx context.Context, noteID, userID uuid.UUID) (users.AccessLevel, error)
+}
+
+type Service struct {
+	repo    Repository
+	notes   NoteAccess
+	blobs   blobs.BlobStore
+	jobs    *jobs.Service
+	maxSize int64
+}
+
+func NewService(injector do.Injector) (*Service, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := do.InvokeAs[NoteAccess](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := do.Invoke[*blobs.Store](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	jobService, err := do.Invoke[*jobs.Service](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := do.Invoke[*config.Config](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		repo:    repo,
+		notes:   notes,
+		blobs:   store,
+		jobs:    jobService,
+		maxSize: cfg.Attachments.MaxSize,
+	}, nil
+}
+
+// MaxSize is the largest attachment, in bytes, that can be uploaded.
+func (s *Service) MaxSize() int64 { return s.maxSize }
+
+// CreateAttachment stores the contents of r as a new attachment of a note.
+// The content type is detected from the contents and name, rather than trusting the uploader.
+func (s *Service) CreateAttachment(ctx context.Context, noteID uuid.UUID, name string, r io.Reader) (*Attachment, error) {
+	userID := scope.MustUserID(ctx)
+
+	if err := s.checkAccess(ctx, noteID, users.AccessLevelEditor); err != nil {
+		return nil, err
+	}
+
+	attachmentID, err := uuid.NewV7()
+	if err != nil {
+		return nil, apiv1.StatusError(http.StatusServiceUnavailable)
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, apiv1.NewError(http.StatusBadRequest, "error reading attachment")
+	}
+	head = head[:n]
+
+	attachment := &Attachment{
+		ID:          attachmentID,
+		NoteID:      noteID,
+		Name:        name,
+		ContentType: DetectContentType(name, head),
+		BlobKey:     "attachments/" + attachmentID.String(),
+		CreatedAt:   time.Now(),
+		CreatedBy:   users.User{ID: userID},
+	}
+
+	body := &sizeLimitReader{
+		r:     io.MultiReader(bytes.NewReader(head), r),
+		limit: s.maxSize,
+	}
+
+	if err := s.blobs.Put(ctx, attachment.BlobKey, body, -1, attachment.ContentType); err != nil {
+		// the store may have kept part of the upload
+		s.deleteBlob(ctx, attachment.BlobKey)
+
+		if body.exceeded {
+			return nil, apiv1.NewError(http.StatusRequestEntityTooLarge, "attachment is too large")
+		}
+
+		log.Error(ctx, "error storing attachment", zap.Stringer("attachment_id", attachment.ID), zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	attachment.Size = body.n
+
+	if err := s.repo.CreateAttachment(ctx, attachment); err != nil {
+		s.deleteBlob(ctx, attachment.BlobKey)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (s *Service) GetAttachment(ctx context.Context, noteID, attachmentID uuid.UUID) (*Attachment, error) {
+	if err := s.checkAccess(ctx, noteID, users.AccessLevelViewer); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAttachment(ctx, noteID, attachmentID)
+}
+
+// OpenAttachment returns an attachment along with its contents, which the caller must close.
+func (s *Service) OpenAttachment(ctx context.Context, noteID, attachmentID uuid.UUID) (*Attachment, blobs.Blob, error) {
+	attachment, err := s.GetAttachment(ctx, noteID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blob, err := s.blobs.Get(ctx, attachment.BlobKey)
+	if err != nil {
+		if errors.Is(err, blobs.ErrNotFound) {
+			log.Warn(ctx, "attachment blob is missing", zap.Stringer("attachment_id", attachment.ID))
+			return nil, nil, apiv1.NewError(http.StatusNotFound, "attachment does not exist")
+		}
+
+		log.Error(ctx, "error opening attachment", zap.Stringer("attachment_id", attachment.ID), zap.Error(err))
+		return nil, nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	return attachment, blob, nil
+}
+
+func (s *Service) ListAttachments(ctx context.Context, noteID uuid.UUID, params AttachmentListParams, pageSize int) (attachments []Attachment, next *AttachmentListParams, err error) {
+	if err := s.checkAccess(ctx, noteID, users.AccessLevelViewer); err != nil {
+		return nil, nil, err
+	}
+
+	if pageSize == 0 {
+		pageSize = 100
+	}
+
+	// retrieve one more to see if there is another page to fetch
+	attachments, err = s.repo.ListAttachments(ctx, noteID, params, pageSize+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(attachments) > pageSize {
+		attachments = attachments[:pageSize]
+
+		last := &attachments[len(attachments)-1]
+
+		next = &AttachmentListParams{
+			LastAttachmentID: uuid.NullUUID{UUID: last.ID, Valid: true},
+		}
+	}
+
+	return attachments, next, nil
+}
+
+// DeleteAttachment removes an attachment from a note. Its contents are deleted in the background.
+func (s *Service) DeleteAttachment(ctx context.Context, noteID, attachmentID uuid.UUID) error {
+	if err := s.checkAccess(ctx, noteID, users.AccessLevelEditor); err != nil {
+		return err
+	}
+
+	return s.repo.DetachAttachment(ctx, noteID, attachmentID)
+}
+
+// Reindex starts extracting the text of the attachments of the notes that the user in ctx owns again, in the
+// background by a [ReindexJob].
+func (s *Service) Reindex(ctx context.Context) (*jobs.Job, error) {
+	job, err := ReindexJob.New(scope.MustUserID(ctx), ReindexPayload{ExtractedBefore: time.Now()})
+	if err != nil {
+		log.Error(ctx, "error creating reindex job", zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusServiceUnavailable)
+	}
+
+	if err := s.jobs.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (s *Service) checkAccess(ctx context.Context, noteID uuid.UUID, required users.AccessLevel) error {
+	userID := scope.MustUserID(ctx)
+
+	access, err := s.notes.GetUsersNoteAccess(ctx, noteID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", noteID), zap.Error(err))
+		return apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < required {
+		return apiv1.StatusError(http.StatusForbidden)
+	}
+
+	return nil
+}
+
+func (s *Service) deleteBlob(ctx context.Context, key string) {
+	if err := s.blobs.Delete(context.WithoutCancel(ctx), key); err != nil {
+		log.Error(ctx, "error deleting attachment blob", zap.String("blob_key", key), zap.Error(err))
+	}
+}
+
+var errTooLarge = errors.New("attachment is too large")
+
+// sizeLimitReader counts the bytes read from r, and fails once more than limit have been read.
+type sizeLimitReader struct {
+	r        io.Reader
+	limit    int64
+	n        int64
+	exceeded bool
+}
+
+func (r *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+
+	if r.n > r.limit {
+		r.exceeded = true
+		return n, errTooLarge
+	}
+
+	return n, err
+}
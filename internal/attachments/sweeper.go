@@ -0,0 +1,84 @@
+package attachments
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/blobs"
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+const (
+	sweepInterval  = time.Minute
+	sweepBatchSize = 100
+)
+
+// Sweeper deletes the contents of attachments that no longer belong to a note, either because they were deleted or
+// because their note was.
+type Sweeper struct {
+	repo  Repository
+	blobs blobs.BlobStore
+}
+
+func NewSweeper(injector do.Injector) (*Sweeper, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := do.Invoke[*blobs.Store](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sweeper{
+		repo:  repo,
+		blobs: store,
+	}, nil
+}
+
+// Run sweeps periodically until ctx is cancelled.
+func (s *Sweeper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Sweep(ctx); err != nil && ctx.Err() == nil {
+			log.Error(ctx, "error sweeping orphaned attachments", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep deletes orphaned attachments until there are none left.
+func (s *Sweeper) Sweep(ctx context.Context) error {
+	for {
+		orphans, err := s.repo.ListOrphanedAttachments(ctx, sweepBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, orphan := range orphans {
+			// the blob goes first, so that a failure leaves the row behind to retry with
+			if err := s.blobs.Delete(ctx, orphan.BlobKey); err != nil {
+				return err
+			}
+
+			if err := s.repo.DeleteOrphanedAttachment(ctx, orphan.ID); err != nil {
+				return err
+			}
+		}
+
+		if len(orphans) < sweepBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package apiv1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/audit"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+type Service interface {
+	ListEntries(ctx context.Context, params audit.EntryListParams, pageSize int) (results []audit.Entry, next *audit.EntryListParams, err error)
+	ListNoteEntries(ctx context.Context, noteID uuid.UUID, params audit.EntryListParams, pageSize int) (results []audit.Entry, next *audit.EntryListParams, err error)
+}
+
+// ListEntries lists the entire audit log, most recent first. It may be filtered by action, actor and target.
+func ListEntries(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		paging, err := parseListEntriesParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		results, next, err := svc.ListEntries(r.Context(), paging.Data.ToDomain(), paging.PageSize)
+		if err != nil {
+			log.Error(r.Context(), "error listing audit entries", zap.Error(err))
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		writeEntriesPage(w, r, results, next, paging.PageSize)
+	}
+}
+
+// ListNoteEntries lists the audit log of a note, most recent first.
+func ListNoteEntries(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		paging, err := parseListEntriesParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		results, next, err := svc.ListNoteEntries(r.Context(), noteID, paging.Data.ToDomain(), paging.PageSize)
+		if err != nil {
+			log.Error(r.Context(), "error listing note audit entries", zap.Stringer("note_id", noteID), zap.Error(err))
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		writeEntriesPage(w, r, results, next, paging.PageSize)
+	}
+}
+
+func writeEntriesPage(w http.ResponseWriter, r *http.Request, results []audit.Entry, next *audit.EntryListParams, pageSize int) {
+	page := apiv1.Page[Entry, *ListEntriesPageTokenData]{
+		NextPageToken: nil,
+		Items: util.MapSlice(results, func(entry audit.Entry) Entry {
+			return EntryFromDomain(&entry)
+		}),
+	}
+
+	if next != nil {
+		page.NextPageToken = &apiv1.PageToken[*ListEntriesPageTokenData]{
+			Data:     ListEntriesPageTokenDataFromDomain(next),
+			PageSize: pageSize,
+		}
+	}
+
+	apiv1.WriteJSON(r.Context(), w, http.StatusOK, page)
+}
+
+func parseListEntriesParams(r *http.Request) (token apiv1.PageToken[*ListEntriesPageTokenData], err error) {
+	rawPageToken := r.FormValue("next_page_token")
+	if rawPageToken != "" {
+		pageToken, err := apiv1.ParsePageToken[*ListEntriesPageTokenData](rawPageToken, 100, 100)
+		if err != nil {
+			return token, apiv1.NewValidationFailureError(err)
+		}
+
+		token = pageToken
+	} else {
+		var errs []error
+
+		token.Data = &ListEntriesPageTokenData{
+			Action:  apiv1.ValidateOptional("action", r.FormValue("action"), &errs, audit.ParseAction),
+			ActorID: apiv1.ValidateOptional("actor_id", r.FormValue("actor_id"), &errs, apiv1.ParseNullUUID),
+			NoteID:  apiv1.ValidateOptional("note_id", r.FormValue("note_id"), &errs, apiv1.ParseNullUUID),
+			TagID:   apiv1.ValidateOptional("tag_id", r.FormValue("tag_id"), &errs, apiv1.ParseNullUUID),
+			UserID:  apiv1.ValidateOptional("user_id", r.FormValue("user_id"), &errs, apiv1.ParseNullUUID),
+		}
+
+		if len(errs) != 0 {
+			return token, apiv1.NewValidationFailureError(errors.Join(errs...))
+		}
+
+		if size := r.FormValue("page_size"); size != "" {
+			pageSize, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return token, apiv1.NewValidationFailureError(err)
+			}
+
+			token.PageSize = int(pageSize)
+		}
+	}
+
+	return token, nil
+}
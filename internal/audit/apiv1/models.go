@@ -0,0 +1,143 @@
+package apiv1
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/audit"
+)
+
+type Entry struct {
+	ID         int64           `json:"id"`
+	Action     string          `json:"action"`
+	ActorID    string          `json:"actor_id,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	NoteID     string          `json:"note_id,omitempty"`
+	TagID      string          `json:"tag_id,omitempty"`
+	UserID     string          `json:"user_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	OccurredAt string          `json:"occurred_at"`
+}
+
+func EntryFromDomain(domain *audit.Entry) (e Entry) {
+	e.ID = domain.ID
+	e.Action = string(domain.Action)
+	e.ActorID = formatOptionalUUID(domain.ActorID)
+	e.RequestID = formatOptionalUUID(domain.RequestID)
+	e.NoteID = formatOptionalUUID(domain.Target.NoteID)
+	e.TagID = formatOptionalUUID(domain.Target.TagID)
+	e.UserID = formatOptionalUUID(domain.Target.UserID)
+	e.Before = domain.Before
+	e.After = domain.After
+	e.OccurredAt = domain.OccurredAt.Format(time.RFC3339Nano)
+	return e
+}
+
+type ListEntriesPageTokenData struct {
+	LastEntryID int64
+	Action      audit.Action
+	ActorID     uuid.NullUUID
+	NoteID      uuid.NullUUID
+	TagID       uuid.NullUUID
+	UserID      uuid.NullUUID
+}
+
+func ListEntriesPageTokenDataFromDomain(params *audit.EntryListParams) *ListEntriesPageTokenData {
+	return &ListEntriesPageTokenData{
+		LastEntryID: params.LastEntryID,
+		Action:      params.Action,
+		ActorID:     params.ActorID,
+		NoteID:      params.NoteID,
+		TagID:       params.TagID,
+		UserID:      params.UserID,
+	}
+}
+
+func (d *ListEntriesPageTokenData) ToDomain() audit.EntryListParams {
+	return audit.EntryListParams{
+		LastEntryID: d.LastEntryID,
+		Action:      d.Action,
+		ActorID:     d.ActorID,
+		NoteID:      d.NoteID,
+		TagID:       d.TagID,
+		UserID:      d.UserID,
+	}
+}
+
+func (d *ListEntriesPageTokenData) EncodePager() ([][]byte, error) {
+	var out [6][]byte
+
+	if d.LastEntryID != 0 {
+		out[0] = strconv.AppendInt(nil, d.LastEntryID, 10)
+	}
+
+	out[1] = []byte(d.Action)
+	out[2] = encodeOptionalUUID(d.ActorID)
+	out[3] = encodeOptionalUUID(d.NoteID)
+	out[4] = encodeOptionalUUID(d.TagID)
+	out[5] = encodeOptionalUUID(d.UserID)
+
+	return out[:], nil
+}
+
+func (d *ListEntriesPageTokenData) DecodePager(data [][]byte) (err error) {
+	if len(data) != 6 {
+		return errors.New("invalid page token format (incorrect number of parts)")
+	}
+
+	if len(data[0]) != 0 {
+		d.LastEntryID, err = strconv.ParseInt(string(data[0]), 10, 64)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(data[1]) != 0 {
+		d.Action, err = audit.ParseAction(string(data[1]))
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, id := range []*uuid.NullUUID{&d.ActorID, &d.NoteID, &d.TagID, &d.UserID} {
+		if *id, err = decodeOptionalUUID(data[2+i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encodeOptionalUUID(id uuid.NullUUID) []byte {
+	if !id.Valid {
+		return nil
+	}
+
+	return []byte(id.UUID.String())
+}
+
+func decodeOptionalUUID(data []byte) (uuid.NullUUID, error) {
+	if len(data) == 0 {
+		return uuid.NullUUID{}, nil
+	}
+
+	id, err := uuid.ParseBytes(data)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
+
+func formatOptionalUUID(id uuid.NullUUID) string {
+	if !id.Valid {
+		return ""
+	}
+
+	return id.UUID.String()
+}
@@ -0,0 +1,33 @@
+package apiv1
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dabbertorres/notes/internal/audit"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+)
+
+func TestListEntriesPageTokenData_MarshalRoundTrip(t *testing.T) {
+	input := apiv1.PageToken[*ListEntriesPageTokenData]{
+		Data: &ListEntriesPageTokenData{
+			LastEntryID: 1337,
+			Action:      audit.ActionNoteAccessChanged,
+			ActorID:     uuid.NullUUID{UUID: uuid.New(), Valid: true},
+			NoteID:      uuid.NullUUID{UUID: uuid.New(), Valid: true},
+			UserID:      uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		},
+		PageSize: 25,
+	}
+
+	out, err := input.MarshalText()
+	assert.NoError(t, err)
+
+	var output apiv1.PageToken[*ListEntriesPageTokenData]
+	err = output.UnmarshalText(out)
+	assert.NoError(t, err)
+
+	assert.Equal(t, input, output)
+}
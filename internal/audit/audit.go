@@ -0,0 +1,9 @@
+package audit
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewPGXRepository),
+	do.Lazy(NewRecorder),
+	do.Lazy(NewService),
+)
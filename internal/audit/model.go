@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Action string
+
+const (
+	ActionNoteAccessChanged Action = "note.access_changed"
+	ActionNoteDeleted       Action = "note.deleted"
+	ActionNotePublished     Action = "note.published"
+	ActionNoteUnpublished   Action = "note.unpublished"
+	ActionTagAccessChanged  Action = "tag.access_changed"
+	ActionTagDeleted        Action = "tag.deleted"
+	ActionTagsMerged        Action = "tag.merged"
+	ActionUserSignedIn      Action = "user.signed_in"
+	ActionTokenCreated      Action = "token.created"
+)
+
+// ParseAction parses the name of an action.
+func ParseAction(s string) (Action, error) {
+	switch action := Action(s); action {
+	case ActionNoteAccessChanged,
+		ActionNoteDeleted,
+		ActionNotePublished,
+		ActionNoteUnpublished,
+		ActionTagAccessChanged,
+		ActionTagDeleted,
+		ActionTagsMerged,
+		ActionUserSignedIn,
+		ActionTokenCreated:
+		return action, nil
+	}
+
+	return "", fmt.Errorf("unknown action %q", s)
+}
+
+// Target is what an action was done to. A tag merge targets the tag that was merged into, and token creation
+// targets the user the token is for.
+type Target struct {
+	NoteID uuid.NullUUID
+	TagID  uuid.NullUUID
+	UserID uuid.NullUUID
+}
+
+func NoteTarget(id uuid.UUID) Target {
+	return Target{NoteID: uuid.NullUUID{UUID: id, Valid: true}}
+}
+
+func TagTarget(id uuid.UUID) Target {
+	return Target{TagID: uuid.NullUUID{UUID: id, Valid: true}}
+}
+
+func UserTarget(id uuid.UUID) Target {
+	return Target{UserID: uuid.NullUUID{UUID: id, Valid: true}}
+}
+
+// Entry records an action, who did it, and what it changed.
+type Entry struct {
+	ID     int64
+	Action Action
+	// ActorID is unset for actions not done on behalf of a user.
+	ActorID   uuid.NullUUID
+	RequestID uuid.NullUUID
+	Target    Target
+	// Before and After are JSON describing the target before and after the action, if it existed.
+	Before     json.RawMessage
+	After      json.RawMessage
+	OccurredAt time.Time
+}
+
+// EntryListParams filters the entries listed. Entries are listed most recent first.
+type EntryListParams struct {
+	LastEntryID int64
+	Action      Action
+	ActorID     uuid.NullUUID
+	NoteID      uuid.NullUUID
+	TagID       uuid.NullUUID
+	UserID      uuid.NullUUID
+}
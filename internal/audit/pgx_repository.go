@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+type PGXRepository struct {
+	db      database.Database
+	queries *database.Queries
+}
+
+func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGXRepository{
+		db:      db,
+		queries: database.New(),
+	}, nil
+}
+
+func (r *PGXRepository) ListEntries(ctx context.Context, params EntryListParams, pageSize int) (entries []Entry, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ListAuditEntries(ctx, tx, database.ListAuditEntriesParams{
+			LastEntryID: pgtype.Int8{Int64: params.LastEntryID, Valid: params.LastEntryID != 0},
+			Action:      pgtype.Text{String: string(params.Action), Valid: params.Action != ""},
+			ActorID:     params.ActorID,
+			NoteID:      params.NoteID,
+			TagID:       params.TagID,
+			UserID:      params.UserID,
+			PageSize:    int64(pageSize),
+		})
+		if err != nil {
+			return err
+		}
+
+		entries = make([]Entry, 0, len(rows))
+		for _, row := range rows {
+			entries = append(entries, Entry{
+				ID:        row.EntryID,
+				Action:    Action(row.Action),
+				ActorID:   row.ActorID,
+				RequestID: row.RequestID,
+				Target: Target{
+					NoteID: row.NoteID,
+					TagID:  row.TagID,
+					UserID: row.UserID,
+				},
+				Before:     row.Before,
+				After:      row.After,
+				OccurredAt: row.OccurredAt.Time,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error listing audit entries", zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,46 @@
+-- name: AppendAuditEntry :exec
+INSERT INTO notes.audit_log (
+  action,
+  actor_id,
+  request_id,
+  note_id,
+  tag_id,
+  user_id,
+  before,
+  after,
+  occurred_at
+) VALUES (
+  sqlc.arg(action),
+  sqlc.narg(actor_id),
+  sqlc.narg(request_id),
+  sqlc.narg(note_id),
+  sqlc.narg(tag_id),
+  sqlc.narg(user_id),
+  sqlc.narg(before),
+  sqlc.narg(after),
+  sqlc.arg(occurred_at)
+)
+;
+
+-- name: ListAuditEntries :many
+SELECT
+  entry_id,
+  action,
+  actor_id,
+  request_id,
+  note_id,
+  tag_id,
+  user_id,
+  before,
+  after,
+  occurred_at
+FROM notes.audit_log
+WHERE (sqlc.narg(last_entry_id)::bigint IS NULL OR entry_id < sqlc.narg(last_entry_id)::bigint)
+  AND (sqlc.narg(action)::text IS NULL OR action = sqlc.narg(action)::text)
+  AND (sqlc.narg(actor_id)::uuid IS NULL OR actor_id = sqlc.narg(actor_id)::uuid)
+  AND (sqlc.narg(note_id)::uuid IS NULL OR note_id = sqlc.narg(note_id)::uuid)
+  AND (sqlc.narg(tag_id)::uuid IS NULL OR tag_id = sqlc.narg(tag_id)::uuid)
+  AND (sqlc.narg(user_id)::uuid IS NULL OR user_id = sqlc.narg(user_id)::uuid)
+ORDER BY entry_id DESC
+LIMIT sqlc.arg(page_size)
+;
@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/scope"
+)
+
+// Recorder appends entries to the audit log as part of the transaction making the change they describe,
+// so that an action is recorded if and only if it is done.
+type Recorder struct {
+	queries *database.Queries
+}
+
+func NewRecorder(injector do.Injector) (*Recorder, error) {
+	return &Recorder{
+		queries: database.New(),
+	}, nil
+}
+
+// Record appends an entry for action on target in tx. The actor and request are taken from ctx.
+// before and after are encoded as JSON, and are omitted if nil.
+func (r *Recorder) Record(ctx context.Context, tx pgx.Tx, action Action, target Target, before, after any) error {
+	params := database.AppendAuditEntryParams{
+		Action:     string(action),
+		NoteID:     target.NoteID,
+		TagID:      target.TagID,
+		UserID:     target.UserID,
+		OccurredAt: pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true},
+	}
+
+	if actorID, ok := scope.UserID(ctx); ok {
+		params.ActorID = uuid.NullUUID{UUID: actorID, Valid: true}
+	}
+
+	if requestID := scope.RequestID(ctx); requestID != uuid.Nil {
+		params.RequestID = uuid.NullUUID{UUID: requestID, Valid: true}
+	}
+
+	var err error
+	if params.Before, err = encode(before); err != nil {
+		return err
+	}
+
+	if params.After, err = encode(after); err != nil {
+		return err
+	}
+
+	return r.queries.AppendAuditEntry(ctx, tx, params)
+}
+
+func encode(v any) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(v)
+}
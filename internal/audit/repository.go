@@ -0,0 +1,7 @@
+package audit
+
+import "context"
+
+type Repository interface {
+	ListEntries(ctx context.Context, params EntryListParams, pageSize int) ([]Entry, error)
+}
@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/config"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/scope"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// NoteAccess reports a user's access to a note. Owners may read a note's audit log.
+type NoteAccess interface {
+	GetUsersNoteAccess(ctx context.Context, noteID, userID uuid.UUID) (users.AccessLevel, error)
+}
+
+type Service struct {
+	repo   Repository
+	notes  NoteAccess
+	admins []uuid.UUID
+}
+
+func NewService(injector do.Injector) (*Service, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := do.InvokeAs[NoteAccess](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := do.Invoke[*config.Config](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		repo:   repo,
+		notes:  notes,
+		admins: cfg.Audit.Admins,
+	}, nil
+}
+
+// ListEntries lists the entries in the audit log matching params. Only admins may read the entire audit log.
+func (s *Service) ListEntries(ctx context.Context, params EntryListParams, pageSize int) (entries []Entry, next *EntryListParams, err error) {
+	userID := scope.MustUserID(ctx)
+
+	if !slices.Contains(s.admins, userID) {
+		return nil, nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	return s.listEntries(ctx, params, pageSize)
+}
+
+// ListNoteEntries lists the entries in the audit log about a note. Only its owners may read them.
+func (s *Service) ListNoteEntries(ctx context.Context, noteID uuid.UUID, params EntryListParams, pageSize int) (entries []Entry, next *EntryListParams, err error) {
+	userID := scope.MustUserID(ctx)
+
+	access, err := s.notes.GetUsersNoteAccess(ctx, noteID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < users.AccessLevelOwner {
+		return nil, nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	params.NoteID = uuid.NullUUID{UUID: noteID, Valid: true}
+
+	return s.listEntries(ctx, params, pageSize)
+}
+
+func (s *Service) listEntries(ctx context.Context, params EntryListParams, pageSize int) (entries []Entry, next *EntryListParams, err error) {
+	if pageSize == 0 {
+		pageSize = 100
+	}
+
+	// retrieve one more to see if there is another page to fetch
+	entries, err = s.repo.ListEntries(ctx, params, pageSize+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+
+		next = &params
+		next.LastEntryID = entries[len(entries)-1].ID
+	}
+
+	return entries, next, nil
+}
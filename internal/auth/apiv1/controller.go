@@ -0,0 +1,65 @@
+package apiv1
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/auth"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+)
+
+type Service interface {
+	SignIn(ctx context.Context, userID uuid.UUID) error
+	CreateToken(ctx context.Context, token *auth.Token) (*auth.Token, string, error)
+}
+
+// PostSession signs the user in.
+func PostSession(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid user id"))
+			return
+		}
+
+		if err := svc.SignIn(r.Context(), userID); err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PostToken creates an API token for the user. The response is the only time its secret is shown.
+func PostToken(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid user id"))
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[WritableToken](w, r)
+		if !ok {
+			return
+		}
+
+		token, err := body.ToDomain(userID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		created, secret, err := svc.CreateToken(r.Context(), token)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := CreatedToken{Token: TokenFromDomain(created), Secret: secret}
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
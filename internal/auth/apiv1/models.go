@@ -0,0 +1,63 @@
+package apiv1
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/auth"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+)
+
+type Token struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+func TokenFromDomain(domain *auth.Token) (t Token) {
+	t.ID = domain.ID.String()
+	t.Name = domain.Name
+	t.CreatedAt = domain.CreatedAt.Format(time.RFC3339)
+	if !domain.ExpiresAt.IsZero() {
+		t.ExpiresAt = domain.ExpiresAt.Format(time.RFC3339)
+	}
+
+	return t
+}
+
+// CreatedToken is a token that was just created, along with its secret, which is only ever shown then.
+type CreatedToken struct {
+	Token
+	// Secret authenticates requests when sent as a bearer token in the Authorization header.
+	Secret string `json:"secret"`
+}
+
+// WritableToken contains only the subset of fields on [Token] that an API user can set.
+type WritableToken struct {
+	Name string `json:"name"`
+	// ExpiresAt is when the token stops working. It never does if left out.
+	ExpiresAt string `json:"expires_at"`
+}
+
+func (t *WritableToken) ToDomain(userID uuid.UUID) (*auth.Token, error) {
+	var errs []error
+
+	if t.Name == "" {
+		errs = append(errs, &apiv1.InvalidFieldError{Field: ".name", Err: "is required"})
+	}
+
+	out := &auth.Token{
+		UserID:    userID,
+		Name:      t.Name,
+		ExpiresAt: apiv1.ValidateOptional(".expires_at", t.ExpiresAt, &errs, apiv1.ParseRFC3339),
+	}
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,9 @@
+// Package auth records users signing in, and authenticates requests made with the API tokens they create.
+package auth
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewPGXRepository),
+	do.Lazy(NewService),
+)
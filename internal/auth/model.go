@@ -0,0 +1,17 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Token is an API token, which authenticates requests as its user until it expires.
+type Token struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	CreatedAt time.Time
+	// ExpiresAt is zero if the token never expires.
+	ExpiresAt time.Time
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/audit"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+var (
+	errUserNotFound = apiv1.NewError(http.StatusNotFound, "user does not exist")
+	errInvalidToken = apiv1.NewError(http.StatusUnauthorized, "invalid or expired token")
+)
+
+type PGXRepository struct {
+	db      database.Database
+	queries *database.Queries
+	audit   *audit.Recorder
+}
+
+func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	auditor, err := do.Invoke[*audit.Recorder](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGXRepository{
+		db:      db,
+		queries: database.New(),
+		audit:   auditor,
+	}, nil
+}
+
+// auditedSignIn is what the audit log records about a user signing in.
+type auditedSignIn struct {
+	SignedInAt time.Time `json:"signed_in_at"`
+}
+
+func (r *PGXRepository) RecordSignIn(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		updated, err := r.queries.RecordSignIn(ctx, tx, database.RecordSignInParams{
+			SignedInAt: pgtype.Timestamptz{Time: at, Valid: true},
+			UserID:     userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if updated == 0 {
+			return errUserNotFound
+		}
+
+		after := auditedSignIn{SignedInAt: at}
+		return r.audit.Record(ctx, tx, audit.ActionUserSignedIn, audit.UserTarget(userID), nil, &after)
+	})
+	if err != nil {
+		if errors.Is(err, errUserNotFound) {
+			return err
+		}
+
+		log.Error(ctx, "error recording sign in", zap.Stringer("user_id", userID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+// auditedToken is what the audit log records about a created token. Its secret is never recorded.
+type auditedToken struct {
+	TokenID   uuid.UUID  `json:"token_id"`
+	Name      string     `json:"name"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (r *PGXRepository) SaveToken(ctx context.Context, token *Token, hash string) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.CreateUserToken(ctx, tx, database.CreateUserTokenParams{
+			TokenID:   token.ID,
+			Name:      token.Name,
+			TokenHash: hash,
+			CreatedAt: pgtype.Timestamptz{Time: token.CreatedAt, Valid: true},
+			ExpiresAt: pgtype.Timestamptz{Time: token.ExpiresAt, Valid: !token.ExpiresAt.IsZero()},
+			UserID:    token.UserID,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errUserNotFound
+			}
+
+			return err
+		}
+
+		*token = *tokenFromRow(row)
+
+		after := auditedToken{TokenID: token.ID, Name: token.Name}
+		if !token.ExpiresAt.IsZero() {
+			after.ExpiresAt = &token.ExpiresAt
+		}
+
+		return r.audit.Record(ctx, tx, audit.ActionTokenCreated, audit.UserTarget(token.UserID), nil, &after)
+	})
+	if err != nil {
+		if errors.Is(err, errUserNotFound) {
+			return err
+		}
+
+		log.Error(ctx, "error saving token", zap.Stringer("user_id", token.UserID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) GetTokenByHash(ctx context.Context, hash string, now time.Time) (*Token, error) {
+	var row database.NotesUserToken
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		row, err = r.queries.GetUserTokenByHash(ctx, tx, database.GetUserTokenByHashParams{
+			TokenHash: hash,
+			Now:       pgtype.Timestamptz{Time: now, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errInvalidToken
+		}
+
+		log.Error(ctx, "error getting token", zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return tokenFromRow(row), nil
+}
+
+func tokenFromRow(row database.NotesUserToken) *Token {
+	return &Token{
+		ID:        row.TokenID,
+		UserID:    row.UserID,
+		Name:      row.Name,
+		CreatedAt: row.CreatedAt.Time,
+		ExpiresAt: row.ExpiresAt.Time,
+	}
+}
@@ -0,0 +1,50 @@
+-- name: RecordSignIn :execrows
+UPDATE notes.users
+SET last_sign_in = sqlc.arg(signed_in_at)
+WHERE user_id = sqlc.arg(user_id)
+  AND active
+;
+
+-- name: CreateUserToken :one
+INSERT INTO notes.user_tokens (
+  token_id,
+  user_id,
+  name,
+  token_hash,
+  created_at,
+  expires_at
+)
+SELECT
+  sqlc.arg(token_id),
+  users.user_id,
+  sqlc.arg(name),
+  sqlc.arg(token_hash),
+  sqlc.arg(created_at),
+  sqlc.narg(expires_at)
+FROM notes.users
+WHERE users.user_id = sqlc.arg(user_id)
+  AND users.active
+RETURNING
+  token_id,
+  user_id,
+  name,
+  token_hash,
+  created_at,
+  expires_at
+;
+
+-- name: GetUserTokenByHash :one
+SELECT
+  user_tokens.token_id,
+  user_tokens.user_id,
+  user_tokens.name,
+  user_tokens.token_hash,
+  user_tokens.created_at,
+  user_tokens.expires_at
+FROM notes.user_tokens
+JOIN notes.users ON
+  users.user_id = user_tokens.user_id
+WHERE user_tokens.token_hash = sqlc.arg(token_hash)
+  AND users.active
+  AND (user_tokens.expires_at IS NULL OR user_tokens.expires_at > sqlc.arg(now))
+;
@@ -0,0 +1,17 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	// RecordSignIn records that the user with userID signed in at.
+	RecordSignIn(ctx context.Context, userID uuid.UUID, at time.Time) error
+	// SaveToken saves a new token, identified by the hash of its secret.
+	SaveToken(ctx context.Context, token *Token, hash string) error
+	// GetTokenByHash returns the token with the hash of its secret, if it has not expired by now.
+	GetTokenByHash(ctx context.Context, hash string, now time.Time) (*Token, error)
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/scope"
+)
+
+// tokenPrefix starts every token's secret, so that they are recognisable, e.g. by secret scanners.
+const tokenPrefix = "ntk_"
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(injector do.Injector) (*Service, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		repo: repo,
+	}, nil
+}
+
+// SignIn records that the current user, who must be the user with userID, signed in.
+// Requests are authenticated before they get here, so signing in only starts a session as the user they came from.
+func (s *Service) SignIn(ctx context.Context, userID uuid.UUID) error {
+	if scope.MustUserID(ctx) != userID {
+		return apiv1.StatusError(http.StatusForbidden)
+	}
+
+	return s.repo.RecordSignIn(ctx, userID, time.Now().UTC())
+}
+
+// CreateToken creates a token for the current user, who must be the user with token.UserID.
+// It returns the token's secret, which can't be retrieved again.
+func (s *Service) CreateToken(ctx context.Context, token *Token) (*Token, string, error) {
+	if scope.MustUserID(ctx) != token.UserID {
+		return nil, "", apiv1.StatusError(http.StatusForbidden)
+	}
+
+	if !token.ExpiresAt.IsZero() && !token.ExpiresAt.After(time.Now()) {
+		return nil, "", apiv1.NewValidationFailureError(&apiv1.InvalidFieldError{
+			Field: ".expires_at",
+			Err:   "must be in the future",
+		})
+	}
+
+	tokenID, err := uuid.NewV7()
+	if err != nil {
+		return nil, "", apiv1.StatusError(http.StatusServiceUnavailable)
+	}
+
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, "", apiv1.StatusError(http.StatusServiceUnavailable)
+	}
+
+	secret := tokenPrefix + base64.RawURLEncoding.EncodeToString(b[:])
+
+	token.ID = tokenID
+	token.CreatedAt = time.Now().UTC()
+
+	if err := s.repo.SaveToken(ctx, token, hashSecret(secret)); err != nil {
+		return nil, "", err
+	}
+
+	return token, secret, nil
+}
+
+// Authenticate returns the user that the token with secret belongs to.
+func (s *Service) Authenticate(ctx context.Context, secret string) (uuid.UUID, error) {
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		return uuid.Nil, errInvalidToken
+	}
+
+	token, err := s.repo.GetTokenByHash(ctx, hashSecret(secret), time.Now())
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return token.UserID, nil
+}
+
+// hashSecret is how a token's secret is stored. Secrets are random enough that they don't need a slow hash.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/scope"
+)
+
+// memoryRepository records when each user last signed in, and holds tokens by the hashes of their secrets.
+type memoryRepository struct {
+	signIns map[uuid.UUID]time.Time
+	tokens  map[string]Token
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		signIns: make(map[uuid.UUID]time.Time),
+		tokens:  make(map[string]Token),
+	}
+}
+
+func (r *memoryRepository) RecordSignIn(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	r.signIns[userID] = at
+	return nil
+}
+
+func (r *memoryRepository) SaveToken(ctx context.Context, token *Token, hash string) error {
+	r.tokens[hash] = *token
+	return nil
+}
+
+func (r *memoryRepository) GetTokenByHash(ctx context.Context, hash string, now time.Time) (*Token, error) {
+	token, ok := r.tokens[hash]
+	if !ok || (!token.ExpiresAt.IsZero() && !token.ExpiresAt.After(now)) {
+		return nil, errInvalidToken
+	}
+
+	return &token, nil
+}
+
+func assertStatus(t *testing.T, status int, err error) {
+	t.Helper()
+
+	var apiErr apiv1.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, status, apiErr.Status())
+}
+
+func TestService_SignIn(t *testing.T) {
+	repo := newMemoryRepository()
+	svc := &Service{repo: repo}
+
+	userID := uuid.New()
+	ctx := scope.WithUserID(context.Background(), userID)
+
+	require.NoError(t, svc.SignIn(ctx, userID))
+	assert.Contains(t, repo.signIns, userID)
+
+	// nobody signs in as someone else
+	other := uuid.New()
+	assertStatus(t, http.StatusForbidden, svc.SignIn(ctx, other))
+	assert.NotContains(t, repo.signIns, other)
+}
+
+func TestService_Tokens(t *testing.T) {
+	repo := newMemoryRepository()
+	svc := &Service{repo: repo}
+
+	userID := uuid.New()
+	ctx := scope.WithUserID(context.Background(), userID)
+
+	token, secret, err := svc.CreateToken(ctx, &Token{UserID: userID, Name: "cli"})
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, token.ID)
+
+	got, err := svc.Authenticate(context.Background(), secret)
+	require.NoError(t, err)
+	assert.Equal(t, userID, got)
+
+	// only the hash of the secret is kept
+	assert.NotContains(t, repo.tokens, secret)
+
+	_, err = svc.Authenticate(context.Background(), secret+"x")
+	assertStatus(t, http.StatusUnauthorized, err)
+
+	_, err = svc.Authenticate(context.Background(), "not a token")
+	assertStatus(t, http.StatusUnauthorized, err)
+
+	// nobody creates tokens for someone else, or tokens that have already expired
+	_, _, err = svc.CreateToken(ctx, &Token{UserID: uuid.New(), Name: "theirs"})
+	assertStatus(t, http.StatusForbidden, err)
+
+	_, _, err = svc.CreateToken(ctx, &Token{UserID: userID, Name: "old", ExpiresAt: time.Now().Add(-time.Minute)})
+	assertStatus(t, http.StatusBadRequest, err)
+}
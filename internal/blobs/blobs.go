@@ -0,0 +1,76 @@
+// Package blobs stores files, such as note attachments, outside of the database.
+package blobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/samber/do/v2"
+
+	"github.com/dabbertorres/notes/internal/config"
+)
+
+var Package = do.Package(
+	do.Lazy(NewStore),
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobStore stores opaque blobs by key. Keys are slash-separated paths, such as "attachments/<id>".
+type BlobStore interface {
+	// Put stores the contents of r as key, replacing any existing blob.
+	// size is the number of bytes r will produce, or -1 if it is unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob stored as key. If there is none, [ErrNotFound] is returned.
+	Get(ctx context.Context, key string) (Blob, error)
+	// Delete removes the blob stored as key, if there is one.
+	Delete(ctx context.Context, key string) error
+}
+
+// Blob is the contents of an open blob, which must be closed when no longer needed.
+type Blob interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+	Size() int64
+	ModTime() time.Time
+}
+
+// Store is the [BlobStore] selected by the configuration.
+type Store struct {
+	BlobStore
+}
+
+func NewStore(injector do.Injector) (*Store, error) {
+	ctx, err := do.Invoke[context.Context](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := do.Invoke[*config.Config](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	var store BlobStore
+	switch cfg.Blobs.Kind {
+	case config.BlobStoreLocal:
+		store, err = NewLocalStore(cfg.Blobs.Local.Dir)
+
+	case config.BlobStoreS3:
+		store, err = NewS3Store(ctx, cfg.Blobs.S3)
+
+	default:
+		err = fmt.Errorf("unknown blob store kind %q", cfg.Blobs.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{BlobStore: store}, nil
+}
@@ -0,0 +1,57 @@
+package blobs
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBlobStore checks the behaviour every [BlobStore] must have.
+func testBlobStore(t *testing.T, store BlobStore) {
+	ctx := context.Background()
+	const contents = "hello, attachments"
+
+	err := store.Put(ctx, "test/hello.txt", strings.NewReader(contents), int64(len(contents)), "text/plain")
+	require.NoError(t, err)
+
+	blob, err := store.Get(ctx, "test/hello.txt")
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(len(contents)), blob.Size())
+	assert.False(t, blob.ModTime().IsZero())
+
+	_, err = blob.Seek(7, io.SeekStart)
+	require.NoError(t, err)
+
+	rest, err := io.ReadAll(blob)
+	require.NoError(t, err)
+	assert.Equal(t, contents[7:], string(rest))
+	require.NoError(t, blob.Close())
+
+	// unknown size, and overwriting
+	err = store.Put(ctx, "test/hello.txt", strings.NewReader("replaced"), -1, "text/plain")
+	require.NoError(t, err)
+
+	blob, err = store.Get(ctx, "test/hello.txt")
+	require.NoError(t, err)
+
+	all, err := io.ReadAll(blob)
+	require.NoError(t, err)
+	assert.Equal(t, "replaced", string(all))
+	require.NoError(t, blob.Close())
+
+	require.NoError(t, store.Delete(ctx, "test/hello.txt"))
+	require.NoError(t, store.Delete(ctx, "test/hello.txt"))
+
+	_, err = store.Get(ctx, "test/hello.txt")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for _, key := range []string{"", ".", "../escape", "/absolute", "a/../../b"} {
+		err := store.Put(ctx, key, strings.NewReader(""), 0, "")
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,12 @@
+package blobs
+
+import "testing"
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testBlobStore(t, store)
+}
@@ -0,0 +1,31 @@
+package blobs
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/dabbertorres/notes/internal/config"
+)
+
+// TestS3Store runs against the object store from docker-compose.yml, when NOTES_TEST_S3_ENDPOINT is set
+// (e.g. to "localhost:9000").
+func TestS3Store(t *testing.T) {
+	endpoint := os.Getenv("NOTES_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("NOTES_TEST_S3_ENDPOINT is not set")
+	}
+
+	store, err := NewS3Store(context.Background(), config.S3Blobs{
+		Endpoint:  endpoint,
+		Bucket:    "notes-test",
+		AccessKey: "minioadmin",
+		SecretKey: "minioadmin",
+		Insecure:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testBlobStore(t, store)
+}
@@ -0,0 +1,130 @@
+package apiv1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/collab"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+// maxMessageSize limits how much an editor can send at once, e.g. when pasting.
+const maxMessageSize = 1 << 20
+
+type Service interface {
+	Join(ctx context.Context, noteID uuid.UUID) (*collab.Editor, *collab.Snapshot, error)
+	Leave(editor *collab.Editor)
+	Apply(ctx context.Context, editor *collab.Editor, ops []collab.Op) error
+	SetCursor(editor *collab.Editor, cursor collab.Cursor)
+}
+
+// EditNote upgrades to a WebSocket that syncs the body of a note between everyone editing it.
+//
+// The server first sends a snapshot of the document, and then every change made by someone else. The client sends
+// its own changes as it makes them, having already applied them locally. Invalid changes are answered with an error,
+// after which the client should reconnect to resync. The connection is closed if the client falls too far behind,
+// and should also be reopened.
+func EditNote(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		editor, snapshot, err := svc.Join(r.Context(), noteID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+		defer svc.Leave(editor)
+
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			// Accept has already responded
+			return
+		}
+		defer conn.CloseNow()
+
+		conn.SetReadLimit(maxMessageSize)
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		if err := wsjson.Write(ctx, conn, SnapshotFromDomain(snapshot)); err != nil {
+			return
+		}
+
+		go func() {
+			defer cancel()
+			readMessages(ctx, conn, svc, editor)
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case update, ok := <-editor.Updates():
+				if !ok {
+					conn.Close(websocket.StatusTryAgainLater, "resync required")
+					return
+				}
+
+				if err := wsjson.Write(ctx, conn, UpdateFromDomain(&update)); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// readMessages applies each message from the editor's connection until it is closed.
+func readMessages(ctx context.Context, conn *websocket.Conn, svc Service, editor *collab.Editor) {
+	for {
+		var msg ClientMessage
+		if err := wsjson.Read(ctx, conn, &msg); err != nil {
+			if websocket.CloseStatus(err) == -1 && ctx.Err() == nil {
+				log.Debug(ctx, "error reading editor message", zap.Uint32("site", editor.Site), zap.Error(err))
+			}
+			return
+		}
+
+		switch msg.Type {
+		case "ops":
+			ops, err := msg.ToDomain()
+			if err == nil {
+				err = svc.Apply(ctx, editor, ops)
+			}
+
+			if err != nil {
+				writeError(ctx, conn, err)
+			}
+
+		case "cursor":
+			if msg.Cursor == nil {
+				writeError(ctx, conn, errors.New("cursor is required"))
+				continue
+			}
+
+			svc.SetCursor(editor, msg.Cursor.ToDomain())
+
+		default:
+			writeError(ctx, conn, errors.New(`type must be one of "ops" or "cursor"`))
+		}
+	}
+}
+
+func writeError(ctx context.Context, conn *websocket.Conn, err error) {
+	_ = wsjson.Write(ctx, conn, ServerMessage{
+		Type:    "error",
+		Message: err.Error(),
+	})
+}
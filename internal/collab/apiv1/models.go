@@ -0,0 +1,206 @@
+package apiv1
+
+import (
+	"errors"
+
+	"github.com/dabbertorres/notes/internal/collab"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+// ClientMessage is sent by an editor. Type is "ops" or "cursor".
+type ClientMessage struct {
+	Type   string  `json:"type"`
+	Ops    []Op    `json:"ops,omitempty"`
+	Cursor *Cursor `json:"cursor,omitempty"`
+}
+
+// ServerMessage is sent to an editor. Type is "snapshot", "ops", "presence", "leave", "saved" or "error".
+//
+// A snapshot is sent first, with the editor's own Site, the Revision of the note it was read from, the whole
+// document as Runs, and the Presence of everyone else editing it.
+type ServerMessage struct {
+	Type     string     `json:"type"`
+	Site     uint32     `json:"site,omitempty"`
+	Revision int64      `json:"revision,omitempty"`
+	Runs     []Run      `json:"runs,omitempty"`
+	Ops      []Op       `json:"ops,omitempty"`
+	Presence []Presence `json:"presence,omitempty"`
+	Message  string     `json:"message,omitempty"`
+}
+
+type ID struct {
+	Seq  uint64 `json:"seq"`
+	Site uint32 `json:"site"`
+}
+
+func IDFromDomain(domain collab.ID) ID {
+	return ID{Seq: domain.Seq, Site: domain.Site}
+}
+
+func (id ID) ToDomain() collab.ID {
+	return collab.ID{Seq: id.Seq, Site: id.Site}
+}
+
+// Op is an "insert" of Text after the character After (or at the start if omitted), or a "delete" of the
+// character ID.
+type Op struct {
+	Type  string `json:"type"`
+	ID    ID     `json:"id"`
+	After *ID    `json:"after,omitempty"`
+	Text  string `json:"text,omitempty"`
+}
+
+func OpFromDomain(domain collab.Op) (o Op) {
+	o.ID = IDFromDomain(domain.ID)
+
+	switch domain.Kind {
+	case collab.OpInsert:
+		o.Type = "insert"
+		o.Text = domain.Text
+		if !domain.After.IsZero() {
+			after := IDFromDomain(domain.After)
+			o.After = &after
+		}
+
+	case collab.OpDelete:
+		o.Type = "delete"
+	}
+
+	return o
+}
+
+func (o *Op) ToDomain() (collab.Op, error) {
+	out := collab.Op{
+		ID:   o.ID.ToDomain(),
+		Text: o.Text,
+	}
+
+	switch o.Type {
+	case "insert":
+		out.Kind = collab.OpInsert
+		if o.After != nil {
+			out.After = o.After.ToDomain()
+		}
+
+		if o.Text == "" {
+			return out, &apiv1.InvalidFieldError{Field: ".text", Err: apiv1.ErrFieldNotSet.Error()}
+		}
+
+	case "delete":
+		out.Kind = collab.OpDelete
+
+	default:
+		return out, &apiv1.InvalidFieldError{Field: ".type", Err: `must be one of "insert" or "delete"`}
+	}
+
+	return out, nil
+}
+
+type Run struct {
+	ID      ID     `json:"id"`
+	Text    string `json:"text"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+func RunFromDomain(domain collab.Run) Run {
+	return Run{
+		ID:      IDFromDomain(domain.ID),
+		Text:    domain.Text,
+		Deleted: domain.Deleted,
+	}
+}
+
+// Cursor positions are just after the character with that ID, or at the start of the document if omitted.
+type Cursor struct {
+	Anchor *ID `json:"anchor,omitempty"`
+	Head   *ID `json:"head,omitempty"`
+}
+
+func CursorFromDomain(domain collab.Cursor) (c Cursor) {
+	if !domain.Anchor.IsZero() {
+		anchor := IDFromDomain(domain.Anchor)
+		c.Anchor = &anchor
+	}
+
+	if !domain.Head.IsZero() {
+		head := IDFromDomain(domain.Head)
+		c.Head = &head
+	}
+
+	return c
+}
+
+func (c *Cursor) ToDomain() (out collab.Cursor) {
+	if c.Anchor != nil {
+		out.Anchor = c.Anchor.ToDomain()
+	}
+
+	if c.Head != nil {
+		out.Head = c.Head.ToDomain()
+	}
+
+	return out
+}
+
+type Presence struct {
+	Site   uint32 `json:"site"`
+	UserID string `json:"user_id"`
+	Cursor Cursor `json:"cursor"`
+}
+
+func PresenceFromDomain(domain collab.Presence) Presence {
+	return Presence{
+		Site:   domain.Site,
+		UserID: domain.User.ID.String(),
+		Cursor: CursorFromDomain(domain.Cursor),
+	}
+}
+
+func SnapshotFromDomain(domain *collab.Snapshot) ServerMessage {
+	return ServerMessage{
+		Type:     "snapshot",
+		Site:     domain.Site,
+		Revision: domain.Revision,
+		Runs:     util.MapSlice(domain.Runs, RunFromDomain),
+		Presence: util.MapSlice(domain.Presence, PresenceFromDomain),
+	}
+}
+
+func UpdateFromDomain(domain *collab.Update) (m ServerMessage) {
+	m.Site = domain.Site
+
+	switch domain.Kind {
+	case collab.UpdateOps:
+		m.Type = "ops"
+		m.Ops = util.MapSlice(domain.Ops, OpFromDomain)
+
+	case collab.UpdatePresence:
+		m.Type = "presence"
+		m.Presence = []Presence{PresenceFromDomain(domain.Presence)}
+
+	case collab.UpdateLeave:
+		m.Type = "leave"
+
+	case collab.UpdateSaved:
+		m.Type = "saved"
+		m.Revision = domain.Revision
+	}
+
+	return m
+}
+
+// ToDomain validates the ops of an "ops" message.
+func (m *ClientMessage) ToDomain() ([]collab.Op, error) {
+	var errs []error
+
+	ops := apiv1.ValidateSlice(".ops", m.Ops, &errs, func(op Op) (collab.Op, error) {
+		return op.ToDomain()
+	})
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return ops, nil
+}
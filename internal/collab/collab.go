@@ -0,0 +1,8 @@
+// Package collab lets several users edit the body of a note at the same time, by syncing a CRDT between them.
+package collab
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewHub),
+)
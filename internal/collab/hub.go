@@ -0,0 +1,478 @@
+package collab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/scope"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+const (
+	flushInterval        = 5 * time.Second
+	shutdownFlushTimeout = 10 * time.Second
+	editorBuffer         = 256
+	// serverSite inserts the note's body when a session starts, and any changes saved to it outside of the session.
+	serverSite = 0
+)
+
+// NoteStore reads and saves notes on behalf of the user in the context.
+type NoteStore interface {
+	GetNote(ctx context.Context, noteID uuid.UUID) (*notes.Note, error)
+	SaveNoteBody(ctx context.Context, noteID uuid.UUID, body string, revision int64) (*notes.Note, error)
+}
+
+// NoteAccess reports a user's access to a note. Viewers may follow a note's document, and editors may change it.
+type NoteAccess interface {
+	GetUsersNoteAccess(ctx context.Context, noteID, userID uuid.UUID) (users.AccessLevel, error)
+}
+
+// Hub holds a session for each note being edited on this server, and periodically saves their documents to their
+// notes. Changes saved to a note by other means, including by a session on another server, are merged into its
+// session's document when it is next saved. Access to a note is checked as each editor joins, as they make each
+// change, and again by saving as the last user to change it.
+type Hub struct {
+	notes  NoteStore
+	access NoteAccess
+
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*session
+}
+
+// session is the document of one note, and everyone editing it.
+type session struct {
+	noteID uuid.UUID
+
+	mu       sync.Mutex
+	doc      *Document
+	editors  map[uint32]*Editor
+	presence map[uint32]Presence
+	nextSite uint32
+	// closed is set once the session is removed from the hub, after which editors must join a new one.
+	closed bool
+
+	// version counts the changes made by editors, and savedVersion is the version last saved.
+	version      uint64
+	savedVersion uint64
+	// base is the IDs of the characters of the note's body as of baseRevision, the last revision saved or merged.
+	base         []ID
+	baseRevision int64
+	// lastEditor is who changes are saved as.
+	lastEditor uuid.UUID
+}
+
+func NewHub(injector do.Injector) (*Hub, error) {
+	notes, err := do.InvokeAs[NoteStore](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := do.InvokeAs[NoteAccess](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Hub{
+		notes:    notes,
+		access:   access,
+		sessions: make(map[uuid.UUID]*session),
+	}, nil
+}
+
+// Join starts editing a note as the user in ctx, and returns the document as it is now.
+// [Hub.Leave] must be called once the editor is done.
+func (h *Hub) Join(ctx context.Context, noteID uuid.UUID) (*Editor, *Snapshot, error) {
+	userID := scope.MustUserID(ctx)
+
+	access, err := h.access.GetUsersNoteAccess(ctx, noteID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < users.AccessLevelViewer {
+		return nil, nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	var sess *session
+	for {
+		if sess, err = h.session(ctx, noteID); err != nil {
+			return nil, nil, err
+		}
+
+		sess.mu.Lock()
+		if !sess.closed {
+			break
+		}
+
+		// it ended between finding it and locking it
+		sess.mu.Unlock()
+	}
+	defer sess.mu.Unlock()
+
+	sess.nextSite++
+	editor := &Editor{
+		Site:    sess.nextSite,
+		UserID:  userID,
+		CanEdit: access >= users.AccessLevelEditor,
+		session: sess,
+		updates: make(chan Update, editorBuffer),
+	}
+
+	snapshot := &Snapshot{
+		Site:     editor.Site,
+		Revision: sess.baseRevision,
+		Runs:     sess.doc.Runs(),
+		Presence: make([]Presence, 0, len(sess.presence)),
+	}
+
+	for _, presence := range sess.presence {
+		snapshot.Presence = append(snapshot.Presence, presence)
+	}
+
+	presence := Presence{
+		Site: editor.Site,
+		User: users.User{ID: userID},
+	}
+
+	sess.editors[editor.Site] = editor
+	sess.presence[editor.Site] = presence
+	sess.broadcast(Update{Kind: UpdatePresence, Site: editor.Site, Presence: presence}, editor)
+
+	return editor, snapshot, nil
+}
+
+// session returns the session for a note, starting one if there isn't one yet.
+func (h *Hub) session(ctx context.Context, noteID uuid.UUID) (*session, error) {
+	h.mu.Lock()
+	sess, ok := h.sessions[noteID]
+	h.mu.Unlock()
+
+	if ok {
+		return sess, nil
+	}
+
+	note, err := h.notes.GetNote(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// someone else may have started it in the meantime
+	if sess, ok := h.sessions[noteID]; ok {
+		return sess, nil
+	}
+
+	doc := NewDocument(note.Body)
+	sess = &session{
+		noteID:       noteID,
+		doc:          doc,
+		editors:      make(map[uint32]*Editor),
+		presence:     make(map[uint32]Presence),
+		nextSite:     serverSite,
+		base:         doc.VisibleIDs(),
+		baseRevision: note.Revision,
+	}
+
+	h.sessions[noteID] = sess
+	return sess, nil
+}
+
+// Leave stops editor from editing. Its changes are still saved.
+func (h *Hub) Leave(editor *Editor) {
+	sess := editor.session
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if sess.remove(editor) {
+		sess.broadcast(Update{Kind: UpdateLeave, Site: editor.Site}, nil)
+	}
+}
+
+// Apply applies ops made by editor, and sends them to everyone else editing the note.
+// If one of ops is invalid, those before it are still applied, and an error is returned.
+//
+// The editor's access is checked again first, since it may have changed since they joined. If they can no longer
+// see the note, they are disconnected.
+func (h *Hub) Apply(ctx context.Context, editor *Editor, ops []Op) error {
+	sess := editor.session
+
+	access, err := h.access.GetUsersNoteAccess(ctx, sess.noteID, editor.UserID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", sess.noteID), zap.Error(err))
+		return apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	editor.CanEdit = access >= users.AccessLevelEditor
+
+	if access < users.AccessLevelViewer {
+		h.Leave(editor)
+		return ErrReadOnly
+	}
+
+	if !editor.CanEdit {
+		return ErrReadOnly
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	var applied int
+
+	for _, op := range ops {
+		if op.Kind == OpInsert && op.ID.Site != editor.Site {
+			err = fmt.Errorf("%w: insert must be from site %d", ErrInvalidOp, editor.Site)
+			break
+		}
+
+		if err = sess.doc.Apply(op); err != nil {
+			break
+		}
+
+		applied++
+	}
+
+	if applied != 0 {
+		sess.version++
+		sess.lastEditor = editor.UserID
+		sess.broadcast(Update{Kind: UpdateOps, Site: editor.Site, Ops: ops[:applied]}, editor)
+	}
+
+	return err
+}
+
+// SetCursor moves editor's cursor, and shows it to everyone else editing the note.
+func (h *Hub) SetCursor(editor *Editor, cursor Cursor) {
+	sess := editor.session
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	presence, ok := sess.presence[editor.Site]
+	if !ok {
+		return
+	}
+
+	presence.Cursor = cursor
+	sess.presence[editor.Site] = presence
+	sess.broadcast(Update{Kind: UpdatePresence, Site: editor.Site, Presence: presence}, editor)
+}
+
+// Run saves each session periodically until ctx is cancelled, and then saves them all one last time and disconnects
+// their editors.
+func (h *Hub) Run(ctx context.Context) error {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownFlushTimeout)
+			defer cancel()
+
+			h.flushAll(shutdownCtx, true)
+			return nil
+
+		case <-ticker.C:
+			h.flushAll(ctx, false)
+		}
+	}
+}
+
+// flushAll saves every session, and ends those that nobody is editing anymore, or all of them if closing.
+func (h *Hub) flushAll(ctx context.Context, closing bool) {
+	h.mu.Lock()
+	sessions := make([]*session, 0, len(h.sessions))
+	for _, sess := range h.sessions {
+		sessions = append(sessions, sess)
+	}
+	h.mu.Unlock()
+
+	for _, sess := range sessions {
+		if err := h.flush(ctx, sess); err != nil {
+			log.Error(ctx, "error saving collaborative edits", zap.Stringer("note_id", sess.noteID), zap.Error(err))
+			if !closing {
+				continue
+			}
+		}
+
+		h.mu.Lock()
+		sess.mu.Lock()
+
+		if closing {
+			for _, editor := range sess.editors {
+				sess.remove(editor)
+			}
+		}
+
+		if len(sess.editors) == 0 && sess.version == sess.savedVersion {
+			delete(h.sessions, sess.noteID)
+			sess.closed = true
+		}
+
+		sess.mu.Unlock()
+		h.mu.Unlock()
+	}
+}
+
+// flush merges any changes saved to the session's note since it was last flushed into its document, and then saves
+// its document to the note if it has changed.
+func (h *Hub) flush(ctx context.Context, sess *session) error {
+	sess.mu.Lock()
+	userID := sess.lastEditor
+	if userID == uuid.Nil {
+		for _, editor := range sess.editors {
+			userID = editor.UserID
+			break
+		}
+	}
+	sess.mu.Unlock()
+
+	if userID == uuid.Nil {
+		// nobody has edited it, and nobody is left to notice changes to it
+		return nil
+	}
+
+	ctx = scope.WithUserID(ctx, userID)
+
+	note, err := h.notes.GetNote(ctx, sess.noteID)
+	if err != nil {
+		return h.flushFailed(sess, userID, err)
+	}
+
+	sess.mu.Lock()
+
+	if note.Revision != sess.baseRevision {
+		ops := sess.doc.Diff(sess.base, note.Body, serverSite)
+		for _, op := range ops {
+			if err := sess.doc.Apply(op); err != nil {
+				sess.mu.Unlock()
+				return err
+			}
+		}
+
+		if len(ops) != 0 {
+			sess.broadcast(Update{Kind: UpdateOps, Site: serverSite, Ops: ops}, nil)
+		}
+
+		sess.base = sess.doc.VisibleIDs()
+		sess.baseRevision = note.Revision
+	}
+
+	version := sess.version
+	body := sess.doc.Text()
+	ids := sess.doc.VisibleIDs()
+
+	if body == note.Body {
+		sess.savedVersion = version
+		sess.base = ids
+	}
+
+	if version == sess.savedVersion {
+		sess.mu.Unlock()
+		return nil
+	}
+
+	sess.mu.Unlock()
+
+	saved, err := h.notes.SaveNoteBody(ctx, sess.noteID, body, note.Revision)
+	if err != nil {
+		return h.flushFailed(sess, userID, err)
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	sess.savedVersion = version
+	sess.base = ids
+	sess.baseRevision = saved.Revision
+	sess.broadcast(Update{Kind: UpdateSaved, Revision: saved.Revision}, nil)
+
+	return nil
+}
+
+// flushFailed handles an error reading or saving the session's note as userID, and returns it if it needs reporting.
+func (h *Hub) flushFailed(sess *session, userID uuid.UUID, err error) error {
+	var apiErr apiv1.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	switch apiErr.Status() {
+	case http.StatusConflict:
+		// saved by someone else just now: their changes are merged next time
+		return nil
+
+	case http.StatusForbidden:
+		// no longer allowed to edit: save as whoever edits next instead
+		if sess.lastEditor == userID {
+			sess.lastEditor = uuid.Nil
+		}
+		return err
+
+	case http.StatusNotFound:
+		// the note was deleted, so there's nothing left to edit
+		for _, editor := range sess.editors {
+			sess.remove(editor)
+		}
+		sess.savedVersion = sess.version
+		return nil
+
+	default:
+		return err
+	}
+}
+
+// broadcast sends update to every editor except one. Editors that have fallen too far behind are disconnected.
+// The session must be locked.
+func (s *session) broadcast(update Update, except *Editor) {
+	var dropped []*Editor
+
+	for _, editor := range s.editors {
+		if editor == except {
+			continue
+		}
+
+		select {
+		case editor.updates <- update:
+		default:
+			if s.remove(editor) {
+				dropped = append(dropped, editor)
+			}
+		}
+	}
+
+	// there's no need to tell a dropped editor it's behind: it notices it has been disconnected
+	for _, editor := range dropped {
+		s.broadcast(Update{Kind: UpdateLeave, Site: editor.Site}, nil)
+	}
+}
+
+// remove disconnects editor, and reports whether it was still connected. The session must be locked.
+func (s *session) remove(editor *Editor) bool {
+	if _, ok := s.editors[editor.Site]; !ok {
+		return false
+	}
+
+	delete(s.editors, editor.Site)
+	delete(s.presence, editor.Site)
+	close(editor.updates)
+	return true
+}
@@ -0,0 +1,113 @@
+package collab
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/scope"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// memoryNotes is a single note, and each user's access to it.
+type memoryNotes struct {
+	mu     sync.Mutex
+	note   notes.Note
+	access map[uuid.UUID]users.AccessLevel
+}
+
+func (m *memoryNotes) GetNote(ctx context.Context, noteID uuid.UUID) (*notes.Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	note := m.note
+	return &note, nil
+}
+
+func (m *memoryNotes) SaveNoteBody(ctx context.Context, noteID uuid.UUID, body string, revision int64) (*notes.Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.note.Body = body
+	m.note.Revision++
+	note := m.note
+	return &note, nil
+}
+
+func (m *memoryNotes) GetUsersNoteAccess(ctx context.Context, noteID, userID uuid.UUID) (users.AccessLevel, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.access[userID], nil
+}
+
+func (m *memoryNotes) setAccess(userID uuid.UUID, level users.AccessLevel) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.access[userID] = level
+}
+
+func newTestHub(userID uuid.UUID, level users.AccessLevel) (*Hub, *memoryNotes) {
+	store := &memoryNotes{
+		note:   notes.Note{ID: uuid.New(), Body: "abc", Revision: 1},
+		access: map[uuid.UUID]users.AccessLevel{userID: level},
+	}
+
+	return &Hub{
+		notes:    store,
+		access:   store,
+		sessions: make(map[uuid.UUID]*session),
+	}, store
+}
+
+func TestHub_ApplyRechecksAccess(t *testing.T) {
+	userID := uuid.New()
+	hub, store := newTestHub(userID, users.AccessLevelEditor)
+	ctx := scope.WithUserID(context.Background(), userID)
+
+	editor, snapshot, err := hub.Join(ctx, store.note.ID)
+	require.NoError(t, err)
+	defer hub.Leave(editor)
+
+	insert := func(seq uint64) []Op {
+		return []Op{{Kind: OpInsert, ID: ID{Seq: seq, Site: snapshot.Site}, After: ID{Seq: 3}, Text: "d"}}
+	}
+
+	require.NoError(t, hub.Apply(ctx, editor, insert(4)))
+
+	store.setAccess(userID, users.AccessLevelViewer)
+	assert.ErrorIs(t, hub.Apply(ctx, editor, insert(5)), ErrReadOnly)
+	assert.False(t, editor.CanEdit)
+
+	store.setAccess(userID, users.AccessLevelNone)
+	assert.ErrorIs(t, hub.Apply(ctx, editor, insert(5)), ErrReadOnly)
+
+	_, ok := <-editor.Updates()
+	assert.False(t, ok, "editor that can no longer see the note should be disconnected")
+}
+
+func TestHub_JoinAfterSessionEnds(t *testing.T) {
+	userID := uuid.New()
+	hub, store := newTestHub(userID, users.AccessLevelEditor)
+	ctx := scope.WithUserID(context.Background(), userID)
+
+	editor, _, err := hub.Join(ctx, store.note.ID)
+	require.NoError(t, err)
+
+	ended := editor.session
+	hub.Leave(editor)
+	hub.flushAll(ctx, false)
+
+	assert.True(t, ended.closed)
+	assert.Empty(t, hub.sessions)
+
+	// an editor that found it before it was removed notices it's closed, and joins a new one like this
+	editor, _, err = hub.Join(ctx, store.note.ID)
+	require.NoError(t, err)
+	defer hub.Leave(editor)
+
+	assert.NotSame(t, ended, editor.session)
+	assert.False(t, editor.session.closed)
+}
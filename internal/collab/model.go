@@ -0,0 +1,71 @@
+package collab
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+var ErrReadOnly = errors.New("only editors may change the note")
+
+// Cursor is an editor's selection, from Anchor to Head. Each refers to the position just after that character,
+// or to the start of the document if zero, so that it stays in place as the text around it changes.
+type Cursor struct {
+	Anchor ID
+	Head   ID
+}
+
+// Presence is where another editor of a note is.
+type Presence struct {
+	Site   uint32
+	User   users.User
+	Cursor Cursor
+}
+
+// Snapshot is the state of a note's document when an editor joins it.
+type Snapshot struct {
+	// Site identifies the joining editor's insertions.
+	Site     uint32
+	Revision int64
+	Runs     []Run
+	Presence []Presence
+}
+
+type UpdateKind byte
+
+const (
+	// UpdateOps are Ops applied by Site, or by the server itself if Site is 0.
+	UpdateOps UpdateKind = iota
+	// UpdatePresence is an editor joining, or moving their cursor.
+	UpdatePresence
+	// UpdateLeave is Site leaving.
+	UpdateLeave
+	// UpdateSaved is the document being saved as Revision of its note.
+	UpdateSaved
+)
+
+// Update is a change to a note's document or its editors, sent to each of its other editors.
+type Update struct {
+	Kind     UpdateKind
+	Site     uint32
+	Ops      []Op
+	Presence Presence
+	Revision int64
+}
+
+// Editor is one user's connection to a note's document.
+type Editor struct {
+	Site   uint32
+	UserID uuid.UUID
+	// CanEdit is whether the editor could change the note, as of joining it or their latest change.
+	CanEdit bool
+
+	session *session
+	updates chan Update
+}
+
+// Updates is closed if the editor falls too far behind, or when the server shuts down.
+// Either way, the editor should rejoin to get a fresh [Snapshot].
+func (e *Editor) Updates() <-chan Update { return e.updates }
@@ -0,0 +1,293 @@
+package collab
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ID identifies a character of a [Document] across every copy of it.
+// Each site numbers the characters it inserts with increasing sequence numbers.
+type ID struct {
+	Seq  uint64
+	Site uint32
+}
+
+// IsZero reports whether id is the zero ID, which refers to the start of the document.
+func (id ID) IsZero() bool { return id == ID{} }
+
+// Compare orders IDs by sequence number, then by site.
+func (id ID) Compare(other ID) int {
+	switch {
+	case id.Seq < other.Seq:
+		return -1
+	case id.Seq > other.Seq:
+		return 1
+	case id.Site < other.Site:
+		return -1
+	case id.Site > other.Site:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (id ID) String() string { return fmt.Sprintf("%d@%d", id.Seq, id.Site) }
+
+type OpKind byte
+
+const (
+	// OpInsert inserts Text after the character After, or at the start if After is zero.
+	// Each character of Text has the ID following that of the previous one, starting from ID.
+	OpInsert OpKind = iota
+	// OpDelete deletes the character ID.
+	OpDelete
+)
+
+// Op is an edit to a [Document].
+type Op struct {
+	Kind  OpKind
+	ID    ID
+	After ID
+	Text  string
+}
+
+// Run is consecutive characters of a [Document], inserted one after another by the same site.
+// Its characters have consecutive IDs, starting from ID.
+type Run struct {
+	ID      ID
+	Text    string
+	Deleted bool
+}
+
+// maxSeqAhead is how far ahead of a document's clock an inserted character's sequence number may be. A site's
+// inserts only get ahead of the clock by as many characters as it has inserted that have yet to be applied, so
+// anything further ahead would only use up sequence numbers for everyone else.
+const maxSeqAhead = 1 << 20
+
+var (
+	ErrUnknownID   = errors.New("unknown character id")
+	ErrDuplicateID = errors.New("duplicate character id")
+	ErrInvalidOp   = errors.New("invalid op")
+)
+
+// Document is a text CRDT: a replicated growable array (RGA) of characters.
+//
+// Every copy of a document converges to the same text once they have applied the same ops, in any order that
+// respects their causality. For that to hold, a character must be inserted with a greater sequence number than the
+// character it is inserted after. Deleted characters are kept as tombstones, so that ops may still refer to them.
+//
+// A Document is not safe for concurrent use.
+type Document struct {
+	head  node
+	nodes map[ID]*node
+	clock uint64
+}
+
+type node struct {
+	id      ID
+	value   rune
+	deleted bool
+	next    *node
+}
+
+// NewDocument creates a document containing text, inserted by site 0.
+func NewDocument(text string) *Document {
+	doc := &Document{
+		nodes: make(map[ID]*node),
+	}
+
+	if text != "" {
+		// a freshly seeded document can't conflict with anything, so this can't fail
+		_ = doc.Apply(Op{Kind: OpInsert, ID: ID{Seq: 1}, Text: text})
+	}
+
+	return doc
+}
+
+// Clock is the greatest sequence number of any character in the document.
+func (d *Document) Clock() uint64 { return d.clock }
+
+// Apply applies op, or returns an error, without changing the document, if it is invalid.
+func (d *Document) Apply(op Op) error {
+	switch op.Kind {
+	case OpInsert:
+		return d.insert(op)
+
+	case OpDelete:
+		n, ok := d.nodes[op.ID]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownID, op.ID)
+		}
+
+		n.deleted = true
+		return nil
+
+	default:
+		return fmt.Errorf("%w: unknown kind %d", ErrInvalidOp, op.Kind)
+	}
+}
+
+func (d *Document) insert(op Op) error {
+	if op.Text == "" || !utf8.ValidString(op.Text) {
+		return fmt.Errorf("%w: text must be non-empty UTF-8", ErrInvalidOp)
+	}
+
+	if op.ID.Seq <= op.After.Seq {
+		return fmt.Errorf("%w: %s must follow %s", ErrInvalidOp, op.ID, op.After)
+	}
+
+	if op.ID.Seq > d.clock+maxSeqAhead {
+		return fmt.Errorf("%w: %s is too far ahead of the clock %d", ErrInvalidOp, op.ID, d.clock)
+	}
+
+	prev := &d.head
+	if !op.After.IsZero() {
+		var ok bool
+		if prev, ok = d.nodes[op.After]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownID, op.After)
+		}
+	}
+
+	count := uint64(utf8.RuneCountInString(op.Text))
+	for i := range count {
+		id := ID{Seq: op.ID.Seq + i, Site: op.ID.Site}
+		if _, ok := d.nodes[id]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateID, id)
+		}
+	}
+
+	id := op.ID
+	for _, r := range op.Text {
+		// concurrent inserts after the same character are ordered greatest first; anything inserted after one of
+		// them has a greater ID still, so is skipped along with it
+		for prev.next != nil && prev.next.id.Compare(id) > 0 {
+			prev = prev.next
+		}
+
+		n := &node{
+			id:    id,
+			value: r,
+			next:  prev.next,
+		}
+		prev.next = n
+		d.nodes[id] = n
+
+		prev = n
+		id.Seq++
+	}
+
+	d.clock = max(d.clock, id.Seq-1)
+	return nil
+}
+
+// Text returns the text of the document, without deleted characters.
+func (d *Document) Text() string {
+	var sb strings.Builder
+	for n := d.head.next; n != nil; n = n.next {
+		if !n.deleted {
+			sb.WriteRune(n.value)
+		}
+	}
+
+	return sb.String()
+}
+
+// VisibleIDs returns the IDs of each character of [Document.Text], in order.
+func (d *Document) VisibleIDs() []ID {
+	var ids []ID
+	for n := d.head.next; n != nil; n = n.next {
+		if !n.deleted {
+			ids = append(ids, n.id)
+		}
+	}
+
+	return ids
+}
+
+// Values returns the characters with each of ids, whether or not they have been deleted.
+func (d *Document) Values(ids []ID) []rune {
+	values := make([]rune, len(ids))
+	for i, id := range ids {
+		values[i] = d.nodes[id].value
+	}
+
+	return values
+}
+
+// Runs returns the entire document, including deleted characters, in as few runs as possible.
+// Inserting each run after the previous one recreates the document.
+func (d *Document) Runs() []Run {
+	var (
+		runs []Run
+		sb   strings.Builder
+		last *node
+	)
+
+	flush := func() {
+		if sb.Len() != 0 {
+			runs[len(runs)-1].Text = sb.String()
+			sb.Reset()
+		}
+	}
+
+	for n := d.head.next; n != nil; n = n.next {
+		continues := last != nil &&
+			n.id.Site == last.id.Site &&
+			n.id.Seq == last.id.Seq+1 &&
+			n.deleted == last.deleted
+
+		if !continues {
+			flush()
+			runs = append(runs, Run{ID: n.id, Deleted: n.deleted})
+		}
+
+		sb.WriteRune(n.value)
+		last = n
+	}
+
+	flush()
+	return runs
+}
+
+// Diff returns the ops that change the characters with ids, which must all be in the document, into text.
+// Inserted characters belong to site, and follow the document's clock.
+//
+// This only finds the single range of ids that differs, between their common prefix and suffix.
+func (d *Document) Diff(ids []ID, text string, site uint32) []Op {
+	from := d.Values(ids)
+	to := []rune(text)
+
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+
+	var ops []Op
+
+	for _, id := range ids[prefix : len(ids)-suffix] {
+		ops = append(ops, Op{Kind: OpDelete, ID: id})
+	}
+
+	if inserted := to[prefix : len(to)-suffix]; len(inserted) != 0 {
+		var after ID
+		if prefix > 0 {
+			after = ids[prefix-1]
+		}
+
+		ops = append(ops, Op{
+			Kind:  OpInsert,
+			ID:    ID{Seq: max(d.clock, after.Seq) + 1, Site: site},
+			After: after,
+			Text:  string(inserted),
+		})
+	}
+
+	return ops
+}
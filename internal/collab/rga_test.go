@@ -0,0 +1,106 @@
+package collab
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument_ConcurrentInsertsConverge(t *testing.T) {
+	// both sites insert at the same place without seeing each other's edits,
+	// and then one continues typing after its own insert
+	ops := []Op{
+		{Kind: OpInsert, ID: ID{Seq: 4, Site: 1}, After: ID{Seq: 2}, Text: "XY"},
+		{Kind: OpInsert, ID: ID{Seq: 4, Site: 2}, After: ID{Seq: 2}, Text: "Q"},
+		{Kind: OpInsert, ID: ID{Seq: 6, Site: 1}, After: ID{Seq: 5, Site: 1}, Text: "Z"},
+		{Kind: OpDelete, ID: ID{Seq: 3}},
+	}
+
+	orders := [][]int{
+		{0, 1, 2, 3},
+		{1, 0, 2, 3},
+		{0, 2, 1, 3},
+		{3, 1, 0, 2},
+	}
+
+	var want string
+	for i, order := range orders {
+		doc := NewDocument("abc")
+		for _, j := range order {
+			require.NoError(t, doc.Apply(ops[j]))
+		}
+
+		if i == 0 {
+			want = doc.Text()
+			continue
+		}
+
+		assert.Equal(t, want, doc.Text(), "order %v", order)
+	}
+
+	assert.Equal(t, "abQXYZ", want)
+}
+
+func TestDocument_RejectsInvalidOps(t *testing.T) {
+	doc := NewDocument("abc")
+
+	err := doc.Apply(Op{Kind: OpInsert, ID: ID{Seq: 9, Site: 1}, After: ID{Seq: 4}, Text: "x"})
+	assert.ErrorIs(t, err, ErrUnknownID)
+
+	err = doc.Apply(Op{Kind: OpInsert, ID: ID{Seq: 2, Site: 1}, After: ID{Seq: 3}, Text: "x"})
+	assert.ErrorIs(t, err, ErrInvalidOp)
+
+	err = doc.Apply(Op{Kind: OpInsert, ID: ID{Seq: 2}, Text: "x"})
+	assert.ErrorIs(t, err, ErrDuplicateID)
+
+	err = doc.Apply(Op{Kind: OpDelete, ID: ID{Seq: 7, Site: 3}})
+	assert.ErrorIs(t, err, ErrUnknownID)
+
+	err = doc.Apply(Op{Kind: OpInsert, ID: ID{Seq: doc.Clock() + maxSeqAhead + 1, Site: 1}, After: ID{Seq: 3}, Text: "x"})
+	assert.ErrorIs(t, err, ErrInvalidOp)
+
+	assert.Equal(t, "abc", doc.Text())
+}
+
+func TestDocument_Runs(t *testing.T) {
+	doc := NewDocument("hello")
+	require.NoError(t, doc.Apply(Op{Kind: OpDelete, ID: ID{Seq: 5}}))
+	require.NoError(t, doc.Apply(Op{Kind: OpInsert, ID: ID{Seq: 6, Site: 1}, After: ID{Seq: 5}, Text: "p!"}))
+
+	runs := doc.Runs()
+	assert.Equal(t, []Run{
+		{ID: ID{Seq: 1}, Text: "hell"},
+		{ID: ID{Seq: 5}, Text: "o", Deleted: true},
+		{ID: ID{Seq: 6, Site: 1}, Text: "p!"},
+	}, runs)
+
+	copied := NewDocument("")
+	var after ID
+	for _, run := range runs {
+		require.NoError(t, copied.Apply(Op{Kind: OpInsert, ID: run.ID, After: after, Text: run.Text}))
+
+		for i := range len([]rune(run.Text)) {
+			after = ID{Seq: run.ID.Seq + uint64(i), Site: run.ID.Site}
+			if run.Deleted {
+				require.NoError(t, copied.Apply(Op{Kind: OpDelete, ID: after}))
+			}
+		}
+	}
+
+	assert.Equal(t, "hellp!", copied.Text())
+}
+
+func TestDocument_Diff(t *testing.T) {
+	doc := NewDocument("the quick fox")
+	base := doc.VisibleIDs()
+
+	// a local edit made since base
+	require.NoError(t, doc.Apply(Op{Kind: OpInsert, ID: ID{Seq: 14, Site: 1}, After: ID{Seq: 13}, Text: " jumps"}))
+
+	for _, op := range doc.Diff(base, "the slow brown fox", 0) {
+		require.NoError(t, doc.Apply(op))
+	}
+
+	assert.Equal(t, "the slow brown fox jumps", doc.Text())
+}
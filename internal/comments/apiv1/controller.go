@@ -0,0 +1,278 @@
+package apiv1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/comments"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+type Service interface {
+	CreateThread(ctx context.Context, noteID uuid.UUID, anchor *comments.Anchor, body string) (*comments.Thread, error)
+	AddComment(ctx context.Context, noteID, threadID uuid.UUID, body string) (*comments.Comment, error)
+	UpdateComment(ctx context.Context, noteID, threadID, commentID uuid.UUID, body string) (*comments.Comment, error)
+	DeleteComment(ctx context.Context, noteID, threadID, commentID uuid.UUID) error
+	SetThreadResolved(ctx context.Context, noteID, threadID uuid.UUID, resolved bool) (*comments.Thread, error)
+	GetThread(ctx context.Context, noteID, threadID uuid.UUID) (*comments.Thread, error)
+	ListThreads(ctx context.Context, noteID uuid.UUID, params comments.ThreadListParams, pageSize int) (results []comments.Thread, next *comments.ThreadListParams, err error)
+}
+
+func PostThread(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[NewThread](w, r)
+		if !ok {
+			return
+		}
+
+		anchor, text, err := body.ToDomain()
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		thread, err := svc.CreateThread(r.Context(), noteID, anchor, text)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := ThreadFromDomain(thread)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+// PutThread resolves, or unresolves, a thread.
+func PutThread(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, threadID, err := parseThreadPath(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[WritableThread](w, r)
+		if !ok {
+			return
+		}
+
+		thread, err := svc.SetThreadResolved(r.Context(), noteID, threadID, body.Resolved)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := ThreadFromDomain(thread)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+func GetThread(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, threadID, err := parseThreadPath(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		thread, err := svc.GetThread(r.Context(), noteID, threadID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := ThreadFromDomain(thread)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+func ListThreads(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		paging, err := parseListThreadsParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		params := comments.ThreadListParams{
+			Resolved:     paging.Data.Resolved,
+			LastThreadID: paging.Data.LastThreadID,
+		}
+		results, next, err := svc.ListThreads(r.Context(), noteID, params, paging.PageSize)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		page := apiv1.Page[Thread, *ListThreadsPageTokenData]{
+			NextPageToken: nil,
+			Items: util.MapSlice(results, func(thread comments.Thread) Thread {
+				return ThreadFromDomain(&thread)
+			}),
+		}
+
+		if next != nil {
+			page.NextPageToken = &apiv1.PageToken[*ListThreadsPageTokenData]{
+				Data: &ListThreadsPageTokenData{
+					Resolved:     next.Resolved,
+					LastThreadID: next.LastThreadID,
+				},
+				PageSize: paging.PageSize,
+			}
+		}
+
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, page)
+	}
+}
+
+// PostComment replies to a thread.
+func PostComment(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, threadID, err := parseThreadPath(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[WritableComment](w, r)
+		if !ok {
+			return
+		}
+
+		if err := body.Validate(); err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		comment, err := svc.AddComment(r.Context(), noteID, threadID, body.Body)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := CommentFromDomain(comment)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+func PutComment(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, threadID, err := parseThreadPath(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		commentID, err := apiv1.ParsePathValue(r, "comment_id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid comment id"))
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[WritableComment](w, r)
+		if !ok {
+			return
+		}
+
+		if err := body.Validate(); err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		comment, err := svc.UpdateComment(r.Context(), noteID, threadID, commentID, body.Body)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := CommentFromDomain(comment)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+func DeleteComment(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, threadID, err := parseThreadPath(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		commentID, err := apiv1.ParsePathValue(r, "comment_id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid comment id"))
+			return
+		}
+
+		if err := svc.DeleteComment(r.Context(), noteID, threadID, commentID); err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func parseThreadPath(r *http.Request) (noteID, threadID uuid.UUID, err error) {
+	noteID, err = apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+	if err != nil {
+		return noteID, threadID, apiv1.NewError(http.StatusBadRequest, "invalid note id")
+	}
+
+	threadID, err = apiv1.ParsePathValue(r, "thread_id", true, uuid.Parse)
+	if err != nil {
+		return noteID, threadID, apiv1.NewError(http.StatusBadRequest, "invalid thread id")
+	}
+
+	return noteID, threadID, nil
+}
+
+func parseListThreadsParams(r *http.Request) (token apiv1.PageToken[*ListThreadsPageTokenData], err error) {
+	rawPageToken := r.FormValue("next_page_token")
+	if rawPageToken != "" {
+		pageToken, err := apiv1.ParsePageToken[*ListThreadsPageTokenData](rawPageToken, 100, 100)
+		if err != nil {
+			return token, apiv1.NewValidationFailureError(err)
+		}
+
+		token = pageToken
+	} else {
+		var errs []error
+
+		token.Data = &ListThreadsPageTokenData{
+			Resolved: apiv1.ValidateOptional("state", r.FormValue("state"), &errs, ParseResolvedFilter),
+		}
+
+		if len(errs) != 0 {
+			return token, apiv1.NewValidationFailureError(errors.Join(errs...))
+		}
+
+		if size := r.FormValue("page_size"); size != "" {
+			pageSize, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return token, apiv1.NewValidationFailureError(err)
+			}
+
+			token.PageSize = int(pageSize)
+		}
+	}
+
+	return token, nil
+}
@@ -0,0 +1,187 @@
+package apiv1
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/comments"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	notesapiv1 "github.com/dabbertorres/notes/internal/notes/apiv1"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+type Thread struct {
+	ID         string           `json:"id"`
+	NoteID     string           `json:"note_id"`
+	Anchor     *Anchor          `json:"anchor,omitempty"`
+	CreatedAt  string           `json:"created_at"`
+	CreatedBy  notesapiv1.User  `json:"created_by"`
+	Resolved   bool             `json:"resolved"`
+	ResolvedAt string           `json:"resolved_at,omitempty"`
+	ResolvedBy *notesapiv1.User `json:"resolved_by,omitempty"`
+	Comments   []Comment        `json:"comments"`
+}
+
+func ThreadFromDomain(domain *comments.Thread) (t Thread) {
+	t.ID = domain.ID.String()
+	t.NoteID = domain.NoteID.String()
+	t.CreatedAt = domain.CreatedAt.Format(time.RFC3339)
+	t.CreatedBy = notesapiv1.UserFromDomain(domain.CreatedBy)
+	t.Comments = util.MapSlice(domain.Comments, func(comment comments.Comment) Comment {
+		return CommentFromDomain(&comment)
+	})
+
+	if domain.Anchor != nil {
+		t.Anchor = &Anchor{
+			Start: domain.Anchor.Start,
+			End:   domain.Anchor.End,
+			Quote: domain.Anchor.Quote,
+		}
+	}
+
+	if domain.Resolved() {
+		resolvedBy := notesapiv1.UserFromDomain(domain.ResolvedBy)
+		t.Resolved = true
+		t.ResolvedAt = domain.ResolvedAt.Format(time.RFC3339)
+		t.ResolvedBy = &resolvedBy
+	}
+
+	return t
+}
+
+// Anchor is a range of a note's body, in characters: [start, end).
+type Anchor struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Quote is the text of the range when the thread was started. It is ignored when starting a thread.
+	Quote string `json:"quote,omitempty"`
+}
+
+type Comment struct {
+	ID        string            `json:"id"`
+	ThreadID  string            `json:"thread_id"`
+	CreatedAt string            `json:"created_at"`
+	CreatedBy notesapiv1.User   `json:"created_by"`
+	UpdatedAt string            `json:"updated_at"`
+	Body      string            `json:"body"`
+	Mentions  []notesapiv1.User `json:"mentions,omitempty"`
+}
+
+func CommentFromDomain(domain *comments.Comment) (c Comment) {
+	c.ID = domain.ID.String()
+	c.ThreadID = domain.ThreadID.String()
+	c.CreatedAt = domain.CreatedAt.Format(time.RFC3339)
+	c.CreatedBy = notesapiv1.UserFromDomain(domain.CreatedBy)
+	c.UpdatedAt = domain.UpdatedAt.Format(time.RFC3339)
+	c.Body = domain.Body
+	c.Mentions = util.MapSlice(domain.Mentions, notesapiv1.UserFromDomain)
+	return c
+}
+
+// NewThread starts a thread, optionally about a range of the note's body, with its first comment.
+type NewThread struct {
+	Anchor *Anchor `json:"anchor,omitempty"`
+	Body   string  `json:"body"`
+}
+
+func (t *NewThread) ToDomain() (anchor *comments.Anchor, body string, err error) {
+	var errs []error
+
+	if t.Body == "" {
+		errs = append(errs, &apiv1.InvalidFieldError{Field: ".body", Err: apiv1.ErrFieldNotSet.Error()})
+	}
+
+	if t.Anchor != nil {
+		anchor = &comments.Anchor{
+			Start: t.Anchor.Start,
+			End:   t.Anchor.End,
+		}
+	}
+
+	if len(errs) != 0 {
+		return nil, "", errors.Join(errs...)
+	}
+
+	return anchor, t.Body, nil
+}
+
+// WritableComment contains only the subset of fields on [Comment] that an API user can modify.
+type WritableComment struct {
+	Body string `json:"body"`
+}
+
+func (c *WritableComment) Validate() error {
+	if c.Body == "" {
+		return &apiv1.InvalidFieldError{Field: ".body", Err: apiv1.ErrFieldNotSet.Error()}
+	}
+
+	return nil
+}
+
+// WritableThread contains only the subset of fields on [Thread] that an API user can modify.
+type WritableThread struct {
+	Resolved bool `json:"resolved"`
+}
+
+// ParseResolvedFilter parses whether to list threads that are "open", "resolved", or "all" of them.
+func ParseResolvedFilter(s string) (sql.NullBool, error) {
+	switch s {
+	case "open":
+		return sql.NullBool{Bool: false, Valid: true}, nil
+	case "resolved":
+		return sql.NullBool{Bool: true, Valid: true}, nil
+	case "all":
+		return sql.NullBool{}, nil
+	default:
+		return sql.NullBool{}, errors.New(`must be one of "open", "resolved", or "all"`)
+	}
+}
+
+type ListThreadsPageTokenData struct {
+	Resolved     sql.NullBool
+	LastThreadID uuid.NullUUID
+}
+
+func (d *ListThreadsPageTokenData) EncodePager() ([][]byte, error) {
+	var out [2][]byte
+
+	if d.Resolved.Valid {
+		out[0] = strconv.AppendBool(nil, d.Resolved.Bool)
+	}
+
+	if d.LastThreadID.Valid {
+		out[1] = []byte(d.LastThreadID.UUID.String())
+	}
+
+	return out[:], nil
+}
+
+func (d *ListThreadsPageTokenData) DecodePager(data [][]byte) (err error) {
+	if len(data) != 2 {
+		return errors.New("invalid page token format (incorrect number of parts)")
+	}
+
+	if len(data[0]) != 0 {
+		if d.Resolved.Bool, err = strconv.ParseBool(string(data[0])); err != nil {
+			return err
+		}
+
+		d.Resolved.Valid = true
+	}
+
+	if len(data[1]) != 0 {
+		lastID, err := uuid.ParseBytes(data[1])
+		if err != nil {
+			return err
+		}
+
+		d.LastThreadID.UUID = lastID
+		d.LastThreadID.Valid = true
+	}
+
+	return nil
+}
@@ -0,0 +1,9 @@
+// Package comments manages discussions about notes.
+package comments
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewPGXRepository),
+	do.Lazy(NewService),
+)
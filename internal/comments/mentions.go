@@ -0,0 +1,24 @@
+package comments
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// a mention is an @ followed by a user's name, which cannot be part of a longer word (e.g. an email address)
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@-])@([\p{L}\p{N}_][\p{L}\p{N}_.-]*)`)
+
+// ParseMentions finds the names of the users mentioned (e.g. "@alice") in a comment's body.
+// Names are lower case, and each is only returned once.
+func ParseMentions(body string) []string {
+	var names []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// allow a mention to end a sentence
+		name := strings.TrimRight(match[1], ".")
+		names = append(names, strings.ToLower(name))
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names)
+}
@@ -0,0 +1,52 @@
+package comments
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "none",
+			body: "looks good to me",
+			want: nil,
+		},
+		{
+			name: "start and end of sentences",
+			body: "@Alice can you check this? Thanks @bob.",
+			want: []string{"alice", "bob"},
+		},
+		{
+			name: "duplicates",
+			body: "@carol, @Carol and @carol",
+			want: []string{"carol"},
+		},
+		{
+			name: "punctuation within names",
+			body: "(@dan.smith) @erin-o_neil",
+			want: []string{"dan.smith", "erin-o_neil"},
+		},
+		{
+			name: "not email addresses",
+			body: "email frank@example.com or @@grace",
+			want: nil,
+		},
+		{
+			name: "unicode",
+			body: "@Zoë",
+			want: []string{"zoë"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseMentions(tt.body))
+		})
+	}
+}
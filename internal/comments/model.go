@@ -0,0 +1,54 @@
+package comments
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// Thread is a discussion about a note, or about part of its body.
+type Thread struct {
+	ID     uuid.UUID
+	NoteID uuid.UUID
+	// Anchor is the part of the note's body that the thread is about, or nil if it is about the whole note.
+	Anchor    *Anchor
+	CreatedAt time.Time
+	CreatedBy users.User
+	// ResolvedAt is the zero value while the thread is unresolved.
+	ResolvedAt time.Time
+	ResolvedBy users.User
+	// Comments are in the order they were made, starting with the one that started the thread.
+	Comments []Comment
+}
+
+func (t *Thread) Resolved() bool {
+	return !t.ResolvedAt.IsZero()
+}
+
+// Anchor is a range of a note's body, in characters (not bytes): [Start, End).
+// It is not updated as the note is edited, so Quote holds the text of the range when the thread was started.
+type Anchor struct {
+	Start int
+	End   int
+	Quote string
+}
+
+type Comment struct {
+	ID        uuid.UUID
+	ThreadID  uuid.UUID
+	CreatedAt time.Time
+	CreatedBy users.User
+	UpdatedAt time.Time
+	Body      string
+	// Mentions are the users mentioned in the body that can access the note.
+	Mentions []users.User
+}
+
+type ThreadListParams struct {
+	// Resolved, if valid, only lists threads that are (or are not) resolved.
+	Resolved     sql.NullBool
+	LastThreadID uuid.NullUUID
+}
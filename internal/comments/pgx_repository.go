@@ -0,0 +1,360 @@
+package comments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/users"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+type PGXRepository struct {
+	db      database.Database
+	queries *database.Queries
+}
+
+func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGXRepository{
+		db:      db,
+		queries: database.New(),
+	}, nil
+}
+
+func (r *PGXRepository) CreateThread(ctx context.Context, thread *Thread) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		params := database.CreateThreadParams{
+			ThreadID:  thread.ID,
+			NoteID:    thread.NoteID,
+			CreatedAt: pgtype.Timestamptz{Time: thread.CreatedAt, Valid: true},
+			CreatedBy: optionalUser(thread.CreatedBy),
+		}
+
+		if thread.Anchor != nil {
+			params.AnchorStart = pgtype.Int4{Int32: int32(thread.Anchor.Start), Valid: true}
+			params.AnchorEnd = pgtype.Int4{Int32: int32(thread.Anchor.End), Valid: true}
+			params.AnchorQuote = pgtype.Text{String: thread.Anchor.Quote, Valid: true}
+		}
+
+		if err := r.queries.CreateThread(ctx, tx, params); err != nil {
+			return err
+		}
+
+		for i := range thread.Comments {
+			if err := r.saveComment(ctx, tx, &thread.Comments[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error creating comment thread", zap.Stringer("thread_id", thread.ID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) GetThread(ctx context.Context, noteID, threadID uuid.UUID) (thread *Thread, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.GetThread(ctx, tx, database.GetThreadParams{
+			ThreadID: threadID,
+			NoteID:   noteID,
+		})
+		if err != nil {
+			return err
+		}
+
+		threads := []Thread{threadFromRow(row)}
+		if err := r.getComments(ctx, tx, threads); err != nil {
+			return err
+		}
+
+		thread = &threads[0]
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apiv1.NewError(http.StatusNotFound, "thread does not exist")
+		}
+
+		log.Error(ctx, "error fetching comment thread", zap.Stringer("thread_id", threadID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return thread, nil
+}
+
+func (r *PGXRepository) ListThreads(ctx context.Context, noteID uuid.UUID, params ThreadListParams, pageSize int) (threads []Thread, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ListThreads(ctx, tx, database.ListThreadsParams{
+			NoteID:       noteID,
+			Resolved:     pgtype.Bool{Bool: params.Resolved.Bool, Valid: params.Resolved.Valid},
+			LastThreadID: params.LastThreadID,
+			PageSize:     int64(pageSize),
+		})
+		if err != nil {
+			return err
+		}
+
+		threads = util.MapSlice(rows, threadFromRow)
+		return r.getComments(ctx, tx, threads)
+	})
+	if err != nil {
+		log.Error(ctx, "error listing comment threads", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return threads, nil
+}
+
+// getComments retrieves the comments of every thread, along with their mentions.
+func (r *PGXRepository) getComments(ctx context.Context, tx pgx.Tx, threads []Thread) error {
+	if len(threads) == 0 {
+		return nil
+	}
+
+	threadIDs := make([]uuid.UUID, len(threads))
+	threadsByID := make(map[uuid.UUID]*Thread, len(threads))
+	for i := range threads {
+		threadIDs[i] = threads[i].ID
+		threadsByID[threads[i].ID] = &threads[i]
+	}
+
+	rows, err := r.queries.ListThreadComments(ctx, tx, threadIDs)
+	if err != nil {
+		return err
+	}
+
+	commentIDs := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		commentIDs[i] = row.CommentID
+	}
+
+	mentions, err := r.getMentions(ctx, tx, commentIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		comment := commentFromRow(row)
+		comment.Mentions = mentions[comment.ID]
+
+		thread := threadsByID[row.ThreadID]
+		thread.Comments = append(thread.Comments, comment)
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) getMentions(ctx context.Context, tx pgx.Tx, commentIDs []uuid.UUID) (map[uuid.UUID][]users.User, error) {
+	rows, err := r.queries.ListCommentMentions(ctx, tx, commentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	mentions := make(map[uuid.UUID][]users.User)
+	for _, row := range rows {
+		mentions[row.CommentID] = append(mentions[row.CommentID], users.User{
+			ID:   row.UserID,
+			Name: row.Name,
+		})
+	}
+
+	return mentions, nil
+}
+
+func (r *PGXRepository) SetThreadResolved(ctx context.Context, noteID, threadID uuid.UUID, resolvedAt time.Time, resolvedBy uuid.UUID) error {
+	var updated int64
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		resolved := !resolvedAt.IsZero()
+		updated, err = r.queries.SetThreadResolved(ctx, tx, database.SetThreadResolvedParams{
+			ResolvedAt: pgtype.Timestamptz{Time: resolvedAt, Valid: resolved},
+			ResolvedBy: uuid.NullUUID{UUID: resolvedBy, Valid: resolved},
+			ThreadID:   threadID,
+			NoteID:     noteID,
+		})
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error resolving comment thread", zap.Stringer("thread_id", threadID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	if updated == 0 {
+		return apiv1.NewError(http.StatusNotFound, "thread does not exist")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) SaveComment(ctx context.Context, comment *Comment) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return r.saveComment(ctx, tx, comment)
+	})
+	if err != nil {
+		log.Error(ctx, "error saving comment", zap.Stringer("comment_id", comment.ID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) saveComment(ctx context.Context, tx pgx.Tx, comment *Comment) error {
+	err := r.queries.SaveComment(ctx, tx, database.SaveCommentParams{
+		CommentID: comment.ID,
+		ThreadID:  comment.ThreadID,
+		CreatedAt: pgtype.Timestamptz{Time: comment.CreatedAt, Valid: true},
+		CreatedBy: optionalUser(comment.CreatedBy),
+		UpdatedAt: pgtype.Timestamptz{Time: comment.UpdatedAt, Valid: true},
+		Body:      comment.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := r.queries.ClearCommentMentions(ctx, tx, comment.ID); err != nil {
+		return err
+	}
+
+	if len(comment.Mentions) == 0 {
+		return nil
+	}
+
+	return r.queries.AddCommentMentions(ctx, tx, database.AddCommentMentionsParams{
+		CommentID: comment.ID,
+		UserIds:   util.MapSlice(comment.Mentions, func(user users.User) uuid.UUID { return user.ID }),
+	})
+}
+
+func (r *PGXRepository) GetComment(ctx context.Context, threadID, commentID uuid.UUID) (comment *Comment, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.GetComment(ctx, tx, database.GetCommentParams{
+			CommentID: commentID,
+			ThreadID:  threadID,
+		})
+		if err != nil {
+			return err
+		}
+
+		mentions, err := r.getMentions(ctx, tx, []uuid.UUID{commentID})
+		if err != nil {
+			return err
+		}
+
+		found := commentFromRow(row)
+		found.Mentions = mentions[commentID]
+		comment = &found
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apiv1.NewError(http.StatusNotFound, "comment does not exist")
+		}
+
+		log.Error(ctx, "error fetching comment", zap.Stringer("comment_id", commentID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return comment, nil
+}
+
+func (r *PGXRepository) DeleteComment(ctx context.Context, threadID, commentID uuid.UUID) error {
+	var deleted int64
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		deleted, err = r.queries.DeleteComment(ctx, tx, database.DeleteCommentParams{
+			CommentID: commentID,
+			ThreadID:  threadID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return r.queries.DeleteEmptyThread(ctx, tx, threadID)
+	})
+	if err != nil {
+		log.Error(ctx, "error deleting comment", zap.Stringer("comment_id", commentID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	if deleted == 0 {
+		return apiv1.NewError(http.StatusNotFound, "comment does not exist")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) FindMentionableUsers(ctx context.Context, noteID uuid.UUID, names []string) (found []users.User, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.FindMentionableUsers(ctx, tx, database.FindMentionableUsersParams{
+			NoteID: noteID,
+			Names:  names,
+		})
+		if err != nil {
+			return err
+		}
+
+		found = util.MapSlice(rows, func(row database.FindMentionableUsersRow) users.User {
+			return users.User{ID: row.UserID, Name: row.Name}
+		})
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error finding mentioned users", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return found, nil
+}
+
+func threadFromRow(row database.NotesCommentThread) Thread {
+	thread := Thread{
+		ID:         row.ThreadID,
+		NoteID:     row.NoteID,
+		CreatedAt:  row.CreatedAt.Time,
+		CreatedBy:  users.User{ID: row.CreatedBy.UUID},
+		ResolvedAt: row.ResolvedAt.Time,
+		ResolvedBy: users.User{ID: row.ResolvedBy.UUID},
+	}
+
+	if row.AnchorStart.Valid {
+		thread.Anchor = &Anchor{
+			Start: int(row.AnchorStart.Int32),
+			End:   int(row.AnchorEnd.Int32),
+			Quote: row.AnchorQuote.String,
+		}
+	}
+
+	return thread
+}
+
+func commentFromRow(row database.NotesComment) Comment {
+	return Comment{
+		ID:        row.CommentID,
+		ThreadID:  row.ThreadID,
+		CreatedAt: row.CreatedAt.Time,
+		CreatedBy: users.User{ID: row.CreatedBy.UUID},
+		UpdatedAt: row.UpdatedAt.Time,
+		Body:      row.Body,
+	}
+}
+
+func optionalUser(user users.User) uuid.NullUUID {
+	return uuid.NullUUID{UUID: user.ID, Valid: user.ID != uuid.Nil}
+}
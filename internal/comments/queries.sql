@@ -0,0 +1,163 @@
+-- name: CreateThread :exec
+INSERT INTO notes.comment_threads (
+  thread_id,
+  note_id,
+  anchor_start,
+  anchor_end,
+  anchor_quote,
+  created_at,
+  created_by
+) VALUES (
+  sqlc.arg(thread_id),
+  sqlc.arg(note_id),
+  sqlc.narg(anchor_start),
+  sqlc.narg(anchor_end),
+  sqlc.narg(anchor_quote),
+  sqlc.arg(created_at),
+  sqlc.arg(created_by)
+)
+;
+
+-- name: GetThread :one
+SELECT
+  thread_id,
+  note_id,
+  anchor_start,
+  anchor_end,
+  anchor_quote,
+  created_at,
+  created_by,
+  resolved_at,
+  resolved_by
+FROM notes.comment_threads
+WHERE thread_id = sqlc.arg(thread_id)
+  AND note_id = sqlc.arg(note_id)
+;
+
+-- name: ListThreads :many
+SELECT
+  thread_id,
+  note_id,
+  anchor_start,
+  anchor_end,
+  anchor_quote,
+  created_at,
+  created_by,
+  resolved_at,
+  resolved_by
+FROM notes.comment_threads
+WHERE note_id = sqlc.arg(note_id)
+  AND (sqlc.narg(resolved)::bool IS NULL OR (resolved_at IS NOT NULL) = sqlc.narg(resolved)::bool)
+  AND (sqlc.narg(last_thread_id)::uuid IS NULL OR thread_id > sqlc.narg(last_thread_id)::uuid)
+ORDER BY thread_id ASC
+LIMIT sqlc.arg(page_size)
+;
+
+-- name: SetThreadResolved :execrows
+UPDATE notes.comment_threads
+  SET resolved_at = sqlc.narg(resolved_at),
+      resolved_by = sqlc.narg(resolved_by)
+WHERE thread_id = sqlc.arg(thread_id)
+  AND note_id = sqlc.arg(note_id)
+;
+
+-- name: DeleteEmptyThread :exec
+DELETE FROM notes.comment_threads
+WHERE thread_id = sqlc.arg(thread_id)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM notes.comments
+    WHERE comments.thread_id = comment_threads.thread_id
+  )
+;
+
+-- name: SaveComment :exec
+INSERT INTO notes.comments (
+  comment_id,
+  thread_id,
+  created_at,
+  created_by,
+  updated_at,
+  body
+) VALUES (
+  sqlc.arg(comment_id),
+  sqlc.arg(thread_id),
+  sqlc.arg(created_at),
+  sqlc.arg(created_by),
+  sqlc.arg(updated_at),
+  sqlc.arg(body)
+) ON CONFLICT (comment_id) DO UPDATE
+  SET updated_at = excluded.updated_at,
+      body       = excluded.body
+;
+
+-- name: GetComment :one
+SELECT
+  comment_id,
+  thread_id,
+  created_at,
+  created_by,
+  updated_at,
+  body
+FROM notes.comments
+WHERE comment_id = sqlc.arg(comment_id)
+  AND thread_id = sqlc.arg(thread_id)
+;
+
+-- name: ListThreadComments :many
+SELECT
+  comment_id,
+  thread_id,
+  created_at,
+  created_by,
+  updated_at,
+  body
+FROM notes.comments
+WHERE thread_id = ANY(sqlc.arg(thread_ids)::uuid[])
+ORDER BY thread_id, comment_id
+;
+
+-- name: DeleteComment :execrows
+DELETE FROM notes.comments
+WHERE comment_id = sqlc.arg(comment_id)
+  AND thread_id = sqlc.arg(thread_id)
+;
+
+-- name: ClearCommentMentions :exec
+DELETE FROM notes.comment_mentions
+WHERE comment_id = sqlc.arg(comment_id)
+;
+
+-- name: AddCommentMentions :exec
+INSERT INTO notes.comment_mentions (
+  comment_id,
+  user_id
+)
+SELECT
+  sqlc.arg(comment_id),
+  UNNEST(sqlc.arg(user_ids)::uuid[])
+;
+
+-- name: ListCommentMentions :many
+SELECT
+  comment_mentions.comment_id,
+  users.user_id,
+  users.name
+FROM notes.comment_mentions
+JOIN notes.users ON
+  users.user_id = comment_mentions.user_id
+WHERE comment_mentions.comment_id = ANY(sqlc.arg(comment_ids)::uuid[])
+ORDER BY comment_mentions.comment_id, users.name, users.user_id
+;
+
+-- name: FindMentionableUsers :many
+SELECT
+  users.user_id,
+  users.name
+FROM notes.users
+JOIN notes.user_note_access ON
+  user_note_access.user_id = users.user_id
+  AND user_note_access.note_id = sqlc.arg(note_id)
+  -- NOTE: any access
+WHERE lower(users.name) = ANY(sqlc.arg(names)::text[])
+;
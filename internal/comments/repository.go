@@ -0,0 +1,26 @@
+package comments
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+type Repository interface {
+	// CreateThread saves a new thread, along with its first comment.
+	CreateThread(ctx context.Context, thread *Thread) error
+	GetThread(ctx context.Context, noteID, threadID uuid.UUID) (*Thread, error)
+	ListThreads(ctx context.Context, noteID uuid.UUID, params ThreadListParams, pageSize int) ([]Thread, error)
+	// SetThreadResolved resolves a thread, or unresolves it if resolvedAt is the zero value.
+	SetThreadResolved(ctx context.Context, noteID, threadID uuid.UUID, resolvedAt time.Time, resolvedBy uuid.UUID) error
+	// SaveComment creates or updates a comment, and replaces its mentions.
+	SaveComment(ctx context.Context, comment *Comment) error
+	GetComment(ctx context.Context, threadID, commentID uuid.UUID) (*Comment, error)
+	// DeleteComment deletes a comment, and its thread if no comments are left.
+	DeleteComment(ctx context.Context, threadID, commentID uuid.UUID) error
+	// FindMentionableUsers finds the users that can access a note with any of the (lower case) names.
+	FindMentionableUsers(ctx context.Context, noteID uuid.UUID, names []string) ([]users.User, error)
+}
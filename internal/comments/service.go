@@ -0,0 +1,285 @@
+package comments
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/scope"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// NoteReader reports a user's access to a note, and retrieves notes to anchor threads to.
+// Threads can be read by anyone who can view their note, and started or replied to by commenters.
+type NoteReader interface {
+	GetUsersNoteAccess(ctx context.Context, noteID, userID uuid.UUID) (users.AccessLevel, error)
+	GetNote(ctx context.Context, noteID, asUserID uuid.UUID) (*notes.Note, error)
+}
+
+type Service struct {
+	repo  Repository
+	notes NoteReader
+}
+
+func NewService(injector do.Injector) (*Service, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := do.InvokeAs[NoteReader](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		repo:  repo,
+		notes: notes,
+	}, nil
+}
+
+// CreateThread starts a thread about a note with a comment.
+// If anchor is not nil, the thread is about its range of the note's body, and its Quote is set from the body.
+func (s *Service) CreateThread(ctx context.Context, noteID uuid.UUID, anchor *Anchor, body string) (*Thread, error) {
+	userID := scope.MustUserID(ctx)
+
+	if err := s.checkAccess(ctx, noteID, userID, users.AccessLevelCommenter); err != nil {
+		return nil, err
+	}
+
+	if anchor != nil {
+		note, err := s.notes.GetNote(ctx, noteID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if anchor.Start < 0 || anchor.Start >= anchor.End || anchor.End > utf8.RuneCountInString(note.Body) {
+			return nil, apiv1.NewError(http.StatusBadRequest, "anchor is not within the note's body")
+		}
+
+		anchor.Quote = string([]rune(note.Body)[anchor.Start:anchor.End])
+	}
+
+	threadID, err := uuid.NewV7()
+	if err != nil {
+		return nil, apiv1.StatusError(http.StatusServiceUnavailable)
+	}
+
+	comment, err := s.newComment(ctx, noteID, threadID, userID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	thread := &Thread{
+		ID:        threadID,
+		NoteID:    noteID,
+		Anchor:    anchor,
+		CreatedAt: comment.CreatedAt,
+		CreatedBy: comment.CreatedBy,
+		Comments:  []Comment{*comment},
+	}
+
+	if err := s.repo.CreateThread(ctx, thread); err != nil {
+		return nil, err
+	}
+
+	return thread, nil
+}
+
+// AddComment replies to a thread.
+func (s *Service) AddComment(ctx context.Context, noteID, threadID uuid.UUID, body string) (*Comment, error) {
+	userID := scope.MustUserID(ctx)
+
+	if err := s.checkAccess(ctx, noteID, userID, users.AccessLevelCommenter); err != nil {
+		return nil, err
+	}
+
+	// ensure the thread is about the note
+	if _, err := s.repo.GetThread(ctx, noteID, threadID); err != nil {
+		return nil, err
+	}
+
+	comment, err := s.newComment(ctx, noteID, threadID, userID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveComment(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// UpdateComment replaces the body of a comment. Only its author can edit it.
+func (s *Service) UpdateComment(ctx context.Context, noteID, threadID, commentID uuid.UUID, body string) (*Comment, error) {
+	userID := scope.MustUserID(ctx)
+
+	comment, err := s.getOwnComment(ctx, noteID, threadID, commentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.Body = body
+	comment.UpdatedAt = time.Now()
+
+	comment.Mentions, err = s.findMentions(ctx, noteID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveComment(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// DeleteComment deletes a comment. Only its author can delete it.
+// Deleting the last comment of a thread deletes the thread.
+func (s *Service) DeleteComment(ctx context.Context, noteID, threadID, commentID uuid.UUID) error {
+	userID := scope.MustUserID(ctx)
+
+	if _, err := s.getOwnComment(ctx, noteID, threadID, commentID, userID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteComment(ctx, threadID, commentID)
+}
+
+// SetThreadResolved resolves, or unresolves, a thread.
+func (s *Service) SetThreadResolved(ctx context.Context, noteID, threadID uuid.UUID, resolved bool) (*Thread, error) {
+	userID := scope.MustUserID(ctx)
+
+	if err := s.checkAccess(ctx, noteID, userID, users.AccessLevelCommenter); err != nil {
+		return nil, err
+	}
+
+	var resolvedAt time.Time
+	if resolved {
+		resolvedAt = time.Now()
+	}
+
+	if err := s.repo.SetThreadResolved(ctx, noteID, threadID, resolvedAt, userID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetThread(ctx, noteID, threadID)
+}
+
+func (s *Service) GetThread(ctx context.Context, noteID, threadID uuid.UUID) (*Thread, error) {
+	userID := scope.MustUserID(ctx)
+
+	if err := s.checkAccess(ctx, noteID, userID, users.AccessLevelViewer); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetThread(ctx, noteID, threadID)
+}
+
+func (s *Service) ListThreads(ctx context.Context, noteID uuid.UUID, params ThreadListParams, pageSize int) ([]Thread, *ThreadListParams, error) {
+	userID := scope.MustUserID(ctx)
+
+	if err := s.checkAccess(ctx, noteID, userID, users.AccessLevelViewer); err != nil {
+		return nil, nil, err
+	}
+
+	if pageSize == 0 {
+		pageSize = 100
+	}
+
+	// retrieve one more to see if there is another page to fetch
+	results, err := s.repo.ListThreads(ctx, noteID, params, pageSize+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *ThreadListParams
+	if len(results) > pageSize {
+		results = results[:pageSize]
+
+		nextParams := params
+		nextParams.LastThreadID = uuid.NullUUID{UUID: results[len(results)-1].ID, Valid: true}
+		next = &nextParams
+	}
+
+	return results, next, nil
+}
+
+func (s *Service) checkAccess(ctx context.Context, noteID, userID uuid.UUID, required users.AccessLevel) error {
+	access, err := s.notes.GetUsersNoteAccess(ctx, noteID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", noteID), zap.Error(err))
+		return apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < required {
+		return apiv1.StatusError(http.StatusForbidden)
+	}
+
+	return nil
+}
+
+// getOwnComment retrieves a comment that userID wrote, and can still comment on.
+func (s *Service) getOwnComment(ctx context.Context, noteID, threadID, commentID, userID uuid.UUID) (*Comment, error) {
+	if err := s.checkAccess(ctx, noteID, userID, users.AccessLevelCommenter); err != nil {
+		return nil, err
+	}
+
+	// ensure the thread is about the note
+	if _, err := s.repo.GetThread(ctx, noteID, threadID); err != nil {
+		return nil, err
+	}
+
+	comment, err := s.repo.GetComment(ctx, threadID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.CreatedBy.ID != userID {
+		return nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	return comment, nil
+}
+
+func (s *Service) newComment(ctx context.Context, noteID, threadID, userID uuid.UUID, body string) (*Comment, error) {
+	commentID, err := uuid.NewV7()
+	if err != nil {
+		return nil, apiv1.StatusError(http.StatusServiceUnavailable)
+	}
+
+	mentions, err := s.findMentions(ctx, noteID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &Comment{
+		ID:        commentID,
+		ThreadID:  threadID,
+		CreatedAt: now,
+		CreatedBy: users.User{ID: userID},
+		UpdatedAt: now,
+		Body:      body,
+		Mentions:  mentions,
+	}, nil
+}
+
+// findMentions resolves the users mentioned in body. Mentions of users that cannot access the note are ignored.
+func (s *Service) findMentions(ctx context.Context, noteID uuid.UUID, body string) ([]users.User, error) {
+	names := ParseMentions(body)
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	return s.repo.FindMentionableUsers(ctx, noteID, names)
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"go.uber.org/zap"
 
@@ -88,3 +92,82 @@ func WriteJSON(ctx context.Context, w http.ResponseWriter, status int, body any)
 		}
 	}
 }
+
+// WriteHTML writes body, which must already be safe to serve, as an HTML response.
+func WriteHTML(ctx context.Context, w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	if _, err := io.WriteString(w, body); err != nil {
+		select {
+		case <-ctx.Done():
+			log.Debug(ctx, "client closed connection")
+		default:
+			log.Warn(ctx, "error writing response", zap.Error(err))
+		}
+	}
+}
+
+// NegotiateContentType returns which of offers the request's Accept header prefers.
+// If there is no Accept header, or it accepts none of offers, the first offer is returned.
+func NegotiateContentType(r *http.Request, offers ...string) string {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return offers[0]
+	}
+
+	type mediaRange struct {
+		typ, subtype string
+		quality      float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		typ, subtype, _ := strings.Cut(mediaType, "/")
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, quality: quality})
+	}
+
+	best := offers[0]
+	bestQuality := 0.0
+	for _, offer := range offers {
+		typ, subtype, _ := strings.Cut(offer, "/")
+
+		// the most specific matching range determines the offer's quality
+		quality, specificity := 0.0, -1
+		for _, rng := range ranges {
+			var s int
+			switch {
+			case rng.typ == typ && rng.subtype == subtype:
+				s = 2
+			case rng.typ == typ && rng.subtype == "*":
+				s = 1
+			case rng.typ == "*" && rng.subtype == "*":
+				s = 0
+			default:
+				continue
+			}
+
+			if s > specificity {
+				quality, specificity = rng.quality, s
+			}
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+
+	return best
+}
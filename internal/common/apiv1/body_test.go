@@ -0,0 +1,34 @@
+package apiv1
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateContentType(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: "application/json"},
+		{accept: "text/html", want: "text/html"},
+		{accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", want: "text/html"},
+		{accept: "text/*;q=0.5, application/json", want: "application/json"},
+		{accept: "text/*", want: "text/html"},
+		{accept: "*/*", want: "application/json"},
+		{accept: "image/png", want: "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			assert.Equal(t, tt.want, NegotiateContentType(r, "application/json", "text/html"))
+		})
+	}
+}
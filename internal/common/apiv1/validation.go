@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/dabbertorres/notes/internal/util"
 )
 
@@ -165,3 +167,40 @@ func ValidateSlice[S ~[]I, I any, O any](fieldName string, in S, errs *[]error,
 func ParseRFC3339(s string) (time.Time, error) {
 	return time.Parse(time.RFC3339, s)
 }
+
+func ParseNullUUID(s string) (uuid.NullUUID, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
+
+// ParseSortOrder returns true if s requests a descending sort.
+func ParseSortOrder(s string) (descending bool, err error) {
+	switch s {
+	case "asc":
+		return false, nil
+	case "desc":
+		return true, nil
+	default:
+		return false, errors.New(`must be one of "asc" or "desc"`)
+	}
+}
+
+// ParseIntInRange returns a parser for integers between lo and hi, inclusive.
+func ParseIntInRange(lo, hi int) func(string) (int, error) {
+	return func(s string) (int, error) {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, err
+		}
+
+		if v < lo || v > hi {
+			return 0, fmt.Errorf("must be between %d and %d", lo, hi)
+		}
+
+		return v, nil
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+import "github.com/google/uuid"
+
+type Audit struct {
+	// Admins are the users who may read the entire audit log.
+	Admins []uuid.UUID `json:"admins"`
+}
+
+func (a *Audit) validate() (errs fieldErrorList) {
+	for _, id := range a.Admins {
+		if id == uuid.Nil {
+			errs = append(errs, fieldError{".admins", "must not contain the nil user"})
+			break
+		}
+	}
+
+	return errs
+}
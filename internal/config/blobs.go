@@ -0,0 +1,80 @@
+package config
+
+type BlobStoreKind string
+
+const (
+	BlobStoreLocal BlobStoreKind = "local"
+	BlobStoreS3    BlobStoreKind = "s3"
+)
+
+type Blobs struct {
+	Kind  BlobStoreKind `json:"kind"`
+	Local LocalBlobs    `json:"local"`
+	S3    S3Blobs       `json:"s3"`
+}
+
+type LocalBlobs struct {
+	Dir string `json:"dir"`
+}
+
+// S3Blobs configures any S3-compatible object store.
+type S3Blobs struct {
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	Insecure  bool   `json:"insecure"`
+}
+
+func (b *Blobs) applyDefaults() {
+	if b.Kind == "" {
+		b.Kind = BlobStoreLocal
+	}
+
+	if b.Local.Dir == "" {
+		b.Local.Dir = "blobs"
+	}
+}
+
+func (b *Blobs) validate() (errs fieldErrorList) {
+	switch b.Kind {
+	case BlobStoreLocal:
+		if b.Local.Dir == "" {
+			errs = append(errs, fieldError{".local.dir", "is required"})
+		}
+
+	case BlobStoreS3:
+		if b.S3.Endpoint == "" {
+			errs = append(errs, fieldError{".s3.endpoint", "is required"})
+		}
+
+		if b.S3.Bucket == "" {
+			errs = append(errs, fieldError{".s3.bucket", "is required"})
+		}
+
+	default:
+		errs = append(errs, fieldError{".kind", `must be one of "local" or "s3"`})
+	}
+
+	return errs
+}
+
+type Attachments struct {
+	// MaxSize is the largest attachment that may be uploaded, in bytes.
+	MaxSize int64 `json:"max_size"`
+}
+
+func (a *Attachments) applyDefaults() {
+	if a.MaxSize == 0 {
+		a.MaxSize = 25 << 20
+	}
+}
+
+func (a *Attachments) validate() (errs fieldErrorList) {
+	if a.MaxSize < 0 {
+		errs = append(errs, fieldError{".max_size", "must not be negative"})
+	}
+
+	return errs
+}
@@ -19,9 +19,13 @@ const (
 )
 
 type Config struct {
-	Database  Database  `json:"database"`
-	HTTP      HTTP      `json:"http"`
-	Telemetry Telemetry `json:"telemetry"`
+	Database    Database    `json:"database"`
+	HTTP        HTTP        `json:"http"`
+	Telemetry   Telemetry   `json:"telemetry"`
+	Blobs       Blobs       `json:"blobs"`
+	Attachments Attachments `json:"attachments"`
+	Audit       Audit       `json:"audit"`
+	Jobs        Jobs        `json:"jobs"`
 }
 
 type decoder interface {
@@ -77,6 +81,9 @@ func (c *Config) applyDefaults() {
 	c.Database.applyDefaults()
 	c.HTTP.applyDefaults()
 	c.Telemetry.applyDefaults()
+	c.Blobs.applyDefaults()
+	c.Attachments.applyDefaults()
+	c.Jobs.applyDefaults()
 }
 
 func (c *Config) validate() error {
@@ -94,5 +101,21 @@ func (c *Config) validate() error {
 		errs = append(errs, err.qualify(".telemetry")...)
 	}
 
+	if err := c.Blobs.validate(); err != nil {
+		errs = append(errs, err.qualify(".blobs")...)
+	}
+
+	if err := c.Attachments.validate(); err != nil {
+		errs = append(errs, err.qualify(".attachments")...)
+	}
+
+	if err := c.Audit.validate(); err != nil {
+		errs = append(errs, err.qualify(".audit")...)
+	}
+
+	if err := c.Jobs.validate(); err != nil {
+		errs = append(errs, err.qualify(".jobs")...)
+	}
+
 	return errors.Join(errs.asErrors()...)
 }
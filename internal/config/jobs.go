@@ -0,0 +1,20 @@
+package config
+
+type Jobs struct {
+	// Concurrency is how many jobs each server runs at once.
+	Concurrency int `json:"concurrency"`
+}
+
+func (j *Jobs) applyDefaults() {
+	if j.Concurrency == 0 {
+		j.Concurrency = 4
+	}
+}
+
+func (j *Jobs) validate() (errs fieldErrorList) {
+	if j.Concurrency < 0 {
+		errs = append(errs, fieldError{".concurrency", "must not be negative"})
+	}
+
+	return errs
+}
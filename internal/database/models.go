@@ -15,9 +15,10 @@ import (
 type NotesAccessLevel string
 
 const (
-	NotesAccessLevelOwner  NotesAccessLevel = "owner"
-	NotesAccessLevelEditor NotesAccessLevel = "editor"
-	NotesAccessLevelViewer NotesAccessLevel = "viewer"
+	NotesAccessLevelOwner     NotesAccessLevel = "owner"
+	NotesAccessLevelEditor    NotesAccessLevel = "editor"
+	NotesAccessLevelCommenter NotesAccessLevel = "commenter"
+	NotesAccessLevelViewer    NotesAccessLevel = "viewer"
 )
 
 func (e *NotesAccessLevel) Scan(src interface{}) error {
@@ -59,17 +60,381 @@ func (e NotesAccessLevel) Valid() bool {
 	switch e {
 	case NotesAccessLevelOwner,
 		NotesAccessLevelEditor,
+		NotesAccessLevelCommenter,
 		NotesAccessLevelViewer:
 		return true
 	}
 	return false
 }
 
+type NotesImportFormat string
+
+const (
+	NotesImportFormatEnex   NotesImportFormat = "enex"
+	NotesImportFormatJoplin NotesImportFormat = "joplin"
+)
+
+func (e *NotesImportFormat) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotesImportFormat(s)
+	case string:
+		*e = NotesImportFormat(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotesImportFormat: %T", src)
+	}
+	return nil
+}
+
+type NullNotesImportFormat struct {
+	NotesImportFormat NotesImportFormat
+	Valid             bool // Valid is true if NotesImportFormat is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullNotesImportFormat) Scan(value interface{}) error {
+	if value == nil {
+		ns.NotesImportFormat, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.NotesImportFormat.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullNotesImportFormat) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.NotesImportFormat), nil
+}
+
+func (e NotesImportFormat) Valid() bool {
+	switch e {
+	case NotesImportFormatEnex,
+		NotesImportFormatJoplin:
+		return true
+	}
+	return false
+}
+
+type NotesImportStatus string
+
+const (
+	NotesImportStatusPending   NotesImportStatus = "pending"
+	NotesImportStatusRunning   NotesImportStatus = "running"
+	NotesImportStatusSucceeded NotesImportStatus = "succeeded"
+	NotesImportStatusFailed    NotesImportStatus = "failed"
+	NotesImportStatusCancelled NotesImportStatus = "cancelled"
+)
+
+func (e *NotesImportStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotesImportStatus(s)
+	case string:
+		*e = NotesImportStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotesImportStatus: %T", src)
+	}
+	return nil
+}
+
+type NullNotesImportStatus struct {
+	NotesImportStatus NotesImportStatus
+	Valid             bool // Valid is true if NotesImportStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullNotesImportStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.NotesImportStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.NotesImportStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullNotesImportStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.NotesImportStatus), nil
+}
+
+func (e NotesImportStatus) Valid() bool {
+	switch e {
+	case NotesImportStatusPending,
+		NotesImportStatusRunning,
+		NotesImportStatusSucceeded,
+		NotesImportStatusFailed,
+		NotesImportStatusCancelled:
+		return true
+	}
+	return false
+}
+
+type NotesJobStatus string
+
+const (
+	NotesJobStatusPending   NotesJobStatus = "pending"
+	NotesJobStatusRunning   NotesJobStatus = "running"
+	NotesJobStatusSucceeded NotesJobStatus = "succeeded"
+	NotesJobStatusFailed    NotesJobStatus = "failed"
+	NotesJobStatusCancelled NotesJobStatus = "cancelled"
+)
+
+func (e *NotesJobStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotesJobStatus(s)
+	case string:
+		*e = NotesJobStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotesJobStatus: %T", src)
+	}
+	return nil
+}
+
+type NullNotesJobStatus struct {
+	NotesJobStatus NotesJobStatus
+	Valid          bool // Valid is true if NotesJobStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullNotesJobStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.NotesJobStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.NotesJobStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullNotesJobStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.NotesJobStatus), nil
+}
+
+func (e NotesJobStatus) Valid() bool {
+	switch e {
+	case NotesJobStatusPending,
+		NotesJobStatusRunning,
+		NotesJobStatusSucceeded,
+		NotesJobStatusFailed,
+		NotesJobStatusCancelled:
+		return true
+	}
+	return false
+}
+
+type NotesWebhookDeliveryStatus string
+
+const (
+	NotesWebhookDeliveryStatusPending   NotesWebhookDeliveryStatus = "pending"
+	NotesWebhookDeliveryStatusSucceeded NotesWebhookDeliveryStatus = "succeeded"
+	NotesWebhookDeliveryStatusFailed    NotesWebhookDeliveryStatus = "failed"
+)
+
+func (e *NotesWebhookDeliveryStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = NotesWebhookDeliveryStatus(s)
+	case string:
+		*e = NotesWebhookDeliveryStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for NotesWebhookDeliveryStatus: %T", src)
+	}
+	return nil
+}
+
+type NullNotesWebhookDeliveryStatus struct {
+	NotesWebhookDeliveryStatus NotesWebhookDeliveryStatus
+	Valid                      bool // Valid is true if NotesWebhookDeliveryStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullNotesWebhookDeliveryStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.NotesWebhookDeliveryStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.NotesWebhookDeliveryStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullNotesWebhookDeliveryStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.NotesWebhookDeliveryStatus), nil
+}
+
+func (e NotesWebhookDeliveryStatus) Valid() bool {
+	switch e {
+	case NotesWebhookDeliveryStatusPending,
+		NotesWebhookDeliveryStatusSucceeded,
+		NotesWebhookDeliveryStatusFailed:
+		return true
+	}
+	return false
+}
+
+type NotesAttachment struct {
+	AttachmentID uuid.UUID
+	NoteID       uuid.NullUUID
+	Name         string
+	ContentType  string
+	Size         int64
+	BlobKey      string
+	CreatedAt    pgtype.Timestamptz
+	CreatedBy    uuid.NullUUID
+}
+
+type NotesAuditLog struct {
+	EntryID    int64
+	Action     string
+	ActorID    uuid.NullUUID
+	RequestID  uuid.NullUUID
+	NoteID     uuid.NullUUID
+	TagID      uuid.NullUUID
+	UserID     uuid.NullUUID
+	Before     []byte
+	After      []byte
+	OccurredAt pgtype.Timestamptz
+}
+
+type NotesComment struct {
+	CommentID uuid.UUID
+	ThreadID  uuid.UUID
+	CreatedAt pgtype.Timestamptz
+	CreatedBy uuid.NullUUID
+	UpdatedAt pgtype.Timestamptz
+	Body      string
+}
+
+type NotesCommentThread struct {
+	ThreadID    uuid.UUID
+	NoteID      uuid.UUID
+	AnchorStart pgtype.Int4
+	AnchorEnd   pgtype.Int4
+	AnchorQuote pgtype.Text
+	CreatedAt   pgtype.Timestamptz
+	CreatedBy   uuid.NullUUID
+	ResolvedAt  pgtype.Timestamptz
+	ResolvedBy  uuid.NullUUID
+}
+
+type NotesEvent struct {
+	EventID    int64
+	Kind       string
+	NoteID     uuid.NullUUID
+	TagID      uuid.NullUUID
+	Audience   []uuid.UUID
+	OccurredAt pgtype.Timestamptz
+}
+
+type NotesImport struct {
+	ImportID   uuid.UUID
+	JobID      uuid.UUID
+	UserID     uuid.UUID
+	Format     NotesImportFormat
+	Status     NotesImportStatus
+	BlobKey    string
+	CreatedAt  pgtype.Timestamptz
+	StartedAt  pgtype.Timestamptz
+	FinishedAt pgtype.Timestamptz
+	Total      pgtype.Int4
+	Created    int32
+	Updated    int32
+	Failed     int32
+	Error      pgtype.Text
+}
+
+type NotesImportFailure struct {
+	FailureID int64
+	ImportID  uuid.UUID
+	Item      string
+	Error     string
+}
+
+type NotesJob struct {
+	JobID           uuid.UUID
+	Kind            string
+	UserID          uuid.NullUUID
+	Payload         []byte
+	Status          NotesJobStatus
+	Attempts        int32
+	MaxAttempts     int32
+	RunAt           pgtype.Timestamptz
+	LockedUntil     pgtype.Timestamptz
+	CancelRequested bool
+	ProgressDone    int32
+	ProgressTotal   pgtype.Int4
+	Error           pgtype.Text
+	CreatedAt       pgtype.Timestamptz
+	StartedAt       pgtype.Timestamptz
+	FinishedAt      pgtype.Timestamptz
+}
+
+type NotesNotePublication struct {
+	NoteID      uuid.UUID
+	Slug        string
+	PublishedAt pgtype.Timestamptz
+	PublishedBy uuid.NullUUID
+}
+
+type NotesNoteRevision struct {
+	NoteID   uuid.UUID
+	Revision int64
+	Title    string
+	Body     string
+	SavedAt  pgtype.Timestamptz
+	SavedBy  uuid.NullUUID
+}
+
+type NotesReminder struct {
+	NoteID     uuid.UUID
+	UserID     uuid.UUID
+	RemindAt   pgtype.Timestamptz
+	Recurrence pgtype.Text
+	NextAt     pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+}
+
+type NotesSavedSearch struct {
+	SavedSearchID uuid.UUID
+	UserID        uuid.UUID
+	Name          string
+	Query         []byte
+	TrackMatches  bool
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	LastCheckedAt pgtype.Timestamptz
+}
+
 type NotesTag struct {
 	TagID uuid.UUID
 	Name  string
 }
 
+type NotesTemplate struct {
+	TemplateID uuid.UUID
+	Name       string
+	Title      string
+	Body       string
+	CreatedAt  pgtype.Timestamptz
+	CreatedBy  uuid.NullUUID
+	UpdatedAt  pgtype.Timestamptz
+	UpdatedBy  uuid.NullUUID
+}
+
 type NotesUser struct {
 	UserID     uuid.UUID
 	Name       string
@@ -77,3 +442,41 @@ type NotesUser struct {
 	LastSignIn pgtype.Timestamptz
 	Active     bool
 }
+
+type NotesUserToken struct {
+	TokenID   uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+	TokenHash string
+	CreatedAt pgtype.Timestamptz
+	ExpiresAt pgtype.Timestamptz
+}
+
+type NotesWebhook struct {
+	WebhookID           uuid.UUID
+	UserID              uuid.UUID
+	Url                 string
+	Secret              string
+	EventKinds          []string
+	TagIds              []uuid.UUID
+	Active              bool
+	ConsecutiveFailures int32
+	DisabledAt          pgtype.Timestamptz
+	CreatedAt           pgtype.Timestamptz
+	UpdatedAt           pgtype.Timestamptz
+}
+
+type NotesWebhookDelivery struct {
+	DeliveryID     uuid.UUID
+	WebhookID      uuid.UUID
+	EventID        int64
+	EventKind      string
+	Payload        []byte
+	Status         NotesWebhookDeliveryStatus
+	Attempts       int32
+	NextAttemptAt  pgtype.Timestamptz
+	LastAttemptAt  pgtype.Timestamptz
+	ResponseStatus pgtype.Int4
+	LastError      pgtype.Text
+	CreatedAt      pgtype.Timestamptz
+}
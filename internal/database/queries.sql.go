@@ -12,306 +12,542 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const deleteNote = `-- name: DeleteNote :execrows
-DELETE FROM notes.notes
-WHERE note_id = $1
+const abandonOutboxMessage = `-- name: AbandonOutboxMessage :exec
+UPDATE notes.outbox
+SET abandoned_at = $1,
+    attempts     = attempts + 1,
+    last_error   = $2
+WHERE message_id = $3
 `
 
-func (q *Queries) DeleteNote(ctx context.Context, db DBTX, noteID uuid.UUID) (int64, error) {
-	result, err := db.Exec(ctx, deleteNote, noteID)
-	if err != nil {
-		return 0, err
-	}
-	return result.RowsAffected(), nil
+type AbandonOutboxMessageParams struct {
+	AbandonedAt pgtype.Timestamptz
+	LastError   pgtype.Text
+	MessageID   int64
 }
 
-const deleteTag = `-- name: DeleteTag :execrows
-DELETE FROM notes.tags
-WHERE tag_id = $1
+func (q *Queries) AbandonOutboxMessage(ctx context.Context, db DBTX, arg AbandonOutboxMessageParams) error {
+	_, err := db.Exec(ctx, abandonOutboxMessage, arg.AbandonedAt, arg.LastError, arg.MessageID)
+	return err
+}
+
+const addCommentMentions = `-- name: AddCommentMentions :exec
+INSERT INTO notes.comment_mentions (
+  comment_id,
+  user_id
+)
+SELECT
+  $1,
+  UNNEST($2::uuid[])
 `
 
-func (q *Queries) DeleteTag(ctx context.Context, db DBTX, tagID uuid.UUID) (int64, error) {
-	result, err := db.Exec(ctx, deleteTag, tagID)
-	if err != nil {
-		return 0, err
-	}
-	return result.RowsAffected(), nil
+type AddCommentMentionsParams struct {
+	CommentID uuid.UUID
+	UserIds   []uuid.UUID
 }
 
-const deleteUser = `-- name: DeleteUser :execrows
-DELETE FROM notes.users
-WHERE
-  user_id = $1
+func (q *Queries) AddCommentMentions(ctx context.Context, db DBTX, arg AddCommentMentionsParams) error {
+	_, err := db.Exec(ctx, addCommentMentions, arg.CommentID, arg.UserIds)
+	return err
+}
+
+const addImportFailure = `-- name: AddImportFailure :exec
+INSERT INTO notes.import_failures (
+  import_id,
+  item,
+  error
+) VALUES (
+  $1,
+  $2,
+  $3
+)
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, db DBTX, userID uuid.UUID) (int64, error) {
-	result, err := db.Exec(ctx, deleteUser, userID)
-	if err != nil {
-		return 0, err
-	}
-	return result.RowsAffected(), nil
+type AddImportFailureParams struct {
+	ImportID uuid.UUID
+	Item     string
+	Error    string
 }
 
-const getNote = `-- name: GetNote :one
+func (q *Queries) AddImportFailure(ctx context.Context, db DBTX, arg AddImportFailureParams) error {
+	_, err := db.Exec(ctx, addImportFailure, arg.ImportID, arg.Item, arg.Error)
+	return err
+}
+
+const addNoteLinks = `-- name: AddNoteLinks :exec
+INSERT INTO notes.note_links (
+  source_note_id,
+  reference,
+  target_note_id
+)
 SELECT
+  $1,
+  refs.reference,
+  -- resolve to the note with that ID, or otherwise the oldest note with that title,
+  -- out of the notes that someone with access to the source note can also read
+  COALESCE(
+    (
+      SELECT note_id
+      FROM notes.notes
+      WHERE note_id = CASE
+        WHEN refs.reference ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$' THEN refs.reference::uuid
+      END
+        AND EXISTS (
+          SELECT 1
+          FROM notes.user_note_access AS source_access
+          JOIN notes.user_note_access AS target_access ON
+            target_access.user_id = source_access.user_id
+          WHERE source_access.note_id = $1
+            AND target_access.note_id = notes.note_id
+        )
+    ),
+    (
+      SELECT note_id
+      FROM notes.notes
+      WHERE lower(title) = lower(refs.reference)
+        AND EXISTS (
+          SELECT 1
+          FROM notes.user_note_access AS source_access
+          JOIN notes.user_note_access AS target_access ON
+            target_access.user_id = source_access.user_id
+          WHERE source_access.note_id = $1
+            AND target_access.note_id = notes.note_id
+        )
+      ORDER BY created_at, note_id
+      LIMIT 1
+    )
+  )
+FROM unnest($2::text[]) AS refs(reference)
+ON CONFLICT DO NOTHING
+`
+
+type AddNoteLinksParams struct {
+	SourceNoteID uuid.UUID
+	Refs         []string
+}
+
+func (q *Queries) AddNoteLinks(ctx context.Context, db DBTX, arg AddNoteLinksParams) error {
+	_, err := db.Exec(ctx, addNoteLinks, arg.SourceNoteID, arg.Refs)
+	return err
+}
+
+const addNoteRevision = `-- name: AddNoteRevision :exec
+INSERT INTO notes.note_revisions (
   note_id,
-  created_at,
-  created_by,
-  updated_at,
-  updated_by,
+  revision,
   title,
-  body
-FROM notes.notes
-WHERE note_id = $1
+  body,
+  saved_at,
+  saved_by
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6
+)
 `
 
-type GetNoteRow struct {
-	NoteID    uuid.UUID
-	CreatedAt pgtype.Timestamptz
-	CreatedBy uuid.NullUUID
-	UpdatedAt pgtype.Timestamptz
-	UpdatedBy uuid.NullUUID
-	Title     string
-	Body      string
+type AddNoteRevisionParams struct {
+	NoteID   uuid.UUID
+	Revision int64
+	Title    string
+	Body     string
+	SavedAt  pgtype.Timestamptz
+	SavedBy  uuid.NullUUID
 }
 
-func (q *Queries) GetNote(ctx context.Context, db DBTX, noteID uuid.UUID) (GetNoteRow, error) {
-	row := db.QueryRow(ctx, getNote, noteID)
-	var i GetNoteRow
-	err := row.Scan(
-		&i.NoteID,
-		&i.CreatedAt,
-		&i.CreatedBy,
-		&i.UpdatedAt,
-		&i.UpdatedBy,
-		&i.Title,
-		&i.Body,
+func (q *Queries) AddNoteRevision(ctx context.Context, db DBTX, arg AddNoteRevisionParams) error {
+	_, err := db.Exec(ctx, addNoteRevision,
+		arg.NoteID,
+		arg.Revision,
+		arg.Title,
+		arg.Body,
+		arg.SavedAt,
+		arg.SavedBy,
 	)
-	return i, err
+	return err
 }
 
-const getNoteAccess = `-- name: GetNoteAccess :many
-SELECT
-  user_note_access.user_id,
-  access
-FROM notes.user_note_access
-JOIN notes.users ON
-  user_note_access.user_id = users.user_id
-WHERE note_id = $1
+const addNoteTag = `-- name: AddNoteTag :exec
+INSERT INTO notes.note_tags (
+  note_id,
+  tag_id
+) VALUES (
+  $1,
+  $2
+) ON CONFLICT (note_id, tag_id) DO NOTHING
 `
 
-type GetNoteAccessRow struct {
-	UserID uuid.UUID
-	Access NotesAccessLevel
+type AddNoteTagParams struct {
+	NoteID uuid.UUID
+	TagID  uuid.UUID
 }
 
-func (q *Queries) GetNoteAccess(ctx context.Context, db DBTX, noteID uuid.UUID) ([]GetNoteAccessRow, error) {
-	rows, err := db.Query(ctx, getNoteAccess, noteID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetNoteAccessRow
-	for rows.Next() {
-		var i GetNoteAccessRow
-		if err := rows.Scan(&i.UserID, &i.Access); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) AddNoteTag(ctx context.Context, db DBTX, arg AddNoteTagParams) error {
+	_, err := db.Exec(ctx, addNoteTag, arg.NoteID, arg.TagID)
+	return err
 }
 
-const getNoteTags = `-- name: GetNoteTags :many
+const addNoteTasks = `-- name: AddNoteTasks :exec
+INSERT INTO notes.note_tasks (
+  note_id,
+  line,
+  text,
+  done
+)
 SELECT
-  tags.tag_id,
-  name
-FROM notes.note_tags
-JOIN notes.tags ON
-  note_tags.tag_id = tags.tag_id
-JOIN notes.user_tag_access ON
-  user_tag_access.tag_id = tags.tag_id
-  AND user_id = $1
-  -- NOTE: any access
-WHERE note_id = $2
+  $1,
+  task.line,
+  task.text,
+  task.done
+FROM unnest(
+  $2::int4[],
+  $3::text[],
+  $4::bool[]
+) AS task(line, text, done)
 `
 
-type GetNoteTagsParams struct {
-	UserID uuid.UUID
+type AddNoteTasksParams struct {
 	NoteID uuid.UUID
+	Lines  []int32
+	Texts  []string
+	Done   []bool
 }
 
-func (q *Queries) GetNoteTags(ctx context.Context, db DBTX, arg GetNoteTagsParams) ([]NotesTag, error) {
-	rows, err := db.Query(ctx, getNoteTags, arg.UserID, arg.NoteID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []NotesTag
-	for rows.Next() {
-		var i NotesTag
-		if err := rows.Scan(&i.TagID, &i.Name); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) AddNoteTasks(ctx context.Context, db DBTX, arg AddNoteTasksParams) error {
+	_, err := db.Exec(ctx, addNoteTasks,
+		arg.NoteID,
+		arg.Lines,
+		arg.Texts,
+		arg.Done,
+	)
+	return err
 }
 
-const getTag = `-- name: GetTag :one
+const addSavedSearchMatches = `-- name: AddSavedSearchMatches :exec
+INSERT INTO notes.saved_search_matches (
+  saved_search_id,
+  note_id,
+  matched_at
+)
 SELECT
-  tag_id,
-  name
-FROM notes.tags
-WHERE tag_id = $1
+  $1,
+  UNNEST($2::uuid[]),
+  $3
+ON CONFLICT (saved_search_id, note_id) DO NOTHING
 `
 
-func (q *Queries) GetTag(ctx context.Context, db DBTX, tagID uuid.UUID) (NotesTag, error) {
-	row := db.QueryRow(ctx, getTag, tagID)
-	var i NotesTag
-	err := row.Scan(&i.TagID, &i.Name)
-	return i, err
+type AddSavedSearchMatchesParams struct {
+	SavedSearchID uuid.UUID
+	NoteIds       []uuid.UUID
+	MatchedAt     pgtype.Timestamptz
 }
 
-const getTagAccess = `-- name: GetTagAccess :many
-SELECT
+func (q *Queries) AddSavedSearchMatches(ctx context.Context, db DBTX, arg AddSavedSearchMatchesParams) error {
+	_, err := db.Exec(ctx, addSavedSearchMatches, arg.SavedSearchID, arg.NoteIds, arg.MatchedAt)
+	return err
+}
+
+const addTemplateAccess = `-- name: AddTemplateAccess :exec
+INSERT INTO notes.user_template_access (
+  template_id,
   user_id,
   access
-FROM notes.user_tag_access
-WHERE tag_id = $1
+)
+SELECT
+  $1,
+  grants.user_id,
+  grants.access::notes.access_level
+FROM UNNEST($2::uuid[], $3::text[]) AS grants (user_id, access)
 `
 
-type GetTagAccessRow struct {
-	UserID uuid.UUID
-	Access NotesAccessLevel
+type AddTemplateAccessParams struct {
+	TemplateID uuid.UUID
+	UserIds    []uuid.UUID
+	Access     []string
 }
 
-func (q *Queries) GetTagAccess(ctx context.Context, db DBTX, tagID uuid.UUID) ([]GetTagAccessRow, error) {
-	rows, err := db.Query(ctx, getTagAccess, tagID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetTagAccessRow
-	for rows.Next() {
-		var i GetTagAccessRow
-		if err := rows.Scan(&i.UserID, &i.Access); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) AddTemplateAccess(ctx context.Context, db DBTX, arg AddTemplateAccessParams) error {
+	_, err := db.Exec(ctx, addTemplateAccess, arg.TemplateID, arg.UserIds, arg.Access)
+	return err
 }
 
-const getUser = `-- name: GetUser :one
-SELECT
+const addTemplateNoteAccess = `-- name: AddTemplateNoteAccess :exec
+INSERT INTO notes.template_note_access (
+  template_id,
   user_id,
-  name,
-  created_at,
-  last_sign_in,
-  active
-FROM notes.users
-WHERE
-  user_id = $1
+  access
+)
+SELECT
+  $1,
+  grants.user_id,
+  grants.access::notes.access_level
+FROM UNNEST($2::uuid[], $3::text[]) AS grants (user_id, access)
 `
 
-func (q *Queries) GetUser(ctx context.Context, db DBTX, noteID uuid.UUID) (NotesUser, error) {
-	row := db.QueryRow(ctx, getUser, noteID)
-	var i NotesUser
-	err := row.Scan(
-		&i.UserID,
-		&i.Name,
-		&i.CreatedAt,
-		&i.LastSignIn,
-		&i.Active,
-	)
-	return i, err
+type AddTemplateNoteAccessParams struct {
+	TemplateID uuid.UUID
+	UserIds    []uuid.UUID
+	Access     []string
 }
 
-const getUserNoteAccess = `-- name: GetUserNoteAccess :one
+func (q *Queries) AddTemplateNoteAccess(ctx context.Context, db DBTX, arg AddTemplateNoteAccessParams) error {
+	_, err := db.Exec(ctx, addTemplateNoteAccess, arg.TemplateID, arg.UserIds, arg.Access)
+	return err
+}
+
+const addTemplateTags = `-- name: AddTemplateTags :exec
+INSERT INTO notes.template_tags (
+  template_id,
+  tag_id
+)
 SELECT
-  access
-FROM notes.user_note_access
-WHERE note_id = $1
-  AND user_id = $2
+  $1,
+  UNNEST($2::uuid[])
+ON CONFLICT (template_id, tag_id) DO NOTHING
 `
 
-type GetUserNoteAccessParams struct {
-	NoteID uuid.UUID
-	UserID uuid.UUID
+type AddTemplateTagsParams struct {
+	TemplateID uuid.UUID
+	TagIds     []uuid.UUID
 }
 
-func (q *Queries) GetUserNoteAccess(ctx context.Context, db DBTX, arg GetUserNoteAccessParams) (NotesAccessLevel, error) {
-	row := db.QueryRow(ctx, getUserNoteAccess, arg.NoteID, arg.UserID)
-	var access NotesAccessLevel
-	err := row.Scan(&access)
-	return access, err
+func (q *Queries) AddTemplateTags(ctx context.Context, db DBTX, arg AddTemplateTagsParams) error {
+	_, err := db.Exec(ctx, addTemplateTags, arg.TemplateID, arg.TagIds)
+	return err
 }
 
-const getUserTagAccess = `-- name: GetUserTagAccess :one
-SELECT
-  access
-FROM notes.user_tag_access
-WHERE tag_id = $1
-  AND user_id = $2
+const appendAuditEntry = `-- name: AppendAuditEntry :exec
+INSERT INTO notes.audit_log (
+  action,
+  actor_id,
+  request_id,
+  note_id,
+  tag_id,
+  user_id,
+  before,
+  after,
+  occurred_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
+  $8,
+  $9
+)
 `
 
-type GetUserTagAccessParams struct {
-	TagID  uuid.UUID
-	UserID uuid.UUID
+type AppendAuditEntryParams struct {
+	Action     string
+	ActorID    uuid.NullUUID
+	RequestID  uuid.NullUUID
+	NoteID     uuid.NullUUID
+	TagID      uuid.NullUUID
+	UserID     uuid.NullUUID
+	Before     []byte
+	After      []byte
+	OccurredAt pgtype.Timestamptz
 }
 
-func (q *Queries) GetUserTagAccess(ctx context.Context, db DBTX, arg GetUserTagAccessParams) (NotesAccessLevel, error) {
-	row := db.QueryRow(ctx, getUserTagAccess, arg.TagID, arg.UserID)
-	var access NotesAccessLevel
-	err := row.Scan(&access)
-	return access, err
+func (q *Queries) AppendAuditEntry(ctx context.Context, db DBTX, arg AppendAuditEntryParams) error {
+	_, err := db.Exec(ctx, appendAuditEntry,
+		arg.Action,
+		arg.ActorID,
+		arg.RequestID,
+		arg.NoteID,
+		arg.TagID,
+		arg.UserID,
+		arg.Before,
+		arg.After,
+		arg.OccurredAt,
+	)
+	return err
 }
 
-const listNotes = `-- name: ListNotes :many
+const appendEvent = `-- name: AppendEvent :one
+INSERT INTO notes.events (
+  kind,
+  note_id,
+  tag_id,
+  audience,
+  occurred_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4::uuid[],
+  $5
+)
+RETURNING event_id
+`
+
+type AppendEventParams struct {
+	Kind       string
+	NoteID     uuid.NullUUID
+	TagID      uuid.NullUUID
+	Audience   []uuid.UUID
+	OccurredAt pgtype.Timestamptz
+}
+
+func (q *Queries) AppendEvent(ctx context.Context, db DBTX, arg AppendEventParams) (int64, error) {
+	row := db.QueryRow(ctx, appendEvent,
+		arg.Kind,
+		arg.NoteID,
+		arg.TagID,
+		arg.Audience,
+		arg.OccurredAt,
+	)
+	var event_id int64
+	err := row.Scan(&event_id)
+	return event_id, err
+}
+
+const appendOutboxMessage = `-- name: AppendOutboxMessage :exec
+INSERT INTO notes.outbox (
+  topic,
+  payload,
+  created_at,
+  available_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $3
+)
+`
+
+type AppendOutboxMessageParams struct {
+	Topic     string
+	Payload   []byte
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) AppendOutboxMessage(ctx context.Context, db DBTX, arg AppendOutboxMessageParams) error {
+	_, err := db.Exec(ctx, appendOutboxMessage, arg.Topic, arg.Payload, arg.CreatedAt)
+	return err
+}
+
+const cancelJob = `-- name: CancelJob :one
+UPDATE notes.jobs
+SET cancel_requested = TRUE,
+    -- a running job is cancelled by its worker, once it notices
+    status           = CASE WHEN status = 'pending' THEN 'cancelled' ELSE status END,
+    finished_at      = CASE WHEN status = 'pending' THEN $1 ELSE finished_at END
+WHERE job_id = $2
+  AND status IN ('pending', 'running')
+RETURNING
+  job_id,
+  kind,
+  user_id,
+  payload,
+  status,
+  attempts,
+  max_attempts,
+  run_at,
+  locked_until,
+  cancel_requested,
+  progress_done,
+  progress_total,
+  error,
+  created_at,
+  started_at,
+  finished_at
+`
+
+type CancelJobParams struct {
+	Now   pgtype.Timestamptz
+	JobID uuid.UUID
+}
+
+func (q *Queries) CancelJob(ctx context.Context, db DBTX, arg CancelJobParams) (NotesJob, error) {
+	row := db.QueryRow(ctx, cancelJob, arg.Now, arg.JobID)
+	var i NotesJob
+	err := row.Scan(
+		&i.JobID,
+		&i.Kind,
+		&i.UserID,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.CancelRequested,
+		&i.ProgressDone,
+		&i.ProgressTotal,
+		&i.Error,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const claimDueReminders = `-- name: ClaimDueReminders :many
 SELECT
-  notes.note_id,
-  title
-FROM notes.notes
+  reminders.note_id,
+  reminders.user_id,
+  reminders.remind_at,
+  reminders.recurrence,
+  reminders.next_at,
+  reminders.created_at,
+  reminders.updated_at,
+  notes.title
+FROM notes.reminders
+JOIN notes.notes ON
+  notes.note_id = reminders.note_id
+-- users are only reminded of notes they can still access
 JOIN notes.user_note_access ON
-  user_note_access.note_id = notes.note_id
-  AND user_id = $1
+  user_note_access.note_id = reminders.note_id
+  AND user_note_access.user_id = reminders.user_id
   -- NOTE: any access
-WHERE $2::uuid IS NULL OR notes.note_id > $2::uuid
-ORDER BY notes.note_id ASC
-LIMIT $3
+WHERE reminders.next_at <= $1
+ORDER BY reminders.next_at
+LIMIT $2
+FOR UPDATE OF reminders SKIP LOCKED
 `
 
-type ListNotesParams struct {
-	UserID     uuid.UUID
-	LastNoteID uuid.NullUUID
-	PageSize   int64
+type ClaimDueRemindersParams struct {
+	Now      pgtype.Timestamptz
+	PageSize int64
 }
 
-type ListNotesRow struct {
-	NoteID uuid.UUID
-	Title  string
+type ClaimDueRemindersRow struct {
+	NoteID     uuid.UUID
+	UserID     uuid.UUID
+	RemindAt   pgtype.Timestamptz
+	Recurrence pgtype.Text
+	NextAt     pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+	Title      string
 }
 
-func (q *Queries) ListNotes(ctx context.Context, db DBTX, arg ListNotesParams) ([]ListNotesRow, error) {
-	rows, err := db.Query(ctx, listNotes, arg.UserID, arg.LastNoteID, arg.PageSize)
+func (q *Queries) ClaimDueReminders(ctx context.Context, db DBTX, arg ClaimDueRemindersParams) ([]ClaimDueRemindersRow, error) {
+	rows, err := db.Query(ctx, claimDueReminders, arg.Now, arg.PageSize)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListNotesRow
+	var items []ClaimDueRemindersRow
 	for rows.Next() {
-		var i ListNotesRow
-		if err := rows.Scan(&i.NoteID, &i.Title); err != nil {
+		var i ClaimDueRemindersRow
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.UserID,
+			&i.RemindAt,
+			&i.Recurrence,
+			&i.NextAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -322,49 +558,204 @@ func (q *Queries) ListNotes(ctx context.Context, db DBTX, arg ListNotesParams) (
 	return items, nil
 }
 
-const listTags = `-- name: ListTags :many
+const claimJob = `-- name: ClaimJob :one
+WITH next AS (
+  SELECT
+    job_id
+  FROM notes.jobs
+  WHERE kind = ANY($1::text[])
+    AND (
+      (status = 'pending' AND run_at <= $2)
+      -- a running job whose lease has expired was interrupted
+      OR (status = 'running' AND locked_until <= $2)
+    )
+  ORDER BY run_at ASC
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+UPDATE notes.jobs
+SET status         = 'running',
+    attempts       = jobs.attempts + 1,
+    started_at     = $2,
+    locked_until   = $3,
+    progress_done  = 0,
+    progress_total = NULL
+FROM next
+WHERE jobs.job_id = next.job_id
+RETURNING
+  jobs.job_id,
+  jobs.kind,
+  jobs.user_id,
+  jobs.payload,
+  jobs.status,
+  jobs.attempts,
+  jobs.max_attempts,
+  jobs.run_at,
+  jobs.locked_until,
+  jobs.cancel_requested,
+  jobs.progress_done,
+  jobs.progress_total,
+  jobs.error,
+  jobs.created_at,
+  jobs.started_at,
+  jobs.finished_at
+`
+
+type ClaimJobParams struct {
+	Kinds      []string
+	Now        pgtype.Timestamptz
+	LeaseUntil pgtype.Timestamptz
+}
+
+func (q *Queries) ClaimJob(ctx context.Context, db DBTX, arg ClaimJobParams) (NotesJob, error) {
+	row := db.QueryRow(ctx, claimJob, arg.Kinds, arg.Now, arg.LeaseUntil)
+	var i NotesJob
+	err := row.Scan(
+		&i.JobID,
+		&i.Kind,
+		&i.UserID,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.CancelRequested,
+		&i.ProgressDone,
+		&i.ProgressTotal,
+		&i.Error,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const claimOutboxMessages = `-- name: ClaimOutboxMessages :many
 SELECT
-  tags.tag_id,
-  name,
-  access
-FROM notes.tags
-JOIN notes.user_tag_access ON 
-  tags.tag_id = user_tag_access.tag_id
-  AND user_id = $1 -- NOTE: any access
-WHERE ($2::uuid IS NULL OR tags.tag_id > $2::uuid)
-  AND LOWER(name) LIKE '%' || LOWER($3) || '%'
-ORDER BY tags.tag_id ASC
-LIMIT $4
+  message_id,
+  topic,
+  payload,
+  created_at,
+  attempts
+FROM notes.outbox
+WHERE published_at IS NULL
+  AND abandoned_at IS NULL
+  AND available_at <= $1
+  -- messages about topics nothing subscribes to yet are left until something does
+  AND topic = ANY($2::text[])
+ORDER BY message_id ASC
+LIMIT $3
+FOR UPDATE SKIP LOCKED
 `
 
-type ListTagsParams struct {
-	UserID       uuid.UUID
-	LastTagID    uuid.NullUUID
-	SearchString string
-	PageSize     int64
+type ClaimOutboxMessagesParams struct {
+	Now      pgtype.Timestamptz
+	Topics   []string
+	PageSize int64
 }
 
-type ListTagsRow struct {
-	TagID  uuid.UUID
-	Name   string
-	Access NotesAccessLevel
+type ClaimOutboxMessagesRow struct {
+	MessageID int64
+	Topic     string
+	Payload   []byte
+	CreatedAt pgtype.Timestamptz
+	Attempts  int32
 }
 
-func (q *Queries) ListTags(ctx context.Context, db DBTX, arg ListTagsParams) ([]ListTagsRow, error) {
-	rows, err := db.Query(ctx, listTags,
-		arg.UserID,
-		arg.LastTagID,
-		arg.SearchString,
-		arg.PageSize,
-	)
+func (q *Queries) ClaimOutboxMessages(ctx context.Context, db DBTX, arg ClaimOutboxMessagesParams) ([]ClaimOutboxMessagesRow, error) {
+	rows, err := db.Query(ctx, claimOutboxMessages, arg.Now, arg.Topics, arg.PageSize)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListTagsRow
+	var items []ClaimOutboxMessagesRow
 	for rows.Next() {
-		var i ListTagsRow
-		if err := rows.Scan(&i.TagID, &i.Name, &i.Access); err != nil {
+		var i ClaimOutboxMessagesRow
+		if err := rows.Scan(
+			&i.MessageID,
+			&i.Topic,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimWebhookDeliveries = `-- name: ClaimWebhookDeliveries :many
+WITH due AS (
+  SELECT
+    webhook_deliveries.delivery_id
+  FROM notes.webhook_deliveries
+  JOIN notes.webhooks ON
+    webhooks.webhook_id = webhook_deliveries.webhook_id
+  WHERE webhook_deliveries.status = 'pending'
+    AND webhook_deliveries.next_attempt_at <= $1
+    AND webhooks.active
+  ORDER BY webhook_deliveries.next_attempt_at ASC
+  LIMIT $2
+  FOR UPDATE OF webhook_deliveries SKIP LOCKED
+)
+UPDATE notes.webhook_deliveries
+-- if the attempt is interrupted, it is retried once this lease expires
+SET next_attempt_at = $3
+FROM due, notes.webhooks
+WHERE webhook_deliveries.delivery_id = due.delivery_id
+  AND webhooks.webhook_id = webhook_deliveries.webhook_id
+RETURNING
+  webhook_deliveries.delivery_id,
+  webhook_deliveries.webhook_id,
+  webhook_deliveries.event_id,
+  webhook_deliveries.event_kind,
+  webhook_deliveries.payload,
+  webhook_deliveries.attempts,
+  webhooks.url,
+  webhooks.secret
+`
+
+type ClaimWebhookDeliveriesParams struct {
+	Now        pgtype.Timestamptz
+	PageSize   int64
+	LeaseUntil pgtype.Timestamptz
+}
+
+type ClaimWebhookDeliveriesRow struct {
+	DeliveryID uuid.UUID
+	WebhookID  uuid.UUID
+	EventID    int64
+	EventKind  string
+	Payload    []byte
+	Attempts   int32
+	Url        string
+	Secret     string
+}
+
+func (q *Queries) ClaimWebhookDeliveries(ctx context.Context, db DBTX, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error) {
+	rows, err := db.Query(ctx, claimWebhookDeliveries, arg.Now, arg.PageSize, arg.LeaseUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimWebhookDeliveriesRow
+	for rows.Next() {
+		var i ClaimWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.DeliveryID,
+			&i.WebhookID,
+			&i.EventID,
+			&i.EventKind,
+			&i.Payload,
+			&i.Attempts,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -375,15 +766,3766 @@ func (q *Queries) ListTags(ctx context.Context, db DBTX, arg ListTagsParams) ([]
 	return items, nil
 }
 
-const saveNote = `-- name: SaveNote :exec
-INSERT INTO notes.notes (
+const clearCommentMentions = `-- name: ClearCommentMentions :exec
+DELETE FROM notes.comment_mentions
+WHERE comment_id = $1
+`
+
+func (q *Queries) ClearCommentMentions(ctx context.Context, db DBTX, commentID uuid.UUID) error {
+	_, err := db.Exec(ctx, clearCommentMentions, commentID)
+	return err
+}
+
+const clearImportFailures = `-- name: ClearImportFailures :exec
+DELETE FROM notes.import_failures
+WHERE import_id = $1
+`
+
+func (q *Queries) ClearImportFailures(ctx context.Context, db DBTX, importID uuid.UUID) error {
+	_, err := db.Exec(ctx, clearImportFailures, importID)
+	return err
+}
+
+const clearNoteLinks = `-- name: ClearNoteLinks :exec
+DELETE FROM notes.note_links
+WHERE source_note_id = $1
+`
+
+func (q *Queries) ClearNoteLinks(ctx context.Context, db DBTX, sourceNoteID uuid.UUID) error {
+	_, err := db.Exec(ctx, clearNoteLinks, sourceNoteID)
+	return err
+}
+
+const clearNoteTasks = `-- name: ClearNoteTasks :exec
+DELETE FROM notes.note_tasks
+WHERE note_id = $1
+`
+
+func (q *Queries) ClearNoteTasks(ctx context.Context, db DBTX, noteID uuid.UUID) error {
+	_, err := db.Exec(ctx, clearNoteTasks, noteID)
+	return err
+}
+
+const clearReminderDeliveries = `-- name: ClearReminderDeliveries :exec
+DELETE FROM notes.reminder_deliveries
+WHERE note_id = $1
+  AND user_id = $2
+`
+
+type ClearReminderDeliveriesParams struct {
+	NoteID uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) ClearReminderDeliveries(ctx context.Context, db DBTX, arg ClearReminderDeliveriesParams) error {
+	_, err := db.Exec(ctx, clearReminderDeliveries, arg.NoteID, arg.UserID)
+	return err
+}
+
+const clearSavedSearchMatches = `-- name: ClearSavedSearchMatches :exec
+DELETE FROM notes.saved_search_matches
+WHERE saved_search_id = $1
+`
+
+func (q *Queries) ClearSavedSearchMatches(ctx context.Context, db DBTX, savedSearchID uuid.UUID) error {
+	_, err := db.Exec(ctx, clearSavedSearchMatches, savedSearchID)
+	return err
+}
+
+const clearTemplateAccess = `-- name: ClearTemplateAccess :exec
+DELETE FROM notes.user_template_access
+WHERE template_id = $1
+`
+
+func (q *Queries) ClearTemplateAccess(ctx context.Context, db DBTX, templateID uuid.UUID) error {
+	_, err := db.Exec(ctx, clearTemplateAccess, templateID)
+	return err
+}
+
+const clearTemplateNoteAccess = `-- name: ClearTemplateNoteAccess :exec
+DELETE FROM notes.template_note_access
+WHERE template_id = $1
+`
+
+func (q *Queries) ClearTemplateNoteAccess(ctx context.Context, db DBTX, templateID uuid.UUID) error {
+	_, err := db.Exec(ctx, clearTemplateNoteAccess, templateID)
+	return err
+}
+
+const clearTemplateTags = `-- name: ClearTemplateTags :exec
+DELETE FROM notes.template_tags
+WHERE template_id = $1
+`
+
+func (q *Queries) ClearTemplateTags(ctx context.Context, db DBTX, templateID uuid.UUID) error {
+	_, err := db.Exec(ctx, clearTemplateTags, templateID)
+	return err
+}
+
+const countStaleExtractions = `-- name: CountStaleExtractions :one
+SELECT COUNT(*)
+FROM notes.attachments
+JOIN notes.attachment_texts ON
+  attachment_texts.attachment_id = attachments.attachment_id
+JOIN notes.user_note_access ON
+  user_note_access.note_id = attachments.note_id
+WHERE user_note_access.user_id = $1
+  AND user_note_access.access = 'owner'
+  AND attachment_texts.extracted_at < $2
+`
+
+type CountStaleExtractionsParams struct {
+	UserID          uuid.UUID
+	ExtractedBefore pgtype.Timestamptz
+}
+
+func (q *Queries) CountStaleExtractions(ctx context.Context, db DBTX, arg CountStaleExtractionsParams) (int64, error) {
+	row := db.QueryRow(ctx, countStaleExtractions, arg.UserID, arg.ExtractedBefore)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAttachment = `-- name: CreateAttachment :exec
+INSERT INTO notes.attachments (
+  attachment_id,
+  note_id,
+  name,
+  content_type,
+  size,
+  blob_key,
+  created_at,
+  created_by
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
+  $8
+)
+`
+
+type CreateAttachmentParams struct {
+	AttachmentID uuid.UUID
+	NoteID       uuid.NullUUID
+	Name         string
+	ContentType  string
+	Size         int64
+	BlobKey      string
+	CreatedAt    pgtype.Timestamptz
+	CreatedBy    uuid.NullUUID
+}
+
+func (q *Queries) CreateAttachment(ctx context.Context, db DBTX, arg CreateAttachmentParams) error {
+	_, err := db.Exec(ctx, createAttachment,
+		arg.AttachmentID,
+		arg.NoteID,
+		arg.Name,
+		arg.ContentType,
+		arg.Size,
+		arg.BlobKey,
+		arg.CreatedAt,
+		arg.CreatedBy,
+	)
+	return err
+}
+
+const createImport = `-- name: CreateImport :exec
+INSERT INTO notes.imports (
+  import_id,
+  job_id,
+  user_id,
+  format,
+  status,
+  blob_key,
+  created_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  'pending',
+  $5,
+  $6
+)
+`
+
+type CreateImportParams struct {
+	ImportID  uuid.UUID
+	JobID     uuid.UUID
+	UserID    uuid.UUID
+	Format    NotesImportFormat
+	BlobKey   string
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) CreateImport(ctx context.Context, db DBTX, arg CreateImportParams) error {
+	_, err := db.Exec(ctx, createImport,
+		arg.ImportID,
+		arg.JobID,
+		arg.UserID,
+		arg.Format,
+		arg.BlobKey,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const createJob = `-- name: CreateJob :exec
+INSERT INTO notes.jobs (
+  job_id,
+  kind,
+  user_id,
+  payload,
+  status,
+  max_attempts,
+  run_at,
+  created_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  'pending',
+  $5,
+  $6,
+  $7
+)
+`
+
+type CreateJobParams struct {
+	JobID       uuid.UUID
+	Kind        string
+	UserID      uuid.NullUUID
+	Payload     []byte
+	MaxAttempts int32
+	RunAt       pgtype.Timestamptz
+	CreatedAt   pgtype.Timestamptz
+}
+
+func (q *Queries) CreateJob(ctx context.Context, db DBTX, arg CreateJobParams) error {
+	_, err := db.Exec(ctx, createJob,
+		arg.JobID,
+		arg.Kind,
+		arg.UserID,
+		arg.Payload,
+		arg.MaxAttempts,
+		arg.RunAt,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const createThread = `-- name: CreateThread :exec
+INSERT INTO notes.comment_threads (
+  thread_id,
+  note_id,
+  anchor_start,
+  anchor_end,
+  anchor_quote,
+  created_at,
+  created_by
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7
+)
+`
+
+type CreateThreadParams struct {
+	ThreadID    uuid.UUID
+	NoteID      uuid.UUID
+	AnchorStart pgtype.Int4
+	AnchorEnd   pgtype.Int4
+	AnchorQuote pgtype.Text
+	CreatedAt   pgtype.Timestamptz
+	CreatedBy   uuid.NullUUID
+}
+
+func (q *Queries) CreateThread(ctx context.Context, db DBTX, arg CreateThreadParams) error {
+	_, err := db.Exec(ctx, createThread,
+		arg.ThreadID,
+		arg.NoteID,
+		arg.AnchorStart,
+		arg.AnchorEnd,
+		arg.AnchorQuote,
+		arg.CreatedAt,
+		arg.CreatedBy,
+	)
+	return err
+}
+
+const createUserToken = `-- name: CreateUserToken :one
+INSERT INTO notes.user_tokens (
+  token_id,
+  user_id,
+  name,
+  token_hash,
+  created_at,
+  expires_at
+)
+SELECT
+  $1,
+  users.user_id,
+  $2,
+  $3,
+  $4,
+  $5
+FROM notes.users
+WHERE users.user_id = $6
+  AND users.active
+RETURNING
+  token_id,
+  user_id,
+  name,
+  token_hash,
+  created_at,
+  expires_at
+`
+
+type CreateUserTokenParams struct {
+	TokenID   uuid.UUID
+	Name      string
+	TokenHash string
+	CreatedAt pgtype.Timestamptz
+	ExpiresAt pgtype.Timestamptz
+	UserID    uuid.UUID
+}
+
+func (q *Queries) CreateUserToken(ctx context.Context, db DBTX, arg CreateUserTokenParams) (NotesUserToken, error) {
+	row := db.QueryRow(ctx, createUserToken,
+		arg.TokenID,
+		arg.Name,
+		arg.TokenHash,
+		arg.CreatedAt,
+		arg.ExpiresAt,
+		arg.UserID,
+	)
+	var i NotesUserToken
+	err := row.Scan(
+		&i.TokenID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const deleteComment = `-- name: DeleteComment :execrows
+DELETE FROM notes.comments
+WHERE comment_id = $1
+  AND thread_id = $2
+`
+
+type DeleteCommentParams struct {
+	CommentID uuid.UUID
+	ThreadID  uuid.UUID
+}
+
+func (q *Queries) DeleteComment(ctx context.Context, db DBTX, arg DeleteCommentParams) (int64, error) {
+	result, err := db.Exec(ctx, deleteComment, arg.CommentID, arg.ThreadID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteEmptyThread = `-- name: DeleteEmptyThread :exec
+DELETE FROM notes.comment_threads
+WHERE thread_id = $1
+  AND NOT EXISTS (
+    SELECT 1
+    FROM notes.comments
+    WHERE comments.thread_id = comment_threads.thread_id
+  )
+`
+
+func (q *Queries) DeleteEmptyThread(ctx context.Context, db DBTX, threadID uuid.UUID) error {
+	_, err := db.Exec(ctx, deleteEmptyThread, threadID)
+	return err
+}
+
+const deleteEventsBefore = `-- name: DeleteEventsBefore :execrows
+DELETE FROM notes.events
+WHERE occurred_at < $1
+`
+
+func (q *Queries) DeleteEventsBefore(ctx context.Context, db DBTX, occurredBefore pgtype.Timestamptz) (int64, error) {
+	result, err := db.Exec(ctx, deleteEventsBefore, occurredBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteNote = `-- name: DeleteNote :execrows
+DELETE FROM notes.notes
+WHERE note_id = $1
+`
+
+func (q *Queries) DeleteNote(ctx context.Context, db DBTX, noteID uuid.UUID) (int64, error) {
+	result, err := db.Exec(ctx, deleteNote, noteID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteOrphanedAttachment = `-- name: DeleteOrphanedAttachment :exec
+DELETE FROM notes.attachments
+WHERE attachment_id = $1
+  AND note_id IS NULL
+`
+
+func (q *Queries) DeleteOrphanedAttachment(ctx context.Context, db DBTX, attachmentID uuid.UUID) error {
+	_, err := db.Exec(ctx, deleteOrphanedAttachment, attachmentID)
+	return err
+}
+
+const deleteOutboxMessagesBefore = `-- name: DeleteOutboxMessagesBefore :execrows
+DELETE FROM notes.outbox
+WHERE created_at < $1
+  AND (published_at IS NOT NULL OR abandoned_at IS NOT NULL)
+`
+
+func (q *Queries) DeleteOutboxMessagesBefore(ctx context.Context, db DBTX, createdBefore pgtype.Timestamptz) (int64, error) {
+	result, err := db.Exec(ctx, deleteOutboxMessagesBefore, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deletePublication = `-- name: DeletePublication :execrows
+DELETE FROM notes.note_publications
+WHERE note_id = $1
+`
+
+func (q *Queries) DeletePublication(ctx context.Context, db DBTX, noteID uuid.UUID) (int64, error) {
+	result, err := db.Exec(ctx, deletePublication, noteID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteReminder = `-- name: DeleteReminder :execrows
+DELETE FROM notes.reminders
+WHERE note_id = $1
+  AND user_id = $2
+`
+
+type DeleteReminderParams struct {
+	NoteID uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteReminder(ctx context.Context, db DBTX, arg DeleteReminderParams) (int64, error) {
+	result, err := db.Exec(ctx, deleteReminder, arg.NoteID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSavedSearch = `-- name: DeleteSavedSearch :execrows
+DELETE FROM notes.saved_searches
+WHERE saved_search_id = $1
+`
+
+func (q *Queries) DeleteSavedSearch(ctx context.Context, db DBTX, savedSearchID uuid.UUID) (int64, error) {
+	result, err := db.Exec(ctx, deleteSavedSearch, savedSearchID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTag = `-- name: DeleteTag :execrows
+DELETE FROM notes.tags
+WHERE tag_id = $1
+`
+
+func (q *Queries) DeleteTag(ctx context.Context, db DBTX, tagID uuid.UUID) (int64, error) {
+	result, err := db.Exec(ctx, deleteTag, tagID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteTemplate = `-- name: DeleteTemplate :execrows
+DELETE FROM notes.templates
+WHERE template_id = $1
+`
+
+func (q *Queries) DeleteTemplate(ctx context.Context, db DBTX, templateID uuid.UUID) (int64, error) {
+	result, err := db.Exec(ctx, deleteTemplate, templateID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM notes.users
+WHERE
+  user_id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, db DBTX, userID uuid.UUID) (int64, error) {
+	result, err := db.Exec(ctx, deleteUser, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :execrows
+DELETE FROM notes.webhooks
+WHERE webhook_id = $1
+`
+
+func (q *Queries) DeleteWebhook(ctx context.Context, db DBTX, webhookID uuid.UUID) (int64, error) {
+	result, err := db.Exec(ctx, deleteWebhook, webhookID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const detachAttachment = `-- name: DetachAttachment :execrows
+UPDATE notes.attachments
+  SET note_id = NULL
+WHERE attachment_id = $1
+  AND note_id = $2
+`
+
+type DetachAttachmentParams struct {
+	AttachmentID uuid.UUID
+	NoteID       uuid.NullUUID
+}
+
+func (q *Queries) DetachAttachment(ctx context.Context, db DBTX, arg DetachAttachmentParams) (int64, error) {
+	result, err := db.Exec(ctx, detachAttachment, arg.AttachmentID, arg.NoteID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const enqueueWebhookDelivery = `-- name: EnqueueWebhookDelivery :exec
+INSERT INTO notes.webhook_deliveries (
+  delivery_id,
+  webhook_id,
+  event_id,
+  event_kind,
+  payload,
+  status,
+  next_attempt_at,
+  created_at
+)
+SELECT
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  'pending',
+  $6,
+  $6
+-- the event may be handled again, if recording that it was handled failed
+WHERE NOT EXISTS (
+  SELECT 1
+  FROM notes.webhook_deliveries
+  WHERE webhook_id = $2
+    AND event_id = $3
+)
+`
+
+type EnqueueWebhookDeliveryParams struct {
+	DeliveryID uuid.UUID
+	WebhookID  uuid.UUID
+	EventID    int64
+	EventKind  string
+	Payload    []byte
+	CreatedAt  pgtype.Timestamptz
+}
+
+func (q *Queries) EnqueueWebhookDelivery(ctx context.Context, db DBTX, arg EnqueueWebhookDeliveryParams) error {
+	_, err := db.Exec(ctx, enqueueWebhookDelivery,
+		arg.DeliveryID,
+		arg.WebhookID,
+		arg.EventID,
+		arg.EventKind,
+		arg.Payload,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const findMentionableUsers = `-- name: FindMentionableUsers :many
+SELECT
+  users.user_id,
+  users.name
+FROM notes.users
+JOIN notes.user_note_access ON
+  user_note_access.user_id = users.user_id
+  AND user_note_access.note_id = $1
+  -- NOTE: any access
+WHERE lower(users.name) = ANY($2::text[])
+`
+
+type FindMentionableUsersParams struct {
+	NoteID uuid.UUID
+	Names  []string
+}
+
+type FindMentionableUsersRow struct {
+	UserID uuid.UUID
+	Name   string
+}
+
+func (q *Queries) FindMentionableUsers(ctx context.Context, db DBTX, arg FindMentionableUsersParams) ([]FindMentionableUsersRow, error) {
+	rows, err := db.Query(ctx, findMentionableUsers, arg.NoteID, arg.Names)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindMentionableUsersRow
+	for rows.Next() {
+		var i FindMentionableUsersRow
+		if err := rows.Scan(&i.UserID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const finishImport = `-- name: FinishImport :exec
+UPDATE notes.imports
+SET status      = $1,
+    finished_at = $2,
+    error       = $3
+WHERE import_id = $4
+`
+
+type FinishImportParams struct {
+	Status     NotesImportStatus
+	FinishedAt pgtype.Timestamptz
+	Error      pgtype.Text
+	ImportID   uuid.UUID
+}
+
+func (q *Queries) FinishImport(ctx context.Context, db DBTX, arg FinishImportParams) error {
+	_, err := db.Exec(ctx, finishImport,
+		arg.Status,
+		arg.FinishedAt,
+		arg.Error,
+		arg.ImportID,
+	)
+	return err
+}
+
+const finishJob = `-- name: FinishJob :execrows
+UPDATE notes.jobs
+SET status       = $1,
+    finished_at  = $2,
+    locked_until = NULL,
+    error        = $3
+WHERE job_id = $4
+  AND status = 'running'
+  AND attempts = $5
+`
+
+type FinishJobParams struct {
+	Status     NotesJobStatus
+	FinishedAt pgtype.Timestamptz
+	Error      pgtype.Text
+	JobID      uuid.UUID
+	Attempt    int32
+}
+
+func (q *Queries) FinishJob(ctx context.Context, db DBTX, arg FinishJobParams) (int64, error) {
+	result, err := db.Exec(ctx, finishJob,
+		arg.Status,
+		arg.FinishedAt,
+		arg.Error,
+		arg.JobID,
+		arg.Attempt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getAttachment = `-- name: GetAttachment :one
+SELECT
+  attachment_id,
+  note_id,
+  name,
+  content_type,
+  size,
+  blob_key,
+  created_at,
+  created_by
+FROM notes.attachments
+WHERE attachment_id = $1
+  AND note_id = $2
+`
+
+type GetAttachmentParams struct {
+	AttachmentID uuid.UUID
+	NoteID       uuid.NullUUID
+}
+
+func (q *Queries) GetAttachment(ctx context.Context, db DBTX, arg GetAttachmentParams) (NotesAttachment, error) {
+	row := db.QueryRow(ctx, getAttachment, arg.AttachmentID, arg.NoteID)
+	var i NotesAttachment
+	err := row.Scan(
+		&i.AttachmentID,
+		&i.NoteID,
+		&i.Name,
+		&i.ContentType,
+		&i.Size,
+		&i.BlobKey,
+		&i.CreatedAt,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const getBacklinks = `-- name: GetBacklinks :many
+SELECT
+  notes.note_id,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by,
+  title
+FROM notes.note_links
+JOIN notes.notes ON
+  notes.note_id = note_links.source_note_id
+JOIN notes.user_note_access ON
+  user_note_access.note_id = notes.note_id
+  AND user_id = $1
+  -- NOTE: any access
+WHERE note_links.target_note_id = $2
+  AND ($3::uuid IS NULL OR notes.note_id > $3::uuid)
+ORDER BY notes.note_id
+LIMIT $4
+`
+
+type GetBacklinksParams struct {
+	UserID       uuid.UUID
+	TargetNoteID uuid.NullUUID
+	LastNoteID   uuid.NullUUID
+	PageSize     int64
+}
+
+type GetBacklinksRow struct {
+	NoteID    uuid.UUID
+	CreatedAt pgtype.Timestamptz
+	CreatedBy uuid.NullUUID
+	UpdatedAt pgtype.Timestamptz
+	UpdatedBy uuid.NullUUID
+	Title     string
+}
+
+func (q *Queries) GetBacklinks(ctx context.Context, db DBTX, arg GetBacklinksParams) ([]GetBacklinksRow, error) {
+	rows, err := db.Query(ctx, getBacklinks,
+		arg.UserID,
+		arg.TargetNoteID,
+		arg.LastNoteID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBacklinksRow
+	for rows.Next() {
+		var i GetBacklinksRow
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.CreatedAt,
+			&i.CreatedBy,
+			&i.UpdatedAt,
+			&i.UpdatedBy,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getComment = `-- name: GetComment :one
+SELECT
+  comment_id,
+  thread_id,
+  created_at,
+  created_by,
+  updated_at,
+  body
+FROM notes.comments
+WHERE comment_id = $1
+  AND thread_id = $2
+`
+
+type GetCommentParams struct {
+	CommentID uuid.UUID
+	ThreadID  uuid.UUID
+}
+
+func (q *Queries) GetComment(ctx context.Context, db DBTX, arg GetCommentParams) (NotesComment, error) {
+	row := db.QueryRow(ctx, getComment, arg.CommentID, arg.ThreadID)
+	var i NotesComment
+	err := row.Scan(
+		&i.CommentID,
+		&i.ThreadID,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.UpdatedAt,
+		&i.Body,
+	)
+	return i, err
+}
+
+const getEvent = `-- name: GetEvent :one
+SELECT
+  event_id,
+  kind,
+  note_id,
+  tag_id,
+  audience,
+  occurred_at
+FROM notes.events
+WHERE event_id = $1
+`
+
+func (q *Queries) GetEvent(ctx context.Context, db DBTX, eventID int64) (NotesEvent, error) {
+	row := db.QueryRow(ctx, getEvent, eventID)
+	var i NotesEvent
+	err := row.Scan(
+		&i.EventID,
+		&i.Kind,
+		&i.NoteID,
+		&i.TagID,
+		&i.Audience,
+		&i.OccurredAt,
+	)
+	return i, err
+}
+
+const getImport = `-- name: GetImport :one
+SELECT
+  import_id,
+  job_id,
+  user_id,
+  format,
+  status,
+  blob_key,
+  created_at,
+  started_at,
+  finished_at,
+  total,
+  created,
+  updated,
+  failed,
+  error
+FROM notes.imports
+WHERE import_id = $1
+`
+
+func (q *Queries) GetImport(ctx context.Context, db DBTX, importID uuid.UUID) (NotesImport, error) {
+	row := db.QueryRow(ctx, getImport, importID)
+	var i NotesImport
+	err := row.Scan(
+		&i.ImportID,
+		&i.JobID,
+		&i.UserID,
+		&i.Format,
+		&i.Status,
+		&i.BlobKey,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.Total,
+		&i.Created,
+		&i.Updated,
+		&i.Failed,
+		&i.Error,
+	)
+	return i, err
+}
+
+const getJob = `-- name: GetJob :one
+SELECT
+  job_id,
+  kind,
+  user_id,
+  payload,
+  status,
+  attempts,
+  max_attempts,
+  run_at,
+  locked_until,
+  cancel_requested,
+  progress_done,
+  progress_total,
+  error,
+  created_at,
+  started_at,
+  finished_at
+FROM notes.jobs
+WHERE job_id = $1
+`
+
+func (q *Queries) GetJob(ctx context.Context, db DBTX, jobID uuid.UUID) (NotesJob, error) {
+	row := db.QueryRow(ctx, getJob, jobID)
+	var i NotesJob
+	err := row.Scan(
+		&i.JobID,
+		&i.Kind,
+		&i.UserID,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.MaxAttempts,
+		&i.RunAt,
+		&i.LockedUntil,
+		&i.CancelRequested,
+		&i.ProgressDone,
+		&i.ProgressTotal,
+		&i.Error,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const getNote = `-- name: GetNote :one
+SELECT
+  note_id,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by,
+  title,
+  body,
+  revision
+FROM notes.notes
+WHERE note_id = $1
+`
+
+type GetNoteRow struct {
+	NoteID    uuid.UUID
+	CreatedAt pgtype.Timestamptz
+	CreatedBy uuid.NullUUID
+	UpdatedAt pgtype.Timestamptz
+	UpdatedBy uuid.NullUUID
+	Title     string
+	Body      string
+	Revision  int64
+}
+
+func (q *Queries) GetNote(ctx context.Context, db DBTX, noteID uuid.UUID) (GetNoteRow, error) {
+	row := db.QueryRow(ctx, getNote, noteID)
+	var i GetNoteRow
+	err := row.Scan(
+		&i.NoteID,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.UpdatedAt,
+		&i.UpdatedBy,
+		&i.Title,
+		&i.Body,
+		&i.Revision,
+	)
+	return i, err
+}
+
+const getNoteAccess = `-- name: GetNoteAccess :many
+SELECT
+  user_note_access.user_id,
+  access
+FROM notes.user_note_access
+JOIN notes.users ON
+  user_note_access.user_id = users.user_id
+WHERE note_id = $1
+`
+
+type GetNoteAccessRow struct {
+	UserID uuid.UUID
+	Access NotesAccessLevel
+}
+
+func (q *Queries) GetNoteAccess(ctx context.Context, db DBTX, noteID uuid.UUID) ([]GetNoteAccessRow, error) {
+	rows, err := db.Query(ctx, getNoteAccess, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNoteAccessRow
+	for rows.Next() {
+		var i GetNoteAccessRow
+		if err := rows.Scan(&i.UserID, &i.Access); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNoteLinks = `-- name: GetNoteLinks :many
+SELECT
+  reference,
+  target_note_id
+FROM notes.note_links
+JOIN notes.user_note_access ON
+  user_note_access.note_id = note_links.target_note_id
+  AND user_id = $1
+  -- NOTE: any access
+WHERE source_note_id = $2
+`
+
+type GetNoteLinksParams struct {
+	UserID       uuid.UUID
+	SourceNoteID uuid.UUID
+}
+
+type GetNoteLinksRow struct {
+	Reference    string
+	TargetNoteID uuid.NullUUID
+}
+
+func (q *Queries) GetNoteLinks(ctx context.Context, db DBTX, arg GetNoteLinksParams) ([]GetNoteLinksRow, error) {
+	rows, err := db.Query(ctx, getNoteLinks, arg.UserID, arg.SourceNoteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNoteLinksRow
+	for rows.Next() {
+		var i GetNoteLinksRow
+		if err := rows.Scan(&i.Reference, &i.TargetNoteID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNoteRevision = `-- name: GetNoteRevision :one
+SELECT
+  note_id,
+  revision,
+  title,
+  body,
+  saved_at,
+  saved_by
+FROM notes.note_revisions
+WHERE note_id = $1
+  AND revision = $2
+`
+
+type GetNoteRevisionParams struct {
+	NoteID   uuid.UUID
+	Revision int64
+}
+
+func (q *Queries) GetNoteRevision(ctx context.Context, db DBTX, arg GetNoteRevisionParams) (NotesNoteRevision, error) {
+	row := db.QueryRow(ctx, getNoteRevision, arg.NoteID, arg.Revision)
+	var i NotesNoteRevision
+	err := row.Scan(
+		&i.NoteID,
+		&i.Revision,
+		&i.Title,
+		&i.Body,
+		&i.SavedAt,
+		&i.SavedBy,
+	)
+	return i, err
+}
+
+const getNoteState = `-- name: GetNoteState :one
+SELECT
+  pinned,
+  starred,
+  archived
+FROM notes.user_note_states
+WHERE note_id = $1
+  AND user_id = $2
+`
+
+type GetNoteStateParams struct {
+	NoteID uuid.UUID
+	UserID uuid.UUID
+}
+
+type GetNoteStateRow struct {
+	Pinned   bool
+	Starred  bool
+	Archived bool
+}
+
+func (q *Queries) GetNoteState(ctx context.Context, db DBTX, arg GetNoteStateParams) (GetNoteStateRow, error) {
+	row := db.QueryRow(ctx, getNoteState, arg.NoteID, arg.UserID)
+	var i GetNoteStateRow
+	err := row.Scan(&i.Pinned, &i.Starred, &i.Archived)
+	return i, err
+}
+
+const getNoteTags = `-- name: GetNoteTags :many
+SELECT
+  tags.tag_id,
+  name
+FROM notes.note_tags
+JOIN notes.tags ON
+  note_tags.tag_id = tags.tag_id
+JOIN notes.user_tag_access ON
+  user_tag_access.tag_id = tags.tag_id
+  AND user_id = $1
+  -- NOTE: any access
+WHERE note_id = $2
+`
+
+type GetNoteTagsParams struct {
+	UserID uuid.UUID
+	NoteID uuid.UUID
+}
+
+func (q *Queries) GetNoteTags(ctx context.Context, db DBTX, arg GetNoteTagsParams) ([]NotesTag, error) {
+	rows, err := db.Query(ctx, getNoteTags, arg.UserID, arg.NoteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesTag
+	for rows.Next() {
+		var i NotesTag
+		if err := rows.Scan(&i.TagID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPublication = `-- name: GetPublication :one
+SELECT
+  note_id,
+  slug,
+  published_at,
+  published_by
+FROM notes.note_publications
+WHERE note_id = $1
+`
+
+func (q *Queries) GetPublication(ctx context.Context, db DBTX, noteID uuid.UUID) (NotesNotePublication, error) {
+	row := db.QueryRow(ctx, getPublication, noteID)
+	var i NotesNotePublication
+	err := row.Scan(
+		&i.NoteID,
+		&i.Slug,
+		&i.PublishedAt,
+		&i.PublishedBy,
+	)
+	return i, err
+}
+
+const getPublishedNote = `-- name: GetPublishedNote :one
+SELECT
+  note_publications.note_id,
+  note_publications.slug,
+  note_publications.published_at,
+  notes.updated_at,
+  notes.title,
+  notes.body
+FROM notes.note_publications
+JOIN notes.notes ON
+  notes.note_id = note_publications.note_id
+WHERE note_publications.slug = $1
+`
+
+type GetPublishedNoteRow struct {
+	NoteID      uuid.UUID
+	Slug        string
+	PublishedAt pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+	Title       string
+	Body        string
+}
+
+func (q *Queries) GetPublishedNote(ctx context.Context, db DBTX, slug string) (GetPublishedNoteRow, error) {
+	row := db.QueryRow(ctx, getPublishedNote, slug)
+	var i GetPublishedNoteRow
+	err := row.Scan(
+		&i.NoteID,
+		&i.Slug,
+		&i.PublishedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Body,
+	)
+	return i, err
+}
+
+const getPublishedNoteLinks = `-- name: GetPublishedNoteLinks :many
+SELECT
+  note_links.reference,
+  note_publications.slug
+FROM notes.note_links
+JOIN notes.note_publications ON
+  note_publications.note_id = note_links.target_note_id
+WHERE note_links.source_note_id = $1
+`
+
+type GetPublishedNoteLinksRow struct {
+	Reference string
+	Slug      string
+}
+
+func (q *Queries) GetPublishedNoteLinks(ctx context.Context, db DBTX, sourceNoteID uuid.UUID) ([]GetPublishedNoteLinksRow, error) {
+	rows, err := db.Query(ctx, getPublishedNoteLinks, sourceNoteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPublishedNoteLinksRow
+	for rows.Next() {
+		var i GetPublishedNoteLinksRow
+		if err := rows.Scan(&i.Reference, &i.Slug); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRelatedNotes = `-- name: GetRelatedNotes :many
+WITH source_tags AS (
+  SELECT tag_id
+  FROM notes.note_tags
+  WHERE note_id = $1
+),
+source_terms AS (
+  SELECT terms.lexeme
+  FROM notes.notes
+  CROSS JOIN LATERAL unnest(notes.search_index) AS terms
+  WHERE notes.note_id = $1
+),
+candidates AS (
+  SELECT
+    notes.note_id,
+    notes.created_at,
+    notes.created_by,
+    notes.updated_at,
+    notes.updated_by,
+    notes.title,
+    (
+      SELECT COUNT(*)
+      FROM notes.note_tags
+      WHERE note_tags.note_id = notes.note_id
+        AND note_tags.tag_id IN (SELECT tag_id FROM source_tags)
+    ) AS shared_tags,
+    (
+      SELECT COUNT(*)
+      FROM notes.note_tags
+      WHERE note_tags.note_id = notes.note_id
+    ) AS num_tags,
+    (
+      SELECT COUNT(*)
+      FROM unnest(notes.search_index) AS terms
+      WHERE terms.lexeme IN (SELECT lexeme FROM source_terms)
+    ) AS shared_terms,
+    length(notes.search_index) AS num_terms,
+    EXISTS (
+      SELECT 1
+      FROM notes.note_links
+      WHERE (note_links.source_note_id = $1 AND note_links.target_note_id = notes.note_id)
+        OR (note_links.source_note_id = notes.note_id AND note_links.target_note_id = $1)
+    ) AS linked
+  FROM notes.notes
+  JOIN notes.user_note_access ON
+    user_note_access.note_id = notes.note_id
+    AND user_id = $2
+    -- NOTE: any access
+  WHERE notes.note_id <> $1
+),
+scored AS (
+  -- the jaccard index of each candidate's tags and terms with those of the source note
+  SELECT
+    note_id,
+    created_at,
+    created_by,
+    updated_at,
+    updated_by,
+    title,
+    COALESCE(shared_tags::float4 / NULLIF(num_tags + (SELECT COUNT(*) FROM source_tags) - shared_tags, 0), 0) AS tag_score,
+    COALESCE(shared_terms::float4 / NULLIF(num_terms + (SELECT COUNT(*) FROM source_terms) - shared_terms, 0), 0) AS term_score,
+    CASE WHEN linked THEN 1 ELSE 0 END AS link_score
+  FROM candidates
+)
+SELECT
+  note_id,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by,
+  title,
+  tag_score::float4,
+  term_score::float4,
+  link_score::float4,
+  (
+    $3::float4 * tag_score
+    + $4::float4 * term_score
+    + $5::float4 * link_score
+  )::float4 AS score
+FROM scored
+WHERE tag_score > 0 OR term_score > 0 OR link_score > 0
+ORDER BY score DESC, note_id
+LIMIT $6
+`
+
+type GetRelatedNotesParams struct {
+	NoteID     uuid.UUID
+	UserID     uuid.UUID
+	TagWeight  float32
+	TermWeight float32
+	LinkWeight float32
+	MaxResults int64
+}
+
+type GetRelatedNotesRow struct {
+	NoteID    uuid.UUID
+	CreatedAt pgtype.Timestamptz
+	CreatedBy uuid.NullUUID
+	UpdatedAt pgtype.Timestamptz
+	UpdatedBy uuid.NullUUID
+	Title     string
+	TagScore  float32
+	TermScore float32
+	LinkScore float32
+	Score     float32
+}
+
+func (q *Queries) GetRelatedNotes(ctx context.Context, db DBTX, arg GetRelatedNotesParams) ([]GetRelatedNotesRow, error) {
+	rows, err := db.Query(ctx, getRelatedNotes,
+		arg.NoteID,
+		arg.UserID,
+		arg.TagWeight,
+		arg.TermWeight,
+		arg.LinkWeight,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRelatedNotesRow
+	for rows.Next() {
+		var i GetRelatedNotesRow
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.CreatedAt,
+			&i.CreatedBy,
+			&i.UpdatedAt,
+			&i.UpdatedBy,
+			&i.Title,
+			&i.TagScore,
+			&i.TermScore,
+			&i.LinkScore,
+			&i.Score,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReminder = `-- name: GetReminder :one
+SELECT
+  note_id,
+  user_id,
+  remind_at,
+  recurrence,
+  next_at,
+  created_at,
+  updated_at
+FROM notes.reminders
+WHERE note_id = $1
+  AND user_id = $2
+`
+
+type GetReminderParams struct {
+	NoteID uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetReminder(ctx context.Context, db DBTX, arg GetReminderParams) (NotesReminder, error) {
+	row := db.QueryRow(ctx, getReminder, arg.NoteID, arg.UserID)
+	var i NotesReminder
+	err := row.Scan(
+		&i.NoteID,
+		&i.UserID,
+		&i.RemindAt,
+		&i.Recurrence,
+		&i.NextAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getReplayStartEventID = `-- name: GetReplayStartEventID :one
+SELECT COALESCE(MIN(events.event_id) - 1, $1::bigint)::bigint AS start_event_id
+FROM notes.events
+JOIN notes.events AS after_event ON after_event.event_id = $1::bigint
+WHERE events.event_id <= after_event.event_id
+  AND events.occurred_at >= after_event.occurred_at - make_interval(secs => $2::float8)
+`
+
+type GetReplayStartEventIDParams struct {
+	AfterEventID int64
+	GraceSeconds float64
+}
+
+func (q *Queries) GetReplayStartEventID(ctx context.Context, db DBTX, arg GetReplayStartEventIDParams) (int64, error) {
+	row := db.QueryRow(ctx, getReplayStartEventID, arg.AfterEventID, arg.GraceSeconds)
+	var start_event_id int64
+	err := row.Scan(&start_event_id)
+	return start_event_id, err
+}
+
+const getSavedSearch = `-- name: GetSavedSearch :one
+SELECT
+  saved_search_id,
+  user_id,
+  name,
+  query,
+  track_matches,
+  created_at,
+  updated_at,
+  last_checked_at
+FROM notes.saved_searches
+WHERE saved_search_id = $1
+`
+
+func (q *Queries) GetSavedSearch(ctx context.Context, db DBTX, savedSearchID uuid.UUID) (NotesSavedSearch, error) {
+	row := db.QueryRow(ctx, getSavedSearch, savedSearchID)
+	var i NotesSavedSearch
+	err := row.Scan(
+		&i.SavedSearchID,
+		&i.UserID,
+		&i.Name,
+		&i.Query,
+		&i.TrackMatches,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastCheckedAt,
+	)
+	return i, err
+}
+
+const getSavedSearchMatches = `-- name: GetSavedSearchMatches :many
+SELECT
+  note_id
+FROM notes.saved_search_matches
+WHERE saved_search_id = $1
+`
+
+func (q *Queries) GetSavedSearchMatches(ctx context.Context, db DBTX, savedSearchID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := db.Query(ctx, getSavedSearchMatches, savedSearchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var note_id uuid.UUID
+		if err := rows.Scan(&note_id); err != nil {
+			return nil, err
+		}
+		items = append(items, note_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTag = `-- name: GetTag :one
+SELECT
+  tag_id,
+  name
+FROM notes.tags
+WHERE tag_id = $1
+`
+
+func (q *Queries) GetTag(ctx context.Context, db DBTX, tagID uuid.UUID) (NotesTag, error) {
+	row := db.QueryRow(ctx, getTag, tagID)
+	var i NotesTag
+	err := row.Scan(&i.TagID, &i.Name)
+	return i, err
+}
+
+const getTagAccess = `-- name: GetTagAccess :many
+SELECT
+  user_id,
+  access
+FROM notes.user_tag_access
+WHERE tag_id = $1
+`
+
+type GetTagAccessRow struct {
+	UserID uuid.UUID
+	Access NotesAccessLevel
+}
+
+func (q *Queries) GetTagAccess(ctx context.Context, db DBTX, tagID uuid.UUID) ([]GetTagAccessRow, error) {
+	rows, err := db.Query(ctx, getTagAccess, tagID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTagAccessRow
+	for rows.Next() {
+		var i GetTagAccessRow
+		if err := rows.Scan(&i.UserID, &i.Access); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTemplate = `-- name: GetTemplate :one
+SELECT
+  template_id,
+  name,
+  title,
+  body,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by
+FROM notes.templates
+WHERE template_id = $1
+`
+
+func (q *Queries) GetTemplate(ctx context.Context, db DBTX, templateID uuid.UUID) (NotesTemplate, error) {
+	row := db.QueryRow(ctx, getTemplate, templateID)
+	var i NotesTemplate
+	err := row.Scan(
+		&i.TemplateID,
+		&i.Name,
+		&i.Title,
+		&i.Body,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.UpdatedAt,
+		&i.UpdatedBy,
+	)
+	return i, err
+}
+
+const getTemplateAccess = `-- name: GetTemplateAccess :many
+SELECT
+  user_id,
+  access
+FROM notes.user_template_access
+WHERE template_id = $1
+`
+
+type GetTemplateAccessRow struct {
+	UserID uuid.UUID
+	Access NotesAccessLevel
+}
+
+func (q *Queries) GetTemplateAccess(ctx context.Context, db DBTX, templateID uuid.UUID) ([]GetTemplateAccessRow, error) {
+	rows, err := db.Query(ctx, getTemplateAccess, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTemplateAccessRow
+	for rows.Next() {
+		var i GetTemplateAccessRow
+		if err := rows.Scan(&i.UserID, &i.Access); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTemplateNoteAccess = `-- name: GetTemplateNoteAccess :many
+SELECT
+  user_id,
+  access
+FROM notes.template_note_access
+WHERE template_id = $1
+`
+
+type GetTemplateNoteAccessRow struct {
+	UserID uuid.UUID
+	Access NotesAccessLevel
+}
+
+func (q *Queries) GetTemplateNoteAccess(ctx context.Context, db DBTX, templateID uuid.UUID) ([]GetTemplateNoteAccessRow, error) {
+	rows, err := db.Query(ctx, getTemplateNoteAccess, templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTemplateNoteAccessRow
+	for rows.Next() {
+		var i GetTemplateNoteAccessRow
+		if err := rows.Scan(&i.UserID, &i.Access); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTemplateTags = `-- name: GetTemplateTags :many
+SELECT
+  tags.tag_id,
+  tags.name
+FROM notes.template_tags
+JOIN notes.tags ON
+  template_tags.tag_id = tags.tag_id
+JOIN notes.user_tag_access ON
+  user_tag_access.tag_id = tags.tag_id
+  AND user_tag_access.user_id = $1
+  -- NOTE: any access
+WHERE template_tags.template_id = $2
+`
+
+type GetTemplateTagsParams struct {
+	UserID     uuid.UUID
+	TemplateID uuid.UUID
+}
+
+func (q *Queries) GetTemplateTags(ctx context.Context, db DBTX, arg GetTemplateTagsParams) ([]NotesTag, error) {
+	rows, err := db.Query(ctx, getTemplateTags, arg.UserID, arg.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesTag
+	for rows.Next() {
+		var i NotesTag
+		if err := rows.Scan(&i.TagID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getThread = `-- name: GetThread :one
+SELECT
+  thread_id,
+  note_id,
+  anchor_start,
+  anchor_end,
+  anchor_quote,
+  created_at,
+  created_by,
+  resolved_at,
+  resolved_by
+FROM notes.comment_threads
+WHERE thread_id = $1
+  AND note_id = $2
+`
+
+type GetThreadParams struct {
+	ThreadID uuid.UUID
+	NoteID   uuid.UUID
+}
+
+func (q *Queries) GetThread(ctx context.Context, db DBTX, arg GetThreadParams) (NotesCommentThread, error) {
+	row := db.QueryRow(ctx, getThread, arg.ThreadID, arg.NoteID)
+	var i NotesCommentThread
+	err := row.Scan(
+		&i.ThreadID,
+		&i.NoteID,
+		&i.AnchorStart,
+		&i.AnchorEnd,
+		&i.AnchorQuote,
+		&i.CreatedAt,
+		&i.CreatedBy,
+		&i.ResolvedAt,
+		&i.ResolvedBy,
+	)
+	return i, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT
+  user_id,
+  name,
+  created_at,
+  last_sign_in,
+  active
+FROM notes.users
+WHERE
+  user_id = $1
+`
+
+func (q *Queries) GetUser(ctx context.Context, db DBTX, noteID uuid.UUID) (NotesUser, error) {
+	row := db.QueryRow(ctx, getUser, noteID)
+	var i NotesUser
+	err := row.Scan(
+		&i.UserID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.LastSignIn,
+		&i.Active,
+	)
+	return i, err
+}
+
+const getUserNoteAccess = `-- name: GetUserNoteAccess :one
+SELECT
+  access
+FROM notes.user_note_access
+WHERE note_id = $1
+  AND user_id = $2
+`
+
+type GetUserNoteAccessParams struct {
+	NoteID uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetUserNoteAccess(ctx context.Context, db DBTX, arg GetUserNoteAccessParams) (NotesAccessLevel, error) {
+	row := db.QueryRow(ctx, getUserNoteAccess, arg.NoteID, arg.UserID)
+	var access NotesAccessLevel
+	err := row.Scan(&access)
+	return access, err
+}
+
+const getUserNotesAccess = `-- name: GetUserNotesAccess :many
+SELECT
+  note_id,
+  access
+FROM notes.user_note_access
+WHERE note_id = ANY($1::uuid[])
+  AND user_id = $2
+`
+
+type GetUserNotesAccessParams struct {
+	NoteIds []uuid.UUID
+	UserID  uuid.UUID
+}
+
+type GetUserNotesAccessRow struct {
+	NoteID uuid.UUID
+	Access NotesAccessLevel
+}
+
+func (q *Queries) GetUserNotesAccess(ctx context.Context, db DBTX, arg GetUserNotesAccessParams) ([]GetUserNotesAccessRow, error) {
+	rows, err := db.Query(ctx, getUserNotesAccess, arg.NoteIds, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserNotesAccessRow
+	for rows.Next() {
+		var i GetUserNotesAccessRow
+		if err := rows.Scan(&i.NoteID, &i.Access); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserTagAccess = `-- name: GetUserTagAccess :one
+SELECT
+  access
+FROM notes.user_tag_access
+WHERE tag_id = $1
+  AND user_id = $2
+`
+
+type GetUserTagAccessParams struct {
+	TagID  uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetUserTagAccess(ctx context.Context, db DBTX, arg GetUserTagAccessParams) (NotesAccessLevel, error) {
+	row := db.QueryRow(ctx, getUserTagAccess, arg.TagID, arg.UserID)
+	var access NotesAccessLevel
+	err := row.Scan(&access)
+	return access, err
+}
+
+const getUserTemplateAccess = `-- name: GetUserTemplateAccess :one
+SELECT
+  access
+FROM notes.user_template_access
+WHERE template_id = $1
+  AND user_id = $2
+`
+
+type GetUserTemplateAccessParams struct {
+	TemplateID uuid.UUID
+	UserID     uuid.UUID
+}
+
+func (q *Queries) GetUserTemplateAccess(ctx context.Context, db DBTX, arg GetUserTemplateAccessParams) (NotesAccessLevel, error) {
+	row := db.QueryRow(ctx, getUserTemplateAccess, arg.TemplateID, arg.UserID)
+	var access NotesAccessLevel
+	err := row.Scan(&access)
+	return access, err
+}
+
+const getUserTokenByHash = `-- name: GetUserTokenByHash :one
+SELECT
+  user_tokens.token_id,
+  user_tokens.user_id,
+  user_tokens.name,
+  user_tokens.token_hash,
+  user_tokens.created_at,
+  user_tokens.expires_at
+FROM notes.user_tokens
+JOIN notes.users ON
+  users.user_id = user_tokens.user_id
+WHERE user_tokens.token_hash = $1
+  AND users.active
+  AND (user_tokens.expires_at IS NULL OR user_tokens.expires_at > $2)
+`
+
+type GetUserTokenByHashParams struct {
+	TokenHash string
+	Now       pgtype.Timestamptz
+}
+
+func (q *Queries) GetUserTokenByHash(ctx context.Context, db DBTX, arg GetUserTokenByHashParams) (NotesUserToken, error) {
+	row := db.QueryRow(ctx, getUserTokenByHash, arg.TokenHash, arg.Now)
+	var i NotesUserToken
+	err := row.Scan(
+		&i.TokenID,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT
+  webhook_id,
+  user_id,
+  url,
+  secret,
+  event_kinds,
+  tag_ids,
+  active,
+  consecutive_failures,
+  disabled_at,
+  created_at,
+  updated_at
+FROM notes.webhooks
+WHERE webhook_id = $1
+`
+
+func (q *Queries) GetWebhook(ctx context.Context, db DBTX, webhookID uuid.UUID) (NotesWebhook, error) {
+	row := db.QueryRow(ctx, getWebhook, webhookID)
+	var i NotesWebhook
+	err := row.Scan(
+		&i.WebhookID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		&i.EventKinds,
+		&i.TagIds,
+		&i.Active,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const grantNoteAccess = `-- name: GrantNoteAccess :exec
+INSERT INTO notes.user_note_access (
+  note_id,
+  user_id,
+  access
+) VALUES (
+  $1,
+  $2,
+  $3::notes.access_level
+) ON CONFLICT (note_id, user_id) DO UPDATE
+  SET access = excluded.access
+`
+
+type GrantNoteAccessParams struct {
+	NoteID uuid.UUID
+	UserID uuid.UUID
+	Access NotesAccessLevel
+}
+
+func (q *Queries) GrantNoteAccess(ctx context.Context, db DBTX, arg GrantNoteAccessParams) error {
+	_, err := db.Exec(ctx, grantNoteAccess, arg.NoteID, arg.UserID, arg.Access)
+	return err
+}
+
+const heartbeatJob = `-- name: HeartbeatJob :one
+UPDATE notes.jobs
+SET locked_until   = $1,
+    progress_done  = $2,
+    progress_total = $3
+WHERE job_id = $4
+  -- only the worker running this attempt owns the job
+  AND status = 'running'
+  AND attempts = $5
+RETURNING cancel_requested
+`
+
+type HeartbeatJobParams struct {
+	LeaseUntil    pgtype.Timestamptz
+	ProgressDone  int32
+	ProgressTotal pgtype.Int4
+	JobID         uuid.UUID
+	Attempt       int32
+}
+
+func (q *Queries) HeartbeatJob(ctx context.Context, db DBTX, arg HeartbeatJobParams) (bool, error) {
+	row := db.QueryRow(ctx, heartbeatJob,
+		arg.LeaseUntil,
+		arg.ProgressDone,
+		arg.ProgressTotal,
+		arg.JobID,
+		arg.Attempt,
+	)
+	var cancel_requested bool
+	err := row.Scan(&cancel_requested)
+	return cancel_requested, err
+}
+
+const listAttachments = `-- name: ListAttachments :many
+SELECT
+  attachment_id,
+  note_id,
+  name,
+  content_type,
+  size,
+  blob_key,
+  created_at,
+  created_by
+FROM notes.attachments
+WHERE note_id = $1
+  AND ($2::uuid IS NULL OR attachment_id > $2::uuid)
+ORDER BY attachment_id ASC
+LIMIT $3
+`
+
+type ListAttachmentsParams struct {
+	NoteID           uuid.NullUUID
+	LastAttachmentID uuid.NullUUID
+	PageSize         int64
+}
+
+func (q *Queries) ListAttachments(ctx context.Context, db DBTX, arg ListAttachmentsParams) ([]NotesAttachment, error) {
+	rows, err := db.Query(ctx, listAttachments, arg.NoteID, arg.LastAttachmentID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesAttachment
+	for rows.Next() {
+		var i NotesAttachment
+		if err := rows.Scan(
+			&i.AttachmentID,
+			&i.NoteID,
+			&i.Name,
+			&i.ContentType,
+			&i.Size,
+			&i.BlobKey,
+			&i.CreatedAt,
+			&i.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEntries = `-- name: ListAuditEntries :many
+SELECT
+  entry_id,
+  action,
+  actor_id,
+  request_id,
+  note_id,
+  tag_id,
+  user_id,
+  before,
+  after,
+  occurred_at
+FROM notes.audit_log
+WHERE ($1::bigint IS NULL OR entry_id < $1::bigint)
+  AND ($2::text IS NULL OR action = $2::text)
+  AND ($3::uuid IS NULL OR actor_id = $3::uuid)
+  AND ($4::uuid IS NULL OR note_id = $4::uuid)
+  AND ($5::uuid IS NULL OR tag_id = $5::uuid)
+  AND ($6::uuid IS NULL OR user_id = $6::uuid)
+ORDER BY entry_id DESC
+LIMIT $7
+`
+
+type ListAuditEntriesParams struct {
+	LastEntryID pgtype.Int8
+	Action      pgtype.Text
+	ActorID     uuid.NullUUID
+	NoteID      uuid.NullUUID
+	TagID       uuid.NullUUID
+	UserID      uuid.NullUUID
+	PageSize    int64
+}
+
+func (q *Queries) ListAuditEntries(ctx context.Context, db DBTX, arg ListAuditEntriesParams) ([]NotesAuditLog, error) {
+	rows, err := db.Query(ctx, listAuditEntries,
+		arg.LastEntryID,
+		arg.Action,
+		arg.ActorID,
+		arg.NoteID,
+		arg.TagID,
+		arg.UserID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesAuditLog
+	for rows.Next() {
+		var i NotesAuditLog
+		if err := rows.Scan(
+			&i.EntryID,
+			&i.Action,
+			&i.ActorID,
+			&i.RequestID,
+			&i.NoteID,
+			&i.TagID,
+			&i.UserID,
+			&i.Before,
+			&i.After,
+			&i.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCommentMentions = `-- name: ListCommentMentions :many
+SELECT
+  comment_mentions.comment_id,
+  users.user_id,
+  users.name
+FROM notes.comment_mentions
+JOIN notes.users ON
+  users.user_id = comment_mentions.user_id
+WHERE comment_mentions.comment_id = ANY($1::uuid[])
+ORDER BY comment_mentions.comment_id, users.name, users.user_id
+`
+
+type ListCommentMentionsRow struct {
+	CommentID uuid.UUID
+	UserID    uuid.UUID
+	Name      string
+}
+
+func (q *Queries) ListCommentMentions(ctx context.Context, db DBTX, commentIds []uuid.UUID) ([]ListCommentMentionsRow, error) {
+	rows, err := db.Query(ctx, listCommentMentions, commentIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCommentMentionsRow
+	for rows.Next() {
+		var i ListCommentMentionsRow
+		if err := rows.Scan(&i.CommentID, &i.UserID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventsAfter = `-- name: ListEventsAfter :many
+SELECT
+  event_id,
+  kind,
+  note_id,
+  tag_id,
+  audience,
+  occurred_at
+FROM notes.events
+WHERE event_id > $1
+  AND $2::uuid = ANY(audience)
+ORDER BY event_id ASC
+LIMIT $3
+`
+
+type ListEventsAfterParams struct {
+	AfterEventID int64
+	UserID       uuid.UUID
+	PageSize     int64
+}
+
+func (q *Queries) ListEventsAfter(ctx context.Context, db DBTX, arg ListEventsAfterParams) ([]NotesEvent, error) {
+	rows, err := db.Query(ctx, listEventsAfter, arg.AfterEventID, arg.UserID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesEvent
+	for rows.Next() {
+		var i NotesEvent
+		if err := rows.Scan(
+			&i.EventID,
+			&i.Kind,
+			&i.NoteID,
+			&i.TagID,
+			&i.Audience,
+			&i.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listImportFailures = `-- name: ListImportFailures :many
+SELECT
+  failure_id,
+  import_id,
+  item,
+  error
+FROM notes.import_failures
+WHERE import_id = $1
+  AND ($2::bigint IS NULL OR failure_id > $2::bigint)
+ORDER BY failure_id ASC
+LIMIT $3
+`
+
+type ListImportFailuresParams struct {
+	ImportID      uuid.UUID
+	LastFailureID pgtype.Int8
+	PageSize      int64
+}
+
+func (q *Queries) ListImportFailures(ctx context.Context, db DBTX, arg ListImportFailuresParams) ([]NotesImportFailure, error) {
+	rows, err := db.Query(ctx, listImportFailures, arg.ImportID, arg.LastFailureID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesImportFailure
+	for rows.Next() {
+		var i NotesImportFailure
+		if err := rows.Scan(
+			&i.FailureID,
+			&i.ImportID,
+			&i.Item,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMatchingWebhooks = `-- name: ListMatchingWebhooks :many
+SELECT
+  webhook_id
+FROM notes.webhooks
+WHERE active
+  -- only tell users about what they can see
+  AND user_id = ANY($1::uuid[])
+  AND (cardinality(event_kinds) = 0 OR $2::text = ANY(event_kinds))
+  AND (cardinality(webhooks.tag_ids) = 0 OR webhooks.tag_ids && $3::uuid[])
+ORDER BY webhook_id ASC
+`
+
+type ListMatchingWebhooksParams struct {
+	Audience  []uuid.UUID
+	EventKind string
+	TagIds    []uuid.UUID
+}
+
+func (q *Queries) ListMatchingWebhooks(ctx context.Context, db DBTX, arg ListMatchingWebhooksParams) ([]uuid.UUID, error) {
+	rows, err := db.Query(ctx, listMatchingWebhooks, arg.Audience, arg.EventKind, arg.TagIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var webhook_id uuid.UUID
+		if err := rows.Scan(&webhook_id); err != nil {
+			return nil, err
+		}
+		items = append(items, webhook_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNoteRevisions = `-- name: ListNoteRevisions :many
+SELECT
+  note_id,
+  revision,
+  title,
+  body,
+  saved_at,
+  saved_by
+FROM notes.note_revisions
+WHERE note_id = $1
+  AND ($2::bigint IS NULL OR revision < $2::bigint)
+ORDER BY revision DESC
+LIMIT $3
+`
+
+type ListNoteRevisionsParams struct {
+	NoteID       uuid.UUID
+	LastRevision pgtype.Int8
+	PageSize     int64
+}
+
+func (q *Queries) ListNoteRevisions(ctx context.Context, db DBTX, arg ListNoteRevisionsParams) ([]NotesNoteRevision, error) {
+	rows, err := db.Query(ctx, listNoteRevisions, arg.NoteID, arg.LastRevision, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesNoteRevision
+	for rows.Next() {
+		var i NotesNoteRevision
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Revision,
+			&i.Title,
+			&i.Body,
+			&i.SavedAt,
+			&i.SavedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNoteTagIDs = `-- name: ListNoteTagIDs :many
+SELECT
+  tag_id
+FROM notes.note_tags
+WHERE note_id = $1
+`
+
+func (q *Queries) ListNoteTagIDs(ctx context.Context, db DBTX, noteID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := db.Query(ctx, listNoteTagIDs, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var tag_id uuid.UUID
+		if err := rows.Scan(&tag_id); err != nil {
+			return nil, err
+		}
+		items = append(items, tag_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotes = `-- name: ListNotes :many
+SELECT
+  notes.note_id,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by,
+  title
+FROM notes.notes
+JOIN notes.user_note_access ON
+  user_note_access.note_id = notes.note_id
+  AND user_note_access.user_id = $1
+  -- NOTE: any access
+LEFT JOIN notes.user_note_states ON
+  user_note_states.note_id = notes.note_id
+  AND user_note_states.user_id = user_note_access.user_id
+CROSS JOIN LATERAL (
+  SELECT
+    COALESCE(user_note_states.pinned, false) AS pinned,
+    COALESCE(user_note_states.starred, false) AS starred,
+    COALESCE(user_note_states.archived, false) AS archived
+) AS state
+WHERE ($2::uuid IS NULL OR notes.created_by = $2::uuid)
+  AND ($3::uuid IS NULL OR notes.updated_by = $3::uuid)
+  AND ($4::timestamptz IS NULL OR notes.created_at >= $4::timestamptz)
+  AND ($5::timestamptz IS NULL OR notes.created_at < $5::timestamptz)
+  AND ($6::timestamptz IS NULL OR notes.updated_at >= $6::timestamptz)
+  AND ($7::timestamptz IS NULL OR notes.updated_at < $7::timestamptz)
+  AND (cardinality($8::uuid[]) = 0 OR cardinality($8::uuid[]) = (
+    SELECT COUNT(*)
+    FROM notes.note_tags
+    WHERE note_tags.note_id = notes.note_id
+      AND note_tags.tag_id = ANY($8::uuid[])
+  ))
+  AND (cardinality($9::uuid[]) = 0 OR EXISTS (
+    SELECT 1
+    FROM notes.note_tags
+    WHERE note_tags.note_id = notes.note_id
+      AND note_tags.tag_id = ANY($9::uuid[])
+  ))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM notes.note_tags
+    WHERE note_tags.note_id = notes.note_id
+      AND note_tags.tag_id = ANY($10::uuid[])
+  )
+  AND ($11::bool IS NULL OR state.pinned = $11::bool)
+  AND ($12::bool IS NULL OR state.starred = $12::bool)
+  AND ($13::bool IS NULL OR state.archived = $13::bool)
+  -- pinned notes come first
+  AND ($14::uuid IS NULL
+    OR state.pinned < $15::bool
+    OR (state.pinned = $15::bool AND CASE $16::text
+      WHEN 'updated_at' THEN CASE WHEN $17::bool
+        THEN (notes.updated_at, notes.note_id) < ($18::timestamptz, $14::uuid)
+        ELSE (notes.updated_at, notes.note_id) > ($18::timestamptz, $14::uuid)
+      END
+      WHEN 'created_at' THEN CASE WHEN $17::bool
+        THEN (notes.created_at, notes.note_id) < ($18::timestamptz, $14::uuid)
+        ELSE (notes.created_at, notes.note_id) > ($18::timestamptz, $14::uuid)
+      END
+      WHEN 'title' THEN CASE WHEN $17::bool
+        THEN (notes.title, notes.note_id) < ($19::text, $14::uuid)
+        ELSE (notes.title, notes.note_id) > ($19::text, $14::uuid)
+      END
+      ELSE CASE WHEN $17::bool
+        THEN notes.note_id < $14::uuid
+        ELSE notes.note_id > $14::uuid
+      END
+    END))
+ORDER BY
+  state.pinned DESC,
+  CASE WHEN $16::text = 'updated_at' AND NOT $17::bool THEN notes.updated_at END ASC,
+  CASE WHEN $16::text = 'updated_at' AND $17::bool THEN notes.updated_at END DESC,
+  CASE WHEN $16::text = 'created_at' AND NOT $17::bool THEN notes.created_at END ASC,
+  CASE WHEN $16::text = 'created_at' AND $17::bool THEN notes.created_at END DESC,
+  CASE WHEN $16::text = 'title' AND NOT $17::bool THEN notes.title END ASC,
+  CASE WHEN $16::text = 'title' AND $17::bool THEN notes.title END DESC,
+  CASE WHEN $17::bool THEN notes.note_id END DESC,
+  notes.note_id ASC
+LIMIT $20
+`
+
+type ListNotesParams struct {
+	UserID        uuid.UUID
+	CreatedBy     uuid.NullUUID
+	UpdatedBy     uuid.NullUUID
+	CreatedAfter  pgtype.Timestamptz
+	CreatedBefore pgtype.Timestamptz
+	UpdatedAfter  pgtype.Timestamptz
+	UpdatedBefore pgtype.Timestamptz
+	AllTags       []uuid.UUID
+	AnyTags       []uuid.UUID
+	NoneTags      []uuid.UUID
+	Pinned        pgtype.Bool
+	Starred       pgtype.Bool
+	Archived      pgtype.Bool
+	LastNoteID    uuid.NullUUID
+	LastPinned    pgtype.Bool
+	SortBy        string
+	SortDesc      bool
+	LastTime      pgtype.Timestamptz
+	LastTitle     pgtype.Text
+	PageSize      int64
+}
+
+type ListNotesRow struct {
+	NoteID    uuid.UUID
+	CreatedAt pgtype.Timestamptz
+	CreatedBy uuid.NullUUID
+	UpdatedAt pgtype.Timestamptz
+	UpdatedBy uuid.NullUUID
+	Title     string
+	Pinned    bool
+	Starred   bool
+	Archived  bool
+}
+
+func (q *Queries) ListNotes(ctx context.Context, db DBTX, arg ListNotesParams) ([]ListNotesRow, error) {
+	rows, err := db.Query(ctx, listNotes,
+		arg.UserID,
+		arg.CreatedBy,
+		arg.UpdatedBy,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.UpdatedAfter,
+		arg.UpdatedBefore,
+		arg.AllTags,
+		arg.AnyTags,
+		arg.NoneTags,
+		arg.Pinned,
+		arg.Starred,
+		arg.Archived,
+		arg.LastNoteID,
+		arg.LastPinned,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.LastTime,
+		arg.LastTitle,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNotesRow
+	for rows.Next() {
+		var i ListNotesRow
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.CreatedAt,
+			&i.CreatedBy,
+			&i.UpdatedAt,
+			&i.UpdatedBy,
+			&i.Title,
+			&i.Pinned,
+			&i.Starred,
+			&i.Archived,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrphanedAttachments = `-- name: ListOrphanedAttachments :many
+SELECT
+  attachment_id,
+  note_id,
+  name,
+  content_type,
+  size,
+  blob_key,
+  created_at,
+  created_by
+FROM notes.attachments
+WHERE note_id IS NULL
+ORDER BY created_at ASC
+LIMIT $1
+`
+
+func (q *Queries) ListOrphanedAttachments(ctx context.Context, db DBTX, pageSize int64) ([]NotesAttachment, error) {
+	rows, err := db.Query(ctx, listOrphanedAttachments, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesAttachment
+	for rows.Next() {
+		var i NotesAttachment
+		if err := rows.Scan(
+			&i.AttachmentID,
+			&i.NoteID,
+			&i.Name,
+			&i.ContentType,
+			&i.Size,
+			&i.BlobKey,
+			&i.CreatedAt,
+			&i.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingExtractions = `-- name: ListPendingExtractions :many
+SELECT
+  attachments.attachment_id,
+  attachments.note_id,
+  attachments.name,
+  attachments.content_type,
+  attachments.size,
+  attachments.blob_key,
+  attachments.created_at,
+  attachments.created_by
+FROM notes.attachments
+LEFT JOIN notes.attachment_texts ON
+  attachment_texts.attachment_id = attachments.attachment_id
+WHERE attachment_texts.attachment_id IS NULL
+  AND attachments.note_id IS NOT NULL
+ORDER BY attachments.created_at ASC
+LIMIT $1
+`
+
+func (q *Queries) ListPendingExtractions(ctx context.Context, db DBTX, pageSize int64) ([]NotesAttachment, error) {
+	rows, err := db.Query(ctx, listPendingExtractions, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesAttachment
+	for rows.Next() {
+		var i NotesAttachment
+		if err := rows.Scan(
+			&i.AttachmentID,
+			&i.NoteID,
+			&i.Name,
+			&i.ContentType,
+			&i.Size,
+			&i.BlobKey,
+			&i.CreatedAt,
+			&i.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReminders = `-- name: ListReminders :many
+SELECT
+  note_id,
+  user_id,
+  remind_at,
+  recurrence,
+  next_at,
+  created_at,
+  updated_at
+FROM notes.reminders
+WHERE user_id = $1
+  AND ($2::uuid IS NULL OR note_id > $2::uuid)
+ORDER BY note_id ASC
+LIMIT $3
+`
+
+type ListRemindersParams struct {
+	UserID     uuid.UUID
+	LastNoteID uuid.NullUUID
+	PageSize   int64
+}
+
+func (q *Queries) ListReminders(ctx context.Context, db DBTX, arg ListRemindersParams) ([]NotesReminder, error) {
+	rows, err := db.Query(ctx, listReminders, arg.UserID, arg.LastNoteID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesReminder
+	for rows.Next() {
+		var i NotesReminder
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.UserID,
+			&i.RemindAt,
+			&i.Recurrence,
+			&i.NextAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSavedSearches = `-- name: ListSavedSearches :many
+SELECT
+  saved_search_id,
+  user_id,
+  name,
+  query,
+  track_matches,
+  created_at,
+  updated_at,
+  last_checked_at
+FROM notes.saved_searches
+WHERE user_id = $1
+  AND ($2::uuid IS NULL OR saved_search_id > $2::uuid)
+ORDER BY saved_search_id ASC
+LIMIT $3
+`
+
+type ListSavedSearchesParams struct {
+	UserID            uuid.UUID
+	LastSavedSearchID uuid.NullUUID
+	PageSize          int64
+}
+
+func (q *Queries) ListSavedSearches(ctx context.Context, db DBTX, arg ListSavedSearchesParams) ([]NotesSavedSearch, error) {
+	rows, err := db.Query(ctx, listSavedSearches, arg.UserID, arg.LastSavedSearchID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesSavedSearch
+	for rows.Next() {
+		var i NotesSavedSearch
+		if err := rows.Scan(
+			&i.SavedSearchID,
+			&i.UserID,
+			&i.Name,
+			&i.Query,
+			&i.TrackMatches,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastCheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStaleExtractions = `-- name: ListStaleExtractions :many
+SELECT
+  attachments.attachment_id,
+  attachments.note_id,
+  attachments.name,
+  attachments.content_type,
+  attachments.size,
+  attachments.blob_key,
+  attachments.created_at,
+  attachments.created_by
+FROM notes.attachments
+JOIN notes.attachment_texts ON
+  attachment_texts.attachment_id = attachments.attachment_id
+JOIN notes.user_note_access ON
+  user_note_access.note_id = attachments.note_id
+WHERE user_note_access.user_id = $1
+  AND user_note_access.access = 'owner'
+  AND attachment_texts.extracted_at < $2
+  AND ($3::uuid IS NULL OR attachments.attachment_id > $3::uuid)
+ORDER BY attachments.attachment_id ASC
+LIMIT $4
+`
+
+type ListStaleExtractionsParams struct {
+	UserID           uuid.UUID
+	ExtractedBefore  pgtype.Timestamptz
+	LastAttachmentID uuid.NullUUID
+	PageSize         int64
+}
+
+func (q *Queries) ListStaleExtractions(ctx context.Context, db DBTX, arg ListStaleExtractionsParams) ([]NotesAttachment, error) {
+	rows, err := db.Query(ctx, listStaleExtractions,
+		arg.UserID,
+		arg.ExtractedBefore,
+		arg.LastAttachmentID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesAttachment
+	for rows.Next() {
+		var i NotesAttachment
+		if err := rows.Scan(
+			&i.AttachmentID,
+			&i.NoteID,
+			&i.Name,
+			&i.ContentType,
+			&i.Size,
+			&i.BlobKey,
+			&i.CreatedAt,
+			&i.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT
+  tags.tag_id,
+  name,
+  access
+FROM notes.tags
+JOIN notes.user_tag_access ON 
+  tags.tag_id = user_tag_access.tag_id
+  AND user_id = $1 -- NOTE: any access
+WHERE ($2::uuid IS NULL OR tags.tag_id > $2::uuid)
+  AND LOWER(name) LIKE '%' || LOWER($3) || '%'
+ORDER BY tags.tag_id ASC
+LIMIT $4
+`
+
+type ListTagsParams struct {
+	UserID       uuid.UUID
+	LastTagID    uuid.NullUUID
+	SearchString string
+	PageSize     int64
+}
+
+type ListTagsRow struct {
+	TagID  uuid.UUID
+	Name   string
+	Access NotesAccessLevel
+}
+
+func (q *Queries) ListTags(ctx context.Context, db DBTX, arg ListTagsParams) ([]ListTagsRow, error) {
+	rows, err := db.Query(ctx, listTags,
+		arg.UserID,
+		arg.LastTagID,
+		arg.SearchString,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTagsRow
+	for rows.Next() {
+		var i ListTagsRow
+		if err := rows.Scan(&i.TagID, &i.Name, &i.Access); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTasks = `-- name: ListTasks :many
+SELECT
+  note_tasks.note_id,
+  note_tasks.line,
+  note_tasks.text,
+  note_tasks.done,
+  notes.created_at,
+  notes.created_by,
+  notes.updated_at,
+  notes.updated_by,
+  notes.title
+FROM notes.note_tasks
+JOIN notes.notes ON
+  notes.note_id = note_tasks.note_id
+JOIN notes.user_note_access ON
+  user_note_access.note_id = note_tasks.note_id
+  AND user_id = $1
+  -- NOTE: any access
+WHERE ($2::bool IS NULL OR note_tasks.done = $2::bool)
+  AND ($3::uuid IS NULL OR note_tasks.note_id = $3::uuid)
+  AND ($4::uuid IS NULL OR EXISTS (
+    SELECT 1
+    FROM notes.note_tags
+    WHERE note_tags.note_id = note_tasks.note_id
+      AND note_tags.tag_id = $4::uuid
+  ))
+  AND ($5::uuid IS NULL
+    OR (note_tasks.note_id, note_tasks.line) > ($5::uuid, $6::int4))
+ORDER BY note_tasks.note_id, note_tasks.line
+LIMIT $7
+`
+
+type ListTasksParams struct {
+	UserID     uuid.UUID
+	Done       pgtype.Bool
+	NoteID     uuid.NullUUID
+	TagID      uuid.NullUUID
+	LastNoteID uuid.NullUUID
+	LastLine   pgtype.Int4
+	PageSize   int64
+}
+
+type ListTasksRow struct {
+	NoteID    uuid.UUID
+	Line      int32
+	Text      string
+	Done      bool
+	CreatedAt pgtype.Timestamptz
+	CreatedBy uuid.NullUUID
+	UpdatedAt pgtype.Timestamptz
+	UpdatedBy uuid.NullUUID
+	Title     string
+}
+
+func (q *Queries) ListTasks(ctx context.Context, db DBTX, arg ListTasksParams) ([]ListTasksRow, error) {
+	rows, err := db.Query(ctx, listTasks,
+		arg.UserID,
+		arg.Done,
+		arg.NoteID,
+		arg.TagID,
+		arg.LastNoteID,
+		arg.LastLine,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTasksRow
+	for rows.Next() {
+		var i ListTasksRow
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Line,
+			&i.Text,
+			&i.Done,
+			&i.CreatedAt,
+			&i.CreatedBy,
+			&i.UpdatedAt,
+			&i.UpdatedBy,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTemplates = `-- name: ListTemplates :many
+SELECT
+  templates.template_id,
+  templates.name,
+  templates.title,
+  templates.body,
+  templates.created_at,
+  templates.created_by,
+  templates.updated_at,
+  templates.updated_by
+FROM notes.templates
+JOIN notes.user_template_access ON
+  user_template_access.template_id = templates.template_id
+  AND user_template_access.user_id = $1
+  -- NOTE: any access
+WHERE ($2::uuid IS NULL OR templates.template_id > $2::uuid)
+ORDER BY templates.template_id ASC
+LIMIT $3
+`
+
+type ListTemplatesParams struct {
+	UserID         uuid.UUID
+	LastTemplateID uuid.NullUUID
+	PageSize       int64
+}
+
+func (q *Queries) ListTemplates(ctx context.Context, db DBTX, arg ListTemplatesParams) ([]NotesTemplate, error) {
+	rows, err := db.Query(ctx, listTemplates, arg.UserID, arg.LastTemplateID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesTemplate
+	for rows.Next() {
+		var i NotesTemplate
+		if err := rows.Scan(
+			&i.TemplateID,
+			&i.Name,
+			&i.Title,
+			&i.Body,
+			&i.CreatedAt,
+			&i.CreatedBy,
+			&i.UpdatedAt,
+			&i.UpdatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadComments = `-- name: ListThreadComments :many
+SELECT
+  comment_id,
+  thread_id,
+  created_at,
+  created_by,
+  updated_at,
+  body
+FROM notes.comments
+WHERE thread_id = ANY($1::uuid[])
+ORDER BY thread_id, comment_id
+`
+
+func (q *Queries) ListThreadComments(ctx context.Context, db DBTX, threadIds []uuid.UUID) ([]NotesComment, error) {
+	rows, err := db.Query(ctx, listThreadComments, threadIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesComment
+	for rows.Next() {
+		var i NotesComment
+		if err := rows.Scan(
+			&i.CommentID,
+			&i.ThreadID,
+			&i.CreatedAt,
+			&i.CreatedBy,
+			&i.UpdatedAt,
+			&i.Body,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreads = `-- name: ListThreads :many
+SELECT
+  thread_id,
+  note_id,
+  anchor_start,
+  anchor_end,
+  anchor_quote,
+  created_at,
+  created_by,
+  resolved_at,
+  resolved_by
+FROM notes.comment_threads
+WHERE note_id = $1
+  AND ($2::bool IS NULL OR (resolved_at IS NOT NULL) = $2::bool)
+  AND ($3::uuid IS NULL OR thread_id > $3::uuid)
+ORDER BY thread_id ASC
+LIMIT $4
+`
+
+type ListThreadsParams struct {
+	NoteID       uuid.UUID
+	Resolved     pgtype.Bool
+	LastThreadID uuid.NullUUID
+	PageSize     int64
+}
+
+func (q *Queries) ListThreads(ctx context.Context, db DBTX, arg ListThreadsParams) ([]NotesCommentThread, error) {
+	rows, err := db.Query(ctx, listThreads,
+		arg.NoteID,
+		arg.Resolved,
+		arg.LastThreadID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesCommentThread
+	for rows.Next() {
+		var i NotesCommentThread
+		if err := rows.Scan(
+			&i.ThreadID,
+			&i.NoteID,
+			&i.AnchorStart,
+			&i.AnchorEnd,
+			&i.AnchorQuote,
+			&i.CreatedAt,
+			&i.CreatedBy,
+			&i.ResolvedAt,
+			&i.ResolvedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT
+  delivery_id,
+  webhook_id,
+  event_id,
+  event_kind,
+  payload,
+  status,
+  attempts,
+  next_attempt_at,
+  last_attempt_at,
+  response_status,
+  last_error,
+  created_at
+FROM notes.webhook_deliveries
+WHERE webhook_id = $1
+  AND ($2::uuid IS NULL OR delivery_id < $2::uuid)
+ORDER BY delivery_id DESC
+LIMIT $3
+`
+
+type ListWebhookDeliveriesParams struct {
+	WebhookID      uuid.UUID
+	LastDeliveryID uuid.NullUUID
+	PageSize       int64
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, db DBTX, arg ListWebhookDeliveriesParams) ([]NotesWebhookDelivery, error) {
+	rows, err := db.Query(ctx, listWebhookDeliveries, arg.WebhookID, arg.LastDeliveryID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesWebhookDelivery
+	for rows.Next() {
+		var i NotesWebhookDelivery
+		if err := rows.Scan(
+			&i.DeliveryID,
+			&i.WebhookID,
+			&i.EventID,
+			&i.EventKind,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastAttemptAt,
+			&i.ResponseStatus,
+			&i.LastError,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooks = `-- name: ListWebhooks :many
+SELECT
+  webhook_id,
+  user_id,
+  url,
+  secret,
+  event_kinds,
+  tag_ids,
+  active,
+  consecutive_failures,
+  disabled_at,
+  created_at,
+  updated_at
+FROM notes.webhooks
+WHERE user_id = $1
+  AND ($2::uuid IS NULL OR webhook_id > $2::uuid)
+ORDER BY webhook_id ASC
+LIMIT $3
+`
+
+type ListWebhooksParams struct {
+	UserID        uuid.UUID
+	LastWebhookID uuid.NullUUID
+	PageSize      int64
+}
+
+func (q *Queries) ListWebhooks(ctx context.Context, db DBTX, arg ListWebhooksParams) ([]NotesWebhook, error) {
+	rows, err := db.Query(ctx, listWebhooks, arg.UserID, arg.LastWebhookID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotesWebhook
+	for rows.Next() {
+		var i NotesWebhook
+		if err := rows.Scan(
+			&i.WebhookID,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			&i.EventKinds,
+			&i.TagIds,
+			&i.Active,
+			&i.ConsecutiveFailures,
+			&i.DisabledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const mergeNoteTags = `-- name: MergeNoteTags :execrows
+INSERT INTO notes.note_tags (
+  note_id,
+  tag_id
+)
+SELECT DISTINCT
+  note_id,
+  $1::uuid
+FROM notes.note_tags
+WHERE tag_id = ANY($2::uuid[])
+ON CONFLICT (note_id, tag_id) DO NOTHING
+`
+
+type MergeNoteTagsParams struct {
+	IntoTagID  uuid.UUID
+	FromTagIds []uuid.UUID
+}
+
+func (q *Queries) MergeNoteTags(ctx context.Context, db DBTX, arg MergeNoteTagsParams) (int64, error) {
+	result, err := db.Exec(ctx, mergeNoteTags, arg.IntoTagID, arg.FromTagIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const notifyEvent = `-- name: NotifyEvent :exec
+SELECT pg_notify('notes_events', $1::text)
+`
+
+func (q *Queries) NotifyEvent(ctx context.Context, db DBTX, payload string) error {
+	_, err := db.Exec(ctx, notifyEvent, payload)
+	return err
+}
+
+const publishOutboxMessage = `-- name: PublishOutboxMessage :exec
+UPDATE notes.outbox
+SET published_at = $1,
+    attempts     = attempts + 1,
+    last_error   = NULL
+WHERE message_id = $2
+`
+
+type PublishOutboxMessageParams struct {
+	PublishedAt pgtype.Timestamptz
+	MessageID   int64
+}
+
+func (q *Queries) PublishOutboxMessage(ctx context.Context, db DBTX, arg PublishOutboxMessageParams) error {
+	_, err := db.Exec(ctx, publishOutboxMessage, arg.PublishedAt, arg.MessageID)
+	return err
+}
+
+const recordReminderDelivery = `-- name: RecordReminderDelivery :execrows
+INSERT INTO notes.reminder_deliveries (
+  note_id,
+  user_id,
+  occurrence_at,
+  delivered_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4
+) ON CONFLICT DO NOTHING
+`
+
+type RecordReminderDeliveryParams struct {
+	NoteID       uuid.UUID
+	UserID       uuid.UUID
+	OccurrenceAt pgtype.Timestamptz
+	DeliveredAt  pgtype.Timestamptz
+}
+
+func (q *Queries) RecordReminderDelivery(ctx context.Context, db DBTX, arg RecordReminderDeliveryParams) (int64, error) {
+	result, err := db.Exec(ctx, recordReminderDelivery,
+		arg.NoteID,
+		arg.UserID,
+		arg.OccurrenceAt,
+		arg.DeliveredAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const recordSignIn = `-- name: RecordSignIn :execrows
+UPDATE notes.users
+SET last_sign_in = $1
+WHERE user_id = $2
+  AND active
+`
+
+type RecordSignInParams struct {
+	SignedInAt pgtype.Timestamptz
+	UserID     uuid.UUID
+}
+
+func (q *Queries) RecordSignIn(ctx context.Context, db DBTX, arg RecordSignInParams) (int64, error) {
+	result, err := db.Exec(ctx, recordSignIn, arg.SignedInAt, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const recordWebhookDeliveryAttempt = `-- name: RecordWebhookDeliveryAttempt :execrows
+UPDATE notes.webhook_deliveries
+SET status          = $1,
+    attempts        = attempts + 1,
+    next_attempt_at = $2,
+    last_attempt_at = $3,
+    response_status = $4,
+    last_error      = $5
+WHERE delivery_id = $6
+  -- another deliverer has not already recorded an attempt since this one was claimed
+  AND status = 'pending'
+  AND attempts = $7::integer
+`
+
+type RecordWebhookDeliveryAttemptParams struct {
+	Status           NotesWebhookDeliveryStatus
+	NextAttemptAt    pgtype.Timestamptz
+	AttemptedAt      pgtype.Timestamptz
+	ResponseStatus   pgtype.Int4
+	LastError        pgtype.Text
+	DeliveryID       uuid.UUID
+	ExpectedAttempts int32
+}
+
+func (q *Queries) RecordWebhookDeliveryAttempt(ctx context.Context, db DBTX, arg RecordWebhookDeliveryAttemptParams) (int64, error) {
+	result, err := db.Exec(ctx, recordWebhookDeliveryAttempt,
+		arg.Status,
+		arg.NextAttemptAt,
+		arg.AttemptedAt,
+		arg.ResponseStatus,
+		arg.LastError,
+		arg.DeliveryID,
+		arg.ExpectedAttempts,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const recordWebhookFailure = `-- name: RecordWebhookFailure :one
+UPDATE notes.webhooks
+SET consecutive_failures = consecutive_failures + 1,
+    active               = active AND consecutive_failures + 1 < $1::integer,
+    disabled_at          = CASE
+                             WHEN active AND consecutive_failures + 1 >= $1::integer THEN $2
+                             ELSE disabled_at
+                           END
+WHERE webhook_id = $3
+RETURNING active
+`
+
+type RecordWebhookFailureParams struct {
+	MaxFailures int32
+	Now         pgtype.Timestamptz
+	WebhookID   uuid.UUID
+}
+
+func (q *Queries) RecordWebhookFailure(ctx context.Context, db DBTX, arg RecordWebhookFailureParams) (bool, error) {
+	row := db.QueryRow(ctx, recordWebhookFailure, arg.MaxFailures, arg.Now, arg.WebhookID)
+	var active bool
+	err := row.Scan(&active)
+	return active, err
+}
+
+const releaseJob = `-- name: ReleaseJob :execrows
+UPDATE notes.jobs
+SET status       = 'pending',
+    -- the attempt was interrupted, rather than failing
+    attempts     = GREATEST(attempts - 1, 0),
+    run_at       = $1,
+    locked_until = NULL
+WHERE job_id = $2
+  AND status = 'running'
+  AND attempts = $3
+`
+
+type ReleaseJobParams struct {
+	RunAt   pgtype.Timestamptz
+	JobID   uuid.UUID
+	Attempt int32
+}
+
+func (q *Queries) ReleaseJob(ctx context.Context, db DBTX, arg ReleaseJobParams) (int64, error) {
+	result, err := db.Exec(ctx, releaseJob, arg.RunAt, arg.JobID, arg.Attempt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const removeNoteTag = `-- name: RemoveNoteTag :exec
+DELETE FROM notes.note_tags
+WHERE note_id = $1
+  AND tag_id = $2
+`
+
+type RemoveNoteTagParams struct {
+	NoteID uuid.UUID
+	TagID  uuid.UUID
+}
+
+func (q *Queries) RemoveNoteTag(ctx context.Context, db DBTX, arg RemoveNoteTagParams) error {
+	_, err := db.Exec(ctx, removeNoteTag, arg.NoteID, arg.TagID)
+	return err
+}
+
+const resetWebhookFailures = `-- name: ResetWebhookFailures :exec
+UPDATE notes.webhooks
+SET consecutive_failures = 0
+WHERE webhook_id = $1
+`
+
+func (q *Queries) ResetWebhookFailures(ctx context.Context, db DBTX, webhookID uuid.UUID) error {
+	_, err := db.Exec(ctx, resetWebhookFailures, webhookID)
+	return err
+}
+
+const resolveDanglingNoteLinks = `-- name: ResolveDanglingNoteLinks :exec
+UPDATE notes.note_links
+SET target_note_id = $1
+WHERE target_note_id IS NULL
+  AND (lower(reference) = lower($2) OR lower(reference) = $1::text)
+  -- only from notes that someone who can read the note also has access to
+  AND EXISTS (
+    SELECT 1
+    FROM notes.user_note_access AS source_access
+    JOIN notes.user_note_access AS target_access ON
+      target_access.user_id = source_access.user_id
+    WHERE source_access.note_id = note_links.source_note_id
+      AND target_access.note_id = $1
+  )
+`
+
+type ResolveDanglingNoteLinksParams struct {
+	NoteID uuid.UUID
+	Title  string
+}
+
+func (q *Queries) ResolveDanglingNoteLinks(ctx context.Context, db DBTX, arg ResolveDanglingNoteLinksParams) error {
+	_, err := db.Exec(ctx, resolveDanglingNoteLinks, arg.NoteID, arg.Title)
+	return err
+}
+
+const retryJob = `-- name: RetryJob :execrows
+UPDATE notes.jobs
+SET status       = 'pending',
+    run_at       = $1,
+    locked_until = NULL,
+    error        = $2
+WHERE job_id = $3
+  AND status = 'running'
+  AND attempts = $4
+`
+
+type RetryJobParams struct {
+	RunAt   pgtype.Timestamptz
+	Error   pgtype.Text
+	JobID   uuid.UUID
+	Attempt int32
+}
+
+func (q *Queries) RetryJob(ctx context.Context, db DBTX, arg RetryJobParams) (int64, error) {
+	result, err := db.Exec(ctx, retryJob,
+		arg.RunAt,
+		arg.Error,
+		arg.JobID,
+		arg.Attempt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const retryOutboxMessage = `-- name: RetryOutboxMessage :exec
+UPDATE notes.outbox
+SET available_at = $1,
+    attempts     = attempts + 1,
+    last_error   = $2
+WHERE message_id = $3
+`
+
+type RetryOutboxMessageParams struct {
+	AvailableAt pgtype.Timestamptz
+	LastError   pgtype.Text
+	MessageID   int64
+}
+
+func (q *Queries) RetryOutboxMessage(ctx context.Context, db DBTX, arg RetryOutboxMessageParams) error {
+	_, err := db.Exec(ctx, retryOutboxMessage, arg.AvailableAt, arg.LastError, arg.MessageID)
+	return err
+}
+
+const revokeNoteAccess = `-- name: RevokeNoteAccess :exec
+DELETE FROM notes.user_note_access
+WHERE note_id = $1
+  AND user_id = $2
+`
+
+type RevokeNoteAccessParams struct {
+	NoteID uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RevokeNoteAccess(ctx context.Context, db DBTX, arg RevokeNoteAccessParams) error {
+	_, err := db.Exec(ctx, revokeNoteAccess, arg.NoteID, arg.UserID)
+	return err
+}
+
+const saveAttachmentText = `-- name: SaveAttachmentText :exec
+INSERT INTO notes.attachment_texts (
+  attachment_id,
+  content,
+  extracted_at,
+  error
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4
+) ON CONFLICT (attachment_id) DO UPDATE
+  SET content      = excluded.content,
+      extracted_at = excluded.extracted_at,
+      error        = excluded.error
+`
+
+type SaveAttachmentTextParams struct {
+	AttachmentID uuid.UUID
+	Content      string
+	ExtractedAt  pgtype.Timestamptz
+	Error        pgtype.Text
+}
+
+func (q *Queries) SaveAttachmentText(ctx context.Context, db DBTX, arg SaveAttachmentTextParams) error {
+	_, err := db.Exec(ctx, saveAttachmentText,
+		arg.AttachmentID,
+		arg.Content,
+		arg.ExtractedAt,
+		arg.Error,
+	)
+	return err
+}
+
+const saveComment = `-- name: SaveComment :exec
+INSERT INTO notes.comments (
+  comment_id,
+  thread_id,
+  created_at,
+  created_by,
+  updated_at,
+  body
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6
+) ON CONFLICT (comment_id) DO UPDATE
+  SET updated_at = excluded.updated_at,
+      body       = excluded.body
+`
+
+type SaveCommentParams struct {
+	CommentID uuid.UUID
+	ThreadID  uuid.UUID
+	CreatedAt pgtype.Timestamptz
+	CreatedBy uuid.NullUUID
+	UpdatedAt pgtype.Timestamptz
+	Body      string
+}
+
+func (q *Queries) SaveComment(ctx context.Context, db DBTX, arg SaveCommentParams) error {
+	_, err := db.Exec(ctx, saveComment,
+		arg.CommentID,
+		arg.ThreadID,
+		arg.CreatedAt,
+		arg.CreatedBy,
+		arg.UpdatedAt,
+		arg.Body,
+	)
+	return err
+}
+
+const saveNote = `-- name: SaveNote :one
+INSERT INTO notes.notes (
+  note_id,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by,
+  title,
+  body,
+  revision
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
+  1
+) ON CONFLICT (note_id) DO UPDATE
+  SET updated_at = excluded.updated_at,
+      updated_by = excluded.updated_by,
+      title      = excluded.title,
+      body       = excluded.body,
+      revision   = notes.revision + 1
+  -- an expected revision of 0 overwrites any revision
+  WHERE $8::bigint = 0
+    OR notes.revision = $8::bigint
+RETURNING revision
+`
+
+type SaveNoteParams struct {
+	NoteID           uuid.UUID
+	CreatedAt        pgtype.Timestamptz
+	CreatedBy        uuid.NullUUID
+	UpdatedAt        pgtype.Timestamptz
+	UpdatedBy        uuid.NullUUID
+	Title            string
+	Body             string
+	ExpectedRevision int64
+}
+
+func (q *Queries) SaveNote(ctx context.Context, db DBTX, arg SaveNoteParams) (int64, error) {
+	row := db.QueryRow(ctx, saveNote,
+		arg.NoteID,
+		arg.CreatedAt,
+		arg.CreatedBy,
+		arg.UpdatedAt,
+		arg.UpdatedBy,
+		arg.Title,
+		arg.Body,
+		arg.ExpectedRevision,
+	)
+	var revision int64
+	err := row.Scan(&revision)
+	return revision, err
+}
+
+const saveNoteState = `-- name: SaveNoteState :exec
+INSERT INTO notes.user_note_states (
+  note_id,
+  user_id,
+  pinned,
+  starred,
+  archived,
+  updated_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6
+) ON CONFLICT (note_id, user_id) DO UPDATE
+  SET pinned     = excluded.pinned,
+      starred    = excluded.starred,
+      archived   = excluded.archived,
+      updated_at = excluded.updated_at
+`
+
+type SaveNoteStateParams struct {
+	NoteID    uuid.UUID
+	UserID    uuid.UUID
+	Pinned    bool
+	Starred   bool
+	Archived  bool
+	UpdatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) SaveNoteState(ctx context.Context, db DBTX, arg SaveNoteStateParams) error {
+	_, err := db.Exec(ctx, saveNoteState,
+		arg.NoteID,
+		arg.UserID,
+		arg.Pinned,
+		arg.Starred,
+		arg.Archived,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const savePublication = `-- name: SavePublication :one
+INSERT INTO notes.note_publications (
+  note_id,
+  slug,
+  published_at,
+  published_by
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4
+)
+-- republishing only changes the slug
+ON CONFLICT (note_id) DO UPDATE
+SET slug = excluded.slug
+RETURNING
+  note_id,
+  slug,
+  published_at,
+  published_by
+`
+
+type SavePublicationParams struct {
+	NoteID      uuid.UUID
+	Slug        string
+	PublishedAt pgtype.Timestamptz
+	PublishedBy uuid.NullUUID
+}
+
+func (q *Queries) SavePublication(ctx context.Context, db DBTX, arg SavePublicationParams) (NotesNotePublication, error) {
+	row := db.QueryRow(ctx, savePublication,
+		arg.NoteID,
+		arg.Slug,
+		arg.PublishedAt,
+		arg.PublishedBy,
+	)
+	var i NotesNotePublication
+	err := row.Scan(
+		&i.NoteID,
+		&i.Slug,
+		&i.PublishedAt,
+		&i.PublishedBy,
+	)
+	return i, err
+}
+
+const saveReminder = `-- name: SaveReminder :exec
+INSERT INTO notes.reminders (
   note_id,
+  user_id,
+  remind_at,
+  recurrence,
+  next_at,
   created_at,
-  created_by,
-  updated_at,
-  updated_by,
-  title,
-  body
+  updated_at
 ) VALUES (
   $1,
   $2,
@@ -392,32 +4534,79 @@ INSERT INTO notes.notes (
   $5,
   $6,
   $7
-) ON CONFLICT (note_id) DO UPDATE
-  SET updated_at = excluded.updated_at,
-      updated_by = excluded.updated_by,
-      title      = excluded.title,
-      body       = excluded.body
+) ON CONFLICT (note_id, user_id) DO UPDATE
+  SET remind_at  = excluded.remind_at,
+      recurrence = excluded.recurrence,
+      next_at    = excluded.next_at,
+      updated_at = excluded.updated_at
 `
 
-type SaveNoteParams struct {
-	NoteID    uuid.UUID
-	CreatedAt pgtype.Timestamptz
-	CreatedBy uuid.NullUUID
-	UpdatedAt pgtype.Timestamptz
-	UpdatedBy uuid.NullUUID
-	Title     string
-	Body      string
+type SaveReminderParams struct {
+	NoteID     uuid.UUID
+	UserID     uuid.UUID
+	RemindAt   pgtype.Timestamptz
+	Recurrence pgtype.Text
+	NextAt     pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
 }
 
-func (q *Queries) SaveNote(ctx context.Context, db DBTX, arg SaveNoteParams) error {
-	_, err := db.Exec(ctx, saveNote,
+func (q *Queries) SaveReminder(ctx context.Context, db DBTX, arg SaveReminderParams) error {
+	_, err := db.Exec(ctx, saveReminder,
 		arg.NoteID,
+		arg.UserID,
+		arg.RemindAt,
+		arg.Recurrence,
+		arg.NextAt,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const saveSavedSearch = `-- name: SaveSavedSearch :exec
+INSERT INTO notes.saved_searches (
+  saved_search_id,
+  user_id,
+  name,
+  query,
+  track_matches,
+  created_at,
+  updated_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7
+) ON CONFLICT (saved_search_id) DO UPDATE
+  SET name          = excluded.name,
+      query         = excluded.query,
+      track_matches = excluded.track_matches,
+      updated_at    = excluded.updated_at
+`
+
+type SaveSavedSearchParams struct {
+	SavedSearchID uuid.UUID
+	UserID        uuid.UUID
+	Name          string
+	Query         []byte
+	TrackMatches  bool
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+}
+
+func (q *Queries) SaveSavedSearch(ctx context.Context, db DBTX, arg SaveSavedSearchParams) error {
+	_, err := db.Exec(ctx, saveSavedSearch,
+		arg.SavedSearchID,
+		arg.UserID,
+		arg.Name,
+		arg.Query,
+		arg.TrackMatches,
 		arg.CreatedAt,
-		arg.CreatedBy,
 		arg.UpdatedAt,
-		arg.UpdatedBy,
-		arg.Title,
-		arg.Body,
 	)
 	return err
 }
@@ -443,6 +4632,58 @@ func (q *Queries) SaveTag(ctx context.Context, db DBTX, arg SaveTagParams) error
 	return err
 }
 
+const saveTemplate = `-- name: SaveTemplate :exec
+INSERT INTO notes.templates (
+  template_id,
+  name,
+  title,
+  body,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
+  $8
+) ON CONFLICT (template_id) DO UPDATE
+  SET name       = excluded.name,
+      title      = excluded.title,
+      body       = excluded.body,
+      updated_at = excluded.updated_at,
+      updated_by = excluded.updated_by
+`
+
+type SaveTemplateParams struct {
+	TemplateID uuid.UUID
+	Name       string
+	Title      string
+	Body       string
+	CreatedAt  pgtype.Timestamptz
+	CreatedBy  uuid.NullUUID
+	UpdatedAt  pgtype.Timestamptz
+	UpdatedBy  uuid.NullUUID
+}
+
+func (q *Queries) SaveTemplate(ctx context.Context, db DBTX, arg SaveTemplateParams) error {
+	_, err := db.Exec(ctx, saveTemplate,
+		arg.TemplateID,
+		arg.Name,
+		arg.Title,
+		arg.Body,
+		arg.CreatedAt,
+		arg.CreatedBy,
+		arg.UpdatedAt,
+		arg.UpdatedBy,
+	)
+	return err
+}
+
 const saveUser = `-- name: SaveUser :exec
 INSERT INTO notes.users (
   user_id,
@@ -482,112 +4723,218 @@ func (q *Queries) SaveUser(ctx context.Context, db DBTX, arg SaveUserParams) err
 	return err
 }
 
-const searchNotesWithTag = `-- name: SearchNotesWithTag :many
-SELECT
-  notes.note_id,
-  title
-FROM notes.notes
-JOIN notes.user_note_access ON
-  user_note_access.note_id = notes.note_id
-  AND user_id = $1
-  -- NOTE: any access
-JOIN notes.note_tags ON
-  note_tags.note_id = notes.note_id
-WHERE note_tags.tag_id = $2
-  AND ($3::uuid IS NULL OR notes.note_id > $3::uuid)
-ORDER BY notes.note_id ASC
-LIMIT $4
+const saveWebhook = `-- name: SaveWebhook :exec
+INSERT INTO notes.webhooks (
+  webhook_id,
+  user_id,
+  url,
+  secret,
+  event_kinds,
+  tag_ids,
+  active,
+  consecutive_failures,
+  disabled_at,
+  created_at,
+  updated_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5::text[],
+  $6::uuid[],
+  $7,
+  $8,
+  $9,
+  $10,
+  $11
+) ON CONFLICT (webhook_id) DO UPDATE
+  SET url                  = excluded.url,
+      secret               = excluded.secret,
+      event_kinds          = excluded.event_kinds,
+      tag_ids              = excluded.tag_ids,
+      active               = excluded.active,
+      consecutive_failures = excluded.consecutive_failures,
+      disabled_at          = excluded.disabled_at,
+      updated_at           = excluded.updated_at
 `
 
-type SearchNotesWithTagParams struct {
-	UserID     uuid.UUID
-	TagID      uuid.UUID
-	LastNoteID uuid.NullUUID
-	PageSize   int64
-}
-
-type SearchNotesWithTagRow struct {
-	NoteID uuid.UUID
-	Title  string
+type SaveWebhookParams struct {
+	WebhookID           uuid.UUID
+	UserID              uuid.UUID
+	Url                 string
+	Secret              string
+	EventKinds          []string
+	TagIds              []uuid.UUID
+	Active              bool
+	ConsecutiveFailures int32
+	DisabledAt          pgtype.Timestamptz
+	CreatedAt           pgtype.Timestamptz
+	UpdatedAt           pgtype.Timestamptz
 }
 
-func (q *Queries) SearchNotesWithTag(ctx context.Context, db DBTX, arg SearchNotesWithTagParams) ([]SearchNotesWithTagRow, error) {
-	rows, err := db.Query(ctx, searchNotesWithTag,
+func (q *Queries) SaveWebhook(ctx context.Context, db DBTX, arg SaveWebhookParams) error {
+	_, err := db.Exec(ctx, saveWebhook,
+		arg.WebhookID,
 		arg.UserID,
-		arg.TagID,
-		arg.LastNoteID,
-		arg.PageSize,
+		arg.Url,
+		arg.Secret,
+		arg.EventKinds,
+		arg.TagIds,
+		arg.Active,
+		arg.ConsecutiveFailures,
+		arg.DisabledAt,
+		arg.CreatedAt,
+		arg.UpdatedAt,
 	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []SearchNotesWithTagRow
-	for rows.Next() {
-		var i SearchNotesWithTagRow
-		if err := rows.Scan(&i.NoteID, &i.Title); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+	return err
 }
 
-const searchNotesWithText = `-- name: SearchNotesWithText :many
+const searchNoteFacets = `-- name: SearchNoteFacets :many
+WITH matches AS (
+  SELECT
+    notes.note_id,
+    notes.created_by,
+    notes.updated_at
+  FROM notes.notes
+  JOIN notes.user_note_access ON
+    user_note_access.note_id = notes.note_id
+    AND user_note_access.user_id = $1
+    -- NOTE: any access
+  LEFT JOIN notes.user_note_states ON
+    user_note_states.note_id = notes.note_id
+    AND user_note_states.user_id = user_note_access.user_id
+  CROSS JOIN LATERAL (
+    SELECT
+      COALESCE(user_note_states.pinned, false) AS pinned,
+      COALESCE(user_note_states.starred, false) AS starred,
+      COALESCE(user_note_states.archived, false) AS archived
+  ) AS state
+  WHERE ($2::text = '' OR websearch_to_tsquery($2::text) @@ notes.search_index OR EXISTS (
+      SELECT 1
+      FROM notes.attachments
+      JOIN notes.attachment_texts ON
+        attachment_texts.attachment_id = attachments.attachment_id
+      WHERE attachments.note_id = notes.note_id
+        AND websearch_to_tsquery($2::text) @@ attachment_texts.search_index
+    ))
+    AND ($3::uuid IS NULL OR notes.created_by = $3::uuid)
+    AND ($4::uuid IS NULL OR notes.updated_by = $4::uuid)
+    AND ($5::timestamptz IS NULL OR notes.created_at >= $5::timestamptz)
+    AND ($6::timestamptz IS NULL OR notes.created_at < $6::timestamptz)
+    AND ($7::timestamptz IS NULL OR notes.updated_at >= $7::timestamptz)
+    AND ($8::timestamptz IS NULL OR notes.updated_at < $8::timestamptz)
+    AND (cardinality($9::uuid[]) = 0 OR cardinality($9::uuid[]) = (
+      SELECT COUNT(*)
+      FROM notes.note_tags
+      WHERE note_tags.note_id = notes.note_id
+        AND note_tags.tag_id = ANY($9::uuid[])
+    ))
+    AND (cardinality($10::uuid[]) = 0 OR EXISTS (
+      SELECT 1
+      FROM notes.note_tags
+      WHERE note_tags.note_id = notes.note_id
+        AND note_tags.tag_id = ANY($10::uuid[])
+    ))
+    AND NOT EXISTS (
+      SELECT 1
+      FROM notes.note_tags
+      WHERE note_tags.note_id = notes.note_id
+        AND note_tags.tag_id = ANY($11::uuid[])
+    )
+    AND ($12::bool IS NULL OR state.pinned = $12::bool)
+    AND ($13::bool IS NULL OR state.starred = $13::bool)
+    AND ($14::bool IS NULL OR state.archived = $14::bool)
+)
 SELECT
-  notes.note_id,
-  title,
-  rank::float4,
-  ts_headline(title || '\n' || body, query, 'StartSel=<<, StopSel=>>') AS match
-FROM notes.notes
-CROSS JOIN LATERAL websearch_to_tsquery($1) AS query
-CROSS JOIN LATERAL ts_rank_cd(search_index, query) AS rank
-JOIN notes.user_note_access ON
-  user_note_access.note_id = notes.note_id
-  AND user_id = $2
+  'tag'::text AS facet,
+  tags.tag_id::text AS value,
+  tags.name AS label,
+  COUNT(*) AS count
+FROM matches
+JOIN notes.note_tags ON
+  note_tags.note_id = matches.note_id
+JOIN notes.tags ON
+  tags.tag_id = note_tags.tag_id
+JOIN notes.user_tag_access ON
+  user_tag_access.tag_id = tags.tag_id
+  AND user_tag_access.user_id = $1
   -- NOTE: any access
-WHERE query @@ search_index
-  AND $3::float4 IS NULL OR rank::float4 < $3::float4
-ORDER BY rank::float4 DESC
-LIMIT $4
+GROUP BY tags.tag_id, tags.name
+UNION ALL
+SELECT
+  'author'::text AS facet,
+  users.user_id::text AS value,
+  users.name AS label,
+  COUNT(*) AS count
+FROM matches
+JOIN notes.users ON
+  users.user_id = matches.created_by
+GROUP BY users.user_id, users.name
+UNION ALL
+SELECT
+  'updated_month'::text AS facet,
+  to_char(matches.updated_at AT TIME ZONE 'UTC', 'YYYY-MM') AS value,
+  '' AS label,
+  COUNT(*) AS count
+FROM matches
+GROUP BY 2
+ORDER BY facet, count DESC, value
 `
 
-type SearchNotesWithTextParams struct {
-	TextSearch string
-	UserID     uuid.UUID
-	LastRank   pgtype.Float4
-	PageSize   int64
+type SearchNoteFacetsParams struct {
+	UserID        uuid.UUID
+	TextSearch    string
+	CreatedBy     uuid.NullUUID
+	UpdatedBy     uuid.NullUUID
+	CreatedAfter  pgtype.Timestamptz
+	CreatedBefore pgtype.Timestamptz
+	UpdatedAfter  pgtype.Timestamptz
+	UpdatedBefore pgtype.Timestamptz
+	AllTags       []uuid.UUID
+	AnyTags       []uuid.UUID
+	NoneTags      []uuid.UUID
+	Pinned        pgtype.Bool
+	Starred       pgtype.Bool
+	Archived      pgtype.Bool
 }
 
-type SearchNotesWithTextRow struct {
-	NoteID uuid.UUID
-	Title  string
-	Rank   float32
-	Match  pgtype.Text
+type SearchNoteFacetsRow struct {
+	Facet string
+	Value string
+	Label string
+	Count int64
 }
 
-func (q *Queries) SearchNotesWithText(ctx context.Context, db DBTX, arg SearchNotesWithTextParams) ([]SearchNotesWithTextRow, error) {
-	rows, err := db.Query(ctx, searchNotesWithText,
-		arg.TextSearch,
+func (q *Queries) SearchNoteFacets(ctx context.Context, db DBTX, arg SearchNoteFacetsParams) ([]SearchNoteFacetsRow, error) {
+	rows, err := db.Query(ctx, searchNoteFacets,
 		arg.UserID,
-		arg.LastRank,
-		arg.PageSize,
+		arg.TextSearch,
+		arg.CreatedBy,
+		arg.UpdatedBy,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.UpdatedAfter,
+		arg.UpdatedBefore,
+		arg.AllTags,
+		arg.AnyTags,
+		arg.NoneTags,
+		arg.Pinned,
+		arg.Starred,
+		arg.Archived,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []SearchNotesWithTextRow
+	var items []SearchNoteFacetsRow
 	for rows.Next() {
-		var i SearchNotesWithTextRow
+		var i SearchNoteFacetsRow
 		if err := rows.Scan(
-			&i.NoteID,
-			&i.Title,
-			&i.Rank,
-			&i.Match,
+			&i.Facet,
+			&i.Value,
+			&i.Label,
+			&i.Count,
 		); err != nil {
 			return nil, err
 		}
@@ -599,48 +4946,198 @@ func (q *Queries) SearchNotesWithText(ctx context.Context, db DBTX, arg SearchNo
 	return items, nil
 }
 
-const searchNotesWithTextAndTag = `-- name: SearchNotesWithTextAndTag :many
+const searchNotesWithText = `-- name: SearchNotesWithText :many
 SELECT
   notes.note_id,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by,
   title,
-  rank::float4 AS rank,
-  ts_headline(title || '\n' || body, query, 'StartSel=<<, StopSel=>>') AS match
-FROM notes.notes
-CROSS JOIN LATERAL websearch_to_tsquery($1) AS query
-CROSS JOIN LATERAL ts_rank_cd(search_index, query) AS rank
-JOIN notes.user_note_access ON
-  user_note_access.note_id = notes.note_id
-  AND user_id = $2
+  rank::float4,
+  state.pinned,
+  state.starred,
+  state.archived,
+  -- the highlighting markers are stripped from the text first, so matches can be found unambiguously
+  ts_headline(translate(title, U&'\E000\E001\E002', ''), query, U&'StartSel="\E000", StopSel="\E001", HighlightAll=true') AS title_match,
+  ts_headline(translate(body, U&'\E000\E001\E002', ''), query, $1::text) AS body_match,
+  attachment_hits.attachment_ids,
+  attachment_hits.attachment_names,
+  attachment_hits.attachment_matches
+-- the user's notes are found first, so that only their attachments are searched
+FROM notes.user_note_access
+JOIN notes.notes ON
+  notes.note_id = user_note_access.note_id
+  AND user_note_access.user_id = $2
   -- NOTE: any access
-JOIN notes.note_tags ON
-  note_tags.note_id = notes.note_id
-WHERE tag_id = $3
-  AND query @@ search_index
-  AND ($4::float4 IS NULL OR rank::float4 < $4::float4)
-ORDER BY rank::float4 DESC
-LIMIT $5
+CROSS JOIN LATERAL websearch_to_tsquery($3) AS query
+CROSS JOIN LATERAL (
+  -- the note's attachments whose text matches, best first
+  SELECT
+    array_agg(hits.attachment_id ORDER BY hits.rank DESC, hits.attachment_id)::uuid[] AS attachment_ids,
+    array_agg(hits.name ORDER BY hits.rank DESC, hits.attachment_id)::text[] AS attachment_names,
+    array_agg(
+      ts_headline(translate(hits.content, U&'\E000\E001\E002', ''), query, $1::text)
+      ORDER BY hits.rank DESC, hits.attachment_id
+    )::text[] AS attachment_matches,
+    max(hits.rank) AS best_rank
+  FROM (
+    SELECT
+      attachments.attachment_id,
+      attachments.name,
+      attachment_texts.content,
+      ts_rank_cd(attachment_texts.search_index, query) AS rank
+    FROM notes.attachments
+    JOIN notes.attachment_texts ON
+      attachment_texts.attachment_id = attachments.attachment_id
+    WHERE attachments.note_id = notes.note_id
+      AND query @@ attachment_texts.search_index
+    ORDER BY rank DESC, attachments.attachment_id
+    LIMIT $4::int
+  ) AS hits
+) AS attachment_hits
+CROSS JOIN LATERAL (
+  SELECT GREATEST(ts_rank_cd(notes.search_index, query), attachment_hits.best_rank) AS rank
+) AS ranked
+LEFT JOIN notes.user_note_states ON
+  user_note_states.note_id = notes.note_id
+  AND user_note_states.user_id = user_note_access.user_id
+CROSS JOIN LATERAL (
+  SELECT
+    COALESCE(user_note_states.pinned, false) AS pinned,
+    COALESCE(user_note_states.starred, false) AS starred,
+    COALESCE(user_note_states.archived, false) AS archived
+) AS state
+WHERE (query @@ notes.search_index OR attachment_hits.attachment_ids IS NOT NULL)
+  AND ($5::uuid IS NULL OR notes.created_by = $5::uuid)
+  AND ($6::uuid IS NULL OR notes.updated_by = $6::uuid)
+  AND ($7::timestamptz IS NULL OR notes.created_at >= $7::timestamptz)
+  AND ($8::timestamptz IS NULL OR notes.created_at < $8::timestamptz)
+  AND ($9::timestamptz IS NULL OR notes.updated_at >= $9::timestamptz)
+  AND ($10::timestamptz IS NULL OR notes.updated_at < $10::timestamptz)
+  AND (cardinality($11::uuid[]) = 0 OR cardinality($11::uuid[]) = (
+    SELECT COUNT(*)
+    FROM notes.note_tags
+    WHERE note_tags.note_id = notes.note_id
+      AND note_tags.tag_id = ANY($11::uuid[])
+  ))
+  AND (cardinality($12::uuid[]) = 0 OR EXISTS (
+    SELECT 1
+    FROM notes.note_tags
+    WHERE note_tags.note_id = notes.note_id
+      AND note_tags.tag_id = ANY($12::uuid[])
+  ))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM notes.note_tags
+    WHERE note_tags.note_id = notes.note_id
+      AND note_tags.tag_id = ANY($13::uuid[])
+  )
+  AND ($14::bool IS NULL OR state.pinned = $14::bool)
+  AND ($15::bool IS NULL OR state.starred = $15::bool)
+  AND ($16::bool IS NULL OR state.archived = $16::bool)
+  -- pinned notes come first
+  AND ($17::uuid IS NULL
+    OR state.pinned < $18::bool
+    OR (state.pinned = $18::bool AND CASE $19::text
+      WHEN 'updated_at' THEN CASE WHEN $20::bool
+        THEN (notes.updated_at, notes.note_id) < ($21::timestamptz, $17::uuid)
+        ELSE (notes.updated_at, notes.note_id) > ($21::timestamptz, $17::uuid)
+      END
+      WHEN 'created_at' THEN CASE WHEN $20::bool
+        THEN (notes.created_at, notes.note_id) < ($21::timestamptz, $17::uuid)
+        ELSE (notes.created_at, notes.note_id) > ($21::timestamptz, $17::uuid)
+      END
+      WHEN 'title' THEN CASE WHEN $20::bool
+        THEN (notes.title, notes.note_id) < ($22::text, $17::uuid)
+        ELSE (notes.title, notes.note_id) > ($22::text, $17::uuid)
+      END
+      ELSE rank::float4 < $23::float4
+        OR (rank::float4 = $23::float4 AND notes.note_id > $17::uuid)
+    END))
+ORDER BY
+  state.pinned DESC,
+  CASE WHEN $19::text = 'default' THEN rank::float4 END DESC,
+  CASE WHEN $19::text = 'updated_at' AND NOT $20::bool THEN notes.updated_at END ASC,
+  CASE WHEN $19::text = 'updated_at' AND $20::bool THEN notes.updated_at END DESC,
+  CASE WHEN $19::text = 'created_at' AND NOT $20::bool THEN notes.created_at END ASC,
+  CASE WHEN $19::text = 'created_at' AND $20::bool THEN notes.created_at END DESC,
+  CASE WHEN $19::text = 'title' AND NOT $20::bool THEN notes.title END ASC,
+  CASE WHEN $19::text = 'title' AND $20::bool THEN notes.title END DESC,
+  CASE WHEN $20::bool THEN notes.note_id END DESC,
+  notes.note_id ASC
+LIMIT $24
 `
 
-type SearchNotesWithTextAndTagParams struct {
-	TextSearch string
-	UserID     uuid.UUID
-	TagID      uuid.UUID
-	LastRank   pgtype.Float4
-	PageSize   int64
+type SearchNotesWithTextParams struct {
+	HeadlineOptions      string
+	UserID               uuid.UUID
+	TextSearch           string
+	MaxAttachmentMatches int32
+	CreatedBy            uuid.NullUUID
+	UpdatedBy            uuid.NullUUID
+	CreatedAfter         pgtype.Timestamptz
+	CreatedBefore        pgtype.Timestamptz
+	UpdatedAfter         pgtype.Timestamptz
+	UpdatedBefore        pgtype.Timestamptz
+	AllTags              []uuid.UUID
+	AnyTags              []uuid.UUID
+	NoneTags             []uuid.UUID
+	Pinned               pgtype.Bool
+	Starred              pgtype.Bool
+	Archived             pgtype.Bool
+	LastNoteID           uuid.NullUUID
+	LastPinned           pgtype.Bool
+	SortBy               string
+	SortDesc             bool
+	LastTime             pgtype.Timestamptz
+	LastTitle            pgtype.Text
+	LastRank             pgtype.Float4
+	PageSize             int64
 }
 
-type SearchNotesWithTextAndTagRow struct {
-	NoteID uuid.UUID
-	Title  string
-	Rank   float32
-	Match  pgtype.Text
+type SearchNotesWithTextRow struct {
+	NoteID            uuid.UUID
+	CreatedAt         pgtype.Timestamptz
+	CreatedBy         uuid.NullUUID
+	UpdatedAt         pgtype.Timestamptz
+	UpdatedBy         uuid.NullUUID
+	Title             string
+	Rank              float32
+	Pinned            bool
+	Starred           bool
+	Archived          bool
+	TitleMatch        pgtype.Text
+	BodyMatch         pgtype.Text
+	AttachmentIds     []uuid.UUID
+	AttachmentNames   []string
+	AttachmentMatches []string
 }
 
-func (q *Queries) SearchNotesWithTextAndTag(ctx context.Context, db DBTX, arg SearchNotesWithTextAndTagParams) ([]SearchNotesWithTextAndTagRow, error) {
-	rows, err := db.Query(ctx, searchNotesWithTextAndTag,
-		arg.TextSearch,
+func (q *Queries) SearchNotesWithText(ctx context.Context, db DBTX, arg SearchNotesWithTextParams) ([]SearchNotesWithTextRow, error) {
+	rows, err := db.Query(ctx, searchNotesWithText,
+		arg.HeadlineOptions,
 		arg.UserID,
-		arg.TagID,
+		arg.TextSearch,
+		arg.MaxAttachmentMatches,
+		arg.CreatedBy,
+		arg.UpdatedBy,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.UpdatedAfter,
+		arg.UpdatedBefore,
+		arg.AllTags,
+		arg.AnyTags,
+		arg.NoneTags,
+		arg.Pinned,
+		arg.Starred,
+		arg.Archived,
+		arg.LastNoteID,
+		arg.LastPinned,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.LastTime,
+		arg.LastTitle,
 		arg.LastRank,
 		arg.PageSize,
 	)
@@ -648,14 +5145,25 @@ func (q *Queries) SearchNotesWithTextAndTag(ctx context.Context, db DBTX, arg Se
 		return nil, err
 	}
 	defer rows.Close()
-	var items []SearchNotesWithTextAndTagRow
+	var items []SearchNotesWithTextRow
 	for rows.Next() {
-		var i SearchNotesWithTextAndTagRow
+		var i SearchNotesWithTextRow
 		if err := rows.Scan(
 			&i.NoteID,
+			&i.CreatedAt,
+			&i.CreatedBy,
+			&i.UpdatedAt,
+			&i.UpdatedBy,
 			&i.Title,
 			&i.Rank,
-			&i.Match,
+			&i.Pinned,
+			&i.Starred,
+			&i.Archived,
+			&i.TitleMatch,
+			&i.BodyMatch,
+			&i.AttachmentIds,
+			&i.AttachmentNames,
+			&i.AttachmentMatches,
 		); err != nil {
 			return nil, err
 		}
@@ -694,25 +5202,63 @@ func (q *Queries) SetNoteAccess(ctx context.Context, db DBTX, arg SetNoteAccessP
 }
 
 const setNoteTags = `-- name: SetNoteTags :exec
-MERGE INTO notes.note_tags
-USING (SELECT $2::uuid AS set_tag_id) ON
-  note_id = $1::uuid
-WHEN MATCHED AND set_tag_id IS NULL THEN
-  DELETE
-WHEN MATCHED AND set_tag_id IS NOT NULL THEN
-  UPDATE SET tag_id = set_tag_id
-WHEN NOT MATCHED THEN
-  INSERT (note_id, tag_id)
-  VALUES ($1::uuid, set_tag_id)
+WITH removed AS (
+  DELETE FROM notes.note_tags
+  WHERE note_id = $1
+    AND NOT (tag_id = ANY($2::uuid[]))
+)
+INSERT INTO notes.note_tags (
+  note_id,
+  tag_id
+)
+SELECT
+  $1,
+  tag_ids.tag_id
+FROM unnest($2::uuid[]) AS tag_ids(tag_id)
+ON CONFLICT (note_id, tag_id) DO NOTHING
 `
 
 type SetNoteTagsParams struct {
-	Column1 uuid.NullUUID
-	Column2 uuid.NullUUID
+	NoteID uuid.UUID
+	TagIds []uuid.UUID
 }
 
 func (q *Queries) SetNoteTags(ctx context.Context, db DBTX, arg SetNoteTagsParams) error {
-	_, err := db.Exec(ctx, setNoteTags, arg.Column1, arg.Column2)
+	_, err := db.Exec(ctx, setNoteTags, arg.NoteID, arg.TagIds)
+	return err
+}
+
+const setReminderNextAt = `-- name: SetReminderNextAt :exec
+UPDATE notes.reminders
+  SET next_at = $1
+WHERE note_id = $2
+  AND user_id = $3
+`
+
+type SetReminderNextAtParams struct {
+	NextAt pgtype.Timestamptz
+	NoteID uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) SetReminderNextAt(ctx context.Context, db DBTX, arg SetReminderNextAtParams) error {
+	_, err := db.Exec(ctx, setReminderNextAt, arg.NextAt, arg.NoteID, arg.UserID)
+	return err
+}
+
+const setSavedSearchLastChecked = `-- name: SetSavedSearchLastChecked :exec
+UPDATE notes.saved_searches
+  SET last_checked_at = $1
+WHERE saved_search_id = $2
+`
+
+type SetSavedSearchLastCheckedParams struct {
+	LastCheckedAt pgtype.Timestamptz
+	SavedSearchID uuid.UUID
+}
+
+func (q *Queries) SetSavedSearchLastChecked(ctx context.Context, db DBTX, arg SetSavedSearchLastCheckedParams) error {
+	_, err := db.Exec(ctx, setSavedSearchLastChecked, arg.LastCheckedAt, arg.SavedSearchID)
 	return err
 }
 
@@ -741,3 +5287,102 @@ func (q *Queries) SetTagAccess(ctx context.Context, db DBTX, arg SetTagAccessPar
 	_, err := db.Exec(ctx, setTagAccess, arg.Column1, arg.Column2, arg.Column3)
 	return err
 }
+
+const setThreadResolved = `-- name: SetThreadResolved :execrows
+UPDATE notes.comment_threads
+  SET resolved_at = $1,
+      resolved_by = $2
+WHERE thread_id = $3
+  AND note_id = $4
+`
+
+type SetThreadResolvedParams struct {
+	ResolvedAt pgtype.Timestamptz
+	ResolvedBy uuid.NullUUID
+	ThreadID   uuid.UUID
+	NoteID     uuid.UUID
+}
+
+func (q *Queries) SetThreadResolved(ctx context.Context, db DBTX, arg SetThreadResolvedParams) (int64, error) {
+	result, err := db.Exec(ctx, setThreadResolved,
+		arg.ResolvedAt,
+		arg.ResolvedBy,
+		arg.ThreadID,
+		arg.NoteID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const startImport = `-- name: StartImport :exec
+UPDATE notes.imports
+SET status     = 'running',
+    started_at = COALESCE(started_at, $1),
+    -- an import that was interrupted is started over
+    total      = NULL,
+    created    = 0,
+    updated    = 0,
+    failed     = 0
+WHERE import_id = $2
+`
+
+type StartImportParams struct {
+	Now      pgtype.Timestamptz
+	ImportID uuid.UUID
+}
+
+func (q *Queries) StartImport(ctx context.Context, db DBTX, arg StartImportParams) error {
+	_, err := db.Exec(ctx, startImport, arg.Now, arg.ImportID)
+	return err
+}
+
+const touchNote = `-- name: TouchNote :execrows
+UPDATE notes.notes
+SET updated_at = $1,
+    updated_by = $2
+WHERE note_id = $3
+`
+
+type TouchNoteParams struct {
+	UpdatedAt pgtype.Timestamptz
+	UpdatedBy uuid.NullUUID
+	NoteID    uuid.UUID
+}
+
+func (q *Queries) TouchNote(ctx context.Context, db DBTX, arg TouchNoteParams) (int64, error) {
+	result, err := db.Exec(ctx, touchNote, arg.UpdatedAt, arg.UpdatedBy, arg.NoteID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateImportProgress = `-- name: UpdateImportProgress :exec
+UPDATE notes.imports
+SET total   = $1,
+    created = $2,
+    updated = $3,
+    failed  = $4
+WHERE import_id = $5
+`
+
+type UpdateImportProgressParams struct {
+	Total    pgtype.Int4
+	Created  int32
+	Updated  int32
+	Failed   int32
+	ImportID uuid.UUID
+}
+
+func (q *Queries) UpdateImportProgress(ctx context.Context, db DBTX, arg UpdateImportProgressParams) error {
+	_, err := db.Exec(ctx, updateImportProgress,
+		arg.Total,
+		arg.Created,
+		arg.Updated,
+		arg.Failed,
+		arg.ImportID,
+	)
+	return err
+}
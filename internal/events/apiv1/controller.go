@@ -0,0 +1,156 @@
+package apiv1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/events"
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+const (
+	keepAliveInterval = 30 * time.Second
+	replayPageSize    = 100
+	// retryMillis is how long clients should wait before reconnecting.
+	retryMillis = 5000
+)
+
+type Service interface {
+	Subscribe(ctx context.Context) *events.Subscription
+	Unsubscribe(sub *events.Subscription)
+	ListEventsAfter(ctx context.Context, afterID int64, pageSize int) ([]events.Event, error)
+	ReplayStart(ctx context.Context, lastEventID int64) (int64, error)
+}
+
+// StreamEvents streams the changes the caller can see as Server-Sent Events.
+//
+// A client resumes after the last event it received by sending its ID in the Last-Event-ID header, as EventSource
+// does when it reconnects, or in the last_event_id query parameter. Events are sent in the order they were recorded
+// when resuming, but may arrive slightly out of order afterwards.
+//
+// Because an event can become visible after one with a higher ID, resuming replays the events recorded shortly
+// before the last one received as well, so clients may be sent events they have already seen, and must ignore any
+// event whose ID they have already received.
+func StreamEvents(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("last_event_id")
+		}
+
+		var errs []error
+		afterID := apiv1.ValidateOptional(".last_event_id", lastEventID, &errs, apiv1.ParseIntInRange(0, math.MaxInt))
+		if len(errs) != 0 {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(errs[0]))
+			return
+		}
+
+		var replayAfter int64
+		if lastEventID != "" {
+			var err error
+			replayAfter, err = svc.ReplayStart(r.Context(), int64(afterID))
+			if err != nil {
+				apiv1.WriteError(r.Context(), w, err)
+				return
+			}
+		}
+
+		// subscribe before replaying, so nothing committed in between is missed
+		sub := svc.Subscribe(r.Context())
+		defer svc.Unsubscribe(sub)
+
+		rc := http.NewResponseController(w)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		if _, err := fmt.Fprintf(w, "retry: %d\n\n", retryMillis); err != nil {
+			return
+		}
+
+		if err := rc.Flush(); err != nil {
+			log.Error(r.Context(), "error flushing event stream", zap.Error(err))
+			return
+		}
+
+		var replayed map[int64]struct{}
+		if lastEventID != "" {
+			replayed = make(map[int64]struct{})
+
+			for {
+				page, err := svc.ListEventsAfter(r.Context(), replayAfter, replayPageSize)
+				if err != nil {
+					// the status is already sent; ending the stream makes the client retry from where it got to
+					return
+				}
+
+				for i := range page {
+					if err := writeEvent(w, rc, &page[i]); err != nil {
+						return
+					}
+
+					replayed[page[i].ID] = struct{}{}
+					replayAfter = page[i].ID
+				}
+
+				if len(page) < replayPageSize {
+					break
+				}
+			}
+		}
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+
+				if err := rc.Flush(); err != nil {
+					return
+				}
+
+			case event, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+
+				if _, ok := replayed[event.ID]; ok {
+					continue
+				}
+
+				if err := writeEvent(w, rc, &event); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, rc *http.ResponseController, event *events.Event) error {
+	data, err := json.Marshal(EventFromDomain(event))
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Kind, data); err != nil {
+		return err
+	}
+
+	return rc.Flush()
+}
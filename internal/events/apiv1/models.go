@@ -0,0 +1,31 @@
+package apiv1
+
+import (
+	"time"
+
+	"github.com/dabbertorres/notes/internal/events"
+)
+
+type Event struct {
+	ID         int64  `json:"id"`
+	Type       string `json:"type"`
+	NoteID     string `json:"note_id,omitempty"`
+	TagID      string `json:"tag_id,omitempty"`
+	OccurredAt string `json:"occurred_at"`
+}
+
+func EventFromDomain(domain *events.Event) (e Event) {
+	e.ID = domain.ID
+	e.Type = string(domain.Kind)
+	e.OccurredAt = domain.OccurredAt.Format(time.RFC3339Nano)
+
+	if domain.NoteID.Valid {
+		e.NoteID = domain.NoteID.UUID.String()
+	}
+
+	if domain.TagID.Valid {
+		e.TagID = domain.TagID.UUID.String()
+	}
+
+	return e
+}
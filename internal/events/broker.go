@@ -0,0 +1,187 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+const (
+	listenRetryInterval = 5 * time.Second
+	subscriptionBuffer  = 64
+)
+
+// Subscription receives the events visible to one user, as they are committed.
+type Subscription struct {
+	userID uuid.UUID
+	events chan Event
+}
+
+// Events is closed if the subscriber falls too far behind, or if events may have been missed while the [Broker]
+// was reconnecting. Either way, the subscriber should resume from the last event it received.
+func (s *Subscription) Events() <-chan Event { return s.events }
+
+// Broker listens for events committed by any server, and fans them out to this server's subscribers.
+type Broker struct {
+	db   database.Database
+	repo Repository
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	stopped     bool
+}
+
+func NewBroker(injector do.Injector) (*Broker, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Broker{
+		db:          db,
+		repo:        repo,
+		subscribers: make(map[*Subscription]struct{}),
+	}, nil
+}
+
+func (b *Broker) Subscribe(userID uuid.UUID) *Subscription {
+	sub := &Subscription{
+		userID: userID,
+		events: make(chan Event, subscriptionBuffer),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stopped {
+		// nothing will be published anymore, so end the subscription straight away
+		close(sub.events)
+		return sub
+	}
+
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Run listens for events until ctx is cancelled, reconnecting if the connection is lost. Every subscription is
+// ended when it returns.
+func (b *Broker) Run(ctx context.Context) error {
+	defer b.stop()
+
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		log.Error(ctx, "error listening for events", zap.Error(err))
+
+		// anything committed while we weren't listening was missed, so everyone has to resume from the log
+		b.dropAll()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(listenRetryInterval):
+		}
+	}
+}
+
+func (b *Broker) listen(ctx context.Context) error {
+	return b.db.AcquireFunc(ctx, func(conn *pgxpool.Conn) error {
+		channel := pgx.Identifier{notifyChannel}.Sanitize()
+
+		if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+			return err
+		}
+
+		defer func() {
+			// don't leave the pooled connection listening, if it's still usable
+			_, _ = conn.Exec(context.WithoutCancel(ctx), "UNLISTEN "+channel)
+		}()
+
+		for {
+			notification, err := conn.Conn().WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+
+			id, err := strconv.ParseInt(notification.Payload, 10, 64)
+			if err != nil {
+				log.Warn(ctx, "ignoring malformed event notification", zap.String("payload", notification.Payload))
+				continue
+			}
+
+			event, err := b.repo.GetEvent(ctx, id)
+			if err != nil {
+				// it may have already been pruned; subscribers can still find anything else in the log
+				log.Warn(ctx, "error getting notified event", zap.Int64("event_id", id), zap.Error(err))
+				continue
+			}
+
+			b.publish(event)
+		}
+	})
+}
+
+func (b *Broker) publish(event *Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		if !event.VisibleTo(sub.userID) {
+			continue
+		}
+
+		select {
+		case sub.events <- *event:
+		default:
+			// too far behind: let it catch up from the log instead of holding everyone else up
+			delete(b.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+func (b *Broker) stop() {
+	b.mu.Lock()
+	b.stopped = true
+	b.mu.Unlock()
+
+	b.dropAll()
+}
+
+func (b *Broker) dropAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
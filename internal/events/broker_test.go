@@ -0,0 +1,41 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dabbertorres/notes/internal/database"
+)
+
+// cancelledDB fails to acquire a connection the way a pool does once its context is cancelled.
+type cancelledDB struct{ database.Database }
+
+func (cancelledDB) AcquireFunc(ctx context.Context, _ func(*pgxpool.Conn) error) error {
+	return ctx.Err()
+}
+
+func TestBroker_RunEndsSubscriptions(t *testing.T) {
+	b := &Broker{db: cancelledDB{}, subscribers: make(map[*Subscription]struct{})}
+
+	before := b.Subscribe(uuid.New())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.NoError(t, b.Run(ctx))
+
+	_, ok := <-before.Events()
+	assert.False(t, ok, "subscription from before stopping should be closed")
+
+	after := b.Subscribe(uuid.New())
+	_, ok = <-after.Events()
+	assert.False(t, ok, "subscription from after stopping should be closed")
+
+	// unsubscribing an ended subscription must not close it again
+	b.Unsubscribe(before)
+	b.Unsubscribe(after)
+}
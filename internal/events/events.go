@@ -0,0 +1,12 @@
+// Package events records changes to notes, tags and their access, and streams them to the users who can see them.
+package events
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewPGXRepository),
+	do.Lazy(NewRecorder),
+	do.Lazy(NewBroker),
+	do.Lazy(NewPruner),
+	do.Lazy(NewService),
+)
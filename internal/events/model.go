@@ -0,0 +1,87 @@
+package events
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Kind string
+
+const (
+	KindNoteSaved         Kind = "note.saved"
+	KindNoteDeleted       Kind = "note.deleted"
+	KindNoteAccessChanged Kind = "note.access_changed"
+	KindTagSaved          Kind = "tag.saved"
+	KindTagDeleted        Kind = "tag.deleted"
+	KindTagAccessChanged  Kind = "tag.access_changed"
+)
+
+// Kinds is every kind of event.
+var Kinds = []Kind{
+	KindNoteSaved,
+	KindNoteDeleted,
+	KindNoteAccessChanged,
+	KindTagSaved,
+	KindTagDeleted,
+	KindTagAccessChanged,
+}
+
+// ParseKind parses the name of a kind of event.
+func ParseKind(s string) (Kind, error) {
+	if kind := Kind(s); slices.Contains(Kinds, kind) {
+		return kind, nil
+	}
+
+	return "", fmt.Errorf("unknown event kind %q", s)
+}
+
+// Event is a change to a note or a tag. Exactly one of NoteID or TagID is set.
+type Event struct {
+	ID         int64
+	Kind       Kind
+	NoteID     uuid.NullUUID
+	TagID      uuid.NullUUID
+	Audience   []uuid.UUID
+	OccurredAt time.Time
+}
+
+// VisibleTo reports whether userID could see what changed when the event occurred.
+func (e *Event) VisibleTo(userID uuid.UUID) bool {
+	return slices.Contains(e.Audience, userID)
+}
+
+// Audience returns everyone in access.
+func Audience[L any](access map[uuid.UUID]L) []uuid.UUID {
+	audience := make([]uuid.UUID, 0, len(access))
+	for userID := range access {
+		audience = append(audience, userID)
+	}
+
+	return audience
+}
+
+// AccessChange compares who had access to something before and after it was saved.
+// It returns everyone who had or has access, and whether any of their access changed.
+func AccessChange[L comparable](before, after map[uuid.UUID]L) (audience []uuid.UUID, changed bool) {
+	audience = make([]uuid.UUID, 0, len(before)+len(after))
+
+	for userID, level := range before {
+		audience = append(audience, userID)
+
+		if afterLevel, ok := after[userID]; !ok || afterLevel != level {
+			changed = true
+		}
+	}
+
+	for userID := range after {
+		if _, ok := before[userID]; !ok {
+			audience = append(audience, userID)
+			changed = true
+		}
+	}
+
+	return audience, changed
+}
@@ -0,0 +1,59 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessChange(t *testing.T) {
+	alice := uuid.New()
+	bob := uuid.New()
+	carol := uuid.New()
+
+	tests := []struct {
+		name         string
+		before       map[uuid.UUID]string
+		after        map[uuid.UUID]string
+		wantAudience []uuid.UUID
+		wantChanged  bool
+	}{
+		{
+			name:         "unchanged",
+			before:       map[uuid.UUID]string{alice: "owner", bob: "viewer"},
+			after:        map[uuid.UUID]string{alice: "owner", bob: "viewer"},
+			wantAudience: []uuid.UUID{alice, bob},
+			wantChanged:  false,
+		},
+		{
+			name:         "granted",
+			before:       map[uuid.UUID]string{alice: "owner"},
+			after:        map[uuid.UUID]string{alice: "owner", carol: "editor"},
+			wantAudience: []uuid.UUID{alice, carol},
+			wantChanged:  true,
+		},
+		{
+			name:         "revoked",
+			before:       map[uuid.UUID]string{alice: "owner", bob: "viewer"},
+			after:        map[uuid.UUID]string{alice: "owner"},
+			wantAudience: []uuid.UUID{alice, bob},
+			wantChanged:  true,
+		},
+		{
+			name:         "level changed",
+			before:       map[uuid.UUID]string{alice: "owner", bob: "viewer"},
+			after:        map[uuid.UUID]string{alice: "owner", bob: "editor"},
+			wantAudience: []uuid.UUID{alice, bob},
+			wantChanged:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audience, changed := AccessChange(tt.before, tt.after)
+			assert.ElementsMatch(t, tt.wantAudience, audience)
+			assert.Equal(t, tt.wantChanged, changed)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+type PGXRepository struct {
+	db      database.Database
+	queries *database.Queries
+}
+
+func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGXRepository{
+		db:      db,
+		queries: database.New(),
+	}, nil
+}
+
+func (r *PGXRepository) GetEvent(ctx context.Context, id int64) (event *Event, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.GetEvent(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		event = eventFromDB(&row)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apiv1.NewError(http.StatusNotFound, "event does not exist")
+		}
+
+		log.Error(ctx, "error getting event", zap.Int64("event_id", id), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return event, nil
+}
+
+func (r *PGXRepository) ListEventsAfter(ctx context.Context, userID uuid.UUID, afterID int64, pageSize int) (events []Event, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ListEventsAfter(ctx, tx, database.ListEventsAfterParams{
+			AfterEventID: afterID,
+			UserID:       userID,
+			PageSize:     int64(pageSize),
+		})
+		if err != nil {
+			return err
+		}
+
+		events = make([]Event, len(rows))
+		for i := range rows {
+			events[i] = *eventFromDB(&rows[i])
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error listing events", zap.Stringer("user_id", userID), zap.Int64("after_event_id", afterID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return events, nil
+}
+
+func (r *PGXRepository) ReplayStartID(ctx context.Context, afterID int64, grace time.Duration) (startID int64, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		startID, err = r.queries.GetReplayStartEventID(ctx, tx, database.GetReplayStartEventIDParams{
+			AfterEventID: afterID,
+			GraceSeconds: grace.Seconds(),
+		})
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error finding where to replay events from", zap.Int64("after_event_id", afterID), zap.Error(err))
+		return 0, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return startID, nil
+}
+
+func (r *PGXRepository) DeleteEventsBefore(ctx context.Context, t time.Time) (numDeleted int64, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		numDeleted, err = r.queries.DeleteEventsBefore(ctx, tx, pgtype.Timestamptz{Time: t, Valid: true})
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error deleting events", zap.Time("before", t), zap.Error(err))
+		return 0, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return numDeleted, nil
+}
+
+func eventFromDB(row *database.NotesEvent) *Event {
+	return &Event{
+		ID:         row.EventID,
+		Kind:       Kind(row.Kind),
+		NoteID:     row.NoteID,
+		TagID:      row.TagID,
+		Audience:   row.Audience,
+		OccurredAt: row.OccurredAt.Time,
+	}
+}
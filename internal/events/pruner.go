@@ -0,0 +1,54 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+const (
+	pruneInterval = time.Hour
+	// eventRetention is how long a client may be disconnected and still resume without missing anything.
+	eventRetention = 7 * 24 * time.Hour
+)
+
+// Pruner deletes events old enough that no client should still be resuming from them.
+type Pruner struct {
+	repo Repository
+}
+
+func NewPruner(injector do.Injector) (*Pruner, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pruner{
+		repo: repo,
+	}, nil
+}
+
+// Run prunes periodically until ctx is cancelled.
+func (p *Pruner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		numDeleted, err := p.repo.DeleteEventsBefore(ctx, time.Now().Add(-eventRetention))
+		if err != nil && ctx.Err() == nil {
+			log.Error(ctx, "error pruning events", zap.Error(err))
+		} else if numDeleted > 0 {
+			log.Debug(ctx, "pruned events", zap.Int64("count", numDeleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,60 @@
+-- name: AppendEvent :one
+INSERT INTO notes.events (
+  kind,
+  note_id,
+  tag_id,
+  audience,
+  occurred_at
+) VALUES (
+  sqlc.arg(kind),
+  sqlc.narg(note_id),
+  sqlc.narg(tag_id),
+  sqlc.arg(audience)::uuid[],
+  sqlc.arg(occurred_at)
+)
+RETURNING event_id
+;
+
+-- name: NotifyEvent :exec
+SELECT pg_notify('notes_events', sqlc.arg(payload)::text)
+;
+
+-- name: GetEvent :one
+SELECT
+  event_id,
+  kind,
+  note_id,
+  tag_id,
+  audience,
+  occurred_at
+FROM notes.events
+WHERE event_id = sqlc.arg(event_id)
+;
+
+-- name: ListEventsAfter :many
+SELECT
+  event_id,
+  kind,
+  note_id,
+  tag_id,
+  audience,
+  occurred_at
+FROM notes.events
+WHERE event_id > sqlc.arg(after_event_id)
+  AND sqlc.arg(user_id)::uuid = ANY(audience)
+ORDER BY event_id ASC
+LIMIT sqlc.arg(page_size)
+;
+
+-- name: GetReplayStartEventID :one
+SELECT COALESCE(MIN(events.event_id) - 1, sqlc.arg(after_event_id)::bigint)::bigint AS start_event_id
+FROM notes.events
+JOIN notes.events AS after_event ON after_event.event_id = sqlc.arg(after_event_id)::bigint
+WHERE events.event_id <= after_event.event_id
+  AND events.occurred_at >= after_event.occurred_at - make_interval(secs => sqlc.arg(grace_seconds)::float8)
+;
+
+-- name: DeleteEventsBefore :execrows
+DELETE FROM notes.events
+WHERE occurred_at < sqlc.arg(occurred_before)
+;
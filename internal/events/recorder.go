@@ -0,0 +1,70 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+
+	"github.com/dabbertorres/notes/internal/database"
+)
+
+// notifyChannel is the Postgres channel that an event's ID is sent on once it has been committed.
+const notifyChannel = "notes_events"
+
+// Observer is notified of each event as it is recorded, in the same transaction.
+// Returning an error aborts the change the event describes.
+type Observer interface {
+	ObserveEvent(ctx context.Context, tx pgx.Tx, event *Event) error
+}
+
+// Recorder appends events to the log as part of the transaction making the change they describe,
+// so that an event is recorded if and only if its change is.
+type Recorder struct {
+	queries   *database.Queries
+	observers []Observer
+}
+
+func NewRecorder(injector do.Injector) (*Recorder, error) {
+	return &Recorder{
+		queries: database.New(),
+	}, nil
+}
+
+// Observe registers observer to be notified of every recorded event.
+// It must be called before any events are recorded.
+func (r *Recorder) Observe(observer Observer) {
+	r.observers = append(r.observers, observer)
+}
+
+// Record appends event to the log in tx, and notifies every [Broker] of it once tx commits.
+// The event's ID, and its OccurredAt if unset, are filled in.
+func (r *Recorder) Record(ctx context.Context, tx pgx.Tx, event *Event) error {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	id, err := r.queries.AppendEvent(ctx, tx, database.AppendEventParams{
+		Kind:       string(event.Kind),
+		NoteID:     event.NoteID,
+		TagID:      event.TagID,
+		Audience:   event.Audience,
+		OccurredAt: pgtype.Timestamptz{Time: event.OccurredAt, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	event.ID = id
+
+	for _, observer := range r.observers {
+		if err := observer.ObserveEvent(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return r.queries.NotifyEvent(ctx, tx, strconv.FormatInt(id, 10))
+}
@@ -0,0 +1,19 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	GetEvent(ctx context.Context, id int64) (*Event, error)
+	// ListEventsAfter lists the events visible to userID that were recorded after the event with ID afterID.
+	ListEventsAfter(ctx context.Context, userID uuid.UUID, afterID int64, pageSize int) ([]Event, error)
+	// ReplayStartID finds where to replay from after the event with ID afterID, so that events with lower IDs that
+	// occurred within grace before it are replayed too.
+	ReplayStartID(ctx context.Context, afterID int64, grace time.Duration) (int64, error)
+	// DeleteEventsBefore deletes events that occurred before t, and returns how many there were.
+	DeleteEventsBefore(ctx context.Context, t time.Time) (int64, error)
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/do/v2"
+
+	"github.com/dabbertorres/notes/internal/scope"
+)
+
+// replayGracePeriod is how long before the last event a client received to replay events from.
+// Event IDs are assigned when an event is recorded, but become visible when its transaction commits, so an event
+// with a lower ID can become visible after a client has already received a higher one. Replaying those that
+// occurred within a transaction's lifetime before it catches them.
+const replayGracePeriod = time.Minute
+
+type Service struct {
+	repo   Repository
+	broker *Broker
+}
+
+func NewService(injector do.Injector) (*Service, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	broker, err := do.Invoke[*Broker](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		repo:   repo,
+		broker: broker,
+	}, nil
+}
+
+// Subscribe follows the events visible to the current user, until [Service.Unsubscribe] is called.
+func (s *Service) Subscribe(ctx context.Context) *Subscription {
+	userID := scope.MustUserID(ctx)
+	return s.broker.Subscribe(userID)
+}
+
+func (s *Service) Unsubscribe(sub *Subscription) {
+	s.broker.Unsubscribe(sub)
+}
+
+// ListEventsAfter lists the events visible to the current user that were recorded after the event with ID afterID,
+// in the order they were recorded.
+func (s *Service) ListEventsAfter(ctx context.Context, afterID int64, pageSize int) ([]Event, error) {
+	userID := scope.MustUserID(ctx)
+	return s.repo.ListEventsAfter(ctx, userID, afterID, pageSize)
+}
+
+// ReplayStart returns the ID to list events after, to resume a client that last received the event with ID
+// lastEventID.
+// It is earlier than lastEventID when other events were recorded shortly before that one, so some events may be
+// replayed that the client has already received; clients must ignore events with IDs they have seen.
+func (s *Service) ReplayStart(ctx context.Context, lastEventID int64) (int64, error) {
+	return s.repo.ReplayStartID(ctx, lastEventID, replayGracePeriod)
+}
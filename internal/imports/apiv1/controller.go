@@ -0,0 +1,132 @@
+package apiv1
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/imports"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+type Service interface {
+	CreateImport(ctx context.Context, format imports.Format, r io.Reader) (*imports.Import, error)
+	GetImport(ctx context.Context, importID uuid.UUID) (*imports.Import, error)
+	ListFailures(ctx context.Context, importID uuid.UUID, params imports.FailureListParams, pageSize int) (results []imports.Failure, next *imports.FailureListParams, err error)
+}
+
+// PostImport starts importing the body, a file exported from the application named by the "format" parameter.
+// The import runs in the background, and its progress can be followed with [GetImport].
+func PostImport(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := imports.ParseFormat(r.URL.Query().Get("format"))
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(&apiv1.InvalidFieldError{
+				Field: ".format",
+				Err:   err.Error(),
+			}))
+			return
+		}
+
+		created, err := svc.CreateImport(r.Context(), format, r.Body)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		w.Header().Set("Location", "/api/v1/imports/"+created.ID.String())
+
+		dto := ImportFromDomain(created)
+		apiv1.WriteJSON(r.Context(), w, http.StatusAccepted, &dto)
+	}
+}
+
+func GetImport(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		importID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid import id"))
+			return
+		}
+
+		imp, err := svc.GetImport(r.Context(), importID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := ImportFromDomain(imp)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
+// ListFailures lists the notes of an import that could not be imported, and why.
+func ListFailures(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		importID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid import id"))
+			return
+		}
+
+		paging, err := parseListFailuresParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		params := imports.FailureListParams{
+			LastFailureID: paging.Data.LastFailureID,
+		}
+		results, next, err := svc.ListFailures(r.Context(), importID, params, paging.PageSize)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		page := apiv1.Page[Failure, *ListFailuresPageTokenData]{
+			NextPageToken: nil,
+			Items:         util.MapSlice(results, FailureFromDomain),
+		}
+
+		if next != nil {
+			page.NextPageToken = &apiv1.PageToken[*ListFailuresPageTokenData]{
+				Data: &ListFailuresPageTokenData{
+					LastFailureID: next.LastFailureID,
+				},
+				PageSize: paging.PageSize,
+			}
+		}
+
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, page)
+	}
+}
+
+func parseListFailuresParams(r *http.Request) (token apiv1.PageToken[*ListFailuresPageTokenData], err error) {
+	rawPageToken := r.FormValue("next_page_token")
+	if rawPageToken != "" {
+		pageToken, err := apiv1.ParsePageToken[*ListFailuresPageTokenData](rawPageToken, 100, 100)
+		if err != nil {
+			return token, apiv1.NewValidationFailureError(err)
+		}
+
+		token = pageToken
+	} else {
+		token.Data = &ListFailuresPageTokenData{}
+
+		if size := r.FormValue("page_size"); size != "" {
+			pageSize, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return token, apiv1.NewValidationFailureError(err)
+			}
+
+			token.PageSize = int(pageSize)
+		}
+	}
+
+	return token, nil
+}
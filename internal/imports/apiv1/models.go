@@ -0,0 +1,92 @@
+package apiv1
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/dabbertorres/notes/internal/imports"
+)
+
+type Import struct {
+	ID string `json:"id"`
+	// JobID is the job running the import, which reports its progress and can cancel it.
+	JobID      string  `json:"job_id"`
+	Format     string  `json:"format"`
+	Status     string  `json:"status"`
+	CreatedAt  string  `json:"created_at"`
+	StartedAt  *string `json:"started_at,omitempty"`
+	FinishedAt *string `json:"finished_at,omitempty"`
+	Total      *int    `json:"total,omitempty"`
+	Created    int     `json:"created"`
+	Updated    int     `json:"updated"`
+	Failed     int     `json:"failed"`
+	Error      string  `json:"error,omitempty"`
+}
+
+func ImportFromDomain(domain *imports.Import) (i Import) {
+	i.ID = domain.ID.String()
+	i.JobID = domain.JobID.String()
+	i.Format = string(domain.Format)
+	i.Status = string(domain.Status)
+	i.CreatedAt = domain.CreatedAt.Format(time.RFC3339)
+	i.StartedAt = formatOptionalTime(domain.StartedAt)
+	i.FinishedAt = formatOptionalTime(domain.FinishedAt)
+	i.Total = domain.Total
+	i.Created = domain.Created
+	i.Updated = domain.Updated
+	i.Failed = domain.Failed
+	i.Error = domain.Error
+	return i
+}
+
+type Failure struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+}
+
+func FailureFromDomain(domain imports.Failure) (f Failure) {
+	f.Item = domain.Item
+	f.Error = domain.Error
+	return f
+}
+
+type ListFailuresPageTokenData struct {
+	LastFailureID *int64
+}
+
+func (d *ListFailuresPageTokenData) EncodePager() ([][]byte, error) {
+	var out [1][]byte
+
+	if d.LastFailureID != nil {
+		out[0] = strconv.AppendInt(nil, *d.LastFailureID, 10)
+	}
+
+	return out[:], nil
+}
+
+func (d *ListFailuresPageTokenData) DecodePager(data [][]byte) error {
+	if len(data) != 1 {
+		return errors.New("invalid page token format (incorrect number of parts)")
+	}
+
+	if len(data[0]) != 0 {
+		lastID, err := strconv.ParseInt(string(data[0]), 10, 64)
+		if err != nil {
+			return err
+		}
+
+		d.LastFailureID = &lastID
+	}
+
+	return nil
+}
+
+func formatOptionalTime(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+
+	s := t.Format(time.RFC3339)
+	return &s
+}
@@ -0,0 +1,126 @@
+package imports
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dabbertorres/notes/internal/archives"
+)
+
+// enexTimeLayout is the format of times in an ENEX file.
+const enexTimeLayout = "20060102T150405Z"
+
+type enexNote struct {
+	Title   string   `xml:"title"`
+	Content string   `xml:"content"`
+	Created string   `xml:"created"`
+	Updated string   `xml:"updated"`
+	Tags    []string `xml:"tag"`
+}
+
+// ParseENEX reads the notes of an Evernote export from r, calling yield with each as it is read. Attachments are not
+// imported. If yield returns an error, parsing stops and that error is returned.
+func ParseENEX(r io.Reader, yield func(*Item) error) error {
+	d := xml.NewDecoder(r)
+	d.Entity = xml.HTMLEntity
+
+	index := 0
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			if index == 0 {
+				return &parseError{errors.New("no notes found in ENEX file")}
+			}
+
+			return nil
+		}
+		if err != nil {
+			return &parseError{fmt.Errorf("invalid ENEX file: %w", err)}
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+
+		index++
+
+		var note enexNote
+		if err := d.DecodeElement(&note, &start); err != nil {
+			return &parseError{fmt.Errorf("invalid ENEX file: %w", err)}
+		}
+
+		if err := yield(enexItem(&note, index)); err != nil {
+			return err
+		}
+	}
+}
+
+// enexItem converts the index'th note of an ENEX file.
+func enexItem(note *enexNote, index int) *Item {
+	item := &Item{Name: note.Title}
+	if item.Name == "" {
+		item.Name = "note " + strconv.Itoa(index)
+	}
+
+	var errs []error
+
+	createdAt, err := parseENEXTime(note.Created)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid created: %w", err))
+	}
+
+	updatedAt, err := parseENEXTime(note.Updated)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid updated: %w", err))
+	}
+
+	body, err := ENMLToMarkdown(note.Content)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) != 0 {
+		item.Err = errors.Join(errs...)
+		return item
+	}
+
+	// ENEX notes have no ID, so they are identified by when they were created, and what they are called
+	key := note.Created
+	if key == "" {
+		key = strconv.Itoa(index)
+	}
+
+	item.Doc = &archives.Document{
+		Path: "evernote/" + key + "/" + strings.ReplaceAll(note.Title, "/", "-") + ".md",
+		FrontMatter: archives.FrontMatter{
+			Title:     strings.TrimSpace(note.Title),
+			Tags:      note.Tags,
+			CreatedAt: createdAt,
+			UpdatedAt: updatedAt,
+		},
+		Body: body,
+	}
+
+	return item
+}
+
+// parseENEXTime converts a time in an ENEX file to the format used by front matter.
+func parseENEXTime(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+
+	t, err := time.Parse(enexTimeLayout, s)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Format(time.RFC3339), nil
+}
@@ -0,0 +1,56 @@
+package imports
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseENEX(t *testing.T) {
+	const enex = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export4.dtd">
+<en-export export-date="20240705T090000Z" application="Evernote" version="10">
+  <note>
+    <title>Handoff</title>
+    <created>20240704T133700Z</created>
+    <updated>20240705T090000Z</updated>
+    <tag>oncall</tag>
+    <tag>2024</tag>
+    <content><![CDATA[<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd"><en-note><div>page the next person</div></en-note>]]></content>
+    <resource><data encoding="base64">aGVsbG8=</data></resource>
+  </note>
+  <note>
+    <title>Broken</title>
+    <created>yesterday</created>
+    <content><![CDATA[<en-note/>]]></content>
+  </note>
+</en-export>`
+
+	var items []*Item
+	err := ParseENEX(strings.NewReader(enex), func(item *Item) error {
+		items = append(items, item)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	require.NoError(t, items[0].Err)
+	assert.Equal(t, "Handoff", items[0].Doc.FrontMatter.Title)
+	assert.Equal(t, []string{"oncall", "2024"}, []string(items[0].Doc.FrontMatter.Tags))
+	assert.Equal(t, "2024-07-04T13:37:00Z", items[0].Doc.FrontMatter.CreatedAt)
+	assert.Equal(t, "2024-07-05T09:00:00Z", items[0].Doc.FrontMatter.UpdatedAt)
+	assert.Equal(t, "page the next person\n", items[0].Doc.Body)
+
+	assert.Equal(t, "Broken", items[1].Name)
+	assert.Error(t, items[1].Err)
+}
+
+func TestParseENEX_Invalid(t *testing.T) {
+	err := ParseENEX(strings.NewReader("not xml <"), func(*Item) error { return nil })
+
+	var parseErr *parseError
+	assert.True(t, errors.As(err, &parseErr))
+}
@@ -0,0 +1,341 @@
+package imports
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ENMLToMarkdown converts the ENML (Evernote's subset of XHTML) body of an Evernote note to markdown.
+// Formatting that markdown has no equivalent for is dropped, keeping its text, and so are attachments.
+func ENMLToMarkdown(enml string) (string, error) {
+	d := xml.NewDecoder(strings.NewReader(enml))
+	// notes are often not quite valid XML, and use HTML's entities
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	c := &enmlConverter{}
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid ENML: %w", err)
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			c.start(tok)
+		case xml.EndElement:
+			c.end(tok)
+		case xml.CharData:
+			c.text(string(tok))
+		}
+	}
+
+	return c.markdown(), nil
+}
+
+type enmlList struct {
+	ordered bool
+	next    int
+}
+
+type enmlConverter struct {
+	out strings.Builder
+	// atLineStart is whether nothing has been written since the last newline, other than the line's prefix
+	atLineStart bool
+	// blankLines is how many blank lines have been written in a row
+	blankLines int
+	// pendingSpace collapses whitespace between words into a single space, as HTML does
+	pendingSpace bool
+
+	lists  []enmlList
+	quotes int
+	pre    int
+	links  []string
+
+	// tables are written one row at a time, with a separator after the first
+	tableRow  int
+	tableCell int
+}
+
+func (c *enmlConverter) start(el xml.StartElement) {
+	switch name := strings.ToLower(el.Name.Local); name {
+	case "div", "p", "section", "article", "center":
+		c.newline()
+		if name == "p" {
+			c.blankLine()
+		}
+
+	case "br":
+		// a line break on a line of its own is how Evernote writes a blank line
+		if c.atLineStart {
+			c.blankLine()
+		} else {
+			c.newline()
+		}
+
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.blankLine()
+		level, _ := strconv.Atoi(name[1:])
+		c.write(strings.Repeat("#", level) + " ")
+
+	case "hr":
+		c.blankLine()
+		c.write("---")
+		c.blankLine()
+
+	case "b", "strong":
+		c.inline("**")
+
+	case "i", "em":
+		c.inline("*")
+
+	case "s", "strike", "del":
+		c.inline("~~")
+
+	case "code":
+		if c.pre == 0 {
+			c.inline("`")
+		}
+
+	case "a":
+		c.links = append(c.links, attr(el, "href"))
+		c.inline("[")
+
+	case "img":
+		c.inline("![" + attr(el, "alt") + "](" + attr(el, "src") + ")")
+
+	case "ul", "ol":
+		if len(c.lists) == 0 {
+			c.blankLine()
+		}
+		c.lists = append(c.lists, enmlList{ordered: name == "ol", next: 1})
+
+	case "li":
+		c.newline()
+		depth := max(len(c.lists), 1)
+		c.write(strings.Repeat("  ", depth-1))
+
+		if len(c.lists) != 0 && c.lists[len(c.lists)-1].ordered {
+			list := &c.lists[len(c.lists)-1]
+			c.write(strconv.Itoa(list.next) + ". ")
+			list.next++
+		} else {
+			c.write("- ")
+		}
+
+	case "en-todo":
+		if c.atLineStart && len(c.lists) == 0 {
+			c.write("- ")
+		}
+
+		if attr(el, "checked") == "true" {
+			c.write("[x] ")
+		} else {
+			c.write("[ ] ")
+		}
+
+	case "blockquote":
+		c.blankLine()
+		c.quotes++
+		c.write("")
+
+	case "pre":
+		c.blankLine()
+		c.write("```")
+		c.newline()
+		c.pre++
+
+	case "table":
+		c.blankLine()
+		c.tableRow = 0
+
+	case "tr":
+		c.newline()
+		c.tableCell = 0
+		c.write("|")
+
+	case "td", "th":
+		c.pendingSpace = true
+	}
+}
+
+func (c *enmlConverter) end(el xml.EndElement) {
+	switch name := strings.ToLower(el.Name.Local); name {
+	case "div", "section", "article", "center":
+		c.newline()
+
+	case "p", "h1", "h2", "h3", "h4", "h5", "h6":
+		c.blankLine()
+
+	case "b", "strong":
+		c.closeInline("**")
+
+	case "i", "em":
+		c.closeInline("*")
+
+	case "s", "strike", "del":
+		c.closeInline("~~")
+
+	case "code":
+		if c.pre == 0 {
+			c.closeInline("`")
+		}
+
+	case "a":
+		href := ""
+		if len(c.links) != 0 {
+			href = c.links[len(c.links)-1]
+			c.links = c.links[:len(c.links)-1]
+		}
+		c.closeInline("](" + href + ")")
+
+	case "ul", "ol":
+		if len(c.lists) != 0 {
+			c.lists = c.lists[:len(c.lists)-1]
+		}
+		if len(c.lists) == 0 {
+			c.blankLine()
+		}
+
+	case "blockquote":
+		c.quotes = max(c.quotes-1, 0)
+		c.blankLine()
+
+	case "pre":
+		c.pre = max(c.pre-1, 0)
+		c.newline()
+		c.write("```")
+		c.blankLine()
+
+	case "td", "th":
+		c.pendingSpace = false
+		c.write(" |")
+		c.tableCell++
+
+	case "tr":
+		// markdown tables need a separator after their header, so the first row is always used as one
+		if c.tableRow == 0 && c.tableCell != 0 {
+			c.newline()
+			c.write("|" + strings.Repeat(" --- |", c.tableCell))
+		}
+		c.tableRow++
+		c.newline()
+
+	case "table":
+		c.blankLine()
+	}
+}
+
+func (c *enmlConverter) text(s string) {
+	if c.pre != 0 {
+		for i, line := range strings.Split(s, "\n") {
+			if i != 0 {
+				c.newline()
+			}
+			c.write(line)
+		}
+		return
+	}
+
+	for i, word := range strings.Fields(s) {
+		if i != 0 || startsWithSpace(s) {
+			c.pendingSpace = true
+		}
+		c.write(word)
+	}
+
+	if endsWithSpace(s) {
+		c.pendingSpace = true
+	}
+}
+
+// inline starts inline formatting, which sticks to the word after it.
+func (c *enmlConverter) inline(s string) {
+	c.write(s)
+}
+
+// closeInline ends inline formatting, which sticks to the word before it.
+func (c *enmlConverter) closeInline(s string) {
+	space := c.pendingSpace
+	c.pendingSpace = false
+	c.write(s)
+	c.pendingSpace = space
+}
+
+func (c *enmlConverter) write(s string) {
+	if c.atLineStart || c.out.Len() == 0 {
+		c.pendingSpace = false
+		if c.out.Len() == 0 || c.atLineStart {
+			c.out.WriteString(strings.Repeat("> ", c.quotes))
+		}
+		c.atLineStart = false
+	}
+
+	if c.pendingSpace {
+		c.out.WriteByte(' ')
+		c.pendingSpace = false
+	}
+
+	if s != "" {
+		c.blankLines = 0
+	}
+	c.out.WriteString(s)
+}
+
+func (c *enmlConverter) newline() {
+	if c.out.Len() == 0 {
+		return
+	}
+
+	if !c.atLineStart {
+		c.out.WriteByte('\n')
+		c.atLineStart = true
+	}
+	c.pendingSpace = false
+}
+
+func (c *enmlConverter) blankLine() {
+	c.newline()
+	if c.out.Len() == 0 || c.blankLines > 0 {
+		return
+	}
+
+	c.out.WriteByte('\n')
+	c.blankLines++
+}
+
+func (c *enmlConverter) markdown() string {
+	lines := strings.Split(c.out.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n")) + "\n"
+}
+
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if strings.EqualFold(a.Name.Local, name) {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+func startsWithSpace(s string) bool {
+	return strings.TrimLeftFunc(s, unicode.IsSpace) != s
+}
+
+func endsWithSpace(s string) bool {
+	return strings.TrimRightFunc(s, unicode.IsSpace) != s
+}
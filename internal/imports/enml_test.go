@@ -0,0 +1,57 @@
+package imports
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestENMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		enml string
+		want string
+	}{
+		{
+			name: "paragraphs and formatting",
+			enml: `<en-note><div>Hello <b>bold</b> and <i>italic</i>&nbsp;text</div><div><br/></div><div>second <a href="https://example.com">link</a></div></en-note>`,
+			want: "Hello **bold** and *italic* text\n\nsecond [link](https://example.com)\n",
+		},
+		{
+			name: "headings and rules",
+			enml: `<en-note><h2>Runbook</h2><hr/><p>Drain it first.</p></en-note>`,
+			want: "## Runbook\n\n---\n\nDrain it first.\n",
+		},
+		{
+			name: "lists and todos",
+			enml: `<en-note><ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul><div><en-todo checked="true"/>done</div><div><en-todo/>not done</div></en-note>`,
+			want: "- one\n- two\n  1. nested\n\n- [x] done\n- [ ] not done\n",
+		},
+		{
+			name: "code",
+			enml: `<en-note><pre>if x {
+  y()
+}</pre><div>run <code>make</code></div></en-note>`,
+			want: "```\nif x {\n  y()\n}\n```\n\nrun `make`\n",
+		},
+		{
+			name: "table",
+			enml: `<en-note><table><tr><td>a</td><td>b</td></tr><tr><td>1</td><td>2</td></tr></table></en-note>`,
+			want: "| a | b |\n| --- | --- |\n| 1 | 2 |\n",
+		},
+		{
+			name: "declaration and media",
+			enml: `<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd"><en-note><div>see<en-media type="image/png" hash="abc"/></div></en-note>`,
+			want: "see\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ENMLToMarkdown(tt.enml)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
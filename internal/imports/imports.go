@@ -0,0 +1,10 @@
+// Package imports imports notes exported from other applications, in the background.
+package imports
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewPGXRepository),
+	do.Lazy(NewService),
+	do.Lazy(NewRunner),
+)
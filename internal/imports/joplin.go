@@ -0,0 +1,355 @@
+package imports
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dabbertorres/notes/internal/archives"
+)
+
+// maxItemSize is the largest that an item in a Joplin export may be, once decompressed.
+const maxItemSize = 4 << 20
+
+// joplinType is the kind of a Joplin item.
+type joplinType int
+
+const (
+	joplinNote    joplinType = 1
+	joplinTag     joplinType = 5
+	joplinNoteTag joplinType = 6
+)
+
+type joplinItem struct {
+	ID                string     `json:"id"`
+	Type              joplinType `json:"type_"`
+	Title             string     `json:"title"`
+	Body              string     `json:"body"`
+	NoteID            string     `json:"note_id"`
+	TagID             string     `json:"tag_id"`
+	CreatedTime       joplinTime `json:"created_time"`
+	UpdatedTime       joplinTime `json:"updated_time"`
+	UserCreatedTime   joplinTime `json:"user_created_time"`
+	UserUpdatedTime   joplinTime `json:"user_updated_time"`
+	DeletedTime       joplinTime `json:"deleted_time"`
+	EncryptionApplied int        `json:"encryption_applied"`
+}
+
+// joplinTime is a time in a Joplin item: milliseconds since the epoch in JSON exports, and ISO 8601 in RAW exports.
+type joplinTime struct {
+	time.Time
+}
+
+func (t *joplinTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return t.parse(s)
+	}
+
+	var ms int64
+	if err := json.Unmarshal(data, &ms); err != nil {
+		return err
+	}
+
+	if ms != 0 {
+		t.Time = time.UnixMilli(ms).UTC()
+	}
+
+	return nil
+}
+
+func (t *joplinTime) parse(s string) error {
+	if s == "" {
+		return nil
+	}
+
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if ms != 0 {
+			t.Time = time.UnixMilli(ms).UTC()
+		}
+		return nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return err
+	}
+
+	t.Time = parsed
+	return nil
+}
+
+// ParseJoplin reads the notes of a Joplin export: a zip or tar (JEX) archive of items in its RAW or JSON formats.
+// Notebooks and attachments are not imported.
+func ParseJoplin(r io.ReaderAt, size int64) ([]Item, error) {
+	var (
+		items []joplinItem
+		// failures are files that could not be read, which may or may not have been notes
+		failures []Item
+	)
+
+	err := walkArchive(r, size, func(name string, data []byte, err error) {
+		if err == nil {
+			var item *joplinItem
+			item, err = parseJoplinItem(name, data)
+			if err == nil {
+				items = append(items, *item)
+				return
+			}
+		}
+
+		failures = append(failures, Item{Name: name, Err: err})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tagNames := make(map[string]string)
+	noteTags := make(map[string][]string)
+	for i := range items {
+		switch items[i].Type {
+		case joplinTag:
+			tagNames[items[i].ID] = items[i].Title
+		case joplinNoteTag:
+			noteTags[items[i].NoteID] = append(noteTags[items[i].NoteID], items[i].TagID)
+		}
+	}
+
+	results := failures
+	for i := range items {
+		if items[i].Type != joplinNote || !items[i].DeletedTime.IsZero() {
+			continue
+		}
+
+		results = append(results, joplinNoteItem(&items[i], noteTags[items[i].ID], tagNames))
+	}
+
+	if len(results) == 0 {
+		return nil, &parseError{errors.New("no notes found in Joplin export")}
+	}
+
+	return results, nil
+}
+
+func joplinNoteItem(note *joplinItem, tagIDs []string, tagNames map[string]string) Item {
+	item := Item{Name: note.Title}
+	if item.Name == "" {
+		item.Name = note.ID
+	}
+
+	if note.EncryptionApplied != 0 {
+		item.Err = errors.New("note is encrypted")
+		return item
+	}
+
+	createdAt := note.UserCreatedTime
+	if createdAt.IsZero() {
+		createdAt = note.CreatedTime
+	}
+
+	updatedAt := note.UserUpdatedTime
+	if updatedAt.IsZero() {
+		updatedAt = note.UpdatedTime
+	}
+
+	doc := &archives.Document{
+		// Joplin's IDs are unique to its user, not everyone, so the note's ID is derived from it instead of being it
+		Path: "joplin/" + note.ID,
+		FrontMatter: archives.FrontMatter{
+			Title:     note.Title,
+			CreatedAt: formatJoplinTime(createdAt),
+			UpdatedAt: formatJoplinTime(updatedAt),
+		},
+		Body: note.Body,
+	}
+
+	for _, tagID := range tagIDs {
+		if name, ok := tagNames[tagID]; ok {
+			doc.FrontMatter.Tags = append(doc.FrontMatter.Tags, name)
+		}
+	}
+
+	item.Doc = doc
+	return item
+}
+
+func formatJoplinTime(t joplinTime) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// parseJoplinItem parses a file in either of Joplin's formats, chosen by its extension.
+func parseJoplinItem(name string, data []byte) (*joplinItem, error) {
+	var item joplinItem
+
+	if path.Ext(name) == ".json" {
+		if err := json.Unmarshal(data, &item); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	} else if err := parseJoplinRaw(string(data), &item); err != nil {
+		return nil, err
+	}
+
+	if item.ID == "" {
+		return nil, errors.New("item is missing an id")
+	}
+
+	return &item, nil
+}
+
+// parseJoplinRaw parses an item in Joplin's RAW format: the title, a blank line, the body, a blank line, and then one
+// "key: value" line for each property. Items without a title or body, such as a note's tags, only have properties.
+func parseJoplinRaw(text string, item *joplinItem) error {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	content, props := "", text
+	if i := strings.LastIndex(text, "\n\n"); i >= 0 {
+		content, props = text[:i], text[i+2:]
+	}
+
+	var errs []error
+	for _, line := range strings.Split(props, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return errors.New("invalid RAW item: properties are missing")
+		}
+
+		value = strings.TrimSpace(value)
+
+		var err error
+		switch key {
+		case "id":
+			item.ID = value
+		case "note_id":
+			item.NoteID = value
+		case "tag_id":
+			item.TagID = value
+		case "type_":
+			var t int
+			t, err = strconv.Atoi(value)
+			item.Type = joplinType(t)
+		case "encryption_applied":
+			item.EncryptionApplied, err = strconv.Atoi(value)
+		case "created_time":
+			err = item.CreatedTime.parse(value)
+		case "updated_time":
+			err = item.UpdatedTime.parse(value)
+		case "user_created_time":
+			err = item.UserCreatedTime.parse(value)
+		case "user_updated_time":
+			err = item.UserUpdatedTime.parse(value)
+		case "deleted_time":
+			err = item.DeletedTime.parse(value)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", key, err))
+		}
+	}
+
+	if len(errs) != 0 {
+		return errors.Join(errs...)
+	}
+
+	item.Title, item.Body, _ = strings.Cut(content, "\n")
+	item.Body = strings.TrimPrefix(item.Body, "\n")
+	return nil
+}
+
+// walkArchive calls fn with each item in the zip or tar archive r, skipping resources and anything that isn't an
+// item. If an item can't be read, fn is called with why.
+func walkArchive(r io.ReaderAt, size int64, fn func(name string, data []byte, err error)) error {
+	magic := make([]byte, 4)
+	if _, err := r.ReadAt(magic, 0); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	if bytes.Equal(magic, []byte("PK\x03\x04")) {
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return &parseError{errors.New("invalid zip archive")}
+		}
+
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || !isJoplinItem(f.Name) {
+				continue
+			}
+
+			rc, err := f.Open()
+			if err != nil {
+				fn(f.Name, nil, err)
+				continue
+			}
+
+			data, err := readItem(rc)
+			rc.Close()
+			fn(f.Name, data, err)
+		}
+
+		return nil
+	}
+
+	tr := tar.NewReader(io.NewSectionReader(r, 0, size))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return &parseError{errors.New("expected a zip or tar archive")}
+		}
+
+		if hdr.Typeflag != tar.TypeReg || !isJoplinItem(hdr.Name) {
+			continue
+		}
+
+		data, err := readItem(tr)
+		fn(hdr.Name, data, err)
+	}
+}
+
+// isJoplinItem reports whether name is an item, rather than a resource or hidden file.
+func isJoplinItem(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".md", ".json":
+	default:
+		return false
+	}
+
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" || part == "resources" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func readItem(r io.Reader) ([]byte, error) {
+	// the size in the header may be a lie
+	data, err := io.ReadAll(io.LimitReader(r, maxItemSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxItemSize {
+		return nil, fmt.Errorf("item is larger than %d bytes", maxItemSize)
+	}
+
+	return data, nil
+}
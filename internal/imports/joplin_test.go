@@ -0,0 +1,86 @@
+package imports
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJoplin_Raw(t *testing.T) {
+	files := map[string]string{
+		"0123456789abcdef0123456789abcdef.md": "Handoff\n\npage the next person\n\nsecond paragraph\n\n" +
+			"id: 0123456789abcdef0123456789abcdef\n" +
+			"created_time: 2024-07-04T13:37:00.000Z\n" +
+			"updated_time: 2024-07-05T09:00:00.000Z\n" +
+			"user_created_time: 2024-07-01T08:00:00.000Z\n" +
+			"encryption_applied: 0\n" +
+			"type_: 1",
+		"11111111111111111111111111111111.md": "oncall\n\nid: 11111111111111111111111111111111\ntype_: 5",
+		"22222222222222222222222222222222.md": "id: 22222222222222222222222222222222\n" +
+			"note_id: 0123456789abcdef0123456789abcdef\n" +
+			"tag_id: 11111111111111111111111111111111\n" +
+			"type_: 6",
+		"resources/33333333333333333333333333333333.png": "not an item",
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, data := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data))}))
+		_, err := tw.Write([]byte(data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	items, err := ParseJoplin(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	require.NoError(t, items[0].Err)
+	doc := items[0].Doc
+	assert.Equal(t, "Handoff", doc.FrontMatter.Title)
+	assert.Equal(t, "page the next person\n\nsecond paragraph", doc.Body)
+	assert.Equal(t, []string{"oncall"}, []string(doc.FrontMatter.Tags))
+	assert.Equal(t, "2024-07-01T08:00:00Z", doc.FrontMatter.CreatedAt)
+	assert.Equal(t, "2024-07-05T09:00:00Z", doc.FrontMatter.UpdatedAt)
+}
+
+func TestParseJoplin_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	fw, err := zw.Create("export/note.json")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(`{"id":"0123456789abcdef0123456789abcdef","type_":1,"title":"Handoff","body":"body","created_time":1720100220000,"updated_time":1720170000000}`))
+	require.NoError(t, err)
+
+	fw, err = zw.Create("export/encrypted.json")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(`{"id":"fedcba9876543210fedcba9876543210","type_":1,"encryption_applied":1}`))
+	require.NoError(t, err)
+
+	fw, err = zw.Create("export/broken.json")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(`{`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	items, err := ParseJoplin(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+
+	// files that can't be read are reported first
+	assert.Equal(t, "export/broken.json", items[0].Name)
+	assert.Error(t, items[0].Err)
+
+	require.NoError(t, items[1].Err)
+	assert.Equal(t, "joplin/0123456789abcdef0123456789abcdef", items[1].Doc.Path)
+	assert.Equal(t, "2024-07-04T13:37:00Z", items[1].Doc.FrontMatter.CreatedAt)
+	assert.Equal(t, "body", items[1].Doc.Body)
+
+	assert.Error(t, items[2].Err)
+}
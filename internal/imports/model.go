@@ -0,0 +1,82 @@
+package imports
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/archives"
+)
+
+// Format is the application that an import was exported from.
+type Format string
+
+const (
+	// FormatENEX is an Evernote export: a single XML file of notes.
+	FormatENEX Format = "enex"
+	// FormatJoplin is a Joplin export, as a zip or tar (JEX) of its RAW or JSON items.
+	FormatJoplin Format = "joplin"
+)
+
+// ParseFormat parses the name of a format.
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(s); format {
+	case FormatENEX,
+		FormatJoplin:
+		return format, nil
+	}
+
+	return "", fmt.Errorf("unknown format %q", s)
+}
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed is an import that could not be read at all. Notes that fail to import don't fail the import.
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Import is a file of notes being imported by a user. The file is stored as BlobKey until the import finishes.
+type Import struct {
+	ID uuid.UUID
+	// JobID is the job that runs the import.
+	JobID      uuid.UUID
+	UserID     uuid.UUID
+	Format     Format
+	Status     Status
+	BlobKey    string
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// Total is the number of notes in the import, once it is known.
+	Total   *int
+	Created int
+	Updated int
+	Failed  int
+	// Error is why the import failed, if it did.
+	Error string
+}
+
+// Failure is a note that could not be imported.
+type Failure struct {
+	ID int64
+	// Item identifies the note within the import, such as by its title.
+	Item  string
+	Error string
+}
+
+type FailureListParams struct {
+	LastFailureID *int64
+}
+
+// Item is a note read from an import. If it could not be read, Err is why, and Doc is nil.
+type Item struct {
+	Name string
+	Doc  *archives.Document
+	Err  error
+}
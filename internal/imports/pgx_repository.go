@@ -0,0 +1,233 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/jobs"
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+type PGXRepository struct {
+	db      database.Database
+	queries *database.Queries
+	jobs    *jobs.Enqueuer
+}
+
+func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	enqueuer, err := do.Invoke[*jobs.Enqueuer](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGXRepository{
+		db:      db,
+		queries: database.New(),
+		jobs:    enqueuer,
+	}, nil
+}
+
+func (r *PGXRepository) CreateImport(ctx context.Context, imp *Import, job *jobs.Job) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		// the import refers to its job, so the job has to exist first
+		if err := r.jobs.Enqueue(ctx, tx, job); err != nil {
+			return err
+		}
+
+		return r.queries.CreateImport(ctx, tx, database.CreateImportParams{
+			ImportID:  imp.ID,
+			JobID:     imp.JobID,
+			UserID:    imp.UserID,
+			Format:    database.NotesImportFormat(imp.Format),
+			BlobKey:   imp.BlobKey,
+			CreatedAt: pgtype.Timestamptz{Time: imp.CreatedAt, Valid: true},
+		})
+	})
+	if err != nil {
+		log.Error(ctx, "error creating import", zap.Stringer("import_id", imp.ID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) GetImport(ctx context.Context, id uuid.UUID) (imp *Import, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.GetImport(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		imp = importFromRow(&row)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apiv1.NewError(http.StatusNotFound, "import does not exist")
+		}
+
+		log.Error(ctx, "error fetching import", zap.Stringer("import_id", id), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return imp, nil
+}
+
+func (r *PGXRepository) StartImport(ctx context.Context, imp *Import, now time.Time) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		err := r.queries.StartImport(ctx, tx, database.StartImportParams{
+			Now:      pgtype.Timestamptz{Time: now, Valid: true},
+			ImportID: imp.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return r.queries.ClearImportFailures(ctx, tx, imp.ID)
+	})
+	if err != nil {
+		log.Error(ctx, "error starting import", zap.Stringer("import_id", imp.ID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	// match the row, so an attempt's counts don't add to the ones left by an earlier attempt
+	imp.Status = StatusRunning
+	if imp.StartedAt.IsZero() {
+		imp.StartedAt = now
+	}
+	imp.Total = nil
+	imp.Created = 0
+	imp.Updated = 0
+	imp.Failed = 0
+
+	return nil
+}
+
+func (r *PGXRepository) UpdateProgress(ctx context.Context, imp *Import) error {
+	params := database.UpdateImportProgressParams{
+		Created:  int32(imp.Created),
+		Updated:  int32(imp.Updated),
+		Failed:   int32(imp.Failed),
+		ImportID: imp.ID,
+	}
+	if imp.Total != nil {
+		params.Total = pgtype.Int4{Int32: int32(*imp.Total), Valid: true}
+	}
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return r.queries.UpdateImportProgress(ctx, tx, params)
+	})
+	if err != nil {
+		log.Error(ctx, "error updating import progress", zap.Stringer("import_id", imp.ID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) AddFailure(ctx context.Context, importID uuid.UUID, failure *Failure) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return r.queries.AddImportFailure(ctx, tx, database.AddImportFailureParams{
+			ImportID: importID,
+			Item:     failure.Item,
+			Error:    failure.Error,
+		})
+	})
+	if err != nil {
+		log.Error(ctx, "error recording import failure", zap.Stringer("import_id", importID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) FinishImport(ctx context.Context, imp *Import) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return r.queries.FinishImport(ctx, tx, database.FinishImportParams{
+			Status:     database.NotesImportStatus(imp.Status),
+			FinishedAt: pgtype.Timestamptz{Time: imp.FinishedAt, Valid: true},
+			Error:      pgtype.Text{String: imp.Error, Valid: imp.Error != ""},
+			ImportID:   imp.ID,
+		})
+	})
+	if err != nil {
+		log.Error(ctx, "error finishing import", zap.Stringer("import_id", imp.ID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) ListFailures(ctx context.Context, importID uuid.UUID, params FailureListParams, pageSize int) (failures []Failure, err error) {
+	args := database.ListImportFailuresParams{
+		ImportID: importID,
+		PageSize: int64(pageSize),
+	}
+	if params.LastFailureID != nil {
+		args.LastFailureID = pgtype.Int8{Int64: *params.LastFailureID, Valid: true}
+	}
+
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ListImportFailures(ctx, tx, args)
+		if err != nil {
+			return err
+		}
+
+		failures = make([]Failure, 0, len(rows))
+		for _, row := range rows {
+			failures = append(failures, Failure{
+				ID:    row.FailureID,
+				Item:  row.Item,
+				Error: row.Error,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error listing import failures", zap.Stringer("import_id", importID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return failures, nil
+}
+
+func importFromRow(row *database.NotesImport) *Import {
+	imp := &Import{
+		ID:         row.ImportID,
+		JobID:      row.JobID,
+		UserID:     row.UserID,
+		Format:     Format(row.Format),
+		Status:     Status(row.Status),
+		BlobKey:    row.BlobKey,
+		CreatedAt:  row.CreatedAt.Time,
+		StartedAt:  row.StartedAt.Time,
+		FinishedAt: row.FinishedAt.Time,
+		Created:    int(row.Created),
+		Updated:    int(row.Updated),
+		Failed:     int(row.Failed),
+		Error:      row.Error.String,
+	}
+
+	if row.Total.Valid {
+		total := int(row.Total.Int32)
+		imp.Total = &total
+	}
+
+	return imp
+}
@@ -0,0 +1,98 @@
+-- name: CreateImport :exec
+INSERT INTO notes.imports (
+  import_id,
+  job_id,
+  user_id,
+  format,
+  status,
+  blob_key,
+  created_at
+) VALUES (
+  sqlc.arg(import_id),
+  sqlc.arg(job_id),
+  sqlc.arg(user_id),
+  sqlc.arg(format),
+  'pending',
+  sqlc.arg(blob_key),
+  sqlc.arg(created_at)
+)
+;
+
+-- name: GetImport :one
+SELECT
+  import_id,
+  job_id,
+  user_id,
+  format,
+  status,
+  blob_key,
+  created_at,
+  started_at,
+  finished_at,
+  total,
+  created,
+  updated,
+  failed,
+  error
+FROM notes.imports
+WHERE import_id = sqlc.arg(import_id)
+;
+
+-- name: StartImport :exec
+UPDATE notes.imports
+SET status     = 'running',
+    started_at = COALESCE(started_at, sqlc.arg(now)),
+    -- an import that was interrupted is started over
+    total      = NULL,
+    created    = 0,
+    updated    = 0,
+    failed     = 0
+WHERE import_id = sqlc.arg(import_id)
+;
+
+-- name: ClearImportFailures :exec
+DELETE FROM notes.import_failures
+WHERE import_id = sqlc.arg(import_id)
+;
+
+-- name: UpdateImportProgress :exec
+UPDATE notes.imports
+SET total   = sqlc.narg(total),
+    created = sqlc.arg(created),
+    updated = sqlc.arg(updated),
+    failed  = sqlc.arg(failed)
+WHERE import_id = sqlc.arg(import_id)
+;
+
+-- name: AddImportFailure :exec
+INSERT INTO notes.import_failures (
+  import_id,
+  item,
+  error
+) VALUES (
+  sqlc.arg(import_id),
+  sqlc.arg(item),
+  sqlc.arg(error)
+)
+;
+
+-- name: FinishImport :exec
+UPDATE notes.imports
+SET status      = sqlc.arg(status),
+    finished_at = sqlc.arg(finished_at),
+    error       = sqlc.narg(error)
+WHERE import_id = sqlc.arg(import_id)
+;
+
+-- name: ListImportFailures :many
+SELECT
+  failure_id,
+  import_id,
+  item,
+  error
+FROM notes.import_failures
+WHERE import_id = sqlc.arg(import_id)
+  AND (sqlc.narg(last_failure_id)::bigint IS NULL OR failure_id > sqlc.narg(last_failure_id)::bigint)
+ORDER BY failure_id ASC
+LIMIT sqlc.arg(page_size)
+;
@@ -0,0 +1,22 @@
+package imports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/jobs"
+)
+
+type Repository interface {
+	// CreateImport records imp, and enqueues job to run it.
+	CreateImport(ctx context.Context, imp *Import, job *jobs.Job) error
+	GetImport(ctx context.Context, id uuid.UUID) (*Import, error)
+	// StartImport marks imp as running, discarding any progress from an earlier attempt, both saved and in imp.
+	StartImport(ctx context.Context, imp *Import, now time.Time) error
+	UpdateProgress(ctx context.Context, imp *Import) error
+	AddFailure(ctx context.Context, importID uuid.UUID, failure *Failure) error
+	FinishImport(ctx context.Context, imp *Import) error
+	ListFailures(ctx context.Context, importID uuid.UUID, params FailureListParams, pageSize int) ([]Failure, error)
+}
@@ -0,0 +1,241 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/archives"
+	"github.com/dabbertorres/notes/internal/blobs"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/jobs"
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+// progressInterval is how many notes are imported between recording progress.
+const progressInterval = 25
+
+// RunJob is the job that runs an import.
+var RunJob = jobs.Type[RunPayload]{
+	Kind:        "imports.run",
+	MaxAttempts: 5,
+}
+
+type RunPayload struct {
+	ImportID uuid.UUID `json:"import_id"`
+}
+
+// DocumentImporter creates or updates notes from documents.
+type DocumentImporter interface {
+	NewImporter() *archives.Importer
+}
+
+// Runner runs imports, as the handler of [RunJob].
+//
+// An import that is interrupted, such as by shutting down, is started over when its job is run again. Importing a
+// note again updates it rather than creating another, so this is harmless.
+type Runner struct {
+	repo      Repository
+	blobs     blobs.BlobStore
+	documents DocumentImporter
+}
+
+func NewRunner(injector do.Injector) (*Runner, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := do.Invoke[*blobs.Store](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := do.InvokeAs[DocumentImporter](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Runner{
+		repo:      repo,
+		blobs:     store,
+		documents: documents,
+	}, nil
+}
+
+// Handler runs [RunJob]s, for registering with a [jobs.Worker].
+func (r *Runner) Handler() jobs.Handler {
+	return RunJob.Handler(r.RunImport)
+}
+
+// RunImport imports every note of the import in payload. Problems with the file being imported, or with its notes,
+// are recorded rather than returned, so that it is not retried forever.
+func (r *Runner) RunImport(ctx context.Context, job *jobs.Job, payload RunPayload, progress *jobs.Reporter) error {
+	imp, err := r.repo.GetImport(ctx, payload.ImportID)
+	if err != nil {
+		var apiErr apiv1.Error
+		if errors.As(err, &apiErr) && apiErr.Status() == http.StatusNotFound {
+			return jobs.Permanent(err)
+		}
+
+		return err
+	}
+
+	if imp.Status != StatusPending && imp.Status != StatusRunning {
+		// the job was run again after finishing the import
+		return nil
+	}
+
+	if err := r.repo.StartImport(ctx, imp, time.Now()); err != nil {
+		return err
+	}
+
+	err = r.run(ctx, imp, progress)
+
+	// outcomes are recorded even if the import was cancelled
+	recordCtx := context.WithoutCancel(ctx)
+
+	var parseErr *parseError
+	switch {
+	case err != nil && errors.Is(context.Cause(ctx), jobs.ErrCancelled):
+		imp.Status = StatusCancelled
+		return errors.Join(err, r.finish(recordCtx, imp))
+
+	case errors.As(err, &parseErr):
+		log.Warn(ctx, "unable to read import", zap.Stringer("import_id", imp.ID), zap.Error(err))
+		imp.Status = StatusFailed
+		imp.Error = parseErr.Error()
+		return r.finish(recordCtx, imp)
+
+	case err != nil && job.FinalAttempt():
+		imp.Status = StatusFailed
+		imp.Error = "the import could not be completed"
+		return errors.Join(err, r.finish(recordCtx, imp))
+
+	case err != nil:
+		// the import is started over when the job is retried
+		return err
+	}
+
+	imp.Status = StatusSucceeded
+	return r.finish(ctx, imp)
+}
+
+// run imports every note in imp.
+func (r *Runner) run(ctx context.Context, imp *Import, progress *jobs.Reporter) error {
+	blob, err := r.blobs.Get(ctx, imp.BlobKey)
+	if err != nil {
+		if errors.Is(err, blobs.ErrNotFound) {
+			log.Warn(ctx, "import blob is missing", zap.Stringer("import_id", imp.ID))
+			return &parseError{errors.New("the imported file is missing")}
+		}
+
+		return err
+	}
+	defer blob.Close()
+
+	importer := r.documents.NewImporter()
+
+	handle := func(item *Item) error {
+		if err := r.importItem(ctx, imp, importer, item); err != nil {
+			return err
+		}
+
+		progress.Advance(1)
+
+		if n := imp.Created + imp.Updated + imp.Failed; n%progressInterval == 0 {
+			return r.repo.UpdateProgress(ctx, imp)
+		}
+
+		return nil
+	}
+
+	switch imp.Format {
+	case FormatENEX:
+		return ParseENEX(blob, handle)
+
+	case FormatJoplin:
+		items, err := ParseJoplin(blob, blob.Size())
+		if err != nil {
+			return err
+		}
+
+		total := len(items)
+		imp.Total = &total
+		progress.SetTotal(total)
+
+		for i := range items {
+			if err := handle(&items[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	default:
+		return &parseError{errors.New("unsupported format " + string(imp.Format))}
+	}
+}
+
+func (r *Runner) importItem(ctx context.Context, imp *Import, importer *archives.Importer, item *Item) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := item.Err
+	if err == nil {
+		var created bool
+		created, err = importer.Import(ctx, item.Doc)
+		if err == nil {
+			if created {
+				imp.Created++
+			} else {
+				imp.Updated++
+			}
+
+			return nil
+		}
+	}
+
+	imp.Failed++
+	return r.repo.AddFailure(ctx, imp.ID, &Failure{
+		Item:  item.Name,
+		Error: archives.FailureMessage(err),
+	})
+}
+
+// finish records the outcome of imp, and deletes the file that was imported.
+func (r *Runner) finish(ctx context.Context, imp *Import) error {
+	if imp.Total == nil || imp.Status == StatusSucceeded {
+		total := imp.Created + imp.Updated + imp.Failed
+		imp.Total = &total
+	}
+
+	if err := r.repo.UpdateProgress(ctx, imp); err != nil {
+		return err
+	}
+
+	imp.FinishedAt = time.Now()
+	if err := r.repo.FinishImport(ctx, imp); err != nil {
+		return err
+	}
+
+	if err := r.blobs.Delete(ctx, imp.BlobKey); err != nil {
+		log.Error(ctx, "error deleting import blob", zap.String("blob_key", imp.BlobKey), zap.Error(err))
+	}
+
+	return nil
+}
+
+// parseError is a problem with the file being imported, as a whole.
+type parseError struct {
+	err error
+}
+
+func (e *parseError) Error() string { return e.err.Error() }
+func (e *parseError) Unwrap() error { return e.err }
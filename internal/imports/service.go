@@ -0,0 +1,164 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/blobs"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/scope"
+)
+
+// MaxImportSize is the largest file that can be imported.
+const MaxImportSize = 256 << 20
+
+type Service struct {
+	repo  Repository
+	blobs blobs.BlobStore
+}
+
+func NewService(injector do.Injector) (*Service, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := do.Invoke[*blobs.Store](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		repo:  repo,
+		blobs: store,
+	}, nil
+}
+
+// CreateImport stores the contents of r to be imported in the background by a job, as the user in ctx.
+func (s *Service) CreateImport(ctx context.Context, format Format, r io.Reader) (*Import, error) {
+	userID := scope.MustUserID(ctx)
+
+	importID, err := uuid.NewV7()
+	if err != nil {
+		return nil, apiv1.StatusError(http.StatusServiceUnavailable)
+	}
+
+	job, err := RunJob.New(userID, RunPayload{ImportID: importID})
+	if err != nil {
+		log.Error(ctx, "error creating import job", zap.Stringer("import_id", importID), zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusServiceUnavailable)
+	}
+
+	imp := &Import{
+		ID:        importID,
+		JobID:     job.ID,
+		UserID:    userID,
+		Format:    format,
+		Status:    StatusPending,
+		BlobKey:   "imports/" + importID.String(),
+		CreatedAt: time.Now(),
+	}
+
+	body := &sizeLimitReader{r: r, limit: MaxImportSize}
+
+	if err := s.blobs.Put(ctx, imp.BlobKey, body, -1, "application/octet-stream"); err != nil {
+		// the store may have kept part of the upload
+		s.deleteBlob(ctx, imp.BlobKey)
+
+		if body.exceeded {
+			return nil, apiv1.NewError(http.StatusRequestEntityTooLarge, "import is too large")
+		}
+
+		log.Error(ctx, "error storing import", zap.Stringer("import_id", imp.ID), zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if body.n == 0 {
+		s.deleteBlob(ctx, imp.BlobKey)
+		return nil, apiv1.NewError(http.StatusBadRequest, "import is empty")
+	}
+
+	if err := s.repo.CreateImport(ctx, imp, job); err != nil {
+		s.deleteBlob(ctx, imp.BlobKey)
+		return nil, err
+	}
+
+	return imp, nil
+}
+
+// GetImport returns an import of the user in ctx. Other users' imports don't exist, as far as they are concerned.
+func (s *Service) GetImport(ctx context.Context, importID uuid.UUID) (*Import, error) {
+	imp, err := s.repo.GetImport(ctx, importID)
+	if err != nil {
+		return nil, err
+	}
+
+	if imp.UserID != scope.MustUserID(ctx) {
+		return nil, apiv1.NewError(http.StatusNotFound, "import does not exist")
+	}
+
+	return imp, nil
+}
+
+func (s *Service) ListFailures(ctx context.Context, importID uuid.UUID, params FailureListParams, pageSize int) (failures []Failure, next *FailureListParams, err error) {
+	if _, err := s.GetImport(ctx, importID); err != nil {
+		return nil, nil, err
+	}
+
+	if pageSize == 0 {
+		pageSize = 100
+	}
+
+	// retrieve one more to see if there is another page to fetch
+	failures, err = s.repo.ListFailures(ctx, importID, params, pageSize+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(failures) > pageSize {
+		failures = failures[:pageSize]
+
+		lastID := failures[len(failures)-1].ID
+		next = &FailureListParams{
+			LastFailureID: &lastID,
+		}
+	}
+
+	return failures, next, nil
+}
+
+func (s *Service) deleteBlob(ctx context.Context, key string) {
+	if err := s.blobs.Delete(context.WithoutCancel(ctx), key); err != nil {
+		log.Error(ctx, "error deleting import blob", zap.String("blob_key", key), zap.Error(err))
+	}
+}
+
+var errTooLarge = errors.New("import is too large")
+
+// sizeLimitReader counts the bytes read from r, and fails once more than limit have been read.
+type sizeLimitReader struct {
+	r        io.Reader
+	limit    int64
+	n        int64
+	exceeded bool
+}
+
+func (r *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+
+	if r.n > r.limit {
+		r.exceeded = true
+		return n, errTooLarge
+	}
+
+	return n, err
+}
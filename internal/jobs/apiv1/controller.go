@@ -0,0 +1,56 @@
+package apiv1
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/jobs"
+)
+
+type Service interface {
+	GetJob(ctx context.Context, jobID uuid.UUID) (*jobs.Job, error)
+	CancelJob(ctx context.Context, jobID uuid.UUID) (*jobs.Job, error)
+}
+
+// GetJob reports the status and progress of a job, so that clients can poll it.
+func GetJob(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid job id"))
+			return
+		}
+
+		job, err := svc.GetJob(r.Context(), jobID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := JobFromDomain(job)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
+// PostCancelJob requests that a job be cancelled. A running job may take a little while to stop.
+func PostCancelJob(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid job id"))
+			return
+		}
+
+		job, err := svc.CancelJob(r.Context(), jobID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := JobFromDomain(job)
+		apiv1.WriteJSON(r.Context(), w, http.StatusAccepted, &dto)
+	}
+}
@@ -0,0 +1,61 @@
+package apiv1
+
+import (
+	"time"
+
+	"github.com/dabbertorres/notes/internal/jobs"
+)
+
+type Job struct {
+	ID              string   `json:"id"`
+	Kind            string   `json:"kind"`
+	Status          string   `json:"status"`
+	Attempts        int      `json:"attempts"`
+	MaxAttempts     int      `json:"max_attempts"`
+	Progress        Progress `json:"progress"`
+	CancelRequested bool     `json:"cancel_requested"`
+	Error           string   `json:"error,omitempty"`
+	CreatedAt       string   `json:"created_at"`
+	// RunAt is when a pending job is next due to run.
+	RunAt      *string `json:"run_at,omitempty"`
+	StartedAt  *string `json:"started_at,omitempty"`
+	FinishedAt *string `json:"finished_at,omitempty"`
+}
+
+func JobFromDomain(domain *jobs.Job) (j Job) {
+	j.ID = domain.ID.String()
+	j.Kind = domain.Kind
+	j.Status = string(domain.Status)
+	j.Attempts = domain.Attempts
+	j.MaxAttempts = domain.MaxAttempts
+	j.Progress = ProgressFromDomain(domain.Progress)
+	j.CancelRequested = domain.CancelRequested
+	j.Error = domain.Error
+	j.CreatedAt = domain.CreatedAt.Format(time.RFC3339)
+	if domain.Status == jobs.StatusPending {
+		j.RunAt = formatOptionalTime(domain.RunAt)
+	}
+	j.StartedAt = formatOptionalTime(domain.StartedAt)
+	j.FinishedAt = formatOptionalTime(domain.FinishedAt)
+	return j
+}
+
+type Progress struct {
+	Done  int  `json:"done"`
+	Total *int `json:"total,omitempty"`
+}
+
+func ProgressFromDomain(domain jobs.Progress) (p Progress) {
+	p.Done = domain.Done
+	p.Total = domain.Total
+	return p
+}
+
+func formatOptionalTime(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+
+	s := t.Format(time.RFC3339)
+	return &s
+}
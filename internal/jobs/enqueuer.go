@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+
+	"github.com/dabbertorres/notes/internal/database"
+)
+
+// Enqueuer adds jobs to the queue as part of the transaction that needs them done,
+// so that a job is run if and only if that transaction commits.
+type Enqueuer struct {
+	queries *database.Queries
+}
+
+func NewEnqueuer(injector do.Injector) (*Enqueuer, error) {
+	return &Enqueuer{
+		queries: database.New(),
+	}, nil
+}
+
+// Enqueue adds job, created by [Type.New], to the queue in tx.
+func (e *Enqueuer) Enqueue(ctx context.Context, tx pgx.Tx, job *Job) error {
+	return e.queries.CreateJob(ctx, tx, database.CreateJobParams{
+		JobID:       job.ID,
+		Kind:        job.Kind,
+		UserID:      job.UserID,
+		Payload:     job.Payload,
+		MaxAttempts: int32(job.MaxAttempts),
+		RunAt:       pgtype.Timestamptz{Time: job.RunAt, Valid: true},
+		CreatedAt:   pgtype.Timestamptz{Time: job.CreatedAt, Valid: true},
+	})
+}
@@ -0,0 +1,11 @@
+// Package jobs runs long-running work in the background, from a queue stored in Postgres.
+package jobs
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewPGXRepository),
+	do.Lazy(NewEnqueuer),
+	do.Lazy(NewService),
+	do.Lazy(NewWorker),
+)
@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultMaxAttempts is how many times a job is run before it fails, unless its [Type] says otherwise.
+const defaultMaxAttempts = 3
+
+// ErrCancelled is the cause of a running job's context being cancelled because cancelling the job was requested.
+var ErrCancelled = errors.New("job was cancelled")
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Finished reports whether a job with this status will never run again.
+func (s Status) Finished() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCancelled
+}
+
+// Job is a unit of background work. Its Kind decides what it does, and how its Payload is interpreted.
+type Job struct {
+	ID     uuid.UUID
+	Kind   string
+	UserID uuid.NullUUID
+	// Payload is the JSON encoding of the job's [Type]'s payload.
+	Payload     json.RawMessage
+	Status      Status
+	Attempts    int
+	MaxAttempts int
+	// RunAt is when a pending job is next due to run.
+	RunAt           time.Time
+	CancelRequested bool
+	Progress        Progress
+	// Error is why the last attempt failed, if it did.
+	Error      string
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// FinalAttempt reports whether the job will fail, rather than be retried, if its current attempt fails.
+func (j *Job) FinalAttempt() bool {
+	return j.Attempts >= j.MaxAttempts
+}
+
+type Progress struct {
+	Done int
+	// Total is how much there is to do, once it is known.
+	Total *int
+}
+
+// Reporter records the progress of a running job. It is saved periodically, rather than every time it changes.
+type Reporter struct {
+	mu       sync.Mutex
+	progress Progress
+}
+
+// SetTotal records how much there is to do.
+func (r *Reporter) SetTotal(total int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress.Total = &total
+}
+
+// Advance records that n more has been done.
+func (r *Reporter) Advance(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress.Done += n
+}
+
+func (r *Reporter) Progress() Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.progress
+}
+
+// Type is a kind of job, whose payload is a P.
+type Type[P any] struct {
+	Kind string
+	// MaxAttempts is how many times a job is run before it fails. If it is 0, a default is used.
+	MaxAttempts int
+}
+
+// New creates a pending job of this type for userID, which may be [uuid.Nil] for jobs that aren't run for anyone.
+// It is run once it has been enqueued with an [Enqueuer].
+func (t Type[P]) New(userID uuid.UUID, payload P) (*Job, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAttempts := t.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	now := time.Now()
+	return &Job{
+		ID:          id,
+		Kind:        t.Kind,
+		UserID:      uuid.NullUUID{UUID: userID, Valid: userID != uuid.Nil},
+		Payload:     encoded,
+		Status:      StatusPending,
+		MaxAttempts: maxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+	}, nil
+}
+
+// HandlerFunc runs a job of a [Type]. It is run with the job's user in its context, if it has one.
+//
+// If it returns an error, the job is retried later, unless that was its final attempt or the error is [Permanent].
+// Jobs may be run more than once, even if they succeed, so they must be safe to repeat.
+//
+// ctx is cancelled if cancelling the job is requested, with [ErrCancelled] as its cause, or if the server is shutting
+// down, in which case the job is run again later.
+type HandlerFunc[P any] func(ctx context.Context, job *Job, payload P, progress *Reporter) error
+
+// Handler runs jobs of one kind. It is created by [Type.Handler], and registered with a [Worker].
+type Handler struct {
+	kind string
+	run  func(ctx context.Context, job *Job, progress *Reporter) error
+}
+
+// Handler creates a handler that runs jobs of this type with fn.
+func (t Type[P]) Handler(fn HandlerFunc[P]) Handler {
+	return Handler{
+		kind: t.Kind,
+		run: func(ctx context.Context, job *Job, progress *Reporter) error {
+			var payload P
+			if err := json.Unmarshal(job.Payload, &payload); err != nil {
+				return Permanent(err)
+			}
+
+			return fn(ctx, job, payload, progress)
+		},
+	}
+}
+
+// Permanent marks err as a failure that retrying won't fix, so that the job fails immediately.
+func Permanent(err error) error {
+	return &permanentError{err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
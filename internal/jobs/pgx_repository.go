@@ -0,0 +1,249 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+type PGXRepository struct {
+	db       database.Database
+	queries  *database.Queries
+	enqueuer *Enqueuer
+}
+
+func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	enqueuer, err := do.Invoke[*Enqueuer](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGXRepository{
+		db:       db,
+		queries:  database.New(),
+		enqueuer: enqueuer,
+	}, nil
+}
+
+func (r *PGXRepository) CreateJob(ctx context.Context, job *Job) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return r.enqueuer.Enqueue(ctx, tx, job)
+	})
+	if err != nil {
+		log.Error(ctx, "error creating job", zap.Stringer("job_id", job.ID), zap.String("kind", job.Kind), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) GetJob(ctx context.Context, id uuid.UUID) (job *Job, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.GetJob(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		job = jobFromRow(&row)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apiv1.NewError(http.StatusNotFound, "job does not exist")
+		}
+
+		log.Error(ctx, "error fetching job", zap.Stringer("job_id", id), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return job, nil
+}
+
+func (r *PGXRepository) CancelJob(ctx context.Context, id uuid.UUID, now time.Time) (job *Job, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.CancelJob(ctx, tx, database.CancelJobParams{
+			Now:   pgtype.Timestamptz{Time: now, Valid: true},
+			JobID: id,
+		})
+		if err != nil {
+			return err
+		}
+
+		job = jobFromRow(&row)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		log.Error(ctx, "error cancelling job", zap.Stringer("job_id", id), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return job, nil
+}
+
+func (r *PGXRepository) ClaimJob(ctx context.Context, kinds []string, now, leaseUntil time.Time) (job *Job, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.ClaimJob(ctx, tx, database.ClaimJobParams{
+			Kinds:      kinds,
+			Now:        pgtype.Timestamptz{Time: now, Valid: true},
+			LeaseUntil: pgtype.Timestamptz{Time: leaseUntil, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		job = jobFromRow(&row)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		log.Error(ctx, "error claiming job", zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return job, nil
+}
+
+func (r *PGXRepository) Heartbeat(ctx context.Context, id uuid.UUID, attempt int, progress Progress, leaseUntil time.Time) (cancelRequested bool, err error) {
+	params := database.HeartbeatJobParams{
+		LeaseUntil:   pgtype.Timestamptz{Time: leaseUntil, Valid: true},
+		ProgressDone: int32(progress.Done),
+		JobID:        id,
+		Attempt:      int32(attempt),
+	}
+	if progress.Total != nil {
+		params.ProgressTotal = pgtype.Int4{Int32: int32(*progress.Total), Valid: true}
+	}
+
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		cancelRequested, err = r.queries.HeartbeatJob(ctx, tx, params)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrLeaseLost
+		}
+
+		log.Error(ctx, "error recording job heartbeat", zap.Stringer("job_id", id), zap.Error(err))
+		return false, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return cancelRequested, nil
+}
+
+func (r *PGXRepository) FinishJob(ctx context.Context, id uuid.UUID, attempt int, status Status, finishedAt time.Time, jobErr string) error {
+	var updated int64
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		updated, err = r.queries.FinishJob(ctx, tx, database.FinishJobParams{
+			Status:     database.NotesJobStatus(status),
+			FinishedAt: pgtype.Timestamptz{Time: finishedAt, Valid: true},
+			Error:      pgtype.Text{String: jobErr, Valid: jobErr != ""},
+			JobID:      id,
+			Attempt:    int32(attempt),
+		})
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error finishing job", zap.Stringer("job_id", id), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	if updated == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) RetryJob(ctx context.Context, id uuid.UUID, attempt int, runAt time.Time, jobErr string) error {
+	var updated int64
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		updated, err = r.queries.RetryJob(ctx, tx, database.RetryJobParams{
+			RunAt:   pgtype.Timestamptz{Time: runAt, Valid: true},
+			Error:   pgtype.Text{String: jobErr, Valid: jobErr != ""},
+			JobID:   id,
+			Attempt: int32(attempt),
+		})
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error retrying job", zap.Stringer("job_id", id), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	if updated == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) ReleaseJob(ctx context.Context, id uuid.UUID, attempt int, runAt time.Time) error {
+	var updated int64
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		updated, err = r.queries.ReleaseJob(ctx, tx, database.ReleaseJobParams{
+			RunAt:   pgtype.Timestamptz{Time: runAt, Valid: true},
+			JobID:   id,
+			Attempt: int32(attempt),
+		})
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error releasing job", zap.Stringer("job_id", id), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	if updated == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+func jobFromRow(row *database.NotesJob) *Job {
+	job := &Job{
+		ID:              row.JobID,
+		Kind:            row.Kind,
+		UserID:          row.UserID,
+		Payload:         row.Payload,
+		Status:          Status(row.Status),
+		Attempts:        int(row.Attempts),
+		MaxAttempts:     int(row.MaxAttempts),
+		RunAt:           row.RunAt.Time,
+		CancelRequested: row.CancelRequested,
+		Progress:        Progress{Done: int(row.ProgressDone)},
+		Error:           row.Error.String,
+		CreatedAt:       row.CreatedAt.Time,
+		StartedAt:       row.StartedAt.Time,
+		FinishedAt:      row.FinishedAt.Time,
+	}
+
+	if row.ProgressTotal.Valid {
+		total := int(row.ProgressTotal.Int32)
+		job.Progress.Total = &total
+	}
+
+	return job
+}
@@ -0,0 +1,159 @@
+-- name: CreateJob :exec
+INSERT INTO notes.jobs (
+  job_id,
+  kind,
+  user_id,
+  payload,
+  status,
+  max_attempts,
+  run_at,
+  created_at
+) VALUES (
+  sqlc.arg(job_id),
+  sqlc.arg(kind),
+  sqlc.narg(user_id),
+  sqlc.arg(payload),
+  'pending',
+  sqlc.arg(max_attempts),
+  sqlc.arg(run_at),
+  sqlc.arg(created_at)
+)
+;
+
+-- name: GetJob :one
+SELECT
+  job_id,
+  kind,
+  user_id,
+  payload,
+  status,
+  attempts,
+  max_attempts,
+  run_at,
+  locked_until,
+  cancel_requested,
+  progress_done,
+  progress_total,
+  error,
+  created_at,
+  started_at,
+  finished_at
+FROM notes.jobs
+WHERE job_id = sqlc.arg(job_id)
+;
+
+-- name: ClaimJob :one
+WITH next AS (
+  SELECT
+    job_id
+  FROM notes.jobs
+  WHERE kind = ANY(sqlc.arg(kinds)::text[])
+    AND (
+      (status = 'pending' AND run_at <= sqlc.arg(now))
+      -- a running job whose lease has expired was interrupted
+      OR (status = 'running' AND locked_until <= sqlc.arg(now))
+    )
+  ORDER BY run_at ASC
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+UPDATE notes.jobs
+SET status         = 'running',
+    attempts       = jobs.attempts + 1,
+    started_at     = sqlc.arg(now),
+    locked_until   = sqlc.arg(lease_until),
+    progress_done  = 0,
+    progress_total = NULL
+FROM next
+WHERE jobs.job_id = next.job_id
+RETURNING
+  jobs.job_id,
+  jobs.kind,
+  jobs.user_id,
+  jobs.payload,
+  jobs.status,
+  jobs.attempts,
+  jobs.max_attempts,
+  jobs.run_at,
+  jobs.locked_until,
+  jobs.cancel_requested,
+  jobs.progress_done,
+  jobs.progress_total,
+  jobs.error,
+  jobs.created_at,
+  jobs.started_at,
+  jobs.finished_at
+;
+
+-- name: HeartbeatJob :one
+UPDATE notes.jobs
+SET locked_until   = sqlc.arg(lease_until),
+    progress_done  = sqlc.arg(progress_done),
+    progress_total = sqlc.narg(progress_total)
+WHERE job_id = sqlc.arg(job_id)
+  -- only the worker running this attempt owns the job
+  AND status = 'running'
+  AND attempts = sqlc.arg(attempt)
+RETURNING cancel_requested
+;
+
+-- name: FinishJob :execrows
+UPDATE notes.jobs
+SET status       = sqlc.arg(status),
+    finished_at  = sqlc.arg(finished_at),
+    locked_until = NULL,
+    error        = sqlc.narg(error)
+WHERE job_id = sqlc.arg(job_id)
+  AND status = 'running'
+  AND attempts = sqlc.arg(attempt)
+;
+
+-- name: RetryJob :execrows
+UPDATE notes.jobs
+SET status       = 'pending',
+    run_at       = sqlc.arg(run_at),
+    locked_until = NULL,
+    error        = sqlc.arg(error)
+WHERE job_id = sqlc.arg(job_id)
+  AND status = 'running'
+  AND attempts = sqlc.arg(attempt)
+;
+
+-- name: ReleaseJob :execrows
+UPDATE notes.jobs
+SET status       = 'pending',
+    -- the attempt was interrupted, rather than failing
+    attempts     = GREATEST(attempts - 1, 0),
+    run_at       = sqlc.arg(run_at),
+    locked_until = NULL
+WHERE job_id = sqlc.arg(job_id)
+  AND status = 'running'
+  AND attempts = sqlc.arg(attempt)
+;
+
+-- name: CancelJob :one
+UPDATE notes.jobs
+SET cancel_requested = TRUE,
+    -- a running job is cancelled by its worker, once it notices
+    status           = CASE WHEN status = 'pending' THEN 'cancelled' ELSE status END,
+    finished_at      = CASE WHEN status = 'pending' THEN sqlc.arg(now) ELSE finished_at END
+WHERE job_id = sqlc.arg(job_id)
+  AND status IN ('pending', 'running')
+RETURNING
+  job_id,
+  kind,
+  user_id,
+  payload,
+  status,
+  attempts,
+  max_attempts,
+  run_at,
+  locked_until,
+  cancel_requested,
+  progress_done,
+  progress_total,
+  error,
+  created_at,
+  started_at,
+  finished_at
+;
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrLeaseLost is returned when updating a job attempt that is no longer running, because its lease expired and
+// another worker claimed the job, or it was otherwise taken away from the worker running it.
+var ErrLeaseLost = errors.New("job is no longer leased to this attempt")
+
+// Repository records the lifecycle of jobs. The methods that update a running job only do so if attempt is still
+// its current attempt, returning [ErrLeaseLost] if not.
+type Repository interface {
+	// CreateJob adds a job, created by [Type.New], to the queue on its own. Use an [Enqueuer] to add a job as part of
+	// the change that needs it done.
+	CreateJob(ctx context.Context, job *Job) error
+	GetJob(ctx context.Context, id uuid.UUID) (*Job, error)
+	// CancelJob requests that a pending or running job be cancelled. A pending job is cancelled immediately.
+	// If the job has already finished, nil is returned.
+	CancelJob(ctx context.Context, id uuid.UUID, now time.Time) (*Job, error)
+	// ClaimJob starts the next due job of one of kinds, leasing it until leaseUntil. If there is none, nil is returned.
+	ClaimJob(ctx context.Context, kinds []string, now, leaseUntil time.Time) (*Job, error)
+	// Heartbeat records the progress of a running job and extends its lease, reporting whether cancelling it was
+	// requested.
+	Heartbeat(ctx context.Context, id uuid.UUID, attempt int, progress Progress, leaseUntil time.Time) (cancelRequested bool, err error)
+	FinishJob(ctx context.Context, id uuid.UUID, attempt int, status Status, finishedAt time.Time, jobErr string) error
+	// RetryJob returns a job whose attempt failed to the queue, to be run again at runAt.
+	RetryJob(ctx context.Context, id uuid.UUID, attempt int, runAt time.Time, jobErr string) error
+	// ReleaseJob returns a job whose attempt was interrupted to the queue, without counting the attempt.
+	ReleaseJob(ctx context.Context, id uuid.UUID, attempt int, runAt time.Time) error
+}
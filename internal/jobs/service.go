@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/scope"
+)
+
+var errJobFinished = apiv1.NewError(http.StatusConflict, "job has already finished")
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(injector do.Injector) (*Service, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		repo: repo,
+	}, nil
+}
+
+// CreateJob adds job, created by [Type.New], to the queue.
+func (s *Service) CreateJob(ctx context.Context, job *Job) error {
+	return s.repo.CreateJob(ctx, job)
+}
+
+// GetJob returns a job run for the user in ctx. Other jobs don't exist, as far as they are concerned.
+func (s *Service) GetJob(ctx context.Context, jobID uuid.UUID) (*Job, error) {
+	job, err := s.repo.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !job.UserID.Valid || job.UserID.UUID != scope.MustUserID(ctx) {
+		return nil, apiv1.NewError(http.StatusNotFound, "job does not exist")
+	}
+
+	return job, nil
+}
+
+// CancelJob requests that a job be cancelled. A pending job is cancelled immediately, and a running one once its
+// worker notices.
+func (s *Service) CancelJob(ctx context.Context, jobID uuid.UUID) (*Job, error) {
+	job, err := s.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status.Finished() {
+		return nil, errJobFinished
+	}
+
+	cancelled, err := s.repo.CancelJob(ctx, jobID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if cancelled == nil {
+		// it finished in the meantime
+		return nil, errJobFinished
+	}
+
+	return cancelled, nil
+}
@@ -0,0 +1,306 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/config"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/scope"
+)
+
+const (
+	pollInterval = time.Second
+	// leaseDuration is how long a job may go without a heartbeat before it is assumed to be abandoned, and is run
+	// again.
+	leaseDuration     = time.Minute
+	heartbeatInterval = leaseDuration / 4
+	// releaseTimeout is how long a shutdown waits for jobs to stop once their contexts have been cancelled.
+	releaseTimeout = 5 * time.Second
+
+	minRetryDelay = 10 * time.Second
+	maxRetryDelay = time.Hour
+)
+
+// errShuttingDown is the cause of a running job's context being cancelled because the server is shutting down.
+var errShuttingDown = errors.New("shutting down")
+
+// Worker claims jobs from the queue and runs them, several at a time.
+//
+// Once the context given to [Worker.Run] is cancelled, it stops claiming jobs, and waits for the ones it is running to
+// finish. [Worker.Shutdown] bounds that wait, returning any jobs still running to the queue.
+type Worker struct {
+	repo        Repository
+	concurrency int
+	handlers    map[string]Handler
+	kinds       []string
+
+	// jobsCtx is the parent of every job's context. It outlives the context given to Run, so that jobs can finish
+	// while draining.
+	jobsCtx    context.Context
+	cancelJobs context.CancelCauseFunc
+	done       chan struct{}
+}
+
+func NewWorker(injector do.Injector) (*Worker, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := do.Invoke[*config.Config](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	jobsCtx, cancelJobs := context.WithCancelCause(context.Background())
+
+	return &Worker{
+		repo:        repo,
+		concurrency: cfg.Jobs.Concurrency,
+		handlers:    make(map[string]Handler),
+		jobsCtx:     jobsCtx,
+		cancelJobs:  cancelJobs,
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// Handle registers handlers to run the jobs of their kinds. It must be called before [Worker.Run].
+func (w *Worker) Handle(handlers ...Handler) {
+	for _, h := range handlers {
+		if _, ok := w.handlers[h.kind]; ok {
+			panic(fmt.Sprintf("jobs: handler for %q registered more than once", h.kind))
+		}
+
+		w.handlers[h.kind] = h
+		w.kinds = append(w.kinds, h.kind)
+	}
+}
+
+// Run claims and runs jobs until ctx is cancelled, and then waits for the jobs it is running to finish.
+func (w *Worker) Run(ctx context.Context) error {
+	defer close(w.done)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	if len(w.kinds) == 0 || w.concurrency == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	slots := make(chan struct{}, w.concurrency)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case slots <- struct{}{}:
+		}
+
+		now := time.Now()
+		job, err := w.repo.ClaimJob(ctx, w.kinds, now, now.Add(leaseDuration))
+		if err != nil || job == nil {
+			<-slots
+
+			if err != nil && ctx.Err() == nil {
+				log.Error(ctx, "error claiming job", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			w.run(job)
+		}()
+	}
+}
+
+// Shutdown waits for [Worker.Run] to finish draining, or for ctx to be cancelled. If ctx is cancelled first, the
+// jobs still running are cancelled, and returned to the queue to be run again later.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	w.cancelJobs(errShuttingDown)
+
+	select {
+	case <-w.done:
+	case <-time.After(releaseTimeout):
+	}
+
+	return ctx.Err()
+}
+
+// run runs job, and records its outcome.
+func (w *Worker) run(job *Job) {
+	ctx, cancel := context.WithCancelCause(w.jobsCtx)
+	defer cancel(nil)
+
+	if job.UserID.Valid {
+		ctx = scope.WithUserID(ctx, job.UserID.UUID)
+	}
+
+	// outcomes are recorded even if the job was cancelled
+	recordCtx := context.WithoutCancel(ctx)
+
+	if job.CancelRequested {
+		w.finish(recordCtx, job, StatusCancelled, "")
+		return
+	}
+
+	if job.Attempts > job.MaxAttempts {
+		// the job's last attempt was interrupted
+		w.finish(recordCtx, job, StatusFailed, "job was interrupted too many times")
+		return
+	}
+
+	progress := &Reporter{}
+
+	stopHeartbeat := make(chan struct{})
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		w.heartbeat(recordCtx, job, progress, cancel, stopHeartbeat)
+	}()
+
+	err := w.call(ctx, job, progress)
+
+	close(stopHeartbeat)
+	<-heartbeatDone
+
+	cause := context.Cause(ctx)
+	var permanentErr *permanentError
+	switch {
+	case err == nil:
+		// the job may have finished before noticing that it was cancelled
+		w.finish(recordCtx, job, StatusSucceeded, "")
+
+	case errors.Is(cause, ErrLeaseLost):
+		// another worker is running the job now, so its outcome is theirs to record
+		log.Warn(ctx, "job's lease was lost while running it", zap.Stringer("job_id", job.ID), zap.Error(err))
+
+	case errors.Is(cause, ErrCancelled):
+		w.finish(recordCtx, job, StatusCancelled, "")
+
+	case errors.Is(cause, errShuttingDown):
+		err := w.repo.ReleaseJob(recordCtx, job.ID, job.Attempts, time.Now())
+		logRecordError(ctx, job, "error releasing job", err)
+
+	case errors.As(err, &permanentErr), job.FinalAttempt():
+		log.Warn(ctx, "job failed", zap.Stringer("job_id", job.ID), zap.String("kind", job.Kind), zap.Error(err))
+		w.finish(recordCtx, job, StatusFailed, failureMessage(err))
+
+	default:
+		log.Warn(ctx, "job attempt failed", zap.Stringer("job_id", job.ID), zap.String("kind", job.Kind), zap.Error(err))
+
+		runAt := time.Now().Add(retryDelay(job.Attempts))
+		err := w.repo.RetryJob(recordCtx, job.ID, job.Attempts, runAt, failureMessage(err))
+		logRecordError(ctx, job, "error retrying job", err)
+	}
+}
+
+// call runs job's handler, treating a panic as a failed attempt.
+func (w *Worker) call(ctx context.Context, job *Job, progress *Reporter) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return w.handlers[job.Kind].run(ctx, job, progress)
+}
+
+// heartbeat periodically saves the progress of job and extends its lease, until stop is closed.
+// If cancelling the job is requested, or its lease has been lost, cancel is called.
+func (w *Worker) heartbeat(ctx context.Context, job *Job, progress *Reporter, cancel context.CancelCauseFunc, stop <-chan struct{}) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		cancelRequested, err := w.repo.Heartbeat(ctx, job.ID, job.Attempts, progress.Progress(), time.Now().Add(leaseDuration))
+		if errors.Is(err, ErrLeaseLost) {
+			cancel(ErrLeaseLost)
+			return
+		}
+		if err != nil {
+			log.Error(ctx, "error recording job heartbeat", zap.Stringer("job_id", job.ID), zap.Error(err))
+			continue
+		}
+
+		if cancelRequested {
+			cancel(ErrCancelled)
+		}
+	}
+}
+
+func (w *Worker) finish(ctx context.Context, job *Job, status Status, jobErr string) {
+	err := w.repo.FinishJob(ctx, job.ID, job.Attempts, status, time.Now(), jobErr)
+	logRecordError(ctx, job, "error finishing job", err)
+}
+
+// logRecordError logs err, if recording the outcome of job's attempt failed.
+func logRecordError(ctx context.Context, job *Job, msg string, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrLeaseLost):
+		// the lease expired before the attempt ended, and the job has already been claimed again
+		log.Warn(ctx, "job's lease was lost before recording its outcome", zap.Stringer("job_id", job.ID))
+	default:
+		log.Error(ctx, msg, zap.Stringer("job_id", job.ID), zap.Error(err))
+	}
+}
+
+// retryDelay is how long to wait before the attempt after the attempt'th, doubling each time.
+func retryDelay(attempt int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxRetryDelay)
+}
+
+// failureMessage describes why a job failed to the user who can see it, without revealing internal errors.
+func failureMessage(err error) string {
+	var (
+		apiErr         apiv1.Error
+		apiErrWithBody apiv1.ErrorWithBody
+		permanentErr   *permanentError
+	)
+	switch {
+	case errors.As(err, &apiErrWithBody):
+		return apiErrWithBody.Error()
+	case errors.As(err, &apiErr):
+		return http.StatusText(apiErr.Status())
+	case errors.As(err, &permanentErr):
+		return permanentErr.Error()
+	default:
+		return http.StatusText(http.StatusInternalServerError)
+	}
+}
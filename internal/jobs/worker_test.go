@@ -0,0 +1,267 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepository hands out a fixed set of jobs, remembering what became of each.
+type memoryRepository struct {
+	mu       sync.Mutex
+	pending  []*Job
+	attempts map[uuid.UUID]int
+	statuses map[uuid.UUID]Status
+	errors   map[uuid.UUID]string
+	retried  map[uuid.UUID]time.Time
+	released map[uuid.UUID]bool
+}
+
+func newMemoryRepository(jobs ...*Job) *memoryRepository {
+	return &memoryRepository{
+		pending:  jobs,
+		attempts: make(map[uuid.UUID]int),
+		statuses: make(map[uuid.UUID]Status),
+		errors:   make(map[uuid.UUID]string),
+		retried:  make(map[uuid.UUID]time.Time),
+		released: make(map[uuid.UUID]bool),
+	}
+}
+
+func (r *memoryRepository) CreateJob(context.Context, *Job) error { return nil }
+
+func (r *memoryRepository) GetJob(context.Context, uuid.UUID) (*Job, error) { return nil, nil }
+
+func (r *memoryRepository) CancelJob(context.Context, uuid.UUID, time.Time) (*Job, error) {
+	return nil, nil
+}
+
+func (r *memoryRepository) ClaimJob(ctx context.Context, kinds []string, now, leaseUntil time.Time) (*Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.pending) == 0 {
+		return nil, nil
+	}
+
+	job := r.pending[0]
+	r.pending = r.pending[1:]
+	job.Attempts++
+	job.Status = StatusRunning
+	r.attempts[job.ID] = job.Attempts
+	return job, nil
+}
+
+// steal claims id again, as another worker would once its lease expired.
+func (r *memoryRepository) steal(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts[id]++
+}
+
+// owns reports whether attempt is still the current attempt of the job with id. r.mu must be held.
+func (r *memoryRepository) owns(id uuid.UUID, attempt int) bool {
+	return r.attempts[id] == attempt
+}
+
+func (r *memoryRepository) Heartbeat(ctx context.Context, id uuid.UUID, attempt int, progress Progress, leaseUntil time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.owns(id, attempt) {
+		return false, ErrLeaseLost
+	}
+	return false, nil
+}
+
+func (r *memoryRepository) FinishJob(ctx context.Context, id uuid.UUID, attempt int, status Status, finishedAt time.Time, jobErr string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.owns(id, attempt) {
+		return ErrLeaseLost
+	}
+	r.statuses[id] = status
+	r.errors[id] = jobErr
+	return nil
+}
+
+func (r *memoryRepository) RetryJob(ctx context.Context, id uuid.UUID, attempt int, runAt time.Time, jobErr string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.owns(id, attempt) {
+		return ErrLeaseLost
+	}
+	r.statuses[id] = StatusPending
+	r.errors[id] = jobErr
+	r.retried[id] = runAt
+	return nil
+}
+
+func (r *memoryRepository) ReleaseJob(ctx context.Context, id uuid.UUID, attempt int, runAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.owns(id, attempt) {
+		return ErrLeaseLost
+	}
+	r.statuses[id] = StatusPending
+	r.released[id] = true
+	return nil
+}
+
+type testPayload struct {
+	Outcome string `json:"outcome"`
+}
+
+var testJob = Type[testPayload]{Kind: "test", MaxAttempts: 2}
+
+func newTestJob(t *testing.T, outcome string) *Job {
+	t.Helper()
+
+	job, err := testJob.New(uuid.New(), testPayload{Outcome: outcome})
+	require.NoError(t, err)
+	return job
+}
+
+func newTestWorker(repo Repository) *Worker {
+	jobsCtx, cancelJobs := context.WithCancelCause(context.Background())
+	return &Worker{
+		repo:        repo,
+		concurrency: 2,
+		handlers:    make(map[string]Handler),
+		jobsCtx:     jobsCtx,
+		cancelJobs:  cancelJobs,
+		done:        make(chan struct{}),
+	}
+}
+
+func TestWorker_Outcomes(t *testing.T) {
+	succeeds := newTestJob(t, "succeed")
+	retried := newTestJob(t, "fail")
+	exhausted := newTestJob(t, "fail")
+	exhausted.Attempts = 1
+	permanent := newTestJob(t, "permanent")
+	panics := newTestJob(t, "panic")
+	cancelled := newTestJob(t, "succeed")
+	cancelled.CancelRequested = true
+
+	repo := newMemoryRepository(succeeds, retried, exhausted, permanent, panics, cancelled)
+	worker := newTestWorker(repo)
+
+	worker.Handle(testJob.Handler(func(ctx context.Context, job *Job, payload testPayload, progress *Reporter) error {
+		switch payload.Outcome {
+		case "fail":
+			return errors.New("database is down")
+		case "permanent":
+			return Permanent(errors.New("note does not exist"))
+		case "panic":
+			panic("oops")
+		}
+
+		return nil
+	}))
+
+	for {
+		job, err := repo.ClaimJob(context.Background(), worker.kinds, time.Now(), time.Now())
+		require.NoError(t, err)
+		if job == nil {
+			break
+		}
+
+		worker.run(job)
+	}
+
+	assert.Equal(t, StatusSucceeded, repo.statuses[succeeds.ID])
+
+	assert.Equal(t, StatusPending, repo.statuses[retried.ID])
+	assert.Equal(t, "Internal Server Error", repo.errors[retried.ID])
+	assert.False(t, repo.retried[retried.ID].IsZero())
+
+	assert.Equal(t, StatusFailed, repo.statuses[exhausted.ID])
+	assert.Equal(t, StatusFailed, repo.statuses[permanent.ID])
+	assert.Equal(t, "note does not exist", repo.errors[permanent.ID])
+	assert.Equal(t, StatusPending, repo.statuses[panics.ID])
+	assert.Equal(t, StatusCancelled, repo.statuses[cancelled.ID])
+}
+
+func TestWorker_Shutdown(t *testing.T) {
+	drained := newTestJob(t, "drain")
+	interrupted := newTestJob(t, "block")
+
+	repo := newMemoryRepository(drained, interrupted)
+	worker := newTestWorker(repo)
+
+	started := make(chan struct{}, 2)
+	worker.Handle(testJob.Handler(func(ctx context.Context, job *Job, payload testPayload, progress *Reporter) error {
+		started <- struct{}{}
+		if payload.Outcome == "block" {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go worker.Run(ctx)
+
+	<-started
+	<-started
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer shutdownCancel()
+
+	err := worker.Shutdown(shutdownCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the job that finished while draining is done, and the one that didn't is run again later
+	assert.Equal(t, StatusSucceeded, repo.statuses[drained.ID])
+	assert.True(t, repo.released[interrupted.ID])
+}
+
+func TestWorker_LostLease(t *testing.T) {
+	succeeds := newTestJob(t, "succeed")
+	fails := newTestJob(t, "fail")
+
+	repo := newMemoryRepository(succeeds, fails)
+	worker := newTestWorker(repo)
+
+	worker.Handle(testJob.Handler(func(ctx context.Context, job *Job, payload testPayload, progress *Reporter) error {
+		// the attempt outlives its lease, and another worker claims the job
+		repo.steal(job.ID)
+
+		if payload.Outcome == "fail" {
+			return errors.New("database is down")
+		}
+
+		return nil
+	}))
+
+	for {
+		job, err := repo.ClaimJob(context.Background(), worker.kinds, time.Now(), time.Now())
+		require.NoError(t, err)
+		if job == nil {
+			break
+		}
+
+		worker.run(job)
+	}
+
+	// the outcomes are left for the other worker to record
+	assert.NotContains(t, repo.statuses, succeeds.ID)
+	assert.NotContains(t, repo.statuses, fails.ID)
+	assert.NotContains(t, repo.retried, fails.ID)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, minRetryDelay, retryDelay(1))
+	assert.Equal(t, 2*minRetryDelay, retryDelay(2))
+	assert.Equal(t, 4*minRetryDelay, retryDelay(3))
+	assert.Equal(t, maxRetryDelay, retryDelay(100))
+}
@@ -0,0 +1,60 @@
+// Package markdown renders notes' markdown bodies as HTML that is safe to embed in other pages.
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+)
+
+// LinkResolver returns the URL that a wiki-style link (e.g. "[[Runbook]]") to reference should point to.
+// If ok is false, the link is rendered as dangling, without a URL.
+type LinkResolver func(reference string) (href string, ok bool)
+
+var (
+	md = goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			wikiLinks{},
+		),
+	)
+
+	policy = newPolicy()
+)
+
+// Render converts source from CommonMark, with GitHub flavored extensions, to sanitized HTML.
+// Wiki-style links are resolved with resolve, which may be nil to leave all of them dangling.
+func Render(source string, resolve LinkResolver) (string, error) {
+	if resolve == nil {
+		resolve = func(string) (string, bool) { return "", false }
+	}
+
+	pc := parser.NewContext()
+	pc.Set(resolverKey, resolve)
+
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(source), &buf, parser.WithContext(pc)); err != nil {
+		return "", err
+	}
+
+	return policy.SanitizeReader(&buf).String(), nil
+}
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	// task list items
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+
+	// table column alignment
+	p.AllowStyles("text-align").MatchingEnum("left", "center", "right").OnElements("th", "td")
+
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^wikilink( wikilink-dangling)?$`)).OnElements("a", "span")
+
+	return p
+}
@@ -0,0 +1,66 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	resolve := func(ref string) (string, bool) {
+		if ref == "Runbook" {
+			return "/notes/1", true
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "emphasis",
+			source: "some *text*",
+			want:   "<p>some <em>text</em></p>\n",
+		},
+		{
+			name:   "task list",
+			source: "- [ ] todo\n- [x] done",
+			want: "<ul>\n" +
+				"<li><input disabled=\"\" type=\"checkbox\"> todo</li>\n" +
+				"<li><input checked=\"\" disabled=\"\" type=\"checkbox\"> done</li>\n" +
+				"</ul>\n",
+		},
+		{
+			name:   "table",
+			source: "| a | b |\n|---|--:|\n| 1 | 2 |",
+			want: "<table>\n<thead>\n<tr>\n<th>a</th>\n<th style=\"text-align: right\">b</th>\n</tr>\n</thead>\n" +
+				"<tbody>\n<tr>\n<td>1</td>\n<td style=\"text-align: right\">2</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		{
+			name:   "wiki links",
+			source: "see [[Runbook|the runbook]] and [[Missing]]",
+			want: `<p>see <a class="wikilink" href="/notes/1" rel="nofollow">the runbook</a>` +
+				` and <span class="wikilink wikilink-dangling">Missing</span></p>` + "\n",
+		},
+		{
+			name:   "raw html",
+			source: "<script>alert(1)</script>\n\nhi <img src=x onerror=alert(1)>",
+			want:   "\n<p>hi </p>\n",
+		},
+		{
+			name:   "unsafe link",
+			source: "[click](javascript:alert(1))",
+			want:   "<p>click</p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.source, resolve)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
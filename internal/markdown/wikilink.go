@@ -0,0 +1,126 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var resolverKey = parser.NewContextKey()
+
+var KindWikiLink = ast.NewNodeKind("WikiLink")
+
+// WikiLink is a link to another note, written as "[[reference]]" or "[[reference|label]]".
+// The syntax matches that understood by notes.ParseLinks.
+type WikiLink struct {
+	ast.BaseInline
+
+	Reference string
+	Label     string
+	// Href is empty if the link is dangling.
+	Href string
+}
+
+func (n *WikiLink) Kind() ast.NodeKind { return KindWikiLink }
+
+func (n *WikiLink) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{
+		"Reference": n.Reference,
+		"Label":     n.Label,
+		"Href":      n.Href,
+	}, nil)
+}
+
+type wikiLinks struct{}
+
+func (wikiLinks) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(
+		// must run before the standard link parser, which has a priority of 200
+		util.Prioritized(wikiLinkParser{}, 199),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(wikiLinkRenderer{}, 500),
+	))
+}
+
+type wikiLinkParser struct{}
+
+func (wikiLinkParser) Trigger() []byte { return []byte{'['} }
+
+func (wikiLinkParser) Parse(_ ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+
+	end := bytes.Index(line[2:], []byte("]]"))
+	if end < 0 {
+		return nil
+	}
+
+	inner := line[2 : 2+end]
+	if bytes.ContainsAny(inner, "[]") {
+		return nil
+	}
+
+	ref, label, hasLabel := strings.Cut(string(inner), "|")
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil
+	}
+
+	label = strings.TrimSpace(label)
+	if !hasLabel || label == "" {
+		label = ref
+	}
+
+	block.Advance(2 + end + 2)
+
+	link := &WikiLink{
+		Reference: ref,
+		Label:     label,
+	}
+
+	if resolve, ok := pc.Get(resolverKey).(LinkResolver); ok {
+		if href, ok := resolve(ref); ok {
+			link.Href = href
+		}
+	}
+
+	return link
+}
+
+type wikiLinkRenderer struct{}
+
+func (wikiLinkRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindWikiLink, renderWikiLink)
+}
+
+func renderWikiLink(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	link := node.(*WikiLink)
+	label := util.EscapeHTML([]byte(link.Label))
+
+	if link.Href == "" {
+		_, _ = w.WriteString(`<span class="wikilink wikilink-dangling">`)
+		_, _ = w.Write(label)
+		_, _ = w.WriteString(`</span>`)
+	} else {
+		_, _ = w.WriteString(`<a class="wikilink" href="`)
+		_, _ = w.Write(util.EscapeHTML(util.URLEscape([]byte(link.Href), true)))
+		_, _ = w.WriteString(`">`)
+		_, _ = w.Write(label)
+		_, _ = w.WriteString(`</a>`)
+	}
+
+	return ast.WalkSkipChildren, nil
+}
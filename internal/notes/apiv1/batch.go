@@ -0,0 +1,141 @@
+package apiv1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// Batch selects notes either by ID or by a query, but not both.
+type Batch struct {
+	NoteIDs    []uuid.UUID      `json:"note_ids,omitempty"`
+	Query      *SearchQuery     `json:"query,omitempty"`
+	Operations []BatchOperation `json:"operations"`
+	// Transactional, if true, only changes any note if every note can be changed.
+	Transactional bool `json:"transactional,omitempty"`
+}
+
+func (b *Batch) ToDomain() (*notes.Batch, error) {
+	var errs []error
+
+	out := &notes.Batch{
+		NoteIDs:    b.NoteIDs,
+		Operations: apiv1.ValidateSlice(".operations", b.Operations, &errs, BatchOperation.ToDomain),
+		Atomic:     b.Transactional,
+	}
+
+	switch {
+	case len(b.NoteIDs) == 0 && b.Query == nil:
+		errs = append(errs, &apiv1.InvalidFieldError{Field: ".note_ids", Err: apiv1.ErrFieldNotSet.Error()})
+
+	case len(b.NoteIDs) != 0 && b.Query != nil:
+		errs = append(errs, &apiv1.InvalidFieldError{Field: ".query", Err: "cannot be set along with .note_ids"})
+
+	case b.Query != nil:
+		out.Query = apiv1.Validate(".query", *b.Query, &errs, SearchQuery.ToDomain)
+	}
+
+	if len(b.Operations) == 0 {
+		errs = append(errs, &apiv1.InvalidFieldError{Field: ".operations", Err: apiv1.ErrFieldNotSet.Error()})
+	}
+
+	// nothing is left to change after a delete
+	for _, op := range out.Operations {
+		if op.Kind == notes.BatchDelete && len(b.Operations) != 1 {
+			errs = append(errs, &apiv1.InvalidFieldError{Field: ".operations", Err: "delete cannot be combined with other operations"})
+			break
+		}
+	}
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return out, nil
+}
+
+type BatchOperation struct {
+	Op        string `json:"op"`
+	TagID     string `json:"tag_id,omitempty"`
+	FromTagID string `json:"from_tag_id,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+	Access    string `json:"access,omitempty"`
+}
+
+func (o BatchOperation) ToDomain() (notes.BatchOperation, error) {
+	var errs []error
+
+	out := notes.BatchOperation{
+		Kind: apiv1.Validate(".op", o.Op, &errs, notes.ParseBatchOperationKind),
+	}
+
+	switch out.Kind {
+	case notes.BatchAddTag, notes.BatchRemoveTag:
+		out.TagID = apiv1.Validate(".tag_id", o.TagID, &errs, uuid.Parse)
+
+	case notes.BatchMove:
+		out.TagID = apiv1.Validate(".tag_id", o.TagID, &errs, uuid.Parse)
+		out.FromTagID = apiv1.Validate(".from_tag_id", o.FromTagID, &errs, uuid.Parse)
+
+	case notes.BatchGrant:
+		out.UserID = apiv1.Validate(".user_id", o.UserID, &errs, uuid.Parse)
+		out.Access = apiv1.Validate(".access", o.Access, &errs, users.ParseAccessLevel)
+		if out.Access == users.AccessLevelNone {
+			errs = append(errs, &apiv1.InvalidFieldError{Field: ".access", Err: "use revoke to remove access"})
+		}
+
+	case notes.BatchRevoke:
+		out.UserID = apiv1.Validate(".user_id", o.UserID, &errs, uuid.Parse)
+	}
+
+	if len(errs) != 0 {
+		return notes.BatchOperation{}, errors.Join(errs...)
+	}
+
+	return out, nil
+}
+
+type BatchResults struct {
+	Items []BatchResult `json:"items"`
+}
+
+// BatchResult is the outcome for one note, with the status it would have had if changed on its own.
+type BatchResult struct {
+	ID     string `json:"id"`
+	OK     bool   `json:"ok"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func BatchResultFromDomain(domain notes.BatchResult) (r BatchResult) {
+	r.ID = domain.NoteID.String()
+	if domain.Err == nil {
+		r.OK = true
+		r.Status = http.StatusOK
+		return r
+	}
+
+	r.Status = http.StatusInternalServerError
+	r.Error = http.StatusText(r.Status)
+
+	// like a response, only an error with a body may describe itself
+	var (
+		apiErr         apiv1.Error
+		apiErrWithBody apiv1.ErrorWithBody
+	)
+	switch {
+	case errors.As(domain.Err, &apiErrWithBody):
+		r.Status = apiErrWithBody.Status()
+		r.Error = apiErrWithBody.Error()
+	case errors.As(domain.Err, &apiErr):
+		r.Status = apiErr.Status()
+		r.Error = http.StatusText(r.Status)
+	}
+
+	return r
+}
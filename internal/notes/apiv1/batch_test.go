@@ -0,0 +1,90 @@
+package apiv1
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+func TestBatch_ToDomain(t *testing.T) {
+	noteID := uuid.New()
+	tagID := uuid.New()
+	fromTagID := uuid.New()
+	userID := uuid.New()
+
+	input := Batch{
+		NoteIDs: []uuid.UUID{noteID},
+		Operations: []BatchOperation{
+			{Op: "move", TagID: tagID.String(), FromTagID: fromTagID.String()},
+			{Op: "grant", UserID: userID.String(), Access: "editor"},
+		},
+		Transactional: true,
+	}
+
+	out, err := input.ToDomain()
+	assert.NoError(t, err)
+	assert.Equal(t, &notes.Batch{
+		NoteIDs: []uuid.UUID{noteID},
+		Operations: []notes.BatchOperation{
+			{Kind: notes.BatchMove, TagID: tagID, FromTagID: fromTagID},
+			{Kind: notes.BatchGrant, UserID: userID, Access: users.AccessLevelEditor},
+		},
+		Atomic: true,
+	}, out)
+}
+
+func TestBatch_ToDomain_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input Batch
+	}{
+		{
+			name:  "no notes",
+			input: Batch{Operations: []BatchOperation{{Op: "delete"}}},
+		},
+		{
+			name: "notes and query",
+			input: Batch{
+				NoteIDs:    []uuid.UUID{uuid.New()},
+				Query:      &SearchQuery{Text: "incident"},
+				Operations: []BatchOperation{{Op: "delete"}},
+			},
+		},
+		{
+			name:  "no operations",
+			input: Batch{NoteIDs: []uuid.UUID{uuid.New()}},
+		},
+		{
+			name: "delete with other operations",
+			input: Batch{
+				NoteIDs:    []uuid.UUID{uuid.New()},
+				Operations: []BatchOperation{{Op: "delete"}, {Op: "add-tag", TagID: uuid.NewString()}},
+			},
+		},
+		{
+			name: "missing tag",
+			input: Batch{
+				NoteIDs:    []uuid.UUID{uuid.New()},
+				Operations: []BatchOperation{{Op: "add-tag"}},
+			},
+		},
+		{
+			name: "unknown operation",
+			input: Batch{
+				NoteIDs:    []uuid.UUID{uuid.New()},
+				Operations: []BatchOperation{{Op: "rename"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.input.ToDomain()
+			assert.Error(t, err)
+		})
+	}
+}
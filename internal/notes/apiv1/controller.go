@@ -2,6 +2,9 @@ package apiv1
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
 
@@ -18,8 +21,18 @@ type Service interface {
 	CreateNote(ctx context.Context, note *notes.Note) (*notes.Note, error)
 	UpdateNote(ctx context.Context, note *notes.Note) (*notes.Note, error)
 	DeleteNote(ctx context.Context, id uuid.UUID) error
+	ApplyBatch(ctx context.Context, batch *notes.Batch) ([]notes.BatchResult, error)
 	GetNote(ctx context.Context, id uuid.UUID) (*notes.Note, error)
 	SearchNotes(ctx context.Context, params notes.NoteSearchParams, pageSize int) (results []notes.NoteSearchResult, next *notes.NoteSearchParams, err error)
+	SearchNoteFacets(ctx context.Context, params notes.NoteSearchParams) (*notes.NoteFacets, error)
+	RenderNote(ctx context.Context, note *notes.Note, linkHref func(noteID uuid.UUID) string) (string, error)
+	GetRelatedNotes(ctx context.Context, id uuid.UUID, limit int) ([]notes.RelatedNote, error)
+	ListTasks(ctx context.Context, params notes.TaskListParams, pageSize int) (results []notes.TaskWithNote, next *notes.TaskListParams, err error)
+	SetTaskDone(ctx context.Context, noteID uuid.UUID, line int, done bool, revision int64) (*notes.Note, error)
+	GetBacklinks(ctx context.Context, id uuid.UUID, params notes.BacklinkListParams, pageSize int) (results []notes.NoteSearchResult, next *notes.BacklinkListParams, err error)
+	SetNoteState(ctx context.Context, id uuid.UUID, state notes.NoteState) (*notes.NoteState, error)
+	GetNoteRevision(ctx context.Context, id uuid.UUID, revision int64) (*notes.Revision, error)
+	ListNoteRevisions(ctx context.Context, id uuid.UUID, params notes.RevisionListParams, pageSize int) (results []notes.Revision, next *notes.RevisionListParams, err error)
 }
 
 func PostNote(svc Service) http.HandlerFunc {
@@ -95,6 +108,32 @@ func DeleteNote(svc Service) http.HandlerFunc {
 	}
 }
 
+func PostBatch(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bodyDto, ok := apiv1.ReadJSONOrFail[Batch](w, r)
+		if !ok {
+			return
+		}
+
+		batch, err := bodyDto.ToDomain()
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		results, err := svc.ApplyBatch(r.Context(), batch)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := BatchResults{
+			Items: util.MapSlice(results, BatchResultFromDomain),
+		}
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
 func GetNote(svc Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
@@ -103,6 +142,17 @@ func GetNote(svc Service) http.HandlerFunc {
 			return
 		}
 
+		render := r.FormValue("render")
+		if render != "" && render != "html" {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(&apiv1.InvalidFieldError{
+				Field: "render",
+				Err:   `must be "html"`,
+			}))
+			return
+		}
+
+		wantHTML := apiv1.NegotiateContentType(r, "application/json", "text/html") == "text/html"
+
 		note, err := svc.GetNote(r.Context(), noteID)
 		if err != nil {
 			apiv1.WriteError(r.Context(), w, err)
@@ -111,10 +161,253 @@ func GetNote(svc Service) http.HandlerFunc {
 
 		dto := NoteFromDomain(note)
 
+		if render == "html" || wantHTML {
+			html, err := svc.RenderNote(r.Context(), note, noteHref)
+			if err != nil {
+				apiv1.WriteError(r.Context(), w, err)
+				return
+			}
+
+			if wantHTML {
+				apiv1.WriteHTML(r.Context(), w, http.StatusOK, html)
+				return
+			}
+
+			dto.BodyHTML = html
+		}
+
 		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
 	}
 }
 
+// noteHref is where links between notes point to when rendered.
+func noteHref(noteID uuid.UUID) string {
+	return "/api/v1/notes/" + noteID.String()
+}
+
+func GetRelatedNotes(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		var errs []error
+		limit := apiv1.ValidateOptional("limit", r.FormValue("limit"), &errs, apiv1.ParseIntInRange(1, 50))
+		if len(errs) != 0 {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(errors.Join(errs...)))
+			return
+		}
+
+		related, err := svc.GetRelatedNotes(r.Context(), noteID, limit)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := RelatedNotes{
+			Items: util.MapSlice(related, RelatedNoteFromDomain),
+		}
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+func GetBacklinks(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		paging, err := parseListBacklinksParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		params := notes.BacklinkListParams{
+			LastNoteID: paging.Data.LastNoteID,
+		}
+		results, next, err := svc.GetBacklinks(r.Context(), noteID, params, paging.PageSize)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		page := apiv1.Page[Note, *ListBacklinksPageTokenData]{
+			NextPageToken: nil,
+			Items:         util.MapSlice(results, NoteFromSearchResult),
+		}
+
+		if next != nil {
+			page.NextPageToken = &apiv1.PageToken[*ListBacklinksPageTokenData]{
+				Data: &ListBacklinksPageTokenData{
+					LastNoteID: next.LastNoteID,
+				},
+				PageSize: paging.PageSize,
+			}
+		}
+
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, page)
+	}
+}
+
+func GetNoteRevision(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		revision, err := apiv1.ParsePathValue(r, "revision", true, func(s string) (int64, error) {
+			return strconv.ParseInt(s, 10, 64)
+		})
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid revision"))
+			return
+		}
+
+		result, err := svc.GetNoteRevision(r.Context(), noteID, revision)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := NoteRevisionFromDomain(result)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
+func ListNoteRevisions(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		paging, err := parseListRevisionsParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		params := notes.RevisionListParams{
+			LastRevision: paging.Data.LastRevision,
+		}
+		results, next, err := svc.ListNoteRevisions(r.Context(), noteID, params, paging.PageSize)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		page := apiv1.Page[NoteRevision, *ListRevisionsPageTokenData]{
+			NextPageToken: nil,
+			Items:         util.MapSlice(results, NoteRevisionSummaryFromDomain),
+		}
+
+		if next != nil {
+			page.NextPageToken = &apiv1.PageToken[*ListRevisionsPageTokenData]{
+				Data: &ListRevisionsPageTokenData{
+					LastRevision: next.LastRevision,
+				},
+				PageSize: paging.PageSize,
+			}
+		}
+
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, page)
+	}
+}
+
+func ListTasks(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		paging, err := parseListTasksParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		results, next, err := svc.ListTasks(r.Context(), paging.Data.ToDomain(), paging.PageSize)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		page := apiv1.Page[Task, *ListTasksPageTokenData]{
+			NextPageToken: nil,
+			Items:         util.MapSlice(results, TaskFromDomain),
+		}
+
+		if next != nil {
+			page.NextPageToken = &apiv1.PageToken[*ListTasksPageTokenData]{
+				Data:     ListTasksPageTokenDataFromDomain(next),
+				PageSize: paging.PageSize,
+			}
+		}
+
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, page)
+	}
+}
+
+// PutTask checks or unchecks a task, by rewriting its line in the note's body.
+func PutTask(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		line, err := apiv1.ParsePathValue(r, "line", true, apiv1.ParseIntInRange(1, math.MaxInt32))
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid line"))
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[WritableTask](w, r)
+		if !ok {
+			return
+		}
+
+		note, err := svc.SetTaskDone(r.Context(), noteID, line, body.Done, body.Revision)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := NoteFromDomain(note)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+// PutNoteState sets the requesting user's own state of a note.
+func PutNoteState(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[NoteState](w, r)
+		if !ok {
+			return
+		}
+
+		state, err := svc.SetNoteState(r.Context(), noteID, body.ToDomain())
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := NoteStateFromDomain(state)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
 func ListNotes(svc Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		paging, err := parseListNotesParams(r)
@@ -123,12 +416,23 @@ func ListNotes(svc Service) http.HandlerFunc {
 			return
 		}
 
-		params := notes.NoteSearchParams{
-			TextSearch: paging.Data.TextSearch,
-			TagSearch:  paging.Data.TagSearch,
-			LastNoteID: paging.Data.LastNoteID,
-			LastRank:   paging.Data.LastRank,
+		var withFacets bool
+		if raw := r.FormValue("facets"); raw != "" {
+			withFacets, err = strconv.ParseBool(raw)
+			if err != nil {
+				apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+				return
+			}
 		}
+
+		highlight, snippets, err := parseHighlightParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		params := paging.Data.ToDomain()
+		params.Snippets = snippets
 		results, next, err := svc.SearchNotes(r.Context(), params, paging.PageSize)
 		if err != nil {
 			log.Error(r.Context(), "error searching notes", zap.Error(err))
@@ -136,21 +440,23 @@ func ListNotes(svc Service) http.HandlerFunc {
 			return
 		}
 
-		page := apiv1.Page[Note, *ListNotesPageTokenData]{
-			NextPageToken: nil,
-			Items: util.MapSlice(results, func(note notes.NoteSearchResult) Note {
-				return Note{ID: note.ID.String()}
-			}),
+		var page ListNotesPage
+		page.Items = util.MapSlice(results, highlight.NoteFromSearchResult)
+
+		if withFacets {
+			facets, err := svc.SearchNoteFacets(r.Context(), params)
+			if err != nil {
+				apiv1.WriteError(r.Context(), w, err)
+				return
+			}
+
+			dto := NoteFacetsFromDomain(facets)
+			page.Facets = &dto
 		}
 
 		if next != nil {
 			page.NextPageToken = &apiv1.PageToken[*ListNotesPageTokenData]{
-				Data: &ListNotesPageTokenData{
-					LastNoteID: next.LastNoteID,
-					LastRank:   next.LastRank,
-					TextSearch: next.TextSearch,
-					TagSearch:  next.TagSearch,
-				},
+				Data:     ListNotesPageTokenDataFromDomain(next),
 				PageSize: paging.PageSize,
 			}
 		}
@@ -169,17 +475,119 @@ func parseListNotesParams(r *http.Request) (token apiv1.PageToken[*ListNotesPage
 
 		token = pageToken
 	} else {
-		token.Data.TextSearch = r.FormValue("text")
+		var errs []error
+
+		token.Data = &ListNotesPageTokenData{
+			TextSearch:     r.FormValue("text"),
+			AllTags:        apiv1.ValidateSlice("tag", r.Form["tag"], &errs, uuid.Parse),
+			AnyTags:        apiv1.ValidateSlice("any_tag", r.Form["any_tag"], &errs, uuid.Parse),
+			NoneTags:       apiv1.ValidateSlice("exclude_tag", r.Form["exclude_tag"], &errs, uuid.Parse),
+			CreatedBy:      apiv1.ValidateOptional("created_by", r.FormValue("created_by"), &errs, apiv1.ParseNullUUID),
+			UpdatedBy:      apiv1.ValidateOptional("updated_by", r.FormValue("updated_by"), &errs, apiv1.ParseNullUUID),
+			CreatedAfter:   apiv1.ValidateOptional("created_after", r.FormValue("created_after"), &errs, apiv1.ParseRFC3339),
+			CreatedBefore:  apiv1.ValidateOptional("created_before", r.FormValue("created_before"), &errs, apiv1.ParseRFC3339),
+			UpdatedAfter:   apiv1.ValidateOptional("updated_after", r.FormValue("updated_after"), &errs, apiv1.ParseRFC3339),
+			UpdatedBefore:  apiv1.ValidateOptional("updated_before", r.FormValue("updated_before"), &errs, apiv1.ParseRFC3339),
+			SortBy:         apiv1.ValidateOptional("sort", r.FormValue("sort"), &errs, notes.ParseSortKey),
+			SortDescending: apiv1.ValidateOptional("order", r.FormValue("order"), &errs, apiv1.ParseSortOrder),
+			Pinned:         apiv1.ValidateOptional("pinned", r.FormValue("pinned"), &errs, ParseStateFilter),
+			Starred:        apiv1.ValidateOptional("starred", r.FormValue("starred"), &errs, ParseStateFilter),
+			Archived:       apiv1.ValidateOptional("archived", r.FormValue("archived"), &errs, ParseStateFilter),
+		}
+
+		// archived notes are hidden unless asked for
+		if r.FormValue("archived") == "" {
+			token.Data.Archived = sql.NullBool{Bool: false, Valid: true}
+		}
 
-		if tag := r.FormValue("tag"); tag != "" {
-			tagID, err := uuid.Parse(tag)
+		if len(errs) != 0 {
+			return token, apiv1.NewValidationFailureError(errors.Join(errs...))
+		}
+
+		if size := r.FormValue("page_size"); size != "" {
+			pageSize, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return token, apiv1.NewValidationFailureError(err)
+			}
+
+			token.PageSize = int(pageSize)
+		}
+	}
+
+	return token, nil
+}
+
+func parseListTasksParams(r *http.Request) (token apiv1.PageToken[*ListTasksPageTokenData], err error) {
+	rawPageToken := r.FormValue("next_page_token")
+	if rawPageToken != "" {
+		pageToken, err := apiv1.ParsePageToken[*ListTasksPageTokenData](rawPageToken, 100, 100)
+		if err != nil {
+			return token, apiv1.NewValidationFailureError(err)
+		}
+
+		token = pageToken
+	} else {
+		var errs []error
+
+		token.Data = &ListTasksPageTokenData{
+			Done:   apiv1.ValidateOptional("state", r.FormValue("state"), &errs, ParseTaskState),
+			TagID:  apiv1.ValidateOptional("tag", r.FormValue("tag"), &errs, apiv1.ParseNullUUID),
+			NoteID: apiv1.ValidateOptional("note", r.FormValue("note"), &errs, apiv1.ParseNullUUID),
+		}
+
+		if len(errs) != 0 {
+			return token, apiv1.NewValidationFailureError(errors.Join(errs...))
+		}
+
+		if size := r.FormValue("page_size"); size != "" {
+			pageSize, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return token, apiv1.NewValidationFailureError(err)
+			}
+
+			token.PageSize = int(pageSize)
+		}
+	}
+
+	return token, nil
+}
+
+func parseListRevisionsParams(r *http.Request) (token apiv1.PageToken[*ListRevisionsPageTokenData], err error) {
+	rawPageToken := r.FormValue("next_page_token")
+	if rawPageToken != "" {
+		pageToken, err := apiv1.ParsePageToken[*ListRevisionsPageTokenData](rawPageToken, 100, 100)
+		if err != nil {
+			return token, apiv1.NewValidationFailureError(err)
+		}
+
+		token = pageToken
+	} else {
+		token.Data = &ListRevisionsPageTokenData{}
+
+		if size := r.FormValue("page_size"); size != "" {
+			pageSize, err := strconv.ParseInt(size, 10, 64)
 			if err != nil {
 				return token, apiv1.NewValidationFailureError(err)
 			}
 
-			token.Data.TagSearch.UUID = tagID
-			token.Data.TagSearch.Valid = true
+			token.PageSize = int(pageSize)
 		}
+	}
+
+	return token, nil
+}
+
+func parseListBacklinksParams(r *http.Request) (token apiv1.PageToken[*ListBacklinksPageTokenData], err error) {
+	rawPageToken := r.FormValue("next_page_token")
+	if rawPageToken != "" {
+		pageToken, err := apiv1.ParsePageToken[*ListBacklinksPageTokenData](rawPageToken, 100, 100)
+		if err != nil {
+			return token, apiv1.NewValidationFailureError(err)
+		}
+
+		token = pageToken
+	} else {
+		token.Data = &ListBacklinksPageTokenData{}
 
 		if size := r.FormValue("page_size"); size != "" {
 			pageSize, err := strconv.ParseInt(size, 10, 64)
@@ -193,3 +601,25 @@ func parseListNotesParams(r *http.Request) (token apiv1.PageToken[*ListNotesPage
 
 	return token, nil
 }
+
+// parseHighlightParams parses how matches should be presented when searching by text.
+// These are not part of the page token, so they may differ between pages.
+func parseHighlightParams(r *http.Request) (mode HighlightMode, opts notes.SnippetOptions, err error) {
+	var errs []error
+
+	mode = apiv1.ValidateOptional("highlight", r.FormValue("highlight"), &errs, ParseHighlightMode)
+	if mode == "" {
+		mode = HighlightHTML
+	}
+
+	opts = notes.SnippetOptions{
+		MaxFragments: apiv1.ValidateOptional("max_fragments", r.FormValue("max_fragments"), &errs, apiv1.ParseIntInRange(0, notes.MaxSnippetFragments)),
+		MaxWords:     apiv1.ValidateOptional("max_words", r.FormValue("max_words"), &errs, apiv1.ParseIntInRange(3, notes.MaxSnippetWords)),
+	}
+
+	if len(errs) != 0 {
+		return mode, opts, apiv1.NewValidationFailureError(errors.Join(errs...))
+	}
+
+	return mode, opts, nil
+}
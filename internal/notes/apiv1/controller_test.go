@@ -0,0 +1,41 @@
+package apiv1
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dabbertorres/notes/internal/notes"
+)
+
+func TestParseListNotesParams_Sort(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    notes.SortKey
+		wantErr bool
+	}{
+		{name: "missing", query: "", want: notes.SortByDefault},
+		{name: "empty", query: "?sort=", want: notes.SortByDefault},
+		{name: "valid", query: "?sort=updated_at", want: notes.SortByUpdatedAt},
+		{name: "prefix", query: "?sort=up", wantErr: true},
+		{name: "spans names", query: "?sort=at", wantErr: true},
+		{name: "invalid", query: "?sort=relevance", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/notes"+tt.query, nil)
+
+			token, err := parseListNotesParams(r)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, token.Data.SortBy)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package apiv1
+
+import (
+	"errors"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+// HighlightMode selects how the parts of search results that matched a text search are presented.
+type HighlightMode string
+
+const (
+	// HighlightHTML escapes the text, and wraps each match in a <mark> element.
+	HighlightHTML HighlightMode = "html"
+	// HighlightOffsets leaves the text as-is, and lists the ranges of each match, in unicode code points.
+	HighlightOffsets HighlightMode = "offsets"
+	// HighlightNone omits matches entirely.
+	HighlightNone HighlightMode = "none"
+)
+
+func ParseHighlightMode(s string) (HighlightMode, error) {
+	switch mode := HighlightMode(s); mode {
+	case HighlightHTML, HighlightOffsets, HighlightNone:
+		return mode, nil
+	default:
+		return "", errors.New(`must be one of "html", "offsets", or "none"`)
+	}
+}
+
+type NoteMatches struct {
+	Title       Highlight         `json:"title"`
+	Body        []Highlight       `json:"body"`
+	Attachments []AttachmentMatch `json:"attachments,omitempty"`
+}
+
+// AttachmentMatch is an attachment of the note whose text matched.
+type AttachmentMatch struct {
+	ID      string      `json:"id"`
+	Name    string      `json:"name"`
+	Matches []Highlight `json:"matches,omitempty"`
+}
+
+type Highlight struct {
+	Text    string      `json:"text"`
+	Matches []TextRange `json:"matches,omitempty"`
+}
+
+type TextRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// NoteFromSearchResult converts result like the function of the same name, but also includes
+// the parts of the note that matched, formatted according to m.
+func (m HighlightMode) NoteFromSearchResult(result notes.NoteSearchResult) Note {
+	n := NoteFromSearchResult(result)
+
+	if m != HighlightNone && (len(result.TitleMatch.Matches) != 0 || len(result.BodyMatches) != 0 || len(result.AttachmentMatches) != 0) {
+		n.Matches = &NoteMatches{
+			Title: m.formatHighlight(result.TitleMatch),
+			Body:  util.MapSlice(result.BodyMatches, m.formatHighlight),
+			Attachments: util.MapSlice(result.AttachmentMatches, func(match notes.AttachmentMatch) AttachmentMatch {
+				return AttachmentMatch{
+					ID:      match.AttachmentID.String(),
+					Name:    match.Name,
+					Matches: util.MapSlice(match.Matches, m.formatHighlight),
+				}
+			}),
+		}
+	}
+
+	return n
+}
+
+func (m HighlightMode) formatHighlight(h notes.Highlight) Highlight {
+	switch m {
+	case HighlightOffsets:
+		return Highlight{
+			Text: h.Text,
+			Matches: util.MapSlice(h.Matches, func(r notes.TextRange) TextRange {
+				start := utf8.RuneCountInString(h.Text[:r.Start])
+				return TextRange{
+					Start: start,
+					End:   start + utf8.RuneCountInString(h.Text[r.Start:r.End]),
+				}
+			}),
+		}
+
+	default:
+		var b strings.Builder
+		last := 0
+		for _, r := range h.Matches {
+			b.WriteString(html.EscapeString(h.Text[last:r.Start]))
+			b.WriteString("<mark>")
+			b.WriteString(html.EscapeString(h.Text[r.Start:r.End]))
+			b.WriteString("</mark>")
+			last = r.End
+		}
+		b.WriteString(html.EscapeString(h.Text[last:]))
+
+		return Highlight{Text: b.String()}
+	}
+}
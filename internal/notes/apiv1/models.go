@@ -1,6 +1,9 @@
 package apiv1
 
 import (
+	"bytes"
+	"database/sql"
+	"encoding/base64"
 	"errors"
 	"strconv"
 	"time"
@@ -22,8 +25,15 @@ type Note struct {
 	UpdatedBy User         `json:"updated_by"`
 	Title     string       `json:"title,omitempty"`
 	Body      string       `json:"body,omitempty"`
+	BodyHTML  string       `json:"body_html,omitempty"`
 	Tags      []Tag        `json:"tags,omitempty"`
 	Access    []UserAccess `json:"access,omitempty"`
+	Matches   *NoteMatches `json:"matches,omitempty"`
+	Revision  int64        `json:"revision,omitempty"`
+	// Pinned, Starred, and Archived are the requesting user's own state of the note.
+	Pinned   bool `json:"pinned,omitempty"`
+	Starred  bool `json:"starred,omitempty"`
+	Archived bool `json:"archived,omitempty"`
 }
 
 func NoteFromDomain(domain *notes.Note) (n Note) {
@@ -36,6 +46,49 @@ func NoteFromDomain(domain *notes.Note) (n Note) {
 	n.Body = domain.Body
 	n.Tags = util.MapSlice(domain.Tags, TagFromDomain)
 	n.Access = util.MapSlice(domain.Access, UserAccessFromDomain)
+	n.Revision = domain.Revision
+	n.Pinned = domain.State.Pinned
+	n.Starred = domain.State.Starred
+	n.Archived = domain.State.Archived
+	return n
+}
+
+type NoteRevision struct {
+	NoteID   string `json:"note_id"`
+	Revision int64  `json:"revision"`
+	Title    string `json:"title,omitempty"`
+	Body     string `json:"body,omitempty"`
+	SavedAt  string `json:"saved_at"`
+	SavedBy  User   `json:"saved_by"`
+}
+
+func NoteRevisionFromDomain(domain *notes.Revision) (r NoteRevision) {
+	r = NoteRevisionSummaryFromDomain(*domain)
+	r.Body = domain.Body
+	return r
+}
+
+// NoteRevisionSummaryFromDomain converts a revision to a [NoteRevision] without its body, for listing.
+func NoteRevisionSummaryFromDomain(domain notes.Revision) (r NoteRevision) {
+	r.NoteID = domain.NoteID.String()
+	r.Revision = domain.Revision
+	r.Title = domain.Title
+	r.SavedAt = domain.SavedAt.Format(time.RFC3339)
+	r.SavedBy = UserFromDomain(domain.SavedBy)
+	return r
+}
+
+// NoteFromSearchResult converts a search result, which only contains a summary of a note, to a [Note].
+func NoteFromSearchResult(result notes.NoteSearchResult) (n Note) {
+	n.ID = result.ID.String()
+	n.CreatedAt = result.CreatedAt.Format(time.RFC3339)
+	n.CreatedBy = UserFromDomain(result.CreatedBy)
+	n.UpdatedAt = result.UpdatedAt.Format(time.RFC3339)
+	n.UpdatedBy = UserFromDomain(result.UpdatedBy)
+	n.Title = result.Title
+	n.Pinned = result.State.Pinned
+	n.Starred = result.State.Starred
+	n.Archived = result.State.Archived
 	return n
 }
 
@@ -52,6 +105,7 @@ func (n *Note) ToDomain() (*notes.Note, error) {
 		Body:      n.Body,
 		Tags:      util.MapSlice(n.Tags, Tag.ToDomain),
 		Access:    apiv1.ValidateSlice(".access", n.Access, &errs, UserAccess.ToDomain),
+		Revision:  n.Revision,
 	}
 
 	if len(errs) != 0 {
@@ -67,16 +121,19 @@ type WritableNote struct {
 	Body   string       `json:"body,omitempty"`
 	Tags   []Tag        `json:"tags,omitempty"`
 	Access []UserAccess `json:"access,omitempty"`
+	// Revision, if set, must be the note's current revision for an update to succeed.
+	Revision int64 `json:"revision,omitempty"`
 }
 
 func (n *WritableNote) ToDomain() (*notes.Note, error) {
 	var errs []error
 
 	out := &notes.Note{
-		Title:  n.Title,
-		Body:   n.Body,
-		Tags:   util.MapSlice(n.Tags, Tag.ToDomain),
-		Access: apiv1.ValidateSlice(".access", n.Access, &errs, UserAccess.ToDomain),
+		Title:    n.Title,
+		Body:     n.Body,
+		Tags:     util.MapSlice(n.Tags, Tag.ToDomain),
+		Access:   apiv1.ValidateSlice(".access", n.Access, &errs, UserAccess.ToDomain),
+		Revision: n.Revision,
 	}
 
 	if len(errs) != 0 {
@@ -86,6 +143,42 @@ func (n *WritableNote) ToDomain() (*notes.Note, error) {
 	return out, nil
 }
 
+// NoteState is a user's own organization of a note, which is not shared with anyone else who can access it.
+type NoteState struct {
+	Pinned   bool `json:"pinned"`
+	Starred  bool `json:"starred"`
+	Archived bool `json:"archived"`
+}
+
+func NoteStateFromDomain(domain *notes.NoteState) (s NoteState) {
+	s.Pinned = domain.Pinned
+	s.Starred = domain.Starred
+	s.Archived = domain.Archived
+	return s
+}
+
+func (s *NoteState) ToDomain() notes.NoteState {
+	return notes.NoteState{
+		Pinned:   s.Pinned,
+		Starred:  s.Starred,
+		Archived: s.Archived,
+	}
+}
+
+// ParseStateFilter parses whether to list notes that are in a state ("true"), are not ("false"), or "all" of them.
+func ParseStateFilter(s string) (sql.NullBool, error) {
+	if s == "all" {
+		return sql.NullBool{}, nil
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return sql.NullBool{}, errors.New(`must be one of "true", "false", or "all"`)
+	}
+
+	return sql.NullBool{Bool: b, Valid: true}, nil
+}
+
 type UserAccess struct {
 	User   User   `json:"user"`
 	Access string `json:"access"`
@@ -159,11 +252,147 @@ func (u User) ToDomain() (users.User, error) {
 	return out, nil
 }
 
+// ListNotesPage is a page of notes, along with facets summarizing every matching note when they were requested.
+type ListNotesPage struct {
+	apiv1.Page[Note, *ListNotesPageTokenData]
+	Facets *NoteFacets `json:"facets,omitempty"`
+}
+
+type NoteFacets struct {
+	Tags          []TagFacet    `json:"tags"`
+	Authors       []AuthorFacet `json:"authors"`
+	UpdatedMonths []MonthFacet  `json:"updated_months"`
+}
+
+func NoteFacetsFromDomain(domain *notes.NoteFacets) (f NoteFacets) {
+	f.Tags = util.MapSlice(domain.Tags, func(facet notes.TagFacet) TagFacet {
+		return TagFacet{Tag: TagFromDomain(facet.Tag), Count: facet.Count}
+	})
+	f.Authors = util.MapSlice(domain.Authors, func(facet notes.AuthorFacet) AuthorFacet {
+		return AuthorFacet{Author: UserFromDomain(facet.Author), Count: facet.Count}
+	})
+	f.UpdatedMonths = util.MapSlice(domain.UpdatedMonths, func(facet notes.MonthFacet) MonthFacet {
+		return MonthFacet{Month: facet.Month.Format("2006-01"), Count: facet.Count}
+	})
+	return f
+}
+
+type TagFacet struct {
+	Tag   Tag `json:"tag"`
+	Count int `json:"count"`
+}
+
+type AuthorFacet struct {
+	Author User `json:"author"`
+	Count  int  `json:"count"`
+}
+
+type MonthFacet struct {
+	Month string `json:"month"`
+	Count int    `json:"count"`
+}
+
+type RelatedNotes struct {
+	Items []RelatedNote `json:"items"`
+}
+
+type RelatedNote struct {
+	Note   Note          `json:"note"`
+	Score  float32       `json:"score"`
+	Scores RelatedScores `json:"scores"`
+}
+
+// RelatedScores breaks down a [RelatedNote]'s score by each kind of similarity.
+type RelatedScores struct {
+	Tags  float32 `json:"tags"`
+	Terms float32 `json:"terms"`
+	Links float32 `json:"links"`
+}
+
+func RelatedNoteFromDomain(domain notes.RelatedNote) (r RelatedNote) {
+	r.Note = NoteFromSearchResult(domain.Note)
+	r.Score = domain.Score
+	r.Scores.Tags = domain.TagScore
+	r.Scores.Terms = domain.TermScore
+	r.Scores.Links = domain.LinkScore
+	return r
+}
+
 type ListNotesPageTokenData struct {
-	LastNoteID uuid.NullUUID
-	LastRank   float32
-	TextSearch string
-	TagSearch  uuid.NullUUID
+	LastNoteID     uuid.NullUUID
+	LastPinned     bool
+	LastRank       float32
+	LastTime       time.Time
+	LastTitle      string
+	TextSearch     string
+	AllTags        []uuid.UUID
+	AnyTags        []uuid.UUID
+	NoneTags       []uuid.UUID
+	CreatedBy      uuid.NullUUID
+	UpdatedBy      uuid.NullUUID
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+	UpdatedAfter   time.Time
+	UpdatedBefore  time.Time
+	SortBy         notes.SortKey
+	SortDescending bool
+	Pinned         sql.NullBool
+	Starred        sql.NullBool
+	Archived       sql.NullBool
+}
+
+func ListNotesPageTokenDataFromDomain(params *notes.NoteSearchParams) *ListNotesPageTokenData {
+	return &ListNotesPageTokenData{
+		LastNoteID:     params.LastNoteID,
+		LastPinned:     params.LastPinned,
+		LastRank:       params.LastRank,
+		LastTime:       params.LastTime,
+		LastTitle:      params.LastTitle,
+		TextSearch:     params.TextSearch,
+		AllTags:        params.Tags.AllOf,
+		AnyTags:        params.Tags.AnyOf,
+		NoneTags:       params.Tags.NoneOf,
+		CreatedBy:      params.CreatedBy,
+		UpdatedBy:      params.UpdatedBy,
+		CreatedAfter:   params.CreatedAfter,
+		CreatedBefore:  params.CreatedBefore,
+		UpdatedAfter:   params.UpdatedAfter,
+		UpdatedBefore:  params.UpdatedBefore,
+		SortBy:         params.SortBy,
+		SortDescending: params.SortDescending,
+		Pinned:         params.State.Pinned,
+		Starred:        params.State.Starred,
+		Archived:       params.State.Archived,
+	}
+}
+
+func (d *ListNotesPageTokenData) ToDomain() notes.NoteSearchParams {
+	return notes.NoteSearchParams{
+		TextSearch: d.TextSearch,
+		Tags: notes.TagFilter{
+			AllOf:  d.AllTags,
+			AnyOf:  d.AnyTags,
+			NoneOf: d.NoneTags,
+		},
+		State: notes.StateFilter{
+			Pinned:   d.Pinned,
+			Starred:  d.Starred,
+			Archived: d.Archived,
+		},
+		CreatedBy:      d.CreatedBy,
+		UpdatedBy:      d.UpdatedBy,
+		CreatedAfter:   d.CreatedAfter,
+		CreatedBefore:  d.CreatedBefore,
+		UpdatedAfter:   d.UpdatedAfter,
+		UpdatedBefore:  d.UpdatedBefore,
+		SortBy:         d.SortBy,
+		SortDescending: d.SortDescending,
+		LastNoteID:     d.LastNoteID,
+		LastPinned:     d.LastPinned,
+		LastRank:       d.LastRank,
+		LastTime:       d.LastTime,
+		LastTitle:      d.LastTitle,
+	}
 }
 
 func (d *ListNotesPageTokenData) EncodePager() ([][]byte, error) {
@@ -171,40 +400,50 @@ func (d *ListNotesPageTokenData) EncodePager() ([][]byte, error) {
 		return nil, nil
 	}
 
-	var out [4][]byte
+	var out [20][]byte
 
-	if d.LastNoteID.Valid {
-		out[0] = []byte(d.LastNoteID.UUID.String())
-	}
+	out[0] = encodeNullUUID(d.LastNoteID)
 
 	if d.LastRank > 0.0 {
 		out[1] = strconv.AppendFloat(out[1], float64(d.LastRank), 'g', -1, 32)
 	}
 
-	if d.TextSearch != "" {
-		out[2] = []byte(d.TextSearch)
+	out[2] = encodeTime(d.LastTime)
+	out[3] = encodeText(d.LastTitle)
+	out[4] = encodeText(d.TextSearch)
+	out[5] = encodeUUIDs(d.AllTags)
+	out[6] = encodeUUIDs(d.AnyTags)
+	out[7] = encodeUUIDs(d.NoneTags)
+	out[8] = encodeNullUUID(d.CreatedBy)
+	out[9] = encodeNullUUID(d.UpdatedBy)
+	out[10] = encodeTime(d.CreatedAfter)
+	out[11] = encodeTime(d.CreatedBefore)
+	out[12] = encodeTime(d.UpdatedAfter)
+	out[13] = encodeTime(d.UpdatedBefore)
+	out[14] = []byte(d.SortBy.String())
+
+	if d.SortDescending {
+		out[15] = []byte{'1'}
 	}
 
-	if d.TagSearch.Valid {
-		out[3] = []byte(d.TagSearch.UUID.String())
+	if d.LastPinned {
+		out[16] = []byte{'1'}
 	}
 
+	out[17] = encodeNullBool(d.Pinned)
+	out[18] = encodeNullBool(d.Starred)
+	out[19] = encodeNullBool(d.Archived)
+
 	return out[:], nil
 }
 
 func (t *ListNotesPageTokenData) DecodePager(data [][]byte) (err error) {
-	if len(data) != 4 {
+	if len(data) != 20 {
 		return errors.New("invalid page token format (incorrect number of parts)")
 	}
 
-	if len(data[0]) != 0 {
-		lastNoteID, err := uuid.ParseBytes(data[0])
-		if err != nil {
-			return err
-		}
-
-		t.LastNoteID.UUID = lastNoteID
-		t.LastNoteID.Valid = true
+	if t.LastNoteID, err = decodeNullUUID(data[0]); err != nil {
+		return err
 	}
 
 	if len(data[1]) != 0 {
@@ -216,19 +455,334 @@ func (t *ListNotesPageTokenData) DecodePager(data [][]byte) (err error) {
 		t.LastRank = float32(lastRank)
 	}
 
-	if len(data[2]) != 0 {
-		t.TextSearch = string(data[2])
+	if t.LastTime, err = decodeTime(data[2]); err != nil {
+		return err
+	}
+
+	if t.LastTitle, err = decodeText(data[3]); err != nil {
+		return err
+	}
+
+	if t.TextSearch, err = decodeText(data[4]); err != nil {
+		return err
+	}
+
+	if t.AllTags, err = decodeUUIDs(data[5]); err != nil {
+		return err
+	}
+
+	if t.AnyTags, err = decodeUUIDs(data[6]); err != nil {
+		return err
+	}
+
+	if t.NoneTags, err = decodeUUIDs(data[7]); err != nil {
+		return err
+	}
+
+	if t.CreatedBy, err = decodeNullUUID(data[8]); err != nil {
+		return err
+	}
+
+	if t.UpdatedBy, err = decodeNullUUID(data[9]); err != nil {
+		return err
+	}
+
+	if t.CreatedAfter, err = decodeTime(data[10]); err != nil {
+		return err
+	}
+
+	if t.CreatedBefore, err = decodeTime(data[11]); err != nil {
+		return err
+	}
+
+	if t.UpdatedAfter, err = decodeTime(data[12]); err != nil {
+		return err
+	}
+
+	if t.UpdatedBefore, err = decodeTime(data[13]); err != nil {
+		return err
+	}
+
+	if t.SortBy, err = notes.ParseSortKey(string(data[14])); err != nil {
+		return err
+	}
+
+	t.SortDescending = len(data[15]) != 0
+	t.LastPinned = len(data[16]) != 0
+
+	if t.Pinned, err = decodeNullBool(data[17]); err != nil {
+		return err
+	}
+
+	if t.Starred, err = decodeNullBool(data[18]); err != nil {
+		return err
+	}
+
+	if t.Archived, err = decodeNullBool(data[19]); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type Task struct {
+	Note Note   `json:"note"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+func TaskFromDomain(domain notes.TaskWithNote) (t Task) {
+	t.Note = NoteFromSearchResult(domain.Note)
+	t.Line = domain.Line
+	t.Text = domain.Text
+	t.Done = domain.Done
+	return t
+}
+
+type WritableTask struct {
+	Done bool `json:"done"`
+	// Revision, if set, must be the note's current revision for the update to succeed.
+	Revision int64 `json:"revision,omitempty"`
+}
+
+// ParseTaskState parses whether to list tasks that are "open", "done", or "all" of them.
+func ParseTaskState(s string) (sql.NullBool, error) {
+	switch s {
+	case "open":
+		return sql.NullBool{Bool: false, Valid: true}, nil
+	case "done":
+		return sql.NullBool{Bool: true, Valid: true}, nil
+	case "all":
+		return sql.NullBool{}, nil
+	default:
+		return sql.NullBool{}, errors.New(`must be one of "open", "done", or "all"`)
+	}
+}
+
+type ListTasksPageTokenData struct {
+	Done       sql.NullBool
+	TagID      uuid.NullUUID
+	NoteID     uuid.NullUUID
+	LastNoteID uuid.NullUUID
+	LastLine   int
+}
+
+func ListTasksPageTokenDataFromDomain(params *notes.TaskListParams) *ListTasksPageTokenData {
+	return &ListTasksPageTokenData{
+		Done:       params.Done,
+		TagID:      params.TagID,
+		NoteID:     params.NoteID,
+		LastNoteID: params.LastNoteID,
+		LastLine:   params.LastLine,
+	}
+}
+
+func (d *ListTasksPageTokenData) ToDomain() notes.TaskListParams {
+	return notes.TaskListParams{
+		Done:       d.Done,
+		TagID:      d.TagID,
+		NoteID:     d.NoteID,
+		LastNoteID: d.LastNoteID,
+		LastLine:   d.LastLine,
+	}
+}
+
+func (d *ListTasksPageTokenData) EncodePager() ([][]byte, error) {
+	var out [5][]byte
+
+	out[0] = encodeNullBool(d.Done)
+
+	out[1] = encodeNullUUID(d.TagID)
+	out[2] = encodeNullUUID(d.NoteID)
+	out[3] = encodeNullUUID(d.LastNoteID)
+	out[4] = strconv.AppendInt(nil, int64(d.LastLine), 10)
+
+	return out[:], nil
+}
+
+func (d *ListTasksPageTokenData) DecodePager(data [][]byte) (err error) {
+	if len(data) != 5 {
+		return errors.New("invalid page token format (incorrect number of parts)")
+	}
+
+	if d.Done, err = decodeNullBool(data[0]); err != nil {
+		return err
+	}
+
+	if d.TagID, err = decodeNullUUID(data[1]); err != nil {
+		return err
+	}
+
+	if d.NoteID, err = decodeNullUUID(data[2]); err != nil {
+		return err
+	}
+
+	if d.LastNoteID, err = decodeNullUUID(data[3]); err != nil {
+		return err
+	}
+
+	d.LastLine, err = strconv.Atoi(string(data[4]))
+	return err
+}
+
+type ListBacklinksPageTokenData struct {
+	LastNoteID uuid.NullUUID
+}
+
+func (d *ListBacklinksPageTokenData) EncodePager() ([][]byte, error) {
+	var out [1][]byte
+	out[0] = encodeNullUUID(d.LastNoteID)
+	return out[:], nil
+}
+
+func (d *ListBacklinksPageTokenData) DecodePager(data [][]byte) (err error) {
+	if len(data) != 1 {
+		return errors.New("invalid page token format (incorrect number of parts)")
+	}
+
+	d.LastNoteID, err = decodeNullUUID(data[0])
+	return err
+}
+
+type ListRevisionsPageTokenData struct {
+	LastRevision int64
+}
+
+func (d *ListRevisionsPageTokenData) EncodePager() ([][]byte, error) {
+	var out [1][]byte
+	out[0] = strconv.AppendInt(nil, d.LastRevision, 10)
+	return out[:], nil
+}
+
+func (d *ListRevisionsPageTokenData) DecodePager(data [][]byte) (err error) {
+	if len(data) != 1 {
+		return errors.New("invalid page token format (incorrect number of parts)")
+	}
+
+	d.LastRevision, err = strconv.ParseInt(string(data[0]), 10, 64)
+	return err
+}
+
+var textEncoding = base64.RawURLEncoding
+
+// encodeText encodes arbitrary text for use in a page token, so that it cannot contain the token's separator.
+func encodeText(s string) []byte {
+	if s == "" {
+		return nil
+	}
+
+	out := make([]byte, textEncoding.EncodedLen(len(s)))
+	textEncoding.Encode(out, []byte(s))
+	return out
+}
+
+// decodeText is the inverse of [encodeText].
+func decodeText(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	out := make([]byte, textEncoding.DecodedLen(len(data)))
+	n, err := textEncoding.Decode(out, data)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out[:n]), nil
+}
+
+func encodeTime(t time.Time) []byte {
+	if t.IsZero() {
+		return nil
+	}
+
+	return t.AppendFormat(nil, time.RFC3339Nano)
+}
+
+func decodeTime(data []byte) (time.Time, error) {
+	if len(data) == 0 {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339Nano, string(data))
+}
+
+func encodeNullUUID(id uuid.NullUUID) []byte {
+	if !id.Valid {
+		return nil
+	}
+
+	return []byte(id.UUID.String())
+}
+
+func decodeNullUUID(data []byte) (uuid.NullUUID, error) {
+	if len(data) == 0 {
+		return uuid.NullUUID{}, nil
+	}
+
+	id, err := uuid.ParseBytes(data)
+	if err != nil {
+		return uuid.NullUUID{}, err
 	}
 
-	if len(data[3]) != 0 {
-		tagSearch, err := uuid.ParseBytes(data[3])
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
+
+func encodeNullBool(b sql.NullBool) []byte {
+	if !b.Valid {
+		return nil
+	}
+
+	return strconv.AppendBool(nil, b.Bool)
+}
+
+func decodeNullBool(data []byte) (sql.NullBool, error) {
+	if len(data) == 0 {
+		return sql.NullBool{}, nil
+	}
+
+	b, err := strconv.ParseBool(string(data))
+	if err != nil {
+		return sql.NullBool{}, err
+	}
+
+	return sql.NullBool{Bool: b, Valid: true}, nil
+}
+
+// encodeUUIDs encodes ids as a comma separated list, for use in a page token.
+func encodeUUIDs(ids []uuid.UUID) []byte {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	out := make([]byte, 0, len(ids)*37)
+	for i, id := range ids {
+		if i != 0 {
+			out = append(out, ',')
+		}
+		out = append(out, id.String()...)
+	}
+
+	return out
+}
+
+// decodeUUIDs is the inverse of [encodeUUIDs].
+func decodeUUIDs(data []byte) ([]uuid.UUID, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	parts := bytes.Split(data, []byte{','})
+	out := make([]uuid.UUID, len(parts))
+	for i, p := range parts {
+		id, err := uuid.ParseBytes(p)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		t.TagSearch.UUID = tagSearch
-		t.TagSearch.Valid = true
+		out[i] = id
 	}
 
-	return nil
+	return out, nil
 }
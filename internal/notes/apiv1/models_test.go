@@ -0,0 +1,65 @@
+package apiv1
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/notes"
+)
+
+func TestListNotesPageTokenData_MarshalRoundTrip(t *testing.T) {
+	input := apiv1.PageToken[*ListNotesPageTokenData]{
+		Data: &ListNotesPageTokenData{
+			LastNoteID:     uuid.NullUUID{UUID: uuid.New(), Valid: true},
+			LastPinned:     true,
+			LastRank:       0.25,
+			LastTime:       time.Date(2024, 7, 4, 13, 37, 0, 123456000, time.UTC),
+			LastTitle:      "on-call; handoff",
+			TextSearch:     "incident report",
+			AllTags:        []uuid.UUID{uuid.New(), uuid.New()},
+			AnyTags:        nil,
+			NoneTags:       []uuid.UUID{uuid.New()},
+			UpdatedBy:      uuid.NullUUID{UUID: uuid.New(), Valid: true},
+			UpdatedAfter:   time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+			SortBy:         notes.SortByUpdatedAt,
+			SortDescending: true,
+			Starred:        sql.NullBool{Bool: true, Valid: true},
+			Archived:       sql.NullBool{Bool: false, Valid: true},
+		},
+		PageSize: 50,
+	}
+
+	out, err := input.MarshalText()
+	assert.NoError(t, err)
+
+	var output apiv1.PageToken[*ListNotesPageTokenData]
+	err = output.UnmarshalText(out)
+	assert.NoError(t, err)
+
+	assert.Equal(t, input, output)
+}
+
+func TestSearchQuery_State(t *testing.T) {
+	// archived notes are hidden unless asked for, as when listing notes
+	params, err := SearchQuery{}.ToDomain()
+	assert.NoError(t, err)
+	assert.Equal(t, notes.StateFilter{Archived: sql.NullBool{Bool: false, Valid: true}}, params.State)
+
+	params, err = SearchQuery{Pinned: "true", Starred: "false", Archived: "all"}.ToDomain()
+	assert.NoError(t, err)
+	assert.Equal(t, notes.StateFilter{
+		Pinned:  sql.NullBool{Bool: true, Valid: true},
+		Starred: sql.NullBool{Bool: false, Valid: true},
+	}, params.State)
+
+	// and saving the query keeps it that way
+	assert.Equal(t, SearchQuery{Pinned: "true", Starred: "false", Archived: "all", Sort: "default"}, SearchQueryFromDomain(&params))
+
+	_, err = SearchQuery{Archived: "sometimes"}.ToDomain()
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package apiv1
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/notes"
+)
+
+// SearchQuery mirrors the filters accepted when listing notes.
+type SearchQuery struct {
+	Text          string      `json:"text,omitempty"`
+	Tags          []uuid.UUID `json:"tags,omitempty"`
+	AnyTags       []uuid.UUID `json:"any_tags,omitempty"`
+	ExcludeTags   []uuid.UUID `json:"exclude_tags,omitempty"`
+	CreatedBy     string      `json:"created_by,omitempty"`
+	UpdatedBy     string      `json:"updated_by,omitempty"`
+	CreatedAfter  string      `json:"created_after,omitempty"`
+	CreatedBefore string      `json:"created_before,omitempty"`
+	UpdatedAfter  string      `json:"updated_after,omitempty"`
+	UpdatedBefore string      `json:"updated_before,omitempty"`
+	Pinned        string      `json:"pinned,omitempty"`
+	Starred       string      `json:"starred,omitempty"`
+	Archived      string      `json:"archived,omitempty"`
+	Sort          string      `json:"sort,omitempty"`
+	Order         string      `json:"order,omitempty"`
+}
+
+func SearchQueryFromDomain(domain *notes.NoteSearchParams) (q SearchQuery) {
+	q.Text = domain.TextSearch
+	q.Tags = domain.Tags.AllOf
+	q.AnyTags = domain.Tags.AnyOf
+	q.ExcludeTags = domain.Tags.NoneOf
+	if domain.CreatedBy.Valid {
+		q.CreatedBy = domain.CreatedBy.UUID.String()
+	}
+	if domain.UpdatedBy.Valid {
+		q.UpdatedBy = domain.UpdatedBy.UUID.String()
+	}
+	q.CreatedAfter = formatOptionalTime(domain.CreatedAfter)
+	q.CreatedBefore = formatOptionalTime(domain.CreatedBefore)
+	q.UpdatedAfter = formatOptionalTime(domain.UpdatedAfter)
+	q.UpdatedBefore = formatOptionalTime(domain.UpdatedBefore)
+	q.Pinned = formatStateFilter(domain.State.Pinned)
+	q.Starred = formatStateFilter(domain.State.Starred)
+	q.Archived = formatStateFilter(domain.State.Archived)
+	if !domain.State.Archived.Valid {
+		// left empty, it would default to hiding archived notes
+		q.Archived = "all"
+	}
+	q.Sort = domain.SortBy.String()
+	if domain.SortDescending {
+		q.Order = "desc"
+	}
+	return q
+}
+
+func (q SearchQuery) ToDomain() (notes.NoteSearchParams, error) {
+	var errs []error
+
+	out := notes.NoteSearchParams{
+		TextSearch: q.Text,
+		Tags: notes.TagFilter{
+			AllOf:  q.Tags,
+			AnyOf:  q.AnyTags,
+			NoneOf: q.ExcludeTags,
+		},
+		CreatedBy:      apiv1.ValidateOptional(".created_by", q.CreatedBy, &errs, apiv1.ParseNullUUID),
+		UpdatedBy:      apiv1.ValidateOptional(".updated_by", q.UpdatedBy, &errs, apiv1.ParseNullUUID),
+		CreatedAfter:   apiv1.ValidateOptional(".created_after", q.CreatedAfter, &errs, apiv1.ParseRFC3339),
+		CreatedBefore:  apiv1.ValidateOptional(".created_before", q.CreatedBefore, &errs, apiv1.ParseRFC3339),
+		UpdatedAfter:   apiv1.ValidateOptional(".updated_after", q.UpdatedAfter, &errs, apiv1.ParseRFC3339),
+		UpdatedBefore:  apiv1.ValidateOptional(".updated_before", q.UpdatedBefore, &errs, apiv1.ParseRFC3339),
+		SortBy:         apiv1.ValidateOptional(".sort", q.Sort, &errs, notes.ParseSortKey),
+		SortDescending: apiv1.ValidateOptional(".order", q.Order, &errs, apiv1.ParseSortOrder),
+		State: notes.StateFilter{
+			Pinned:   apiv1.ValidateOptional(".pinned", q.Pinned, &errs, ParseStateFilter),
+			Starred:  apiv1.ValidateOptional(".starred", q.Starred, &errs, ParseStateFilter),
+			Archived: apiv1.ValidateOptional(".archived", q.Archived, &errs, ParseStateFilter),
+		},
+	}
+
+	// archived notes are hidden unless asked for, as when listing notes
+	if q.Archived == "" {
+		out.State.Archived = sql.NullBool{Bool: false, Valid: true}
+	}
+
+	if len(errs) != 0 {
+		return notes.NoteSearchParams{}, errors.Join(errs...)
+	}
+
+	return out, nil
+}
+
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func formatStateFilter(b sql.NullBool) string {
+	if !b.Valid {
+		return ""
+	}
+
+	return strconv.FormatBool(b.Bool)
+}
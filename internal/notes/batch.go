@@ -0,0 +1,96 @@
+package notes
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// MaxBatchSize is the most notes that a single batch may change.
+const MaxBatchSize = 1000
+
+// errBatchNotApplied is the result of every other note in an atomic batch when one of them fails.
+var errBatchNotApplied = apiv1.NewError(http.StatusFailedDependency, "not applied because another note in the batch failed")
+
+type BatchOperationKind string
+
+const (
+	BatchAddTag    BatchOperationKind = "add-tag"
+	BatchRemoveTag BatchOperationKind = "remove-tag"
+	BatchGrant     BatchOperationKind = "grant"
+	BatchRevoke    BatchOperationKind = "revoke"
+	BatchDelete    BatchOperationKind = "delete"
+	BatchMove      BatchOperationKind = "move"
+)
+
+// ParseBatchOperationKind parses the name of a batch operation.
+func ParseBatchOperationKind(s string) (BatchOperationKind, error) {
+	switch kind := BatchOperationKind(s); kind {
+	case BatchAddTag,
+		BatchRemoveTag,
+		BatchGrant,
+		BatchRevoke,
+		BatchDelete,
+		BatchMove:
+		return kind, nil
+	}
+
+	return "", fmt.Errorf("unknown operation %q", s)
+}
+
+// BatchOperation is a change to make to every note in a [Batch].
+// Which fields are used depends on its Kind.
+type BatchOperation struct {
+	Kind BatchOperationKind
+	// TagID is the tag to add, remove, or move notes to.
+	TagID uuid.UUID
+	// FromTagID is the tag to move notes from.
+	FromTagID uuid.UUID
+	// UserID is the user to grant access to, or revoke access from.
+	UserID uuid.UUID
+	// Access is the access to grant.
+	Access users.AccessLevel
+}
+
+// requiredAccess is the access a user needs to a note to apply op to it.
+func (op *BatchOperation) requiredAccess() users.AccessLevel {
+	switch op.Kind {
+	case BatchGrant, BatchRevoke, BatchDelete:
+		return users.AccessLevelOwner
+	default:
+		return users.AccessLevelEditor
+	}
+}
+
+// tagIDs lists the tags that op changes on notes.
+func (op *BatchOperation) tagIDs() []uuid.UUID {
+	switch op.Kind {
+	case BatchAddTag, BatchRemoveTag:
+		return []uuid.UUID{op.TagID}
+	case BatchMove:
+		return []uuid.UUID{op.FromTagID, op.TagID}
+	default:
+		return nil
+	}
+}
+
+// Batch applies the same operations, in order, to many notes.
+type Batch struct {
+	// NoteIDs are the notes to change. If empty, the notes matching Query are changed instead.
+	NoteIDs    []uuid.UUID
+	Query      NoteSearchParams
+	Operations []BatchOperation
+	// Atomic, if true, applies the operations to either every note or, if any of them fail, none of them.
+	Atomic bool
+}
+
+// BatchResult is the outcome of applying a [Batch] to one of its notes.
+type BatchResult struct {
+	NoteID uuid.UUID
+	// Err is nil if every operation was applied to the note.
+	Err error
+}
@@ -0,0 +1,108 @@
+package notes
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SnippetOptions controls the excerpts of note bodies returned by text searches.
+type SnippetOptions struct {
+	// MaxFragments is the most excerpts to extract from the body.
+	// If zero, a single excerpt is chosen, which is not required to contain every matching term.
+	MaxFragments int
+	// MaxWords is the longest an excerpt may be. If zero, a default is used.
+	MaxWords int
+}
+
+const (
+	DefaultSnippetWords = 35
+	MaxSnippetWords     = 100
+	MaxSnippetFragments = 10
+)
+
+// maxAttachmentMatches is the most attachments of each note that are reported as matching a search.
+const maxAttachmentMatches = 3
+
+// Highlight is text from a note, along with the parts of it that matched a search.
+type Highlight struct {
+	Text    string
+	Matches []TextRange
+}
+
+// TextRange is a half-open range of byte offsets into a string.
+type TextRange struct {
+	Start int
+	End   int
+}
+
+// Postgres surrounds matches with these, which are from the private use area so they cannot be confused with the
+// note's own text. Notes' text has them stripped before highlighting (see queries.sql).
+const (
+	highlightStart    = "\uE000"
+	highlightStop     = "\uE001"
+	fragmentDelimiter = "\uE002"
+)
+
+// headlineOptions formats opts as options for Postgres' ts_headline.
+func headlineOptions(opts SnippetOptions) string {
+	maxWords := opts.MaxWords
+	if maxWords <= 0 {
+		maxWords = DefaultSnippetWords
+	}
+
+	var b strings.Builder
+	b.WriteString(`StartSel="` + highlightStart + `", StopSel="` + highlightStop + `"`)
+	b.WriteString(`, FragmentDelimiter="` + fragmentDelimiter + `"`)
+	b.WriteString(", MaxWords=" + strconv.Itoa(maxWords))
+	b.WriteString(", MinWords=" + strconv.Itoa(max(1, maxWords/2)))
+	b.WriteString(", MaxFragments=" + strconv.Itoa(opts.MaxFragments))
+	return b.String()
+}
+
+// parseHeadline splits the output of ts_headline into each of its fragments.
+func parseHeadline(headline string) []Highlight {
+	if headline == "" {
+		return nil
+	}
+
+	fragments := strings.Split(headline, fragmentDelimiter)
+	out := make([]Highlight, 0, len(fragments))
+	for _, fragment := range fragments {
+		out = append(out, parseHighlight(fragment))
+	}
+
+	return out
+}
+
+// parseHighlight removes the highlighting markers from text, recording where they were.
+func parseHighlight(text string) (h Highlight) {
+	var b strings.Builder
+	b.Grow(len(text))
+
+	start := -1
+	for len(text) > 0 {
+		i := strings.IndexAny(text, highlightStart+highlightStop)
+		if i < 0 {
+			b.WriteString(text)
+			break
+		}
+
+		b.WriteString(text[:i])
+
+		if strings.HasPrefix(text[i:], highlightStart) {
+			if start < 0 {
+				start = b.Len()
+			}
+			text = text[i+len(highlightStart):]
+		} else {
+			if start >= 0 && start < b.Len() {
+				h.Matches = append(h.Matches, TextRange{Start: start, End: b.Len()})
+			}
+			start = -1
+			text = text[i+len(highlightStop):]
+		}
+	}
+
+	h.Text = b.String()
+	return h
+}
@@ -0,0 +1,48 @@
+package notes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHeadline(t *testing.T) {
+	tests := []struct {
+		name     string
+		headline string
+		want     []Highlight
+	}{
+		{
+			name:     "empty",
+			headline: "",
+			want:     nil,
+		},
+		{
+			name:     "no matches",
+			headline: "nothing to see",
+			want:     []Highlight{{Text: "nothing to see"}},
+		},
+		{
+			name:     "matches",
+			headline: "the \uE000incident\uE001 was \uE000résolu\uE001",
+			want: []Highlight{{
+				Text:    "the incident was résolu",
+				Matches: []TextRange{{Start: 4, End: 12}, {Start: 17, End: 24}},
+			}},
+		},
+		{
+			name:     "fragments",
+			headline: "\uE000one\uE001 fish\uE002two \uE000fish\uE001",
+			want: []Highlight{
+				{Text: "one fish", Matches: []TextRange{{Start: 0, End: 3}}},
+				{Text: "two fish", Matches: []TextRange{{Start: 4, End: 8}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseHeadline(tt.headline))
+		})
+	}
+}
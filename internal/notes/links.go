@@ -0,0 +1,37 @@
+package notes
+
+import (
+	"regexp"
+	"strings"
+)
+
+var linkPattern = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+
+// ParseLinks returns the references from each wiki-style link in body, such as "[[Runbook]]" or "[[<note ID>]]".
+// A link may also have display text, as in "[[Runbook|the runbook]]", which is not part of the reference.
+//
+// References are returned in the order they first appear, and are compared case-insensitively, as titles are.
+func ParseLinks(body string) []string {
+	var (
+		refs []string
+		seen = make(map[string]struct{})
+	)
+
+	for _, match := range linkPattern.FindAllStringSubmatch(body, -1) {
+		ref, _, _ := strings.Cut(match[1], "|")
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+
+		key := strings.ToLower(ref)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		refs = append(refs, ref)
+	}
+
+	return refs
+}
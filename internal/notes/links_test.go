@@ -0,0 +1,47 @@
+package notes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "none",
+			body: "no links [here] or [[there",
+			want: nil,
+		},
+		{
+			name: "titles and ids",
+			body: "see [[Runbook]] and [[0190c3a4-5e6f-7a8b-9c0d-1e2f3a4b5c6d]].",
+			want: []string{"Runbook", "0190c3a4-5e6f-7a8b-9c0d-1e2f3a4b5c6d"},
+		},
+		{
+			name: "display text",
+			body: "the [[ Runbook | runbook ]] says so",
+			want: []string{"Runbook"},
+		},
+		{
+			name: "duplicates",
+			body: "[[Runbook]], [[runbook]], [[Postmortem]], [[Runbook|again]]",
+			want: []string{"Runbook", "Postmortem"},
+		},
+		{
+			name: "empty and multiline",
+			body: "[[]] [[ ]] [[split\nacross lines]]",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseLinks(tt.body))
+		})
+	}
+}
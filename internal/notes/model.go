@@ -1,6 +1,7 @@
 package notes
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,6 +10,9 @@ import (
 	"github.com/dabbertorres/notes/internal/users"
 )
 
+// ErrRevisionConflict is returned when saving a note that has been changed since it was retrieved.
+var ErrRevisionConflict = errors.New("note has been modified")
+
 type Note struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
@@ -19,4 +23,29 @@ type Note struct {
 	Body      string
 	Tags      []tags.Tag
 	Access    []users.Access
+	// Revision is incremented each time the note is saved.
+	// When saving, it must be the note's current revision, or 0 to overwrite any changes since it was retrieved.
+	Revision int64
+	// State is the requesting user's own state of the note. It is not saved with the note.
+	State NoteState
+}
+
+// Revision is a note's title and body as they were saved in one of its revisions.
+type Revision struct {
+	NoteID   uuid.UUID
+	Revision int64
+	Title    string
+	Body     string
+	SavedAt  time.Time
+	SavedBy  users.User
+}
+
+// NoteState is how a user has organized a note for themselves.
+// Each user has their own state for every note they can access.
+type NoteState struct {
+	// Pinned notes are listed before any others.
+	Pinned  bool
+	Starred bool
+	// Archived notes are hidden from searches unless asked for.
+	Archived bool
 }
@@ -2,8 +2,12 @@ package notes
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -11,17 +15,24 @@ import (
 	"github.com/samber/do/v2"
 	"go.uber.org/zap"
 
+	"github.com/dabbertorres/notes/internal/audit"
 	"github.com/dabbertorres/notes/internal/common/apiv1"
 	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/events"
 	"github.com/dabbertorres/notes/internal/log"
 	"github.com/dabbertorres/notes/internal/tags"
 	"github.com/dabbertorres/notes/internal/users"
 	"github.com/dabbertorres/notes/internal/util"
 )
 
+// errNoteNotFound aborts a transaction that found no note to change.
+var errNoteNotFound = errors.New("note does not exist")
+
 type PGXRepository struct {
 	db      database.Database
 	queries *database.Queries
+	events  *events.Recorder
+	audit   *audit.Recorder
 }
 
 func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
@@ -30,13 +41,25 @@ func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
 		return nil, err
 	}
 
+	recorder, err := do.Invoke[*events.Recorder](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	auditor, err := do.Invoke[*audit.Recorder](injector)
+	if err != nil {
+		return nil, err
+	}
+
 	return &PGXRepository{
 		db:      db,
 		queries: database.New(),
+		events:  recorder,
+		audit:   auditor,
 	}, nil
 }
 
-func (r *PGXRepository) SaveNote(ctx context.Context, note *Note) error {
+func (r *PGXRepository) SaveNote(ctx context.Context, note *Note, update TagsUpdate) error {
 	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
 		params := database.SaveNoteParams{
 			NoteID:    note.ID,
@@ -50,8 +73,9 @@ func (r *PGXRepository) SaveNote(ctx context.Context, note *Note) error {
 				UUID:  note.UpdatedBy.ID,
 				Valid: true,
 			},
-			Title: note.Title,
-			Body:  note.Body,
+			Title:            note.Title,
+			Body:             note.Body,
+			ExpectedRevision: note.Revision,
 		}
 
 		if note.CreatedBy.ID != uuid.Nil {
@@ -68,27 +92,52 @@ func (r *PGXRepository) SaveNote(ctx context.Context, note *Note) error {
 			}
 		}
 
-		if err := r.queries.SaveNote(ctx, tx, params); err != nil {
+		revision, err := r.queries.SaveNote(ctx, tx, params)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrRevisionConflict
+			}
+
 			log.Error(ctx, "error saving note", zap.Stringer("note_id", note.ID), zap.Error(err))
 			return err
 		}
 
-		for _, t := range note.Tags {
-			params := database.SetNoteTagsParams{
-				Column1: uuid.NullUUID{UUID: note.ID, Valid: true},
-				Column2: uuid.NullUUID{UUID: t.ID, Valid: true},
-			}
-			if err := r.queries.SetNoteTags(ctx, tx, params); err != nil {
-				log.Error(ctx, "error setting note tags", zap.Stringer("note_id", note.ID), zap.Error(err))
-				return apiv1.StatusError(http.StatusInternalServerError)
-			}
+		note.Revision = revision
+
+		err = r.queries.AddNoteRevision(ctx, tx, database.AddNoteRevisionParams{
+			NoteID:   note.ID,
+			Revision: revision,
+			Title:    note.Title,
+			Body:     note.Body,
+			SavedAt:  params.UpdatedAt,
+			SavedBy:  params.UpdatedBy,
+		})
+		if err != nil {
+			log.Error(ctx, "error saving note revision", zap.Stringer("note_id", note.ID), zap.Error(err))
+			return err
+		}
+
+		if err := r.saveNoteTasks(ctx, tx, note); err != nil {
+			log.Error(ctx, "error saving note tasks", zap.Stringer("note_id", note.ID), zap.Error(err))
+			return err
+		}
+
+		if err := r.saveNoteTags(ctx, tx, note, update); err != nil {
+			log.Error(ctx, "error setting note tags", zap.Stringer("note_id", note.ID), zap.Error(err))
+			return apiv1.StatusError(http.StatusInternalServerError)
+		}
+
+		accessBefore, err := r.noteAccessByUser(ctx, tx, note.ID)
+		if err != nil {
+			log.Error(ctx, "error getting note access", zap.Stringer("note_id", note.ID), zap.Error(err))
+			return apiv1.StatusError(http.StatusInternalServerError)
 		}
 
 		for _, a := range note.Access {
 			params := database.SetNoteAccessParams{
 				Column1: uuid.NullUUID{UUID: a.User.ID, Valid: true},
 				Column2: database.NullNotesAccessLevel{
-					NotesAccessLevel: database.NotesAccessLevel(a.Access),
+					NotesAccessLevel: database.NotesAccessLevel(a.Access.String()),
 					Valid:            a.Access != users.AccessLevelNone,
 				},
 				Column3: uuid.NullUUID{UUID: note.ID, Valid: true},
@@ -100,23 +149,212 @@ func (r *PGXRepository) SaveNote(ctx context.Context, note *Note) error {
 			}
 		}
 
+		// links only resolve to notes that the note's users can read, so its access has to be saved first
+		if err := r.saveNoteLinks(ctx, tx, note); err != nil {
+			log.Error(ctx, "error saving note links", zap.Stringer("note_id", note.ID), zap.Error(err))
+			return err
+		}
+
+		if err := r.recordNoteSaved(ctx, tx, note.ID, accessBefore); err != nil {
+			log.Error(ctx, "error recording note events", zap.Stringer("note_id", note.ID), zap.Error(err))
+			return apiv1.StatusError(http.StatusInternalServerError)
+		}
+
 		return nil
 	})
 }
 
-func (r *PGXRepository) DeleteNote(ctx context.Context, id uuid.UUID) error {
-	var numDeleted int64
-	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
-		numDeleted, err = r.queries.DeleteNote(ctx, tx, id)
+// recordNoteSaved records that the note with id was saved, and whether its access changed from accessBefore.
+func (r *PGXRepository) recordNoteSaved(ctx context.Context, tx pgx.Tx, id uuid.UUID, accessBefore map[uuid.UUID]database.NotesAccessLevel) error {
+	accessAfter, err := r.noteAccessByUser(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = r.events.Record(ctx, tx, &events.Event{
+		Kind:     events.KindNoteSaved,
+		NoteID:   uuid.NullUUID{UUID: id, Valid: true},
+		Audience: events.Audience(accessAfter),
+	})
+	if err != nil {
+		return err
+	}
+
+	audience, changed := events.AccessChange(accessBefore, accessAfter)
+	if !changed {
+		return nil
+	}
+
+	// unlike its event, a new note's first access is recorded, since it is still someone being granted access
+	err = r.audit.Record(ctx, tx, audit.ActionNoteAccessChanged, audit.NoteTarget(id), accessBefore, accessAfter)
+	if err != nil {
+		return err
+	}
+
+	// a new note has no access before it's saved, and gaining its first owner isn't news to anyone
+	if len(accessBefore) == 0 {
+		return nil
+	}
+
+	return r.events.Record(ctx, tx, &events.Event{
+		Kind:     events.KindNoteAccessChanged,
+		NoteID:   uuid.NullUUID{UUID: id, Valid: true},
+		Audience: audience,
+	})
+}
+
+// auditedNote is what the audit log records about a deleted note.
+type auditedNote struct {
+	Title  string                                  `json:"title"`
+	Access map[uuid.UUID]database.NotesAccessLevel `json:"access"`
+}
+
+func (r *PGXRepository) noteAccessByUser(ctx context.Context, tx pgx.Tx, id uuid.UUID) (map[uuid.UUID]database.NotesAccessLevel, error) {
+	rows, err := r.queries.GetNoteAccess(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	access := make(map[uuid.UUID]database.NotesAccessLevel, len(rows))
+	for _, row := range rows {
+		access[row.UserID] = row.Access
+	}
+
+	return access, nil
+}
+
+// saveNoteLinks replaces the links from note with those currently in its body,
+// and resolves any dangling links from other notes that refer to note.
+func (r *PGXRepository) saveNoteLinks(ctx context.Context, tx pgx.Tx, note *Note) error {
+	err := r.queries.ResolveDanglingNoteLinks(ctx, tx, database.ResolveDanglingNoteLinksParams{
+		NoteID: note.ID,
+		Title:  note.Title,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := r.queries.ClearNoteLinks(ctx, tx, note.ID); err != nil {
+		return err
+	}
+
+	refs := ParseLinks(note.Body)
+	if len(refs) == 0 {
+		return nil
+	}
+
+	return r.queries.AddNoteLinks(ctx, tx, database.AddNoteLinksParams{
+		SourceNoteID: note.ID,
+		Refs:         refs,
+	})
+}
+
+// saveNoteTags changes the tags of note to its Tags, as update says to.
+func (r *PGXRepository) saveNoteTags(ctx context.Context, tx pgx.Tx, note *Note, update TagsUpdate) error {
+	switch update {
+	case TagsReplace:
+		return r.queries.SetNoteTags(ctx, tx, database.SetNoteTagsParams{
+			NoteID: note.ID,
+			TagIds: util.MapSlice(note.Tags, func(t tags.Tag) uuid.UUID { return t.ID }),
+		})
+
+	case TagsAdd:
+		for _, t := range note.Tags {
+			params := database.AddNoteTagParams{
+				NoteID: note.ID,
+				TagID:  t.ID,
+			}
+			if err := r.queries.AddNoteTag(ctx, tx, params); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// saveNoteTasks replaces the tasks of note with those currently in its body.
+func (r *PGXRepository) saveNoteTasks(ctx context.Context, tx pgx.Tx, note *Note) error {
+	if err := r.queries.ClearNoteTasks(ctx, tx, note.ID); err != nil {
 		return err
+	}
+
+	tasks := ParseTasks(note.Body)
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	params := database.AddNoteTasksParams{
+		NoteID: note.ID,
+		Lines:  make([]int32, len(tasks)),
+		Texts:  make([]string, len(tasks)),
+		Done:   make([]bool, len(tasks)),
+	}
+
+	for i, task := range tasks {
+		params.Lines[i] = int32(task.Line)
+		params.Texts[i] = task.Text
+		params.Done[i] = task.Done
+	}
+
+	return r.queries.AddNoteTasks(ctx, tx, params)
+}
+
+func (r *PGXRepository) DeleteNote(ctx context.Context, id uuid.UUID) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return r.deleteNote(ctx, tx, id)
 	})
 	if err != nil {
+		if errors.Is(err, errNoteNotFound) {
+			return apiv1.NewError(http.StatusNotFound, "note does not exist")
+		}
+
 		log.Error(ctx, "error deleting note", zap.Stringer("note_id", id), zap.Error(err))
 		return apiv1.NewError(http.StatusInternalServerError, "try again later")
 	}
 
+	return nil
+}
+
+func (r *PGXRepository) deleteNote(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	// who could see the note has to be found before its access is deleted along with it
+	access, err := r.noteAccessByUser(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	row, err := r.queries.GetNote(ctx, tx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errNoteNotFound
+		}
+
+		return err
+	}
+
+	before := auditedNote{Title: row.Title, Access: access}
+	if err := r.audit.Record(ctx, tx, audit.ActionNoteDeleted, audit.NoteTarget(id), &before, nil); err != nil {
+		return err
+	}
+
+	// observers of the event may still need to look at the note, so it is recorded first
+	err = r.events.Record(ctx, tx, &events.Event{
+		Kind:     events.KindNoteDeleted,
+		NoteID:   uuid.NullUUID{UUID: id, Valid: true},
+		Audience: events.Audience(access),
+	})
+	if err != nil {
+		return err
+	}
+
+	numDeleted, err := r.queries.DeleteNote(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
 	if numDeleted != 1 {
-		return apiv1.NewError(http.StatusNotFound, "note does not exist")
+		// roll back the event and audit entry
+		return errNoteNotFound
 	}
 
 	return nil
@@ -147,6 +385,15 @@ func (r *PGXRepository) GetNote(ctx context.Context, noteID, asUserID uuid.UUID)
 			return err
 		}
 
+		state, err := r.queries.GetNoteState(ctx, tx, database.GetNoteStateParams{
+			NoteID: noteID,
+			UserID: asUserID,
+		})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			log.Error(ctx, "error getting note state", zap.Stringer("note_id", noteID), zap.Error(err))
+			return err
+		}
+
 		var mapAccessErrors []error
 
 		note = &Note{
@@ -157,6 +404,12 @@ func (r *PGXRepository) GetNote(ctx context.Context, noteID, asUserID uuid.UUID)
 			UpdatedBy: users.User{ID: row.UpdatedBy.UUID},
 			Title:     row.Title,
 			Body:      row.Body,
+			Revision:  row.Revision,
+			State: NoteState{
+				Pinned:   state.Pinned,
+				Starred:  state.Starred,
+				Archived: state.Archived,
+			},
 			Tags: util.MapSlice(tagRows, func(row database.NotesTag) tags.Tag {
 				return tags.Tag{
 					ID:   row.TagID,
@@ -190,6 +443,25 @@ func (r *PGXRepository) GetNote(ctx context.Context, noteID, asUserID uuid.UUID)
 	return note, nil
 }
 
+func (r *PGXRepository) SaveNoteState(ctx context.Context, noteID, userID uuid.UUID, state NoteState) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return r.queries.SaveNoteState(ctx, tx, database.SaveNoteStateParams{
+			NoteID:    noteID,
+			UserID:    userID,
+			Pinned:    state.Pinned,
+			Starred:   state.Starred,
+			Archived:  state.Archived,
+			UpdatedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		})
+	})
+	if err != nil {
+		log.Error(ctx, "error saving note state", zap.Stringer("note_id", noteID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
 func (r *PGXRepository) GetUsersNoteAccess(ctx context.Context, noteID, userID uuid.UUID) (level users.AccessLevel, err error) {
 	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
 		accessLevel, err := r.queries.GetUserNoteAccess(ctx, tx, database.GetUserNoteAccessParams{
@@ -218,90 +490,238 @@ func (r *PGXRepository) GetUsersNoteAccess(ctx context.Context, noteID, userID u
 	return level, nil
 }
 
-func (r *PGXRepository) SearchNotes(ctx context.Context, searchingUser uuid.UUID, search NoteSearchParams, pageSize int) (notes []NoteSearchResult, err error) {
-	var searchFunc func(pgx.Tx) error
-	switch {
-	case search.TagSearch.Valid && search.TextSearch != "":
-		params := database.SearchNotesWithTextAndTagParams{
-			TextSearch: search.TextSearch,
-			UserID:     searchingUser,
-			TagID:      search.TagSearch.UUID,
-			LastRank:   pgtype.Float4{Float32: search.LastRank, Valid: true},
-			PageSize:   int64(pageSize),
+// GetUsersNotesAccess retrieves userID's access to each of noteIDs. Notes the user cannot access are left out.
+func (r *PGXRepository) GetUsersNotesAccess(ctx context.Context, noteIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID]users.AccessLevel, error) {
+	access := make(map[uuid.UUID]users.AccessLevel, len(noteIDs))
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.GetUserNotesAccess(ctx, tx, database.GetUserNotesAccessParams{
+			NoteIds: noteIDs,
+			UserID:  userID,
+		})
+		if err != nil {
+			return err
 		}
-		searchFunc = r.searchNotesWithTextAndTag(ctx, params, &notes)
 
-	case search.TagSearch.Valid:
-		params := database.SearchNotesWithTagParams{
-			UserID:     searchingUser,
-			TagID:      search.TagSearch.UUID,
-			LastNoteID: search.LastNoteID,
-			PageSize:   int64(pageSize),
-		}
-		searchFunc = r.searchNotesWithTag(ctx, params, &notes)
+		for _, row := range rows {
+			level, err := users.ParseAccessLevel(string(row.Access))
+			if err != nil {
+				return err
+			}
 
-	case search.TextSearch != "":
-		params := database.SearchNotesWithTextParams{
-			TextSearch: search.TextSearch,
-			UserID:     searchingUser,
-			LastRank:   pgtype.Float4{Float32: search.LastRank, Valid: true},
-			PageSize:   int64(pageSize),
+			access[row.NoteID] = level
 		}
-		searchFunc = r.searchNotesWithText(ctx, params, &notes)
 
-	default:
-		params := database.ListNotesParams{
-			UserID:     searchingUser,
-			LastNoteID: search.LastNoteID,
-			PageSize:   int64(pageSize),
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return access, nil
+}
+
+// ApplyBatch applies ops to each of noteIDs, and returns the error, if any, from each note.
+// If atomic, every note is changed in one transaction, otherwise each note is changed in its own.
+func (r *PGXRepository) ApplyBatch(ctx context.Context, noteIDs []uuid.UUID, ops []BatchOperation, updatedBy uuid.UUID, updatedAt time.Time, atomic bool) []error {
+	errs := make([]error, len(noteIDs))
+
+	if atomic {
+		failed := -1
+		err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+			for i, id := range noteIDs {
+				if err := r.applyBatchOperations(ctx, tx, id, ops, updatedBy, updatedAt); err != nil {
+					failed = i
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			for i := range errs {
+				// if no note failed, the transaction itself did
+				if failed == -1 || failed == i {
+					errs[i] = err
+				} else {
+					errs[i] = errBatchNotApplied
+				}
+			}
+		}
+	} else {
+		for i, id := range noteIDs {
+			errs[i] = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+				return r.applyBatchOperations(ctx, tx, id, ops, updatedBy, updatedAt)
+			})
 		}
-		searchFunc = r.listNotes(ctx, params, &notes)
 	}
 
-	if err := pgx.BeginFunc(ctx, r.db, searchFunc); err != nil {
-		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	for i, err := range errs {
+		switch {
+		case err == nil, errors.Is(err, errBatchNotApplied):
+		case errors.Is(err, errNoteNotFound):
+			errs[i] = apiv1.NewError(http.StatusNotFound, "note does not exist")
+		case errors.Is(err, errNoteOwnerless):
+			errs[i] = apiv1.NewError(http.StatusConflict, err.Error())
+		default:
+			log.Error(ctx, "error applying batch to note", zap.Stringer("note_id", noteIDs[i]), zap.Error(err))
+			errs[i] = apiv1.NewError(http.StatusInternalServerError, "try again later")
+		}
 	}
 
-	return notes, nil
+	return errs
 }
 
-func (r *PGXRepository) searchNotesWithTextAndTag(ctx context.Context, params database.SearchNotesWithTextAndTagParams, notes *[]NoteSearchResult) func(pgx.Tx) error {
-	return func(tx pgx.Tx) error {
-		rows, err := r.queries.SearchNotesWithTextAndTag(ctx, tx, params)
+// errNoteOwnerless aborts a transaction that would leave a note without an owner.
+var errNoteOwnerless = errors.New("note must have an owner")
+
+func (r *PGXRepository) applyBatchOperations(ctx context.Context, tx pgx.Tx, id uuid.UUID, ops []BatchOperation, updatedBy uuid.UUID, updatedAt time.Time) error {
+	if len(ops) != 0 && ops[0].Kind == BatchDelete {
+		return r.deleteNote(ctx, tx, id)
+	}
+
+	accessBefore, err := r.noteAccessByUser(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	// the title and body are left as they are, so the note doesn't get a new revision
+	touched, err := r.queries.TouchNote(ctx, tx, database.TouchNoteParams{
+		UpdatedAt: pgtype.Timestamptz{Time: updatedAt, Valid: true},
+		UpdatedBy: uuid.NullUUID{UUID: updatedBy, Valid: true},
+		NoteID:    id,
+	})
+	if err != nil {
+		return err
+	}
+
+	if touched == 0 {
+		return errNoteNotFound
+	}
+
+	accessChanged := false
+	for _, op := range ops {
+		switch op.Kind {
+		case BatchAddTag:
+			err = r.queries.AddNoteTag(ctx, tx, database.AddNoteTagParams{NoteID: id, TagID: op.TagID})
+
+		case BatchRemoveTag:
+			err = r.queries.RemoveNoteTag(ctx, tx, database.RemoveNoteTagParams{NoteID: id, TagID: op.TagID})
+
+		case BatchMove:
+			err = r.queries.RemoveNoteTag(ctx, tx, database.RemoveNoteTagParams{NoteID: id, TagID: op.FromTagID})
+			if err == nil {
+				err = r.queries.AddNoteTag(ctx, tx, database.AddNoteTagParams{NoteID: id, TagID: op.TagID})
+			}
+
+		case BatchGrant:
+			accessChanged = true
+			err = r.queries.GrantNoteAccess(ctx, tx, database.GrantNoteAccessParams{
+				NoteID: id,
+				UserID: op.UserID,
+				Access: database.NotesAccessLevel(op.Access.String()),
+			})
+
+		case BatchRevoke:
+			accessChanged = true
+			err = r.queries.RevokeNoteAccess(ctx, tx, database.RevokeNoteAccessParams{NoteID: id, UserID: op.UserID})
+
+		default:
+			err = fmt.Errorf("unsupported batch operation %q", op.Kind)
+		}
+
 		if err != nil {
 			return err
 		}
+	}
 
-		*notes = util.MapSlice(rows,
-			func(row database.SearchNotesWithTextAndTagRow) NoteSearchResult {
-				return NoteSearchResult{
-					ID:      row.NoteID,
-					Rank:    row.Rank,
-					Title:   row.Title,
-					Matched: row.Match.String,
-				}
-			})
+	if accessChanged {
+		accessAfter, err := r.noteAccessByUser(ctx, tx, id)
+		if err != nil {
+			return err
+		}
 
-		return nil
+		if !hasOwner(accessAfter) {
+			return errNoteOwnerless
+		}
 	}
+
+	return r.recordNoteSaved(ctx, tx, id, accessBefore)
 }
 
-func (r *PGXRepository) searchNotesWithTag(ctx context.Context, params database.SearchNotesWithTagParams, notes *[]NoteSearchResult) func(pgx.Tx) error {
-	return func(tx pgx.Tx) error {
-		rows, err := r.queries.SearchNotesWithTag(ctx, tx, params)
-		if err != nil {
-			return err
+func hasOwner(access map[uuid.UUID]database.NotesAccessLevel) bool {
+	for _, level := range access {
+		if level == database.NotesAccessLevelOwner {
+			return true
 		}
+	}
 
-		*notes = util.MapSlice(rows, func(row database.SearchNotesWithTagRow) NoteSearchResult {
-			return NoteSearchResult{
-				ID:    row.NoteID,
-				Title: row.Title,
-			}
-		})
+	return false
+}
 
-		return nil
+func (r *PGXRepository) SearchNotes(ctx context.Context, searchingUser uuid.UUID, search NoteSearchParams, pageSize int) (notes []NoteSearchResult, err error) {
+	tags := search.Tags.normalize()
+
+	var searchFunc func(pgx.Tx) error
+	if search.TextSearch != "" {
+		params := database.SearchNotesWithTextParams{
+			HeadlineOptions:      headlineOptions(search.Snippets),
+			TextSearch:           search.TextSearch,
+			MaxAttachmentMatches: maxAttachmentMatches,
+			UserID:               searchingUser,
+			CreatedBy:            search.CreatedBy,
+			UpdatedBy:            search.UpdatedBy,
+			CreatedAfter:         optionalTimestamp(search.CreatedAfter),
+			CreatedBefore:        optionalTimestamp(search.CreatedBefore),
+			UpdatedAfter:         optionalTimestamp(search.UpdatedAfter),
+			UpdatedBefore:        optionalTimestamp(search.UpdatedBefore),
+			AllTags:              tags.AllOf,
+			AnyTags:              tags.AnyOf,
+			NoneTags:             tags.NoneOf,
+			Pinned:               optionalBool(search.State.Pinned),
+			Starred:              optionalBool(search.State.Starred),
+			Archived:             optionalBool(search.State.Archived),
+			LastNoteID:           search.LastNoteID,
+			LastPinned:           pgtype.Bool{Bool: search.LastPinned, Valid: search.LastNoteID.Valid},
+			SortBy:               search.SortBy.String(),
+			SortDesc:             search.SortDescending && search.SortBy != SortByDefault, // most relevant is always first
+			LastTime:             optionalTimestamp(search.LastTime),
+			LastTitle:            pgtype.Text{String: search.LastTitle, Valid: search.LastNoteID.Valid},
+			LastRank:             pgtype.Float4{Float32: search.LastRank, Valid: search.LastNoteID.Valid},
+			PageSize:             int64(pageSize),
+		}
+		searchFunc = r.searchNotesWithText(ctx, params, &notes)
+	} else {
+		params := database.ListNotesParams{
+			UserID:        searchingUser,
+			CreatedBy:     search.CreatedBy,
+			UpdatedBy:     search.UpdatedBy,
+			CreatedAfter:  optionalTimestamp(search.CreatedAfter),
+			CreatedBefore: optionalTimestamp(search.CreatedBefore),
+			UpdatedAfter:  optionalTimestamp(search.UpdatedAfter),
+			UpdatedBefore: optionalTimestamp(search.UpdatedBefore),
+			AllTags:       tags.AllOf,
+			AnyTags:       tags.AnyOf,
+			NoneTags:      tags.NoneOf,
+			Pinned:        optionalBool(search.State.Pinned),
+			Starred:       optionalBool(search.State.Starred),
+			Archived:      optionalBool(search.State.Archived),
+			LastNoteID:    search.LastNoteID,
+			LastPinned:    pgtype.Bool{Bool: search.LastPinned, Valid: search.LastNoteID.Valid},
+			SortBy:        search.SortBy.String(),
+			SortDesc:      search.SortDescending,
+			LastTime:      optionalTimestamp(search.LastTime),
+			LastTitle:     pgtype.Text{String: search.LastTitle, Valid: search.LastNoteID.Valid},
+			PageSize:      int64(pageSize),
+		}
+		searchFunc = r.listNotes(ctx, params, &notes)
+	}
+
+	if err := pgx.BeginFunc(ctx, r.db, searchFunc); err != nil {
+		log.Error(ctx, "error searching notes", zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
 	}
+
+	return notes, nil
 }
 
 func (r *PGXRepository) searchNotesWithText(ctx context.Context, params database.SearchNotesWithTextParams, notes *[]NoteSearchResult) func(pgx.Tx) error {
@@ -312,13 +732,46 @@ func (r *PGXRepository) searchNotesWithText(ctx context.Context, params database
 		}
 
 		*notes = util.MapSlice(rows, func(row database.SearchNotesWithTextRow) NoteSearchResult {
-			return NoteSearchResult{}
+			return NoteSearchResult{
+				ID:        row.NoteID,
+				CreatedAt: row.CreatedAt.Time,
+				CreatedBy: users.User{ID: row.CreatedBy.UUID},
+				UpdatedAt: row.UpdatedAt.Time,
+				UpdatedBy: users.User{ID: row.UpdatedBy.UUID},
+				Rank:      row.Rank,
+				Title:     row.Title,
+				State: NoteState{
+					Pinned:   row.Pinned,
+					Starred:  row.Starred,
+					Archived: row.Archived,
+				},
+				TitleMatch:        parseHighlight(row.TitleMatch.String),
+				BodyMatches:       parseHeadline(row.BodyMatch.String),
+				AttachmentMatches: attachmentMatchesFromRow(&row),
+			}
 		})
 
 		return nil
 	}
 }
 
+func attachmentMatchesFromRow(row *database.SearchNotesWithTextRow) []AttachmentMatch {
+	if len(row.AttachmentIds) == 0 {
+		return nil
+	}
+
+	out := make([]AttachmentMatch, len(row.AttachmentIds))
+	for i, id := range row.AttachmentIds {
+		out[i] = AttachmentMatch{
+			AttachmentID: id,
+			Name:         row.AttachmentNames[i],
+			Matches:      parseHeadline(row.AttachmentMatches[i]),
+		}
+	}
+
+	return out
+}
+
 func (r *PGXRepository) listNotes(ctx context.Context, params database.ListNotesParams, notes *[]NoteSearchResult) func(pgx.Tx) error {
 	return func(tx pgx.Tx) error {
 		rows, err := r.queries.ListNotes(ctx, tx, params)
@@ -328,11 +781,288 @@ func (r *PGXRepository) listNotes(ctx context.Context, params database.ListNotes
 
 		*notes = util.MapSlice(rows, func(row database.ListNotesRow) NoteSearchResult {
 			return NoteSearchResult{
-				ID:    row.NoteID,
-				Title: row.Title,
+				ID:        row.NoteID,
+				CreatedAt: row.CreatedAt.Time,
+				CreatedBy: users.User{ID: row.CreatedBy.UUID},
+				UpdatedAt: row.UpdatedAt.Time,
+				UpdatedBy: users.User{ID: row.UpdatedBy.UUID},
+				Title:     row.Title,
+				State: NoteState{
+					Pinned:   row.Pinned,
+					Starred:  row.Starred,
+					Archived: row.Archived,
+				},
 			}
 		})
 
 		return nil
 	}
 }
+
+func (r *PGXRepository) SearchNoteFacets(ctx context.Context, searchingUser uuid.UUID, search NoteSearchParams) (*NoteFacets, error) {
+	tagFilter := search.Tags.normalize()
+
+	params := database.SearchNoteFacetsParams{
+		UserID:        searchingUser,
+		TextSearch:    search.TextSearch,
+		CreatedBy:     search.CreatedBy,
+		UpdatedBy:     search.UpdatedBy,
+		CreatedAfter:  optionalTimestamp(search.CreatedAfter),
+		CreatedBefore: optionalTimestamp(search.CreatedBefore),
+		UpdatedAfter:  optionalTimestamp(search.UpdatedAfter),
+		UpdatedBefore: optionalTimestamp(search.UpdatedBefore),
+		AllTags:       tagFilter.AllOf,
+		AnyTags:       tagFilter.AnyOf,
+		NoneTags:      tagFilter.NoneOf,
+		Pinned:        optionalBool(search.State.Pinned),
+		Starred:       optionalBool(search.State.Starred),
+		Archived:      optionalBool(search.State.Archived),
+	}
+
+	var rows []database.SearchNoteFacetsRow
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		rows, err = r.queries.SearchNoteFacets(ctx, tx, params)
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error searching note facets", zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	facets := &NoteFacets{}
+	for _, row := range rows {
+		switch row.Facet {
+		case "tag":
+			id, err := uuid.Parse(row.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			facets.Tags = append(facets.Tags, TagFacet{
+				Tag:   tags.Tag{ID: id, Name: row.Label},
+				Count: int(row.Count),
+			})
+
+		case "author":
+			id, err := uuid.Parse(row.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			facets.Authors = append(facets.Authors, AuthorFacet{
+				Author: users.User{ID: id, Name: row.Label},
+				Count:  int(row.Count),
+			})
+
+		case "updated_month":
+			month, err := time.Parse("2006-01", row.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			facets.UpdatedMonths = append(facets.UpdatedMonths, MonthFacet{
+				Month: month,
+				Count: int(row.Count),
+			})
+		}
+	}
+
+	return facets, nil
+}
+
+// GetNoteLinks returns the notes that noteID links to, which asUserID can access,
+// keyed by the lowercase reference used in the link.
+func (r *PGXRepository) GetNoteLinks(ctx context.Context, noteID, asUserID uuid.UUID) (map[string]uuid.UUID, error) {
+	var rows []database.GetNoteLinksRow
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		rows, err = r.queries.GetNoteLinks(ctx, tx, database.GetNoteLinksParams{
+			UserID:       asUserID,
+			SourceNoteID: noteID,
+		})
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error getting note links", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	links := make(map[string]uuid.UUID, len(rows))
+	for _, row := range rows {
+		links[strings.ToLower(row.Reference)] = row.TargetNoteID.UUID
+	}
+
+	return links, nil
+}
+
+func (r *PGXRepository) GetBacklinks(ctx context.Context, noteID, asUserID uuid.UUID, params BacklinkListParams, pageSize int) (notes []NoteSearchResult, err error) {
+	var rows []database.GetBacklinksRow
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		rows, err = r.queries.GetBacklinks(ctx, tx, database.GetBacklinksParams{
+			UserID:       asUserID,
+			TargetNoteID: uuid.NullUUID{UUID: noteID, Valid: true},
+			LastNoteID:   params.LastNoteID,
+			PageSize:     int64(pageSize),
+		})
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error getting backlinks", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return util.MapSlice(rows, func(row database.GetBacklinksRow) NoteSearchResult {
+		return NoteSearchResult{
+			ID:        row.NoteID,
+			CreatedAt: row.CreatedAt.Time,
+			CreatedBy: users.User{ID: row.CreatedBy.UUID},
+			UpdatedAt: row.UpdatedAt.Time,
+			UpdatedBy: users.User{ID: row.UpdatedBy.UUID},
+			Title:     row.Title,
+		}
+	}), nil
+}
+
+func (r *PGXRepository) ListTasks(ctx context.Context, asUserID uuid.UUID, params TaskListParams, pageSize int) (tasks []TaskWithNote, err error) {
+	var rows []database.ListTasksRow
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		rows, err = r.queries.ListTasks(ctx, tx, database.ListTasksParams{
+			UserID:     asUserID,
+			Done:       pgtype.Bool{Bool: params.Done.Bool, Valid: params.Done.Valid},
+			NoteID:     params.NoteID,
+			TagID:      params.TagID,
+			LastNoteID: params.LastNoteID,
+			LastLine:   pgtype.Int4{Int32: int32(params.LastLine), Valid: params.LastNoteID.Valid},
+			PageSize:   int64(pageSize),
+		})
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error listing tasks", zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return util.MapSlice(rows, func(row database.ListTasksRow) TaskWithNote {
+		return TaskWithNote{
+			Task: Task{
+				NoteID: row.NoteID,
+				Line:   int(row.Line),
+				Text:   row.Text,
+				Done:   row.Done,
+			},
+			Note: NoteSearchResult{
+				ID:        row.NoteID,
+				CreatedAt: row.CreatedAt.Time,
+				CreatedBy: users.User{ID: row.CreatedBy.UUID},
+				UpdatedAt: row.UpdatedAt.Time,
+				UpdatedBy: users.User{ID: row.UpdatedBy.UUID},
+				Title:     row.Title,
+			},
+		}
+	}), nil
+}
+
+// How much each kind of similarity contributes to a related note's overall score.
+const (
+	relatedTagWeight  = 0.35
+	relatedTermWeight = 0.35
+	relatedLinkWeight = 0.3
+)
+
+func (r *PGXRepository) GetRelatedNotes(ctx context.Context, noteID, asUserID uuid.UUID, limit int) (related []RelatedNote, err error) {
+	params := database.GetRelatedNotesParams{
+		NoteID:     noteID,
+		UserID:     asUserID,
+		TagWeight:  relatedTagWeight,
+		TermWeight: relatedTermWeight,
+		LinkWeight: relatedLinkWeight,
+		MaxResults: int64(limit),
+	}
+
+	var rows []database.GetRelatedNotesRow
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		rows, err = r.queries.GetRelatedNotes(ctx, tx, params)
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error getting related notes", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return util.MapSlice(rows, func(row database.GetRelatedNotesRow) RelatedNote {
+		return RelatedNote{
+			Note: NoteSearchResult{
+				ID:        row.NoteID,
+				CreatedAt: row.CreatedAt.Time,
+				CreatedBy: users.User{ID: row.CreatedBy.UUID},
+				UpdatedAt: row.UpdatedAt.Time,
+				UpdatedBy: users.User{ID: row.UpdatedBy.UUID},
+				Title:     row.Title,
+			},
+			Score:     row.Score,
+			TagScore:  row.TagScore,
+			TermScore: row.TermScore,
+			LinkScore: row.LinkScore,
+		}
+	}), nil
+}
+
+// optionalTimestamp converts t to a timestamp that is NULL if t is the zero value.
+func optionalTimestamp(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: !t.IsZero()}
+}
+
+// optionalBool converts b to a bool that is NULL if b is not valid.
+func optionalBool(b sql.NullBool) pgtype.Bool {
+	return pgtype.Bool{Bool: b.Bool, Valid: b.Valid}
+}
+
+func (r *PGXRepository) GetNoteRevision(ctx context.Context, noteID uuid.UUID, revision int64) (*Revision, error) {
+	var row database.NotesNoteRevision
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		row, err = r.queries.GetNoteRevision(ctx, tx, database.GetNoteRevisionParams{
+			NoteID:   noteID,
+			Revision: revision,
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apiv1.NewError(http.StatusNotFound, "revision does not exist")
+		}
+
+		log.Error(ctx, "error getting note revision", zap.Stringer("note_id", noteID), zap.Int64("revision", revision), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	out := revisionFromDB(row)
+	return &out, nil
+}
+
+func (r *PGXRepository) ListNoteRevisions(ctx context.Context, noteID uuid.UUID, params RevisionListParams, pageSize int) (revisions []Revision, err error) {
+	var rows []database.NotesNoteRevision
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		rows, err = r.queries.ListNoteRevisions(ctx, tx, database.ListNoteRevisionsParams{
+			NoteID:       noteID,
+			LastRevision: pgtype.Int8{Int64: params.LastRevision, Valid: params.LastRevision != 0},
+			PageSize:     int64(pageSize),
+		})
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error listing note revisions", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return util.MapSlice(rows, revisionFromDB), nil
+}
+
+func revisionFromDB(row database.NotesNoteRevision) Revision {
+	return Revision{
+		NoteID:   row.NoteID,
+		Revision: row.Revision,
+		Title:    row.Title,
+		Body:     row.Body,
+		SavedAt:  row.SavedAt.Time,
+		SavedBy:  users.User{ID: row.SavedBy.UUID},
+	}
+}
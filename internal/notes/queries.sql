@@ -1,4 +1,4 @@
--- name: SaveNote :exec
+-- name: SaveNote :one
 INSERT INTO notes.notes (
   note_id,
   created_at,
@@ -6,7 +6,8 @@ INSERT INTO notes.notes (
   updated_at,
   updated_by,
   title,
-  body
+  body,
+  revision
 ) VALUES (
   sqlc.arg(note_id),
   sqlc.arg(created_at),
@@ -14,12 +15,18 @@ INSERT INTO notes.notes (
   sqlc.arg(updated_at),
   sqlc.arg(updated_by),
   sqlc.arg(title),
-  sqlc.arg(body)
+  sqlc.arg(body),
+  1
 ) ON CONFLICT (note_id) DO UPDATE
   SET updated_at = excluded.updated_at,
       updated_by = excluded.updated_by,
       title      = excluded.title,
-      body       = excluded.body
+      body       = excluded.body,
+      revision   = notes.revision + 1
+  -- an expected revision of 0 overwrites any revision
+  WHERE sqlc.arg(expected_revision)::bigint = 0
+    OR notes.revision = sqlc.arg(expected_revision)::bigint
+RETURNING revision
 ;
 
 -- name: DeleteNote :execrows
@@ -35,22 +42,28 @@ SELECT
   updated_at,
   updated_by,
   title,
-  body
+  body,
+  revision
 FROM notes.notes
 WHERE note_id = sqlc.arg(note_id)
 ;
 
 -- name: SetNoteTags :exec
-MERGE INTO notes.note_tags
-USING (SELECT $2::uuid AS set_tag_id) ON
-  note_id = $1::uuid
-WHEN MATCHED AND set_tag_id IS NULL THEN
-  DELETE
-WHEN MATCHED AND set_tag_id IS NOT NULL THEN
-  UPDATE SET tag_id = set_tag_id
-WHEN NOT MATCHED THEN
-  INSERT (note_id, tag_id)
-  VALUES ($1::uuid, set_tag_id)
+WITH removed AS (
+  -- runs even though nothing refers to it, as data-modifying statements in WITH always do
+  DELETE FROM notes.note_tags
+  WHERE note_id = sqlc.arg(note_id)
+    AND NOT (tag_id = ANY(sqlc.arg(tag_ids)::uuid[]))
+)
+INSERT INTO notes.note_tags (
+  note_id,
+  tag_id
+)
+SELECT
+  sqlc.arg(note_id),
+  tag_ids.tag_id
+FROM unnest(sqlc.arg(tag_ids)::uuid[]) AS tag_ids(tag_id)
+ON CONFLICT (note_id, tag_id) DO NOTHING
 ;
 
 -- name: SetNoteAccess :exec
@@ -100,74 +113,671 @@ WHERE note_id = sqlc.arg(note_id)
   AND user_id = sqlc.arg(user_id)
 ;
 
+-- name: GetUserNotesAccess :many
+SELECT
+  note_id,
+  access
+FROM notes.user_note_access
+WHERE note_id = ANY(sqlc.arg(note_ids)::uuid[])
+  AND user_id = sqlc.arg(user_id)
+;
+
+-- name: AddNoteTag :exec
+INSERT INTO notes.note_tags (
+  note_id,
+  tag_id
+) VALUES (
+  sqlc.arg(note_id),
+  sqlc.arg(tag_id)
+) ON CONFLICT (note_id, tag_id) DO NOTHING
+;
+
+-- name: RemoveNoteTag :exec
+DELETE FROM notes.note_tags
+WHERE note_id = sqlc.arg(note_id)
+  AND tag_id = sqlc.arg(tag_id)
+;
+
+-- name: GrantNoteAccess :exec
+INSERT INTO notes.user_note_access (
+  note_id,
+  user_id,
+  access
+) VALUES (
+  sqlc.arg(note_id),
+  sqlc.arg(user_id),
+  sqlc.arg(access)::notes.access_level
+) ON CONFLICT (note_id, user_id) DO UPDATE
+  SET access = excluded.access
+;
+
+-- name: RevokeNoteAccess :exec
+DELETE FROM notes.user_note_access
+WHERE note_id = sqlc.arg(note_id)
+  AND user_id = sqlc.arg(user_id)
+;
+
+-- name: TouchNote :execrows
+UPDATE notes.notes
+SET updated_at = sqlc.arg(updated_at),
+    updated_by = sqlc.arg(updated_by)
+WHERE note_id = sqlc.arg(note_id)
+;
+
+-- name: GetNoteState :one
+SELECT
+  pinned,
+  starred,
+  archived
+FROM notes.user_note_states
+WHERE note_id = sqlc.arg(note_id)
+  AND user_id = sqlc.arg(user_id)
+;
+
+-- name: SaveNoteState :exec
+INSERT INTO notes.user_note_states (
+  note_id,
+  user_id,
+  pinned,
+  starred,
+  archived,
+  updated_at
+) VALUES (
+  sqlc.arg(note_id),
+  sqlc.arg(user_id),
+  sqlc.arg(pinned),
+  sqlc.arg(starred),
+  sqlc.arg(archived),
+  sqlc.arg(updated_at)
+) ON CONFLICT (note_id, user_id) DO UPDATE
+  SET pinned     = excluded.pinned,
+      starred    = excluded.starred,
+      archived   = excluded.archived,
+      updated_at = excluded.updated_at
+;
+
 -- name: ListNotes :many
 SELECT
   notes.note_id,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by,
   title
 FROM notes.notes
 JOIN notes.user_note_access ON
   user_note_access.note_id = notes.note_id
-  AND user_id = sqlc.arg(user_id)
+  AND user_note_access.user_id = sqlc.arg(user_id)
   -- NOTE: any access
-WHERE sqlc.narg(last_note_id)::uuid IS NULL OR notes.note_id > sqlc.narg(last_note_id)::uuid
-ORDER BY notes.note_id ASC
+LEFT JOIN notes.user_note_states ON
+  user_note_states.note_id = notes.note_id
+  AND user_note_states.user_id = user_note_access.user_id
+CROSS JOIN LATERAL (
+  SELECT
+    COALESCE(user_note_states.pinned, false) AS pinned,
+    COALESCE(user_note_states.starred, false) AS starred,
+    COALESCE(user_note_states.archived, false) AS archived
+) AS state
+WHERE (sqlc.narg(created_by)::uuid IS NULL OR notes.created_by = sqlc.narg(created_by)::uuid)
+  AND (sqlc.narg(updated_by)::uuid IS NULL OR notes.updated_by = sqlc.narg(updated_by)::uuid)
+  AND (sqlc.narg(created_after)::timestamptz IS NULL OR notes.created_at >= sqlc.narg(created_after)::timestamptz)
+  AND (sqlc.narg(created_before)::timestamptz IS NULL OR notes.created_at < sqlc.narg(created_before)::timestamptz)
+  AND (sqlc.narg(updated_after)::timestamptz IS NULL OR notes.updated_at >= sqlc.narg(updated_after)::timestamptz)
+  AND (sqlc.narg(updated_before)::timestamptz IS NULL OR notes.updated_at < sqlc.narg(updated_before)::timestamptz)
+  AND (cardinality(sqlc.arg(all_tags)::uuid[]) = 0 OR cardinality(sqlc.arg(all_tags)::uuid[]) = (
+    SELECT COUNT(*)
+    FROM notes.note_tags
+    WHERE note_tags.note_id = notes.note_id
+      AND note_tags.tag_id = ANY(sqlc.arg(all_tags)::uuid[])
+  ))
+  AND (cardinality(sqlc.arg(any_tags)::uuid[]) = 0 OR EXISTS (
+    SELECT 1
+    FROM notes.note_tags
+    WHERE note_tags.note_id = notes.note_id
+      AND note_tags.tag_id = ANY(sqlc.arg(any_tags)::uuid[])
+  ))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM notes.note_tags
+    WHERE note_tags.note_id = notes.note_id
+      AND note_tags.tag_id = ANY(sqlc.arg(none_tags)::uuid[])
+  )
+  AND (sqlc.narg(pinned)::bool IS NULL OR state.pinned = sqlc.narg(pinned)::bool)
+  AND (sqlc.narg(starred)::bool IS NULL OR state.starred = sqlc.narg(starred)::bool)
+  AND (sqlc.narg(archived)::bool IS NULL OR state.archived = sqlc.narg(archived)::bool)
+  -- pinned notes come first
+  AND (sqlc.narg(last_note_id)::uuid IS NULL
+    OR state.pinned < sqlc.narg(last_pinned)::bool
+    OR (state.pinned = sqlc.narg(last_pinned)::bool AND CASE sqlc.arg(sort_by)::text
+      WHEN 'updated_at' THEN CASE WHEN sqlc.arg(sort_desc)::bool
+        THEN (notes.updated_at, notes.note_id) < (sqlc.narg(last_time)::timestamptz, sqlc.narg(last_note_id)::uuid)
+        ELSE (notes.updated_at, notes.note_id) > (sqlc.narg(last_time)::timestamptz, sqlc.narg(last_note_id)::uuid)
+      END
+      WHEN 'created_at' THEN CASE WHEN sqlc.arg(sort_desc)::bool
+        THEN (notes.created_at, notes.note_id) < (sqlc.narg(last_time)::timestamptz, sqlc.narg(last_note_id)::uuid)
+        ELSE (notes.created_at, notes.note_id) > (sqlc.narg(last_time)::timestamptz, sqlc.narg(last_note_id)::uuid)
+      END
+      WHEN 'title' THEN CASE WHEN sqlc.arg(sort_desc)::bool
+        THEN (notes.title, notes.note_id) < (sqlc.narg(last_title)::text, sqlc.narg(last_note_id)::uuid)
+        ELSE (notes.title, notes.note_id) > (sqlc.narg(last_title)::text, sqlc.narg(last_note_id)::uuid)
+      END
+      ELSE CASE WHEN sqlc.arg(sort_desc)::bool
+        THEN notes.note_id < sqlc.narg(last_note_id)::uuid
+        ELSE notes.note_id > sqlc.narg(last_note_id)::uuid
+      END
+    END))
+ORDER BY
+  state.pinned DESC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'updated_at' AND NOT sqlc.arg(sort_desc)::bool THEN notes.updated_at END ASC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'updated_at' AND sqlc.arg(sort_desc)::bool THEN notes.updated_at END DESC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND NOT sqlc.arg(sort_desc)::bool THEN notes.created_at END ASC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_desc)::bool THEN notes.created_at END DESC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'title' AND NOT sqlc.arg(sort_desc)::bool THEN notes.title END ASC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'title' AND sqlc.arg(sort_desc)::bool THEN notes.title END DESC,
+  CASE WHEN sqlc.arg(sort_desc)::bool THEN notes.note_id END DESC,
+  notes.note_id ASC
 LIMIT sqlc.arg(page_size)
 ;
 
 -- name: SearchNotesWithText :many
 SELECT
   notes.note_id,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by,
   title,
   rank::float4,
-  ts_headline(title || '\n' || body, query, 'StartSel=<<, StopSel=>>') AS match
-FROM notes.notes
+  state.pinned,
+  state.starred,
+  state.archived,
+  -- the highlighting markers are stripped from the text first, so matches can be found unambiguously
+  ts_headline(translate(title, U&'\E000\E001\E002', ''), query, U&'StartSel="\E000", StopSel="\E001", HighlightAll=true') AS title_match,
+  ts_headline(translate(body, U&'\E000\E001\E002', ''), query, sqlc.arg(headline_options)::text) AS body_match,
+  attachment_hits.attachment_ids,
+  attachment_hits.attachment_names,
+  attachment_hits.attachment_matches
+-- the user's notes are found first, so that only their attachments are searched
+FROM notes.user_note_access
+JOIN notes.notes ON
+  notes.note_id = user_note_access.note_id
+  AND user_note_access.user_id = sqlc.arg(user_id)
+  -- NOTE: any access
 CROSS JOIN LATERAL websearch_to_tsquery(sqlc.arg(text_search)) AS query
-CROSS JOIN LATERAL ts_rank_cd(search_index, query) AS rank
+CROSS JOIN LATERAL (
+  -- the note's attachments whose text matches, best first
+  SELECT
+    array_agg(hits.attachment_id ORDER BY hits.rank DESC, hits.attachment_id)::uuid[] AS attachment_ids,
+    array_agg(hits.name ORDER BY hits.rank DESC, hits.attachment_id)::text[] AS attachment_names,
+    array_agg(
+      ts_headline(translate(hits.content, U&'\E000\E001\E002', ''), query, sqlc.arg(headline_options)::text)
+      ORDER BY hits.rank DESC, hits.attachment_id
+    )::text[] AS attachment_matches,
+    max(hits.rank) AS best_rank
+  FROM (
+    SELECT
+      attachments.attachment_id,
+      attachments.name,
+      attachment_texts.content,
+      ts_rank_cd(attachment_texts.search_index, query) AS rank
+    FROM notes.attachments
+    JOIN notes.attachment_texts ON
+      attachment_texts.attachment_id = attachments.attachment_id
+    WHERE attachments.note_id = notes.note_id
+      AND query @@ attachment_texts.search_index
+    ORDER BY rank DESC, attachments.attachment_id
+    LIMIT sqlc.arg(max_attachment_matches)::int
+  ) AS hits
+) AS attachment_hits
+CROSS JOIN LATERAL (
+  SELECT GREATEST(ts_rank_cd(notes.search_index, query), attachment_hits.best_rank) AS rank
+) AS ranked
+LEFT JOIN notes.user_note_states ON
+  user_note_states.note_id = notes.note_id
+  AND user_note_states.user_id = user_note_access.user_id
+CROSS JOIN LATERAL (
+  SELECT
+    COALESCE(user_note_states.pinned, false) AS pinned,
+    COALESCE(user_note_states.starred, false) AS starred,
+    COALESCE(user_note_states.archived, false) AS archived
+) AS state
+WHERE (query @@ notes.search_index OR attachment_hits.attachment_ids IS NOT NULL)
+  AND (sqlc.narg(created_by)::uuid IS NULL OR notes.created_by = sqlc.narg(created_by)::uuid)
+  AND (sqlc.narg(updated_by)::uuid IS NULL OR notes.updated_by = sqlc.narg(updated_by)::uuid)
+  AND (sqlc.narg(created_after)::timestamptz IS NULL OR notes.created_at >= sqlc.narg(created_after)::timestamptz)
+  AND (sqlc.narg(created_before)::timestamptz IS NULL OR notes.created_at < sqlc.narg(created_before)::timestamptz)
+  AND (sqlc.narg(updated_after)::timestamptz IS NULL OR notes.updated_at >= sqlc.narg(updated_after)::timestamptz)
+  AND (sqlc.narg(updated_before)::timestamptz IS NULL OR notes.updated_at < sqlc.narg(updated_before)::timestamptz)
+  AND (cardinality(sqlc.arg(all_tags)::uuid[]) = 0 OR cardinality(sqlc.arg(all_tags)::uuid[]) = (
+    SELECT COUNT(*)
+    FROM notes.note_tags
+    WHERE note_tags.note_id = notes.note_id
+      AND note_tags.tag_id = ANY(sqlc.arg(all_tags)::uuid[])
+  ))
+  AND (cardinality(sqlc.arg(any_tags)::uuid[]) = 0 OR EXISTS (
+    SELECT 1
+    FROM notes.note_tags
+    WHERE note_tags.note_id = notes.note_id
+      AND note_tags.tag_id = ANY(sqlc.arg(any_tags)::uuid[])
+  ))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM notes.note_tags
+    WHERE note_tags.note_id = notes.note_id
+      AND note_tags.tag_id = ANY(sqlc.arg(none_tags)::uuid[])
+  )
+  AND (sqlc.narg(pinned)::bool IS NULL OR state.pinned = sqlc.narg(pinned)::bool)
+  AND (sqlc.narg(starred)::bool IS NULL OR state.starred = sqlc.narg(starred)::bool)
+  AND (sqlc.narg(archived)::bool IS NULL OR state.archived = sqlc.narg(archived)::bool)
+  -- pinned notes come first
+  AND (sqlc.narg(last_note_id)::uuid IS NULL
+    OR state.pinned < sqlc.narg(last_pinned)::bool
+    OR (state.pinned = sqlc.narg(last_pinned)::bool AND CASE sqlc.arg(sort_by)::text
+      WHEN 'updated_at' THEN CASE WHEN sqlc.arg(sort_desc)::bool
+        THEN (notes.updated_at, notes.note_id) < (sqlc.narg(last_time)::timestamptz, sqlc.narg(last_note_id)::uuid)
+        ELSE (notes.updated_at, notes.note_id) > (sqlc.narg(last_time)::timestamptz, sqlc.narg(last_note_id)::uuid)
+      END
+      WHEN 'created_at' THEN CASE WHEN sqlc.arg(sort_desc)::bool
+        THEN (notes.created_at, notes.note_id) < (sqlc.narg(last_time)::timestamptz, sqlc.narg(last_note_id)::uuid)
+        ELSE (notes.created_at, notes.note_id) > (sqlc.narg(last_time)::timestamptz, sqlc.narg(last_note_id)::uuid)
+      END
+      WHEN 'title' THEN CASE WHEN sqlc.arg(sort_desc)::bool
+        THEN (notes.title, notes.note_id) < (sqlc.narg(last_title)::text, sqlc.narg(last_note_id)::uuid)
+        ELSE (notes.title, notes.note_id) > (sqlc.narg(last_title)::text, sqlc.narg(last_note_id)::uuid)
+      END
+      ELSE rank::float4 < sqlc.narg(last_rank)::float4
+        OR (rank::float4 = sqlc.narg(last_rank)::float4 AND notes.note_id > sqlc.narg(last_note_id)::uuid)
+    END))
+ORDER BY
+  state.pinned DESC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'default' THEN rank::float4 END DESC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'updated_at' AND NOT sqlc.arg(sort_desc)::bool THEN notes.updated_at END ASC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'updated_at' AND sqlc.arg(sort_desc)::bool THEN notes.updated_at END DESC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND NOT sqlc.arg(sort_desc)::bool THEN notes.created_at END ASC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_desc)::bool THEN notes.created_at END DESC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'title' AND NOT sqlc.arg(sort_desc)::bool THEN notes.title END ASC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'title' AND sqlc.arg(sort_desc)::bool THEN notes.title END DESC,
+  CASE WHEN sqlc.arg(sort_desc)::bool THEN notes.note_id END DESC,
+  notes.note_id ASC
+LIMIT sqlc.arg(page_size)
+;
+
+-- name: SearchNoteFacets :many
+WITH matches AS (
+  SELECT
+    notes.note_id,
+    notes.created_by,
+    notes.updated_at
+  FROM notes.notes
+  JOIN notes.user_note_access ON
+    user_note_access.note_id = notes.note_id
+    AND user_note_access.user_id = sqlc.arg(user_id)
+    -- NOTE: any access
+  LEFT JOIN notes.user_note_states ON
+    user_note_states.note_id = notes.note_id
+    AND user_note_states.user_id = user_note_access.user_id
+  CROSS JOIN LATERAL (
+    SELECT
+      COALESCE(user_note_states.pinned, false) AS pinned,
+      COALESCE(user_note_states.starred, false) AS starred,
+      COALESCE(user_note_states.archived, false) AS archived
+  ) AS state
+  WHERE (sqlc.arg(text_search)::text = '' OR websearch_to_tsquery(sqlc.arg(text_search)::text) @@ notes.search_index OR EXISTS (
+      SELECT 1
+      FROM notes.attachments
+      JOIN notes.attachment_texts ON
+        attachment_texts.attachment_id = attachments.attachment_id
+      WHERE attachments.note_id = notes.note_id
+        AND websearch_to_tsquery(sqlc.arg(text_search)::text) @@ attachment_texts.search_index
+    ))
+    AND (sqlc.narg(created_by)::uuid IS NULL OR notes.created_by = sqlc.narg(created_by)::uuid)
+    AND (sqlc.narg(updated_by)::uuid IS NULL OR notes.updated_by = sqlc.narg(updated_by)::uuid)
+    AND (sqlc.narg(created_after)::timestamptz IS NULL OR notes.created_at >= sqlc.narg(created_after)::timestamptz)
+    AND (sqlc.narg(created_before)::timestamptz IS NULL OR notes.created_at < sqlc.narg(created_before)::timestamptz)
+    AND (sqlc.narg(updated_after)::timestamptz IS NULL OR notes.updated_at >= sqlc.narg(updated_after)::timestamptz)
+    AND (sqlc.narg(updated_before)::timestamptz IS NULL OR notes.updated_at < sqlc.narg(updated_before)::timestamptz)
+    AND (cardinality(sqlc.arg(all_tags)::uuid[]) = 0 OR cardinality(sqlc.arg(all_tags)::uuid[]) = (
+      SELECT COUNT(*)
+      FROM notes.note_tags
+      WHERE note_tags.note_id = notes.note_id
+        AND note_tags.tag_id = ANY(sqlc.arg(all_tags)::uuid[])
+    ))
+    AND (cardinality(sqlc.arg(any_tags)::uuid[]) = 0 OR EXISTS (
+      SELECT 1
+      FROM notes.note_tags
+      WHERE note_tags.note_id = notes.note_id
+        AND note_tags.tag_id = ANY(sqlc.arg(any_tags)::uuid[])
+    ))
+    AND NOT EXISTS (
+      SELECT 1
+      FROM notes.note_tags
+      WHERE note_tags.note_id = notes.note_id
+        AND note_tags.tag_id = ANY(sqlc.arg(none_tags)::uuid[])
+    )
+    AND (sqlc.narg(pinned)::bool IS NULL OR state.pinned = sqlc.narg(pinned)::bool)
+    AND (sqlc.narg(starred)::bool IS NULL OR state.starred = sqlc.narg(starred)::bool)
+    AND (sqlc.narg(archived)::bool IS NULL OR state.archived = sqlc.narg(archived)::bool)
+)
+SELECT
+  'tag'::text AS facet,
+  tags.tag_id::text AS value,
+  tags.name AS label,
+  COUNT(*) AS count
+FROM matches
+JOIN notes.note_tags ON
+  note_tags.note_id = matches.note_id
+JOIN notes.tags ON
+  tags.tag_id = note_tags.tag_id
+JOIN notes.user_tag_access ON
+  user_tag_access.tag_id = tags.tag_id
+  AND user_tag_access.user_id = sqlc.arg(user_id)
+  -- NOTE: any access
+GROUP BY tags.tag_id, tags.name
+UNION ALL
+SELECT
+  'author'::text AS facet,
+  users.user_id::text AS value,
+  users.name AS label,
+  COUNT(*) AS count
+FROM matches
+JOIN notes.users ON
+  users.user_id = matches.created_by
+GROUP BY users.user_id, users.name
+UNION ALL
+SELECT
+  'updated_month'::text AS facet,
+  to_char(matches.updated_at AT TIME ZONE 'UTC', 'YYYY-MM') AS value,
+  '' AS label,
+  COUNT(*) AS count
+FROM matches
+GROUP BY 2
+ORDER BY facet, count DESC, value
+;
+
+-- name: GetRelatedNotes :many
+WITH source_tags AS (
+  SELECT tag_id
+  FROM notes.note_tags
+  WHERE note_id = sqlc.arg(note_id)
+),
+source_terms AS (
+  SELECT terms.lexeme
+  FROM notes.notes
+  CROSS JOIN LATERAL unnest(notes.search_index) AS terms
+  WHERE notes.note_id = sqlc.arg(note_id)
+),
+candidates AS (
+  SELECT
+    notes.note_id,
+    notes.created_at,
+    notes.created_by,
+    notes.updated_at,
+    notes.updated_by,
+    notes.title,
+    (
+      SELECT COUNT(*)
+      FROM notes.note_tags
+      WHERE note_tags.note_id = notes.note_id
+        AND note_tags.tag_id IN (SELECT tag_id FROM source_tags)
+    ) AS shared_tags,
+    (
+      SELECT COUNT(*)
+      FROM notes.note_tags
+      WHERE note_tags.note_id = notes.note_id
+    ) AS num_tags,
+    (
+      SELECT COUNT(*)
+      FROM unnest(notes.search_index) AS terms
+      WHERE terms.lexeme IN (SELECT lexeme FROM source_terms)
+    ) AS shared_terms,
+    length(notes.search_index) AS num_terms,
+    EXISTS (
+      SELECT 1
+      FROM notes.note_links
+      WHERE (note_links.source_note_id = sqlc.arg(note_id) AND note_links.target_note_id = notes.note_id)
+        OR (note_links.source_note_id = notes.note_id AND note_links.target_note_id = sqlc.arg(note_id))
+    ) AS linked
+  FROM notes.notes
+  JOIN notes.user_note_access ON
+    user_note_access.note_id = notes.note_id
+    AND user_id = sqlc.arg(user_id)
+    -- NOTE: any access
+  WHERE notes.note_id <> sqlc.arg(note_id)
+),
+scored AS (
+  -- the jaccard index of each candidate's tags and terms with those of the source note
+  SELECT
+    note_id,
+    created_at,
+    created_by,
+    updated_at,
+    updated_by,
+    title,
+    COALESCE(shared_tags::float4 / NULLIF(num_tags + (SELECT COUNT(*) FROM source_tags) - shared_tags, 0), 0) AS tag_score,
+    COALESCE(shared_terms::float4 / NULLIF(num_terms + (SELECT COUNT(*) FROM source_terms) - shared_terms, 0), 0) AS term_score,
+    CASE WHEN linked THEN 1 ELSE 0 END AS link_score
+  FROM candidates
+)
+SELECT
+  note_id,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by,
+  title,
+  tag_score::float4,
+  term_score::float4,
+  link_score::float4,
+  (
+    sqlc.arg(tag_weight)::float4 * tag_score
+    + sqlc.arg(term_weight)::float4 * term_score
+    + sqlc.arg(link_weight)::float4 * link_score
+  )::float4 AS score
+FROM scored
+WHERE tag_score > 0 OR term_score > 0 OR link_score > 0
+ORDER BY score DESC, note_id
+LIMIT sqlc.arg(max_results)
+;
+
+-- name: ClearNoteLinks :exec
+DELETE FROM notes.note_links
+WHERE source_note_id = sqlc.arg(source_note_id)
+;
+
+-- name: AddNoteLinks :exec
+INSERT INTO notes.note_links (
+  source_note_id,
+  reference,
+  target_note_id
+)
+SELECT
+  sqlc.arg(source_note_id),
+  refs.reference,
+  -- resolve to the note with that ID, or otherwise the oldest note with that title,
+  -- out of the notes that someone with access to the source note can also read
+  COALESCE(
+    (
+      SELECT note_id
+      FROM notes.notes
+      WHERE note_id = CASE
+        WHEN refs.reference ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$' THEN refs.reference::uuid
+      END
+        AND EXISTS (
+          SELECT 1
+          FROM notes.user_note_access AS source_access
+          JOIN notes.user_note_access AS target_access ON
+            target_access.user_id = source_access.user_id
+          WHERE source_access.note_id = sqlc.arg(source_note_id)
+            AND target_access.note_id = notes.note_id
+        )
+    ),
+    (
+      SELECT note_id
+      FROM notes.notes
+      WHERE lower(title) = lower(refs.reference)
+        AND EXISTS (
+          SELECT 1
+          FROM notes.user_note_access AS source_access
+          JOIN notes.user_note_access AS target_access ON
+            target_access.user_id = source_access.user_id
+          WHERE source_access.note_id = sqlc.arg(source_note_id)
+            AND target_access.note_id = notes.note_id
+        )
+      ORDER BY created_at, note_id
+      LIMIT 1
+    )
+  )
+FROM unnest(sqlc.arg(refs)::text[]) AS refs(reference)
+ON CONFLICT DO NOTHING
+;
+
+-- name: ResolveDanglingNoteLinks :exec
+UPDATE notes.note_links
+SET target_note_id = sqlc.arg(note_id)
+WHERE target_note_id IS NULL
+  AND (lower(reference) = lower(sqlc.arg(title)) OR lower(reference) = sqlc.arg(note_id)::text)
+  -- only from notes that someone who can read the note also has access to
+  AND EXISTS (
+    SELECT 1
+    FROM notes.user_note_access AS source_access
+    JOIN notes.user_note_access AS target_access ON
+      target_access.user_id = source_access.user_id
+    WHERE source_access.note_id = note_links.source_note_id
+      AND target_access.note_id = sqlc.arg(note_id)
+  )
+;
+
+-- name: GetNoteLinks :many
+SELECT
+  reference,
+  target_note_id
+FROM notes.note_links
 JOIN notes.user_note_access ON
-  user_note_access.note_id = notes.note_id
+  user_note_access.note_id = note_links.target_note_id
   AND user_id = sqlc.arg(user_id)
   -- NOTE: any access
-WHERE query @@ search_index
-  AND sqlc.narg(last_rank)::float4 IS NULL OR rank::float4 < sqlc.narg(last_rank)::float4
-ORDER BY rank::float4 DESC
-LIMIT sqlc.arg(page_size)
+WHERE source_note_id = sqlc.arg(source_note_id)
 ;
 
--- name: SearchNotesWithTag :many
+-- name: GetBacklinks :many
 SELECT
   notes.note_id,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by,
   title
-FROM notes.notes
+FROM notes.note_links
+JOIN notes.notes ON
+  notes.note_id = note_links.source_note_id
 JOIN notes.user_note_access ON
   user_note_access.note_id = notes.note_id
   AND user_id = sqlc.arg(user_id)
   -- NOTE: any access
-JOIN notes.note_tags ON
-  note_tags.note_id = notes.note_id
-WHERE note_tags.tag_id = sqlc.arg(tag_id)
+WHERE note_links.target_note_id = sqlc.arg(target_note_id)
   AND (sqlc.narg(last_note_id)::uuid IS NULL OR notes.note_id > sqlc.narg(last_note_id)::uuid)
-ORDER BY notes.note_id ASC
+ORDER BY notes.note_id
 LIMIT sqlc.arg(page_size)
 ;
 
--- name: SearchNotesWithTextAndTag :many
+-- name: ClearNoteTasks :exec
+DELETE FROM notes.note_tasks
+WHERE note_id = sqlc.arg(note_id)
+;
+
+-- name: AddNoteTasks :exec
+INSERT INTO notes.note_tasks (
+  note_id,
+  line,
+  text,
+  done
+)
 SELECT
-  notes.note_id,
-  title,
-  rank::float4 AS rank,
-  ts_headline(title || '\n' || body, query, 'StartSel=<<, StopSel=>>') AS match
-FROM notes.notes
-CROSS JOIN LATERAL websearch_to_tsquery(sqlc.arg(text_search)) AS query
-CROSS JOIN LATERAL ts_rank_cd(search_index, query) AS rank
+  sqlc.arg(note_id),
+  task.line,
+  task.text,
+  task.done
+FROM unnest(
+  sqlc.arg(lines)::int4[],
+  sqlc.arg(texts)::text[],
+  sqlc.arg(done)::bool[]
+) AS task(line, text, done)
+;
+
+-- name: ListTasks :many
+SELECT
+  note_tasks.note_id,
+  note_tasks.line,
+  note_tasks.text,
+  note_tasks.done,
+  notes.created_at,
+  notes.created_by,
+  notes.updated_at,
+  notes.updated_by,
+  notes.title
+FROM notes.note_tasks
+JOIN notes.notes ON
+  notes.note_id = note_tasks.note_id
 JOIN notes.user_note_access ON
-  user_note_access.note_id = notes.note_id
+  user_note_access.note_id = note_tasks.note_id
   AND user_id = sqlc.arg(user_id)
   -- NOTE: any access
-JOIN notes.note_tags ON
-  note_tags.note_id = notes.note_id
-WHERE tag_id = sqlc.arg(tag_id)
-  AND query @@ search_index
-  AND (sqlc.narg(last_rank)::float4 IS NULL OR rank::float4 < sqlc.narg(last_rank)::float4)
-ORDER BY rank::float4 DESC
+WHERE (sqlc.narg(done)::bool IS NULL OR note_tasks.done = sqlc.narg(done)::bool)
+  AND (sqlc.narg(note_id)::uuid IS NULL OR note_tasks.note_id = sqlc.narg(note_id)::uuid)
+  AND (sqlc.narg(tag_id)::uuid IS NULL OR EXISTS (
+    SELECT 1
+    FROM notes.note_tags
+    WHERE note_tags.note_id = note_tasks.note_id
+      AND note_tags.tag_id = sqlc.narg(tag_id)::uuid
+  ))
+  AND (sqlc.narg(last_note_id)::uuid IS NULL
+    OR (note_tasks.note_id, note_tasks.line) > (sqlc.narg(last_note_id)::uuid, sqlc.narg(last_line)::int4))
+ORDER BY note_tasks.note_id, note_tasks.line
+LIMIT sqlc.arg(page_size)
+;
+
+-- name: AddNoteRevision :exec
+INSERT INTO notes.note_revisions (
+  note_id,
+  revision,
+  title,
+  body,
+  saved_at,
+  saved_by
+) VALUES (
+  sqlc.arg(note_id),
+  sqlc.arg(revision),
+  sqlc.arg(title),
+  sqlc.arg(body),
+  sqlc.arg(saved_at),
+  sqlc.arg(saved_by)
+)
+;
+
+-- name: GetNoteRevision :one
+SELECT
+  note_id,
+  revision,
+  title,
+  body,
+  saved_at,
+  saved_by
+FROM notes.note_revisions
+WHERE note_id = sqlc.arg(note_id)
+  AND revision = sqlc.arg(revision)
+;
+
+-- name: ListNoteRevisions :many
+SELECT
+  note_id,
+  revision,
+  title,
+  body,
+  saved_at,
+  saved_by
+FROM notes.note_revisions
+WHERE note_id = sqlc.arg(note_id)
+  AND (sqlc.narg(last_revision)::bigint IS NULL OR revision < sqlc.narg(last_revision)::bigint)
+ORDER BY revision DESC
 LIMIT sqlc.arg(page_size)
 ;
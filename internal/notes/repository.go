@@ -1,31 +1,186 @@
 package notes
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/dabbertorres/notes/internal/tags"
 	"github.com/dabbertorres/notes/internal/users"
+	"github.com/dabbertorres/notes/internal/util"
 )
 
 type Repository interface {
-	SaveNote(ctx context.Context, note *Note) error
+	SaveNote(ctx context.Context, note *Note, tags TagsUpdate) error
 	DeleteNote(ctx context.Context, noteID uuid.UUID) error
 	GetNote(ctx context.Context, noteID, asUserID uuid.UUID) (*Note, error)
 	GetUsersNoteAccess(ctx context.Context, noteID, userID uuid.UUID) (users.AccessLevel, error)
+	GetUsersNotesAccess(ctx context.Context, noteIDs []uuid.UUID, userID uuid.UUID) (map[uuid.UUID]users.AccessLevel, error)
+	ApplyBatch(ctx context.Context, noteIDs []uuid.UUID, ops []BatchOperation, updatedBy uuid.UUID, updatedAt time.Time, atomic bool) []error
 	SearchNotes(ctx context.Context, asUserID uuid.UUID, search NoteSearchParams, pageSize int) ([]NoteSearchResult, error)
+	SearchNoteFacets(ctx context.Context, asUserID uuid.UUID, search NoteSearchParams) (*NoteFacets, error)
+	GetRelatedNotes(ctx context.Context, noteID, asUserID uuid.UUID, limit int) ([]RelatedNote, error)
+	GetNoteLinks(ctx context.Context, noteID, asUserID uuid.UUID) (map[string]uuid.UUID, error)
+	GetBacklinks(ctx context.Context, noteID, asUserID uuid.UUID, params BacklinkListParams, pageSize int) ([]NoteSearchResult, error)
+	ListTasks(ctx context.Context, asUserID uuid.UUID, params TaskListParams, pageSize int) ([]TaskWithNote, error)
+	SaveNoteState(ctx context.Context, noteID, userID uuid.UUID, state NoteState) error
+	GetNoteRevision(ctx context.Context, noteID uuid.UUID, revision int64) (*Revision, error)
+	ListNoteRevisions(ctx context.Context, noteID uuid.UUID, params RevisionListParams, pageSize int) ([]Revision, error)
 }
 
+// TagsUpdate is how saving a note changes its tags.
+type TagsUpdate byte
+
+const (
+	// TagsKeep leaves the note's tags as they are, ignoring its Tags.
+	TagsKeep TagsUpdate = iota
+	// TagsReplace makes the note's Tags its only tags.
+	TagsReplace
+	// TagsAdd adds the note's Tags to those it already has.
+	TagsAdd
+)
+
 type NoteSearchParams struct {
-	TextSearch string
-	TagSearch  uuid.NullUUID
+	TextSearch     string
+	Tags           TagFilter
+	State          StateFilter
+	CreatedBy      uuid.NullUUID
+	UpdatedBy      uuid.NullUUID
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+	UpdatedAfter   time.Time
+	UpdatedBefore  time.Time
+	SortBy         SortKey
+	SortDescending bool
+	Snippets       SnippetOptions
+
+	// the remaining fields are the position of the last result of the previous page
+
 	LastNoteID uuid.NullUUID
+	LastPinned bool
 	LastRank   float32
+	LastTime   time.Time
+	LastTitle  string
+}
+
+//go:generate go run ../../tools/stringer -type=SortKey -trimprefix=SortBy -linecomment -lower
+type SortKey byte
+
+const (
+	// SortByDefault orders by relevance when searching by text, and by ID otherwise.
+	SortByDefault   SortKey = iota // default
+	SortByUpdatedAt                // updated_at
+	SortByCreatedAt                // created_at
+	SortByTitle                    // title
+)
+
+// TagFilter restricts a search by the tags applied to notes.
+// Empty sets are ignored, and a note must satisfy every non-empty set to match.
+type TagFilter struct {
+	// AllOf matches notes that have every one of the tags.
+	AllOf []uuid.UUID
+	// AnyOf matches notes that have at least one of the tags.
+	AnyOf []uuid.UUID
+	// NoneOf matches notes that have none of the tags.
+	NoneOf []uuid.UUID
+}
+
+func (f TagFilter) IsEmpty() bool {
+	return len(f.AllOf) == 0 && len(f.AnyOf) == 0 && len(f.NoneOf) == 0
+}
+
+// normalize removes duplicate tags from each set, and ensures none are nil,
+// so that they are always sent to the database as (possibly empty) arrays.
+func (f TagFilter) normalize() TagFilter {
+	return TagFilter{
+		AllOf:  distinctTags(f.AllOf),
+		AnyOf:  distinctTags(f.AnyOf),
+		NoneOf: distinctTags(f.NoneOf),
+	}
+}
+
+func distinctTags(ids []uuid.UUID) []uuid.UUID {
+	out := make([]uuid.UUID, len(ids))
+	copy(out, ids)
+	return util.SliceDistinctBy(out, func(lhs, rhs uuid.UUID) int {
+		return bytes.Compare(lhs[:], rhs[:])
+	})
+}
+
+// StateFilter restricts a search by the searching user's own [NoteState] of notes.
+// Each field, if valid, only matches notes that are (or are not) in that state.
+type StateFilter struct {
+	Pinned   sql.NullBool
+	Starred  sql.NullBool
+	Archived sql.NullBool
 }
 
 type NoteSearchResult struct {
-	ID      uuid.UUID
-	Rank    float32
-	Title   string
-	Matched string
+	ID        uuid.UUID
+	CreatedAt time.Time
+	CreatedBy users.User
+	UpdatedAt time.Time
+	UpdatedBy users.User
+	Rank      float32
+	Title     string
+	// State is only set when searching or listing notes.
+	State NoteState
+
+	// TitleMatch, BodyMatches, and AttachmentMatches are only set when searching by text.
+
+	TitleMatch        Highlight
+	BodyMatches       []Highlight
+	AttachmentMatches []AttachmentMatch
+}
+
+// AttachmentMatch is an attachment of a note whose text matched a search.
+type AttachmentMatch struct {
+	AttachmentID uuid.UUID
+	Name         string
+	Matches      []Highlight
+}
+
+// NoteFacets summarizes every note matching a search, regardless of pagination.
+type NoteFacets struct {
+	Tags          []TagFacet
+	Authors       []AuthorFacet
+	UpdatedMonths []MonthFacet
+}
+
+type TagFacet struct {
+	Tag   tags.Tag
+	Count int
+}
+
+type AuthorFacet struct {
+	Author users.User
+	Count  int
+}
+
+type MonthFacet struct {
+	// Month is the first instant of the month, in UTC.
+	Month time.Time
+	Count int
+}
+
+// RelatedNote is a note similar to another, and how similar they are.
+// Each score is between 0 (nothing in common) and 1 (identical).
+type RelatedNote struct {
+	Note      NoteSearchResult
+	Score     float32
+	TagScore  float32
+	TermScore float32
+	LinkScore float32
+}
+
+type BacklinkListParams struct {
+	LastNoteID uuid.NullUUID
+}
+
+type RevisionListParams struct {
+	// LastRevision is the last revision of the previous page, or 0 to start from the latest revision.
+	LastRevision int64
 }
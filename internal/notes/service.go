@@ -2,7 +2,10 @@ package notes
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,12 +14,19 @@ import (
 
 	"github.com/dabbertorres/notes/internal/common/apiv1"
 	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/markdown"
 	"github.com/dabbertorres/notes/internal/scope"
 	"github.com/dabbertorres/notes/internal/users"
 )
 
 type Service struct {
 	repo Repository
+	tags TagAccess
+}
+
+// TagAccess looks up users' access to tags, so that notes are only tagged with tags a user can see.
+type TagAccess interface {
+	GetUsersTagAccess(ctx context.Context, tagID, userID uuid.UUID) (users.AccessLevel, error)
 }
 
 func NewService(injector do.Injector) (*Service, error) {
@@ -25,8 +35,14 @@ func NewService(injector do.Injector) (*Service, error) {
 		return nil, err
 	}
 
+	tagAccess, err := do.InvokeAs[TagAccess](injector)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Service{
 		repo: repo,
+		tags: tagAccess,
 	}, nil
 }
 
@@ -48,7 +64,7 @@ func (s *Service) CreateNote(ctx context.Context, note *Note) (*Note, error) {
 		Access: users.AccessLevelOwner,
 	})
 
-	if err := s.repo.SaveNote(ctx, note); err != nil {
+	if err := s.repo.SaveNote(ctx, note, TagsReplace); err != nil {
 		log.Error(ctx, "error creating note", zap.Stringer("note_id", note.ID), zap.Error(err))
 		return nil, apiv1.StatusError(http.StatusInternalServerError)
 	}
@@ -74,7 +90,11 @@ func (s *Service) UpdateNote(ctx context.Context, note *Note) (*Note, error) {
 	note.UpdatedAt = time.Now()
 	note.UpdatedBy.ID = userID
 
-	if err := s.repo.SaveNote(ctx, note); err != nil {
+	if err := s.repo.SaveNote(ctx, note, TagsReplace); err != nil {
+		if errors.Is(err, ErrRevisionConflict) {
+			return nil, apiv1.NewError(http.StatusConflict, err.Error())
+		}
+
 		log.Error(ctx, "error saving note", zap.Stringer("note_id", note.ID), zap.Error(err))
 		return nil, apiv1.StatusError(http.StatusInternalServerError)
 	}
@@ -82,6 +102,82 @@ func (s *Service) UpdateNote(ctx context.Context, note *Note) (*Note, error) {
 	return note, nil
 }
 
+// ImportNote saves note with the ID it already has, creating it if there is no note with that ID yet,
+// so that importing the same note again updates it rather than duplicating it.
+// Times that are set on note are kept, and the note's access is only changed if the user owns it.
+func (s *Service) ImportNote(ctx context.Context, note *Note) (created bool, err error) {
+	userID := scope.MustUserID(ctx)
+
+	existing, err := s.repo.GetNote(ctx, note.ID, userID)
+	if err != nil {
+		var apiErr apiv1.Error
+		if !errors.As(err, &apiErr) || apiErr.Status() != http.StatusNotFound {
+			return false, err
+		}
+
+		existing = nil
+	}
+
+	now := time.Now()
+	if note.UpdatedAt.IsZero() {
+		note.UpdatedAt = now
+	}
+	note.UpdatedBy = users.User{ID: userID}
+	note.Revision = 0
+
+	if existing == nil {
+		created = true
+
+		if note.CreatedAt.IsZero() {
+			note.CreatedAt = note.UpdatedAt
+		}
+		note.CreatedBy = users.User{ID: userID}
+		note.Access = withOwner(note.Access, userID)
+	} else {
+		access, err := s.repo.GetUsersNoteAccess(ctx, note.ID, userID)
+		if err != nil {
+			log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", note.ID), zap.Error(err))
+			return false, apiv1.StatusError(http.StatusInternalServerError)
+		}
+
+		if access < users.AccessLevelEditor {
+			return false, apiv1.StatusError(http.StatusForbidden)
+		}
+
+		note.CreatedAt = existing.CreatedAt
+		note.CreatedBy = existing.CreatedBy
+
+		if access < users.AccessLevelOwner {
+			note.Access = nil
+		} else {
+			note.Access = withOwner(note.Access, userID)
+		}
+	}
+
+	// importing adds to a note's tags, rather than removing ones given to it since it was exported
+	if err := s.repo.SaveNote(ctx, note, TagsAdd); err != nil {
+		log.Error(ctx, "error importing note", zap.Stringer("note_id", note.ID), zap.Error(err))
+		return false, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	return created, nil
+}
+
+// withOwner replaces any access userID has in access with owning it.
+func withOwner(access []users.Access, userID uuid.UUID) []users.Access {
+	out := make([]users.Access, 0, len(access)+1)
+	for _, a := range access {
+		if a.User.ID != userID {
+			out = append(out, a)
+		}
+	}
+
+	return append(out, users.Access{
+		User:   users.User{ID: userID},
+		Access: users.AccessLevelOwner,
+	})
+}
+
 func (s *Service) DeleteNote(ctx context.Context, noteID uuid.UUID) error {
 	userID := scope.MustUserID(ctx)
 
@@ -98,6 +194,113 @@ func (s *Service) DeleteNote(ctx context.Context, noteID uuid.UUID) error {
 	return s.repo.DeleteNote(ctx, noteID)
 }
 
+// ApplyBatch applies the operations of batch to each of its notes, and reports how each note went.
+// The user must be able to see every tag the operations use, but failing to change one note only fails that note,
+// unless the batch is atomic.
+func (s *Service) ApplyBatch(ctx context.Context, batch *Batch) ([]BatchResult, error) {
+	userID := scope.MustUserID(ctx)
+
+	noteIDs, err := s.batchNoteIDs(ctx, userID, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	required := users.AccessLevelNone
+	for _, op := range batch.Operations {
+		required = max(required, op.requiredAccess())
+
+		for _, tagID := range op.tagIDs() {
+			access, err := s.tags.GetUsersTagAccess(ctx, tagID, userID)
+			if err != nil {
+				log.Error(ctx, "error retrieving user tag access", zap.Stringer("tag_id", tagID), zap.Error(err))
+				return nil, apiv1.StatusError(http.StatusInternalServerError)
+			}
+
+			if access < users.AccessLevelViewer {
+				return nil, apiv1.NewError(http.StatusForbidden, "cannot use tag "+tagID.String())
+			}
+		}
+	}
+
+	access, err := s.repo.GetUsersNotesAccess(ctx, noteIDs, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user notes access", zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	results := make([]BatchResult, len(noteIDs))
+	allowed := make([]uuid.UUID, 0, len(noteIDs))
+	for i, id := range noteIDs {
+		results[i].NoteID = id
+		if access[id] < required {
+			results[i].Err = apiv1.StatusError(http.StatusForbidden)
+		} else {
+			allowed = append(allowed, id)
+		}
+	}
+
+	if batch.Atomic && len(allowed) != len(noteIDs) {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = errBatchNotApplied
+			}
+		}
+
+		return results, nil
+	}
+
+	errs := s.repo.ApplyBatch(ctx, allowed, batch.Operations, userID, time.Now(), batch.Atomic)
+
+	// the allowed notes are in the same order as the results, just without the forbidden ones
+	next := 0
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Err = errs[next]
+			next++
+		}
+	}
+
+	return results, nil
+}
+
+// batchNoteIDs finds the notes a batch changes, without duplicates.
+func (s *Service) batchNoteIDs(ctx context.Context, userID uuid.UUID, batch *Batch) ([]uuid.UUID, error) {
+	if len(batch.NoteIDs) == 0 {
+		// retrieve one more to see if there are too many notes
+		results, err := s.repo.SearchNotes(ctx, userID, batch.Query, MaxBatchSize+1)
+		if err != nil {
+			log.Error(ctx, "error searching notes", zap.Error(err))
+			return nil, apiv1.StatusError(http.StatusInternalServerError)
+		}
+
+		if len(results) > MaxBatchSize {
+			return nil, apiv1.NewError(http.StatusBadRequest, fmt.Sprintf("query matches more than %d notes", MaxBatchSize))
+		}
+
+		noteIDs := make([]uuid.UUID, len(results))
+		for i := range results {
+			noteIDs[i] = results[i].ID
+		}
+
+		return noteIDs, nil
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(batch.NoteIDs))
+	noteIDs := make([]uuid.UUID, 0, len(batch.NoteIDs))
+	for _, id := range batch.NoteIDs {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			noteIDs = append(noteIDs, id)
+		}
+	}
+
+	if len(noteIDs) > MaxBatchSize {
+		return nil, apiv1.NewError(http.StatusBadRequest, fmt.Sprintf("a batch can change at most %d notes", MaxBatchSize))
+	}
+
+	return noteIDs, nil
+}
+
 func (s *Service) GetNote(ctx context.Context, noteID uuid.UUID) (*Note, error) {
 	userID := scope.MustUserID(ctx)
 
@@ -134,13 +337,307 @@ func (s *Service) SearchNotes(ctx context.Context, params NoteSearchParams, page
 
 		last := &results[len(results)-1]
 
-		next = &NoteSearchParams{
-			TextSearch: params.TextSearch,
-			TagSearch:  params.TagSearch,
-			LastNoteID: uuid.NullUUID{UUID: last.ID, Valid: true},
-			LastRank:   last.Rank,
+		nextParams := params
+		nextParams.LastNoteID = uuid.NullUUID{UUID: last.ID, Valid: true}
+		nextParams.LastPinned = last.State.Pinned
+		nextParams.LastRank = last.Rank
+		nextParams.LastTitle = last.Title
+
+		switch params.SortBy {
+		case SortByUpdatedAt:
+			nextParams.LastTime = last.UpdatedAt
+		case SortByCreatedAt:
+			nextParams.LastTime = last.CreatedAt
 		}
+
+		next = &nextParams
 	}
 
 	return results, next, err
 }
+
+// SetNoteState replaces the user's own state of a note. Every user who can view the note has their own state for it.
+func (s *Service) SetNoteState(ctx context.Context, noteID uuid.UUID, state NoteState) (*NoteState, error) {
+	userID := scope.MustUserID(ctx)
+
+	access, err := s.repo.GetUsersNoteAccess(ctx, noteID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < users.AccessLevelViewer {
+		return nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	if err := s.repo.SaveNoteState(ctx, noteID, userID, state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// SearchNoteFacets counts the notes matching params by tag, author and month of last update.
+// Any position in params, from a previous page of results, is ignored.
+func (s *Service) SearchNoteFacets(ctx context.Context, params NoteSearchParams) (*NoteFacets, error) {
+	userID := scope.MustUserID(ctx)
+
+	facets, err := s.repo.SearchNoteFacets(ctx, userID, params)
+	if err != nil {
+		log.Error(ctx, "error searching note facets", zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	return facets, nil
+}
+
+// GetRelatedNotes finds up to limit notes that the user can access which are most similar to noteID.
+func (s *Service) GetRelatedNotes(ctx context.Context, noteID uuid.UUID, limit int) ([]RelatedNote, error) {
+	userID := scope.MustUserID(ctx)
+
+	access, err := s.repo.GetUsersNoteAccess(ctx, noteID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < users.AccessLevelViewer {
+		return nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	if limit == 0 {
+		limit = 10
+	}
+
+	return s.repo.GetRelatedNotes(ctx, noteID, userID, limit)
+}
+
+// GetBacklinks lists the notes that the user can access which link to noteID.
+func (s *Service) GetBacklinks(ctx context.Context, noteID uuid.UUID, params BacklinkListParams, pageSize int) ([]NoteSearchResult, *BacklinkListParams, error) {
+	userID := scope.MustUserID(ctx)
+
+	access, err := s.repo.GetUsersNoteAccess(ctx, noteID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < users.AccessLevelViewer {
+		return nil, nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	if pageSize == 0 {
+		pageSize = 100
+	}
+
+	// retrive one more to see if there is another page to fetch
+	results, err := s.repo.GetBacklinks(ctx, noteID, userID, params, pageSize+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *BacklinkListParams
+	if len(results) > pageSize {
+		results = results[:pageSize]
+		next = &BacklinkListParams{
+			LastNoteID: uuid.NullUUID{UUID: results[len(results)-1].ID, Valid: true},
+		}
+	}
+
+	return results, next, nil
+}
+
+// RenderNote renders the body of note, which must have been retrieved with [Service.GetNote], as HTML.
+// Links to other notes that the user can access are resolved with linkHref.
+func (s *Service) RenderNote(ctx context.Context, note *Note, linkHref func(noteID uuid.UUID) string) (string, error) {
+	userID := scope.MustUserID(ctx)
+
+	links, err := s.repo.GetNoteLinks(ctx, note.ID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	html, err := markdown.Render(note.Body, func(reference string) (string, bool) {
+		target, ok := links[strings.ToLower(reference)]
+		if !ok {
+			return "", false
+		}
+
+		return linkHref(target), true
+	})
+	if err != nil {
+		log.Error(ctx, "error rendering note", zap.Stringer("note_id", note.ID), zap.Error(err))
+		return "", apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	return html, nil
+}
+
+func (s *Service) ListTasks(ctx context.Context, params TaskListParams, pageSize int) ([]TaskWithNote, *TaskListParams, error) {
+	userID := scope.MustUserID(ctx)
+
+	if pageSize == 0 {
+		pageSize = 100
+	}
+
+	// retrive one more to see if there is another page to fetch
+	results, err := s.repo.ListTasks(ctx, userID, params, pageSize+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *TaskListParams
+	if len(results) > pageSize {
+		results = results[:pageSize]
+
+		last := &results[len(results)-1]
+
+		nextParams := params
+		nextParams.LastNoteID = uuid.NullUUID{UUID: last.NoteID, Valid: true}
+		nextParams.LastLine = last.Line
+		next = &nextParams
+	}
+
+	return results, next, nil
+}
+
+// SetTaskDone checks or unchecks the task on the given line of a note's body.
+// If revision is not 0, the note must not have been modified since that revision.
+func (s *Service) SetTaskDone(ctx context.Context, noteID uuid.UUID, line int, done bool, revision int64) (*Note, error) {
+	userID := scope.MustUserID(ctx)
+
+	access, err := s.repo.GetUsersNoteAccess(ctx, noteID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < users.AccessLevelEditor {
+		return nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	note, err := s.repo.GetNote(ctx, noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if revision != 0 && note.Revision != revision {
+		return nil, apiv1.NewError(http.StatusConflict, ErrRevisionConflict.Error())
+	}
+
+	body, err := SetTaskDone(note.Body, line, done)
+	if err != nil {
+		return nil, apiv1.NewError(http.StatusNotFound, err.Error())
+	}
+
+	if err := s.saveBody(ctx, note, body, userID); err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// SaveNoteBody replaces the body of a note, leaving the rest of it as it is.
+// The note must not have been modified since revision.
+func (s *Service) SaveNoteBody(ctx context.Context, noteID uuid.UUID, body string, revision int64) (*Note, error) {
+	userID := scope.MustUserID(ctx)
+
+	access, err := s.repo.GetUsersNoteAccess(ctx, noteID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < users.AccessLevelEditor {
+		return nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	note, err := s.repo.GetNote(ctx, noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if note.Revision != revision {
+		return nil, apiv1.NewError(http.StatusConflict, ErrRevisionConflict.Error())
+	}
+
+	if err := s.saveBody(ctx, note, body, userID); err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// saveBody saves note with body as its new body, as updated by userID.
+func (s *Service) saveBody(ctx context.Context, note *Note, body string, userID uuid.UUID) error {
+	// only the body is changed, so leave the tags and access as they are
+	note.Body = body
+	note.UpdatedAt = time.Now()
+	note.UpdatedBy = users.User{ID: userID}
+	savedAccess := note.Access
+	note.Access = nil
+
+	if err := s.repo.SaveNote(ctx, note, TagsKeep); err != nil {
+		if errors.Is(err, ErrRevisionConflict) {
+			return apiv1.NewError(http.StatusConflict, err.Error())
+		}
+
+		log.Error(ctx, "error saving note", zap.Stringer("note_id", note.ID), zap.Error(err))
+		return apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	note.Access = savedAccess
+	return nil
+}
+
+// GetNoteRevision retrieves a note as it was saved in one of its revisions.
+func (s *Service) GetNoteRevision(ctx context.Context, noteID uuid.UUID, revision int64) (*Revision, error) {
+	userID := scope.MustUserID(ctx)
+
+	access, err := s.repo.GetUsersNoteAccess(ctx, noteID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < users.AccessLevelViewer {
+		return nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	return s.repo.GetNoteRevision(ctx, noteID, revision)
+}
+
+// ListNoteRevisions lists the revisions of a note, latest first.
+func (s *Service) ListNoteRevisions(ctx context.Context, noteID uuid.UUID, params RevisionListParams, pageSize int) ([]Revision, *RevisionListParams, error) {
+	userID := scope.MustUserID(ctx)
+
+	access, err := s.repo.GetUsersNoteAccess(ctx, noteID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < users.AccessLevelViewer {
+		return nil, nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	if pageSize == 0 {
+		pageSize = 100
+	}
+
+	// retrieve one more to see if there is another page to fetch
+	results, err := s.repo.ListNoteRevisions(ctx, noteID, params, pageSize+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *RevisionListParams
+	if len(results) > pageSize {
+		results = results[:pageSize]
+		next = &RevisionListParams{
+			LastRevision: results[len(results)-1].Revision,
+		}
+	}
+
+	return results, next, nil
+}
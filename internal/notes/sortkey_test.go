@@ -0,0 +1,49 @@
+package notes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSortKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    SortKey
+		wantErr bool
+	}{
+		{name: "default", input: "default", want: SortByDefault},
+		{name: "updated_at", input: "updated_at", want: SortByUpdatedAt},
+		{name: "created_at", input: "created_at", want: SortByCreatedAt},
+		{name: "title", input: "title", want: SortByTitle},
+		{name: "empty", input: "", wantErr: true},
+		{name: "prefix", input: "up", wantErr: true},
+		{name: "spans names", input: "at", wantErr: true},
+		{name: "suffix", input: "itle", wantErr: true},
+		{name: "concatenated", input: "defaultupdated_at", wantErr: true},
+		{name: "wrong case", input: "Title", wantErr: true},
+		{name: "unknown", input: "relevance", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSortKey(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSortKey_StringRoundTrip(t *testing.T) {
+	for _, key := range []SortKey{SortByDefault, SortByUpdatedAt, SortByCreatedAt, SortByTitle} {
+		got, err := ParseSortKey(key.String())
+		assert.NoError(t, err)
+		assert.Equal(t, key, got)
+	}
+}
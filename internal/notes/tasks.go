@@ -0,0 +1,104 @@
+package notes
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Task is a checklist item (e.g. "- [ ] write postmortem") in a note's body.
+type Task struct {
+	NoteID uuid.UUID
+	// Line is the 1-based line number of the task in the note's body.
+	Line int
+	Text string
+	Done bool
+}
+
+// TaskWithNote is a [Task], along with a summary of the note it is from.
+type TaskWithNote struct {
+	Task
+	Note NoteSearchResult
+}
+
+type TaskListParams struct {
+	// Done, if valid, only lists tasks that are (or are not) done.
+	Done   sql.NullBool
+	TagID  uuid.NullUUID
+	NoteID uuid.NullUUID
+
+	// the remaining fields are the position of the last result of the previous page
+
+	LastNoteID uuid.NullUUID
+	LastLine   int
+}
+
+var (
+	ErrNotATask = errors.New("line is not a task")
+
+	// a list item, followed by a checkbox: the 2nd capture group is the checkbox's state
+	taskPattern = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+\[)([ xX])\]\s+(.*?)\s*$`)
+)
+
+// ParseTasks finds every task in body. Tasks within fenced code blocks are ignored.
+func ParseTasks(body string) []Task {
+	var (
+		tasks   []Task
+		inFence bool
+	)
+
+	for i, line := range strings.Split(body, "\n") {
+		if isFence(line) {
+			inFence = !inFence
+			continue
+		}
+
+		if inFence {
+			continue
+		}
+
+		match := taskPattern.FindStringSubmatch(strings.TrimSuffix(line, "\r"))
+		if match == nil || match[3] == "" {
+			continue
+		}
+
+		tasks = append(tasks, Task{
+			Line: i + 1,
+			Text: match[3],
+			Done: match[2] != " ",
+		})
+	}
+
+	return tasks
+}
+
+// SetTaskDone rewrites the task on the given 1-based line of body, checking or unchecking it.
+// If the line is not a task, [ErrNotATask] is returned.
+func SetTaskDone(body string, line int, done bool) (string, error) {
+	for _, task := range ParseTasks(body) {
+		if task.Line != line {
+			continue
+		}
+
+		lines := strings.Split(body, "\n")
+		match := taskPattern.FindStringSubmatchIndex(lines[line-1])
+
+		mark := " "
+		if done {
+			mark = "x"
+		}
+
+		lines[line-1] = lines[line-1][:match[4]] + mark + lines[line-1][match[5]:]
+		return strings.Join(lines, "\n"), nil
+	}
+
+	return "", ErrNotATask
+}
+
+func isFence(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~")
+}
@@ -0,0 +1,44 @@
+package notes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const tasksBody = "# Handoff\r\n" +
+	"- [ ] page the on-call\r\n" +
+	"* [x] write the timeline\n" +
+	"  1. [X] nested\n" +
+	"- [] not a task\n" +
+	"- [ ]\n" +
+	"```\n" +
+	"- [ ] in a code block\n" +
+	"```\n" +
+	"+ [ ] last one"
+
+func TestParseTasks(t *testing.T) {
+	assert.Equal(t, []Task{
+		{Line: 2, Text: "page the on-call", Done: false},
+		{Line: 3, Text: "write the timeline", Done: true},
+		{Line: 4, Text: "nested", Done: true},
+		{Line: 10, Text: "last one", Done: false},
+	}, ParseTasks(tasksBody))
+}
+
+func TestSetTaskDone(t *testing.T) {
+	got, err := SetTaskDone(tasksBody, 2, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "# Handoff\r\n- [x] page the on-call\r\n", got[:len("# Handoff\r\n- [x] page the on-call\r\n")])
+
+	got, err = SetTaskDone(got, 4, false)
+	assert.NoError(t, err)
+	assert.Contains(t, got, "\n  1. [ ] nested\n")
+	assert.Len(t, got, len(tasksBody))
+
+	_, err = SetTaskDone(tasksBody, 8, true)
+	assert.ErrorIs(t, err, ErrNotATask)
+
+	_, err = SetTaskDone(tasksBody, 100, true)
+	assert.ErrorIs(t, err, ErrNotATask)
+}
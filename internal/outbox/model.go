@@ -0,0 +1,64 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/events"
+)
+
+// Message is something that happened, to be published to the subscribers of its topic.
+type Message struct {
+	ID      int64
+	Topic   string
+	Payload json.RawMessage
+	// Attempts is how many times publishing the message was attempted before.
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// Outcome is the result of an attempt to publish a message.
+type Outcome struct {
+	// Err is nil if the message was published.
+	Err error
+	// RetryAt is when to try publishing the message again after a failure. It is given up on if this is zero.
+	RetryAt time.Time
+}
+
+// EventPayload is the payload of the message written for each [events.Event]. The message's topic is the event's
+// kind.
+type EventPayload struct {
+	ID         int64         `json:"id"`
+	Kind       events.Kind   `json:"kind"`
+	NoteID     uuid.NullUUID `json:"note_id"`
+	TagID      uuid.NullUUID `json:"tag_id"`
+	Audience   []uuid.UUID   `json:"audience"`
+	OccurredAt time.Time     `json:"occurred_at"`
+	// TagIDs are the tags of the event's note when it was recorded, or its tag. A note's tags may have changed, or
+	// been deleted along with it, by the time the message is published.
+	TagIDs []uuid.UUID `json:"tag_ids"`
+}
+
+func EventPayloadFromEvent(event *events.Event) EventPayload {
+	return EventPayload{
+		ID:         event.ID,
+		Kind:       event.Kind,
+		NoteID:     event.NoteID,
+		TagID:      event.TagID,
+		Audience:   event.Audience,
+		OccurredAt: event.OccurredAt,
+	}
+}
+
+func (p *EventPayload) ToEvent() *events.Event {
+	return &events.Event{
+		ID:         p.ID,
+		Kind:       p.Kind,
+		NoteID:     p.NoteID,
+		TagID:      p.TagID,
+		Audience:   p.Audience,
+		OccurredAt: p.OccurredAt,
+	}
+}
@@ -0,0 +1,9 @@
+package outbox
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewPGXRepository),
+	do.Lazy(NewWriter),
+	do.Lazy(NewRelay),
+)
@@ -0,0 +1,108 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+type PGXRepository struct {
+	db      database.Database
+	queries *database.Queries
+}
+
+func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGXRepository{
+		db:      db,
+		queries: database.New(),
+	}, nil
+}
+
+func (r *PGXRepository) ProcessMessages(ctx context.Context, topics []string, now time.Time, limit int, publish func(ctx context.Context, msg *Message) Outcome) (numClaimed int, err error) {
+	// the messages stay locked until every outcome is recorded, so if this is interrupted they are all published again
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ClaimOutboxMessages(ctx, tx, database.ClaimOutboxMessagesParams{
+			Now:      pgtype.Timestamptz{Time: now, Valid: true},
+			Topics:   topics,
+			PageSize: int64(limit),
+		})
+		if err != nil {
+			return err
+		}
+
+		numClaimed = len(rows)
+
+		for _, row := range rows {
+			msg := Message{
+				ID:        row.MessageID,
+				Topic:     row.Topic,
+				Payload:   row.Payload,
+				Attempts:  int(row.Attempts),
+				CreatedAt: row.CreatedAt.Time,
+			}
+
+			if err := r.recordOutcome(ctx, tx, &msg, publish(ctx, &msg)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error processing outbox messages", zap.Error(err))
+		return 0, err
+	}
+
+	return numClaimed, nil
+}
+
+func (r *PGXRepository) recordOutcome(ctx context.Context, tx pgx.Tx, msg *Message, outcome Outcome) error {
+	now := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+
+	switch {
+	case outcome.Err == nil:
+		return r.queries.PublishOutboxMessage(ctx, tx, database.PublishOutboxMessageParams{
+			PublishedAt: now,
+			MessageID:   msg.ID,
+		})
+
+	case outcome.RetryAt.IsZero():
+		return r.queries.AbandonOutboxMessage(ctx, tx, database.AbandonOutboxMessageParams{
+			AbandonedAt: now,
+			LastError:   pgtype.Text{String: outcome.Err.Error(), Valid: true},
+			MessageID:   msg.ID,
+		})
+
+	default:
+		return r.queries.RetryOutboxMessage(ctx, tx, database.RetryOutboxMessageParams{
+			AvailableAt: pgtype.Timestamptz{Time: outcome.RetryAt, Valid: true},
+			LastError:   pgtype.Text{String: outcome.Err.Error(), Valid: true},
+			MessageID:   msg.ID,
+		})
+	}
+}
+
+func (r *PGXRepository) DeleteMessagesBefore(ctx context.Context, createdBefore time.Time) (numDeleted int64, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		numDeleted, err = r.queries.DeleteOutboxMessagesBefore(ctx, tx, pgtype.Timestamptz{Time: createdBefore, Valid: true})
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error deleting outbox messages", zap.Error(err))
+		return 0, err
+	}
+
+	return numDeleted, nil
+}
@@ -0,0 +1,68 @@
+-- name: AppendOutboxMessage :exec
+INSERT INTO notes.outbox (
+  topic,
+  payload,
+  created_at,
+  available_at
+) VALUES (
+  sqlc.arg(topic),
+  sqlc.arg(payload),
+  sqlc.arg(created_at),
+  sqlc.arg(created_at)
+)
+;
+
+-- name: ListNoteTagIDs :many
+SELECT
+  tag_id
+FROM notes.note_tags
+WHERE note_id = sqlc.arg(note_id)
+;
+
+-- name: ClaimOutboxMessages :many
+SELECT
+  message_id,
+  topic,
+  payload,
+  created_at,
+  attempts
+FROM notes.outbox
+WHERE published_at IS NULL
+  AND abandoned_at IS NULL
+  AND available_at <= sqlc.arg(now)
+  -- messages about topics nothing subscribes to yet are left until something does
+  AND topic = ANY(sqlc.arg(topics)::text[])
+ORDER BY message_id ASC
+LIMIT sqlc.arg(page_size)
+FOR UPDATE SKIP LOCKED
+;
+
+-- name: PublishOutboxMessage :exec
+UPDATE notes.outbox
+SET published_at = sqlc.arg(published_at),
+    attempts     = attempts + 1,
+    last_error   = NULL
+WHERE message_id = sqlc.arg(message_id)
+;
+
+-- name: RetryOutboxMessage :exec
+UPDATE notes.outbox
+SET available_at = sqlc.arg(available_at),
+    attempts     = attempts + 1,
+    last_error   = sqlc.arg(last_error)
+WHERE message_id = sqlc.arg(message_id)
+;
+
+-- name: AbandonOutboxMessage :exec
+UPDATE notes.outbox
+SET abandoned_at = sqlc.arg(abandoned_at),
+    attempts     = attempts + 1,
+    last_error   = sqlc.arg(last_error)
+WHERE message_id = sqlc.arg(message_id)
+;
+
+-- name: DeleteOutboxMessagesBefore :execrows
+DELETE FROM notes.outbox
+WHERE created_at < sqlc.arg(created_before)
+  AND (published_at IS NOT NULL OR abandoned_at IS NOT NULL)
+;
@@ -0,0 +1,152 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+const (
+	relayInterval  = time.Second
+	relayBatchSize = 100
+
+	// maxAttempts is how many times publishing a message is attempted before it is given up on.
+	maxAttempts   = 10
+	minRetryDelay = 5 * time.Second
+	maxRetryDelay = 15 * time.Minute
+
+	pruneInterval = time.Hour
+	// messageRetention is how long messages are kept after being published, or given up on, for troubleshooting.
+	messageRetention = 7 * 24 * time.Hour
+)
+
+// Subscriber handles the messages published about a topic.
+//
+// A message may be handled more than once, such as when publishing it to another subscriber of the same topic fails,
+// or the server stops part way through publishing it, so handling one must be idempotent.
+type Subscriber interface {
+	HandleMessage(ctx context.Context, msg *Message) error
+}
+
+// SubscriberFunc adapts a function to a [Subscriber].
+type SubscriberFunc func(ctx context.Context, msg *Message) error
+
+func (f SubscriberFunc) HandleMessage(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// Relay publishes messages from the outbox to the subscribers of their topics, roughly in the order they were
+// written. A message whose publishing fails is retried with exponential backoff, without holding up those after it.
+// Messages about a topic that nothing subscribes to are kept until something does.
+//
+// When several servers are running, each message is only published by one of them.
+type Relay struct {
+	repo        Repository
+	subscribers map[string][]Subscriber
+	topics      []string
+}
+
+func NewRelay(injector do.Injector) (*Relay, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Relay{
+		repo:        repo,
+		subscribers: make(map[string][]Subscriber),
+	}, nil
+}
+
+// Subscribe registers subscriber to be sent the messages about topic.
+// It must be called before the relay is run.
+func (r *Relay) Subscribe(topic string, subscriber Subscriber) {
+	if _, ok := r.subscribers[topic]; !ok {
+		r.topics = append(r.topics, topic)
+	}
+
+	r.subscribers[topic] = append(r.subscribers[topic], subscriber)
+}
+
+// Run relays messages, and prunes old ones, periodically until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(relayInterval)
+	defer ticker.Stop()
+
+	pruneTicker := time.NewTicker(pruneInterval)
+	defer pruneTicker.Stop()
+
+	for {
+		if err := r.RelayAvailable(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Error(ctx, "error relaying outbox messages", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-ticker.C:
+
+		case <-pruneTicker.C:
+			numDeleted, err := r.repo.DeleteMessagesBefore(ctx, time.Now().Add(-messageRetention))
+			if err != nil && ctx.Err() == nil {
+				log.Error(ctx, "error pruning outbox messages", zap.Error(err))
+			} else if numDeleted > 0 {
+				log.Debug(ctx, "pruned outbox messages", zap.Int64("count", numDeleted))
+			}
+		}
+	}
+}
+
+// RelayAvailable publishes every message available by now.
+func (r *Relay) RelayAvailable(ctx context.Context, now time.Time) error {
+	if len(r.topics) == 0 {
+		return nil
+	}
+
+	for {
+		numClaimed, err := r.repo.ProcessMessages(ctx, r.topics, now, relayBatchSize, r.publish)
+		if err != nil {
+			return err
+		}
+
+		if numClaimed < relayBatchSize {
+			return nil
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, msg *Message) Outcome {
+	for _, subscriber := range r.subscribers[msg.Topic] {
+		if err := subscriber.HandleMessage(ctx, msg); err != nil {
+			log.Warn(ctx, "error publishing outbox message",
+				zap.Int64("message_id", msg.ID),
+				zap.String("topic", msg.Topic),
+				zap.Int("attempts", msg.Attempts+1),
+				zap.Error(err),
+			)
+
+			outcome := Outcome{Err: err}
+			if msg.Attempts+1 < maxAttempts {
+				outcome.RetryAt = time.Now().Add(retryDelay(msg.Attempts + 1))
+			}
+
+			return outcome
+		}
+	}
+
+	return Outcome{}
+}
+
+// retryDelay returns how long to wait after a message's attempt'th failure before trying again.
+func retryDelay(attempt int) time.Duration {
+	if attempt >= 32 {
+		return maxRetryDelay
+	}
+
+	return min(minRetryDelay<<(attempt-1), maxRetryDelay)
+}
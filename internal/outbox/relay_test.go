@@ -0,0 +1,102 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepository processes a fixed set of messages, remembering the outcome of each.
+type memoryRepository struct {
+	messages []Message
+	outcomes map[int64]Outcome
+}
+
+func (r *memoryRepository) ProcessMessages(ctx context.Context, topics []string, now time.Time, limit int, publish func(ctx context.Context, msg *Message) Outcome) (int, error) {
+	var (
+		n    int
+		kept []Message
+	)
+
+	for i := range r.messages {
+		if n == limit || !slices.Contains(topics, r.messages[i].Topic) {
+			kept = append(kept, r.messages[i])
+			continue
+		}
+
+		r.outcomes[r.messages[i].ID] = publish(ctx, &r.messages[i])
+		n++
+	}
+
+	r.messages = kept
+	return n, nil
+}
+
+func (r *memoryRepository) DeleteMessagesBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestRelay_RelayAvailable(t *testing.T) {
+	repo := &memoryRepository{
+		messages: []Message{
+			{ID: 1, Topic: "note.saved"},
+			{ID: 2, Topic: "tag.saved"},
+			{ID: 3, Topic: "note.saved", Attempts: 2},
+			{ID: 4, Topic: "note.saved", Attempts: maxAttempts - 1},
+			{ID: 5, Topic: "unsubscribed"},
+		},
+		outcomes: make(map[int64]Outcome),
+	}
+
+	relay := &Relay{
+		repo:        repo,
+		subscribers: make(map[string][]Subscriber),
+	}
+
+	var notes, tags []int64
+	errFailed := errors.New("failed")
+
+	relay.Subscribe("note.saved", SubscriberFunc(func(ctx context.Context, msg *Message) error {
+		notes = append(notes, msg.ID)
+		if msg.ID >= 3 {
+			return errFailed
+		}
+		return nil
+	}))
+	relay.Subscribe("tag.saved", SubscriberFunc(func(ctx context.Context, msg *Message) error {
+		tags = append(tags, msg.ID)
+		return nil
+	}))
+
+	err := relay.RelayAvailable(context.Background(), time.Now())
+	require.NoError(t, err)
+
+	assert.Equal(t, []int64{1, 3, 4}, notes)
+	assert.Equal(t, []int64{2}, tags)
+
+	assert.Equal(t, Outcome{}, repo.outcomes[1])
+	assert.Equal(t, Outcome{}, repo.outcomes[2])
+
+	assert.ErrorIs(t, repo.outcomes[3].Err, errFailed)
+	assert.False(t, repo.outcomes[3].RetryAt.IsZero())
+
+	// out of attempts
+	assert.ErrorIs(t, repo.outcomes[4].Err, errFailed)
+	assert.True(t, repo.outcomes[4].RetryAt.IsZero())
+
+	// kept until something subscribes to it
+	assert.NotContains(t, repo.outcomes, int64(5))
+	assert.Equal(t, []Message{{ID: 5, Topic: "unsubscribed"}}, repo.messages)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, minRetryDelay, retryDelay(1))
+	assert.Equal(t, 4*minRetryDelay, retryDelay(3))
+	assert.Equal(t, maxRetryDelay, retryDelay(maxAttempts))
+	assert.Equal(t, maxRetryDelay, retryDelay(64))
+}
@@ -0,0 +1,15 @@
+package outbox
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	// ProcessMessages claims up to limit messages about one of topics available by now, in the order they were
+	// written, and records the outcome of calling publish with each. It returns how many messages were claimed.
+	// Messages claimed by one caller are skipped by any others until the outcomes have been recorded.
+	ProcessMessages(ctx context.Context, topics []string, now time.Time, limit int, publish func(ctx context.Context, msg *Message) Outcome) (int, error)
+	// DeleteMessagesBefore deletes messages written before createdBefore that have been published or given up on.
+	DeleteMessagesBefore(ctx context.Context, createdBefore time.Time) (int64, error)
+}
@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/events"
+)
+
+// Writer appends messages to the outbox as part of the transaction making the change they describe,
+// so that a message is published if and only if its change is committed.
+//
+// It is also an [events.Observer], writing a message with an [EventPayload] for every event.
+type Writer struct {
+	queries *database.Queries
+}
+
+func NewWriter(injector do.Injector) (*Writer, error) {
+	return &Writer{
+		queries: database.New(),
+	}, nil
+}
+
+// Write appends a message about topic to the outbox in tx. payload is encoded as JSON.
+func (w *Writer) Write(ctx context.Context, tx pgx.Tx, topic string, payload any) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return w.queries.AppendOutboxMessage(ctx, tx, database.AppendOutboxMessageParams{
+		Topic:     topic,
+		Payload:   encoded,
+		CreatedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+}
+
+func (w *Writer) ObserveEvent(ctx context.Context, tx pgx.Tx, event *events.Event) error {
+	payload := EventPayloadFromEvent(event)
+
+	switch {
+	case event.NoteID.Valid:
+		tagIDs, err := w.queries.ListNoteTagIDs(ctx, tx, event.NoteID.UUID)
+		if err != nil {
+			return err
+		}
+
+		payload.TagIDs = tagIDs
+
+	case event.TagID.Valid:
+		payload.TagIDs = []uuid.UUID{event.TagID.UUID}
+	}
+
+	return w.Write(ctx, tx, string(event.Kind), &payload)
+}
@@ -0,0 +1,130 @@
+package apiv1
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/publishing"
+)
+
+// pageCacheControl lets anyone cache a published note for a minute before checking that it is unchanged, so it may
+// still be served for that long after it is unpublished.
+const pageCacheControl = "public, max-age=60"
+
+type Service interface {
+	Publish(ctx context.Context, noteID uuid.UUID, slug string) (*publishing.Publication, error)
+	Unpublish(ctx context.Context, noteID uuid.UUID) error
+	GetPublication(ctx context.Context, noteID uuid.UUID) (*publishing.Publication, error)
+	GetPage(ctx context.Context, slug string) (*publishing.Page, error)
+}
+
+// PutPublication publishes a note, or changes the slug it is published with.
+func PutPublication(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[WritablePublication](w, r)
+		if !ok {
+			return
+		}
+
+		slug, err := body.ToDomain()
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		publication, err := svc.Publish(r.Context(), noteID, slug)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := PublicationFromDomain(publication)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+func GetPublication(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		publication, err := svc.GetPublication(r.Context(), noteID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := PublicationFromDomain(publication)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+// DeletePublication unpublishes a note.
+func DeletePublication(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		if err := svc.Unpublish(r.Context(), noteID); err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetPage serves a published note as an HTML page, to anyone. Conditional requests are answered with its ETag.
+func GetPage(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		slug := r.PathValue("slug")
+		if publishing.ValidateSlug(slug) != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusNotFound, "page does not exist"))
+			return
+		}
+
+		page, err := svc.GetPage(r.Context(), slug)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		doc, etag, err := renderPage(page)
+		if err != nil {
+			log.Error(r.Context(), "error rendering page", zap.String("slug", slug), zap.Error(err))
+			apiv1.WriteError(r.Context(), w, apiv1.StatusError(http.StatusInternalServerError))
+			return
+		}
+
+		header := w.Header()
+		header.Set("Content-Type", "text/html; charset=utf-8")
+		header.Set("Cache-Control", pageCacheControl)
+		header.Set("ETag", etag)
+		header.Set("Content-Security-Policy", contentSecurityPolicy)
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", "no-referrer")
+
+		// no Last-Modified: the page also changes when the notes it links to are published or unpublished, which
+		// the note's own update time doesn't reflect
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(doc))
+	}
+}
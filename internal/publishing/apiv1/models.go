@@ -0,0 +1,52 @@
+package apiv1
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	notesapiv1 "github.com/dabbertorres/notes/internal/notes/apiv1"
+	"github.com/dabbertorres/notes/internal/publishing"
+)
+
+type Publication struct {
+	NoteID string `json:"note_id"`
+	Slug   string `json:"slug"`
+	// Path is where the note is served, relative to the server's root.
+	Path        string           `json:"path"`
+	PublishedAt string           `json:"published_at"`
+	PublishedBy *notesapiv1.User `json:"published_by,omitempty"`
+}
+
+func PublicationFromDomain(domain *publishing.Publication) (p Publication) {
+	p.NoteID = domain.NoteID.String()
+	p.Slug = domain.Slug
+	p.Path = publishing.Path(domain.Slug)
+	p.PublishedAt = domain.PublishedAt.Format(time.RFC3339)
+
+	if domain.PublishedBy.ID != uuid.Nil {
+		publishedBy := notesapiv1.UserFromDomain(domain.PublishedBy)
+		p.PublishedBy = &publishedBy
+	}
+
+	return p
+}
+
+// WritablePublication contains only the subset of fields on [Publication] that an API user can modify.
+type WritablePublication struct {
+	// Slug is derived from the note's title if it is left out when first publishing a note.
+	Slug string `json:"slug"`
+}
+
+func (p *WritablePublication) ToDomain() (slug string, err error) {
+	if p.Slug == "" {
+		return "", nil
+	}
+
+	if err := publishing.ValidateSlug(p.Slug); err != nil {
+		return "", &apiv1.InvalidFieldError{Field: ".slug", Err: err.Error()}
+	}
+
+	return p.Slug, nil
+}
@@ -0,0 +1,55 @@
+package apiv1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+
+	"github.com/dabbertorres/notes/internal/publishing"
+)
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { max-width: 48rem; margin: 2rem auto; padding: 0 1rem; font-family: system-ui, sans-serif; line-height: 1.5; }
+pre { overflow-x: auto; }
+.wikilink-dangling { color: inherit; }
+</style>
+</head>
+<body>
+<article>
+<h1>{{.Title}}</h1>
+{{.Body}}
+</article>
+<footer><small>Updated <time datetime="{{.UpdatedAt}}">{{.UpdatedDate}}</time></small></footer>
+</body>
+</html>
+`))
+
+// contentSecurityPolicy only allows the page's own styles, and images from elsewhere; notes can't run scripts.
+const contentSecurityPolicy = "default-src 'none'; img-src https: data:; style-src 'unsafe-inline'; base-uri 'none'; form-action 'none'"
+
+// renderPage renders page as a complete HTML document, returning it with a strong ETag of its contents.
+func renderPage(page *publishing.Page) (doc []byte, etag string, err error) {
+	var buf bytes.Buffer
+	err = pageTemplate.Execute(&buf, map[string]any{
+		"Title": page.Title,
+		// the body is sanitized when it is rendered
+		"Body":        template.HTML(page.HTML),
+		"UpdatedAt":   page.UpdatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		"UpdatedDate": page.UpdatedAt.UTC().Format("January 2, 2006"),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	// the document depends on the note, and on which of the notes it links to are published, so hashing it is
+	// simpler than tracking everything it depends on
+	sum := sha256.Sum256(buf.Bytes())
+	return buf.Bytes(), `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
@@ -0,0 +1,42 @@
+package publishing
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// Publication is a note being served publicly at [Path] of its Slug.
+type Publication struct {
+	NoteID      uuid.UUID
+	Slug        string
+	PublishedAt time.Time
+	PublishedBy users.User
+}
+
+// PublishedNote is a published note, as it is stored.
+type PublishedNote struct {
+	Publication
+	Title     string
+	Body      string
+	UpdatedAt time.Time
+	// Links maps the (lower case) references of the note's links to the slugs of the published notes they link to.
+	// Links to notes that aren't published are left out.
+	Links map[string]string
+}
+
+// Page is a published note, rendered for the public.
+type Page struct {
+	Publication
+	Title string
+	// HTML is the note's rendered body. Links to other notes only resolve to those that are also published.
+	HTML      string
+	UpdatedAt time.Time
+}
+
+// Path is where the note published with slug is served.
+func Path(slug string) string {
+	return "/p/" + slug
+}
@@ -0,0 +1,192 @@
+package publishing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/audit"
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+var (
+	errNotPublished = apiv1.NewError(http.StatusNotFound, "note is not published")
+	errSlugTaken    = apiv1.NewError(http.StatusConflict, "slug is already taken by another note")
+)
+
+type PGXRepository struct {
+	db      database.Database
+	queries *database.Queries
+	audit   *audit.Recorder
+}
+
+func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	auditor, err := do.Invoke[*audit.Recorder](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGXRepository{
+		db:      db,
+		queries: database.New(),
+		audit:   auditor,
+	}, nil
+}
+
+func (r *PGXRepository) GetPublication(ctx context.Context, noteID uuid.UUID) (*Publication, error) {
+	var row database.NotesNotePublication
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		row, err = r.queries.GetPublication(ctx, tx, noteID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotPublished
+		}
+
+		log.Error(ctx, "error fetching publication", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	publication := publicationFromRow(row)
+	return &publication, nil
+}
+
+func (r *PGXRepository) SavePublication(ctx context.Context, publication *Publication) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var before *auditedPublication
+		switch row, err := r.queries.GetPublication(ctx, tx, publication.NoteID); {
+		case err == nil:
+			before = &auditedPublication{Slug: row.Slug}
+		case !errors.Is(err, pgx.ErrNoRows):
+			return err
+		}
+
+		row, err := r.queries.SavePublication(ctx, tx, database.SavePublicationParams{
+			NoteID:      publication.NoteID,
+			Slug:        publication.Slug,
+			PublishedAt: pgtype.Timestamptz{Time: publication.PublishedAt, Valid: true},
+			PublishedBy: uuid.NullUUID{UUID: publication.PublishedBy.ID, Valid: publication.PublishedBy.ID != uuid.Nil},
+		})
+		if err != nil {
+			return err
+		}
+
+		*publication = publicationFromRow(row)
+
+		// republishing with the same slug changes nothing
+		if before != nil && before.Slug == row.Slug {
+			return nil
+		}
+
+		after := auditedPublication{Slug: row.Slug}
+		return r.audit.Record(ctx, tx, audit.ActionNotePublished, audit.NoteTarget(row.NoteID), before, &after)
+	})
+	if err != nil {
+		if pgErr := (*pgconn.PgError)(nil); errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return errSlugTaken
+		}
+
+		log.Error(ctx, "error saving publication", zap.Stringer("note_id", publication.NoteID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) DeletePublication(ctx context.Context, noteID uuid.UUID) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.GetPublication(ctx, tx, noteID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := r.queries.DeletePublication(ctx, tx, noteID); err != nil {
+			return err
+		}
+
+		before := auditedPublication{Slug: row.Slug}
+		return r.audit.Record(ctx, tx, audit.ActionNoteUnpublished, audit.NoteTarget(noteID), &before, nil)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errNotPublished
+		}
+
+		log.Error(ctx, "error deleting publication", zap.Stringer("note_id", noteID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) GetPublishedNote(ctx context.Context, slug string) (note *PublishedNote, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.GetPublishedNote(ctx, tx, slug)
+		if err != nil {
+			return err
+		}
+
+		links, err := r.queries.GetPublishedNoteLinks(ctx, tx, row.NoteID)
+		if err != nil {
+			return err
+		}
+
+		note = &PublishedNote{
+			Publication: Publication{
+				NoteID:      row.NoteID,
+				Slug:        row.Slug,
+				PublishedAt: row.PublishedAt.Time,
+			},
+			Title:     row.Title,
+			Body:      row.Body,
+			UpdatedAt: row.UpdatedAt.Time,
+			Links:     make(map[string]string, len(links)),
+		}
+
+		for _, link := range links {
+			note.Links[strings.ToLower(link.Reference)] = link.Slug
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apiv1.NewError(http.StatusNotFound, "page does not exist")
+		}
+
+		log.Error(ctx, "error fetching published note", zap.String("slug", slug), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return note, nil
+}
+
+type auditedPublication struct {
+	Slug string `json:"slug"`
+}
+
+func publicationFromRow(row database.NotesNotePublication) Publication {
+	return Publication{
+		NoteID:      row.NoteID,
+		Slug:        row.Slug,
+		PublishedAt: row.PublishedAt.Time,
+		PublishedBy: users.User{ID: row.PublishedBy.UUID},
+	}
+}
@@ -0,0 +1,9 @@
+// Package publishing serves notes that their owners have published at public URLs, without authentication.
+package publishing
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewPGXRepository),
+	do.Lazy(NewService),
+)
@@ -0,0 +1,60 @@
+-- name: GetPublication :one
+SELECT
+  note_id,
+  slug,
+  published_at,
+  published_by
+FROM notes.note_publications
+WHERE note_id = sqlc.arg(note_id)
+;
+
+-- name: SavePublication :one
+INSERT INTO notes.note_publications (
+  note_id,
+  slug,
+  published_at,
+  published_by
+) VALUES (
+  sqlc.arg(note_id),
+  sqlc.arg(slug),
+  sqlc.arg(published_at),
+  sqlc.arg(published_by)
+)
+-- republishing only changes the slug
+ON CONFLICT (note_id) DO UPDATE
+SET slug = excluded.slug
+RETURNING
+  note_id,
+  slug,
+  published_at,
+  published_by
+;
+
+-- name: DeletePublication :execrows
+DELETE FROM notes.note_publications
+WHERE note_id = sqlc.arg(note_id)
+;
+
+-- name: GetPublishedNote :one
+SELECT
+  note_publications.note_id,
+  note_publications.slug,
+  note_publications.published_at,
+  notes.updated_at,
+  notes.title,
+  notes.body
+FROM notes.note_publications
+JOIN notes.notes ON
+  notes.note_id = note_publications.note_id
+WHERE note_publications.slug = sqlc.arg(slug)
+;
+
+-- name: GetPublishedNoteLinks :many
+SELECT
+  note_links.reference,
+  note_publications.slug
+FROM notes.note_links
+JOIN notes.note_publications ON
+  note_publications.note_id = note_links.target_note_id
+WHERE note_links.source_note_id = sqlc.arg(source_note_id)
+;
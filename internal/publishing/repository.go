@@ -0,0 +1,16 @@
+package publishing
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	GetPublication(ctx context.Context, noteID uuid.UUID) (*Publication, error)
+	// SavePublication publishes a note, or changes the slug of one that already is.
+	// publication is updated with what was saved.
+	SavePublication(ctx context.Context, publication *Publication) error
+	DeletePublication(ctx context.Context, noteID uuid.UUID) error
+	GetPublishedNote(ctx context.Context, slug string) (*PublishedNote, error)
+}
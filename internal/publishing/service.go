@@ -0,0 +1,158 @@
+package publishing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/markdown"
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/scope"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// NoteReader reports a user's access to a note, and retrieves notes to derive slugs from.
+// Only a note's owners can publish or unpublish it, and anyone who can view it can see where it is published.
+type NoteReader interface {
+	GetUsersNoteAccess(ctx context.Context, noteID, userID uuid.UUID) (users.AccessLevel, error)
+	GetNote(ctx context.Context, noteID, asUserID uuid.UUID) (*notes.Note, error)
+}
+
+type Service struct {
+	repo  Repository
+	notes NoteReader
+}
+
+func NewService(injector do.Injector) (*Service, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := do.InvokeAs[NoteReader](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		repo:  repo,
+		notes: notes,
+	}, nil
+}
+
+// Publish serves a note publicly at the [Path] of slug, which must already be valid.
+// If slug is empty, a published note keeps its slug, and an unpublished one gets one derived from its title.
+func (s *Service) Publish(ctx context.Context, noteID uuid.UUID, slug string) (*Publication, error) {
+	userID := scope.MustUserID(ctx)
+
+	if err := s.checkAccess(ctx, noteID, userID, users.AccessLevelOwner); err != nil {
+		return nil, err
+	}
+
+	if slug == "" {
+		current, err := s.repo.GetPublication(ctx, noteID)
+		if err == nil {
+			return current, nil
+		}
+
+		if !errors.Is(err, errNotPublished) {
+			return nil, err
+		}
+
+		note, err := s.notes.GetNote(ctx, noteID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if slug = Slugify(note.Title); slug == "" {
+			return nil, apiv1.NewValidationFailureError(&apiv1.InvalidFieldError{
+				Field: ".slug",
+				Err:   "is required, since the note's title has no letters or digits to derive one from",
+			})
+		}
+	}
+
+	publication := &Publication{
+		NoteID:      noteID,
+		Slug:        slug,
+		PublishedAt: time.Now().UTC(),
+		PublishedBy: users.User{ID: userID},
+	}
+
+	if err := s.repo.SavePublication(ctx, publication); err != nil {
+		return nil, err
+	}
+
+	return publication, nil
+}
+
+// Unpublish stops serving a note publicly. Its slug is then free to be used by any note.
+func (s *Service) Unpublish(ctx context.Context, noteID uuid.UUID) error {
+	userID := scope.MustUserID(ctx)
+
+	if err := s.checkAccess(ctx, noteID, userID, users.AccessLevelOwner); err != nil {
+		return err
+	}
+
+	return s.repo.DeletePublication(ctx, noteID)
+}
+
+func (s *Service) GetPublication(ctx context.Context, noteID uuid.UUID) (*Publication, error) {
+	userID := scope.MustUserID(ctx)
+
+	if err := s.checkAccess(ctx, noteID, userID, users.AccessLevelViewer); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetPublication(ctx, noteID)
+}
+
+// GetPage renders the note published with slug. It is public, so it does not require a user.
+func (s *Service) GetPage(ctx context.Context, slug string) (*Page, error) {
+	note, err := s.repo.GetPublishedNote(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := markdown.Render(note.Body, func(reference string) (string, bool) {
+		target, ok := note.Links[strings.ToLower(reference)]
+		if !ok {
+			return "", false
+		}
+
+		return Path(target), true
+	})
+	if err != nil {
+		log.Error(ctx, "error rendering published note", zap.Stringer("note_id", note.NoteID), zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	return &Page{
+		Publication: note.Publication,
+		Title:       note.Title,
+		HTML:        html,
+		UpdatedAt:   note.UpdatedAt,
+	}, nil
+}
+
+func (s *Service) checkAccess(ctx context.Context, noteID, userID uuid.UUID, required users.AccessLevel) error {
+	access, err := s.notes.GetUsersNoteAccess(ctx, noteID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", noteID), zap.Error(err))
+		return apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < required {
+		return apiv1.StatusError(http.StatusForbidden)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package publishing
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// MaxSlugLength is the longest slug, in bytes, that a note can be published with.
+const MaxSlugLength = 100
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ValidateSlug checks that slug is lower case letters and digits, in words separated by single hyphens.
+func ValidateSlug(slug string) error {
+	switch {
+	case slug == "":
+		return errors.New("must not be empty")
+	case len(slug) > MaxSlugLength:
+		return errors.New("must be at most 100 characters")
+	case !slugPattern.MatchString(slug):
+		return errors.New("must be lower case letters and digits, separated by single hyphens")
+	}
+
+	return nil
+}
+
+// Slugify derives a slug from title, keeping its ASCII letters and digits, and separating the runs of them with
+// hyphens. If there are none, the empty string is returned.
+func Slugify(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+	})
+
+	slug := strings.Join(words, "-")
+	if len(slug) <= MaxSlugLength {
+		return slug
+	}
+
+	// cut at the last whole word that fits
+	slug = slug[:MaxSlugLength+1]
+	if i := strings.LastIndexByte(slug, '-'); i > 0 {
+		return slug[:i]
+	}
+
+	return slug[:MaxSlugLength]
+}
@@ -0,0 +1,63 @@
+package publishing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{title: "Runbook", want: "runbook"},
+		{title: "  On-call: Paging & Escalation  ", want: "on-call-paging-escalation"},
+		{title: "Déjà vu 2", want: "d-j-vu-2"},
+		{title: "---", want: ""},
+		{title: "日本語", want: ""},
+		{title: strings.Repeat("word ", 30), want: strings.TrimSuffix(strings.Repeat("word-", 20), "-")},
+		{title: strings.Repeat("x", 120), want: strings.Repeat("x", 100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			got := Slugify(tt.title)
+			assert.Equal(t, tt.want, got)
+
+			if got != "" {
+				assert.NoError(t, ValidateSlug(got))
+			}
+		})
+	}
+}
+
+func TestValidateSlug(t *testing.T) {
+	tests := []struct {
+		slug  string
+		valid bool
+	}{
+		{slug: "runbook", valid: true},
+		{slug: "on-call-2", valid: true},
+		{slug: "", valid: false},
+		{slug: "Runbook", valid: false},
+		{slug: "-runbook", valid: false},
+		{slug: "run--book", valid: false},
+		{slug: "runbook-", valid: false},
+		{slug: "run book", valid: false},
+		{slug: "run/book", valid: false},
+		{slug: strings.Repeat("x", 101), valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.slug, func(t *testing.T) {
+			err := ValidateSlug(tt.slug)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
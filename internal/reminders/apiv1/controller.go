@@ -0,0 +1,151 @@
+package apiv1
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/reminders"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+type Service interface {
+	SetReminder(ctx context.Context, noteID uuid.UUID, remindAt time.Time, recurrence string) (*reminders.Reminder, error)
+	GetReminder(ctx context.Context, noteID uuid.UUID) (*reminders.Reminder, error)
+	DeleteReminder(ctx context.Context, noteID uuid.UUID) error
+	ListReminders(ctx context.Context, params reminders.ReminderListParams, pageSize int) (results []reminders.Reminder, next *reminders.ReminderListParams, err error)
+}
+
+// PutReminder sets the requesting user's reminder about a note.
+func PutReminder(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[WritableReminder](w, r)
+		if !ok {
+			return
+		}
+
+		remindAt, recurrence, err := body.ToDomain()
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		reminder, err := svc.SetReminder(r.Context(), noteID, remindAt, recurrence)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := ReminderFromDomain(reminder)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+func GetReminder(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		reminder, err := svc.GetReminder(r.Context(), noteID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := ReminderFromDomain(reminder)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+func DeleteReminder(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid note id"))
+			return
+		}
+
+		if err := svc.DeleteReminder(r.Context(), noteID); err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ListReminders lists the requesting user's reminders.
+func ListReminders(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		paging, err := parseListRemindersParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		params := reminders.ReminderListParams{
+			LastNoteID: paging.Data.LastNoteID,
+		}
+		results, next, err := svc.ListReminders(r.Context(), params, paging.PageSize)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		page := apiv1.Page[Reminder, *ListRemindersPageTokenData]{
+			NextPageToken: nil,
+			Items: util.MapSlice(results, func(reminder reminders.Reminder) Reminder {
+				return ReminderFromDomain(&reminder)
+			}),
+		}
+
+		if next != nil {
+			page.NextPageToken = &apiv1.PageToken[*ListRemindersPageTokenData]{
+				Data: &ListRemindersPageTokenData{
+					LastNoteID: next.LastNoteID,
+				},
+				PageSize: paging.PageSize,
+			}
+		}
+
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, page)
+	}
+}
+
+func parseListRemindersParams(r *http.Request) (token apiv1.PageToken[*ListRemindersPageTokenData], err error) {
+	rawPageToken := r.FormValue("next_page_token")
+	if rawPageToken != "" {
+		pageToken, err := apiv1.ParsePageToken[*ListRemindersPageTokenData](rawPageToken, 100, 100)
+		if err != nil {
+			return token, apiv1.NewValidationFailureError(err)
+		}
+
+		token = pageToken
+	} else {
+		token.Data = &ListRemindersPageTokenData{}
+
+		if size := r.FormValue("page_size"); size != "" {
+			pageSize, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return token, apiv1.NewValidationFailureError(err)
+			}
+
+			token.PageSize = int(pageSize)
+		}
+	}
+
+	return token, nil
+}
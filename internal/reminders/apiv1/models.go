@@ -0,0 +1,86 @@
+package apiv1
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/reminders"
+)
+
+type Reminder struct {
+	NoteID     string `json:"note_id"`
+	RemindAt   string `json:"remind_at"`
+	Recurrence string `json:"recurrence,omitempty"`
+	// NextAt is omitted once the reminder has no more occurrences.
+	NextAt    string `json:"next_at,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+func ReminderFromDomain(domain *reminders.Reminder) (r Reminder) {
+	r.NoteID = domain.NoteID.String()
+	r.RemindAt = domain.RemindAt.Format(time.RFC3339)
+	r.Recurrence = domain.Recurrence
+	r.CreatedAt = domain.CreatedAt.Format(time.RFC3339)
+	r.UpdatedAt = domain.UpdatedAt.Format(time.RFC3339)
+
+	if !domain.NextAt.IsZero() {
+		r.NextAt = domain.NextAt.Format(time.RFC3339)
+	}
+
+	return r
+}
+
+// WritableReminder contains only the subset of fields on [Reminder] that an API user can modify.
+type WritableReminder struct {
+	RemindAt string `json:"remind_at"`
+	// Recurrence is an RFC 5545 RRULE, e.g. "FREQ=WEEKLY;BYDAY=MO".
+	Recurrence string `json:"recurrence,omitempty"`
+}
+
+func (r *WritableReminder) ToDomain() (remindAt time.Time, recurrence string, err error) {
+	var errs []error
+
+	remindAt = apiv1.Validate(".remind_at", r.RemindAt, &errs, apiv1.ParseRFC3339)
+
+	if len(errs) != 0 {
+		return time.Time{}, "", errors.Join(errs...)
+	}
+
+	return remindAt, r.Recurrence, nil
+}
+
+type ListRemindersPageTokenData struct {
+	LastNoteID uuid.NullUUID
+}
+
+func (d *ListRemindersPageTokenData) EncodePager() ([][]byte, error) {
+	var out [1][]byte
+
+	if d.LastNoteID.Valid {
+		out[0] = []byte(d.LastNoteID.UUID.String())
+	}
+
+	return out[:], nil
+}
+
+func (d *ListRemindersPageTokenData) DecodePager(data [][]byte) error {
+	if len(data) != 1 {
+		return errors.New("invalid page token format (incorrect number of parts)")
+	}
+
+	if len(data[0]) != 0 {
+		lastID, err := uuid.ParseBytes(data[0])
+		if err != nil {
+			return err
+		}
+
+		d.LastNoteID.UUID = lastID
+		d.LastNoteID.Valid = true
+	}
+
+	return nil
+}
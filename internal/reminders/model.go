@@ -0,0 +1,100 @@
+package reminders
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/teambition/rrule-go"
+)
+
+var ErrRecurrenceTooFrequent = errors.New("recurrence must not be more frequent than hourly")
+
+// Reminder is a user's reminder about a note, which occurs at RemindAt, and then according to Recurrence, if set.
+type Reminder struct {
+	NoteID   uuid.UUID
+	UserID   uuid.UUID
+	RemindAt time.Time
+	// Recurrence is an RFC 5545 RRULE (e.g. "FREQ=WEEKLY;BYDAY=MO,WE"), or empty if the reminder only occurs once.
+	// Occurrences are calculated in UTC.
+	Recurrence string
+	// NextAt is the next occurrence to be delivered, or the zero value once there are no more.
+	NextAt    time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NextAfter finds the first occurrence of the reminder after t, or the zero value if there are none.
+func (r *Reminder) NextAfter(t time.Time) (time.Time, error) {
+	if r.Recurrence == "" {
+		if r.RemindAt.After(t) {
+			return r.RemindAt, nil
+		}
+
+		return time.Time{}, nil
+	}
+
+	rule, err := parseRecurrence(r.Recurrence, r.RemindAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return rule.After(t, false), nil
+}
+
+// NormalizeRecurrence validates an RRULE, and formats it consistently.
+func NormalizeRecurrence(recurrence string) (string, error) {
+	opts, err := parseOptions(recurrence)
+	if err != nil {
+		return "", err
+	}
+
+	return opts.RRuleString(), nil
+}
+
+func parseRecurrence(recurrence string, start time.Time) (*rrule.RRule, error) {
+	opts, err := parseOptions(recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Dtstart = start.UTC()
+	return rrule.NewRRule(*opts)
+}
+
+func parseOptions(recurrence string) (*rrule.ROption, error) {
+	recurrence = strings.TrimSpace(recurrence)
+	if len(recurrence) >= len("RRULE:") && strings.EqualFold(recurrence[:len("RRULE:")], "RRULE:") {
+		recurrence = recurrence[len("RRULE:"):]
+	}
+
+	// the start of the recurrence is always when the reminder first occurs
+	if strings.ContainsAny(recurrence, "\r\n") {
+		return nil, errors.New("recurrence must be a single RRULE")
+	}
+
+	opts, err := rrule.StrToROption(recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Freq == rrule.MINUTELY || opts.Freq == rrule.SECONDLY {
+		return nil, ErrRecurrenceTooFrequent
+	}
+
+	return opts, nil
+}
+
+type ReminderListParams struct {
+	LastNoteID uuid.NullUUID
+}
+
+// Notification is a due occurrence of a reminder.
+type Notification struct {
+	NoteID    uuid.UUID
+	NoteTitle string
+	UserID    uuid.UUID
+	// OccurrenceAt is when the reminder was due.
+	OccurrenceAt time.Time
+}
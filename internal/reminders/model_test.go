@@ -0,0 +1,80 @@
+package reminders
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReminder_NextAfter(t *testing.T) {
+	start := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC) // a monday
+
+	tests := []struct {
+		name       string
+		recurrence string
+		after      time.Time
+		want       time.Time
+	}{
+		{
+			name:  "once, before",
+			after: start.Add(-time.Minute),
+			want:  start,
+		},
+		{
+			name:  "once, after",
+			after: start,
+			want:  time.Time{},
+		},
+		{
+			name:       "daily, before the start",
+			recurrence: "FREQ=DAILY",
+			after:      start.Add(-time.Hour),
+			want:       start,
+		},
+		{
+			name:       "daily, skips missed occurrences",
+			recurrence: "FREQ=DAILY",
+			after:      start.AddDate(0, 0, 3).Add(time.Hour),
+			want:       start.AddDate(0, 0, 4),
+		},
+		{
+			name:       "weekly on some days",
+			recurrence: "RRULE:FREQ=WEEKLY;BYDAY=MO,TH",
+			after:      start,
+			want:       start.AddDate(0, 0, 3),
+		},
+		{
+			name:       "until the count is exhausted",
+			recurrence: "FREQ=DAILY;COUNT=2",
+			after:      start.AddDate(0, 0, 1),
+			want:       time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reminder := Reminder{RemindAt: start, Recurrence: tt.recurrence}
+
+			got, err := reminder.NextAfter(tt.after)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
+
+func TestNormalizeRecurrence(t *testing.T) {
+	got, err := NormalizeRecurrence(" rrule:FREQ=WEEKLY;BYDAY=MO ")
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO", got)
+
+	_, err = NormalizeRecurrence("FREQ=MINUTELY")
+	assert.ErrorIs(t, err, ErrRecurrenceTooFrequent)
+
+	_, err = NormalizeRecurrence("DTSTART:20240701T090000Z\nRRULE:FREQ=DAILY")
+	assert.Error(t, err)
+
+	_, err = NormalizeRecurrence("FREQ=SOMETIMES")
+	assert.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package reminders
+
+import (
+	"context"
+
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+// Notifier delivers due reminders to their users.
+type Notifier interface {
+	Notify(ctx context.Context, notification *Notification) error
+}
+
+// LogNotifier "delivers" reminders by logging them.
+type LogNotifier struct{}
+
+func NewLogNotifier(do.Injector) (*LogNotifier, error) {
+	return &LogNotifier{}, nil
+}
+
+func (*LogNotifier) Notify(ctx context.Context, notification *Notification) error {
+	log.Info(ctx, "reminder",
+		zap.Stringer("note_id", notification.NoteID),
+		zap.String("note_title", notification.NoteTitle),
+		zap.Stringer("user_id", notification.UserID),
+		zap.Time("occurrence_at", notification.OccurrenceAt),
+	)
+	return nil
+}
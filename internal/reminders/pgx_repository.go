@@ -0,0 +1,217 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+type PGXRepository struct {
+	db      database.Database
+	queries *database.Queries
+}
+
+func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGXRepository{
+		db:      db,
+		queries: database.New(),
+	}, nil
+}
+
+func (r *PGXRepository) SaveReminder(ctx context.Context, reminder *Reminder) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		err := r.queries.SaveReminder(ctx, tx, database.SaveReminderParams{
+			NoteID:     reminder.NoteID,
+			UserID:     reminder.UserID,
+			RemindAt:   pgtype.Timestamptz{Time: reminder.RemindAt, Valid: true},
+			Recurrence: pgtype.Text{String: reminder.Recurrence, Valid: reminder.Recurrence != ""},
+			NextAt:     optionalTimestamp(reminder.NextAt),
+			CreatedAt:  pgtype.Timestamptz{Time: reminder.CreatedAt, Valid: true},
+			UpdatedAt:  pgtype.Timestamptz{Time: reminder.UpdatedAt, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		return r.queries.ClearReminderDeliveries(ctx, tx, database.ClearReminderDeliveriesParams{
+			NoteID: reminder.NoteID,
+			UserID: reminder.UserID,
+		})
+	})
+	if err != nil {
+		log.Error(ctx, "error saving reminder", zap.Stringer("note_id", reminder.NoteID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) GetReminder(ctx context.Context, noteID, userID uuid.UUID) (reminder *Reminder, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.GetReminder(ctx, tx, database.GetReminderParams{
+			NoteID: noteID,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		found := reminderFromRow(row)
+		reminder = &found
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apiv1.NewError(http.StatusNotFound, "reminder does not exist")
+		}
+
+		log.Error(ctx, "error fetching reminder", zap.Stringer("note_id", noteID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return reminder, nil
+}
+
+func (r *PGXRepository) DeleteReminder(ctx context.Context, noteID, userID uuid.UUID) error {
+	var deleted int64
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		deleted, err = r.queries.DeleteReminder(ctx, tx, database.DeleteReminderParams{
+			NoteID: noteID,
+			UserID: userID,
+		})
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error deleting reminder", zap.Stringer("note_id", noteID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	if deleted == 0 {
+		return apiv1.NewError(http.StatusNotFound, "reminder does not exist")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) ListReminders(ctx context.Context, userID uuid.UUID, params ReminderListParams, pageSize int) (reminders []Reminder, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ListReminders(ctx, tx, database.ListRemindersParams{
+			UserID:     userID,
+			LastNoteID: params.LastNoteID,
+			PageSize:   int64(pageSize),
+		})
+		if err != nil {
+			return err
+		}
+
+		reminders = util.MapSlice(rows, reminderFromRow)
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error listing reminders", zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return reminders, nil
+}
+
+func (r *PGXRepository) ClaimDueReminders(ctx context.Context, now time.Time, limit int, next func(*Reminder) (time.Time, error)) (claimed []Notification, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ClaimDueReminders(ctx, tx, database.ClaimDueRemindersParams{
+			Now:      pgtype.Timestamptz{Time: now, Valid: true},
+			PageSize: int64(limit),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			reminder := reminderFromRow(database.NotesReminder{
+				NoteID:     row.NoteID,
+				UserID:     row.UserID,
+				RemindAt:   row.RemindAt,
+				Recurrence: row.Recurrence,
+				NextAt:     row.NextAt,
+				CreatedAt:  row.CreatedAt,
+				UpdatedAt:  row.UpdatedAt,
+			})
+
+			recorded, err := r.queries.RecordReminderDelivery(ctx, tx, database.RecordReminderDeliveryParams{
+				NoteID:       reminder.NoteID,
+				UserID:       reminder.UserID,
+				OccurrenceAt: row.NextAt,
+				DeliveredAt:  pgtype.Timestamptz{Time: now, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+
+			nextAt, err := next(&reminder)
+			if err != nil {
+				// an invalid recurrence won't become valid, so stop trying to deliver it
+				log.Error(ctx, "error scheduling next reminder", zap.Stringer("note_id", reminder.NoteID), zap.Error(err))
+				nextAt = time.Time{}
+			}
+
+			err = r.queries.SetReminderNextAt(ctx, tx, database.SetReminderNextAtParams{
+				NextAt: optionalTimestamp(nextAt),
+				NoteID: reminder.NoteID,
+				UserID: reminder.UserID,
+			})
+			if err != nil {
+				return err
+			}
+
+			if recorded != 0 {
+				claimed = append(claimed, Notification{
+					NoteID:       reminder.NoteID,
+					NoteTitle:    row.Title,
+					UserID:       reminder.UserID,
+					OccurrenceAt: reminder.NextAt,
+				})
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error claiming due reminders", zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return claimed, nil
+}
+
+func reminderFromRow(row database.NotesReminder) Reminder {
+	return Reminder{
+		NoteID:     row.NoteID,
+		UserID:     row.UserID,
+		RemindAt:   row.RemindAt.Time,
+		Recurrence: row.Recurrence.String,
+		NextAt:     row.NextAt.Time,
+		CreatedAt:  row.CreatedAt.Time,
+		UpdatedAt:  row.UpdatedAt.Time,
+	}
+}
+
+// optionalTimestamp converts t to a timestamp that is NULL if t is the zero value.
+func optionalTimestamp(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,110 @@
+-- name: SaveReminder :exec
+INSERT INTO notes.reminders (
+  note_id,
+  user_id,
+  remind_at,
+  recurrence,
+  next_at,
+  created_at,
+  updated_at
+) VALUES (
+  sqlc.arg(note_id),
+  sqlc.arg(user_id),
+  sqlc.arg(remind_at),
+  sqlc.narg(recurrence),
+  sqlc.narg(next_at),
+  sqlc.arg(created_at),
+  sqlc.arg(updated_at)
+) ON CONFLICT (note_id, user_id) DO UPDATE
+  SET remind_at  = excluded.remind_at,
+      recurrence = excluded.recurrence,
+      next_at    = excluded.next_at,
+      updated_at = excluded.updated_at
+;
+
+-- name: ClearReminderDeliveries :exec
+DELETE FROM notes.reminder_deliveries
+WHERE note_id = sqlc.arg(note_id)
+  AND user_id = sqlc.arg(user_id)
+;
+
+-- name: GetReminder :one
+SELECT
+  note_id,
+  user_id,
+  remind_at,
+  recurrence,
+  next_at,
+  created_at,
+  updated_at
+FROM notes.reminders
+WHERE note_id = sqlc.arg(note_id)
+  AND user_id = sqlc.arg(user_id)
+;
+
+-- name: DeleteReminder :execrows
+DELETE FROM notes.reminders
+WHERE note_id = sqlc.arg(note_id)
+  AND user_id = sqlc.arg(user_id)
+;
+
+-- name: ListReminders :many
+SELECT
+  note_id,
+  user_id,
+  remind_at,
+  recurrence,
+  next_at,
+  created_at,
+  updated_at
+FROM notes.reminders
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(last_note_id)::uuid IS NULL OR note_id > sqlc.narg(last_note_id)::uuid)
+ORDER BY note_id ASC
+LIMIT sqlc.arg(page_size)
+;
+
+-- name: ClaimDueReminders :many
+SELECT
+  reminders.note_id,
+  reminders.user_id,
+  reminders.remind_at,
+  reminders.recurrence,
+  reminders.next_at,
+  reminders.created_at,
+  reminders.updated_at,
+  notes.title
+FROM notes.reminders
+JOIN notes.notes ON
+  notes.note_id = reminders.note_id
+-- users are only reminded of notes they can still access
+JOIN notes.user_note_access ON
+  user_note_access.note_id = reminders.note_id
+  AND user_note_access.user_id = reminders.user_id
+  -- NOTE: any access
+WHERE reminders.next_at <= sqlc.arg(now)
+ORDER BY reminders.next_at
+LIMIT sqlc.arg(page_size)
+FOR UPDATE OF reminders SKIP LOCKED
+;
+
+-- name: RecordReminderDelivery :execrows
+INSERT INTO notes.reminder_deliveries (
+  note_id,
+  user_id,
+  occurrence_at,
+  delivered_at
+) VALUES (
+  sqlc.arg(note_id),
+  sqlc.arg(user_id),
+  sqlc.arg(occurrence_at),
+  sqlc.arg(delivered_at)
+) ON CONFLICT DO NOTHING
+;
+
+-- name: SetReminderNextAt :exec
+UPDATE notes.reminders
+  SET next_at = sqlc.narg(next_at)
+WHERE note_id = sqlc.arg(note_id)
+  AND user_id = sqlc.arg(user_id)
+;
@@ -0,0 +1,11 @@
+// Package reminders manages users' reminders about notes, and delivers them when they are due.
+package reminders
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewPGXRepository),
+	do.Lazy(NewService),
+	do.Lazy(NewLogNotifier),
+	do.Lazy(NewScheduler),
+)
@@ -0,0 +1,20 @@
+package reminders
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	// SaveReminder creates or replaces a user's reminder about a note, forgetting any deliveries of the previous one.
+	SaveReminder(ctx context.Context, reminder *Reminder) error
+	GetReminder(ctx context.Context, noteID, userID uuid.UUID) (*Reminder, error)
+	DeleteReminder(ctx context.Context, noteID, userID uuid.UUID) error
+	ListReminders(ctx context.Context, userID uuid.UUID, params ReminderListParams, pageSize int) ([]Reminder, error)
+	// ClaimDueReminders finds up to limit reminders due by now, records their delivery, and schedules their next
+	// occurrence with next, all at once. Reminders that have already been delivered are skipped, so that no
+	// occurrence is claimed twice.
+	ClaimDueReminders(ctx context.Context, now time.Time, limit int, next func(*Reminder) (time.Time, error)) ([]Notification, error)
+}
@@ -0,0 +1,89 @@
+package reminders
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+const (
+	scheduleInterval  = 15 * time.Second
+	scheduleBatchSize = 100
+)
+
+// Scheduler delivers reminders as they become due.
+//
+// Each occurrence is recorded as delivered before it is sent to the [Notifier], so an occurrence is never delivered
+// twice, even by concurrent schedulers or after a restart. A failure to notify is logged, and not retried.
+type Scheduler struct {
+	repo     Repository
+	notifier Notifier
+}
+
+func NewScheduler(injector do.Injector) (*Scheduler, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier, err := do.InvokeAs[Notifier](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Scheduler{
+		repo:     repo,
+		notifier: notifier,
+	}, nil
+}
+
+// Run delivers due reminders periodically until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.DeliverDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Error(ctx, "error delivering reminders", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue delivers every reminder due by now. Occurrences missed while no scheduler was running are only
+// delivered once, rather than once per missed occurrence.
+func (s *Scheduler) DeliverDue(ctx context.Context, now time.Time) error {
+	next := func(reminder *Reminder) (time.Time, error) {
+		return reminder.NextAfter(now)
+	}
+
+	for {
+		due, err := s.repo.ClaimDueReminders(ctx, now, scheduleBatchSize, next)
+		if err != nil {
+			return err
+		}
+
+		for i := range due {
+			if err := s.notifier.Notify(ctx, &due[i]); err != nil {
+				log.Error(ctx, "error notifying reminder",
+					zap.Stringer("note_id", due[i].NoteID),
+					zap.Stringer("user_id", due[i].UserID),
+					zap.Error(err),
+				)
+			}
+		}
+
+		if len(due) < scheduleBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package reminders
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/scope"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// NoteAccess reports a user's access to a note. Anyone who can view a note can be reminded about it.
+type NoteAccess interface {
+	GetUsersNoteAccess(ctx context.Context, noteID, userID uuid.UUID) (users.AccessLevel, error)
+}
+
+type Service struct {
+	repo  Repository
+	notes NoteAccess
+}
+
+func NewService(injector do.Injector) (*Service, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := do.InvokeAs[NoteAccess](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		repo:  repo,
+		notes: notes,
+	}, nil
+}
+
+// SetReminder creates or replaces the user's reminder about a note.
+func (s *Service) SetReminder(ctx context.Context, noteID uuid.UUID, remindAt time.Time, recurrence string) (*Reminder, error) {
+	userID := scope.MustUserID(ctx)
+
+	if err := s.checkAccess(ctx, noteID, userID); err != nil {
+		return nil, err
+	}
+
+	if recurrence != "" {
+		normalized, err := NormalizeRecurrence(recurrence)
+		if err != nil {
+			return nil, apiv1.NewError(http.StatusBadRequest, "invalid recurrence", err.Error())
+		}
+
+		recurrence = normalized
+	}
+
+	now := time.Now()
+	reminder := &Reminder{
+		NoteID:     noteID,
+		UserID:     userID,
+		RemindAt:   remindAt,
+		Recurrence: recurrence,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if existing, err := s.repo.GetReminder(ctx, noteID, userID); err == nil {
+		reminder.CreatedAt = existing.CreatedAt
+	}
+
+	nextAt, err := reminder.NextAfter(now)
+	if err != nil {
+		return nil, apiv1.NewError(http.StatusBadRequest, "invalid recurrence", err.Error())
+	}
+
+	if nextAt.IsZero() {
+		return nil, apiv1.NewError(http.StatusBadRequest, "reminder never occurs in the future")
+	}
+
+	reminder.NextAt = nextAt
+
+	if err := s.repo.SaveReminder(ctx, reminder); err != nil {
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+func (s *Service) GetReminder(ctx context.Context, noteID uuid.UUID) (*Reminder, error) {
+	userID := scope.MustUserID(ctx)
+
+	if err := s.checkAccess(ctx, noteID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetReminder(ctx, noteID, userID)
+}
+
+func (s *Service) DeleteReminder(ctx context.Context, noteID uuid.UUID) error {
+	userID := scope.MustUserID(ctx)
+
+	// no access check: users can always remove their own reminders, even after losing access to the note
+	return s.repo.DeleteReminder(ctx, noteID, userID)
+}
+
+// ListReminders lists the user's own reminders.
+func (s *Service) ListReminders(ctx context.Context, params ReminderListParams, pageSize int) ([]Reminder, *ReminderListParams, error) {
+	userID := scope.MustUserID(ctx)
+
+	if pageSize == 0 {
+		pageSize = 100
+	}
+
+	// retrieve one more to see if there is another page to fetch
+	results, err := s.repo.ListReminders(ctx, userID, params, pageSize+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *ReminderListParams
+	if len(results) > pageSize {
+		results = results[:pageSize]
+		next = &ReminderListParams{
+			LastNoteID: uuid.NullUUID{UUID: results[len(results)-1].NoteID, Valid: true},
+		}
+	}
+
+	return results, next, nil
+}
+
+func (s *Service) checkAccess(ctx context.Context, noteID, userID uuid.UUID) error {
+	access, err := s.notes.GetUsersNoteAccess(ctx, noteID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user note access", zap.Stringer("note_id", noteID), zap.Error(err))
+		return apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < users.AccessLevelViewer {
+		return apiv1.StatusError(http.StatusForbidden)
+	}
+
+	return nil
+}
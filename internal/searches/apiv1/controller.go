@@ -0,0 +1,279 @@
+package apiv1
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/notes"
+	notesapiv1 "github.com/dabbertorres/notes/internal/notes/apiv1"
+	"github.com/dabbertorres/notes/internal/searches"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+type Service interface {
+	CreateSavedSearch(ctx context.Context, search *searches.SavedSearch) (*searches.SavedSearch, error)
+	UpdateSavedSearch(ctx context.Context, search *searches.SavedSearch) (*searches.SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, id uuid.UUID) error
+	GetSavedSearch(ctx context.Context, id uuid.UUID) (*searches.SavedSearch, error)
+	ListSavedSearches(ctx context.Context, params searches.SavedSearchListParams, pageSize int) (results []searches.SavedSearch, next *searches.SavedSearchListParams, err error)
+	SearchResults(ctx context.Context, id uuid.UUID, cursor notes.NoteSearchParams, pageSize int) (results []notes.NoteSearchResult, next *notes.NoteSearchParams, err error)
+	NewResults(ctx context.Context, id uuid.UUID, markChecked bool) (results []notes.NoteSearchResult, lastChecked time.Time, err error)
+}
+
+func PostSavedSearch(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := apiv1.ReadJSONOrFail[WritableSavedSearch](w, r)
+		if !ok {
+			return
+		}
+
+		search, err := body.ToDomain()
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		created, err := svc.CreateSavedSearch(r.Context(), search)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := SavedSearchFromDomain(created)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
+func PutSavedSearch(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		searchID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid saved search id"))
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[WritableSavedSearch](w, r)
+		if !ok {
+			return
+		}
+
+		search, err := body.ToDomain()
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		search.ID = searchID
+
+		result, err := svc.UpdateSavedSearch(r.Context(), search)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := SavedSearchFromDomain(result)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+func DeleteSavedSearch(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		searchID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid saved search id"))
+			return
+		}
+
+		if err := svc.DeleteSavedSearch(r.Context(), searchID); err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func GetSavedSearch(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		searchID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid saved search id"))
+			return
+		}
+
+		search, err := svc.GetSavedSearch(r.Context(), searchID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := SavedSearchFromDomain(search)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
+func ListSavedSearches(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		paging, err := parseListSavedSearchesParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		params := searches.SavedSearchListParams{
+			LastSavedSearchID: paging.Data.LastSavedSearchID,
+		}
+		results, next, err := svc.ListSavedSearches(r.Context(), params, paging.PageSize)
+		if err != nil {
+			log.Error(r.Context(), "error listing saved searches", zap.Error(err))
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		page := apiv1.Page[SavedSearch, *ListSavedSearchesPageTokenData]{
+			NextPageToken: nil,
+			Items: util.MapSlice(results, func(search searches.SavedSearch) SavedSearch {
+				return SavedSearchFromDomain(&search)
+			}),
+		}
+
+		if next != nil {
+			page.NextPageToken = &apiv1.PageToken[*ListSavedSearchesPageTokenData]{
+				Data: &ListSavedSearchesPageTokenData{
+					LastSavedSearchID: next.LastSavedSearchID,
+				},
+				PageSize: paging.PageSize,
+			}
+		}
+
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, page)
+	}
+}
+
+// GetSavedSearchResults runs a saved search, paginating the same way as listing notes.
+func GetSavedSearchResults(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		searchID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid saved search id"))
+			return
+		}
+
+		paging, err := parseResultsParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		results, next, err := svc.SearchResults(r.Context(), searchID, paging.Data.ToDomain(), paging.PageSize)
+		if err != nil {
+			log.Error(r.Context(), "error running saved search", zap.Stringer("saved_search_id", searchID), zap.Error(err))
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		page := apiv1.Page[notesapiv1.Note, *notesapiv1.ListNotesPageTokenData]{
+			NextPageToken: nil,
+			Items:         util.MapSlice(results, notesapiv1.NoteFromSearchResult),
+		}
+
+		if next != nil {
+			page.NextPageToken = &apiv1.PageToken[*notesapiv1.ListNotesPageTokenData]{
+				Data:     notesapiv1.ListNotesPageTokenDataFromDomain(next),
+				PageSize: paging.PageSize,
+			}
+		}
+
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, page)
+	}
+}
+
+// GetSavedSearchNewResults reports the notes that match a saved search which did not match when it was last checked.
+// Unless the "peek" parameter is true, the search is then marked as checked.
+func GetSavedSearchNewResults(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		searchID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid saved search id"))
+			return
+		}
+
+		var peek bool
+		if raw := r.FormValue("peek"); raw != "" {
+			peek, err = strconv.ParseBool(raw)
+			if err != nil {
+				apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+				return
+			}
+		}
+
+		results, lastChecked, err := svc.NewResults(r.Context(), searchID, !peek)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := NewResults{
+			Since: formatOptionalTime(lastChecked),
+			Items: util.MapSlice(results, notesapiv1.NoteFromSearchResult),
+		}
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+func parseListSavedSearchesParams(r *http.Request) (token apiv1.PageToken[*ListSavedSearchesPageTokenData], err error) {
+	rawPageToken := r.FormValue("next_page_token")
+	if rawPageToken != "" {
+		pageToken, err := apiv1.ParsePageToken[*ListSavedSearchesPageTokenData](rawPageToken, 100, 100)
+		if err != nil {
+			return token, apiv1.NewValidationFailureError(err)
+		}
+
+		token = pageToken
+	} else {
+		token.Data = &ListSavedSearchesPageTokenData{}
+
+		if size := r.FormValue("page_size"); size != "" {
+			pageSize, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return token, apiv1.NewValidationFailureError(err)
+			}
+
+			token.PageSize = int(pageSize)
+		}
+	}
+
+	return token, nil
+}
+
+func parseResultsParams(r *http.Request) (token apiv1.PageToken[*notesapiv1.ListNotesPageTokenData], err error) {
+	rawPageToken := r.FormValue("next_page_token")
+	if rawPageToken != "" {
+		pageToken, err := apiv1.ParsePageToken[*notesapiv1.ListNotesPageTokenData](rawPageToken, 100, 100)
+		if err != nil {
+			return token, apiv1.NewValidationFailureError(err)
+		}
+
+		token = pageToken
+	} else {
+		token.Data = &notesapiv1.ListNotesPageTokenData{}
+
+		if size := r.FormValue("page_size"); size != "" {
+			pageSize, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return token, apiv1.NewValidationFailureError(err)
+			}
+
+			token.PageSize = int(pageSize)
+		}
+	}
+
+	return token, nil
+}
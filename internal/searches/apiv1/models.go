@@ -0,0 +1,139 @@
+package apiv1
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	notesapiv1 "github.com/dabbertorres/notes/internal/notes/apiv1"
+	"github.com/dabbertorres/notes/internal/searches"
+)
+
+type SavedSearch struct {
+	ID            string                 `json:"id"`
+	Name          string                 `json:"name"`
+	Query         notesapiv1.SearchQuery `json:"query"`
+	CreatedWithin string                 `json:"created_within,omitempty"`
+	UpdatedWithin string                 `json:"updated_within,omitempty"`
+	TrackMatches  bool                   `json:"track_matches"`
+	CreatedAt     string                 `json:"created_at"`
+	UpdatedAt     string                 `json:"updated_at"`
+	LastCheckedAt string                 `json:"last_checked_at,omitempty"`
+}
+
+func SavedSearchFromDomain(domain *searches.SavedSearch) (s SavedSearch) {
+	s.ID = domain.ID.String()
+	s.Name = domain.Name
+	s.Query = notesapiv1.SearchQueryFromDomain(&domain.Query)
+	s.CreatedWithin = formatOptionalDuration(domain.CreatedWithin)
+	s.UpdatedWithin = formatOptionalDuration(domain.UpdatedWithin)
+	s.TrackMatches = domain.TrackMatches
+	s.CreatedAt = domain.CreatedAt.Format(time.RFC3339)
+	s.UpdatedAt = domain.UpdatedAt.Format(time.RFC3339)
+	s.LastCheckedAt = formatOptionalTime(domain.LastCheckedAt)
+	return s
+}
+
+// WritableSavedSearch contains only the subset of fields on [SavedSearch] that an API user can modify.
+//
+// CreatedWithin and UpdatedWithin are durations such as "168h", which keep the search to notes created or updated
+// that recently whenever it is run, unlike the absolute bounds of its query.
+type WritableSavedSearch struct {
+	Name          string                 `json:"name"`
+	Query         notesapiv1.SearchQuery `json:"query"`
+	CreatedWithin string                 `json:"created_within,omitempty"`
+	UpdatedWithin string                 `json:"updated_within,omitempty"`
+	TrackMatches  bool                   `json:"track_matches"`
+}
+
+func (s *WritableSavedSearch) ToDomain() (*searches.SavedSearch, error) {
+	var errs []error
+
+	if s.Name == "" {
+		errs = append(errs, &apiv1.InvalidFieldError{Field: ".name", Err: apiv1.ErrFieldNotSet.Error()})
+	}
+
+	out := &searches.SavedSearch{
+		Name:          s.Name,
+		Query:         apiv1.Validate(".query", s.Query, &errs, notesapiv1.SearchQuery.ToDomain),
+		CreatedWithin: apiv1.ValidateOptional(".created_within", s.CreatedWithin, &errs, parseWindow),
+		UpdatedWithin: apiv1.ValidateOptional(".updated_within", s.UpdatedWithin, &errs, parseWindow),
+		TrackMatches:  s.TrackMatches,
+	}
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return out, nil
+}
+
+// NewResults lists the notes that newly match a saved search.
+type NewResults struct {
+	// Since is when the saved search was last checked, if ever.
+	Since string            `json:"since,omitempty"`
+	Items []notesapiv1.Note `json:"items"`
+}
+
+type ListSavedSearchesPageTokenData struct {
+	LastSavedSearchID uuid.NullUUID
+}
+
+func (d *ListSavedSearchesPageTokenData) EncodePager() ([][]byte, error) {
+	var out [1][]byte
+
+	if d.LastSavedSearchID.Valid {
+		out[0] = []byte(d.LastSavedSearchID.UUID.String())
+	}
+
+	return out[:], nil
+}
+
+func (d *ListSavedSearchesPageTokenData) DecodePager(data [][]byte) error {
+	if len(data) != 1 {
+		return errors.New("invalid page token format (incorrect number of parts)")
+	}
+
+	if len(data[0]) != 0 {
+		lastID, err := uuid.ParseBytes(data[0])
+		if err != nil {
+			return err
+		}
+
+		d.LastSavedSearchID.UUID = lastID
+		d.LastSavedSearchID.Valid = true
+	}
+
+	return nil
+}
+
+func parseWindow(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+
+	if d <= 0 {
+		return 0, errors.New("must be positive")
+	}
+
+	return d, nil
+}
+
+func formatOptionalDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+
+	return d.String()
+}
+
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,56 @@
+package searches
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// SavedSearch is a named notes search that a user can re-run later.
+type SavedSearch struct {
+	ID    uuid.UUID
+	Owner users.User
+	Name  string
+	// Query holds the search to run. Its pagination fields are ignored.
+	Query notes.NoteSearchParams
+	// CreatedWithin and UpdatedWithin, if set, restrict the search to notes created or updated within that long of
+	// when it is run, in addition to any absolute bounds in Query.
+	CreatedWithin time.Duration
+	UpdatedWithin time.Duration
+	// TrackMatches enables recording the notes that matched the last time the
+	// search was checked, so that new matches can be reported.
+	TrackMatches  bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	LastCheckedAt time.Time
+}
+
+// QueryAt returns the search to run at now, with its relative windows turned into absolute bounds.
+func (s *SavedSearch) QueryAt(now time.Time) notes.NoteSearchParams {
+	params := s.Query
+
+	if s.CreatedWithin > 0 {
+		params.CreatedAfter = latest(params.CreatedAfter, now.Add(-s.CreatedWithin))
+	}
+
+	if s.UpdatedWithin > 0 {
+		params.UpdatedAfter = latest(params.UpdatedAfter, now.Add(-s.UpdatedWithin))
+	}
+
+	return params
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+type SavedSearchListParams struct {
+	LastSavedSearchID uuid.NullUUID
+}
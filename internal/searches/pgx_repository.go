@@ -0,0 +1,265 @@
+package searches
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+type PGXRepository struct {
+	db      database.Database
+	queries *database.Queries
+}
+
+func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGXRepository{
+		db:      db,
+		queries: database.New(),
+	}, nil
+}
+
+func (r *PGXRepository) SaveSavedSearch(ctx context.Context, search *SavedSearch) error {
+	query, err := json.Marshal(storedQueryFromDomain(search))
+	if err != nil {
+		log.Error(ctx, "error encoding saved search query", zap.Stringer("saved_search_id", search.ID), zap.Error(err))
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		params := database.SaveSavedSearchParams{
+			SavedSearchID: search.ID,
+			UserID:        search.Owner.ID,
+			Name:          search.Name,
+			Query:         query,
+			TrackMatches:  search.TrackMatches,
+			CreatedAt:     pgtype.Timestamptz{Time: search.CreatedAt, Valid: true},
+			UpdatedAt:     pgtype.Timestamptz{Time: search.UpdatedAt, Valid: true},
+		}
+
+		if err := r.queries.SaveSavedSearch(ctx, tx, params); err != nil {
+			log.Error(ctx, "error saving saved search", zap.Stringer("saved_search_id", search.ID), zap.Error(err))
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (r *PGXRepository) DeleteSavedSearch(ctx context.Context, id uuid.UUID) error {
+	var numDeleted int64
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		numDeleted, err = r.queries.DeleteSavedSearch(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error deleting saved search", zap.Stringer("saved_search_id", id), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	if numDeleted != 1 {
+		return apiv1.NewError(http.StatusNotFound, "saved search does not exist")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) GetSavedSearch(ctx context.Context, id uuid.UUID) (search *SavedSearch, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.GetSavedSearch(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		search, err = savedSearchFromRow(row)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apiv1.NewError(http.StatusNotFound, "saved search does not exist")
+		}
+
+		log.Error(ctx, "error fetching saved search", zap.Stringer("saved_search_id", id), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return search, nil
+}
+
+func (r *PGXRepository) ListSavedSearches(ctx context.Context, userID uuid.UUID, params SavedSearchListParams, pageSize int) (searches []SavedSearch, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ListSavedSearches(ctx, tx, database.ListSavedSearchesParams{
+			UserID:            userID,
+			LastSavedSearchID: params.LastSavedSearchID,
+			PageSize:          int64(pageSize),
+		})
+		if err != nil {
+			return err
+		}
+
+		searches = make([]SavedSearch, 0, len(rows))
+		for _, row := range rows {
+			search, err := savedSearchFromRow(row)
+			if err != nil {
+				return err
+			}
+
+			searches = append(searches, *search)
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error listing saved searches", zap.Stringer("user_id", userID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return searches, nil
+}
+
+func (r *PGXRepository) GetMatches(ctx context.Context, id uuid.UUID) (noteIDs []uuid.UUID, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		noteIDs, err = r.queries.GetSavedSearchMatches(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error fetching saved search matches", zap.Stringer("saved_search_id", id), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return noteIDs, nil
+}
+
+func (r *PGXRepository) ReplaceMatches(ctx context.Context, id uuid.UUID, noteIDs []uuid.UUID, checkedAt time.Time) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if err := r.queries.ClearSavedSearchMatches(ctx, tx, id); err != nil {
+			return err
+		}
+
+		err := r.queries.AddSavedSearchMatches(ctx, tx, database.AddSavedSearchMatchesParams{
+			SavedSearchID: id,
+			NoteIds:       noteIDs,
+			MatchedAt:     pgtype.Timestamptz{Time: checkedAt, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		return r.queries.SetSavedSearchLastChecked(ctx, tx, database.SetSavedSearchLastCheckedParams{
+			LastCheckedAt: pgtype.Timestamptz{Time: checkedAt, Valid: true},
+			SavedSearchID: id,
+		})
+	})
+	if err != nil {
+		log.Error(ctx, "error recording saved search matches", zap.Stringer("saved_search_id", id), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func savedSearchFromRow(row database.NotesSavedSearch) (*SavedSearch, error) {
+	var query storedQuery
+	if err := json.Unmarshal(row.Query, &query); err != nil {
+		return nil, err
+	}
+
+	params, err := query.toDomain()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SavedSearch{
+		ID:            row.SavedSearchID,
+		Owner:         users.User{ID: row.UserID},
+		Name:          row.Name,
+		Query:         params,
+		CreatedWithin: query.CreatedWithin,
+		UpdatedWithin: query.UpdatedWithin,
+		TrackMatches:  row.TrackMatches,
+		CreatedAt:     row.CreatedAt.Time,
+		UpdatedAt:     row.UpdatedAt.Time,
+		LastCheckedAt: row.LastCheckedAt.Time,
+	}, nil
+}
+
+// storedQuery is the representation of a saved search's [notes.NoteSearchParams], and its relative windows, that is
+// persisted with it.
+type storedQuery struct {
+	Text           string        `json:"text,omitempty"`
+	AllTags        []uuid.UUID   `json:"all_tags,omitempty"`
+	AnyTags        []uuid.UUID   `json:"any_tags,omitempty"`
+	NoneTags       []uuid.UUID   `json:"none_tags,omitempty"`
+	CreatedBy      uuid.NullUUID `json:"created_by"`
+	UpdatedBy      uuid.NullUUID `json:"updated_by"`
+	CreatedAfter   time.Time     `json:"created_after"`
+	CreatedBefore  time.Time     `json:"created_before"`
+	UpdatedAfter   time.Time     `json:"updated_after"`
+	UpdatedBefore  time.Time     `json:"updated_before"`
+	CreatedWithin  time.Duration `json:"created_within,omitempty"`
+	UpdatedWithin  time.Duration `json:"updated_within,omitempty"`
+	SortBy         string        `json:"sort_by"`
+	SortDescending bool          `json:"sort_descending,omitempty"`
+}
+
+func storedQueryFromDomain(search *SavedSearch) storedQuery {
+	params := &search.Query
+	return storedQuery{
+		Text:           params.TextSearch,
+		AllTags:        params.Tags.AllOf,
+		AnyTags:        params.Tags.AnyOf,
+		NoneTags:       params.Tags.NoneOf,
+		CreatedBy:      params.CreatedBy,
+		UpdatedBy:      params.UpdatedBy,
+		CreatedAfter:   params.CreatedAfter,
+		CreatedBefore:  params.CreatedBefore,
+		UpdatedAfter:   params.UpdatedAfter,
+		UpdatedBefore:  params.UpdatedBefore,
+		CreatedWithin:  search.CreatedWithin,
+		UpdatedWithin:  search.UpdatedWithin,
+		SortBy:         params.SortBy.String(),
+		SortDescending: params.SortDescending,
+	}
+}
+
+func (q *storedQuery) toDomain() (notes.NoteSearchParams, error) {
+	sortBy, err := notes.ParseSortKey(q.SortBy)
+	if err != nil {
+		return notes.NoteSearchParams{}, err
+	}
+
+	return notes.NoteSearchParams{
+		TextSearch: q.Text,
+		Tags: notes.TagFilter{
+			AllOf:  q.AllTags,
+			AnyOf:  q.AnyTags,
+			NoneOf: q.NoneTags,
+		},
+		CreatedBy:      q.CreatedBy,
+		UpdatedBy:      q.UpdatedBy,
+		CreatedAfter:   q.CreatedAfter,
+		CreatedBefore:  q.CreatedBefore,
+		UpdatedAfter:   q.UpdatedAfter,
+		UpdatedBefore:  q.UpdatedBefore,
+		SortBy:         sortBy,
+		SortDescending: q.SortDescending,
+	}, nil
+}
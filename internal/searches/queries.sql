@@ -0,0 +1,90 @@
+-- name: SaveSavedSearch :exec
+INSERT INTO notes.saved_searches (
+  saved_search_id,
+  user_id,
+  name,
+  query,
+  track_matches,
+  created_at,
+  updated_at
+) VALUES (
+  sqlc.arg(saved_search_id),
+  sqlc.arg(user_id),
+  sqlc.arg(name),
+  sqlc.arg(query),
+  sqlc.arg(track_matches),
+  sqlc.arg(created_at),
+  sqlc.arg(updated_at)
+) ON CONFLICT (saved_search_id) DO UPDATE
+  SET name          = excluded.name,
+      query         = excluded.query,
+      track_matches = excluded.track_matches,
+      updated_at    = excluded.updated_at
+;
+
+-- name: DeleteSavedSearch :execrows
+DELETE FROM notes.saved_searches
+WHERE saved_search_id = sqlc.arg(saved_search_id)
+;
+
+-- name: GetSavedSearch :one
+SELECT
+  saved_search_id,
+  user_id,
+  name,
+  query,
+  track_matches,
+  created_at,
+  updated_at,
+  last_checked_at
+FROM notes.saved_searches
+WHERE saved_search_id = sqlc.arg(saved_search_id)
+;
+
+-- name: ListSavedSearches :many
+SELECT
+  saved_search_id,
+  user_id,
+  name,
+  query,
+  track_matches,
+  created_at,
+  updated_at,
+  last_checked_at
+FROM notes.saved_searches
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(last_saved_search_id)::uuid IS NULL OR saved_search_id > sqlc.narg(last_saved_search_id)::uuid)
+ORDER BY saved_search_id ASC
+LIMIT sqlc.arg(page_size)
+;
+
+-- name: GetSavedSearchMatches :many
+SELECT
+  note_id
+FROM notes.saved_search_matches
+WHERE saved_search_id = sqlc.arg(saved_search_id)
+;
+
+-- name: ClearSavedSearchMatches :exec
+DELETE FROM notes.saved_search_matches
+WHERE saved_search_id = sqlc.arg(saved_search_id)
+;
+
+-- name: AddSavedSearchMatches :exec
+INSERT INTO notes.saved_search_matches (
+  saved_search_id,
+  note_id,
+  matched_at
+)
+SELECT
+  sqlc.arg(saved_search_id),
+  UNNEST(sqlc.arg(note_ids)::uuid[]),
+  sqlc.arg(matched_at)
+ON CONFLICT (saved_search_id, note_id) DO NOTHING
+;
+
+-- name: SetSavedSearchLastChecked :exec
+UPDATE notes.saved_searches
+  SET last_checked_at = sqlc.arg(last_checked_at)
+WHERE saved_search_id = sqlc.arg(saved_search_id)
+;
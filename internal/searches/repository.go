@@ -0,0 +1,18 @@
+package searches
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	SaveSavedSearch(ctx context.Context, search *SavedSearch) error
+	DeleteSavedSearch(ctx context.Context, id uuid.UUID) error
+	GetSavedSearch(ctx context.Context, id uuid.UUID) (*SavedSearch, error)
+	ListSavedSearches(ctx context.Context, userID uuid.UUID, params SavedSearchListParams, pageSize int) ([]SavedSearch, error)
+	GetMatches(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error)
+	// ReplaceMatches replaces the recorded matches of a saved search with noteIDs, and marks it as checked at checkedAt.
+	ReplaceMatches(ctx context.Context, id uuid.UUID, noteIDs []uuid.UUID, checkedAt time.Time) error
+}
@@ -0,0 +1,213 @@
+package searches
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/scope"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// maxTrackedMatches is the most results of a saved search that will be checked for new matches.
+const maxTrackedMatches = 1000
+
+type NoteSearcher interface {
+	SearchNotes(ctx context.Context, params notes.NoteSearchParams, pageSize int) ([]notes.NoteSearchResult, *notes.NoteSearchParams, error)
+}
+
+type Service struct {
+	repo  Repository
+	notes NoteSearcher
+}
+
+func NewService(injector do.Injector) (*Service, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := do.InvokeAs[NoteSearcher](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		repo:  repo,
+		notes: notes,
+	}, nil
+}
+
+func (s *Service) CreateSavedSearch(ctx context.Context, search *SavedSearch) (*SavedSearch, error) {
+	userID := scope.MustUserID(ctx)
+
+	searchID, err := uuid.NewV7()
+	if err != nil {
+		return nil, apiv1.StatusError(http.StatusServiceUnavailable)
+	}
+
+	search.ID = searchID
+	search.Owner = users.User{ID: userID}
+	search.CreatedAt = time.Now()
+	search.UpdatedAt = search.CreatedAt
+
+	if err := s.repo.SaveSavedSearch(ctx, search); err != nil {
+		log.Error(ctx, "error creating saved search", zap.Stringer("saved_search_id", search.ID), zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	return search, nil
+}
+
+func (s *Service) UpdateSavedSearch(ctx context.Context, search *SavedSearch) (*SavedSearch, error) {
+	existing, err := s.getOwnSavedSearch(ctx, search.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	search.Owner = existing.Owner
+	search.CreatedAt = existing.CreatedAt
+	search.UpdatedAt = time.Now()
+	search.LastCheckedAt = existing.LastCheckedAt
+
+	if err := s.repo.SaveSavedSearch(ctx, search); err != nil {
+		log.Error(ctx, "error updating saved search", zap.Stringer("saved_search_id", search.ID), zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	return search, nil
+}
+
+func (s *Service) DeleteSavedSearch(ctx context.Context, searchID uuid.UUID) error {
+	if _, err := s.getOwnSavedSearch(ctx, searchID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteSavedSearch(ctx, searchID)
+}
+
+func (s *Service) GetSavedSearch(ctx context.Context, searchID uuid.UUID) (*SavedSearch, error) {
+	return s.getOwnSavedSearch(ctx, searchID)
+}
+
+func (s *Service) ListSavedSearches(ctx context.Context, params SavedSearchListParams, pageSize int) (searches []SavedSearch, next *SavedSearchListParams, err error) {
+	userID := scope.MustUserID(ctx)
+
+	if pageSize == 0 {
+		pageSize = 100
+	}
+
+	// retrieve one more to see if there is another page to fetch
+	searches, err = s.repo.ListSavedSearches(ctx, userID, params, pageSize+1)
+	if err != nil {
+		log.Error(ctx, "error listing saved searches", zap.Error(err))
+		return nil, nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if len(searches) > pageSize {
+		searches = searches[:pageSize]
+
+		last := &searches[len(searches)-1]
+
+		next = &SavedSearchListParams{
+			LastSavedSearchID: uuid.NullUUID{UUID: last.ID, Valid: true},
+		}
+	}
+
+	return searches, next, nil
+}
+
+// SearchResults runs a saved search. Only the pagination fields of cursor are used, to continue from a previous page.
+func (s *Service) SearchResults(ctx context.Context, searchID uuid.UUID, cursor notes.NoteSearchParams, pageSize int) ([]notes.NoteSearchResult, *notes.NoteSearchParams, error) {
+	search, err := s.getOwnSavedSearch(ctx, searchID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	params := search.QueryAt(time.Now())
+	params.LastNoteID = cursor.LastNoteID
+	params.LastPinned = cursor.LastPinned
+	params.LastRank = cursor.LastRank
+	params.LastTime = cursor.LastTime
+	params.LastTitle = cursor.LastTitle
+
+	return s.notes.SearchNotes(ctx, params, pageSize)
+}
+
+// NewResults returns the results of a saved search that did not match the last time it was checked, along with
+// when that was.
+// If markChecked is true, the current results are recorded as the ones that have been seen.
+func (s *Service) NewResults(ctx context.Context, searchID uuid.UUID, markChecked bool) (results []notes.NoteSearchResult, lastChecked time.Time, err error) {
+	search, err := s.getOwnSavedSearch(ctx, searchID)
+	if err != nil {
+		return nil, lastChecked, err
+	}
+
+	if !search.TrackMatches {
+		return nil, lastChecked, apiv1.NewError(http.StatusConflict, "saved search does not track matches")
+	}
+
+	checkedAt := time.Now()
+	query := search.QueryAt(checkedAt)
+
+	var all []notes.NoteSearchResult
+	for params := &query; params != nil && len(all) < maxTrackedMatches; {
+		var page []notes.NoteSearchResult
+		page, params, err = s.notes.SearchNotes(ctx, *params, 100)
+		if err != nil {
+			return nil, lastChecked, err
+		}
+
+		all = append(all, page...)
+	}
+
+	previous, err := s.repo.GetMatches(ctx, searchID)
+	if err != nil {
+		return nil, lastChecked, err
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(previous))
+	for _, id := range previous {
+		seen[id] = struct{}{}
+	}
+
+	matched := make([]uuid.UUID, 0, len(all))
+	for _, result := range all {
+		matched = append(matched, result.ID)
+
+		if _, ok := seen[result.ID]; !ok {
+			results = append(results, result)
+		}
+	}
+
+	if markChecked {
+		if err := s.repo.ReplaceMatches(ctx, searchID, matched, checkedAt); err != nil {
+			return nil, lastChecked, err
+		}
+	}
+
+	return results, search.LastCheckedAt, nil
+}
+
+func (s *Service) getOwnSavedSearch(ctx context.Context, searchID uuid.UUID) (*SavedSearch, error) {
+	userID := scope.MustUserID(ctx)
+
+	search, err := s.repo.GetSavedSearch(ctx, searchID)
+	if err != nil {
+		return nil, err
+	}
+
+	// saved searches are private to the user that created them
+	if search.Owner.ID != userID {
+		return nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	return search, nil
+}
@@ -0,0 +1,291 @@
+package searches
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/scope"
+)
+
+// memoryRepository holds saved searches, and the matches recorded for each.
+type memoryRepository struct {
+	searches map[uuid.UUID]SavedSearch
+	matches  map[uuid.UUID][]uuid.UUID
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		searches: make(map[uuid.UUID]SavedSearch),
+		matches:  make(map[uuid.UUID][]uuid.UUID),
+	}
+}
+
+func (r *memoryRepository) SaveSavedSearch(ctx context.Context, search *SavedSearch) error {
+	r.searches[search.ID] = *search
+	return nil
+}
+
+func (r *memoryRepository) DeleteSavedSearch(ctx context.Context, id uuid.UUID) error {
+	delete(r.searches, id)
+	return nil
+}
+
+func (r *memoryRepository) GetSavedSearch(ctx context.Context, id uuid.UUID) (*SavedSearch, error) {
+	search, ok := r.searches[id]
+	if !ok {
+		return nil, apiv1.NewError(http.StatusNotFound, "saved search does not exist")
+	}
+
+	return &search, nil
+}
+
+func (r *memoryRepository) ListSavedSearches(ctx context.Context, userID uuid.UUID, params SavedSearchListParams, pageSize int) ([]SavedSearch, error) {
+	return nil, nil
+}
+
+func (r *memoryRepository) GetMatches(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	return r.matches[id], nil
+}
+
+func (r *memoryRepository) ReplaceMatches(ctx context.Context, id uuid.UUID, noteIDs []uuid.UUID, checkedAt time.Time) error {
+	r.matches[id] = noteIDs
+
+	search := r.searches[id]
+	search.LastCheckedAt = checkedAt
+	r.searches[id] = search
+	return nil
+}
+
+// memorySearcher pages through a fixed list of results, pinned ones first and then by ID, the way notes are ordered
+// by default.
+type memorySearcher struct {
+	results []notes.NoteSearchResult
+	// searched is every search run, in order.
+	searched []notes.NoteSearchParams
+}
+
+func newMemorySearcher(n int) *memorySearcher {
+	s := &memorySearcher{}
+	for range n {
+		var result notes.NoteSearchResult
+		result.ID = uuid.Must(uuid.NewV7())
+		s.results = append(s.results, result)
+	}
+
+	return s
+}
+
+// pin pins the first n results, which keeps them in order.
+func (s *memorySearcher) pin(n int) {
+	for i := range n {
+		s.results[i].State.Pinned = true
+	}
+}
+
+func (s *memorySearcher) SearchNotes(ctx context.Context, params notes.NoteSearchParams, pageSize int) ([]notes.NoteSearchResult, *notes.NoteSearchParams, error) {
+	s.searched = append(s.searched, params)
+
+	start := 0
+	if params.LastNoteID.Valid {
+		start = slices.IndexFunc(s.results, func(r notes.NoteSearchResult) bool {
+			if r.State.Pinned != params.LastPinned {
+				return params.LastPinned
+			}
+			return r.ID.String() > params.LastNoteID.UUID.String()
+		})
+		if start < 0 {
+			start = len(s.results)
+		}
+	}
+
+	end := min(start+pageSize, len(s.results))
+	page := s.results[start:end]
+
+	if end == len(s.results) {
+		return page, nil, nil
+	}
+
+	last := page[len(page)-1]
+	next := params
+	next.LastNoteID = uuid.NullUUID{UUID: last.ID, Valid: true}
+	next.LastPinned = last.State.Pinned
+	return page, &next, nil
+}
+
+func resultIDs(results []notes.NoteSearchResult) []uuid.UUID {
+	ids := make([]uuid.UUID, len(results))
+	for i := range results {
+		ids[i] = results[i].ID
+	}
+
+	return ids
+}
+
+func newTestService(t *testing.T, searcher *memorySearcher) (*Service, context.Context) {
+	t.Helper()
+
+	svc := &Service{
+		repo:  newMemoryRepository(),
+		notes: searcher,
+	}
+
+	return svc, scope.WithUserID(context.Background(), uuid.New())
+}
+
+func TestService_OwnerOnly(t *testing.T) {
+	svc, ctx := newTestService(t, newMemorySearcher(1))
+
+	search, err := svc.CreateSavedSearch(ctx, &SavedSearch{Name: "mine", TrackMatches: true})
+	require.NoError(t, err)
+
+	other := scope.WithUserID(context.Background(), uuid.New())
+
+	assertForbidden := func(t *testing.T, err error) {
+		t.Helper()
+
+		var apiErr apiv1.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusForbidden, apiErr.Status())
+	}
+
+	_, err = svc.GetSavedSearch(other, search.ID)
+	assertForbidden(t, err)
+
+	_, err = svc.UpdateSavedSearch(other, &SavedSearch{ID: search.ID, Name: "theirs"})
+	assertForbidden(t, err)
+
+	_, _, err = svc.SearchResults(other, search.ID, notes.NoteSearchParams{}, 10)
+	assertForbidden(t, err)
+
+	_, _, err = svc.NewResults(other, search.ID, true)
+	assertForbidden(t, err)
+
+	assertForbidden(t, svc.DeleteSavedSearch(other, search.ID))
+
+	got, err := svc.GetSavedSearch(ctx, search.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "mine", got.Name)
+}
+
+func TestService_NewResults(t *testing.T) {
+	searcher := newMemorySearcher(3)
+	svc, ctx := newTestService(t, searcher)
+
+	search, err := svc.CreateSavedSearch(ctx, &SavedSearch{Name: "tracked", TrackMatches: true})
+	require.NoError(t, err)
+
+	// everything is new until it has been checked, and peeking doesn't mark it as seen
+	results, since, err := svc.NewResults(ctx, search.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, resultIDs(searcher.results), resultIDs(results))
+	assert.True(t, since.IsZero())
+
+	results, _, err = svc.NewResults(ctx, search.ID, true)
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	results, since, err = svc.NewResults(ctx, search.ID, true)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.False(t, since.IsZero())
+
+	// only the note that didn't match last time is new; the one that stopped matching isn't reported
+	added := newMemorySearcher(1).results[0]
+	searcher.results = append(searcher.results[1:], added)
+
+	results, _, err = svc.NewResults(ctx, search.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{added.ID}, resultIDs(results))
+}
+
+func TestService_NewResultsRequiresTracking(t *testing.T) {
+	svc, ctx := newTestService(t, newMemorySearcher(1))
+
+	search, err := svc.CreateSavedSearch(ctx, &SavedSearch{Name: "untracked"})
+	require.NoError(t, err)
+
+	_, _, err = svc.NewResults(ctx, search.ID, true)
+
+	var apiErr apiv1.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.Status())
+}
+
+func TestService_SearchResultsPaging(t *testing.T) {
+	searcher := newMemorySearcher(7)
+	// the second page starts with a pinned note, and ends with an unpinned one
+	searcher.pin(4)
+	svc, ctx := newTestService(t, searcher)
+
+	tagID := uuid.New()
+	search, err := svc.CreateSavedSearch(ctx, &SavedSearch{
+		Name:  "paged",
+		Query: notes.NoteSearchParams{Tags: notes.TagFilter{AllOf: []uuid.UUID{tagID}}},
+	})
+	require.NoError(t, err)
+
+	var (
+		all    []notes.NoteSearchResult
+		cursor notes.NoteSearchParams
+	)
+	for {
+		page, next, err := svc.SearchResults(ctx, search.ID, cursor, 3)
+		require.NoError(t, err)
+
+		all = append(all, page...)
+		if next == nil {
+			break
+		}
+
+		// the client only sends back the position, in a page token
+		cursor = notes.NoteSearchParams{
+			LastNoteID: next.LastNoteID,
+			LastPinned: next.LastPinned,
+			LastRank:   next.LastRank,
+			LastTime:   next.LastTime,
+			LastTitle:  next.LastTitle,
+		}
+	}
+
+	assert.Equal(t, resultIDs(searcher.results), resultIDs(all))
+
+	// every page searches by the saved query, not by whatever else the cursor holds
+	require.Len(t, searcher.searched, 3)
+	for _, params := range searcher.searched {
+		assert.Equal(t, []uuid.UUID{tagID}, params.Tags.AllOf)
+	}
+}
+
+func TestSavedSearch_QueryAt(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+
+	search := SavedSearch{
+		Query: notes.NoteSearchParams{
+			// a later absolute bound is kept, and an earlier one is narrowed by the window
+			CreatedAfter: now.Add(-time.Hour),
+			UpdatedAfter: now.Add(-30 * 24 * time.Hour),
+		},
+		CreatedWithin: week,
+		UpdatedWithin: week,
+	}
+
+	params := search.QueryAt(now)
+	assert.Equal(t, now.Add(-time.Hour), params.CreatedAfter)
+	assert.Equal(t, now.Add(-week), params.UpdatedAfter)
+
+	// the window moves with the time it is run at
+	params = search.QueryAt(now.Add(week))
+	assert.Equal(t, now, params.UpdatedAfter)
+
+	assert.Equal(t, search.Query, (&SavedSearch{Query: search.Query}).QueryAt(now))
+}
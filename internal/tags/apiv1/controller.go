@@ -19,6 +19,7 @@ type Service interface {
 	UpdateTag(ctx context.Context, tag *tags.Tag) (*tags.Tag, error)
 	DeleteTag(ctx context.Context, tagID uuid.UUID) error
 	GetTag(ctx context.Context, tagID uuid.UUID) (*tags.Tag, error)
+	MergeTags(ctx context.Context, intoID uuid.UUID, fromIDs []uuid.UUID) (*tags.Tag, error)
 	ListTags(ctx context.Context, params tags.TagSearchParams, pageSize int) (tags []tags.Tag, next *tags.TagSearchParams, err error)
 }
 
@@ -95,6 +96,38 @@ func DeleteTag(svc Service) http.HandlerFunc {
 	}
 }
 
+// MergeTags merges the tags listed in the body into the one in the path: their notes are tagged with it instead, and
+// they are deleted.
+func MergeTags(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tagID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid tag id"))
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[TagMerge](w, r)
+		if !ok {
+			return
+		}
+
+		fromIDs, err := body.ToDomain()
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		tag, err := svc.MergeTags(r.Context(), tagID, fromIDs)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := TagFromDomain(tag)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
 func GetTag(svc Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tagID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
@@ -61,6 +61,23 @@ func (n *WritableTag) ToDomain() (*tags.Tag, error) {
 	return out, nil
 }
 
+// TagMerge lists the tags to merge into another.
+type TagMerge struct {
+	TagIDs []string `json:"tag_ids"`
+}
+
+func (m *TagMerge) ToDomain() ([]uuid.UUID, error) {
+	var errs []error
+
+	out := apiv1.ValidateSlice(".tag_ids", m.TagIDs, &errs, uuid.Parse)
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return out, nil
+}
+
 type UserAccess struct {
 	User   User   `json:"user"`
 	Access string `json:"access"`
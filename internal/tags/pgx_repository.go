@@ -10,8 +10,10 @@ import (
 	"github.com/samber/do/v2"
 	"go.uber.org/zap"
 
+	"github.com/dabbertorres/notes/internal/audit"
 	"github.com/dabbertorres/notes/internal/common/apiv1"
 	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/events"
 	"github.com/dabbertorres/notes/internal/log"
 	"github.com/dabbertorres/notes/internal/users"
 	"github.com/dabbertorres/notes/internal/util"
@@ -20,6 +22,8 @@ import (
 type PGXRepository struct {
 	db      database.Database
 	queries *database.Queries
+	events  *events.Recorder
+	audit   *audit.Recorder
 }
 
 func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
@@ -28,9 +32,21 @@ func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
 		return nil, err
 	}
 
+	recorder, err := do.Invoke[*events.Recorder](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	auditor, err := do.Invoke[*audit.Recorder](injector)
+	if err != nil {
+		return nil, err
+	}
+
 	return &PGXRepository{
 		db:      db,
 		queries: database.New(),
+		events:  recorder,
+		audit:   auditor,
 	}, nil
 }
 
@@ -45,11 +61,17 @@ func (r *PGXRepository) SaveTag(ctx context.Context, tag *Tag) error {
 			return err
 		}
 
+		accessBefore, err := r.tagAccessByUser(ctx, tx, tag.ID)
+		if err != nil {
+			log.Error(ctx, "error getting tag access", zap.Stringer("tag_id", tag.ID), zap.Error(err))
+			return err
+		}
+
 		for _, a := range tag.Access {
 			params := database.SetTagAccessParams{
 				Column1: uuid.NullUUID{UUID: a.User.ID, Valid: true},
 				Column2: database.NullNotesAccessLevel{
-					NotesAccessLevel: database.NotesAccessLevel(a.Access),
+					NotesAccessLevel: database.NotesAccessLevel(a.Access.String()),
 					Valid:            a.Access != users.AccessLevelNone,
 				},
 				Column3: uuid.NullUUID{UUID: tag.ID, Valid: true},
@@ -61,15 +83,108 @@ func (r *PGXRepository) SaveTag(ctx context.Context, tag *Tag) error {
 			}
 		}
 
+		if err := r.recordTagSaved(ctx, tx, tag.ID, accessBefore); err != nil {
+			log.Error(ctx, "error recording tag events", zap.Stringer("tag_id", tag.ID), zap.Error(err))
+			return err
+		}
+
 		return nil
 	})
 }
 
+// recordTagSaved records that the tag with id was saved, and whether its access changed from accessBefore.
+func (r *PGXRepository) recordTagSaved(ctx context.Context, tx pgx.Tx, id uuid.UUID, accessBefore map[uuid.UUID]database.NotesAccessLevel) error {
+	accessAfter, err := r.tagAccessByUser(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = r.events.Record(ctx, tx, &events.Event{
+		Kind:     events.KindTagSaved,
+		TagID:    uuid.NullUUID{UUID: id, Valid: true},
+		Audience: events.Audience(accessAfter),
+	})
+	if err != nil {
+		return err
+	}
+
+	audience, changed := events.AccessChange(accessBefore, accessAfter)
+	if !changed {
+		return nil
+	}
+
+	// unlike its event, a new tag's first access is recorded, since it is still someone being granted access
+	err = r.audit.Record(ctx, tx, audit.ActionTagAccessChanged, audit.TagTarget(id), accessBefore, accessAfter)
+	if err != nil {
+		return err
+	}
+
+	// a new tag has no access before it's saved, and gaining its first owner isn't news to anyone
+	if len(accessBefore) == 0 {
+		return nil
+	}
+
+	return r.events.Record(ctx, tx, &events.Event{
+		Kind:     events.KindTagAccessChanged,
+		TagID:    uuid.NullUUID{UUID: id, Valid: true},
+		Audience: audience,
+	})
+}
+
+// auditedTag is what the audit log records about a deleted tag.
+type auditedTag struct {
+	Name   string                                  `json:"name"`
+	Access map[uuid.UUID]database.NotesAccessLevel `json:"access"`
+}
+
+func (r *PGXRepository) tagAccessByUser(ctx context.Context, tx pgx.Tx, id uuid.UUID) (map[uuid.UUID]database.NotesAccessLevel, error) {
+	rows, err := r.queries.GetTagAccess(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	access := make(map[uuid.UUID]database.NotesAccessLevel, len(rows))
+	for _, row := range rows {
+		access[row.UserID] = row.Access
+	}
+
+	return access, nil
+}
+
 func (r *PGXRepository) DeleteTag(ctx context.Context, id uuid.UUID) error {
 	var numDeleted int64
 	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		// who could see the tag has to be found before its access is deleted along with it
+		access, err := r.tagAccessByUser(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		row, err := r.queries.GetTag(ctx, tx, id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+
+			return err
+		}
+
 		numDeleted, err = r.queries.DeleteTag(ctx, tx, id)
-		return err
+		if err != nil || numDeleted != 1 {
+			return err
+		}
+
+		err = r.events.Record(ctx, tx, &events.Event{
+			Kind:     events.KindTagDeleted,
+			TagID:    uuid.NullUUID{UUID: id, Valid: true},
+			Audience: events.Audience(access),
+		})
+		if err != nil {
+			return err
+		}
+
+		before := auditedTag{Name: row.Name, Access: access}
+		return r.audit.Record(ctx, tx, audit.ActionTagDeleted, audit.TagTarget(id), &before, nil)
 	})
 	if err != nil {
 		log.Error(ctx, "error deleting tag", zap.Stringer("tag", id), zap.Error(err))
@@ -83,6 +198,81 @@ func (r *PGXRepository) DeleteTag(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// auditedMerge is what the audit log records about the tags merged into another.
+type auditedMerge struct {
+	Merged map[uuid.UUID]auditedTag `json:"merged"`
+	// NotesTagged is how many notes were tagged with the tag merged into, that weren't already.
+	NotesTagged int64 `json:"notes_tagged"`
+}
+
+func (r *PGXRepository) MergeTags(ctx context.Context, intoID uuid.UUID, fromIDs []uuid.UUID) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		intoAccess, err := r.tagAccessByUser(ctx, tx, intoID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := r.queries.GetTag(ctx, tx, intoID); err != nil {
+			return err
+		}
+
+		merge := auditedMerge{Merged: make(map[uuid.UUID]auditedTag, len(fromIDs))}
+		for _, id := range fromIDs {
+			// who could see the tag has to be found before its access is deleted along with it
+			access, err := r.tagAccessByUser(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+
+			row, err := r.queries.GetTag(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+
+			merge.Merged[id] = auditedTag{Name: row.Name, Access: access}
+		}
+
+		merge.NotesTagged, err = r.queries.MergeNoteTags(ctx, tx, database.MergeNoteTagsParams{
+			IntoTagID:  intoID,
+			FromTagIds: fromIDs,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, id := range fromIDs {
+			if _, err := r.queries.DeleteTag(ctx, tx, id); err != nil {
+				return err
+			}
+
+			err = r.events.Record(ctx, tx, &events.Event{
+				Kind:     events.KindTagDeleted,
+				TagID:    uuid.NullUUID{UUID: id, Valid: true},
+				Audience: events.Audience(merge.Merged[id].Access),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := r.recordTagSaved(ctx, tx, intoID, intoAccess); err != nil {
+			return err
+		}
+
+		return r.audit.Record(ctx, tx, audit.ActionTagsMerged, audit.TagTarget(intoID), &merge, nil)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apiv1.NewError(http.StatusNotFound, "tag does not exist")
+		}
+
+		log.Error(ctx, "error merging tags", zap.Stringer("tag_id", intoID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
 func (r *PGXRepository) GetTag(ctx context.Context, id uuid.UUID) (tag *Tag, err error) {
 	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
 		row, err := r.queries.GetTag(ctx, tx, id)
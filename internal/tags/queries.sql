@@ -67,3 +67,16 @@ WHEN NOT MATCHED THEN
   INSERT (tag_id, user_id, access)
   VALUES ($3::uuid, set_user_id, set_access)
 ;
+
+-- name: MergeNoteTags :execrows
+INSERT INTO notes.note_tags (
+  note_id,
+  tag_id
+)
+SELECT DISTINCT
+  note_id,
+  sqlc.arg(into_tag_id)::uuid
+FROM notes.note_tags
+WHERE tag_id = ANY(sqlc.arg(from_tag_ids)::uuid[])
+ON CONFLICT (note_id, tag_id) DO NOTHING
+;
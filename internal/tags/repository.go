@@ -14,4 +14,6 @@ type Repository interface {
 	GetTag(ctx context.Context, id uuid.UUID) (*Tag, error)
 	ListTags(ctx context.Context, userID uuid.UUID, params TagSearchParams, pageSize int) ([]Tag, error)
 	GetUsersTagAccess(ctx context.Context, id uuid.UUID, userID uuid.UUID) (users.AccessLevel, error)
+	// MergeTags tags every note tagged with any of fromIDs with intoID instead, and deletes the tags with fromIDs.
+	MergeTags(ctx context.Context, intoID uuid.UUID, fromIDs []uuid.UUID) error
 }
@@ -1,8 +1,10 @@
 package tags
 
 import (
+	"bytes"
 	"context"
 	"net/http"
+	"slices"
 
 	"github.com/google/uuid"
 	"github.com/samber/do/v2"
@@ -12,6 +14,7 @@ import (
 	"github.com/dabbertorres/notes/internal/log"
 	"github.com/dabbertorres/notes/internal/scope"
 	"github.com/dabbertorres/notes/internal/users"
+	"github.com/dabbertorres/notes/internal/util"
 )
 
 // TODO: handle error type - e.g. not found, rather than just returning internal server error
@@ -123,6 +126,54 @@ func (s *Service) GetTag(ctx context.Context, tagID uuid.UUID) (*Tag, error) {
 	return tag, nil
 }
 
+// MergeTags merges the tags with fromIDs into the one with intoID: their notes are tagged with it instead, and they
+// are deleted. The current user must own every tag merged, and be able to edit the one merged into.
+func (s *Service) MergeTags(ctx context.Context, intoID uuid.UUID, fromIDs []uuid.UUID) (*Tag, error) {
+	userID := scope.MustUserID(ctx)
+
+	fromIDs = util.SliceDistinctBy(slices.Clone(fromIDs), func(lhs, rhs uuid.UUID) int {
+		return bytes.Compare(lhs[:], rhs[:])
+	})
+	if len(fromIDs) == 0 {
+		return nil, apiv1.NewValidationFailureError(&apiv1.InvalidFieldError{Field: ".tag_ids", Err: "is required"})
+	}
+
+	if slices.Contains(fromIDs, intoID) {
+		return nil, apiv1.NewValidationFailureError(&apiv1.InvalidFieldError{
+			Field: ".tag_ids",
+			Err:   "must not include the tag being merged into",
+		})
+	}
+
+	access, err := s.repo.GetUsersTagAccess(ctx, intoID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user tag access", zap.Stringer("tag_id", intoID), zap.Error(err))
+		return nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if access < users.AccessLevelEditor {
+		return nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	for _, id := range fromIDs {
+		access, err := s.repo.GetUsersTagAccess(ctx, id, userID)
+		if err != nil {
+			log.Error(ctx, "error retrieving user tag access", zap.Stringer("tag_id", id), zap.Error(err))
+			return nil, apiv1.StatusError(http.StatusInternalServerError)
+		}
+
+		if access < users.AccessLevelOwner {
+			return nil, apiv1.StatusError(http.StatusForbidden)
+		}
+	}
+
+	if err := s.repo.MergeTags(ctx, intoID, fromIDs); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetTag(ctx, intoID)
+}
+
 func (s *Service) ListTags(ctx context.Context, params TagSearchParams, pageSize int) (tags []Tag, next *TagSearchParams, err error) {
 	userID := scope.MustUserID(ctx)
 
@@ -143,5 +194,5 @@ func (s *Service) ListTags(ctx context.Context, params TagSearchParams, pageSize
 		}
 	}
 
-	return tags, next, err
+	return results, next, err
 }
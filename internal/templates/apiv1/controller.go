@@ -0,0 +1,213 @@
+package apiv1
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/notes"
+	notesapiv1 "github.com/dabbertorres/notes/internal/notes/apiv1"
+	"github.com/dabbertorres/notes/internal/templates"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+type Service interface {
+	CreateTemplate(ctx context.Context, template *templates.Template) (*templates.Template, error)
+	UpdateTemplate(ctx context.Context, template *templates.Template) (*templates.Template, error)
+	DeleteTemplate(ctx context.Context, id uuid.UUID) error
+	GetTemplate(ctx context.Context, id uuid.UUID) (*templates.Template, error)
+	ListTemplates(ctx context.Context, params templates.TemplateListParams, pageSize int) (results []templates.Template, next *templates.TemplateListParams, err error)
+	CreateNote(ctx context.Context, id uuid.UUID, prompts map[string]string) (*notes.Note, error)
+}
+
+func PostTemplate(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := apiv1.ReadJSONOrFail[WritableTemplate](w, r)
+		if !ok {
+			return
+		}
+
+		template, err := body.ToDomain()
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		created, err := svc.CreateTemplate(r.Context(), template)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := TemplateFromDomain(created)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
+func PutTemplate(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templateID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid template id"))
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[WritableTemplate](w, r)
+		if !ok {
+			return
+		}
+
+		template, err := body.ToDomain()
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		template.ID = templateID
+
+		result, err := svc.UpdateTemplate(r.Context(), template)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := TemplateFromDomain(result)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+func DeleteTemplate(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templateID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid template id"))
+			return
+		}
+
+		if err := svc.DeleteTemplate(r.Context(), templateID); err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func GetTemplate(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templateID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid template id"))
+			return
+		}
+
+		template, err := svc.GetTemplate(r.Context(), templateID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := TemplateFromDomain(template)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
+func ListTemplates(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		paging, err := parseListTemplatesParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		params := templates.TemplateListParams{
+			LastTemplateID: paging.Data.LastTemplateID,
+		}
+		results, next, err := svc.ListTemplates(r.Context(), params, paging.PageSize)
+		if err != nil {
+			log.Error(r.Context(), "error listing templates", zap.Error(err))
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		page := apiv1.Page[Template, *ListTemplatesPageTokenData]{
+			NextPageToken: nil,
+			Items: util.MapSlice(results, func(template templates.Template) Template {
+				return TemplateFromDomain(&template)
+			}),
+		}
+
+		if next != nil {
+			page.NextPageToken = &apiv1.PageToken[*ListTemplatesPageTokenData]{
+				Data: &ListTemplatesPageTokenData{
+					LastTemplateID: next.LastTemplateID,
+				},
+				PageSize: paging.PageSize,
+			}
+		}
+
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, page)
+	}
+}
+
+// WithTemplate handles requests to create a note that have a "template" parameter, by creating the note from that
+// template. The request body is then a [NoteFromTemplate]. Other requests are passed to createNote.
+func WithTemplate(svc Service, createNote http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rawID := r.URL.Query().Get("template")
+		if rawID == "" {
+			createNote.ServeHTTP(w, r)
+			return
+		}
+
+		templateID, err := uuid.Parse(rawID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid template id"))
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[NoteFromTemplate](w, r)
+		if !ok {
+			return
+		}
+
+		created, err := svc.CreateNote(r.Context(), templateID, body.Prompts)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := notesapiv1.NoteFromDomain(created)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
+func parseListTemplatesParams(r *http.Request) (token apiv1.PageToken[*ListTemplatesPageTokenData], err error) {
+	rawPageToken := r.FormValue("next_page_token")
+	if rawPageToken != "" {
+		pageToken, err := apiv1.ParsePageToken[*ListTemplatesPageTokenData](rawPageToken, 100, 100)
+		if err != nil {
+			return token, apiv1.NewValidationFailureError(err)
+		}
+
+		token = pageToken
+	} else {
+		token.Data = &ListTemplatesPageTokenData{}
+
+		if size := r.FormValue("page_size"); size != "" {
+			pageSize, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return token, apiv1.NewValidationFailureError(err)
+			}
+
+			token.PageSize = int(pageSize)
+		}
+	}
+
+	return token, nil
+}
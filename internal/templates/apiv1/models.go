@@ -0,0 +1,116 @@
+package apiv1
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	notesapiv1 "github.com/dabbertorres/notes/internal/notes/apiv1"
+	"github.com/dabbertorres/notes/internal/templates"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+type Template struct {
+	ID         string                  `json:"id"`
+	Name       string                  `json:"name"`
+	CreatedAt  string                  `json:"created_at"`
+	CreatedBy  notesapiv1.User         `json:"created_by"`
+	UpdatedAt  string                  `json:"updated_at"`
+	UpdatedBy  notesapiv1.User         `json:"updated_by"`
+	Title      string                  `json:"title,omitempty"`
+	Body       string                  `json:"body,omitempty"`
+	Tags       []notesapiv1.Tag        `json:"tags,omitempty"`
+	NoteAccess []notesapiv1.UserAccess `json:"note_access,omitempty"`
+	Access     []notesapiv1.UserAccess `json:"access,omitempty"`
+	// Prompts lists the values that must be provided when creating a note from the template.
+	Prompts []string `json:"prompts,omitempty"`
+}
+
+func TemplateFromDomain(domain *templates.Template) (t Template) {
+	t.ID = domain.ID.String()
+	t.Name = domain.Name
+	t.CreatedAt = domain.CreatedAt.Format(time.RFC3339)
+	t.CreatedBy = notesapiv1.UserFromDomain(domain.CreatedBy)
+	t.UpdatedAt = domain.UpdatedAt.Format(time.RFC3339)
+	t.UpdatedBy = notesapiv1.UserFromDomain(domain.UpdatedBy)
+	t.Title = domain.Title
+	t.Body = domain.Body
+	t.Tags = util.MapSlice(domain.Tags, notesapiv1.TagFromDomain)
+	t.NoteAccess = util.MapSlice(domain.NoteAccess, notesapiv1.UserAccessFromDomain)
+	t.Access = util.MapSlice(domain.Access, notesapiv1.UserAccessFromDomain)
+	t.Prompts = domain.Prompts()
+	return t
+}
+
+// WritableTemplate contains only the subset of fields on [Template] that an API user can modify.
+type WritableTemplate struct {
+	Name       string                  `json:"name"`
+	Title      string                  `json:"title,omitempty"`
+	Body       string                  `json:"body,omitempty"`
+	Tags       []notesapiv1.Tag        `json:"tags,omitempty"`
+	NoteAccess []notesapiv1.UserAccess `json:"note_access,omitempty"`
+	Access     []notesapiv1.UserAccess `json:"access,omitempty"`
+}
+
+func (t *WritableTemplate) ToDomain() (*templates.Template, error) {
+	var errs []error
+
+	if t.Name == "" {
+		errs = append(errs, &apiv1.InvalidFieldError{Field: ".name", Err: apiv1.ErrFieldNotSet.Error()})
+	}
+
+	out := &templates.Template{
+		Name:       t.Name,
+		Title:      t.Title,
+		Body:       t.Body,
+		Tags:       util.MapSlice(t.Tags, notesapiv1.Tag.ToDomain),
+		NoteAccess: apiv1.ValidateSlice(".note_access", t.NoteAccess, &errs, notesapiv1.UserAccess.ToDomain),
+		Access:     apiv1.ValidateSlice(".access", t.Access, &errs, notesapiv1.UserAccess.ToDomain),
+	}
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return out, nil
+}
+
+// NoteFromTemplate is the body of a request to create a note from a template.
+type NoteFromTemplate struct {
+	// Prompts holds the values of the template's prompts, by name.
+	Prompts map[string]string `json:"prompts,omitempty"`
+}
+
+type ListTemplatesPageTokenData struct {
+	LastTemplateID uuid.NullUUID
+}
+
+func (d *ListTemplatesPageTokenData) EncodePager() ([][]byte, error) {
+	var out [1][]byte
+
+	if d.LastTemplateID.Valid {
+		out[0] = []byte(d.LastTemplateID.UUID.String())
+	}
+
+	return out[:], nil
+}
+
+func (d *ListTemplatesPageTokenData) DecodePager(data [][]byte) error {
+	if len(data) != 1 {
+		return errors.New("invalid page token format (incorrect number of parts)")
+	}
+
+	if len(data[0]) != 0 {
+		lastID, err := uuid.ParseBytes(data[0])
+		if err != nil {
+			return err
+		}
+
+		d.LastTemplateID.UUID = lastID
+		d.LastTemplateID.Valid = true
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package templates
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/tags"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// Template is the starting point for new notes. Its Title and Body may contain placeholders (see [Render]).
+type Template struct {
+	ID        uuid.UUID
+	Name      string
+	CreatedAt time.Time
+	CreatedBy users.User
+	UpdatedAt time.Time
+	UpdatedBy users.User
+	Title     string
+	Body      string
+	// Tags are applied to notes created from the template.
+	Tags []tags.Tag
+	// NoteAccess is granted on notes created from the template, in addition to the creator owning it.
+	NoteAccess []users.Access
+	// Access controls who can use (viewer) and change (editor) the template itself.
+	Access []users.Access
+}
+
+// Prompts returns the names of the values that must be provided to create a note from t.
+func (t *Template) Prompts() []string {
+	return Prompts(t.Title, t.Body)
+}
+
+type TemplateListParams struct {
+	LastTemplateID uuid.NullUUID
+}
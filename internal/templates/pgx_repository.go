@@ -0,0 +1,272 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/tags"
+	"github.com/dabbertorres/notes/internal/users"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+type PGXRepository struct {
+	db      database.Database
+	queries *database.Queries
+}
+
+func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGXRepository{
+		db:      db,
+		queries: database.New(),
+	}, nil
+}
+
+func (r *PGXRepository) SaveTemplate(ctx context.Context, template *Template) error {
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		err := r.queries.SaveTemplate(ctx, tx, database.SaveTemplateParams{
+			TemplateID: template.ID,
+			Name:       template.Name,
+			Title:      template.Title,
+			Body:       template.Body,
+			CreatedAt:  pgtype.Timestamptz{Time: template.CreatedAt, Valid: true},
+			CreatedBy:  uuid.NullUUID{UUID: template.CreatedBy.ID, Valid: template.CreatedBy.ID != uuid.Nil},
+			UpdatedAt:  pgtype.Timestamptz{Time: template.UpdatedAt, Valid: true},
+			UpdatedBy:  uuid.NullUUID{UUID: template.UpdatedBy.ID, Valid: template.UpdatedBy.ID != uuid.Nil},
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := r.queries.ClearTemplateTags(ctx, tx, template.ID); err != nil {
+			return err
+		}
+
+		err = r.queries.AddTemplateTags(ctx, tx, database.AddTemplateTagsParams{
+			TemplateID: template.ID,
+			TagIds:     util.MapSlice(template.Tags, func(t tags.Tag) uuid.UUID { return t.ID }),
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := r.queries.ClearTemplateNoteAccess(ctx, tx, template.ID); err != nil {
+			return err
+		}
+
+		userIDs, levels := accessParams(template.NoteAccess)
+		err = r.queries.AddTemplateNoteAccess(ctx, tx, database.AddTemplateNoteAccessParams{
+			TemplateID: template.ID,
+			UserIds:    userIDs,
+			Access:     levels,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := r.queries.ClearTemplateAccess(ctx, tx, template.ID); err != nil {
+			return err
+		}
+
+		userIDs, levels = accessParams(template.Access)
+		return r.queries.AddTemplateAccess(ctx, tx, database.AddTemplateAccessParams{
+			TemplateID: template.ID,
+			UserIds:    userIDs,
+			Access:     levels,
+		})
+	})
+	if err != nil {
+		log.Error(ctx, "error saving template", zap.Stringer("template_id", template.ID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) DeleteTemplate(ctx context.Context, id uuid.UUID) error {
+	var numDeleted int64
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		numDeleted, err = r.queries.DeleteTemplate(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error deleting template", zap.Stringer("template_id", id), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	if numDeleted != 1 {
+		return apiv1.NewError(http.StatusNotFound, "template does not exist")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) GetTemplate(ctx context.Context, id, asUserID uuid.UUID) (template *Template, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.GetTemplate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		tagRows, err := r.queries.GetTemplateTags(ctx, tx, database.GetTemplateTagsParams{
+			UserID:     asUserID,
+			TemplateID: id,
+		})
+		if err != nil {
+			return err
+		}
+
+		noteAccessRows, err := r.queries.GetTemplateNoteAccess(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		accessRows, err := r.queries.GetTemplateAccess(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		template = templateFromRow(&row)
+		template.Tags = util.MapSlice(tagRows, func(row database.NotesTag) tags.Tag {
+			return tags.Tag{
+				ID:   row.TagID,
+				Name: row.Name,
+			}
+		})
+
+		template.NoteAccess, err = accessFromRows(noteAccessRows, func(row database.GetTemplateNoteAccessRow) (uuid.UUID, database.NotesAccessLevel) {
+			return row.UserID, row.Access
+		})
+		if err != nil {
+			return err
+		}
+
+		template.Access, err = accessFromRows(accessRows, func(row database.GetTemplateAccessRow) (uuid.UUID, database.NotesAccessLevel) {
+			return row.UserID, row.Access
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apiv1.NewError(http.StatusNotFound, "template does not exist")
+		}
+
+		log.Error(ctx, "error fetching template", zap.Stringer("template_id", id), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return template, nil
+}
+
+func (r *PGXRepository) ListTemplates(ctx context.Context, userID uuid.UUID, params TemplateListParams, pageSize int) (templates []Template, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ListTemplates(ctx, tx, database.ListTemplatesParams{
+			UserID:         userID,
+			LastTemplateID: params.LastTemplateID,
+			PageSize:       int64(pageSize),
+		})
+		if err != nil {
+			return err
+		}
+
+		templates = make([]Template, 0, len(rows))
+		for i := range rows {
+			templates = append(templates, *templateFromRow(&rows[i]))
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error listing templates", zap.Stringer("user_id", userID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return templates, nil
+}
+
+func (r *PGXRepository) GetUsersTemplateAccess(ctx context.Context, id, userID uuid.UUID) (level users.AccessLevel, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		access, err := r.queries.GetUserTemplateAccess(ctx, tx, database.GetUserTemplateAccessParams{
+			TemplateID: id,
+			UserID:     userID,
+		})
+		if err != nil {
+			return err
+		}
+
+		level, err = users.ParseAccessLevel(string(access))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return users.AccessLevelNone, nil
+		}
+
+		return level, err
+	}
+
+	return level, nil
+}
+
+func templateFromRow(row *database.NotesTemplate) *Template {
+	return &Template{
+		ID:        row.TemplateID,
+		Name:      row.Name,
+		CreatedAt: row.CreatedAt.Time,
+		CreatedBy: users.User{ID: row.CreatedBy.UUID},
+		UpdatedAt: row.UpdatedAt.Time,
+		UpdatedBy: users.User{ID: row.UpdatedBy.UUID},
+		Title:     row.Title,
+		Body:      row.Body,
+	}
+}
+
+func accessParams(access []users.Access) (userIDs []uuid.UUID, levels []string) {
+	userIDs = make([]uuid.UUID, 0, len(access))
+	levels = make([]string, 0, len(access))
+
+	for _, a := range access {
+		if a.Access == users.AccessLevelNone {
+			continue
+		}
+
+		userIDs = append(userIDs, a.User.ID)
+		levels = append(levels, a.Access.String())
+	}
+
+	return userIDs, levels
+}
+
+func accessFromRows[T any](rows []T, get func(T) (uuid.UUID, database.NotesAccessLevel)) ([]users.Access, error) {
+	out := make([]users.Access, 0, len(rows))
+
+	for _, row := range rows {
+		userID, access := get(row)
+
+		level, err := users.ParseAccessLevel(string(access))
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, users.Access{
+			User:   users.User{ID: userID},
+			Access: level,
+		})
+	}
+
+	return out, nil
+}
@@ -0,0 +1,155 @@
+-- name: SaveTemplate :exec
+INSERT INTO notes.templates (
+  template_id,
+  name,
+  title,
+  body,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by
+) VALUES (
+  sqlc.arg(template_id),
+  sqlc.arg(name),
+  sqlc.arg(title),
+  sqlc.arg(body),
+  sqlc.arg(created_at),
+  sqlc.arg(created_by),
+  sqlc.arg(updated_at),
+  sqlc.arg(updated_by)
+) ON CONFLICT (template_id) DO UPDATE
+  SET name       = excluded.name,
+      title      = excluded.title,
+      body       = excluded.body,
+      updated_at = excluded.updated_at,
+      updated_by = excluded.updated_by
+;
+
+-- name: DeleteTemplate :execrows
+DELETE FROM notes.templates
+WHERE template_id = sqlc.arg(template_id)
+;
+
+-- name: GetTemplate :one
+SELECT
+  template_id,
+  name,
+  title,
+  body,
+  created_at,
+  created_by,
+  updated_at,
+  updated_by
+FROM notes.templates
+WHERE template_id = sqlc.arg(template_id)
+;
+
+-- name: ListTemplates :many
+SELECT
+  templates.template_id,
+  templates.name,
+  templates.title,
+  templates.body,
+  templates.created_at,
+  templates.created_by,
+  templates.updated_at,
+  templates.updated_by
+FROM notes.templates
+JOIN notes.user_template_access ON
+  user_template_access.template_id = templates.template_id
+  AND user_template_access.user_id = sqlc.arg(user_id)
+  -- NOTE: any access
+WHERE (sqlc.narg(last_template_id)::uuid IS NULL OR templates.template_id > sqlc.narg(last_template_id)::uuid)
+ORDER BY templates.template_id ASC
+LIMIT sqlc.arg(page_size)
+;
+
+-- name: GetUserTemplateAccess :one
+SELECT
+  access
+FROM notes.user_template_access
+WHERE template_id = sqlc.arg(template_id)
+  AND user_id = sqlc.arg(user_id)
+;
+
+-- name: GetTemplateAccess :many
+SELECT
+  user_id,
+  access
+FROM notes.user_template_access
+WHERE template_id = sqlc.arg(template_id)
+;
+
+-- name: ClearTemplateAccess :exec
+DELETE FROM notes.user_template_access
+WHERE template_id = sqlc.arg(template_id)
+;
+
+-- name: AddTemplateAccess :exec
+INSERT INTO notes.user_template_access (
+  template_id,
+  user_id,
+  access
+)
+SELECT
+  sqlc.arg(template_id),
+  grants.user_id,
+  grants.access::notes.access_level
+FROM UNNEST(sqlc.arg(user_ids)::uuid[], sqlc.arg(access)::text[]) AS grants (user_id, access)
+;
+
+-- name: GetTemplateTags :many
+SELECT
+  tags.tag_id,
+  tags.name
+FROM notes.template_tags
+JOIN notes.tags ON
+  template_tags.tag_id = tags.tag_id
+JOIN notes.user_tag_access ON
+  user_tag_access.tag_id = tags.tag_id
+  AND user_tag_access.user_id = sqlc.arg(user_id)
+  -- NOTE: any access
+WHERE template_tags.template_id = sqlc.arg(template_id)
+;
+
+-- name: ClearTemplateTags :exec
+DELETE FROM notes.template_tags
+WHERE template_id = sqlc.arg(template_id)
+;
+
+-- name: AddTemplateTags :exec
+INSERT INTO notes.template_tags (
+  template_id,
+  tag_id
+)
+SELECT
+  sqlc.arg(template_id),
+  UNNEST(sqlc.arg(tag_ids)::uuid[])
+ON CONFLICT (template_id, tag_id) DO NOTHING
+;
+
+-- name: GetTemplateNoteAccess :many
+SELECT
+  user_id,
+  access
+FROM notes.template_note_access
+WHERE template_id = sqlc.arg(template_id)
+;
+
+-- name: ClearTemplateNoteAccess :exec
+DELETE FROM notes.template_note_access
+WHERE template_id = sqlc.arg(template_id)
+;
+
+-- name: AddTemplateNoteAccess :exec
+INSERT INTO notes.template_note_access (
+  template_id,
+  user_id,
+  access
+)
+SELECT
+  sqlc.arg(template_id),
+  grants.user_id,
+  grants.access::notes.access_level
+FROM UNNEST(sqlc.arg(user_ids)::uuid[], sqlc.arg(access)::text[]) AS grants (user_id, access)
+;
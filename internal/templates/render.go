@@ -0,0 +1,112 @@
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+const promptPrefix = "prompt:"
+
+// Variables are the values substituted for placeholders in a template.
+type Variables struct {
+	Now  time.Time
+	User users.User
+	// Prompts holds the values of {{prompt:<name>}} placeholders, by name.
+	Prompts map[string]string
+}
+
+// MissingPromptsError is returned by [Render] when a template has prompts that were not given values.
+type MissingPromptsError struct {
+	Prompts []string
+}
+
+func (e *MissingPromptsError) Error() string {
+	return fmt.Sprintf("missing values for prompts: %s", strings.Join(e.Prompts, ", "))
+}
+
+// Render replaces the placeholders in text:
+//
+//	{{date}}          the current date, e.g. 2006-01-02
+//	{{time}}          the current time, e.g. 15:04
+//	{{datetime}}      the current date and time in RFC 3339 format
+//	{{user.name}}     the name of the user creating the note
+//	{{prompt:<name>}} a value provided by the user creating the note
+//
+// Anything else between double braces is left as-is.
+func Render(text string, vars *Variables) (string, error) {
+	var missing []string
+
+	out := placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+
+		switch name {
+		case "date":
+			return vars.Now.Format(time.DateOnly)
+		case "time":
+			return vars.Now.Format("15:04")
+		case "datetime":
+			return vars.Now.Format(time.RFC3339)
+		case "user.name":
+			return vars.User.Name
+		}
+
+		if prompt, ok := strings.CutPrefix(name, promptPrefix); ok {
+			prompt = strings.TrimSpace(prompt)
+
+			value, ok := vars.Prompts[prompt]
+			if !ok {
+				missing = append(missing, prompt)
+			}
+
+			return value
+		}
+
+		return match
+	})
+
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return "", &MissingPromptsError{Prompts: slices.Compact(missing)}
+	}
+
+	return out, nil
+}
+
+// Prompts returns the distinct names of the {{prompt:<name>}} placeholders in texts, in the order they first appear.
+func Prompts(texts ...string) []string {
+	var out []string
+
+	for _, text := range texts {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+			if prompt, ok := strings.CutPrefix(match[1], promptPrefix); ok {
+				prompt = strings.TrimSpace(prompt)
+				if !slices.Contains(out, prompt) {
+					out = append(out, prompt)
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+// usesUser reports whether any of texts refer to the user creating the note.
+func usesUser(texts ...string) bool {
+	for _, text := range texts {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+			if strings.HasPrefix(match[1], "user.") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
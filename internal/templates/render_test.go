@@ -0,0 +1,67 @@
+package templates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+func TestRender(t *testing.T) {
+	vars := &Variables{
+		Now:     time.Date(2024, 3, 9, 14, 5, 0, 0, time.UTC),
+		User:    users.User{Name: "Ada"},
+		Prompts: map[string]string{"Service": "billing"},
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		missing []string
+	}{
+		{
+			name: "builtins",
+			text: "Incident {{date}} {{ time }} by {{user.name}}",
+			want: "Incident 2024-03-09 14:05 by Ada",
+		},
+		{
+			name: "prompt",
+			text: "# {{prompt:Service}} outage ({{ prompt: Service }})",
+			want: "# billing outage (billing)",
+		},
+		{
+			name: "unknown placeholders are kept",
+			text: "{{unknown}} and {{ .Go }}",
+			want: "{{unknown}} and {{ .Go }}",
+		},
+		{
+			name:    "missing prompts",
+			text:    "{{prompt:Severity}} {{prompt:Owner}} {{prompt:Severity}}",
+			missing: []string{"Owner", "Severity"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.text, vars)
+			if tt.missing != nil {
+				var missingErr *MissingPromptsError
+				require.ErrorAs(t, err, &missingErr)
+				assert.Equal(t, tt.missing, missingErr.Prompts)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPrompts(t *testing.T) {
+	got := Prompts("{{prompt:Service}} {{date}}", "{{ prompt:Severity }} {{prompt:Service}}")
+	assert.Equal(t, []string{"Service", "Severity"}, got)
+}
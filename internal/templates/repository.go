@@ -0,0 +1,18 @@
+package templates
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+type Repository interface {
+	// SaveTemplate creates or updates a template, replacing its tags and access.
+	SaveTemplate(ctx context.Context, template *Template) error
+	DeleteTemplate(ctx context.Context, id uuid.UUID) error
+	GetTemplate(ctx context.Context, id, asUserID uuid.UUID) (*Template, error)
+	ListTemplates(ctx context.Context, userID uuid.UUID, params TemplateListParams, pageSize int) ([]Template, error)
+	GetUsersTemplateAccess(ctx context.Context, id, userID uuid.UUID) (users.AccessLevel, error)
+}
@@ -0,0 +1,259 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/scope"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+type NoteCreator interface {
+	CreateNote(ctx context.Context, note *notes.Note) (*notes.Note, error)
+}
+
+type UserGetter interface {
+	GetUser(ctx context.Context, userID uuid.UUID) (*users.User, error)
+}
+
+type Service struct {
+	repo  Repository
+	notes NoteCreator
+	users UserGetter
+}
+
+func NewService(injector do.Injector) (*Service, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := do.InvokeAs[NoteCreator](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := do.InvokeAs[UserGetter](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		repo:  repo,
+		notes: notes,
+		users: users,
+	}, nil
+}
+
+func (s *Service) CreateTemplate(ctx context.Context, template *Template) (*Template, error) {
+	userID := scope.MustUserID(ctx)
+
+	templateID, err := uuid.NewV7()
+	if err != nil {
+		return nil, apiv1.StatusError(http.StatusServiceUnavailable)
+	}
+
+	template.ID = templateID
+	template.CreatedAt = time.Now()
+	template.CreatedBy = users.User{ID: userID}
+	template.UpdatedAt = template.CreatedAt
+	template.UpdatedBy = users.User{ID: userID}
+	template.Access = append(withoutUser(template.Access, userID), users.Access{
+		User:   template.CreatedBy,
+		Access: users.AccessLevelOwner,
+	})
+
+	if err := s.repo.SaveTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// UpdateTemplate replaces a template. Only owners may change who has access to it.
+func (s *Service) UpdateTemplate(ctx context.Context, template *Template) (*Template, error) {
+	userID := scope.MustUserID(ctx)
+
+	access, err := s.getAccess(ctx, template.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if access < users.AccessLevelEditor {
+		return nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	existing, err := s.repo.GetTemplate(ctx, template.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if access < users.AccessLevelOwner {
+		template.Access = existing.Access
+	} else if !hasOwner(template.Access) {
+		return nil, apiv1.NewError(http.StatusBadRequest, "a template must have an owner")
+	}
+
+	template.CreatedAt = existing.CreatedAt
+	template.CreatedBy = existing.CreatedBy
+	template.UpdatedAt = time.Now()
+	template.UpdatedBy = users.User{ID: userID}
+
+	if err := s.repo.SaveTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (s *Service) DeleteTemplate(ctx context.Context, templateID uuid.UUID) error {
+	access, err := s.getAccess(ctx, templateID)
+	if err != nil {
+		return err
+	}
+
+	if access < users.AccessLevelOwner {
+		return apiv1.StatusError(http.StatusForbidden)
+	}
+
+	return s.repo.DeleteTemplate(ctx, templateID)
+}
+
+func (s *Service) GetTemplate(ctx context.Context, templateID uuid.UUID) (*Template, error) {
+	userID := scope.MustUserID(ctx)
+
+	access, err := s.getAccess(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if access < users.AccessLevelViewer {
+		return nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	return s.repo.GetTemplate(ctx, templateID, userID)
+}
+
+func (s *Service) ListTemplates(ctx context.Context, params TemplateListParams, pageSize int) (templates []Template, next *TemplateListParams, err error) {
+	userID := scope.MustUserID(ctx)
+
+	if pageSize == 0 {
+		pageSize = 100
+	}
+
+	// retrieve one more to see if there is another page to fetch
+	templates, err = s.repo.ListTemplates(ctx, userID, params, pageSize+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(templates) > pageSize {
+		templates = templates[:pageSize]
+
+		last := &templates[len(templates)-1]
+
+		next = &TemplateListParams{
+			LastTemplateID: uuid.NullUUID{UUID: last.ID, Valid: true},
+		}
+	}
+
+	return templates, next, nil
+}
+
+// CreateNote creates a new note from a template, filling in its placeholders.
+// prompts holds the values of the template's prompts, by name.
+func (s *Service) CreateNote(ctx context.Context, templateID uuid.UUID, prompts map[string]string) (*notes.Note, error) {
+	userID := scope.MustUserID(ctx)
+
+	template, err := s.GetTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := &Variables{
+		Now:     time.Now(),
+		User:    users.User{ID: userID},
+		Prompts: prompts,
+	}
+
+	if usesUser(template.Title, template.Body) {
+		user, err := s.users.GetUser(ctx, userID)
+		if err != nil {
+			log.Error(ctx, "error fetching user for template", zap.Stringer("template_id", templateID), zap.Error(err))
+			return nil, apiv1.StatusError(http.StatusInternalServerError)
+		}
+
+		vars.User = *user
+	}
+
+	title, err := Render(template.Title, vars)
+	if err != nil {
+		return nil, renderError(err)
+	}
+
+	body, err := Render(template.Body, vars)
+	if err != nil {
+		return nil, renderError(err)
+	}
+
+	note := &notes.Note{
+		Title: title,
+		Body:  body,
+		Tags:  template.Tags,
+		// the creator is always the note's owner
+		Access: withoutUser(template.NoteAccess, userID),
+	}
+
+	return s.notes.CreateNote(ctx, note)
+}
+
+func (s *Service) getAccess(ctx context.Context, templateID uuid.UUID) (users.AccessLevel, error) {
+	userID := scope.MustUserID(ctx)
+
+	access, err := s.repo.GetUsersTemplateAccess(ctx, templateID, userID)
+	if err != nil {
+		log.Error(ctx, "error retrieving user template access", zap.Stringer("template_id", templateID), zap.Error(err))
+		return access, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	return access, nil
+}
+
+func renderError(err error) error {
+	var missing *MissingPromptsError
+	if errors.As(err, &missing) {
+		return apiv1.NewError(http.StatusBadRequest, "missing values for prompts", missing.Prompts...)
+	}
+
+	return apiv1.StatusError(http.StatusInternalServerError)
+}
+
+func withoutUser(access []users.Access, userID uuid.UUID) []users.Access {
+	out := make([]users.Access, 0, len(access))
+	for _, a := range access {
+		if a.User.ID != userID {
+			out = append(out, a)
+		}
+	}
+
+	return out
+}
+
+func hasOwner(access []users.Access) bool {
+	for _, a := range access {
+		if a.Access == users.AccessLevelOwner {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,9 @@
+// Package templates manages note templates, which new notes can be created from.
+package templates
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewPGXRepository),
+	do.Lazy(NewService),
+)
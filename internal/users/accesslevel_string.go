@@ -3,9 +3,7 @@
 package users
 
 import "errors"
-import "slices"
 import "strconv"
-import "strings"
 
 func _() {
 	// An "invalid array index" compiler error signifies that the constant values have changed.
@@ -13,13 +11,14 @@ func _() {
 	var x [1]struct{}
 	_ = x[AccessLevelNone-0]
 	_ = x[AccessLevelViewer-1]
-	_ = x[AccessLevelEditor-2]
-	_ = x[AccessLevelOwner-3]
+	_ = x[AccessLevelCommenter-2]
+	_ = x[AccessLevelEditor-3]
+	_ = x[AccessLevelOwner-4]
 }
 
-const _AccessLevel_name = "noneviewereditorowner"
+const _AccessLevel_name = "noneviewercommentereditorowner"
 
-var _AccessLevel_index = [...]uint8{0, 4, 10, 16, 21}
+var _AccessLevel_index = [...]uint8{0, 4, 10, 19, 25, 30}
 
 func (i AccessLevel) String() string {
 	if i >= AccessLevel(len(_AccessLevel_index)-1) {
@@ -29,16 +28,12 @@ func (i AccessLevel) String() string {
 }
 
 func ParseAccessLevel(s string) (AccessLevel, error) {
-	idx := strings.Index(_AccessLevel_name, s)
-	if idx == -1 {
-		var zero AccessLevel
-		return zero, errors.New("invalid value")
+	for i := 0; i < len(_AccessLevel_index)-1; i++ {
+		if _AccessLevel_name[_AccessLevel_index[i]:_AccessLevel_index[i+1]] == s {
+			return AccessLevel(i), nil
+		}
 	}
 
-	value, ok := slices.BinarySearch(_AccessLevel_index[:], uint8(idx))
-	if !ok {
-		panic("could not find index of string - this should not be possible")
-	}
-
-	return AccessLevel(value), nil
+	var zero AccessLevel
+	return zero, errors.New("invalid value")
 }
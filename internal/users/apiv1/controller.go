@@ -12,7 +12,6 @@ type TODO = any
 type Service interface {
 	Create(context.Context, *users.User) (*users.User, error)
 	Update(context.Context, *users.User) error
-	SignIn(context.Context, TODO) error
 	SignOut(context.Context, TODO) error
 }
 
@@ -28,12 +27,6 @@ func PutUser(svc Service) http.HandlerFunc {
 	}
 }
 
-func PostSession(svc Service) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusNotImplemented)
-	}
-}
-
 func DeleteSession(svc Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotImplemented)
@@ -20,6 +20,8 @@ type AccessLevel byte
 const (
 	AccessLevelNone AccessLevel = iota
 	AccessLevelViewer
+	// AccessLevelCommenter can view, and discuss, without editing.
+	AccessLevelCommenter
 	AccessLevelEditor
 	AccessLevelOwner
 )
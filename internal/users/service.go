@@ -41,10 +41,6 @@ func (s *Service) Update(context.Context, *User) error {
 	return apiv1.StatusError(http.StatusNotImplemented)
 }
 
-func (s *Service) SignIn(context.Context, TODO) error {
-	return apiv1.StatusError(http.StatusNotImplemented)
-}
-
 func (s *Service) SignOut(context.Context, TODO) error {
 	return apiv1.StatusError(http.StatusNotImplemented)
 }
@@ -0,0 +1,251 @@
+package apiv1
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/util"
+	"github.com/dabbertorres/notes/internal/webhooks"
+)
+
+type Service interface {
+	CreateWebhook(ctx context.Context, webhook *webhooks.Webhook) (*webhooks.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *webhooks.Webhook) (*webhooks.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	GetWebhook(ctx context.Context, id uuid.UUID) (*webhooks.Webhook, error)
+	ListWebhooks(ctx context.Context, params webhooks.WebhookListParams, pageSize int) (results []webhooks.Webhook, next *webhooks.WebhookListParams, err error)
+	ListDeliveries(ctx context.Context, id uuid.UUID, params webhooks.DeliveryListParams, pageSize int) (results []webhooks.Delivery, next *webhooks.DeliveryListParams, err error)
+}
+
+func PostWebhook(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := apiv1.ReadJSONOrFail[WritableWebhook](w, r)
+		if !ok {
+			return
+		}
+
+		webhook, err := body.ToDomain()
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		created, err := svc.CreateWebhook(r.Context(), webhook)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := WebhookFromDomain(created)
+		// this is the only chance to see a generated secret
+		dto.Secret = created.Secret
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
+func PutWebhook(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid webhook id"))
+			return
+		}
+
+		body, ok := apiv1.ReadJSONOrFail[WritableWebhook](w, r)
+		if !ok {
+			return
+		}
+
+		webhook, err := body.ToDomain()
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewValidationFailureError(err))
+			return
+		}
+
+		webhook.ID = webhookID
+
+		result, err := svc.UpdateWebhook(r.Context(), webhook)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		out := WebhookFromDomain(result)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &out)
+	}
+}
+
+func DeleteWebhook(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid webhook id"))
+			return
+		}
+
+		if err := svc.DeleteWebhook(r.Context(), webhookID); err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func GetWebhook(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid webhook id"))
+			return
+		}
+
+		webhook, err := svc.GetWebhook(r.Context(), webhookID)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		dto := WebhookFromDomain(webhook)
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, &dto)
+	}
+}
+
+func ListWebhooks(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		paging, err := parseListWebhooksParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		params := webhooks.WebhookListParams{
+			LastWebhookID: paging.Data.LastWebhookID,
+		}
+		results, next, err := svc.ListWebhooks(r.Context(), params, paging.PageSize)
+		if err != nil {
+			log.Error(r.Context(), "error listing webhooks", zap.Error(err))
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		page := apiv1.Page[Webhook, *ListWebhooksPageTokenData]{
+			NextPageToken: nil,
+			Items: util.MapSlice(results, func(webhook webhooks.Webhook) Webhook {
+				return WebhookFromDomain(&webhook)
+			}),
+		}
+
+		if next != nil {
+			page.NextPageToken = &apiv1.PageToken[*ListWebhooksPageTokenData]{
+				Data: &ListWebhooksPageTokenData{
+					LastWebhookID: next.LastWebhookID,
+				},
+				PageSize: paging.PageSize,
+			}
+		}
+
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, page)
+	}
+}
+
+// ListDeliveries lists the deliveries made to a webhook, most recent first.
+func ListDeliveries(svc Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhookID, err := apiv1.ParsePathValue(r, "id", true, uuid.Parse)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusBadRequest, "invalid webhook id"))
+			return
+		}
+
+		paging, err := parseListDeliveriesParams(r)
+		if err != nil {
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		params := webhooks.DeliveryListParams{
+			LastDeliveryID: paging.Data.LastDeliveryID,
+		}
+		results, next, err := svc.ListDeliveries(r.Context(), webhookID, params, paging.PageSize)
+		if err != nil {
+			log.Error(r.Context(), "error listing webhook deliveries", zap.Stringer("webhook_id", webhookID), zap.Error(err))
+			apiv1.WriteError(r.Context(), w, err)
+			return
+		}
+
+		page := apiv1.Page[Delivery, *ListDeliveriesPageTokenData]{
+			NextPageToken: nil,
+			Items: util.MapSlice(results, func(delivery webhooks.Delivery) Delivery {
+				return DeliveryFromDomain(&delivery)
+			}),
+		}
+
+		if next != nil {
+			page.NextPageToken = &apiv1.PageToken[*ListDeliveriesPageTokenData]{
+				Data: &ListDeliveriesPageTokenData{
+					LastDeliveryID: next.LastDeliveryID,
+				},
+				PageSize: paging.PageSize,
+			}
+		}
+
+		apiv1.WriteJSON(r.Context(), w, http.StatusOK, page)
+	}
+}
+
+func parseListWebhooksParams(r *http.Request) (token apiv1.PageToken[*ListWebhooksPageTokenData], err error) {
+	rawPageToken := r.FormValue("next_page_token")
+	if rawPageToken != "" {
+		pageToken, err := apiv1.ParsePageToken[*ListWebhooksPageTokenData](rawPageToken, 100, 100)
+		if err != nil {
+			return token, apiv1.NewValidationFailureError(err)
+		}
+
+		token = pageToken
+	} else {
+		token.Data = &ListWebhooksPageTokenData{}
+
+		if size := r.FormValue("page_size"); size != "" {
+			pageSize, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return token, apiv1.NewValidationFailureError(err)
+			}
+
+			token.PageSize = int(pageSize)
+		}
+	}
+
+	return token, nil
+}
+
+func parseListDeliveriesParams(r *http.Request) (token apiv1.PageToken[*ListDeliveriesPageTokenData], err error) {
+	rawPageToken := r.FormValue("next_page_token")
+	if rawPageToken != "" {
+		pageToken, err := apiv1.ParsePageToken[*ListDeliveriesPageTokenData](rawPageToken, 100, 100)
+		if err != nil {
+			return token, apiv1.NewValidationFailureError(err)
+		}
+
+		token = pageToken
+	} else {
+		token.Data = &ListDeliveriesPageTokenData{}
+
+		if size := r.FormValue("page_size"); size != "" {
+			pageSize, err := strconv.ParseInt(size, 10, 64)
+			if err != nil {
+				return token, apiv1.NewValidationFailureError(err)
+			}
+
+			token.PageSize = int(pageSize)
+		}
+	}
+
+	return token, nil
+}
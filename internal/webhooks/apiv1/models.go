@@ -0,0 +1,192 @@
+package apiv1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/events"
+	"github.com/dabbertorres/notes/internal/util"
+	"github.com/dabbertorres/notes/internal/webhooks"
+)
+
+type Webhook struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Secret is only returned when a webhook is created.
+	Secret              string      `json:"secret,omitempty"`
+	Events              []string    `json:"events,omitempty"`
+	Tags                []uuid.UUID `json:"tags,omitempty"`
+	Active              bool        `json:"active"`
+	ConsecutiveFailures int         `json:"consecutive_failures"`
+	DisabledAt          string      `json:"disabled_at,omitempty"`
+	CreatedAt           string      `json:"created_at"`
+	UpdatedAt           string      `json:"updated_at"`
+}
+
+func WebhookFromDomain(domain *webhooks.Webhook) (w Webhook) {
+	w.ID = domain.ID.String()
+	w.URL = domain.URL
+	w.Events = util.MapSlice(domain.EventKinds, func(kind events.Kind) string { return string(kind) })
+	w.Tags = domain.TagIDs
+	w.Active = domain.Active
+	w.ConsecutiveFailures = domain.ConsecutiveFailures
+	w.DisabledAt = formatOptionalTime(domain.DisabledAt)
+	w.CreatedAt = domain.CreatedAt.Format(time.RFC3339)
+	w.UpdatedAt = domain.UpdatedAt.Format(time.RFC3339)
+	return w
+}
+
+// WritableWebhook contains only the subset of fields on [Webhook] that an API user can modify.
+type WritableWebhook struct {
+	URL string `json:"url"`
+	// Secret is generated when creating a webhook, and left as is when updating one, if it is not set.
+	Secret string `json:"secret,omitempty"`
+	// Events limits deliveries to these kinds of event, or all of them if it is empty.
+	Events []string `json:"events,omitempty"`
+	// Tags limits deliveries to events about these tags or notes with them, or all events if it is empty.
+	Tags []uuid.UUID `json:"tags,omitempty"`
+	// Active defaults to true.
+	Active *bool `json:"active,omitempty"`
+}
+
+func (w *WritableWebhook) ToDomain() (*webhooks.Webhook, error) {
+	var errs []error
+
+	if w.URL == "" {
+		errs = append(errs, &apiv1.InvalidFieldError{Field: ".url", Err: apiv1.ErrFieldNotSet.Error()})
+	}
+
+	out := &webhooks.Webhook{
+		URL:        apiv1.ValidateOptional(".url", w.URL, &errs, parseWebhookURL),
+		Secret:     w.Secret,
+		EventKinds: apiv1.ValidateSlice(".events", w.Events, &errs, events.ParseKind),
+		TagIDs:     w.Tags,
+		Active:     w.Active == nil || *w.Active,
+	}
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return out, nil
+}
+
+type Delivery struct {
+	ID             string          `json:"id"`
+	EventID        int64           `json:"event_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  string          `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  string          `json:"last_attempt_at,omitempty"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+}
+
+func DeliveryFromDomain(domain *webhooks.Delivery) (d Delivery) {
+	d.ID = domain.ID.String()
+	d.EventID = domain.EventID
+	d.Event = string(domain.EventKind)
+	d.Payload = domain.Payload
+	d.Status = string(domain.Status)
+	d.Attempts = domain.Attempts
+	d.NextAttemptAt = formatOptionalTime(domain.NextAttemptAt)
+	d.LastAttemptAt = formatOptionalTime(domain.LastAttemptAt)
+	d.ResponseStatus = domain.ResponseStatus
+	d.LastError = domain.LastError
+	d.CreatedAt = domain.CreatedAt.Format(time.RFC3339)
+	return d
+}
+
+type ListWebhooksPageTokenData struct {
+	LastWebhookID uuid.NullUUID
+}
+
+func (d *ListWebhooksPageTokenData) EncodePager() ([][]byte, error) {
+	return encodeLastID(d.LastWebhookID), nil
+}
+
+func (d *ListWebhooksPageTokenData) DecodePager(data [][]byte) error {
+	return decodeLastID(data, &d.LastWebhookID)
+}
+
+type ListDeliveriesPageTokenData struct {
+	LastDeliveryID uuid.NullUUID
+}
+
+func (d *ListDeliveriesPageTokenData) EncodePager() ([][]byte, error) {
+	return encodeLastID(d.LastDeliveryID), nil
+}
+
+func (d *ListDeliveriesPageTokenData) DecodePager(data [][]byte) error {
+	return decodeLastID(data, &d.LastDeliveryID)
+}
+
+func encodeLastID(lastID uuid.NullUUID) [][]byte {
+	var out [1][]byte
+
+	if lastID.Valid {
+		out[0] = []byte(lastID.UUID.String())
+	}
+
+	return out[:]
+}
+
+func decodeLastID(data [][]byte, lastID *uuid.NullUUID) error {
+	if len(data) != 1 {
+		return errors.New("invalid page token format (incorrect number of parts)")
+	}
+
+	if len(data[0]) != 0 {
+		id, err := uuid.ParseBytes(data[0])
+		if err != nil {
+			return err
+		}
+
+		lastID.UUID = id
+		lastID.Valid = true
+	}
+
+	return nil
+}
+
+func parseWebhookURL(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return "", errors.New("missing host")
+	}
+
+	// other hostnames are checked as deliveries connect to them, since what they resolve to can change
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); (err == nil && !webhooks.AllowedAddr(addr)) || strings.EqualFold(host, "localhost") {
+		return "", webhooks.ErrDisallowedAddress
+	}
+
+	return u.String(), nil
+}
+
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
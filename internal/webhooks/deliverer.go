@@ -0,0 +1,283 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/log"
+)
+
+const (
+	deliverInterval    = 5 * time.Second
+	deliverBatchSize   = 50
+	deliverConcurrency = 8
+	deliverTimeout     = 10 * time.Second
+
+	// deliveryLease is how long a claimed delivery is reserved for an attempt. It has to outlast an attempt, and if
+	// the attempt's outcome is never recorded, the delivery is retried once it expires.
+	deliveryLease = time.Minute
+
+	// maxAttempts is how many times a delivery is attempted before it is given up on.
+	maxAttempts = 8
+	// maxConsecutiveFailures is how many attempts in a row may fail before a webhook is disabled.
+	maxConsecutiveFailures = 20
+
+	minRetryDelay = 30 * time.Second
+	maxRetryDelay = 6 * time.Hour
+)
+
+const (
+	headerDelivery  = "X-Notes-Delivery"
+	headerWebhook   = "X-Notes-Webhook"
+	headerEvent     = "X-Notes-Event"
+	headerTimestamp = "X-Notes-Timestamp"
+	headerSignature = "X-Notes-Signature"
+)
+
+// Deliverer sends queued deliveries to their webhooks, retrying failures with exponential backoff.
+type Deliverer struct {
+	repo   Repository
+	client *http.Client
+}
+
+func NewDeliverer(injector do.Injector) (*Deliverer, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Deliverer{
+		repo:   repo,
+		client: newClient(),
+	}, nil
+}
+
+// ErrDisallowedAddress is returned when a webhook's URL refers to this server's own network, rather than the
+// internet.
+var ErrDisallowedAddress = errors.New("webhook address is not publicly routable")
+
+// disallowedPrefixes are the ranges that aren't publicly routable, and that the [netip.Addr] methods don't cover.
+var disallowedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),          // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),      // carrier-grade NAT
+	netip.MustParsePrefix("255.255.255.255/32"), // broadcast
+	netip.MustParsePrefix("64:ff9b:1::/48"),     // local-use NAT64
+}
+
+// nat64Prefix is the well-known NAT64 prefix, which embeds an IPv4 address in its last 32 bits.
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// AllowedAddr reports whether webhooks may be delivered to addr.
+// IPv4 addresses embedded in IPv4-mapped and NAT64 addresses are checked as the addresses they reach.
+func AllowedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if nat64Prefix.Contains(addr) {
+		b := addr.As16()
+		addr = netip.AddrFrom4([4]byte(b[12:]))
+	}
+
+	for _, prefix := range disallowedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return !addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
+
+// newClient returns a client that only connects to allowed addresses. They are checked as each connection is made,
+// after resolving the host, so a host can't resolve to a public address when the webhook is saved, and to a private
+// one when a delivery is sent.
+func newClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: deliverTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+
+			if !AllowedAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrDisallowedAddress, addrPort.Addr())
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// connecting through a proxy would only check the proxy's address
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Transport: transport,
+		// a redirect is treated as a failure, rather than posting the payload somewhere else
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Run sends due deliveries periodically until ctx is cancelled.
+func (d *Deliverer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(deliverInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.DeliverDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Error(ctx, "error delivering webhooks", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue attempts every delivery due by now.
+func (d *Deliverer) DeliverDue(ctx context.Context, now time.Time) error {
+	for ctx.Err() == nil {
+		due, err := d.repo.ClaimDeliveries(ctx, now, now.Add(deliveryLease), deliverBatchSize)
+		if err != nil {
+			return err
+		}
+
+		var (
+			wg    sync.WaitGroup
+			slots = make(chan struct{}, deliverConcurrency)
+		)
+
+		for i := range due {
+			slots <- struct{}{}
+			wg.Add(1)
+
+			go func(delivery *PendingDelivery) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+
+				d.attempt(ctx, delivery)
+			}(&due[i])
+		}
+
+		wg.Wait()
+
+		if len(due) < deliverBatchSize {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (d *Deliverer) attempt(ctx context.Context, delivery *PendingDelivery) {
+	at := time.Now()
+
+	status, err := d.send(ctx, delivery, at)
+	if ctx.Err() != nil {
+		// interrupted by shutting down; it will be retried once its lease expires
+		return
+	}
+
+	attempt := Attempt{
+		DeliveryID:     delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		Attempts:       delivery.Attempts,
+		At:             at,
+		ResponseStatus: status,
+	}
+
+	switch {
+	case err == nil:
+		attempt.Status = DeliverySucceeded
+
+	case delivery.Attempts+1 >= maxAttempts:
+		attempt.Status = DeliveryFailed
+		attempt.Error = err.Error()
+
+	default:
+		attempt.Status = DeliveryPending
+		attempt.NextAt = at.Add(retryDelay(delivery.Attempts + 1))
+		attempt.Error = err.Error()
+	}
+
+	active, err := d.repo.RecordAttempt(ctx, &attempt, maxConsecutiveFailures)
+	if err != nil {
+		if errors.Is(err, ErrClaimLost) {
+			// its lease expired while sending, and the outcome of the other attempt stands
+			log.Warn(ctx, "webhook delivery was attempted again before this attempt finished", zap.Stringer("delivery_id", delivery.ID))
+		}
+		return
+	}
+
+	if !active {
+		log.Warn(ctx, "webhook disabled after repeated delivery failures", zap.Stringer("webhook_id", delivery.WebhookID))
+	}
+}
+
+// send posts a delivery to its webhook, returning the response status if one was received.
+func (d *Deliverer) send(ctx context.Context, delivery *PendingDelivery, at time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, deliverTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerDelivery, delivery.ID.String())
+	req.Header.Set(headerWebhook, delivery.WebhookID.String())
+	req.Header.Set(headerEvent, string(delivery.EventKind))
+	req.Header.Set(headerTimestamp, strconv.FormatInt(at.Unix(), 10))
+	req.Header.Set(headerSignature, Sign(delivery.Secret, at, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// drain some of the body, so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// retryDelay returns how long to wait after a delivery's attempt'th failure before trying again.
+// It doubles with each attempt, with some jitter so that deliveries which failed together are not all retried
+// together.
+func retryDelay(attempt int) time.Duration {
+	delay := maxRetryDelay
+	if attempt < 32 {
+		delay = min(minRetryDelay<<(attempt-1), maxRetryDelay)
+	}
+
+	return delay + rand.N(delay/10)
+}
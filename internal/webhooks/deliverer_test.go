@@ -0,0 +1,110 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	secret := "s3cr3t"
+	timestamp := time.Unix(1720100220, 0)
+	body := []byte(`{"id":42,"type":"note.saved"}`)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(`1720100220.{"id":42,"type":"note.saved"}`))
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, expected, Sign(secret, timestamp, body))
+	assert.True(t, Verify(secret, timestamp, body, expected))
+	assert.False(t, Verify("wrong", timestamp, body, expected))
+	assert.False(t, Verify(secret, timestamp.Add(time.Second), body, expected))
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{attempt: 1, base: minRetryDelay},
+		{attempt: 2, base: 2 * minRetryDelay},
+		{attempt: 5, base: 16 * minRetryDelay},
+		{attempt: 20, base: maxRetryDelay},
+		{attempt: 100, base: maxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		delay := retryDelay(tt.attempt)
+		assert.GreaterOrEqual(t, delay, tt.base, "attempt %d", tt.attempt)
+		assert.Less(t, delay, tt.base+tt.base/10, "attempt %d", tt.attempt)
+	}
+}
+
+func TestDeliverer_RejectsPrivateAddresses(t *testing.T) {
+	var received atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Store(true)
+	}))
+	defer srv.Close()
+
+	d := &Deliverer{client: newClient()}
+
+	_, err := d.send(context.Background(), &PendingDelivery{
+		ID:        uuid.New(),
+		WebhookID: uuid.New(),
+		URL:       srv.URL,
+		Payload:   []byte(`{}`),
+	}, time.Now())
+
+	assert.ErrorIs(t, err, ErrDisallowedAddress)
+	assert.False(t, received.Load())
+}
+
+func TestAllowedAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1::", want: true},
+		{addr: "127.0.0.1", want: false},
+		{addr: "::1", want: false},
+		{addr: "::ffff:127.0.0.1", want: false},
+		{addr: "10.1.2.3", want: false},
+		{addr: "192.168.0.1", want: false},
+		{addr: "fd00::1", want: false},
+		{addr: "169.254.169.254", want: false},
+		{addr: "fe80::1", want: false},
+		{addr: "0.0.0.0", want: false},
+		{addr: "::", want: false},
+		{addr: "0.1.2.3", want: false},
+		{addr: "100.64.0.1", want: false},
+		{addr: "100.127.255.254", want: false},
+		{addr: "100.128.0.1", want: true},
+		{addr: "255.255.255.255", want: false},
+		{addr: "224.0.0.1", want: false},
+		{addr: "239.255.255.250", want: false},
+		{addr: "ff02::1", want: false},
+		{addr: "ff0e::1", want: false},
+		{addr: "::ffff:10.0.0.1", want: false},
+		{addr: "::ffff:93.184.216.34", want: true},
+		{addr: "64:ff9b::7f00:1", want: false},
+		{addr: "64:ff9b::a9fe:a9fe", want: false},
+		{addr: "64:ff9b::5db8:d822", want: true},
+		{addr: "64:ff9b:1::1", want: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, AllowedAddr(netip.MustParseAddr(tt.addr)), tt.addr)
+	}
+}
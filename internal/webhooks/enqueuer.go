@@ -0,0 +1,114 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/events"
+	"github.com/dabbertorres/notes/internal/outbox"
+)
+
+// Enqueuer is an [outbox.Subscriber] that queues a delivery of each event to every webhook subscribed to it.
+// Events reach the outbox in the same transaction as their changes, so no deliveries are lost or sent for changes
+// that did not happen. Webhooks are matched against the tags a note had when the event was recorded, as it may since
+// have been retagged or deleted.
+type Enqueuer struct {
+	db      database.Database
+	queries *database.Queries
+}
+
+func NewEnqueuer(injector do.Injector) (*Enqueuer, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Enqueuer{
+		db:      db,
+		queries: database.New(),
+	}, nil
+}
+
+// Subscribe registers the enqueuer for every kind of event written to the outbox.
+func (e *Enqueuer) Subscribe(relay *outbox.Relay) {
+	for _, kind := range events.Kinds {
+		relay.Subscribe(string(kind), e)
+	}
+}
+
+// payload is the body of a delivery. It matches the events sent by GET /api/v1/events.
+type payload struct {
+	ID         int64      `json:"id"`
+	Type       string     `json:"type"`
+	NoteID     *uuid.UUID `json:"note_id,omitempty"`
+	TagID      *uuid.UUID `json:"tag_id,omitempty"`
+	OccurredAt string     `json:"occurred_at"`
+}
+
+func (e *Enqueuer) HandleMessage(ctx context.Context, msg *outbox.Message) error {
+	var payload outbox.EventPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return err
+	}
+
+	event := payload.ToEvent()
+	return pgx.BeginFunc(ctx, e.db, func(tx pgx.Tx) error {
+		return e.enqueue(ctx, tx, event, payload.TagIDs)
+	})
+}
+
+func (e *Enqueuer) enqueue(ctx context.Context, tx pgx.Tx, event *events.Event, tagIDs []uuid.UUID) error {
+	webhookIDs, err := e.queries.ListMatchingWebhooks(ctx, tx, database.ListMatchingWebhooksParams{
+		Audience:  event.Audience,
+		EventKind: string(event.Kind),
+		TagIds:    tagIDs,
+	})
+	if err != nil || len(webhookIDs) == 0 {
+		return err
+	}
+
+	body := payload{
+		ID:         event.ID,
+		Type:       string(event.Kind),
+		OccurredAt: event.OccurredAt.Format(time.RFC3339Nano),
+	}
+	if event.NoteID.Valid {
+		body.NoteID = &event.NoteID.UUID
+	}
+	if event.TagID.Valid {
+		body.TagID = &event.TagID.UUID
+	}
+
+	encoded, err := json.Marshal(&body)
+	if err != nil {
+		return err
+	}
+
+	for _, webhookID := range webhookIDs {
+		deliveryID, err := uuid.NewV7()
+		if err != nil {
+			return err
+		}
+
+		err = e.queries.EnqueueWebhookDelivery(ctx, tx, database.EnqueueWebhookDeliveryParams{
+			DeliveryID: deliveryID,
+			WebhookID:  webhookID,
+			EventID:    event.ID,
+			EventKind:  string(event.Kind),
+			Payload:    encoded,
+			CreatedAt:  pgtype.Timestamptz{Time: event.OccurredAt, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
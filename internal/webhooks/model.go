@@ -0,0 +1,90 @@
+package webhooks
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/dabbertorres/notes/internal/events"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// Webhook subscribes a URL to the events about notes and tags its owner can see.
+type Webhook struct {
+	ID    uuid.UUID
+	Owner users.User
+	URL   string
+	// Secret is the key each delivery is signed with.
+	Secret string
+	// EventKinds limits deliveries to these kinds of event. All kinds are delivered if it is empty.
+	EventKinds []events.Kind
+	// TagIDs limits deliveries to events about these tags, or notes with any of them.
+	// All events are delivered if it is empty.
+	TagIDs []uuid.UUID
+	// Active is false once the webhook has been disabled, either by its owner or after too many failed deliveries.
+	Active              bool
+	ConsecutiveFailures int
+	DisabledAt          time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+type WebhookListParams struct {
+	LastWebhookID uuid.NullUUID
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is an event queued to be sent to a webhook, and the outcome of the latest attempt to send it.
+type Delivery struct {
+	ID             uuid.UUID
+	WebhookID      uuid.UUID
+	EventID        int64
+	EventKind      events.Kind
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastAttemptAt  time.Time
+	ResponseStatus int
+	LastError      string
+	CreatedAt      time.Time
+}
+
+type DeliveryListParams struct {
+	LastDeliveryID uuid.NullUUID
+}
+
+// PendingDelivery is a delivery claimed to be attempted, along with where to send it.
+type PendingDelivery struct {
+	ID        uuid.UUID
+	WebhookID uuid.UUID
+	EventID   int64
+	EventKind events.Kind
+	Payload   []byte
+	// Attempts is how many attempts were made before this one.
+	Attempts int
+	URL      string
+	Secret   string
+}
+
+// Attempt is the outcome of trying to send a delivery.
+type Attempt struct {
+	DeliveryID uuid.UUID
+	WebhookID  uuid.UUID
+	// Attempts is how many attempts were made before this one.
+	Attempts int
+	Status   DeliveryStatus
+	At       time.Time
+	// NextAt is when to try again, if Status is [DeliveryPending].
+	NextAt time.Time
+	// ResponseStatus is zero if no response was received.
+	ResponseStatus int
+	Error          string
+}
@@ -0,0 +1,271 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/database"
+	"github.com/dabbertorres/notes/internal/events"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/users"
+	"github.com/dabbertorres/notes/internal/util"
+)
+
+type PGXRepository struct {
+	db      database.Database
+	queries *database.Queries
+}
+
+func NewPGXRepository(injector do.Injector) (*PGXRepository, error) {
+	db, err := do.InvokeAs[database.Database](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PGXRepository{
+		db:      db,
+		queries: database.New(),
+	}, nil
+}
+
+func (r *PGXRepository) SaveWebhook(ctx context.Context, webhook *Webhook) error {
+	tagIDs := webhook.TagIDs
+	if tagIDs == nil {
+		// an empty filter, rather than NULL
+		tagIDs = []uuid.UUID{}
+	}
+
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		return r.queries.SaveWebhook(ctx, tx, database.SaveWebhookParams{
+			WebhookID: webhook.ID,
+			UserID:    webhook.Owner.ID,
+			Url:       webhook.URL,
+			Secret:    webhook.Secret,
+			EventKinds: util.MapSlice(webhook.EventKinds, func(kind events.Kind) string {
+				return string(kind)
+			}),
+			TagIds:              tagIDs,
+			Active:              webhook.Active,
+			ConsecutiveFailures: int32(webhook.ConsecutiveFailures),
+			DisabledAt:          pgtype.Timestamptz{Time: webhook.DisabledAt, Valid: !webhook.DisabledAt.IsZero()},
+			CreatedAt:           pgtype.Timestamptz{Time: webhook.CreatedAt, Valid: true},
+			UpdatedAt:           pgtype.Timestamptz{Time: webhook.UpdatedAt, Valid: true},
+		})
+	})
+	if err != nil {
+		log.Error(ctx, "error saving webhook", zap.Stringer("webhook_id", webhook.ID), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	var numDeleted int64
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) (err error) {
+		numDeleted, err = r.queries.DeleteWebhook(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		log.Error(ctx, "error deleting webhook", zap.Stringer("webhook_id", id), zap.Error(err))
+		return apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	if numDeleted != 1 {
+		return apiv1.NewError(http.StatusNotFound, "webhook does not exist")
+	}
+
+	return nil
+}
+
+func (r *PGXRepository) GetWebhook(ctx context.Context, id uuid.UUID) (webhook *Webhook, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		row, err := r.queries.GetWebhook(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		webhook = webhookFromRow(row)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apiv1.NewError(http.StatusNotFound, "webhook does not exist")
+		}
+
+		log.Error(ctx, "error fetching webhook", zap.Stringer("webhook_id", id), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return webhook, nil
+}
+
+func (r *PGXRepository) ListWebhooks(ctx context.Context, userID uuid.UUID, params WebhookListParams, pageSize int) (webhooks []Webhook, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ListWebhooks(ctx, tx, database.ListWebhooksParams{
+			UserID:        userID,
+			LastWebhookID: params.LastWebhookID,
+			PageSize:      int64(pageSize),
+		})
+		if err != nil {
+			return err
+		}
+
+		webhooks = make([]Webhook, 0, len(rows))
+		for _, row := range rows {
+			webhooks = append(webhooks, *webhookFromRow(row))
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error listing webhooks", zap.Stringer("user_id", userID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return webhooks, nil
+}
+
+func (r *PGXRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, params DeliveryListParams, pageSize int) (deliveries []Delivery, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ListWebhookDeliveries(ctx, tx, database.ListWebhookDeliveriesParams{
+			WebhookID:      webhookID,
+			LastDeliveryID: params.LastDeliveryID,
+			PageSize:       int64(pageSize),
+		})
+		if err != nil {
+			return err
+		}
+
+		deliveries = make([]Delivery, 0, len(rows))
+		for _, row := range rows {
+			deliveries = append(deliveries, Delivery{
+				ID:             row.DeliveryID,
+				WebhookID:      row.WebhookID,
+				EventID:        row.EventID,
+				EventKind:      events.Kind(row.EventKind),
+				Payload:        row.Payload,
+				Status:         DeliveryStatus(row.Status),
+				Attempts:       int(row.Attempts),
+				NextAttemptAt:  row.NextAttemptAt.Time,
+				LastAttemptAt:  row.LastAttemptAt.Time,
+				ResponseStatus: int(row.ResponseStatus.Int32),
+				LastError:      row.LastError.String,
+				CreatedAt:      row.CreatedAt.Time,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error listing webhook deliveries", zap.Stringer("webhook_id", webhookID), zap.Error(err))
+		return nil, apiv1.NewError(http.StatusInternalServerError, "try again later")
+	}
+
+	return deliveries, nil
+}
+
+func (r *PGXRepository) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) (deliveries []PendingDelivery, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		rows, err := r.queries.ClaimWebhookDeliveries(ctx, tx, database.ClaimWebhookDeliveriesParams{
+			Now:        pgtype.Timestamptz{Time: now, Valid: true},
+			PageSize:   int64(limit),
+			LeaseUntil: pgtype.Timestamptz{Time: leaseUntil, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		deliveries = make([]PendingDelivery, 0, len(rows))
+		for _, row := range rows {
+			deliveries = append(deliveries, PendingDelivery{
+				ID:        row.DeliveryID,
+				WebhookID: row.WebhookID,
+				EventID:   row.EventID,
+				EventKind: events.Kind(row.EventKind),
+				Payload:   row.Payload,
+				Attempts:  int(row.Attempts),
+				URL:       row.Url,
+				Secret:    row.Secret,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error(ctx, "error claiming webhook deliveries", zap.Error(err))
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *PGXRepository) RecordAttempt(ctx context.Context, attempt *Attempt, maxFailures int) (active bool, err error) {
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		updated, err := r.queries.RecordWebhookDeliveryAttempt(ctx, tx, database.RecordWebhookDeliveryAttemptParams{
+			Status:           database.NotesWebhookDeliveryStatus(attempt.Status),
+			NextAttemptAt:    pgtype.Timestamptz{Time: attempt.NextAt, Valid: !attempt.NextAt.IsZero()},
+			AttemptedAt:      pgtype.Timestamptz{Time: attempt.At, Valid: true},
+			ResponseStatus:   pgtype.Int4{Int32: int32(attempt.ResponseStatus), Valid: attempt.ResponseStatus != 0},
+			LastError:        pgtype.Text{String: attempt.Error, Valid: attempt.Error != ""},
+			DeliveryID:       attempt.DeliveryID,
+			ExpectedAttempts: int32(attempt.Attempts),
+		})
+		if err != nil {
+			return err
+		}
+
+		if updated == 0 {
+			return ErrClaimLost
+		}
+
+		if attempt.Status == DeliverySucceeded {
+			active = true
+			return r.queries.ResetWebhookFailures(ctx, tx, attempt.WebhookID)
+		}
+
+		active, err = r.queries.RecordWebhookFailure(ctx, tx, database.RecordWebhookFailureParams{
+			MaxFailures: int32(maxFailures),
+			Now:         pgtype.Timestamptz{Time: attempt.At, Valid: true},
+			WebhookID:   attempt.WebhookID,
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrClaimLost) {
+			return false, err
+		}
+
+		log.Error(ctx, "error recording webhook delivery attempt", zap.Stringer("delivery_id", attempt.DeliveryID), zap.Error(err))
+		return false, err
+	}
+
+	return active, nil
+}
+
+func webhookFromRow(row database.NotesWebhook) *Webhook {
+	return &Webhook{
+		ID:     row.WebhookID,
+		Owner:  users.User{ID: row.UserID},
+		URL:    row.Url,
+		Secret: row.Secret,
+		EventKinds: util.MapSlice(row.EventKinds, func(kind string) events.Kind {
+			return events.Kind(kind)
+		}),
+		TagIDs:              row.TagIds,
+		Active:              row.Active,
+		ConsecutiveFailures: int(row.ConsecutiveFailures),
+		DisabledAt:          row.DisabledAt.Time,
+		CreatedAt:           row.CreatedAt.Time,
+		UpdatedAt:           row.UpdatedAt.Time,
+	}
+}
@@ -0,0 +1,202 @@
+-- name: SaveWebhook :exec
+INSERT INTO notes.webhooks (
+  webhook_id,
+  user_id,
+  url,
+  secret,
+  event_kinds,
+  tag_ids,
+  active,
+  consecutive_failures,
+  disabled_at,
+  created_at,
+  updated_at
+) VALUES (
+  sqlc.arg(webhook_id),
+  sqlc.arg(user_id),
+  sqlc.arg(url),
+  sqlc.arg(secret),
+  sqlc.arg(event_kinds)::text[],
+  sqlc.arg(tag_ids)::uuid[],
+  sqlc.arg(active),
+  sqlc.arg(consecutive_failures),
+  sqlc.narg(disabled_at),
+  sqlc.arg(created_at),
+  sqlc.arg(updated_at)
+) ON CONFLICT (webhook_id) DO UPDATE
+  SET url                  = excluded.url,
+      secret               = excluded.secret,
+      event_kinds          = excluded.event_kinds,
+      tag_ids              = excluded.tag_ids,
+      active               = excluded.active,
+      consecutive_failures = excluded.consecutive_failures,
+      disabled_at          = excluded.disabled_at,
+      updated_at           = excluded.updated_at
+;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM notes.webhooks
+WHERE webhook_id = sqlc.arg(webhook_id)
+;
+
+-- name: GetWebhook :one
+SELECT
+  webhook_id,
+  user_id,
+  url,
+  secret,
+  event_kinds,
+  tag_ids,
+  active,
+  consecutive_failures,
+  disabled_at,
+  created_at,
+  updated_at
+FROM notes.webhooks
+WHERE webhook_id = sqlc.arg(webhook_id)
+;
+
+-- name: ListWebhooks :many
+SELECT
+  webhook_id,
+  user_id,
+  url,
+  secret,
+  event_kinds,
+  tag_ids,
+  active,
+  consecutive_failures,
+  disabled_at,
+  created_at,
+  updated_at
+FROM notes.webhooks
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(last_webhook_id)::uuid IS NULL OR webhook_id > sqlc.narg(last_webhook_id)::uuid)
+ORDER BY webhook_id ASC
+LIMIT sqlc.arg(page_size)
+;
+
+-- name: ListMatchingWebhooks :many
+SELECT
+  webhook_id
+FROM notes.webhooks
+WHERE active
+  -- only tell users about what they can see
+  AND user_id = ANY(sqlc.arg(audience)::uuid[])
+  AND (cardinality(event_kinds) = 0 OR sqlc.arg(event_kind)::text = ANY(event_kinds))
+  AND (cardinality(webhooks.tag_ids) = 0 OR webhooks.tag_ids && sqlc.arg(tag_ids)::uuid[])
+ORDER BY webhook_id ASC
+;
+
+-- name: EnqueueWebhookDelivery :exec
+INSERT INTO notes.webhook_deliveries (
+  delivery_id,
+  webhook_id,
+  event_id,
+  event_kind,
+  payload,
+  status,
+  next_attempt_at,
+  created_at
+)
+SELECT
+  sqlc.arg(delivery_id),
+  sqlc.arg(webhook_id),
+  sqlc.arg(event_id),
+  sqlc.arg(event_kind),
+  sqlc.arg(payload),
+  'pending',
+  sqlc.arg(created_at),
+  sqlc.arg(created_at)
+-- the event may be handled again, if recording that it was handled failed
+WHERE NOT EXISTS (
+  SELECT 1
+  FROM notes.webhook_deliveries
+  WHERE webhook_id = sqlc.arg(webhook_id)
+    AND event_id = sqlc.arg(event_id)
+)
+;
+
+-- name: ClaimWebhookDeliveries :many
+WITH due AS (
+  SELECT
+    webhook_deliveries.delivery_id
+  FROM notes.webhook_deliveries
+  JOIN notes.webhooks ON
+    webhooks.webhook_id = webhook_deliveries.webhook_id
+  WHERE webhook_deliveries.status = 'pending'
+    AND webhook_deliveries.next_attempt_at <= sqlc.arg(now)
+    AND webhooks.active
+  ORDER BY webhook_deliveries.next_attempt_at ASC
+  LIMIT sqlc.arg(page_size)
+  FOR UPDATE OF webhook_deliveries SKIP LOCKED
+)
+UPDATE notes.webhook_deliveries
+-- if the attempt is interrupted, it is retried once this lease expires
+SET next_attempt_at = sqlc.arg(lease_until)
+FROM due, notes.webhooks
+WHERE webhook_deliveries.delivery_id = due.delivery_id
+  AND webhooks.webhook_id = webhook_deliveries.webhook_id
+RETURNING
+  webhook_deliveries.delivery_id,
+  webhook_deliveries.webhook_id,
+  webhook_deliveries.event_id,
+  webhook_deliveries.event_kind,
+  webhook_deliveries.payload,
+  webhook_deliveries.attempts,
+  webhooks.url,
+  webhooks.secret
+;
+
+-- name: RecordWebhookDeliveryAttempt :execrows
+UPDATE notes.webhook_deliveries
+SET status          = sqlc.arg(status),
+    attempts        = attempts + 1,
+    next_attempt_at = sqlc.narg(next_attempt_at),
+    last_attempt_at = sqlc.arg(attempted_at),
+    response_status = sqlc.narg(response_status),
+    last_error      = sqlc.narg(last_error)
+WHERE delivery_id = sqlc.arg(delivery_id)
+  -- another deliverer has not already recorded an attempt since this one was claimed
+  AND status = 'pending'
+  AND attempts = sqlc.arg(expected_attempts)::integer
+;
+
+-- name: ResetWebhookFailures :exec
+UPDATE notes.webhooks
+SET consecutive_failures = 0
+WHERE webhook_id = sqlc.arg(webhook_id)
+;
+
+-- name: RecordWebhookFailure :one
+UPDATE notes.webhooks
+SET consecutive_failures = consecutive_failures + 1,
+    active               = active AND consecutive_failures + 1 < sqlc.arg(max_failures)::integer,
+    disabled_at          = CASE
+                             WHEN active AND consecutive_failures + 1 >= sqlc.arg(max_failures)::integer THEN sqlc.arg(now)
+                             ELSE disabled_at
+                           END
+WHERE webhook_id = sqlc.arg(webhook_id)
+RETURNING active
+;
+
+-- name: ListWebhookDeliveries :many
+SELECT
+  delivery_id,
+  webhook_id,
+  event_id,
+  event_kind,
+  payload,
+  status,
+  attempts,
+  next_attempt_at,
+  last_attempt_at,
+  response_status,
+  last_error,
+  created_at
+FROM notes.webhook_deliveries
+WHERE webhook_id = sqlc.arg(webhook_id)
+  AND (sqlc.narg(last_delivery_id)::uuid IS NULL OR delivery_id < sqlc.narg(last_delivery_id)::uuid)
+ORDER BY delivery_id DESC
+LIMIT sqlc.arg(page_size)
+;
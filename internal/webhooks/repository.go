@@ -0,0 +1,28 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrClaimLost is returned when recording an attempt at a delivery that is no longer claimed by it, because its
+// lease expired and another [Deliverer] claimed and attempted it in the meantime.
+var ErrClaimLost = errors.New("delivery is no longer claimed by this attempt")
+
+type Repository interface {
+	SaveWebhook(ctx context.Context, webhook *Webhook) error
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	GetWebhook(ctx context.Context, id uuid.UUID) (*Webhook, error)
+	ListWebhooks(ctx context.Context, userID uuid.UUID, params WebhookListParams, pageSize int) ([]Webhook, error)
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, params DeliveryListParams, pageSize int) ([]Delivery, error)
+	// ClaimDeliveries leases up to limit deliveries that are due by now until leaseUntil, so that no other
+	// [Deliverer] attempts them in the meantime.
+	ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]PendingDelivery, error)
+	// RecordAttempt records the outcome of an attempt at a delivery. Its webhook is disabled if the attempt failed,
+	// and was the maxFailures'th in a row. It returns whether the webhook is still active, or [ErrClaimLost] if
+	// another attempt at the delivery was recorded since it was claimed.
+	RecordAttempt(ctx context.Context, attempt *Attempt, maxFailures int) (active bool, err error)
+}
@@ -0,0 +1,200 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/do/v2"
+	"go.uber.org/zap"
+
+	"github.com/dabbertorres/notes/internal/common/apiv1"
+	"github.com/dabbertorres/notes/internal/log"
+	"github.com/dabbertorres/notes/internal/scope"
+	"github.com/dabbertorres/notes/internal/users"
+)
+
+// secretSize is the number of random bytes in a generated secret.
+const secretSize = 32
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(injector do.Injector) (*Service, error) {
+	repo, err := do.InvokeAs[Repository](injector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		repo: repo,
+	}, nil
+}
+
+// CreateWebhook creates a webhook owned by the current user. A secret is generated for it if it does not have one.
+func (s *Service) CreateWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	userID := scope.MustUserID(ctx)
+
+	webhookID, err := uuid.NewV7()
+	if err != nil {
+		return nil, apiv1.StatusError(http.StatusServiceUnavailable)
+	}
+
+	if webhook.Secret == "" {
+		webhook.Secret, err = generateSecret()
+		if err != nil {
+			return nil, apiv1.StatusError(http.StatusServiceUnavailable)
+		}
+	}
+
+	webhook.ID = webhookID
+	webhook.Owner = users.User{ID: userID}
+	webhook.ConsecutiveFailures = 0
+	webhook.CreatedAt = time.Now()
+	webhook.UpdatedAt = webhook.CreatedAt
+	webhook.DisabledAt = time.Time{}
+	if !webhook.Active {
+		webhook.DisabledAt = webhook.CreatedAt
+	}
+
+	if err := s.repo.SaveWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// UpdateWebhook replaces a webhook's settings. Its secret is kept if webhook does not have one.
+// Re-activating a webhook that was disabled gives it a clean slate of failures.
+func (s *Service) UpdateWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	existing, err := s.getOwnWebhook(ctx, webhook.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook.Secret == "" {
+		webhook.Secret = existing.Secret
+	}
+
+	webhook.Owner = existing.Owner
+	webhook.CreatedAt = existing.CreatedAt
+	webhook.UpdatedAt = time.Now()
+
+	switch {
+	case webhook.Active && !existing.Active:
+		webhook.ConsecutiveFailures = 0
+		webhook.DisabledAt = time.Time{}
+
+	case !webhook.Active && existing.Active:
+		webhook.ConsecutiveFailures = existing.ConsecutiveFailures
+		webhook.DisabledAt = webhook.UpdatedAt
+
+	default:
+		webhook.ConsecutiveFailures = existing.ConsecutiveFailures
+		webhook.DisabledAt = existing.DisabledAt
+	}
+
+	if err := s.repo.SaveWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (s *Service) DeleteWebhook(ctx context.Context, webhookID uuid.UUID) error {
+	if _, err := s.getOwnWebhook(ctx, webhookID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteWebhook(ctx, webhookID)
+}
+
+func (s *Service) GetWebhook(ctx context.Context, webhookID uuid.UUID) (*Webhook, error) {
+	return s.getOwnWebhook(ctx, webhookID)
+}
+
+func (s *Service) ListWebhooks(ctx context.Context, params WebhookListParams, pageSize int) (webhooks []Webhook, next *WebhookListParams, err error) {
+	userID := scope.MustUserID(ctx)
+
+	if pageSize == 0 {
+		pageSize = 100
+	}
+
+	// retrieve one more to see if there is another page to fetch
+	webhooks, err = s.repo.ListWebhooks(ctx, userID, params, pageSize+1)
+	if err != nil {
+		log.Error(ctx, "error listing webhooks", zap.Error(err))
+		return nil, nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if len(webhooks) > pageSize {
+		webhooks = webhooks[:pageSize]
+
+		last := &webhooks[len(webhooks)-1]
+
+		next = &WebhookListParams{
+			LastWebhookID: uuid.NullUUID{UUID: last.ID, Valid: true},
+		}
+	}
+
+	return webhooks, next, nil
+}
+
+// ListDeliveries lists the deliveries queued for a webhook, most recent first.
+func (s *Service) ListDeliveries(ctx context.Context, webhookID uuid.UUID, params DeliveryListParams, pageSize int) (deliveries []Delivery, next *DeliveryListParams, err error) {
+	if _, err := s.getOwnWebhook(ctx, webhookID); err != nil {
+		return nil, nil, err
+	}
+
+	if pageSize == 0 {
+		pageSize = 100
+	}
+
+	// retrieve one more to see if there is another page to fetch
+	deliveries, err = s.repo.ListDeliveries(ctx, webhookID, params, pageSize+1)
+	if err != nil {
+		log.Error(ctx, "error listing webhook deliveries", zap.Stringer("webhook_id", webhookID), zap.Error(err))
+		return nil, nil, apiv1.StatusError(http.StatusInternalServerError)
+	}
+
+	if len(deliveries) > pageSize {
+		deliveries = deliveries[:pageSize]
+
+		last := &deliveries[len(deliveries)-1]
+
+		next = &DeliveryListParams{
+			LastDeliveryID: uuid.NullUUID{UUID: last.ID, Valid: true},
+		}
+	}
+
+	return deliveries, next, nil
+}
+
+func (s *Service) getOwnWebhook(ctx context.Context, webhookID uuid.UUID) (*Webhook, error) {
+	userID := scope.MustUserID(ctx)
+
+	webhook, err := s.repo.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	// webhooks are private to the user that created them
+	if webhook.Owner.ID != userID {
+		return nil, apiv1.StatusError(http.StatusForbidden)
+	}
+
+	return webhook, nil
+}
+
+func generateSecret() (string, error) {
+	var secret [secretSize]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret[:]), nil
+}
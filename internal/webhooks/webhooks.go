@@ -0,0 +1,10 @@
+package webhooks
+
+import "github.com/samber/do/v2"
+
+var Package = do.Package(
+	do.Lazy(NewPGXRepository),
+	do.Lazy(NewEnqueuer),
+	do.Lazy(NewDeliverer),
+	do.Lazy(NewService),
+)
@@ -12,11 +12,27 @@ import (
 	"github.com/samber/do/v2"
 	"go.uber.org/zap"
 
+	"github.com/dabbertorres/notes/internal/archives"
+	"github.com/dabbertorres/notes/internal/attachments"
+	"github.com/dabbertorres/notes/internal/audit"
+	"github.com/dabbertorres/notes/internal/auth"
+	"github.com/dabbertorres/notes/internal/blobs"
+	"github.com/dabbertorres/notes/internal/collab"
+	"github.com/dabbertorres/notes/internal/comments"
 	"github.com/dabbertorres/notes/internal/config"
+	"github.com/dabbertorres/notes/internal/events"
+	"github.com/dabbertorres/notes/internal/imports"
+	"github.com/dabbertorres/notes/internal/jobs"
 	"github.com/dabbertorres/notes/internal/notes"
+	"github.com/dabbertorres/notes/internal/outbox"
+	"github.com/dabbertorres/notes/internal/publishing"
+	"github.com/dabbertorres/notes/internal/reminders"
+	"github.com/dabbertorres/notes/internal/searches"
 	"github.com/dabbertorres/notes/internal/tags"
 	"github.com/dabbertorres/notes/internal/telemetry"
+	"github.com/dabbertorres/notes/internal/templates"
 	"github.com/dabbertorres/notes/internal/users"
+	"github.com/dabbertorres/notes/internal/webhooks"
 )
 
 func main() {
@@ -25,9 +41,25 @@ func main() {
 	flag.Parse()
 
 	injector := do.NewWithOpts(&do.InjectorOpts{},
+		archives.Package,
+		attachments.Package,
+		audit.Package,
+		auth.Package,
+		blobs.Package,
+		collab.Package,
+		comments.Package,
+		events.Package,
+		imports.Package,
+		jobs.Package,
 		notes.Package,
+		outbox.Package,
+		publishing.Package,
+		reminders.Package,
+		searches.Package,
 		tags.Package,
+		templates.Package,
 		users.Package,
+		webhooks.Package,
 		telemetry.Package,
 	)
 
@@ -45,6 +77,20 @@ func main() {
 	logger := do.MustInvoke[*zap.Logger](injector)
 	defer logger.Sync()
 
+	// outbox messages are written in the same transaction as the events they are for, and webhook deliveries are
+	// queued as they are relayed
+	recorder := do.MustInvoke[*events.Recorder](injector)
+	recorder.Observe(do.MustInvoke[*outbox.Writer](injector))
+	do.MustInvoke[*webhooks.Enqueuer](injector).Subscribe(do.MustInvoke[*outbox.Relay](injector))
+
+	jobWorker := do.MustInvoke[*jobs.Worker](injector)
+	jobWorker.Handle(
+		do.MustInvoke[*imports.Runner](injector).Handler(),
+		do.MustInvoke[*archives.Exporter](injector).Handler(),
+		do.MustInvoke[*archives.Exporter](injector).ExpireHandler(),
+		do.MustInvoke[*attachments.Extractor](injector).ReindexHandler(),
+	)
+
 	srv := do.MustInvoke[*http.Server](injector)
 
 	logger.Info("starting", zap.String("addr", srv.Addr))
@@ -55,6 +101,18 @@ func main() {
 		}
 	}()
 
+	waitWorkers := startWorkers(ctx, logger,
+		do.MustInvoke[*attachments.Sweeper](injector),
+		do.MustInvoke[*attachments.Extractor](injector),
+		do.MustInvoke[*reminders.Scheduler](injector),
+		do.MustInvoke[*events.Broker](injector),
+		do.MustInvoke[*events.Pruner](injector),
+		do.MustInvoke[*collab.Hub](injector),
+		do.MustInvoke[*webhooks.Deliverer](injector),
+		do.MustInvoke[*outbox.Relay](injector),
+		jobWorker,
+	)
+
 	<-ctx.Done()
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.WithoutCancel(ctx), 15*time.Second)
@@ -62,7 +120,17 @@ func main() {
 
 	logger.Info("shutting down")
 
+	// the workers are drained whether or not the server shut down cleanly
 	if err := srv.Shutdown(shutdownCtx); err != nil {
-		logger.Fatal("error shutting down server:", zap.Error(err))
+		logger.Error("error shutting down server", zap.Error(err))
+	}
+
+	// jobs still running when the deadline passes are returned to the queue, and run again after restarting
+	if err := jobWorker.Shutdown(shutdownCtx); err != nil {
+		logger.Error("error draining jobs", zap.Error(err))
+	}
+
+	if err := waitWorkers(shutdownCtx); err != nil {
+		logger.Error("error waiting for workers to stop", zap.Error(err))
 	}
 }
@@ -0,0 +1,128 @@
+table "attachments" {
+  schema = schema.notes
+
+  column "attachment_id" {
+    type = uuid
+    null = false
+  }
+
+  // null once the note has been deleted, until the blob has been removed
+  column "note_id" {
+    type = uuid
+    null = true
+  }
+
+  column "name" {
+    type = text
+    null = false
+  }
+
+  column "content_type" {
+    type = text
+    null = false
+  }
+
+  column "size" {
+    type = bigint
+    null = false
+  }
+
+  column "blob_key" {
+    type = text
+    null = false
+  }
+
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "created_by" {
+    type = uuid
+    null = true
+  }
+
+  check "non empty name" {
+    expr = "LENGTH(name) > 0"
+  }
+
+  primary_key {
+    columns = [column.attachment_id]
+  }
+
+  foreign_key "note_id" {
+    columns     = [column.note_id]
+    ref_columns = [table.notes.column.note_id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  foreign_key "created_by" {
+    columns     = [column.created_by]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  index "idx_fk_attachments_note_id" {
+    columns = [column.note_id]
+    unique  = false
+  }
+
+  index "idx_attachments_orphaned" {
+    columns = [column.created_at]
+    unique  = false
+    where   = "note_id IS NULL"
+  }
+}
+
+// attachment_texts holds the text extracted from attachments, for searching. An attachment without a row here has not
+// been processed yet.
+table "attachment_texts" {
+  schema = schema.notes
+
+  column "attachment_id" {
+    type = uuid
+    null = false
+  }
+
+  // empty if the attachment's type is not supported, or extraction failed
+  column "content" {
+    type = text
+    null = false
+  }
+
+  column "extracted_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "error" {
+    type = text
+    null = true
+  }
+
+  column "search_index" {
+    type = tsvector
+    as {
+      expr = "to_tsvector('english', content)"
+      type = STORED
+    }
+  }
+
+  primary_key {
+    columns = [column.attachment_id]
+  }
+
+  foreign_key "attachment_id" {
+    columns     = [column.attachment_id]
+    ref_columns = [table.attachments.column.attachment_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_attachment_text_search" {
+    type    = GIN
+    columns = [column.search_index]
+  }
+}
@@ -0,0 +1,75 @@
+// an append-only trail of security-relevant actions
+table "audit_log" {
+  schema = schema.notes
+
+  // increases with each entry
+  column "entry_id" {
+    type = bigint
+    null = false
+    identity {
+      generated = ALWAYS
+    }
+  }
+
+  column "action" {
+    type = text
+    null = false
+  }
+
+  // none of the ids are foreign keys, so that entries outlive what they refer to
+  column "actor_id" {
+    type = uuid
+    null = true
+  }
+
+  column "request_id" {
+    type = uuid
+    null = true
+  }
+
+  column "note_id" {
+    type = uuid
+    null = true
+  }
+
+  column "tag_id" {
+    type = uuid
+    null = true
+  }
+
+  column "user_id" {
+    type = uuid
+    null = true
+  }
+
+  column "before" {
+    type = jsonb
+    null = true
+  }
+
+  column "after" {
+    type = jsonb
+    null = true
+  }
+
+  column "occurred_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.entry_id]
+  }
+
+  index "idx_audit_log_note_id" {
+    columns = [column.note_id, column.entry_id]
+    unique  = false
+    where   = "note_id IS NOT NULL"
+  }
+
+  index "idx_audit_log_actor_id" {
+    columns = [column.actor_id, column.entry_id]
+    unique  = false
+    where   = "actor_id IS NOT NULL"
+  }
+}
@@ -0,0 +1,192 @@
+table "comment_threads" {
+  schema = schema.notes
+
+  column "thread_id" {
+    type = uuid
+    null = false
+  }
+
+  column "note_id" {
+    type = uuid
+    null = false
+  }
+
+  // the range of the note's body, in characters, that the thread is about: all null if it is about the whole note
+  column "anchor_start" {
+    type = integer
+    null = true
+  }
+
+  column "anchor_end" {
+    type = integer
+    null = true
+  }
+
+  // the text of the range when the thread was started, so that it can be found again after the note is edited
+  column "anchor_quote" {
+    type = text
+    null = true
+  }
+
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "created_by" {
+    type = uuid
+    null = true
+  }
+
+  // null while unresolved
+  column "resolved_at" {
+    type = timestamptz
+    null = true
+  }
+
+  column "resolved_by" {
+    type = uuid
+    null = true
+  }
+
+  check "valid anchor" {
+    expr = "(anchor_start IS NULL AND anchor_end IS NULL AND anchor_quote IS NULL) OR (0 <= anchor_start AND anchor_start < anchor_end AND anchor_quote IS NOT NULL)"
+  }
+
+  primary_key {
+    columns = [column.thread_id]
+  }
+
+  foreign_key "note_id" {
+    columns     = [column.note_id]
+    ref_columns = [table.notes.column.note_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "created_by" {
+    columns     = [column.created_by]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  foreign_key "resolved_by" {
+    columns     = [column.resolved_by]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  index "idx_fk_comment_threads_note_id" {
+    columns = [
+      column.note_id,
+      column.thread_id,
+    ]
+    unique = false
+  }
+}
+
+table "comments" {
+  schema = schema.notes
+
+  column "comment_id" {
+    type = uuid
+    null = false
+  }
+
+  column "thread_id" {
+    type = uuid
+    null = false
+  }
+
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "created_by" {
+    type = uuid
+    null = true
+  }
+
+  column "updated_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "body" {
+    type = text
+    null = false
+  }
+
+  check "non empty body" {
+    expr = "LENGTH(body) > 0"
+  }
+
+  primary_key {
+    columns = [column.comment_id]
+  }
+
+  foreign_key "thread_id" {
+    columns     = [column.thread_id]
+    ref_columns = [table.comment_threads.column.thread_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "created_by" {
+    columns     = [column.created_by]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  index "idx_fk_comments_thread_id" {
+    columns = [
+      column.thread_id,
+      column.comment_id,
+    ]
+    unique = false
+  }
+}
+
+table "comment_mentions" {
+  schema = schema.notes
+
+  column "comment_id" {
+    type = uuid
+    null = false
+  }
+
+  column "user_id" {
+    type = uuid
+    null = false
+  }
+
+  primary_key {
+    columns = [
+      column.comment_id,
+      column.user_id,
+    ]
+  }
+
+  foreign_key "comment_id" {
+    columns     = [column.comment_id]
+    ref_columns = [table.comments.column.comment_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "user_id" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_fk_comment_mentions_user_id" {
+    columns = [column.user_id]
+    unique  = false
+  }
+}
@@ -0,0 +1,53 @@
+// a log of changes to notes, tags and their access, for clients following changes as they happen
+table "events" {
+  schema = schema.notes
+
+  // increases with each event, and identifies where a client resumes from
+  column "event_id" {
+    type = bigint
+    null = false
+    identity {
+      generated = ALWAYS
+    }
+  }
+
+  column "kind" {
+    type = text
+    null = false
+  }
+
+  // not foreign keys, so that events outlive what they refer to
+  column "note_id" {
+    type = uuid
+    null = true
+  }
+
+  column "tag_id" {
+    type = uuid
+    null = true
+  }
+
+  // every user who could see the change when it happened
+  column "audience" {
+    type = sql("uuid[]")
+    null = false
+  }
+
+  column "occurred_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.event_id]
+  }
+
+  index "idx_events_occurred_at" {
+    columns = [column.occurred_at]
+    unique  = false
+  }
+
+  check "events_subject" {
+    expr = "(note_id IS NULL) <> (tag_id IS NULL)"
+  }
+}
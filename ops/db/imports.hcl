@@ -0,0 +1,180 @@
+enum "import_format" {
+  schema = schema.notes
+  values = [
+    "enex",
+    "joplin",
+  ]
+}
+
+enum "import_status" {
+  schema = schema.notes
+  values = [
+    "pending",
+    "running",
+    "succeeded",
+    "failed",
+    "cancelled",
+  ]
+}
+
+// an upload of notes exported from another app, which is imported in the background
+table "imports" {
+  schema = schema.notes
+
+  column "import_id" {
+    type = uuid
+    null = false
+  }
+
+  // the job that runs the import
+  column "job_id" {
+    type = uuid
+    null = false
+  }
+
+  // who the notes are imported for
+  column "user_id" {
+    type = uuid
+    null = false
+  }
+
+  column "format" {
+    type = enum.import_format
+    null = false
+  }
+
+  column "status" {
+    type = enum.import_status
+    null = false
+  }
+
+  // the uploaded export, which is deleted once the import is finished
+  column "blob_key" {
+    type = text
+    null = false
+  }
+
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "started_at" {
+    type = timestamptz
+    null = true
+  }
+
+  column "finished_at" {
+    type = timestamptz
+    null = true
+  }
+
+  // how many notes are in the upload, once known
+  column "total" {
+    type = integer
+    null = true
+  }
+
+  column "created" {
+    type    = integer
+    null    = false
+    default = 0
+  }
+
+  column "updated" {
+    type    = integer
+    null    = false
+    default = 0
+  }
+
+  column "failed" {
+    type    = integer
+    null    = false
+    default = 0
+  }
+
+  // why the import as a whole failed, as opposed to any of its notes
+  column "error" {
+    type = text
+    null = true
+  }
+
+  primary_key {
+    columns = [column.import_id]
+  }
+
+  foreign_key "user_id" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "job_id" {
+    columns     = [column.job_id]
+    ref_columns = [table.jobs.column.job_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_fk_imports_job_id" {
+    columns = [column.job_id]
+    unique  = false
+  }
+
+  index "idx_fk_imports_user_id" {
+    columns = [
+      column.user_id,
+      column.import_id,
+    ]
+    unique = false
+  }
+}
+
+// the notes of an import that could not be imported, and why
+table "import_failures" {
+  schema = schema.notes
+
+  column "failure_id" {
+    type = bigint
+    null = false
+    identity {
+      generated = ALWAYS
+    }
+  }
+
+  column "import_id" {
+    type = uuid
+    null = false
+  }
+
+  // what the note was called in the upload
+  column "item" {
+    type = text
+    null = false
+  }
+
+  column "error" {
+    type = text
+    null = false
+  }
+
+  primary_key {
+    columns = [column.failure_id]
+  }
+
+  foreign_key "import_id" {
+    columns     = [column.import_id]
+    ref_columns = [table.imports.column.import_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_fk_import_failures_import_id" {
+    columns = [
+      column.import_id,
+      column.failure_id,
+    ]
+    unique = false
+  }
+}
@@ -0,0 +1,129 @@
+enum "job_status" {
+  schema = schema.notes
+  values = [
+    "pending",
+    "running",
+    "succeeded",
+    "failed",
+    "cancelled",
+  ]
+}
+
+// background work, which is claimed by workers with FOR UPDATE SKIP LOCKED
+table "jobs" {
+  schema = schema.notes
+
+  column "job_id" {
+    type = uuid
+    null = false
+  }
+
+  // what the job does, which decides how its payload is interpreted
+  column "kind" {
+    type = text
+    null = false
+  }
+
+  // who the job is run for, and who may see it, if anyone
+  column "user_id" {
+    type = uuid
+    null = true
+  }
+
+  column "payload" {
+    type = jsonb
+    null = false
+  }
+
+  column "status" {
+    type = enum.job_status
+    null = false
+  }
+
+  column "attempts" {
+    type    = integer
+    null    = false
+    default = 0
+  }
+
+  column "max_attempts" {
+    type = integer
+    null = false
+  }
+
+  // when a pending job is next due to run
+  column "run_at" {
+    type = timestamptz
+    null = false
+  }
+
+  // a running job whose lease has expired was interrupted, and is run again
+  column "locked_until" {
+    type = timestamptz
+    null = true
+  }
+
+  column "cancel_requested" {
+    type    = boolean
+    null    = false
+    default = false
+  }
+
+  column "progress_done" {
+    type    = integer
+    null    = false
+    default = 0
+  }
+
+  // how much there is to do, once known
+  column "progress_total" {
+    type = integer
+    null = true
+  }
+
+  // why the last attempt failed
+  column "error" {
+    type = text
+    null = true
+  }
+
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "started_at" {
+    type = timestamptz
+    null = true
+  }
+
+  column "finished_at" {
+    type = timestamptz
+    null = true
+  }
+
+  primary_key {
+    columns = [column.job_id]
+  }
+
+  foreign_key "user_id" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_fk_jobs_user_id" {
+    columns = [
+      column.user_id,
+      column.job_id,
+    ]
+    unique = false
+  }
+
+  index "idx_jobs_unfinished" {
+    columns = [column.run_at]
+    unique  = false
+    where   = "status IN ('pending', 'running')"
+  }
+}
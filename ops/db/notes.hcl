@@ -36,6 +36,13 @@ table "notes" {
     null = false
   }
 
+  // incremented each time the note is saved
+  column "revision" {
+    type    = bigint
+    null    = false
+    default = 1
+  }
+
   column "search_index" {
     type = tsvector
     as {
@@ -52,6 +59,26 @@ table "notes" {
     type    = GIN
     columns = [column.search_index]
   }
+
+  index "idx_notes_updated_at" {
+    columns = [
+      column.updated_at,
+      column.note_id,
+    ]
+  }
+
+  index "idx_notes_created_at" {
+    columns = [
+      column.created_at,
+      column.note_id,
+    ]
+  }
+
+  index "idx_notes_title_lower" {
+    on {
+      expr = "lower(title)"
+    }
+  }
 }
 
 table "user_note_access" {
@@ -94,6 +121,71 @@ table "user_note_access" {
   }
 }
 
+// each user's own organization of the notes they can access
+table "user_note_states" {
+  schema = schema.notes
+
+  column "note_id" {
+    type = uuid
+    null = false
+  }
+
+  column "user_id" {
+    type = uuid
+    null = false
+  }
+
+  // pinned notes are listed first
+  column "pinned" {
+    type    = bool
+    null    = false
+    default = false
+  }
+
+  column "starred" {
+    type    = bool
+    null    = false
+    default = false
+  }
+
+  // archived notes are hidden unless asked for
+  column "archived" {
+    type    = bool
+    null    = false
+    default = false
+  }
+
+  column "updated_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [
+      column.note_id,
+      column.user_id,
+    ]
+  }
+
+  foreign_key "note_id" {
+    columns     = [column.note_id]
+    ref_columns = [table.notes.column.note_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "user_id" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_fk_user_note_states_user_id" {
+    columns = [column.user_id]
+  }
+}
+
 table "note_tags" {
   schema = schema.notes
 
@@ -128,3 +220,160 @@ table "note_tags" {
     on_delete   = CASCADE
   }
 }
+
+table "note_links" {
+  schema = schema.notes
+
+  column "source_note_id" {
+    type = uuid
+    null = false
+  }
+
+  // the text between the brackets of a link: either a note's title or ID
+  column "reference" {
+    type = text
+    null = false
+  }
+
+  // NULL while the link is dangling
+  column "target_note_id" {
+    type = uuid
+    null = true
+  }
+
+  primary_key {
+    columns = [
+      column.source_note_id,
+      column.reference,
+    ]
+  }
+
+  foreign_key "source_note_id" {
+    columns     = [column.source_note_id]
+    ref_columns = [table.notes.column.note_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "target_note_id" {
+    columns     = [column.target_note_id]
+    ref_columns = [table.notes.column.note_id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  index "idx_note_links_target_note_id" {
+    columns = [column.target_note_id]
+    unique  = false
+  }
+
+  index "idx_note_links_dangling" {
+    on {
+      expr = "lower(reference)"
+    }
+    where = "target_note_id IS NULL"
+  }
+}
+
+table "note_tasks" {
+  schema = schema.notes
+
+  column "note_id" {
+    type = uuid
+    null = false
+  }
+
+  // 1-based line number of the task within the note's body
+  column "line" {
+    type = integer
+    null = false
+  }
+
+  column "text" {
+    type = text
+    null = false
+  }
+
+  column "done" {
+    type = bool
+    null = false
+  }
+
+  primary_key {
+    columns = [
+      column.note_id,
+      column.line,
+    ]
+  }
+
+  foreign_key "note_id" {
+    columns     = [column.note_id]
+    ref_columns = [table.notes.column.note_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_note_tasks_done" {
+    columns = [
+      column.done,
+      column.note_id,
+      column.line,
+    ]
+  }
+}
+
+// the title and body of each saved revision of a note
+table "note_revisions" {
+  schema = schema.notes
+
+  column "note_id" {
+    type = uuid
+    null = false
+  }
+
+  column "revision" {
+    type = bigint
+    null = false
+  }
+
+  column "title" {
+    type = text
+    null = false
+  }
+
+  column "body" {
+    type = text
+    null = false
+  }
+
+  column "saved_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "saved_by" {
+    type = uuid
+    null = true
+  }
+
+  primary_key {
+    columns = [
+      column.note_id,
+      column.revision,
+    ]
+  }
+
+  foreign_key "note_id" {
+    columns     = [column.note_id]
+    ref_columns = [table.notes.column.note_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "saved_by" {
+    columns     = [column.saved_by]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+}
@@ -0,0 +1,71 @@
+// messages written in the same transaction as the changes they describe, to be relayed to subscribers once committed
+table "outbox" {
+  schema = schema.notes
+
+  // increases with each message, and is the order they are published in
+  column "message_id" {
+    type = bigint
+    null = false
+    identity {
+      generated = ALWAYS
+    }
+  }
+
+  column "topic" {
+    type = text
+    null = false
+  }
+
+  column "payload" {
+    type = jsonb
+    null = false
+  }
+
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  // pushed back each time publishing the message fails
+  column "available_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "attempts" {
+    type    = integer
+    null    = false
+    default = 0
+  }
+
+  column "last_error" {
+    type = text
+    null = true
+  }
+
+  column "published_at" {
+    type = timestamptz
+    null = true
+  }
+
+  // set once the message has failed to be published too many times
+  column "abandoned_at" {
+    type = timestamptz
+    null = true
+  }
+
+  primary_key {
+    columns = [column.message_id]
+  }
+
+  index "idx_outbox_unpublished" {
+    columns = [column.message_id]
+    unique  = false
+    where   = "published_at IS NULL AND abandoned_at IS NULL"
+  }
+
+  index "idx_outbox_created_at" {
+    columns = [column.created_at]
+    unique  = false
+  }
+}
@@ -0,0 +1,56 @@
+// notes that are served publicly, without authentication
+table "note_publications" {
+  schema = schema.notes
+
+  column "note_id" {
+    type = uuid
+    null = false
+  }
+
+  // the last segment of the note's public URL
+  column "slug" {
+    type = text
+    null = false
+  }
+
+  column "published_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "published_by" {
+    type = uuid
+    null = true
+  }
+
+  check "valid slug" {
+    expr = "slug ~ '^[a-z0-9]+(-[a-z0-9]+)*$'"
+  }
+
+  primary_key {
+    columns = [column.note_id]
+  }
+
+  foreign_key "note_id" {
+    columns     = [column.note_id]
+    ref_columns = [table.notes.column.note_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "published_by" {
+    columns     = [column.published_by]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+
+  index "idx_fk_note_publications_published_by" {
+    columns = [column.published_by]
+  }
+
+  index "idx_note_publications_slug" {
+    columns = [column.slug]
+    unique  = true
+  }
+}
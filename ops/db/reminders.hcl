@@ -0,0 +1,123 @@
+// each user's reminder about a note
+table "reminders" {
+  schema = schema.notes
+
+  column "note_id" {
+    type = uuid
+    null = false
+  }
+
+  column "user_id" {
+    type = uuid
+    null = false
+  }
+
+  // the first occurrence, and the start of the recurrence
+  column "remind_at" {
+    type = timestamptz
+    null = false
+  }
+
+  // an RFC 5545 RRULE, or null if the reminder only occurs once
+  column "recurrence" {
+    type = text
+    null = true
+  }
+
+  // the next occurrence to deliver, or null once every occurrence has been delivered
+  column "next_at" {
+    type = timestamptz
+    null = true
+  }
+
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "updated_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [
+      column.note_id,
+      column.user_id,
+    ]
+  }
+
+  foreign_key "note_id" {
+    columns     = [column.note_id]
+    ref_columns = [table.notes.column.note_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "user_id" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_fk_reminders_user_id" {
+    columns = [
+      column.user_id,
+      column.note_id,
+    ]
+    unique = false
+  }
+
+  index "idx_reminders_pending" {
+    columns = [column.next_at]
+    unique  = false
+    where   = "next_at IS NOT NULL"
+  }
+}
+
+// every occurrence of a reminder that has been delivered, so that none are delivered twice
+table "reminder_deliveries" {
+  schema = schema.notes
+
+  column "note_id" {
+    type = uuid
+    null = false
+  }
+
+  column "user_id" {
+    type = uuid
+    null = false
+  }
+
+  column "occurrence_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "delivered_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [
+      column.note_id,
+      column.user_id,
+      column.occurrence_at,
+    ]
+  }
+
+  foreign_key "reminder" {
+    columns = [
+      column.note_id,
+      column.user_id,
+    ]
+    ref_columns = [
+      table.reminders.column.note_id,
+      table.reminders.column.user_id,
+    ]
+    on_update = NO_ACTION
+    on_delete = CASCADE
+  }
+}
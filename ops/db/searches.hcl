@@ -0,0 +1,103 @@
+table "saved_searches" {
+  schema = schema.notes
+
+  column "saved_search_id" {
+    type = uuid
+    null = false
+  }
+
+  column "user_id" {
+    type = uuid
+    null = false
+  }
+
+  column "name" {
+    type = text
+    null = false
+  }
+
+  column "query" {
+    type = jsonb
+    null = false
+  }
+
+  column "track_matches" {
+    type = bool
+    null = false
+  }
+
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "updated_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "last_checked_at" {
+    type = timestamptz
+    null = true
+  }
+
+  check "non empty name" {
+    expr = "LENGTH(name) > 0"
+  }
+
+  primary_key {
+    columns = [column.saved_search_id]
+  }
+
+  foreign_key "user_id" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_fk_saved_searches_user_id" {
+    columns = [column.user_id]
+    unique  = false
+  }
+}
+
+table "saved_search_matches" {
+  schema = schema.notes
+
+  column "saved_search_id" {
+    type = uuid
+    null = false
+  }
+
+  column "note_id" {
+    type = uuid
+    null = false
+  }
+
+  column "matched_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [
+      column.saved_search_id,
+      column.note_id,
+    ]
+  }
+
+  foreign_key "saved_search_id" {
+    columns     = [column.saved_search_id]
+    ref_columns = [table.saved_searches.column.saved_search_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "note_id" {
+    columns     = [column.note_id]
+    ref_columns = [table.notes.column.note_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+}
@@ -0,0 +1,174 @@
+table "templates" {
+  schema = schema.notes
+
+  column "template_id" {
+    type = uuid
+    null = false
+  }
+
+  column "name" {
+    type = text
+    null = false
+  }
+
+  column "title" {
+    type = text
+    null = false
+  }
+
+  column "body" {
+    type = text
+    null = false
+  }
+
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "created_by" {
+    type = uuid
+    null = true
+  }
+
+  column "updated_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "updated_by" {
+    type = uuid
+    null = true
+  }
+
+  check "non empty name" {
+    expr = "LENGTH(name) > 0"
+  }
+
+  primary_key {
+    columns = [column.template_id]
+  }
+}
+
+// who may use and edit a template
+table "user_template_access" {
+  schema = schema.notes
+
+  column "template_id" {
+    type = uuid
+    null = false
+  }
+
+  column "user_id" {
+    type = uuid
+    null = false
+  }
+
+  column "access" {
+    type = enum.access_level
+    null = false
+  }
+
+  primary_key {
+    columns = [
+      column.template_id,
+      column.user_id,
+    ]
+  }
+
+  foreign_key "template_id" {
+    columns     = [column.template_id]
+    ref_columns = [table.templates.column.template_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "user_id" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_fk_user_template_access_user_id" {
+    columns = [column.user_id]
+    unique  = false
+  }
+}
+
+// tags applied to notes created from a template
+table "template_tags" {
+  schema = schema.notes
+
+  column "template_id" {
+    type = uuid
+    null = false
+  }
+
+  column "tag_id" {
+    type = uuid
+    null = false
+  }
+
+  primary_key {
+    columns = [
+      column.template_id,
+      column.tag_id,
+    ]
+  }
+
+  foreign_key "template_id" {
+    columns     = [column.template_id]
+    ref_columns = [table.templates.column.template_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "tag_id" {
+    columns     = [column.tag_id]
+    ref_columns = [table.tags.column.tag_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+}
+
+// access granted on notes created from a template
+table "template_note_access" {
+  schema = schema.notes
+
+  column "template_id" {
+    type = uuid
+    null = false
+  }
+
+  column "user_id" {
+    type = uuid
+    null = false
+  }
+
+  column "access" {
+    type = enum.access_level
+    null = false
+  }
+
+  primary_key {
+    columns = [
+      column.template_id,
+      column.user_id,
+    ]
+  }
+
+  foreign_key "template_id" {
+    columns     = [column.template_id]
+    ref_columns = [table.templates.column.template_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  foreign_key "user_id" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+}
@@ -0,0 +1,56 @@
+// API tokens, which authenticate requests as the user they belong to
+table "user_tokens" {
+  schema = schema.notes
+
+  column "token_id" {
+    type = uuid
+    null = false
+  }
+
+  column "user_id" {
+    type = uuid
+    null = false
+  }
+
+  column "name" {
+    type = text
+    null = false
+  }
+
+  // the hex-encoded SHA-256 of the token; the token itself is only shown when it is created
+  column "token_hash" {
+    type = text
+    null = false
+  }
+
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  // the token never expires if null
+  column "expires_at" {
+    type = timestamptz
+    null = true
+  }
+
+  primary_key {
+    columns = [column.token_id]
+  }
+
+  foreign_key "user_id" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_fk_user_tokens_user_id" {
+    columns = [column.user_id]
+  }
+
+  index "idx_user_tokens_token_hash" {
+    columns = [column.token_hash]
+    unique  = true
+  }
+}
@@ -36,6 +36,7 @@ enum "access_level" {
   values = [
     "owner",
     "editor",
+    "commenter",
     "viewer",
   ]
 }
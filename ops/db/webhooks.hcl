@@ -0,0 +1,190 @@
+// a user's subscription to be told about changes to what they can see
+table "webhooks" {
+  schema = schema.notes
+
+  column "webhook_id" {
+    type = uuid
+    null = false
+  }
+
+  column "user_id" {
+    type = uuid
+    null = false
+  }
+
+  column "url" {
+    type = text
+    null = false
+  }
+
+  // signs each delivery's payload
+  column "secret" {
+    type = text
+    null = false
+  }
+
+  // the kinds of events to deliver, or empty for all of them
+  column "event_kinds" {
+    type = sql("text[]")
+    null = false
+  }
+
+  // only deliver events about these tags, or notes with any of them, or empty for all of them
+  column "tag_ids" {
+    type = sql("uuid[]")
+    null = false
+  }
+
+  column "active" {
+    type = bool
+    null = false
+  }
+
+  // reset by any successful delivery; the webhook is deactivated once it reaches a limit
+  column "consecutive_failures" {
+    type    = integer
+    null    = false
+    default = 0
+  }
+
+  column "disabled_at" {
+    type = timestamptz
+    null = true
+  }
+
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  column "updated_at" {
+    type = timestamptz
+    null = false
+  }
+
+  check "webhook_url_scheme" {
+    expr = "url ~ '^https?://'"
+  }
+
+  primary_key {
+    columns = [column.webhook_id]
+  }
+
+  foreign_key "user_id" {
+    columns     = [column.user_id]
+    ref_columns = [table.users.column.user_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_fk_webhooks_user_id" {
+    columns = [
+      column.user_id,
+      column.webhook_id,
+    ]
+    unique = false
+  }
+}
+
+enum "webhook_delivery_status" {
+  schema = schema.notes
+  values = [
+    "pending",
+    "succeeded",
+    "failed",
+  ]
+}
+
+// the queue of events to deliver to each webhook, and a log of how each delivery went
+table "webhook_deliveries" {
+  schema = schema.notes
+
+  column "delivery_id" {
+    type = uuid
+    null = false
+  }
+
+  column "webhook_id" {
+    type = uuid
+    null = false
+  }
+
+  // not a foreign key, so that deliveries outlive their events
+  column "event_id" {
+    type = bigint
+    null = false
+  }
+
+  column "event_kind" {
+    type = text
+    null = false
+  }
+
+  column "payload" {
+    type = jsonb
+    null = false
+  }
+
+  column "status" {
+    type = enum.webhook_delivery_status
+    null = false
+  }
+
+  column "attempts" {
+    type    = integer
+    null    = false
+    default = 0
+  }
+
+  // when a pending delivery is next due to be attempted, or to be retried if its attempt was interrupted
+  column "next_attempt_at" {
+    type = timestamptz
+    null = true
+  }
+
+  column "last_attempt_at" {
+    type = timestamptz
+    null = true
+  }
+
+  // the response status of the last attempt, if it got a response
+  column "response_status" {
+    type = integer
+    null = true
+  }
+
+  column "last_error" {
+    type = text
+    null = true
+  }
+
+  column "created_at" {
+    type = timestamptz
+    null = false
+  }
+
+  primary_key {
+    columns = [column.delivery_id]
+  }
+
+  foreign_key "webhook_id" {
+    columns     = [column.webhook_id]
+    ref_columns = [table.webhooks.column.webhook_id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+
+  index "idx_fk_webhook_deliveries_webhook_id" {
+    columns = [
+      column.webhook_id,
+      column.delivery_id,
+    ]
+    unique = false
+  }
+
+  index "idx_webhook_deliveries_pending" {
+    columns = [column.next_attempt_at]
+    unique  = false
+    where   = "status = 'pending'"
+  }
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/felixge/httpsnoop"
@@ -13,15 +15,30 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	archivesapiv1 "github.com/dabbertorres/notes/internal/archives/apiv1"
+	attachmentsapiv1 "github.com/dabbertorres/notes/internal/attachments/apiv1"
+	auditapiv1 "github.com/dabbertorres/notes/internal/audit/apiv1"
+	"github.com/dabbertorres/notes/internal/auth"
+	authapiv1 "github.com/dabbertorres/notes/internal/auth/apiv1"
+	collabapiv1 "github.com/dabbertorres/notes/internal/collab/apiv1"
+	commentsapiv1 "github.com/dabbertorres/notes/internal/comments/apiv1"
 	"github.com/dabbertorres/notes/internal/common/apiv1"
 	"github.com/dabbertorres/notes/internal/config"
+	eventsapiv1 "github.com/dabbertorres/notes/internal/events/apiv1"
+	importsapiv1 "github.com/dabbertorres/notes/internal/imports/apiv1"
+	jobsapiv1 "github.com/dabbertorres/notes/internal/jobs/apiv1"
 	"github.com/dabbertorres/notes/internal/log"
 	notesapiv1 "github.com/dabbertorres/notes/internal/notes/apiv1"
+	publishingapiv1 "github.com/dabbertorres/notes/internal/publishing/apiv1"
+	remindersapiv1 "github.com/dabbertorres/notes/internal/reminders/apiv1"
 	"github.com/dabbertorres/notes/internal/scope"
+	searchesapiv1 "github.com/dabbertorres/notes/internal/searches/apiv1"
 	tagsapiv1 "github.com/dabbertorres/notes/internal/tags/apiv1"
 	"github.com/dabbertorres/notes/internal/telemetry"
+	templatesapiv1 "github.com/dabbertorres/notes/internal/templates/apiv1"
 	usersapiv1 "github.com/dabbertorres/notes/internal/users/apiv1"
 	"github.com/dabbertorres/notes/internal/util"
+	webhooksapiv1 "github.com/dabbertorres/notes/internal/webhooks/apiv1"
 )
 
 func setupServer(injector do.Injector) (*http.Server, error) {
@@ -37,11 +54,74 @@ func setupServer(injector do.Injector) (*http.Server, error) {
 
 	notesService := do.MustInvokeAs[notesapiv1.Service](injector)
 
-	addHandler(mux, "POST", "/api/v1/notes", notesapiv1.PostNote(notesService))
+	templatesService := do.MustInvokeAs[templatesapiv1.Service](injector)
+
+	addHandler(mux, "POST", "/api/v1/notes", templatesapiv1.WithTemplate(templatesService, notesapiv1.PostNote(notesService)))
+	addHandler(mux, "POST", "/api/v1/notes:batch", notesapiv1.PostBatch(notesService))
 	addHandler(mux, "PUT", "/api/v1/notes/{id}", notesapiv1.PutNote(notesService))
 	addHandler(mux, "DELETE", "/api/v1/notes/{id}", notesapiv1.DeleteNote(notesService))
 	addHandler(mux, "GET", "/api/v1/notes/{id}", notesapiv1.GetNote(notesService))
+	addHandler(mux, "GET", "/api/v1/notes/{id}/related", notesapiv1.GetRelatedNotes(notesService))
+	addHandler(mux, "GET", "/api/v1/notes/{id}/backlinks", notesapiv1.GetBacklinks(notesService))
+	addHandler(mux, "PUT", "/api/v1/notes/{id}/state", notesapiv1.PutNoteState(notesService))
+	addHandler(mux, "GET", "/api/v1/notes/{id}/revisions", notesapiv1.ListNoteRevisions(notesService))
+	addHandler(mux, "GET", "/api/v1/notes/{id}/revisions/{revision}", notesapiv1.GetNoteRevision(notesService))
+	addHandler(mux, "PUT", "/api/v1/notes/{id}/tasks/{line}", notesapiv1.PutTask(notesService))
 	addHandler(mux, "GET", "/api/v1/notes", notesapiv1.ListNotes(notesService))
+	addHandler(mux, "GET", "/api/v1/tasks", notesapiv1.ListTasks(notesService))
+
+	collabService := do.MustInvokeAs[collabapiv1.Service](injector)
+
+	addHandler(mux, "GET", "/api/v1/notes/{id}/collab", collabapiv1.EditNote(collabService))
+
+	commentsService := do.MustInvokeAs[commentsapiv1.Service](injector)
+
+	addHandler(mux, "POST", "/api/v1/notes/{id}/threads", commentsapiv1.PostThread(commentsService))
+	addHandler(mux, "GET", "/api/v1/notes/{id}/threads", commentsapiv1.ListThreads(commentsService))
+	addHandler(mux, "GET", "/api/v1/notes/{id}/threads/{thread_id}", commentsapiv1.GetThread(commentsService))
+	addHandler(mux, "PUT", "/api/v1/notes/{id}/threads/{thread_id}", commentsapiv1.PutThread(commentsService))
+	addHandler(mux, "POST", "/api/v1/notes/{id}/threads/{thread_id}/comments", commentsapiv1.PostComment(commentsService))
+	addHandler(mux, "PUT", "/api/v1/notes/{id}/threads/{thread_id}/comments/{comment_id}", commentsapiv1.PutComment(commentsService))
+	addHandler(mux, "DELETE", "/api/v1/notes/{id}/threads/{thread_id}/comments/{comment_id}", commentsapiv1.DeleteComment(commentsService))
+
+	publishingService := do.MustInvokeAs[publishingapiv1.Service](injector)
+
+	addHandler(mux, "PUT", "/api/v1/notes/{id}/publication", publishingapiv1.PutPublication(publishingService))
+	addHandler(mux, "GET", "/api/v1/notes/{id}/publication", publishingapiv1.GetPublication(publishingService))
+	addHandler(mux, "DELETE", "/api/v1/notes/{id}/publication", publishingapiv1.DeletePublication(publishingService))
+	// published notes are public, so this must stay outside of any authentication
+	addHandler(mux, "GET", "/p/{slug}", publishingapiv1.GetPage(publishingService))
+
+	remindersService := do.MustInvokeAs[remindersapiv1.Service](injector)
+
+	addHandler(mux, "PUT", "/api/v1/notes/{id}/reminder", remindersapiv1.PutReminder(remindersService))
+	addHandler(mux, "GET", "/api/v1/notes/{id}/reminder", remindersapiv1.GetReminder(remindersService))
+	addHandler(mux, "DELETE", "/api/v1/notes/{id}/reminder", remindersapiv1.DeleteReminder(remindersService))
+	addHandler(mux, "GET", "/api/v1/reminders", remindersapiv1.ListReminders(remindersService))
+
+	attachmentsService := do.MustInvokeAs[attachmentsapiv1.Service](injector)
+
+	addHandler(mux, "POST", "/api/v1/notes/{id}/attachments", attachmentsapiv1.PostAttachment(attachmentsService))
+	addHandler(mux, "GET", "/api/v1/notes/{id}/attachments", attachmentsapiv1.ListAttachments(attachmentsService))
+	addHandler(mux, "GET", "/api/v1/notes/{id}/attachments/{attachment_id}", attachmentsapiv1.GetAttachment(attachmentsService))
+	addHandler(mux, "DELETE", "/api/v1/notes/{id}/attachments/{attachment_id}", attachmentsapiv1.DeleteAttachment(attachmentsService))
+	addHandler(mux, "POST", "/api/v1/attachments/reindex", attachmentsapiv1.PostReindex(attachmentsService))
+
+	searchesService := do.MustInvokeAs[searchesapiv1.Service](injector)
+
+	addHandler(mux, "POST", "/api/v1/saved-searches", searchesapiv1.PostSavedSearch(searchesService))
+	addHandler(mux, "PUT", "/api/v1/saved-searches/{id}", searchesapiv1.PutSavedSearch(searchesService))
+	addHandler(mux, "DELETE", "/api/v1/saved-searches/{id}", searchesapiv1.DeleteSavedSearch(searchesService))
+	addHandler(mux, "GET", "/api/v1/saved-searches/{id}", searchesapiv1.GetSavedSearch(searchesService))
+	addHandler(mux, "GET", "/api/v1/saved-searches", searchesapiv1.ListSavedSearches(searchesService))
+	addHandler(mux, "GET", "/api/v1/saved-searches/{id}/results", searchesapiv1.GetSavedSearchResults(searchesService))
+	addHandler(mux, "GET", "/api/v1/saved-searches/{id}/new-results", searchesapiv1.GetSavedSearchNewResults(searchesService))
+
+	addHandler(mux, "POST", "/api/v1/templates", templatesapiv1.PostTemplate(templatesService))
+	addHandler(mux, "PUT", "/api/v1/templates/{id}", templatesapiv1.PutTemplate(templatesService))
+	addHandler(mux, "DELETE", "/api/v1/templates/{id}", templatesapiv1.DeleteTemplate(templatesService))
+	addHandler(mux, "GET", "/api/v1/templates/{id}", templatesapiv1.GetTemplate(templatesService))
+	addHandler(mux, "GET", "/api/v1/templates", templatesapiv1.ListTemplates(templatesService))
 
 	tagsService := do.MustInvokeAs[tagsapiv1.Service](injector)
 
@@ -49,15 +129,56 @@ func setupServer(injector do.Injector) (*http.Server, error) {
 	addHandler(mux, "PUT", "/api/v1/tags", tagsapiv1.PutTag(tagsService))
 	addHandler(mux, "DELETE", "/api/v1/tags/{id}", tagsapiv1.DeleteTag(tagsService))
 	addHandler(mux, "GET", "/api/v1/tags/{id}", tagsapiv1.GetTag(tagsService))
+	addHandler(mux, "POST", "/api/v1/tags/{id}/merge", tagsapiv1.MergeTags(tagsService))
 	addHandler(mux, "GET", "/api/v1/tags", tagsapiv1.ListTags(tagsService))
 
+	eventsService := do.MustInvokeAs[eventsapiv1.Service](injector)
+
+	addHandler(mux, "GET", "/api/v1/events", eventsapiv1.StreamEvents(eventsService))
+
+	webhooksService := do.MustInvokeAs[webhooksapiv1.Service](injector)
+
+	addHandler(mux, "POST", "/api/v1/webhooks", webhooksapiv1.PostWebhook(webhooksService))
+	addHandler(mux, "PUT", "/api/v1/webhooks/{id}", webhooksapiv1.PutWebhook(webhooksService))
+	addHandler(mux, "DELETE", "/api/v1/webhooks/{id}", webhooksapiv1.DeleteWebhook(webhooksService))
+	addHandler(mux, "GET", "/api/v1/webhooks/{id}", webhooksapiv1.GetWebhook(webhooksService))
+	addHandler(mux, "GET", "/api/v1/webhooks", webhooksapiv1.ListWebhooks(webhooksService))
+	addHandler(mux, "GET", "/api/v1/webhooks/{id}/deliveries", webhooksapiv1.ListDeliveries(webhooksService))
+
+	auditService := do.MustInvokeAs[auditapiv1.Service](injector)
+
+	addHandler(mux, "GET", "/api/v1/audit", auditapiv1.ListEntries(auditService))
+	addHandler(mux, "GET", "/api/v1/notes/{id}/audit", auditapiv1.ListNoteEntries(auditService))
+
+	archivesService := do.MustInvokeAs[archivesapiv1.Service](injector)
+
+	addHandler(mux, "POST", "/api/v1/exports", archivesapiv1.PostExport(archivesService))
+	addHandler(mux, "GET", "/api/v1/exports/{id}", archivesapiv1.GetExport(archivesService))
+	addHandler(mux, "GET", "/api/v1/exports/{id}/archive", archivesapiv1.GetExportArchive(archivesService))
+	addHandler(mux, "POST", "/api/v1/import", archivesapiv1.PostImport(archivesService))
+
+	importsService := do.MustInvokeAs[importsapiv1.Service](injector)
+
+	addHandler(mux, "POST", "/api/v1/imports", importsapiv1.PostImport(importsService))
+	addHandler(mux, "GET", "/api/v1/imports/{id}", importsapiv1.GetImport(importsService))
+	addHandler(mux, "GET", "/api/v1/imports/{id}/failures", importsapiv1.ListFailures(importsService))
+
+	jobsService := do.MustInvokeAs[jobsapiv1.Service](injector)
+
+	addHandler(mux, "GET", "/api/v1/jobs/{id}", jobsapiv1.GetJob(jobsService))
+	addHandler(mux, "POST", "/api/v1/jobs/{id}/cancel", jobsapiv1.PostCancelJob(jobsService))
+
 	usersService := do.MustInvokeAs[usersapiv1.Service](injector)
 
 	addHandler(mux, "POST", "/api/v1/users", usersapiv1.PostUser(usersService))
 	addHandler(mux, "PUT", "/api/v1/users/{id}", usersapiv1.PutUser(usersService))
-	addHandler(mux, "POST", "/api/v1/users/{id}/session", usersapiv1.PostSession(usersService))
 	addHandler(mux, "DELETE", "/api/v1/users/{id}/session", usersapiv1.DeleteSession(usersService))
 
+	authService := do.MustInvokeAs[authapiv1.Service](injector)
+
+	addHandler(mux, "POST", "/api/v1/users/{id}/session", authapiv1.PostSession(authService))
+	addHandler(mux, "POST", "/api/v1/users/{id}/tokens", authapiv1.PostToken(authService))
+
 	mw := util.ChainReverse1(
 		otelhttp.NewMiddleware("server",
 			otelhttp.WithFilter(func(r *http.Request) bool {
@@ -77,21 +198,18 @@ func setupServer(injector do.Injector) (*http.Server, error) {
 			otelhttp.WithPublicEndpoint(),
 			otelhttp.WithServerName("notes"),
 		),
+		requestIDMiddleware(),
 		loggingMiddleware(),
 		recoveryMiddleware(),
-		// TODO: auth middleware
-		func(next http.Handler) http.Handler {
-			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				ctx := scope.WithUserID(r.Context(), uuid.Max)
-
-				next.ServeHTTP(w, r.WithContext(ctx))
-			})
-		},
+		authMiddleware(do.MustInvoke[*auth.Service](injector)),
 	)
 
 	cfg := do.MustInvoke[*config.Config](injector)
 	logger := do.MustInvoke[*zap.Logger](injector)
 
+	// long-lived requests, like event streams and collaboration sessions, would otherwise hold up shutting down
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+
 	srv := &http.Server{
 		Addr:                         cfg.HTTP.Addr,
 		Handler:                      mw(mux),
@@ -105,10 +223,12 @@ func setupServer(injector do.Injector) (*http.Server, error) {
 		TLSNextProto:                 nil,
 		ConnState:                    nil,
 		ErrorLog:                     util.Must(zap.NewStdLogAt(logger.Named("server"), zapcore.ErrorLevel)),
-		BaseContext:                  nil,
+		BaseContext:                  func(net.Listener) context.Context { return baseCtx },
 		ConnContext:                  nil,
 	}
 
+	srv.RegisterOnShutdown(cancelBase)
+
 	if cfg.HTTP.LogConnections {
 		srv.ConnState = func(c net.Conn, cs http.ConnState) {
 			// TODO
@@ -171,6 +291,7 @@ func loggingMiddleware() middleware {
 			metrics := httpsnoop.CaptureMetrics(next, w, r)
 
 			log.Info(r.Context(), "request/response",
+				zap.Stringer("request_id", scope.RequestID(r.Context())),
 				zap.String("method", r.Method),
 				zap.String("path", r.URL.Path),
 				zap.Int64("content_length", r.ContentLength),
@@ -186,6 +307,51 @@ func loggingMiddleware() middleware {
 	}
 }
 
+// requestIDMiddleware identifies each request, reusing the caller's X-Request-ID if it is a UUID, and echoes it in the
+// response.
+func requestIDMiddleware() middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID, err := uuid.Parse(r.Header.Get("X-Request-ID"))
+			if err != nil {
+				requestID = uuid.New()
+			}
+
+			w.Header().Set("X-Request-ID", requestID.String())
+
+			ctx := scope.WithRequestID(r.Context(), requestID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authMiddleware authenticates requests made with an API token, sent as a bearer token in the Authorization header.
+func authMiddleware(svc *auth.Service) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// TODO: authenticate requests without a token
+			userID := uuid.Max
+
+			if header := r.Header.Get("Authorization"); header != "" {
+				secret, ok := strings.CutPrefix(header, "Bearer ")
+				if !ok {
+					apiv1.WriteError(r.Context(), w, apiv1.NewError(http.StatusUnauthorized, "unsupported authorization scheme"))
+					return
+				}
+
+				var err error
+				if userID, err = svc.Authenticate(r.Context(), secret); err != nil {
+					apiv1.WriteError(r.Context(), w, err)
+					return
+				}
+			}
+
+			ctx := scope.WithUserID(r.Context(), userID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 func recoveryMiddleware() middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
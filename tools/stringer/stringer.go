@@ -158,9 +158,7 @@ func main() {
 	g.Printf("package %s", g.pkg.name)
 	g.Printf("\n")
 	g.Printf("import \"errors\"\n")
-	g.Printf("import \"slices\"\n")
 	g.Printf("import \"strconv\"\n") // Used by all methods.
-	g.Printf("import \"strings\"\n")
 
 	// Run generate for each type.
 	for _, typeName := range types {
@@ -635,18 +633,14 @@ const stringOneRunWithOffset = `func (i %[1]s) String() string {
 //	[1]: type name
 //	[2]: size of index element (8 for uint8 etc.)
 const parseOneRun = `func Parse%[1]s(s string) (%[1]s, error) {
-    idx := strings.Index(_%[1]s_name, s)
-    if idx == -1 {
-        var zero %[1]s
-        return zero, errors.New("invalid value")
+    for i := 0; i < len(_%[1]s_index)-1; i++ {
+        if _%[1]s_name[_%[1]s_index[i]:_%[1]s_index[i+1]] == s {
+            return %[1]s(i), nil
+        }
     }
 
-    value, ok := slices.BinarySearch(_%[1]s_index[:], uint%[2]d(idx))
-    if !ok {
-        panic("could not find index of string - this should not be possible")
-    }
-
-    return %[1]s(value), nil
+    var zero %[1]s
+    return zero, errors.New("invalid value")
 }
 `
 
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// worker is a background task that runs until its context is cancelled.
+type worker interface {
+	Run(ctx context.Context) error
+}
+
+// startWorkers runs each of workers in its own goroutine, until ctx is cancelled.
+// The returned function waits for them all to stop, or for its context to be cancelled.
+func startWorkers(ctx context.Context, logger *zap.Logger, workers ...worker) (wait func(context.Context) error) {
+	var wg sync.WaitGroup
+
+	for _, w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := w.Run(ctx); err != nil {
+				logger.Error("error running worker", zap.String("worker", fmt.Sprintf("%T", w)), zap.Error(err))
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	return func(ctx context.Context) error {
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}